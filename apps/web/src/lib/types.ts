@@ -2,7 +2,7 @@
 
 export type PropertyType = 'apartment' | 'house' | 'guesthouse' | 'room';
 export type CancellationPolicy = 'flexible' | 'moderate' | 'strict';
-export type ListingStatus = 'draft' | 'active' | 'paused';
+export type ListingStatus = 'draft' | 'active' | 'paused' | 'archived';
 export type AvailabilityStatus = 'available' | 'blocked' | 'booked';
 
 export interface HouseRules {
@@ -21,7 +21,10 @@ import (
 
 type contextKey int
 
-const principalKey contextKey = iota
+const (
+	principalKey contextKey = iota
+	serviceKey
+)
 
 // Principal holds the authenticated identity extracted from gateway-injected headers.
 type Principal struct {
@@ -29,6 +32,9 @@ type Principal struct {
 	TenantID string
 	Email    string
 	Scopes   []string
+	// Service is set for service-to-service calls; UserID is then empty and
+	// TenantID is the tenant the calling service acts for.
+	Service bool
 }
 
 // HasScope reports whether the principal holds the given scope.
@@ -72,6 +78,26 @@ func FromContext(ctx context.Context) *Principal {
 	return p
 }
 
+// RequestPrincipal is the one place handlers should get their caller from.
+// A request that passed service auth (RequireInternalToken or
+// RequireServiceAuth) is trusted to name its tenant in X-Tenant-ID and gets a
+// Service principal for it. Any other request gets the principal Middleware
+// stored, or nil: an X-Tenant-ID header alone never establishes a tenant.
+func RequestPrincipal(r *http.Request) *Principal {
+	if service, _ := r.Context().Value(serviceKey).(bool); service {
+		return &Principal{
+			TenantID: strings.TrimSpace(r.Header.Get("X-Tenant-ID")),
+			Service:  true,
+		}
+	}
+	return FromContext(r.Context())
+}
+
+// asService marks r as authenticated by a service credential.
+func asService(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), serviceKey, true))
+}
+
 // RequireAuth is a middleware that returns 401 Unauthorized if no authenticated
 // principal is present in the context.
 func RequireAuth(next http.Handler) http.Handler {
@@ -99,7 +125,7 @@ func RequireInternalToken(token string) func(http.Handler) http.Handler {
 				w.Write([]byte(`{"error":"forbidden","code":"INVALID_INTERNAL_TOKEN"}`)) //nolint:errcheck
 				return
 			}
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(w, asService(r))
 		})
 	}
 }
@@ -139,3 +139,64 @@ func TestRequireScope_NoAuth(t *testing.T) {
 		t.Fatalf("expected 401, got %d", rr.Code)
 	}
 }
+
+func TestRequestPrincipal_InternalTokenSetsTenant(t *testing.T) {
+	var got *Principal
+	handler := RequireInternalToken("secret-token-123")(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = RequestPrincipal(r)
+		}),
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("X-Internal-Token", "secret-token-123")
+	req.Header.Set("X-Tenant-ID", " tenant-1 ")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got == nil || !got.Service || got.TenantID != "tenant-1" || got.UserID != "" {
+		t.Fatalf("expected service principal for tenant-1, got %+v", got)
+	}
+}
+
+func TestRequestPrincipal_ServiceAuthSetsTenant(t *testing.T) {
+	var got *Principal
+	handler := RequireServiceAuth("legacy", func(string) bool { return true })(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = RequestPrincipal(r)
+		}),
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Authorization", "Bearer service-jwt")
+	req.Header.Set("X-Tenant-ID", "tenant-2")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got == nil || !got.Service || got.TenantID != "tenant-2" {
+		t.Fatalf("expected service principal for tenant-2, got %+v", got)
+	}
+}
+
+func TestRequestPrincipal_UnauthenticatedHasNoTenant(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("X-Tenant-ID", "tenant-1")
+
+	if p := RequestPrincipal(req); p != nil {
+		t.Fatalf("expected nil principal for a bare X-Tenant-ID header, got %+v", p)
+	}
+}
+
+func TestRequestPrincipal_UserFromMiddleware(t *testing.T) {
+	var got *Principal
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = RequestPrincipal(r)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	req.Header.Set("X-Tenant-ID", "tenant-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got == nil || got.Service || got.UserID != "user-1" || got.TenantID != "tenant-1" {
+		t.Fatalf("expected user principal, got %+v", got)
+	}
+}
@@ -93,7 +93,7 @@ func RequireServiceAuth(legacyToken string, jwksValidator func(token string) boo
 			if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
 				jwt := strings.TrimPrefix(auth, "Bearer ")
 				if jwksValidator != nil && jwksValidator(jwt) {
-					next.ServeHTTP(w, r)
+					next.ServeHTTP(w, asService(r))
 					return
 				}
 			}
@@ -101,7 +101,7 @@ func RequireServiceAuth(legacyToken string, jwksValidator func(token string) boo
 			// Fallback: legacy X-Internal-Token
 			got := r.Header.Get("X-Internal-Token")
 			if legacyToken != "" && got != "" && got == legacyToken {
-				next.ServeHTTP(w, r)
+				next.ServeHTTP(w, asService(r))
 				return
 			}
 
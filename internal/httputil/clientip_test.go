@@ -0,0 +1,45 @@
+package httputil
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8, 192.168.1.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name, remote, xff, want string
+	}{
+		{"no header", "203.0.113.7:5000", "", "203.0.113.7"},
+		{"untrusted peer ignores header", "203.0.113.7:5000", "198.51.100.1", "203.0.113.7"},
+		{"trusted peer", "10.0.0.2:5000", "198.51.100.1", "198.51.100.1"},
+		{"spoofed leading hop", "10.0.0.2:5000", "1.2.3.4, 198.51.100.1", "198.51.100.1"},
+		{"chain of proxies", "10.0.0.2:5000", "198.51.100.1, 192.168.1.5, 10.0.0.3", "198.51.100.1"},
+		{"only proxies", "10.0.0.2:5000", "10.0.0.3", "10.0.0.3"},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = c.remote
+		if c.xff != "" {
+			r.Header.Set("X-Forwarded-For", c.xff)
+		}
+		if got := proxies.ClientIP(r); got != c.want {
+			t.Errorf("%s: ClientIP = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	if _, err := ParseTrustedProxies(DefaultTrustedProxies); err != nil {
+		t.Errorf("default list: %v", err)
+	}
+	if p, err := ParseTrustedProxies(""); err != nil || len(p) != 0 {
+		t.Errorf("empty list = %v, %v; want none", p, err)
+	}
+	if _, err := ParseTrustedProxies("10.0.0.0/8,not-an-ip"); err == nil {
+		t.Error("invalid entry: want an error")
+	}
+}
@@ -0,0 +1,5 @@
+module github.com/saidmashhud/zist/internal/money
+
+go 1.22
+
+require github.com/shopspring/decimal v1.4.0
@@ -0,0 +1,144 @@
+// Package money rounds and formats amounts in a currency's minor units. The
+// listings price quote, booking creation and refunds all round through it, so
+// the same stay is priced the same everywhere.
+package money
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Rounding modes.
+const (
+	RoundHalfUp   = "half_up"   // half away from zero: 0.125 → 0.13
+	RoundHalfEven = "half_even" // banker's rounding: 0.125 → 0.12
+)
+
+// DefaultMinorUnits is the number of decimal places for currencies absent
+// from Rounding.MinorUnits.
+const DefaultMinorUnits = 2
+
+// Rounding controls how amounts are rounded: the mode, and the decimal places
+// (minor units) per currency code. The zero value rounds half up to two
+// places for every currency.
+type Rounding struct {
+	Mode       string
+	MinorUnits map[string]int
+}
+
+// NewRounding builds a Rounding from its settings: a mode (empty means
+// RoundHalfUp) and comma-separated CODE:places pairs, e.g. "UZS:0,KWD:3".
+// An unknown mode or a malformed pair is an error.
+func NewRounding(mode, minorUnits string) (Rounding, error) {
+	switch mode {
+	case "", RoundHalfUp, RoundHalfEven:
+	default:
+		return Rounding{}, fmt.Errorf("unknown rounding mode %q; use %s or %s", mode, RoundHalfUp, RoundHalfEven)
+	}
+	units := map[string]int{}
+	for _, part := range strings.Split(minorUnits, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		code, places, ok := strings.Cut(part, ":")
+		n, err := strconv.Atoi(strings.TrimSpace(places))
+		if !ok || err != nil || n < 0 || strings.TrimSpace(code) == "" {
+			return Rounding{}, fmt.Errorf("invalid minor units %q; want CODE:places", part)
+		}
+		units[strings.ToUpper(strings.TrimSpace(code))] = n
+	}
+	return Rounding{Mode: mode, MinorUnits: units}, nil
+}
+
+// Places returns currency's minor units.
+func (r Rounding) Places(currency string) int32 {
+	if n, ok := r.MinorUnits[strings.ToUpper(currency)]; ok && n >= 0 {
+		return int32(n)
+	}
+	return DefaultMinorUnits
+}
+
+// Round rounds d to currency's minor units using r's mode.
+func (r Rounding) Round(d decimal.Decimal, currency string) decimal.Decimal {
+	if r.Mode == RoundHalfEven {
+		return d.RoundBank(r.Places(currency))
+	}
+	return d.Round(r.Places(currency))
+}
+
+// Format rounds d and formats it with exactly currency's minor units.
+func (r Rounding) Format(d decimal.Decimal, currency string) string {
+	return r.Round(d, currency).StringFixed(r.Places(currency))
+}
+
+// Pricing is a stay's amounts, formatted in the currency's minor units.
+type Pricing struct {
+	Subtotal    string
+	CleaningFee string
+	Deposit     string
+	PlatformFee string
+	Total       string
+}
+
+// Price computes a stay's platform fee and total in exact decimal arithmetic.
+// The fee is feePct percent of subtotal plus cleaning; every amount is
+// rounded to currency's minor units with r's mode. The refundable deposit is
+// excluded from the fee base but included in the total. Empty amounts count
+// as zero.
+func (r Rounding) Price(subtotal, cleaning, deposit, currency string, feePct float64) (Pricing, error) {
+	sub, err := Parse(subtotal)
+	if err != nil {
+		return Pricing{}, err
+	}
+	clean, err := Parse(cleaning)
+	if err != nil {
+		return Pricing{}, err
+	}
+	dep, err := Parse(deposit)
+	if err != nil {
+		return Pricing{}, err
+	}
+	sub, clean, dep = r.Round(sub, currency), r.Round(clean, currency), r.Round(dep, currency)
+	base := sub.Add(clean)
+	fee := r.Round(base.Mul(decimal.NewFromFloat(feePct)).Div(decimal.NewFromInt(100)), currency)
+	return Pricing{
+		Subtotal:    r.Format(sub, currency),
+		CleaningFee: r.Format(clean, currency),
+		Deposit:     r.Format(dep, currency),
+		PlatformFee: r.Format(fee, currency),
+		Total:       r.Format(base.Add(fee).Add(dep), currency),
+	}, nil
+}
+
+// Refund splits a refund of pct percent of total: the deposit is carved out
+// first and returned in full, and pct applies to the rest. Both amounts are
+// formatted in currency's minor units.
+func (r Rounding) Refund(total, deposit, currency string, pct int) (refund, depositRefund string, err error) {
+	t, err := Parse(total)
+	if err != nil {
+		return "", "", err
+	}
+	dep, err := Parse(deposit)
+	if err != nil {
+		return "", "", err
+	}
+	stay := t.Sub(dep).Mul(decimal.NewFromInt(int64(pct))).Div(decimal.NewFromInt(100))
+	return r.Format(r.Round(stay, currency).Add(dep), currency), r.Format(dep, currency), nil
+}
+
+// Parse parses a decimal amount; empty means zero.
+func Parse(s string) (decimal.Decimal, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return decimal.Zero, nil
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("invalid amount %q", s)
+	}
+	return d, nil
+}
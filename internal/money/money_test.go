@@ -0,0 +1,47 @@
+package money
+
+import "testing"
+
+func TestNewRounding(t *testing.T) {
+	r, err := NewRounding("half_even", "uzs:0, KWD:3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Mode != RoundHalfEven || r.Places("UZS") != 0 || r.Places("kwd") != 3 || r.Places("USD") != DefaultMinorUnits {
+		t.Errorf("NewRounding = %+v", r)
+	}
+	if r, err := NewRounding("", ""); err != nil || r.Places("UZS") != DefaultMinorUnits {
+		t.Errorf("empty settings: %+v, %v", r, err)
+	}
+	for _, tc := range [][2]string{{"half_down", ""}, {"half_up", "UZS"}, {"half_up", "UZS:-1"}, {"half_up", ":2"}} {
+		if _, err := NewRounding(tc[0], tc[1]); err == nil {
+			t.Errorf("NewRounding(%q, %q) accepted", tc[0], tc[1])
+		}
+	}
+}
+
+func TestRefund(t *testing.T) {
+	// 50% of the 1000.25 stay is 500.125; the 150.00 deposit comes back whole.
+	tests := []struct {
+		r            Rounding
+		currency     string
+		refund, dep  string
+		total, depIn string
+	}{
+		{Rounding{}, "USD", "650.13", "150.00", "1150.25", "150"},
+		{Rounding{Mode: RoundHalfEven}, "USD", "650.12", "150.00", "1150.25", "150"},
+		{Rounding{MinorUnits: map[string]int{"UZS": 0}}, "UZS", "650", "150", "1150.25", "150"},
+	}
+	for _, tt := range tests {
+		refund, dep, err := tt.r.Refund(tt.total, tt.depIn, tt.currency, 50)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if refund != tt.refund || dep != tt.dep {
+			t.Errorf("%+v %s: refund %s/%s, want %s/%s", tt.r, tt.currency, refund, dep, tt.refund, tt.dep)
+		}
+	}
+	if _, _, err := (Rounding{}).Refund("abc", "", "USD", 50); err == nil {
+		t.Error("want error for a non-numeric total")
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/saidmashhud/zist/internal/ratelimit
+
+go 1.22
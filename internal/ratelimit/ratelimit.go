@@ -0,0 +1,49 @@
+// Package ratelimit provides the in-memory request limiter shared by Zist
+// services.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter is a fixed-window counter per key, kept in memory. It is
+// per-process, which is enough to stop a single client hammering an
+// endpoint.
+//
+// Windows are aligned buckets shared by every key, so moving to the next
+// window drops the previous bucket's counts in one step rather than sweeping
+// the keys one by one.
+type Limiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	bucket int64          // index of the current window since the epoch
+	counts map[string]int // hits per key in the current window
+	now    func() time.Time
+}
+
+// New returns a Limiter allowing limit hits per key per window. A
+// non-positive limit disables limiting.
+func New(limit int, window time.Duration) *Limiter {
+	return &Limiter{limit: limit, window: window, counts: make(map[string]int), now: time.Now}
+}
+
+// Allow records a hit for key and reports whether it is within the limit.
+func (l *Limiter) Allow(key string) bool {
+	if l.limit <= 0 || l.window <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if b := l.now().UnixNano() / int64(l.window); b != l.bucket {
+		l.bucket = b
+		l.counts = make(map[string]int)
+	}
+	if l.counts[key] >= l.limit {
+		return false
+	}
+	l.counts[key]++
+	return true
+}
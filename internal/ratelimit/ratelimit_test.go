@@ -0,0 +1,39 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	l := New(2, time.Minute)
+	l.now = func() time.Time { return now }
+
+	if !l.Allow("a") || !l.Allow("a") {
+		t.Fatal("first two hits: want allowed")
+	}
+	if l.Allow("a") {
+		t.Error("third hit in the window: want denied")
+	}
+	if !l.Allow("b") {
+		t.Error("another key: want allowed")
+	}
+
+	now = now.Add(time.Minute)
+	if !l.Allow("a") {
+		t.Error("next window: want allowed again")
+	}
+	if len(l.counts) != 1 {
+		t.Errorf("next window kept %d keys, want only the one hit since", len(l.counts))
+	}
+}
+
+func TestLimiterDisabled(t *testing.T) {
+	l := New(0, time.Minute)
+	for i := 0; i < 100; i++ {
+		if !l.Allow("a") {
+			t.Fatal("a non-positive limit must never deny")
+		}
+	}
+}
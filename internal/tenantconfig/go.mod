@@ -0,0 +1,3 @@
+module github.com/saidmashhud/zist/internal/tenantconfig
+
+go 1.22
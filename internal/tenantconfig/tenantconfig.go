@@ -0,0 +1,224 @@
+// Package tenantconfig reads per-tenant settings from the admin service and
+// holds the defaults and limits admin and the services reading them share.
+package tenantconfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TTL is how long a tenant's config is cached; changes made in admin take
+// effect within this window.
+const TTL = time.Minute
+
+// DefaultMinReviewLength applies to tenants that haven't set minReviewLength.
+const DefaultMinReviewLength = 10
+
+// MaxSearchLimit is the largest search page size. It is also the default for
+// tenants that haven't set maxSearchLimit, which can only lower it.
+const MaxSearchLimit = 100
+
+// MaxListingPhotos is the most photos a listing may have, and so the highest
+// minPhotosToBook a tenant can set.
+const MaxListingPhotos = 20
+
+// DefaultMinPhotosToBook applies to tenants that haven't set minPhotosToBook;
+// it matches the single photo publishing requires.
+const DefaultMinPhotosToBook = 1
+
+// RequirableListingFields are the listing fields, by their JSON names in the
+// listings API, a tenant may make mandatory.
+var RequirableListingFields = []string{
+	"title", "description", "city", "country", "address", "pricePerNight", "timezone",
+}
+
+// DefaultRequiredListingFields returns the listing fields every tenant
+// requires, and the whole list for tenants that haven't configured
+// requiredListingFields.
+func DefaultRequiredListingFields() []string {
+	return []string{"title", "city", "pricePerNight"}
+}
+
+// DefaultListingTypes returns the listing types allowed for tenants that
+// haven't configured allowedListingTypes.
+func DefaultListingTypes() []string {
+	return []string{"apartment", "house", "guesthouse", "room"}
+}
+
+// SearchSorts are the sort_by values the search service accepts besides its
+// default ranking.
+var SearchSorts = []string{"rating", "price", "distance"}
+
+// ValidSort reports whether s is an accepted sort_by; empty means the
+// default ranking.
+func ValidSort(s string) bool {
+	return s == "" || slices.Contains(SearchSorts, s)
+}
+
+// RefundTier refunds RefundPct percent of the stay to cancellations made at
+// least HoursBefore hours before check-in.
+type RefundTier struct {
+	HoursBefore int `json:"hoursBefore"`
+	RefundPct   int `json:"refundPct"`
+}
+
+// CancellationPolicy is a named set of refund tiers. A guest cancelling gets
+// the best refund among the tiers they are early enough for, or nothing.
+type CancellationPolicy struct {
+	Name  string       `json:"name"`
+	Tiers []RefundTier `json:"tiers"`
+}
+
+// builtinPolicies are every tenant's cancellation policies, whatever its
+// catalog adds.
+var builtinPolicies = []CancellationPolicy{
+	{Name: "flexible", Tiers: []RefundTier{{HoursBefore: 24, RefundPct: 100}}},
+	{Name: "moderate", Tiers: []RefundTier{{HoursBefore: 5 * 24, RefundPct: 100}, {HoursBefore: 24, RefundPct: 50}}},
+	{Name: "strict", Tiers: []RefundTier{{HoursBefore: 14 * 24, RefundPct: 50}}},
+}
+
+// BuiltinPolicyNames returns the names of the built-in cancellation policies.
+func BuiltinPolicyNames() []string {
+	names := make([]string, 0, len(builtinPolicies))
+	for _, p := range builtinPolicies {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// BuiltinPolicy returns the built-in cancellation policy called name.
+func BuiltinPolicy(name string) (CancellationPolicy, bool) {
+	for _, p := range builtinPolicies {
+		if p.Name == name {
+			return CancellationPolicy{Name: p.Name, Tiers: slices.Clone(p.Tiers)}, true
+		}
+	}
+	return CancellationPolicy{}, false
+}
+
+// Config is a tenant's settings as the admin service serves them to other
+// services. Fields the admin response omits keep their defaults.
+type Config struct {
+	// Bookings whose total falls outside these decimal bounds are rejected;
+	// nil means unbounded.
+	MinBookingAmount *string `json:"minBookingAmount"`
+	MaxBookingAmount *string `json:"maxBookingAmount"`
+	// MaxPendingBookingsPerGuest caps a guest's bookings awaiting approval
+	// or payment; nil means unlimited.
+	MaxPendingBookingsPerGuest *int `json:"maxPendingBookingsPerGuest"`
+	// SupportedCurrencies lists the ISO 4217 codes listings and bookings may
+	// use; empty allows any.
+	SupportedCurrencies []string `json:"supportedCurrencies"`
+	// PublicReviews lets anonymous callers read listing reviews.
+	PublicReviews bool `json:"publicReviews"`
+	// PaymentGraceMinutes is how late a captured payment may still confirm.
+	PaymentGraceMinutes int `json:"paymentGraceMinutes"`
+	// DefaultSort is the sort_by used when a search names none.
+	DefaultSort string `json:"defaultSort"`
+	// MinReviewLength is the fewest characters a review comment may have.
+	MinReviewLength int `json:"minReviewLength"`
+	// RequiredListingFields are required on top of
+	// DefaultRequiredListingFields.
+	RequiredListingFields []string `json:"requiredListingFields"`
+	// MaxSearchLimit caps a search's page size; 0 leaves MaxSearchLimit.
+	MaxSearchLimit int `json:"maxSearchLimit"`
+	// MinPhotosToBook is the fewest photos a listing needs to be bookable.
+	MinPhotosToBook int `json:"minPhotosToBook"`
+	// CancellationPolicies is the tenant's catalog of custom policies.
+	CancellationPolicies []CancellationPolicy `json:"cancellationPolicies"`
+	// AllowedListingTypes are the types listings may have; empty means
+	// DefaultListingTypes.
+	AllowedListingTypes []string `json:"allowedListingTypes"`
+}
+
+// defaults is the Config of a tenant whose admin response sets nothing.
+func defaults() Config {
+	return Config{
+		PublicReviews:   true,
+		MinReviewLength: DefaultMinReviewLength,
+		MinPhotosToBook: DefaultMinPhotosToBook,
+	}
+}
+
+// PolicyNames returns the names of the tenant's custom cancellation policies.
+func (c Config) PolicyNames() []string {
+	names := make([]string, 0, len(c.CancellationPolicies))
+	for _, p := range c.CancellationPolicies {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// ListingTypes returns the types the tenant allows listings to have.
+func (c Config) ListingTypes() []string {
+	if len(c.AllowedListingTypes) == 0 {
+		return DefaultListingTypes()
+	}
+	return c.AllowedListingTypes
+}
+
+// Client reads tenant configs from the admin service, caching each for TTL.
+type Client struct {
+	baseURL       string
+	internalToken string
+	http          *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cachedConfig
+}
+
+type cachedConfig struct {
+	cfg     Config
+	expires time.Time
+}
+
+// New returns a Client for the admin service at baseURL.
+func New(baseURL, internalToken string) *Client {
+	return &Client{
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		internalToken: internalToken,
+		http:          &http.Client{Timeout: 3 * time.Second},
+		cache:         make(map[string]cachedConfig),
+	}
+}
+
+// Get returns the tenant's config. Errors are not cached.
+func (c *Client) Get(ctx context.Context, tenantID string) (Config, error) {
+	now := time.Now()
+	c.mu.Lock()
+	if e, ok := c.cache[tenantID]; ok && now.Before(e.expires) {
+		c.mu.Unlock()
+		return e.cfg, nil
+	}
+	c.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.baseURL+"/admin/internal/tenants/"+tenantID, nil)
+	if err != nil {
+		return Config{}, err
+	}
+	req.Header.Set("X-Internal-Token", c.internalToken)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return Config{}, fmt.Errorf("admin service unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Config{}, fmt.Errorf("admin service returned %d", resp.StatusCode)
+	}
+	cfg := defaults()
+	if err := json.NewDecoder(resp.Body).Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("decode tenant config: %w", err)
+	}
+
+	c.mu.Lock()
+	c.cache[tenantID] = cachedConfig{cfg: cfg, expires: now.Add(TTL)}
+	c.mu.Unlock()
+	return cfg, nil
+}
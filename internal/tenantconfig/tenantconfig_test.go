@@ -0,0 +1,76 @@
+package tenantconfig
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientGet(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/admin/internal/tenants/t1" || r.Header.Get("X-Internal-Token") != "tok" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"defaultSort":"price","cancellationPolicies":[{"name":"lenient","tiers":[{"hoursBefore":2,"refundPct":100}]}]}`)) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL+"/", "tok")
+	cfg, err := c.Get(context.Background(), "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DefaultSort != "price" {
+		t.Errorf("DefaultSort = %q, want price", cfg.DefaultSort)
+	}
+	if !cfg.PublicReviews || cfg.MinReviewLength != DefaultMinReviewLength || cfg.MinPhotosToBook != DefaultMinPhotosToBook {
+		t.Errorf("omitted fields = %+v, want the defaults", cfg)
+	}
+	if types := cfg.ListingTypes(); len(types) != len(DefaultListingTypes()) {
+		t.Errorf("ListingTypes = %v, want the defaults when none are set", types)
+	}
+	if names := cfg.PolicyNames(); len(names) != 1 || names[0] != "lenient" {
+		t.Errorf("PolicyNames = %v, want [lenient]", names)
+	}
+
+	if _, err := c.Get(context.Background(), "t1"); err != nil || calls != 1 {
+		t.Errorf("second Get: err %v after %d calls, want a cache hit", err, calls)
+	}
+	if _, err := c.Get(context.Background(), "t2"); err == nil {
+		t.Error("non-200 from admin: want an error")
+	}
+}
+
+func TestValidSort(t *testing.T) {
+	for _, s := range []string{"", "rating", "price", "distance"} {
+		if !ValidSort(s) {
+			t.Errorf("ValidSort(%q) = false, want true", s)
+		}
+	}
+	for _, s := range []string{"newest", "Price", " rating"} {
+		if ValidSort(s) {
+			t.Errorf("ValidSort(%q) = true, want false", s)
+		}
+	}
+}
+
+func TestBuiltinPolicy(t *testing.T) {
+	for _, name := range BuiltinPolicyNames() {
+		p, ok := BuiltinPolicy(name)
+		if !ok || p.Name != name || len(p.Tiers) == 0 {
+			t.Errorf("BuiltinPolicy(%q) = %+v, %v; want its tiers", name, p, ok)
+		}
+	}
+	if _, ok := BuiltinPolicy("lenient"); ok {
+		t.Error("a custom name resolved as a built-in")
+	}
+	p, _ := BuiltinPolicy("flexible")
+	p.Tiers[0].RefundPct = 0
+	if again, _ := BuiltinPolicy("flexible"); again.Tiers[0].RefundPct != 100 {
+		t.Error("changing a returned policy changed the built-in")
+	}
+}
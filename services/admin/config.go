@@ -7,6 +7,7 @@ type Config struct {
 	Port          string
 	DatabaseURL   string
 	InternalToken string
+	ListingsURL   string // listings service base URL for policy usage checks (optional)
 }
 
 // LoadConfig reads configuration from environment variables.
@@ -15,5 +16,6 @@ func LoadConfig() *Config {
 		Port:          httputil.Getenv("ADMIN_PORT", "8005"),
 		DatabaseURL:   httputil.Getenv("DATABASE_URL", "postgres://dev:dev@db:5432/zist?sslmode=disable"),
 		InternalToken: httputil.Getenv("INTERNAL_TOKEN", ""),
+		ListingsURL:   httputil.Getenv("LISTINGS_URL", ""),
 	}
 }
@@ -8,6 +8,7 @@ require (
 	github.com/lib/pq v1.10.9
 	github.com/saidmashhud/zist/internal/auth v0.0.0
 	github.com/saidmashhud/zist/internal/httputil v0.0.0
+	github.com/saidmashhud/zist/internal/tenantconfig v0.0.0
 	go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp v0.65.0
 	go.opentelemetry.io/otel v1.40.0
 	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc v1.40.0
@@ -38,3 +39,5 @@ require (
 replace github.com/saidmashhud/zist/internal/auth => ../../internal/auth
 
 replace github.com/saidmashhud/zist/internal/httputil => ../../internal/httputil
+
+replace github.com/saidmashhud/zist/internal/tenantconfig => ../../internal/tenantconfig
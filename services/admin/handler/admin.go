@@ -1,13 +1,21 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	zistauth "github.com/saidmashhud/zist/internal/auth"
 	"github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/internal/tenantconfig"
 	"github.com/saidmashhud/zist/services/admin/store"
 )
 
@@ -20,12 +28,19 @@ func (h *Handler) ListFlags(w http.ResponseWriter, r *http.Request) {
 		httputil.WriteError(w, http.StatusForbidden, "admin scope required")
 		return
 	}
-	flags, err := h.Store.ListFlags(r.Context())
+	// Without a limit every flag is returned, as before paging existed.
+	limit, offset := pageParams(r, 0)
+	flags, total, err := h.Store.ListFlags(r.Context(), limit, offset)
 	if err != nil {
 		httputil.WriteError(w, http.StatusInternalServerError, "db error")
 		return
 	}
-	httputil.WriteJSON(w, http.StatusOK, map[string]any{"flags": flags})
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{
+		"flags":  flags,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
 }
 
 // UpsertFlag handles POST /admin/flags.
@@ -42,12 +57,13 @@ func (h *Handler) UpsertFlag(w http.ResponseWriter, r *http.Request) {
 		Rollout  int     `json:"rollout"`
 		TenantID *string `json:"tenantId"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
-		httputil.WriteError(w, http.StatusBadRequest, "name is required")
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
-	if req.Rollout < 0 || req.Rollout > 100 {
-		req.Rollout = 100
+	if msg := checkFlag(req.Name, req.Rollout); msg != "" {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, msg)
+		return
 	}
 
 	flag, err := h.Store.UpsertFlag(r.Context(), req.Name, req.Enabled, req.Rollout, req.TenantID)
@@ -62,8 +78,53 @@ func (h *Handler) UpsertFlag(w http.ResponseWriter, r *http.Request) {
 	httputil.WriteJSON(w, http.StatusOK, flag)
 }
 
+// flagNamePattern keeps flag names predictable for code that checks them.
+var flagNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// checkFlag validates a flag's name and rollout percentage, returning a
+// message for the caller or "" if they are usable.
+func checkFlag(name string, rollout int) string {
+	if !flagNamePattern.MatchString(name) {
+		return "name must be non-empty and contain only a-z, 0-9 and _"
+	}
+	if rollout < 0 || rollout > 100 {
+		return "rollout must be between 0 and 100"
+	}
+	return ""
+}
+
 // ─── Audit Log ────────────────────────────────────────────────────────────────
 
+// RecordAudit handles POST /admin/internal/audit. Other services use it to
+// log privileged actions (e.g. an admin reading someone else's booking) in
+// the same audit trail as admin-service actions.
+func (h *Handler) RecordAudit(w http.ResponseWriter, r *http.Request) {
+	tenantID := strings.TrimSpace(r.Header.Get("X-Tenant-ID"))
+	if tenantID == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "tenant_id is required")
+		return
+	}
+	var req struct {
+		ActorID  string `json:"actorId"`
+		Action   string `json:"action"`
+		Resource string `json:"resource"`
+		Detail   string `json:"detail"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.ActorID == "" || req.Action == "" || req.Resource == "" {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, "actorId, action, and resource are required")
+		return
+	}
+	if err := h.Store.AddAudit(r.Context(), req.ActorID, req.Action, req.Resource, req.Detail, tenantID); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // ListAudit handles GET /admin/audit.
 func (h *Handler) ListAudit(w http.ResponseWriter, r *http.Request) {
 	p := zistauth.FromContext(r.Context())
@@ -72,16 +133,39 @@ func (h *Handler) ListAudit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	actorFilter := r.URL.Query().Get("actor_id")
-	limit := 100
-	if n, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && n > 0 {
-		limit = n
+	limit, offset := pageParams(r, 100)
+	if limit > maxAuditPage {
+		limit = 100
 	}
-	entries, err := h.Store.ListAudit(r.Context(), actorFilter, limit)
+	entries, total, err := h.Store.ListAudit(r.Context(), actorFilter, limit, offset)
 	if err != nil {
 		httputil.WriteError(w, http.StatusInternalServerError, "db error")
 		return
 	}
-	httputil.WriteJSON(w, http.StatusOK, map[string]any{"entries": entries})
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{
+		"entries": entries,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// maxAuditPage is the largest audit page; bigger limits fall back to the
+// default, matching the store.
+const maxAuditPage = 500
+
+// pageParams reads ?limit= and ?offset=. Missing or invalid values give
+// defaultLimit and 0.
+func pageParams(r *http.Request, defaultLimit int) (limit, offset int) {
+	q := r.URL.Query()
+	limit = defaultLimit
+	if n, err := strconv.Atoi(q.Get("limit")); err == nil && n > 0 {
+		limit = n
+	}
+	if n, err := strconv.Atoi(q.Get("offset")); err == nil && n > 0 {
+		offset = n
+	}
+	return limit, offset
 }
 
 // ─── Tenant Config ────────────────────────────────────────────────────────────
@@ -102,6 +186,14 @@ func (h *Handler) GetTenantConfig(w http.ResponseWriter, r *http.Request) {
 	httputil.WriteJSON(w, http.StatusOK, cfg)
 }
 
+// maxPaymentGraceMinutes bounds the late-payment grace a tenant can grant, so
+// a lapsed hold can't block a listing's dates indefinitely.
+const maxPaymentGraceMinutes = 24 * 60
+
+// maxMinReviewLength keeps minReviewLength within what a guest can
+// reasonably be asked to write.
+const maxMinReviewLength = 1000
+
 // UpsertTenantConfig handles PUT /admin/tenants/{id}.
 func (h *Handler) UpsertTenantConfig(w http.ResponseWriter, r *http.Request) {
 	p := zistauth.FromContext(r.Context())
@@ -111,12 +203,98 @@ func (h *Handler) UpsertTenantConfig(w http.ResponseWriter, r *http.Request) {
 	}
 	tenantID := chi.URLParam(r, "id")
 
-	var req store.TenantConfig
+	// Settings that default on stay on when the request leaves them out.
+	req := store.TenantConfig{
+		PublicReviews:         true,
+		MinReviewLength:       tenantconfig.DefaultMinReviewLength,
+		RequiredListingFields: tenantconfig.DefaultRequiredListingFields(),
+		MaxSearchLimit:        tenantconfig.MaxSearchLimit,
+		MinPhotosToBook:       tenantconfig.DefaultMinPhotosToBook,
+		AllowedListingTypes:   tenantconfig.DefaultListingTypes(),
+	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 	req.TenantID = tenantID
+	if msg := checkBookingBounds(req.MinBookingAmount, req.MaxBookingAmount); msg != "" {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, msg)
+		return
+	}
+	if n := req.MaxPendingBookingsPerGuest; n != nil && *n < 1 {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, "maxPendingBookingsPerGuest must be at least 1 (omit for unlimited)")
+		return
+	}
+	if req.PaymentGraceMinutes < 0 || req.PaymentGraceMinutes > maxPaymentGraceMinutes {
+		httputil.WriteError(w, http.StatusUnprocessableEntity,
+			fmt.Sprintf("paymentGraceMinutes must be between 0 and %d", maxPaymentGraceMinutes))
+		return
+	}
+	if req.MinReviewLength < 0 || req.MinReviewLength > maxMinReviewLength {
+		httputil.WriteError(w, http.StatusUnprocessableEntity,
+			fmt.Sprintf("minReviewLength must be between 0 and %d", maxMinReviewLength))
+		return
+	}
+	if req.MaxSearchLimit < 1 || req.MaxSearchLimit > tenantconfig.MaxSearchLimit {
+		httputil.WriteError(w, http.StatusUnprocessableEntity,
+			fmt.Sprintf("maxSearchLimit must be between 1 and %d", tenantconfig.MaxSearchLimit))
+		return
+	}
+	if req.MinPhotosToBook < 0 || req.MinPhotosToBook > tenantconfig.MaxListingPhotos {
+		httputil.WriteError(w, http.StatusUnprocessableEntity,
+			fmt.Sprintf("minPhotosToBook must be between 0 and %d", tenantconfig.MaxListingPhotos))
+		return
+	}
+	if !tenantconfig.ValidSort(req.DefaultSort) {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, "defaultSort must be one of "+strings.Join(tenantconfig.SearchSorts, ", ")+" (omit for ranking)")
+		return
+	}
+	currencies, msg := normalizeCurrencies(req.SupportedCurrencies)
+	if msg != "" {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, msg)
+		return
+	}
+	req.SupportedCurrencies = currencies
+	fields, msg := normalizeListingFields(req.RequiredListingFields)
+	if msg != "" {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, msg)
+		return
+	}
+	req.RequiredListingFields = fields
+	policies, msg := normalizeCancellationPolicies(req.CancellationPolicies)
+	if msg != "" {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, msg)
+		return
+	}
+	req.CancellationPolicies = policies
+	types, msg := normalizeListingTypes(req.AllowedListingTypes)
+	if msg != "" {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, msg)
+		return
+	}
+	req.AllowedListingTypes = types
+
+	cur, err := h.Store.GetTenantConfig(r.Context(), tenantID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if status, msg := h.checkRemovedPolicies(r.Context(), tenantID, cur.CancellationPolicies, req.CancellationPolicies); msg != "" {
+		httputil.WriteError(w, status, msg)
+		return
+	}
+
+	// Dry run: validate and show what would change, without writing or
+	// auditing anything.
+	if r.URL.Query().Get("dryRun") == "true" {
+		req.CreatedAt, req.UpdatedAt = cur.CreatedAt, cur.UpdatedAt
+		httputil.WriteJSON(w, http.StatusOK, map[string]any{
+			"dryRun": true,
+			"config": req,
+			"diff":   diffTenantConfig(cur, req),
+		})
+		return
+	}
 
 	cfg, err := h.Store.UpsertTenantConfig(r.Context(), req)
 	if err != nil {
@@ -129,3 +307,258 @@ func (h *Handler) UpsertTenantConfig(w http.ResponseWriter, r *http.Request) {
 
 	httputil.WriteJSON(w, http.StatusOK, cfg)
 }
+
+// GetTenantConfigInternal handles GET /admin/internal/tenants/{id} for other
+// services (e.g. bookings reading amount limits).
+func (h *Handler) GetTenantConfigInternal(w http.ResponseWriter, r *http.Request) {
+	cfg, err := h.Store.GetTenantConfig(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, cfg)
+}
+
+// configChange is one field's before/after in a tenant config diff.
+type configChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// diffTenantConfig lists the settable fields that differ between cur and next,
+// keyed by their JSON names.
+func diffTenantConfig(cur, next store.TenantConfig) map[string]configChange {
+	diff := map[string]configChange{}
+	if cur.PlatformFeePct != next.PlatformFeePct {
+		diff["platformFeePct"] = configChange{cur.PlatformFeePct, next.PlatformFeePct}
+	}
+	if cur.MaxListings != next.MaxListings {
+		diff["maxListings"] = configChange{cur.MaxListings, next.MaxListings}
+	}
+	if cur.Verified != next.Verified {
+		diff["verified"] = configChange{cur.Verified, next.Verified}
+	}
+	if !equalAmount(cur.MinBookingAmount, next.MinBookingAmount) {
+		diff["minBookingAmount"] = configChange{cur.MinBookingAmount, next.MinBookingAmount}
+	}
+	if !equalAmount(cur.MaxBookingAmount, next.MaxBookingAmount) {
+		diff["maxBookingAmount"] = configChange{cur.MaxBookingAmount, next.MaxBookingAmount}
+	}
+	if !equalCount(cur.MaxPendingBookingsPerGuest, next.MaxPendingBookingsPerGuest) {
+		diff["maxPendingBookingsPerGuest"] = configChange{cur.MaxPendingBookingsPerGuest, next.MaxPendingBookingsPerGuest}
+	}
+	if !slices.Equal(cur.SupportedCurrencies, next.SupportedCurrencies) {
+		diff["supportedCurrencies"] = configChange{cur.SupportedCurrencies, next.SupportedCurrencies}
+	}
+	if cur.PublicReviews != next.PublicReviews {
+		diff["publicReviews"] = configChange{cur.PublicReviews, next.PublicReviews}
+	}
+	if cur.PaymentGraceMinutes != next.PaymentGraceMinutes {
+		diff["paymentGraceMinutes"] = configChange{cur.PaymentGraceMinutes, next.PaymentGraceMinutes}
+	}
+	if cur.DefaultSort != next.DefaultSort {
+		diff["defaultSort"] = configChange{cur.DefaultSort, next.DefaultSort}
+	}
+	if cur.MinReviewLength != next.MinReviewLength {
+		diff["minReviewLength"] = configChange{cur.MinReviewLength, next.MinReviewLength}
+	}
+	if !slices.Equal(cur.RequiredListingFields, next.RequiredListingFields) {
+		diff["requiredListingFields"] = configChange{cur.RequiredListingFields, next.RequiredListingFields}
+	}
+	if cur.MaxSearchLimit != next.MaxSearchLimit {
+		diff["maxSearchLimit"] = configChange{cur.MaxSearchLimit, next.MaxSearchLimit}
+	}
+	if cur.MinPhotosToBook != next.MinPhotosToBook {
+		diff["minPhotosToBook"] = configChange{cur.MinPhotosToBook, next.MinPhotosToBook}
+	}
+	if !reflect.DeepEqual(cur.CancellationPolicies, next.CancellationPolicies) {
+		diff["cancellationPolicies"] = configChange{cur.CancellationPolicies, next.CancellationPolicies}
+	}
+	if !slices.Equal(cur.AllowedListingTypes, next.AllowedListingTypes) {
+		diff["allowedListingTypes"] = configChange{cur.AllowedListingTypes, next.AllowedListingTypes}
+	}
+	return diff
+}
+
+// normalizeCurrencies upper-cases and de-duplicates a supported currencies
+// list, keeping the given order. Every entry must be a three-letter ISO 4217
+// code; otherwise a message for the caller is returned.
+func normalizeCurrencies(codes []string) ([]string, string) {
+	out := make([]string, 0, len(codes))
+	for _, c := range codes {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if len(c) != 3 || strings.Trim(c, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			return nil, fmt.Sprintf("supportedCurrencies: %q is not a three-letter ISO 4217 code", c)
+		}
+		if !slices.Contains(out, c) {
+			out = append(out, c)
+		}
+	}
+	return out, ""
+}
+
+// normalizeListingFields trims and de-duplicates a required listing fields
+// list, keeping the given order. Every entry must be a requirable field;
+// otherwise a message for the caller is returned.
+func normalizeListingFields(names []string) ([]string, string) {
+	out := make([]string, 0, len(names))
+	for _, n := range names {
+		n = strings.TrimSpace(n)
+		if !slices.Contains(tenantconfig.RequirableListingFields, n) {
+			return nil, fmt.Sprintf("requiredListingFields: %q is not one of %s",
+				n, strings.Join(tenantconfig.RequirableListingFields, ", "))
+		}
+		if !slices.Contains(out, n) {
+			out = append(out, n)
+		}
+	}
+	return out, ""
+}
+
+// maxListingTypes caps a tenant's allowed listing types.
+const maxListingTypes = 20
+
+var listingTypePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// normalizeListingTypes lower-cases and de-duplicates an allowed listing
+// types list, keeping the given order. It needs 1–20 lowercase identifiers;
+// otherwise a message for the caller is returned.
+func normalizeListingTypes(types []string) ([]string, string) {
+	out := make([]string, 0, len(types))
+	for _, t := range types {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if !listingTypePattern.MatchString(t) {
+			return nil, fmt.Sprintf("allowedListingTypes: %q must be a lowercase name of letters, digits and underscores", t)
+		}
+		if !slices.Contains(out, t) {
+			out = append(out, t)
+		}
+	}
+	if len(out) == 0 || len(out) > maxListingTypes {
+		return nil, fmt.Sprintf("allowedListingTypes: list 1 to %d types", maxListingTypes)
+	}
+	return out, ""
+}
+
+// Cancellation policy catalog limits.
+const (
+	maxCancellationPolicies = 20
+	maxRefundTiers          = 10
+	maxRefundTierHours      = 365 * 24
+)
+
+var policyNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// checkRemovedPolicies refuses to drop custom cancellation policies that
+// listings still use, since bookings on them couldn't learn their refund
+// terms. It returns a status and message for the caller, or "" when the
+// removal is safe.
+func (h *Handler) checkRemovedPolicies(ctx context.Context, tenantID string, cur, next []store.CancellationPolicy) (int, string) {
+	var removed []string
+	for _, p := range cur {
+		if !slices.ContainsFunc(next, func(n store.CancellationPolicy) bool { return n.Name == p.Name }) {
+			removed = append(removed, p.Name)
+		}
+	}
+	if len(removed) == 0 {
+		return 0, ""
+	}
+	if h.Listings == nil {
+		slog.Warn("cancellation policies removed unchecked (LISTINGS_URL not set)", "tenantId", tenantID, "policies", removed)
+		return 0, ""
+	}
+	usage, err := h.Listings.PolicyUsage(ctx, tenantID)
+	if err != nil {
+		slog.Warn("listing policy usage unavailable", "tenantId", tenantID, "err", err)
+		return http.StatusBadGateway, "could not check which listings use the removed cancellation policies"
+	}
+	for _, name := range removed {
+		if n := usage[name]; n > 0 {
+			return http.StatusConflict, fmt.Sprintf("cancellationPolicies: %q is still used by %d listings; move them to another policy first", name, n)
+		}
+	}
+	return 0, ""
+}
+
+// normalizeCancellationPolicies validates a custom cancellation policy
+// catalog and orders each policy's tiers earliest first. Names must be
+// lowercase identifiers, unique and not a built-in's; every policy needs
+// 1–10 tiers with distinct hoursBefore. Otherwise a message for the caller
+// is returned.
+func normalizeCancellationPolicies(policies []store.CancellationPolicy) ([]store.CancellationPolicy, string) {
+	if len(policies) > maxCancellationPolicies {
+		return nil, fmt.Sprintf("cancellationPolicies: at most %d policies", maxCancellationPolicies)
+	}
+	out := make([]store.CancellationPolicy, 0, len(policies))
+	seen := make(map[string]bool)
+	for _, p := range policies {
+		p.Name = strings.ToLower(strings.TrimSpace(p.Name))
+		switch {
+		case !policyNamePattern.MatchString(p.Name):
+			return nil, fmt.Sprintf("cancellationPolicies: %q must be a lowercase name of letters, digits and underscores", p.Name)
+		case slices.Contains(tenantconfig.BuiltinPolicyNames(), p.Name):
+			return nil, fmt.Sprintf("cancellationPolicies: %q is a built-in policy", p.Name)
+		case seen[p.Name]:
+			return nil, fmt.Sprintf("cancellationPolicies: %q is defined twice", p.Name)
+		case len(p.Tiers) == 0 || len(p.Tiers) > maxRefundTiers:
+			return nil, fmt.Sprintf("cancellationPolicies: %q needs 1 to %d tiers", p.Name, maxRefundTiers)
+		}
+		seen[p.Name] = true
+		tiers := slices.Clone(p.Tiers)
+		hours := make(map[int]bool)
+		for _, t := range tiers {
+			if t.HoursBefore < 0 || t.HoursBefore > maxRefundTierHours || t.RefundPct < 0 || t.RefundPct > 100 {
+				return nil, fmt.Sprintf("cancellationPolicies: %q tiers need hoursBefore 0–%d and refundPct 0–100", p.Name, maxRefundTierHours)
+			}
+			if hours[t.HoursBefore] {
+				return nil, fmt.Sprintf("cancellationPolicies: %q has two tiers at %d hours", p.Name, t.HoursBefore)
+			}
+			hours[t.HoursBefore] = true
+		}
+		slices.SortFunc(tiers, func(a, b store.RefundTier) int { return b.HoursBefore - a.HoursBefore })
+		p.Tiers = tiers
+		out = append(out, p)
+	}
+	return out, ""
+}
+
+func equalCount(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalAmount(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return strings.TrimSpace(*a) == strings.TrimSpace(*b)
+}
+
+// checkBookingBounds validates optional min/max booking amounts, returning a
+// message for the caller or "" if they are usable.
+func checkBookingBounds(minAmount, maxAmount *string) string {
+	parse := func(name string, v *string) (float64, bool, string) {
+		if v == nil {
+			return 0, false, ""
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(*v), 64)
+		if err != nil || f < 0 {
+			return 0, false, name + " must be a non-negative decimal amount"
+		}
+		return f, true, ""
+	}
+	lo, hasLo, msg := parse("minBookingAmount", minAmount)
+	if msg != "" {
+		return msg
+	}
+	hi, hasHi, msg := parse("maxBookingAmount", maxAmount)
+	if msg != "" {
+		return msg
+	}
+	if hasLo && hasHi && lo > hi {
+		return "minBookingAmount must not exceed maxBookingAmount"
+	}
+	return ""
+}
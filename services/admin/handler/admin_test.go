@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/saidmashhud/zist/services/admin/store"
+)
+
+func TestDiffTenantConfig(t *testing.T) {
+	amount := func(s string) *string { return &s }
+	cur := store.TenantConfig{
+		TenantID:         "t1",
+		PlatformFeePct:   12,
+		MaxListings:      50,
+		MinBookingAmount: amount("100.00"),
+		CreatedAt:        1,
+		UpdatedAt:        2,
+	}
+
+	if d := diffTenantConfig(cur, cur); len(d) != 0 {
+		t.Errorf("identical configs: want empty diff, got %v", d)
+	}
+
+	next := cur
+	next.PlatformFeePct = 15
+	next.Verified = true
+	next.MinBookingAmount = amount(" 100.00 ")
+	next.MaxBookingAmount = amount("5000.00")
+	next.UpdatedAt = 99
+
+	d := diffTenantConfig(cur, next)
+	if len(d) != 3 {
+		t.Fatalf("want 3 changed fields, got %v", d)
+	}
+	if c := d["platformFeePct"]; c.From != 12.0 || c.To != 15.0 {
+		t.Errorf("platformFeePct = %+v", c)
+	}
+	if c := d["verified"]; c.From != false || c.To != true {
+		t.Errorf("verified = %+v", c)
+	}
+	if c, ok := d["maxBookingAmount"]; !ok || c.From.(*string) != nil || *c.To.(*string) != "5000.00" {
+		t.Errorf("maxBookingAmount = %+v", c)
+	}
+	if _, ok := d["minBookingAmount"]; ok {
+		t.Error("whitespace-only amount change should not be reported")
+	}
+
+	three, alsoThree := 3, 3
+	cur.MaxPendingBookingsPerGuest = &three
+	next = cur
+	next.MaxPendingBookingsPerGuest = &alsoThree
+	if d := diffTenantConfig(cur, next); len(d) != 0 {
+		t.Errorf("equal pending caps: want empty diff, got %v", d)
+	}
+	next.MaxPendingBookingsPerGuest = nil
+	if c, ok := diffTenantConfig(cur, next)["maxPendingBookingsPerGuest"]; !ok || *c.From.(*int) != 3 || c.To.(*int) != nil {
+		t.Errorf("maxPendingBookingsPerGuest = %+v", c)
+	}
+
+	next = cur
+	next.PaymentGraceMinutes = 10
+	if c, ok := diffTenantConfig(cur, next)["paymentGraceMinutes"]; !ok || c.From != 0 || c.To != 10 {
+		t.Errorf("paymentGraceMinutes = %+v", c)
+	}
+}
+
+func TestCheckFlag(t *testing.T) {
+	cases := []struct {
+		name    string
+		rollout int
+		ok      bool
+	}{
+		{"new_checkout_flow", 50, true},
+		{"v2", 0, true},
+		{"v2", 100, true},
+		{"v2", 101, false},
+		{"v2", 500, false},
+		{"v2", -1, false},
+		{"", 10, false},
+		{"New_Flow", 10, false},
+		{"new-flow", 10, false},
+		{"new flow", 10, false},
+	}
+	for _, c := range cases {
+		if got := checkFlag(c.name, c.rollout) == ""; got != c.ok {
+			t.Errorf("checkFlag(%q, %d) ok = %v, want %v", c.name, c.rollout, got, c.ok)
+		}
+	}
+}
+
+func TestNormalizeCurrencies(t *testing.T) {
+	got, msg := normalizeCurrencies([]string{"uzs", " USD ", "UZS"})
+	if msg != "" {
+		t.Fatalf("unexpected error: %s", msg)
+	}
+	if len(got) != 2 || got[0] != "UZS" || got[1] != "USD" {
+		t.Errorf("normalizeCurrencies = %v, want [UZS USD]", got)
+	}
+	if got, msg := normalizeCurrencies(nil); msg != "" || got == nil || len(got) != 0 {
+		t.Errorf("nil list: got %v, %q; want empty, no error", got, msg)
+	}
+	for _, bad := range []string{"US", "USDT", "U$D", ""} {
+		if _, msg := normalizeCurrencies([]string{bad}); msg == "" {
+			t.Errorf("normalizeCurrencies(%q): want error", bad)
+		}
+	}
+}
+
+func TestNormalizeListingFields(t *testing.T) {
+	got, msg := normalizeListingFields([]string{"title", " address ", "title"})
+	if msg != "" || len(got) != 2 || got[0] != "title" || got[1] != "address" {
+		t.Errorf("normalizeListingFields = %v, %q; want [title address]", got, msg)
+	}
+	if _, msg := normalizeListingFields([]string{"hostId"}); msg == "" {
+		t.Error("hostId: want error")
+	}
+}
+
+func TestNormalizeListingTypes(t *testing.T) {
+	got, msg := normalizeListingTypes([]string{"house", " Yurt ", "house"})
+	if msg != "" || len(got) != 2 || got[0] != "house" || got[1] != "yurt" {
+		t.Errorf("normalizeListingTypes = %v, %q; want [house yurt]", got, msg)
+	}
+	for _, bad := range [][]string{nil, {"tree house"}, {""}} {
+		if _, msg := normalizeListingTypes(bad); msg == "" {
+			t.Errorf("normalizeListingTypes(%q): want error", bad)
+		}
+	}
+}
+
+func TestNormalizeCancellationPolicies(t *testing.T) {
+	got, msg := normalizeCancellationPolicies([]store.CancellationPolicy{{
+		Name:  " Super_Strict ",
+		Tiers: []store.RefundTier{{HoursBefore: 168, RefundPct: 25}, {HoursBefore: 720, RefundPct: 50}},
+	}})
+	if msg != "" {
+		t.Fatalf("unexpected error: %s", msg)
+	}
+	if len(got) != 1 || got[0].Name != "super_strict" || got[0].Tiers[0].HoursBefore != 720 {
+		t.Errorf("normalizeCancellationPolicies = %+v, want super_strict with the 720h tier first", got)
+	}
+
+	tier := []store.RefundTier{{HoursBefore: 48, RefundPct: 100}}
+	for name, bad := range map[string][]store.CancellationPolicy{
+		"built-in name":  {{Name: "strict", Tiers: tier}},
+		"bad name":       {{Name: "no spaces", Tiers: tier}},
+		"duplicate":      {{Name: "a", Tiers: tier}, {Name: "a", Tiers: tier}},
+		"no tiers":       {{Name: "empty"}},
+		"pct over 100":   {{Name: "generous", Tiers: []store.RefundTier{{HoursBefore: 0, RefundPct: 150}}}},
+		"negative hours": {{Name: "late", Tiers: []store.RefundTier{{HoursBefore: -1, RefundPct: 10}}}},
+		"repeated hours": {{Name: "twice", Tiers: append(tier, store.RefundTier{HoursBefore: 48, RefundPct: 50})}},
+	} {
+		if _, msg := normalizeCancellationPolicies(bad); msg == "" {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
+
+func TestCheckRemovedPolicies(t *testing.T) {
+	listings := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Tenant-ID") != "t1" {
+			http.Error(w, "no tenant", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"policies":{"moderate":4,"in_use":2}}`)) //nolint:errcheck
+	}))
+	defer listings.Close()
+
+	tier := []store.RefundTier{{HoursBefore: 48, RefundPct: 100}}
+	cur := []store.CancellationPolicy{{Name: "in_use", Tiers: tier}, {Name: "unused", Tiers: tier}}
+	h := New(nil).WithListings(listings.URL, "tok")
+	ctx := context.Background()
+
+	if _, msg := h.checkRemovedPolicies(ctx, "t1", cur, cur); msg != "" {
+		t.Errorf("nothing removed: got %q", msg)
+	}
+	if _, msg := h.checkRemovedPolicies(ctx, "t1", cur, cur[:1]); msg != "" {
+		t.Errorf("removing an unused policy: got %q", msg)
+	}
+	if status, msg := h.checkRemovedPolicies(ctx, "t1", cur, cur[1:]); status != http.StatusConflict || msg == "" {
+		t.Errorf("removing a used policy: got %d %q, want 409", status, msg)
+	}
+	if status, _ := h.checkRemovedPolicies(ctx, "t2", cur, nil); status != http.StatusBadGateway {
+		t.Errorf("usage unreadable: got %d, want 502", status)
+	}
+	if _, msg := New(nil).checkRemovedPolicies(ctx, "t1", cur, nil); msg != "" {
+		t.Errorf("no listings client: got %q, want the removal allowed", msg)
+	}
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	zistauth "github.com/saidmashhud/zist/internal/auth"
+	"github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/services/admin/store"
+)
+
+// apiKeyPrefix matches the prefix the gateway recognises as an API key.
+const apiKeyPrefix = "zk_"
+
+// newAPIKey returns a fresh plaintext key and its SHA-256 hex digest.
+func newAPIKey() (key, hash string, err error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	key = apiKeyPrefix + hex.EncodeToString(b)
+	return key, hashAPIKey(key), nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// validAPIKeyScopes reports whether scopes is a non-empty set of zist.*
+// scopes. zist.admin is never grantable to a key: platform operations stay
+// tied to a person.
+func validAPIKeyScopes(scopes []string) bool {
+	if len(scopes) == 0 {
+		return false
+	}
+	for _, s := range scopes {
+		if !strings.HasPrefix(s, "zist.") || s == "zist.admin" {
+			return false
+		}
+	}
+	return true
+}
+
+// CreateAPIKey handles POST /admin/tenants/{id}/api-keys. The plaintext key
+// is only ever returned in this response.
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	p := zistauth.FromContext(r.Context())
+	if !requireAdmin(p) {
+		httputil.WriteError(w, http.StatusForbidden, "admin scope required")
+		return
+	}
+	tenantID := chi.URLParam(r, "id")
+
+	var req struct {
+		Name      string   `json:"name"`
+		Scopes    []string `json:"scopes"`
+		ExpiresAt *int64   `json:"expiresAt"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, "name is required")
+		return
+	}
+	if !validAPIKeyScopes(req.Scopes) {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, "scopes must be non-empty zist.* scopes (zist.admin is not allowed)")
+		return
+	}
+	if req.ExpiresAt != nil && *req.ExpiresAt <= time.Now().Unix() {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, "expiresAt must be in the future")
+		return
+	}
+
+	key, hash, err := newAPIKey()
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to generate key")
+		return
+	}
+	k, err := h.Store.CreateAPIKey(r.Context(), store.APIKey{
+		TenantID:  tenantID,
+		Name:      req.Name,
+		Prefix:    key[:len(apiKeyPrefix)+8],
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: p.UserID,
+	}, hash)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to create API key")
+		return
+	}
+
+	h.Store.AddAudit(r.Context(), p.UserID, "create_api_key", "api_key:"+k.ID, //nolint:errcheck
+		"tenant="+tenantID+" scopes="+strings.Join(k.Scopes, " "), p.TenantID)
+
+	httputil.WriteJSON(w, http.StatusCreated, map[string]any{"key": key, "apiKey": k})
+}
+
+// ListAPIKeys handles GET /admin/tenants/{id}/api-keys.
+func (h *Handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	p := zistauth.FromContext(r.Context())
+	if !requireAdmin(p) {
+		httputil.WriteError(w, http.StatusForbidden, "admin scope required")
+		return
+	}
+	keys, err := h.Store.ListAPIKeys(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"apiKeys": keys})
+}
+
+// RevokeAPIKey handles DELETE /admin/tenants/{id}/api-keys/{keyId}.
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	p := zistauth.FromContext(r.Context())
+	if !requireAdmin(p) {
+		httputil.WriteError(w, http.StatusForbidden, "admin scope required")
+		return
+	}
+	tenantID, keyID := chi.URLParam(r, "id"), chi.URLParam(r, "keyId")
+	err := h.Store.RevokeAPIKey(r.Context(), tenantID, keyID)
+	if errors.Is(err, store.ErrNotFound) {
+		httputil.WriteError(w, http.StatusNotFound, "API key not found")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	h.Store.AddAudit(r.Context(), p.UserID, "revoke_api_key", "api_key:"+keyID, //nolint:errcheck
+		"tenant="+tenantID, p.TenantID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LookupAPIKey handles GET /admin/internal/api-keys/{hash}. The gateway
+// calls it with the SHA-256 of a presented key, so plaintext keys never
+// leave the edge. Unknown, revoked and expired keys are all 404.
+func (h *Handler) LookupAPIKey(w http.ResponseWriter, r *http.Request) {
+	k, err := h.Store.LookupAPIKey(r.Context(), chi.URLParam(r, "hash"))
+	if errors.Is(err, store.ErrNotFound) {
+		httputil.WriteError(w, http.StatusNotFound, "API key not found")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{
+		"keyId":     k.ID,
+		"tenantId":  k.TenantID,
+		"scopes":    k.Scopes,
+		"expiresAt": k.ExpiresAt,
+	})
+}
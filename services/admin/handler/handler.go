@@ -9,6 +9,9 @@ import (
 // Handler holds shared dependencies for all admin HTTP handlers.
 type Handler struct {
 	Store *store.Store
+	// Listings reports which cancellation policies listings use; nil unless
+	// LISTINGS_URL is set.
+	Listings *listingsClient
 }
 
 // New creates a Handler.
@@ -16,6 +19,15 @@ func New(s *store.Store) *Handler {
 	return &Handler{Store: s}
 }
 
+// WithListings lets tenant config updates check the listings service before
+// removing a cancellation policy. Without it removals aren't checked.
+func (h *Handler) WithListings(listingsURL, internalToken string) *Handler {
+	if listingsURL != "" {
+		h.Listings = newListingsClient(listingsURL, internalToken)
+	}
+	return h
+}
+
 // requireAdmin returns the principal or writes 401/403. Requires the
 // zist.admin scope which is only granted to platform operators.
 func requireAdmin(p *zistauth.Principal) bool {
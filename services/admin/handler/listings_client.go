@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// listingsClient calls the listings service's internal endpoints.
+type listingsClient struct {
+	baseURL       string
+	internalToken string
+	http          *http.Client
+}
+
+func newListingsClient(baseURL, internalToken string) *listingsClient {
+	return &listingsClient{
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		internalToken: internalToken,
+		http:          &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// PolicyUsage counts the tenant's listings by cancellation policy.
+func (c *listingsClient) PolicyUsage(ctx context.Context, tenantID string) (map[string]int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/listings/policy-usage", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Internal-Token", c.internalToken)
+	req.Header.Set("X-Tenant-ID", tenantID)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("listings service returned %d", resp.StatusCode)
+	}
+	var out struct {
+		Policies map[string]int `json:"policies"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode listings response: %w", err)
+	}
+	return out.Policies, nil
+}
@@ -45,7 +45,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	h := handler.New(store.New(db))
+	h := handler.New(store.New(db)).WithListings(cfg.ListingsURL, cfg.InternalToken)
 	srv := &server{cfg: cfg, h: h}
 
 	slog.Info("admin service starting", "port", cfg.Port)
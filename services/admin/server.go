@@ -30,15 +30,23 @@ func (s *server) routes() http.Handler {
 
 	// All admin routes require authentication (scope enforcement is in handlers).
 	adminMW := chi.Chain(zistauth.RequireAuth)
+	internal := chi.Chain(zistauth.RequireServiceAuth(s.cfg.InternalToken, nil))
 
 	r.Route("/admin", func(r chi.Router) {
 		r.With(adminMW...).Get("/flags", s.h.ListFlags)
 		r.With(adminMW...).Post("/flags", s.h.UpsertFlag)
 
 		r.With(adminMW...).Get("/audit", s.h.ListAudit)
+		r.With(internal...).Post("/internal/audit", s.h.RecordAudit)
 
 		r.With(adminMW...).Get("/tenants/{id}", s.h.GetTenantConfig)
 		r.With(adminMW...).Put("/tenants/{id}", s.h.UpsertTenantConfig)
+		r.With(internal...).Get("/internal/tenants/{id}", s.h.GetTenantConfigInternal)
+
+		r.With(adminMW...).Get("/tenants/{id}/api-keys", s.h.ListAPIKeys)
+		r.With(adminMW...).Post("/tenants/{id}/api-keys", s.h.CreateAPIKey)
+		r.With(adminMW...).Delete("/tenants/{id}/api-keys/{keyId}", s.h.RevokeAPIKey)
+		r.With(internal...).Get("/internal/api-keys/{hash}", s.h.LookupAPIKey)
 	})
 
 	return r
@@ -1,6 +1,12 @@
 package store
 
-import "database/sql"
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/saidmashhud/zist/internal/tenantconfig"
+)
 
 // Migrate runs idempotent DDL for admin tables.
 func Migrate(db *sql.DB) error {
@@ -38,6 +44,12 @@ func Migrate(db *sql.DB) error {
 	`); err != nil {
 		return err
 	}
+	// Unfiltered paging walks the log newest first.
+	if _, err := db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_admin_audit_created ON admin_audit_log(created_at DESC, id DESC)
+	`); err != nil {
+		return err
+	}
 
 	// Tenant configuration overrides.
 	if _, err := db.Exec(`
@@ -53,5 +65,57 @@ func Migrate(db *sql.DB) error {
 		return err
 	}
 
+	// Booking guards; NULL means no limit.
+	for _, col := range []string{
+		`ALTER TABLE tenant_configs ADD COLUMN IF NOT EXISTS min_booking_amount TEXT`,
+		`ALTER TABLE tenant_configs ADD COLUMN IF NOT EXISTS max_booking_amount TEXT`,
+		`ALTER TABLE tenant_configs ADD COLUMN IF NOT EXISTS max_pending_bookings_per_guest INTEGER`,
+		`ALTER TABLE tenant_configs ADD COLUMN IF NOT EXISTS supported_currencies TEXT[] NOT NULL DEFAULT '{}'`,
+		`ALTER TABLE tenant_configs ADD COLUMN IF NOT EXISTS public_reviews BOOLEAN NOT NULL DEFAULT true`,
+		`ALTER TABLE tenant_configs ADD COLUMN IF NOT EXISTS payment_grace_minutes INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE tenant_configs ADD COLUMN IF NOT EXISTS default_sort TEXT NOT NULL DEFAULT ''`,
+		fmt.Sprintf(`ALTER TABLE tenant_configs ADD COLUMN IF NOT EXISTS min_review_length INTEGER NOT NULL DEFAULT %d`, tenantconfig.DefaultMinReviewLength),
+		`ALTER TABLE tenant_configs ADD COLUMN IF NOT EXISTS required_listing_fields TEXT[] NOT NULL DEFAULT ` + arrayDefault(tenantconfig.DefaultRequiredListingFields()),
+		fmt.Sprintf(`ALTER TABLE tenant_configs ADD COLUMN IF NOT EXISTS max_search_limit INTEGER NOT NULL DEFAULT %d`, tenantconfig.MaxSearchLimit),
+		fmt.Sprintf(`ALTER TABLE tenant_configs ADD COLUMN IF NOT EXISTS min_photos_to_book INTEGER NOT NULL DEFAULT %d`, tenantconfig.DefaultMinPhotosToBook),
+		`ALTER TABLE tenant_configs ADD COLUMN IF NOT EXISTS cancellation_policies JSONB NOT NULL DEFAULT '[]'`,
+		`ALTER TABLE tenant_configs ADD COLUMN IF NOT EXISTS allowed_listing_types TEXT[] NOT NULL DEFAULT ` + arrayDefault(tenantconfig.DefaultListingTypes()),
+	} {
+		if _, err := db.Exec(col); err != nil {
+			return err
+		}
+	}
+
+	// Per-tenant API keys for headless integrations. Only the SHA-256 of the
+	// key is stored; the plaintext is shown once at creation.
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS api_keys (
+			id           TEXT    PRIMARY KEY,
+			tenant_id    TEXT    NOT NULL,
+			name         TEXT    NOT NULL,
+			key_hash     TEXT    UNIQUE NOT NULL,
+			key_prefix   TEXT    NOT NULL,
+			scopes       TEXT[]  NOT NULL DEFAULT '{}',
+			expires_at   BIGINT,
+			revoked_at   BIGINT,
+			last_used_at BIGINT,
+			created_by   TEXT    NOT NULL,
+			created_at   BIGINT  NOT NULL
+		)
+	`); err != nil {
+		return err
+	}
+	if _, err := db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_api_keys_tenant ON api_keys(tenant_id, created_at DESC)
+	`); err != nil {
+		return err
+	}
+
 	return nil
 }
+
+// arrayDefault renders vals as a TEXT[] column default.
+func arrayDefault(vals []string) string {
+	v, _ := pq.StringArray(vals).Value()
+	return "'" + v.(string) + "'"
+}
@@ -4,10 +4,13 @@ package store
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/saidmashhud/zist/internal/tenantconfig"
 )
 
 // ErrNotFound is returned when a requested resource does not exist.
@@ -41,8 +44,64 @@ type TenantConfig struct {
 	PlatformFeePct float64 `json:"platformFeePct"`
 	MaxListings    int     `json:"maxListings"`
 	Verified       bool    `json:"verified"`
-	CreatedAt      int64   `json:"createdAt"`
-	UpdatedAt      int64   `json:"updatedAt"`
+	// Bookings whose total falls outside these decimal bounds are rejected;
+	// nil means unbounded.
+	MinBookingAmount *string `json:"minBookingAmount"`
+	MaxBookingAmount *string `json:"maxBookingAmount"`
+	// MaxPendingBookingsPerGuest caps a guest's bookings awaiting approval
+	// or payment; nil means unlimited.
+	MaxPendingBookingsPerGuest *int `json:"maxPendingBookingsPerGuest"`
+	// SupportedCurrencies restricts listing and booking currencies to these
+	// ISO 4217 codes; empty allows any.
+	SupportedCurrencies []string `json:"supportedCurrencies"`
+	// PublicReviews lets anonymous callers read listing reviews; when false
+	// only signed-in users can.
+	PublicReviews bool `json:"publicReviews"`
+	// PaymentGraceMinutes keeps confirming bookings whose payment is
+	// captured up to this long after the payment window closes.
+	PaymentGraceMinutes int `json:"paymentGraceMinutes"`
+	// DefaultSort is the search order used when a search names none; empty
+	// keeps the search service's ranking.
+	DefaultSort string `json:"defaultSort"`
+	// MinReviewLength is the fewest characters a review comment may have,
+	// whitespace trimmed; 0 allows empty comments.
+	MinReviewLength int `json:"minReviewLength"`
+	// RequiredListingFields names the listing fields (by JSON name) that must
+	// be filled in to create or publish a listing.
+	RequiredListingFields []string `json:"requiredListingFields"`
+	// MaxSearchLimit caps the page size of the tenant's searches.
+	MaxSearchLimit int `json:"maxSearchLimit"`
+	// MinPhotosToBook is the fewest photos a listing needs to be booked.
+	MinPhotosToBook int `json:"minPhotosToBook"`
+	// CancellationPolicies are the tenant's custom policies, offered to
+	// listings alongside tenantconfig.BuiltinPolicyNames.
+	CancellationPolicies []CancellationPolicy `json:"cancellationPolicies"`
+	// AllowedListingTypes are the property types listings may have.
+	AllowedListingTypes []string `json:"allowedListingTypes"`
+	CreatedAt           int64    `json:"createdAt"`
+	UpdatedAt           int64    `json:"updatedAt"`
+}
+
+// CancellationPolicy and RefundTier are shared with the services reading
+// tenant configs.
+type (
+	CancellationPolicy = tenantconfig.CancellationPolicy
+	RefundTier         = tenantconfig.RefundTier
+)
+
+// APIKey is a tenant-scoped credential for headless integrations. The key
+// itself is never stored or returned after creation; Prefix identifies it.
+type APIKey struct {
+	ID         string   `json:"id"`
+	TenantID   string   `json:"tenantId"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  *int64   `json:"expiresAt,omitempty"`
+	RevokedAt  *int64   `json:"revokedAt,omitempty"`
+	LastUsedAt *int64   `json:"lastUsedAt,omitempty"`
+	CreatedBy  string   `json:"createdBy"`
+	CreatedAt  int64    `json:"createdAt"`
 }
 
 // Store wraps a PostgreSQL connection.
@@ -55,12 +114,19 @@ func New(db *sql.DB) *Store { return &Store{db: db} }
 
 // ─── Feature Flags ────────────────────────────────────────────────────────────
 
-func (s *Store) ListFlags(ctx context.Context) ([]FeatureFlag, error) {
+// ListFlags returns flags ordered by name, plus the total count. limit 0
+// returns every flag from offset on.
+func (s *Store) ListFlags(ctx context.Context, limit, offset int) ([]FeatureFlag, int, error) {
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM feature_flags`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
 	rows, err := s.db.QueryContext(ctx,
 		`SELECT id, name, enabled, rollout, tenant_id, created_at, updated_at
-		 FROM feature_flags ORDER BY name`)
+		 FROM feature_flags ORDER BY name LIMIT NULLIF($1, 0) OFFSET $2`,
+		limit, offset)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 	var flags []FeatureFlag
@@ -73,7 +139,7 @@ func (s *Store) ListFlags(ctx context.Context) ([]FeatureFlag, error) {
 	if flags == nil {
 		flags = []FeatureFlag{}
 	}
-	return flags, nil
+	return flags, total, nil
 }
 
 func (s *Store) UpsertFlag(ctx context.Context, name string, enabled bool, rollout int, tenantID *string) (FeatureFlag, error) {
@@ -103,24 +169,28 @@ func (s *Store) AddAudit(ctx context.Context, actorID, action, resource, detail,
 	return err
 }
 
-func (s *Store) ListAudit(ctx context.Context, actorID string, limit int) ([]AuditEntry, error) {
+// ListAudit returns audit entries newest first, optionally for one actor,
+// plus the total matching count.
+func (s *Store) ListAudit(ctx context.Context, actorID string, limit, offset int) ([]AuditEntry, int, error) {
 	if limit <= 0 || limit > 500 {
 		limit = 100
 	}
-	var rows *sql.Rows
-	var err error
-	if actorID != "" {
-		rows, err = s.db.QueryContext(ctx,
-			`SELECT id, actor_id, action, resource, detail, tenant_id, created_at
-			 FROM admin_audit_log WHERE actor_id=$1 ORDER BY created_at DESC LIMIT $2`,
-			actorID, limit)
-	} else {
-		rows, err = s.db.QueryContext(ctx,
-			`SELECT id, actor_id, action, resource, detail, tenant_id, created_at
-			 FROM admin_audit_log ORDER BY created_at DESC LIMIT $1`, limit)
+	if offset < 0 {
+		offset = 0
 	}
+	var total int
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM admin_audit_log WHERE ($1 = '' OR actor_id = $1)`,
+		actorID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, actor_id, action, resource, detail, tenant_id, created_at
+		 FROM admin_audit_log WHERE ($1 = '' OR actor_id = $1)
+		 ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3`,
+		actorID, limit, offset)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 	var entries []AuditEntry
@@ -133,37 +203,145 @@ func (s *Store) ListAudit(ctx context.Context, actorID string, limit int) ([]Aud
 	if entries == nil {
 		entries = []AuditEntry{}
 	}
-	return entries, nil
+	return entries, total, nil
 }
 
 // ─── Tenant Config ────────────────────────────────────────────────────────────
 
 func (s *Store) GetTenantConfig(ctx context.Context, tenantID string) (TenantConfig, error) {
 	var cfg TenantConfig
+	var policies []byte
 	err := s.db.QueryRowContext(ctx,
-		`SELECT tenant_id, platform_fee_pct, max_listings, verified, created_at, updated_at
+		`SELECT tenant_id, platform_fee_pct, max_listings, verified,
+		        min_booking_amount, max_booking_amount, max_pending_bookings_per_guest,
+		        supported_currencies, public_reviews, payment_grace_minutes, default_sort, min_review_length, required_listing_fields, max_search_limit, min_photos_to_book, cancellation_policies, allowed_listing_types, created_at, updated_at
 		 FROM tenant_configs WHERE tenant_id=$1`, tenantID).
-		Scan(&cfg.TenantID, &cfg.PlatformFeePct, &cfg.MaxListings, &cfg.Verified, &cfg.CreatedAt, &cfg.UpdatedAt)
+		Scan(&cfg.TenantID, &cfg.PlatformFeePct, &cfg.MaxListings, &cfg.Verified,
+			&cfg.MinBookingAmount, &cfg.MaxBookingAmount, &cfg.MaxPendingBookingsPerGuest,
+			pq.Array(&cfg.SupportedCurrencies), &cfg.PublicReviews, &cfg.PaymentGraceMinutes, &cfg.DefaultSort, &cfg.MinReviewLength, pq.Array(&cfg.RequiredListingFields), &cfg.MaxSearchLimit, &cfg.MinPhotosToBook, &policies, pq.Array(&cfg.AllowedListingTypes), &cfg.CreatedAt, &cfg.UpdatedAt)
 	if errors.Is(err, sql.ErrNoRows) {
 		// Return sensible defaults if not configured.
 		return TenantConfig{
-			TenantID:       tenantID,
-			PlatformFeePct: 12.0,
-			MaxListings:    50,
+			TenantID:              tenantID,
+			PlatformFeePct:        12.0,
+			MaxListings:           50,
+			SupportedCurrencies:   []string{},
+			PublicReviews:         true,
+			MinReviewLength:       tenantconfig.DefaultMinReviewLength,
+			RequiredListingFields: tenantconfig.DefaultRequiredListingFields(),
+			MaxSearchLimit:        tenantconfig.MaxSearchLimit,
+			MinPhotosToBook:       tenantconfig.DefaultMinPhotosToBook,
+			CancellationPolicies:  []CancellationPolicy{},
+			AllowedListingTypes:   tenantconfig.DefaultListingTypes(),
 		}, nil
 	}
-	return cfg, err
+	if err != nil {
+		return cfg, err
+	}
+	return cfg, json.Unmarshal(policies, &cfg.CancellationPolicies)
 }
 
 func (s *Store) UpsertTenantConfig(ctx context.Context, cfg TenantConfig) (TenantConfig, error) {
 	now := time.Now().Unix()
-	err := s.db.QueryRowContext(ctx, `
-		INSERT INTO tenant_configs (tenant_id, platform_fee_pct, max_listings, verified, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+	if cfg.CancellationPolicies == nil {
+		cfg.CancellationPolicies = []CancellationPolicy{}
+	}
+	policies, err := json.Marshal(cfg.CancellationPolicies)
+	if err != nil {
+		return cfg, err
+	}
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO tenant_configs (tenant_id, platform_fee_pct, max_listings, verified,
+		                            min_booking_amount, max_booking_amount, max_pending_bookings_per_guest,
+		                            supported_currencies, public_reviews, payment_grace_minutes, default_sort, min_review_length, required_listing_fields, max_search_limit, min_photos_to_book, cancellation_policies, allowed_listing_types, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
 		ON CONFLICT (tenant_id) DO UPDATE
-		  SET platform_fee_pct=$2, max_listings=$3, verified=$4, updated_at=$6
-		RETURNING tenant_id, platform_fee_pct, max_listings, verified, created_at, updated_at`,
-		cfg.TenantID, cfg.PlatformFeePct, cfg.MaxListings, cfg.Verified, now, now,
-	).Scan(&cfg.TenantID, &cfg.PlatformFeePct, &cfg.MaxListings, &cfg.Verified, &cfg.CreatedAt, &cfg.UpdatedAt)
-	return cfg, err
+		  SET platform_fee_pct=$2, max_listings=$3, verified=$4,
+		      min_booking_amount=$5, max_booking_amount=$6, max_pending_bookings_per_guest=$7,
+		      supported_currencies=$8, public_reviews=$9, payment_grace_minutes=$10, default_sort=$11,
+		      min_review_length=$12, required_listing_fields=$13,
+		      max_search_limit=$14, min_photos_to_book=$15, cancellation_policies=$16,
+		      allowed_listing_types=$17, updated_at=$19
+		RETURNING tenant_id, platform_fee_pct, max_listings, verified,
+		          min_booking_amount, max_booking_amount, max_pending_bookings_per_guest,
+		          supported_currencies, public_reviews, payment_grace_minutes, default_sort, min_review_length, required_listing_fields, max_search_limit, min_photos_to_book, cancellation_policies, allowed_listing_types, created_at, updated_at`,
+		cfg.TenantID, cfg.PlatformFeePct, cfg.MaxListings, cfg.Verified,
+		cfg.MinBookingAmount, cfg.MaxBookingAmount, cfg.MaxPendingBookingsPerGuest,
+		pq.Array(cfg.SupportedCurrencies), cfg.PublicReviews, cfg.PaymentGraceMinutes, cfg.DefaultSort, cfg.MinReviewLength, pq.Array(cfg.RequiredListingFields), cfg.MaxSearchLimit, cfg.MinPhotosToBook, string(policies), pq.Array(cfg.AllowedListingTypes), now, now,
+	).Scan(&cfg.TenantID, &cfg.PlatformFeePct, &cfg.MaxListings, &cfg.Verified,
+		&cfg.MinBookingAmount, &cfg.MaxBookingAmount, &cfg.MaxPendingBookingsPerGuest,
+		pq.Array(&cfg.SupportedCurrencies), &cfg.PublicReviews, &cfg.PaymentGraceMinutes, &cfg.DefaultSort, &cfg.MinReviewLength, pq.Array(&cfg.RequiredListingFields), &cfg.MaxSearchLimit, &cfg.MinPhotosToBook, &policies, pq.Array(&cfg.AllowedListingTypes), &cfg.CreatedAt, &cfg.UpdatedAt)
+	if err != nil {
+		return cfg, err
+	}
+	return cfg, json.Unmarshal(policies, &cfg.CancellationPolicies)
+}
+
+// ─── API Keys ─────────────────────────────────────────────────────────────────
+
+const apiKeyCols = `id, tenant_id, name, key_prefix, scopes, expires_at, revoked_at, last_used_at, created_by, created_at`
+
+func scanAPIKey(row interface{ Scan(...any) error }) (APIKey, error) {
+	var k APIKey
+	err := row.Scan(&k.ID, &k.TenantID, &k.Name, &k.Prefix, pq.Array(&k.Scopes),
+		&k.ExpiresAt, &k.RevokedAt, &k.LastUsedAt, &k.CreatedBy, &k.CreatedAt)
+	return k, err
+}
+
+// CreateAPIKey stores k under keyHash and returns the persisted row.
+func (s *Store) CreateAPIKey(ctx context.Context, k APIKey, keyHash string) (APIKey, error) {
+	return scanAPIKey(s.db.QueryRowContext(ctx, `
+		INSERT INTO api_keys (id, tenant_id, name, key_hash, key_prefix, scopes, expires_at, created_by, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+		RETURNING `+apiKeyCols,
+		uuid.NewString(), k.TenantID, k.Name, keyHash, k.Prefix, pq.Array(k.Scopes),
+		k.ExpiresAt, k.CreatedBy, time.Now().Unix()))
+}
+
+// ListAPIKeys returns every key of a tenant, revoked ones included, newest first.
+func (s *Store) ListAPIKeys(ctx context.Context, tenantID string) ([]APIKey, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+apiKeyCols+` FROM api_keys WHERE tenant_id=$1 ORDER BY created_at DESC`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	keys := []APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey marks a key revoked. Returns ErrNotFound if the tenant has no
+// such key or it is already revoked.
+func (s *Store) RevokeAPIKey(ctx context.Context, tenantID, id string) error {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE api_keys SET revoked_at=$3 WHERE tenant_id=$1 AND id=$2 AND revoked_at IS NULL`,
+		tenantID, id, time.Now().Unix())
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// LookupAPIKey resolves an active (unrevoked, unexpired) key by hash and
+// records it as used. Returns ErrNotFound otherwise.
+func (s *Store) LookupAPIKey(ctx context.Context, keyHash string) (APIKey, error) {
+	now := time.Now().Unix()
+	k, err := scanAPIKey(s.db.QueryRowContext(ctx, `
+		UPDATE api_keys SET last_used_at=$2
+		WHERE key_hash=$1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $2)
+		RETURNING `+apiKeyCols, keyHash, now))
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKey{}, ErrNotFound
+	}
+	return k, err
 }
@@ -6,13 +6,31 @@ import (
 
 // Config holds all environment-driven configuration for the bookings service.
 type Config struct {
-	Port            string
-	DatabaseURL     string
-	ListingsURL     string
-	InternalToken   string
-	FeeGuestPct     float64
-	NotifyURL       string // mgNotify base URL
-	MashgateAPIKey  string // Mashgate API key for mgNotify auth
+	Port                 string
+	DatabaseURL          string
+	ListingsURL          string
+	AdminURL             string // admin service: audit entries, tenant booking limits (optional)
+	ReviewsURL           string // reviews service: review eligibility in booking summaries (optional)
+	InternalToken        string
+	FeeGuestPct          float64
+	PaymentWindowMinutes int    // default time to pay once payment_pending
+	ExpirySweepSeconds   int    // how often lapsed payment holds are expired
+	NotifyURL            string // mgNotify base URL
+	MashgateAPIKey       string // Mashgate API key for mgNotify auth
+	EventsURL            string // mgEvents base URL for published domain events
+	AutoConfirmFree      bool   // confirm zero-total instant bookings without payment
+	GuestCancelCutoffHrs int    // guests can't cancel within this many hours of check-in
+	MaxMessageLength     int    // characters allowed in a booking-request message (0 = unbounded)
+	MessagesPerHour      int    // booking-request messages per guest per listing per hour (0 = unlimited)
+
+	// Rounding of booking amounts
+	FeeRoundingMode    string // half_up (default) or half_even
+	CurrencyMinorUnits string // CODE:places pairs, e.g. "UZS:0"; others use 2
+
+	// Stay completion and review reminders
+	CompletionSweepSeconds   int // how often checked-out stays are completed (0 disables)
+	ReviewReminderEnabled    bool
+	ReviewReminderDelayHours int
 
 	// Service JWT auth (optional; if set, JWT is preferred over InternalToken)
 	AuthServiceURL string
@@ -23,13 +41,29 @@ type Config struct {
 // LoadConfig reads configuration from environment variables.
 func LoadConfig() *Config {
 	return &Config{
-		Port:           httputil.Getenv("BOOKINGS_PORT", "8002"),
-		DatabaseURL:    httputil.Getenv("DATABASE_URL", "postgres://dev:dev@db:5432/zist?sslmode=disable"),
-		ListingsURL:    httputil.Getenv("LISTINGS_SERVICE_URL", "http://listings:8001"),
-		InternalToken:  httputil.Getenv("INTERNAL_TOKEN", ""),
-		FeeGuestPct:    httputil.GetenvFloat("PLATFORM_FEE_GUEST_PCT", 12.0),
-		NotifyURL:      httputil.Getenv("MGNOTIFY_URL", ""),
-		MashgateAPIKey: httputil.Getenv("MASHGATE_API_KEY", ""),
+		Port:                 httputil.Getenv("BOOKINGS_PORT", "8002"),
+		DatabaseURL:          httputil.Getenv("DATABASE_URL", "postgres://dev:dev@db:5432/zist?sslmode=disable"),
+		ListingsURL:          httputil.Getenv("LISTINGS_SERVICE_URL", "http://listings:8001"),
+		AdminURL:             httputil.Getenv("ADMIN_URL", ""),
+		ReviewsURL:           httputil.Getenv("REVIEWS_URL", ""),
+		InternalToken:        httputil.Getenv("INTERNAL_TOKEN", ""),
+		FeeGuestPct:          httputil.GetenvFloat("PLATFORM_FEE_GUEST_PCT", 12.0),
+		PaymentWindowMinutes: httputil.GetenvInt("PAYMENT_WINDOW_MINUTES", 24*60),
+		ExpirySweepSeconds:   httputil.GetenvInt("EXPIRY_SWEEP_SECONDS", 60),
+		NotifyURL:            httputil.Getenv("MGNOTIFY_URL", ""),
+		MashgateAPIKey:       httputil.Getenv("MASHGATE_API_KEY", ""),
+		EventsURL:            httputil.Getenv("MGEVENTS_URL", ""),
+		AutoConfirmFree:      httputil.Getenv("AUTO_CONFIRM_FREE_BOOKINGS", "true") == "true",
+		GuestCancelCutoffHrs: httputil.GetenvInt("GUEST_CANCEL_CUTOFF_HOURS", 0),
+		MaxMessageLength:     httputil.GetenvInt("BOOKING_MESSAGE_MAX_LENGTH", 2000),
+		MessagesPerHour:      httputil.GetenvInt("BOOKING_MESSAGES_PER_HOUR", 10),
+
+		FeeRoundingMode:    httputil.Getenv("FEE_ROUNDING_MODE", "half_up"),
+		CurrencyMinorUnits: httputil.Getenv("CURRENCY_MINOR_UNITS", ""),
+
+		CompletionSweepSeconds:   httputil.GetenvInt("COMPLETION_SWEEP_SECONDS", 300),
+		ReviewReminderEnabled:    httputil.Getenv("REVIEW_REMINDER_ENABLED", "true") == "true",
+		ReviewReminderDelayHours: httputil.GetenvInt("REVIEW_REMINDER_DELAY_HOURS", 24),
 
 		AuthServiceURL: httputil.Getenv("AUTH_SERVICE_URL", ""),
 		AuthServiceKey: httputil.Getenv("AUTH_SERVICE_KEY", ""),
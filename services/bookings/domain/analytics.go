@@ -0,0 +1,158 @@
+package domain
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ListingCapacity is a host listing with the nights the host blocked in an
+// analytics window, as reported by the listings service.
+type ListingCapacity struct {
+	ListingID     string `json:"listingId"`
+	Title         string `json:"title"`
+	BlockedNights int    `json:"blockedNights"`
+}
+
+// HostAnalytics summarises a host's bookings over [From, To).
+//
+// Revenue is the host's earnings, keyed by currency: total_amount minus the
+// platform fee and the refundable deposit, attributed pro rata to the nights
+// that fall inside the window. Each stay's share is rounded to the currency's
+// minor units before it is summed.
+type HostAnalytics struct {
+	From            string             `json:"from"`
+	To              string             `json:"to"`
+	Revenue         map[string]string  `json:"revenue"`
+	NightsBooked    int                `json:"nightsBooked"`
+	AvailableNights int                `json:"availableNights"`
+	OccupancyRate   float64            `json:"occupancyRate"` // 0–1
+	Listings        []ListingAnalytics `json:"listings"`
+}
+
+// ListingAnalytics is one listing's share of HostAnalytics.
+type ListingAnalytics struct {
+	ListingID       string            `json:"listingId"`
+	Title           string            `json:"title"`
+	Revenue         map[string]string `json:"revenue"`
+	NightsBooked    int               `json:"nightsBooked"`
+	AvailableNights int               `json:"availableNights"`
+	OccupancyRate   float64           `json:"occupancyRate"`
+}
+
+// ComputeHostAnalytics aggregates stays (confirmed or completed bookings)
+// against capacity for the window [from, to). Listings that appear only in
+// stays (e.g. since deleted) are reported with no available nights. Amounts
+// are summed in decimal and formatted with r; stays whose amounts don't
+// parse are skipped.
+func ComputeHostAnalytics(r Rounding, from, to time.Time, stays []Booking, capacity []ListingCapacity) HostAnalytics {
+	windowNights := nightsBetween(from, to)
+
+	type acc struct {
+		ListingAnalytics
+		revenue map[string]decimal.Decimal
+	}
+	byID := map[string]*acc{}
+	var order []string
+	get := func(id, title string) *acc {
+		if a, ok := byID[id]; ok {
+			return a
+		}
+		a := &acc{ListingAnalytics: ListingAnalytics{ListingID: id, Title: title}, revenue: map[string]decimal.Decimal{}}
+		byID[id] = a
+		order = append(order, id)
+		return a
+	}
+	for _, c := range capacity {
+		get(c.ListingID, c.Title).AvailableNights = max(windowNights-c.BlockedNights, 0)
+	}
+
+	total := map[string]decimal.Decimal{}
+	out := HostAnalytics{From: from.Format("2006-01-02"), To: to.Format("2006-01-02")}
+	for _, b := range stays {
+		ci, err1 := time.Parse("2006-01-02", b.CheckIn)
+		co, err2 := time.Parse("2006-01-02", b.CheckOut)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		stayNights := nightsBetween(ci, co)
+		overlap := nightsBetween(later(ci, from), earlier(co, to))
+		if stayNights <= 0 || overlap <= 0 {
+			continue
+		}
+		payout, ok := hostPayout(b)
+		if !ok {
+			continue
+		}
+		share := r.Round(payout.Mul(decimal.NewFromInt(int64(overlap))).Div(decimal.NewFromInt(int64(stayNights))), b.Currency)
+
+		a := get(b.ListingID, "")
+		a.NightsBooked += overlap
+		a.revenue[b.Currency] = a.revenue[b.Currency].Add(share)
+		total[b.Currency] = total[b.Currency].Add(share)
+		out.NightsBooked += overlap
+	}
+
+	sort.SliceStable(order, func(i, j int) bool { return byID[order[i]].Title < byID[order[j]].Title })
+	out.Listings = make([]ListingAnalytics, 0, len(order))
+	for _, id := range order {
+		a := byID[id]
+		a.Revenue = formatAmounts(r, a.revenue)
+		a.OccupancyRate = occupancy(a.NightsBooked, a.AvailableNights)
+		out.AvailableNights += a.AvailableNights
+		out.Listings = append(out.Listings, a.ListingAnalytics)
+	}
+	out.Revenue = formatAmounts(r, total)
+	out.OccupancyRate = occupancy(out.NightsBooked, out.AvailableNights)
+	return out
+}
+
+func nightsBetween(from, to time.Time) int {
+	if !to.After(from) {
+		return 0
+	}
+	return int(to.Sub(from).Hours() / 24)
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earlier(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// occupancy is booked/available rounded to 4 places, capped at 1.
+func occupancy(booked, available int) float64 {
+	if available <= 0 {
+		return 0
+	}
+	return math.Min(1, math.Round(float64(booked)/float64(available)*10000)/10000)
+}
+
+// hostPayout is b's total minus the platform fee and the refundable deposit.
+func hostPayout(b Booking) (decimal.Decimal, bool) {
+	total, err1 := parseMoney(b.TotalAmount)
+	fee, err2 := parseMoney(b.PlatformFee)
+	deposit, err3 := parseMoney(b.Deposit)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return decimal.Zero, false
+	}
+	return total.Sub(fee).Sub(deposit), true
+}
+
+func formatAmounts(r Rounding, m map[string]decimal.Decimal) map[string]string {
+	out := make(map[string]string, len(m))
+	for cur, v := range m {
+		out[cur] = r.Format(v, cur)
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeHostAnalytics(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	// Window: 10 nights, June 1–10.
+	from, to := day("2026-06-01"), day("2026-06-11")
+	stays := []Booking{
+		// Fully inside: 4 nights, payout 1000 - 100 fee - 200 deposit = 700.
+		{ListingID: "a", CheckIn: "2026-06-02", CheckOut: "2026-06-06", TotalAmount: "1000.00", PlatformFee: "100.00", Deposit: "200.00", Currency: "UZS"},
+		// Straddles the end: 2 of 4 nights inside → half of 400.
+		{ListingID: "b", CheckIn: "2026-06-09", CheckOut: "2026-06-13", TotalAmount: "440.00", PlatformFee: "40.00", Deposit: "0.00", Currency: "UZS"},
+	}
+	capacity := []ListingCapacity{
+		{ListingID: "a", Title: "Alpha", BlockedNights: 2},
+		{ListingID: "b", Title: "Beta"},
+	}
+
+	got := ComputeHostAnalytics(Rounding{}, from, to, stays, capacity)
+
+	if got.Revenue["UZS"] != "900.00" {
+		t.Errorf("revenue: want 900.00, got %v", got.Revenue)
+	}
+	if got.NightsBooked != 6 || got.AvailableNights != 18 {
+		t.Errorf("nights: want 6 booked / 18 available, got %d / %d", got.NightsBooked, got.AvailableNights)
+	}
+	if got.OccupancyRate != 0.3333 {
+		t.Errorf("occupancy: want 0.3333, got %v", got.OccupancyRate)
+	}
+	if len(got.Listings) != 2 || got.Listings[0].ListingID != "a" {
+		t.Fatalf("want listings [a b], got %+v", got.Listings)
+	}
+	a := got.Listings[0]
+	if a.Revenue["UZS"] != "700.00" || a.NightsBooked != 4 || a.AvailableNights != 8 || a.OccupancyRate != 0.5 {
+		t.Errorf("listing a: unexpected %+v", a)
+	}
+}
+
+func TestComputeHostAnalytics_NoCapacity(t *testing.T) {
+	from := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	got := ComputeHostAnalytics(Rounding{}, from, from.AddDate(0, 0, 7), nil, nil)
+	if got.OccupancyRate != 0 || got.NightsBooked != 0 || len(got.Listings) != 0 {
+		t.Errorf("empty: want zero analytics, got %+v", got)
+	}
+}
+
+func TestComputeHostAnalytics_MinorUnits(t *testing.T) {
+	from := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	// 3 of 4 nights inside the window: 3/4 of 100001 = 75000.75.
+	stays := []Booking{
+		{ListingID: "a", CheckIn: "2026-05-31", CheckOut: "2026-06-04", TotalAmount: "100001", PlatformFee: "0", Currency: "UZS"},
+		{ListingID: "a", CheckIn: "2026-06-02", CheckOut: "2026-06-03", TotalAmount: "0.1", Currency: "USD"},
+		{ListingID: "a", CheckIn: "2026-06-02", CheckOut: "2026-06-03", TotalAmount: "0.2", Currency: "USD"},
+	}
+	r := Rounding{MinorUnits: map[string]int{"UZS": 0}}
+	got := ComputeHostAnalytics(r, from, from.AddDate(0, 0, 7), stays, nil)
+	if got.Revenue["UZS"] != "75001" {
+		t.Errorf("UZS revenue: want 75001, got %s", got.Revenue["UZS"])
+	}
+	if got.Revenue["USD"] != "0.30" {
+		t.Errorf("USD revenue: want 0.30, got %s", got.Revenue["USD"])
+	}
+}
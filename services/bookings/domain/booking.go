@@ -1,28 +1,94 @@
 // Package domain defines the core domain types for the bookings service.
 package domain
 
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // Booking represents a reservation on a listing.
 type Booking struct {
-	ID                 string  `json:"id"`
-	ListingID          string  `json:"listingId"`
-	GuestID            string  `json:"guestId"`
-	HostID             string  `json:"hostId"`
-	CheckIn            string  `json:"checkIn"`
-	CheckOut           string  `json:"checkOut"`
-	Guests             int     `json:"guests"`
-	TotalAmount        string  `json:"totalAmount"`
-	PlatformFee        string  `json:"platformFee"`
-	CleaningFee        string  `json:"cleaningFee"`
-	Currency           string  `json:"currency"`
-	Status             string  `json:"status"`
-	CancellationPolicy string  `json:"cancellationPolicy"`
-	Message            string  `json:"message,omitempty"`
-	CheckoutID         *string `json:"checkoutId,omitempty"`
-	ApprovedAt         *int64  `json:"approvedAt,omitempty"`
-	ExpiresAt          *int64  `json:"expiresAt,omitempty"`
-	PaymentID          *string `json:"paymentId,omitempty"`
-	CreatedAt          int64   `json:"createdAt"`
-	UpdatedAt          int64   `json:"updatedAt"`
+	ID        string `json:"id"`
+	ListingID string `json:"listingId"`
+	GuestID   string `json:"guestId"`
+	// GuestEmail is captured from the guest's session at creation for the
+	// confirmation event; it is never returned by the API.
+	GuestEmail  string `json:"-"`
+	HostID      string `json:"hostId"`
+	CheckIn     string `json:"checkIn"`
+	CheckOut    string `json:"checkOut"`
+	Guests      int    `json:"guests"`
+	TotalAmount string `json:"totalAmount"`
+	PlatformFee string `json:"platformFee"`
+	CleaningFee string `json:"cleaningFee"`
+	Deposit     string `json:"deposit"` // refundable security deposit, included in TotalAmount
+	Currency    string `json:"currency"`
+	// Breakdown is the line-item pricing captured at creation; nil for
+	// bookings created before it was stored.
+	Breakdown          *Breakdown `json:"breakdown,omitempty"`
+	Status             string     `json:"status"`
+	PaymentStatus      string     `json:"paymentStatus"` // none|pending|captured|failed|partially_refunded|refunded|refund_due|on_arrival
+	CancellationPolicy string     `json:"cancellationPolicy"`
+	// RefundedAmount is the total of the settled refunds; "0" until one
+	// settles.
+	RefundedAmount string `json:"refundedAmount"`
+	// CancellationTiers is the policy's definition captured at creation, so
+	// later catalog edits don't change the refund; nil for bookings created
+	// before it was stored, which fall back to the built-in of that name.
+	CancellationTiers []RefundTier `json:"cancellationTiers,omitempty"`
+	Message           string       `json:"message,omitempty"`
+	CheckoutID        *string      `json:"checkoutId,omitempty"`
+	ApprovedAt        *int64       `json:"approvedAt,omitempty"`
+	ExpiresAt         *int64       `json:"expiresAt,omitempty"`
+	// PaymentWindowMinutes is the listing's payment window captured at
+	// creation; expiresAt is derived from it once the booking is payment_pending.
+	PaymentWindowMinutes int `json:"paymentWindowMinutes"`
+	// PaymentGraceMinutes is the tenant's late-payment grace captured at
+	// creation: a payment captured this long after expiresAt still confirms.
+	PaymentGraceMinutes int `json:"paymentGraceMinutes"`
+	// FreeCancellationUntil is the last moment (unix seconds) a guest can
+	// cancel for a full refund; null for policies without a free window.
+	FreeCancellationUntil *int64 `json:"freeCancellationUntil"`
+	// HoldRemainingSeconds is computed on read: time left before an unpaid
+	// payment_pending booking expires and its dates are released.
+	HoldRemainingSeconds *int64  `json:"holdRemainingSeconds,omitempty"`
+	PaymentID            *string `json:"paymentId,omitempty"`
+	CreatedAt            int64   `json:"createdAt"`
+	UpdatedAt            int64   `json:"updatedAt"`
+	// Listing is embedded only when requested with ?expand=listing.
+	Listing *ListingSummary `json:"listing,omitempty"`
+}
+
+// Policy returns the cancellation policy the booking was made under.
+func (b Booking) Policy() CancellationPolicy {
+	if b.CancellationTiers != nil {
+		return CancellationPolicy{Name: b.CancellationPolicy, Tiers: b.CancellationTiers}
+	}
+	return ResolvePolicy(b.CancellationPolicy, nil)
+}
+
+// CanReview reports whether userID may review the stay: only the guest, and
+// only once it is completed. Whether a review already exists is up to the
+// caller to check.
+func (b Booking) CanReview(userID string) bool {
+	return b.Status == StatusCompleted && userID == b.GuestID
+}
+
+// Summary is the post-stay view of a booking: the booking with its listing
+// embedded, and whether the caller can still leave a review.
+type Summary struct {
+	Booking   Booking `json:"booking"`
+	CanReview bool    `json:"canReview"`
+}
+
+// ListingSummary is the slice of a listing embedded in expanded bookings.
+type ListingSummary struct {
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	City       string `json:"city"`
+	CoverPhoto string `json:"coverPhoto,omitempty"`
 }
 
 // Booking status constants — the full lifecycle state machine.
@@ -35,26 +101,195 @@ const (
 	StatusRejected            = "rejected"
 	StatusFailed              = "failed"
 	StatusCompleted           = "completed"
+	StatusExpired             = "expired" // payment window lapsed; dates released
+	StatusNoShow              = "no_show" // guest never arrived; host marked it
+)
+
+// Reasons CheckNoShow refuses a no-show.
+var (
+	ErrNoShowNotHost       = errors.New("only the host can mark a no-show")
+	ErrNoShowBeforeCheckIn = errors.New("a no-show can only be marked after check-in")
+)
+
+// CheckNoShow reports whether userID may mark b a no-show at now, given
+// when check-in was: only the host, and only once check-in has passed.
+// The booking's status is checked separately via CanTransition.
+func (b Booking) CheckNoShow(userID string, checkInAt, now time.Time) error {
+	if userID != b.HostID {
+		return ErrNoShowNotHost
+	}
+	if now.Before(checkInAt) {
+		return ErrNoShowBeforeCheckIn
+	}
+	return nil
+}
+
+// ErrCompleteBeforeCheckOut is returned by CheckComplete while the stay is
+// still in progress.
+var ErrCompleteBeforeCheckOut = errors.New("a booking can only be completed once check-out has passed")
+
+// CheckComplete reports whether b's stay is over at now: its check-out date
+// has arrived (UTC), as the completion sweep judges it. The booking's status
+// is checked separately via CanTransition.
+func (b Booking) CheckComplete(now time.Time) error {
+	if b.CheckOut > now.UTC().Format("2006-01-02") {
+		return ErrCompleteBeforeCheckOut
+	}
+	return nil
+}
+
+// SetHoldRemaining fills HoldRemainingSeconds for a payment_pending booking.
+// The hold is the reservation TTL: dates stay reserved until ExpiresAt, after
+// which the expiry worker releases them. Other statuses carry no hold.
+func (b *Booking) SetHoldRemaining(now int64) {
+	b.HoldRemainingSeconds = nil
+	if b.Status != StatusPaymentPending || b.ExpiresAt == nil {
+		return
+	}
+	left := *b.ExpiresAt - now
+	if left < 0 {
+		left = 0
+	}
+	b.HoldRemainingSeconds = &left
+}
+
+// PaymentLate reports whether a payment arriving at now is too late to
+// confirm the booking: its payment window plus grace has closed.
+func (b Booking) PaymentLate(now int64) bool {
+	return b.ExpiresAt != nil && now >= *b.ExpiresAt+int64(b.PaymentGraceMinutes)*60
+}
+
+// LateCapture reports whether money captured at now arrived after the
+// booking stopped waiting for it: it has expired, or is still
+// payment_pending past its window and grace. Such a payment can't confirm
+// the booking and has to be refunded.
+func (b Booking) LateCapture(now int64) bool {
+	switch b.Status {
+	case StatusExpired:
+		return true
+	case StatusPaymentPending:
+		return b.PaymentLate(now)
+	}
+	return false
+}
+
+// Payment status constants. These track the money, independently of the
+// booking lifecycle: a payment_pending booking may have no payment yet
+// (none) or one that Mashgate is still processing (pending).
+const (
+	PaymentNone     = "none"
+	PaymentPending  = "pending"
+	PaymentCaptured = "captured"
+	PaymentFailed   = "failed"
+	PaymentRefunded = "refunded"
+	// PaymentPartiallyRefunded marks a captured payment some, but not all,
+	// of which has been refunded.
+	PaymentPartiallyRefunded = "partially_refunded"
+	// PaymentRefundDue marks money captured after the booking's payment
+	// window closed; the booking was not confirmed and the payment awaits a
+	// manual refund.
+	PaymentRefundDue = "refund_due"
+	// PaymentOnArrival marks a booking the guest pays the host in person;
+	// there is no checkout and webhooks never change it.
+	PaymentOnArrival = "on_arrival"
 )
 
-// ListingInfo holds the fields fetched from the listings service at booking creation time.
+// paymentStatusFrom lists, for each payment status, the statuses it may be
+// reached from. Webhooks can arrive out of order, so a late event must not
+// move a payment backwards (e.g. checkout.completed after payment.captured).
+var paymentStatusFrom = map[string][]string{
+	PaymentPending:           {PaymentNone, PaymentFailed},
+	PaymentCaptured:          {PaymentNone, PaymentPending, PaymentFailed},
+	PaymentFailed:            {PaymentNone, PaymentPending},
+	PaymentRefunded:          {PaymentCaptured, PaymentRefundDue, PaymentPartiallyRefunded},
+	PaymentPartiallyRefunded: {PaymentCaptured, PaymentRefundDue, PaymentPartiallyRefunded},
+}
+
+// PaymentStatusPredecessors returns the statuses from which to may be set.
+// It returns nil for unknown targets (including none, which is never set).
+func PaymentStatusPredecessors(to string) []string {
+	return paymentStatusFrom[to]
+}
+
+// InitialStatus picks a new booking's status and payment status. Without
+// instant book the host approves first. Instant bookings await payment unless
+// the stay is free or the listing opted into payment on arrival; both are
+// confirmed straight away.
+func InitialStatus(instantBook, payOnArrival, free bool) (status, paymentStatus string) {
+	switch {
+	case !instantBook:
+		return StatusPendingHostApproval, PaymentNone
+	case free:
+		return StatusConfirmed, PaymentNone
+	case payOnArrival:
+		return StatusConfirmed, PaymentOnArrival
+	}
+	return StatusPaymentPending, PaymentNone
+}
+
+// IsFreeStay reports whether a booking total (fees included) is exactly
+// zero, so there is nothing to collect.
+func IsFreeStay(total string) bool {
+	f, err := strconv.ParseFloat(strings.TrimSpace(total), 64)
+	return err == nil && f == 0
+}
+
+// DefaultGuests is used when a booking request omits guests or sends 0.
+const DefaultGuests = 1
+
+// ErrNegativeGuests is returned by NormalizeGuests for a negative count.
+var ErrNegativeGuests = errors.New("guests must be at least 1")
+
+// NormalizeGuests applies DefaultGuests to a missing (zero) guest count and
+// rejects negative ones. The listing's capacity is checked separately.
+func NormalizeGuests(n int) (int, error) {
+	switch {
+	case n < 0:
+		return 0, ErrNegativeGuests
+	case n == 0:
+		return DefaultGuests, nil
+	}
+	return n, nil
+}
+
+// ListingInfo holds the fields fetched from the listings service at booking
+// creation time. Prices are deliberately absent: amounts come from Quote.
 type ListingInfo struct {
 	ID                 string
+	Title              string
 	HostID             string
 	InstantBook        bool
 	CancellationPolicy string
-	PricePerNight      string
-	CleaningFee        string
-	Currency           string
 	MinNights          int
 	MaxNights          int
 	MaxGuests          int
 	Status             string
+	// PaymentWindowMinutes is the host override; 0 means use the platform default.
+	PaymentWindowMinutes int
+	// PayOnArrival lets instant bookings confirm without online payment.
+	PayOnArrival bool
+	// Timezone (IANA, empty = UTC) and CheckInFrom (HH:MM) place check-in
+	// in time for the guest cancellation cutoff.
+	Timezone    string
+	CheckInFrom string
+}
+
+// Quote is the listings service's price for a stay. Subtotal is the sum of
+// the per-night prices, so host price overrides are already applied.
+type Quote struct {
+	Nights      int    `json:"nights"`
+	Subtotal    string `json:"subtotal"`
+	CleaningFee string `json:"cleaningFee"`
+	Deposit     string `json:"deposit"`
+	Total       string `json:"total"`
+	Currency    string `json:"currency"`
 }
 
 // RefundResult holds the calculated refund amount for a cancellation.
+// RefundAmount includes DepositRefund; RefundPct applies to the stay portion only.
 type RefundResult struct {
-	RefundAmount string `json:"refundAmount"`
-	RefundPct    int    `json:"refundPct"` // 0, 50, or 100
-	Currency     string `json:"currency"`
+	RefundAmount  string `json:"refundAmount"`
+	RefundPct     int    `json:"refundPct"` // 0–100, from the policy's tiers
+	DepositRefund string `json:"depositRefund"`
+	Currency      string `json:"currency"`
 }
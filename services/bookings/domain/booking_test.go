@@ -0,0 +1,191 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSetHoldRemaining(t *testing.T) {
+	exp := int64(1000)
+	tests := []struct {
+		name   string
+		status string
+		now    int64
+		want   *int64
+	}{
+		{"pending", StatusPaymentPending, 400, ptr(600)},
+		{"lapsed clamps to zero", StatusPaymentPending, 1200, ptr(0)},
+		{"confirmed has no hold", StatusConfirmed, 400, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := Booking{Status: tt.status, ExpiresAt: &exp}
+			b.SetHoldRemaining(tt.now)
+			switch {
+			case tt.want == nil && b.HoldRemainingSeconds != nil:
+				t.Errorf("want no hold, got %d", *b.HoldRemainingSeconds)
+			case tt.want != nil && (b.HoldRemainingSeconds == nil || *b.HoldRemainingSeconds != *tt.want):
+				t.Errorf("want %d, got %v", *tt.want, b.HoldRemainingSeconds)
+			}
+		})
+	}
+}
+
+func ptr(n int64) *int64 { return &n }
+
+func TestIsFreeStay(t *testing.T) {
+	for total, want := range map[string]bool{
+		"0.00":   true,
+		"0":      true,
+		"0.01":   false,
+		"100.00": false,
+		"":       false,
+		"free":   false,
+	} {
+		if got := IsFreeStay(total); got != want {
+			t.Errorf("IsFreeStay(%q) = %v, want %v", total, got, want)
+		}
+	}
+}
+
+func TestInitialStatus(t *testing.T) {
+	tests := []struct {
+		name                      string
+		instant, onArrival, free  bool
+		wantStatus, wantPayStatus string
+	}{
+		{"request to book", false, false, false, StatusPendingHostApproval, PaymentNone},
+		{"request to book ignores on arrival", false, true, false, StatusPendingHostApproval, PaymentNone},
+		{"instant", true, false, false, StatusPaymentPending, PaymentNone},
+		{"instant pay on arrival", true, true, false, StatusConfirmed, PaymentOnArrival},
+		{"instant free", true, false, true, StatusConfirmed, PaymentNone},
+		{"free wins over on arrival", true, true, true, StatusConfirmed, PaymentNone},
+	}
+	for _, tt := range tests {
+		status, pay := InitialStatus(tt.instant, tt.onArrival, tt.free)
+		if status != tt.wantStatus || pay != tt.wantPayStatus {
+			t.Errorf("%s: got (%s, %s), want (%s, %s)", tt.name, status, pay, tt.wantStatus, tt.wantPayStatus)
+		}
+	}
+}
+
+func TestNormalizeGuests(t *testing.T) {
+	if got, err := NormalizeGuests(0); err != nil || got != DefaultGuests {
+		t.Errorf("NormalizeGuests(0) = %d, %v; want %d, nil", got, err, DefaultGuests)
+	}
+	if got, err := NormalizeGuests(3); err != nil || got != 3 {
+		t.Errorf("NormalizeGuests(3) = %d, %v; want 3, nil", got, err)
+	}
+	if _, err := NormalizeGuests(-1); !errors.Is(err, ErrNegativeGuests) {
+		t.Errorf("NormalizeGuests(-1) err = %v, want ErrNegativeGuests", err)
+	}
+}
+
+func TestBookingCanReview(t *testing.T) {
+	b := Booking{GuestID: "guest", HostID: "host", Status: StatusCompleted}
+	if !b.CanReview("guest") {
+		t.Error("guest of a completed stay should be able to review")
+	}
+	if b.CanReview("host") {
+		t.Error("host should not be able to review their own listing")
+	}
+	b.Status = StatusConfirmed
+	if b.CanReview("guest") {
+		t.Error("a stay that hasn't completed should not be reviewable")
+	}
+}
+
+func TestPaymentLate(t *testing.T) {
+	exp := int64(10_000)
+	tests := []struct {
+		name  string
+		grace int
+		now   int64
+		want  bool
+	}{
+		{"within window", 0, exp - 1, false},
+		{"at expiry without grace", 0, exp, true},
+		{"within grace", 5, exp + 4*60, false},
+		{"past grace", 5, exp + 5*60, true},
+	}
+	for _, tt := range tests {
+		b := Booking{ExpiresAt: &exp, PaymentGraceMinutes: tt.grace}
+		if got := b.PaymentLate(tt.now); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if (Booking{}).PaymentLate(exp) {
+		t.Error("a booking without a payment window is never late")
+	}
+}
+
+func TestLateCapture(t *testing.T) {
+	exp := int64(10_000)
+	tests := []struct {
+		name   string
+		status string
+		now    int64
+		want   bool
+	}{
+		{"pending within window", StatusPaymentPending, exp - 1, false},
+		{"pending past window", StatusPaymentPending, exp, true},
+		{"expired by the sweep", StatusExpired, exp - 1, true},
+		{"already confirmed", StatusConfirmed, exp + 60, false},
+		{"cancelled", StatusCancelledByGuest, exp + 60, false},
+	}
+	for _, tt := range tests {
+		b := Booking{Status: tt.status, ExpiresAt: &exp}
+		if got := b.LateCapture(tt.now); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if got := PaymentStatusPredecessors(PaymentRefunded); !slices.Contains(got, PaymentRefundDue) {
+		t.Errorf("refunded predecessors = %v, want refund_due among them", got)
+	}
+	if slices.Contains(PaymentStatusPredecessors(PaymentCaptured), PaymentRefundDue) {
+		t.Error("a replayed capture must not clear refund_due")
+	}
+}
+
+func TestCheckNoShow(t *testing.T) {
+	b := Booking{HostID: "host", GuestID: "guest"}
+	checkIn := time.Date(2026, 7, 1, 14, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		user string
+		now  time.Time
+		want error
+	}{
+		{"host after check-in", "host", checkIn.Add(time.Hour), nil},
+		{"host at check-in", "host", checkIn, nil},
+		{"host before check-in", "host", checkIn.Add(-time.Minute), ErrNoShowBeforeCheckIn},
+		{"guest after check-in", "guest", checkIn.Add(time.Hour), ErrNoShowNotHost},
+		{"stranger", "someone", checkIn.Add(time.Hour), ErrNoShowNotHost},
+	}
+	for _, tt := range tests {
+		if got := b.CheckNoShow(tt.user, checkIn, tt.now); !errors.Is(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckComplete(t *testing.T) {
+	b := Booking{CheckIn: "2026-07-01", CheckOut: "2026-07-04"}
+	tests := []struct {
+		name string
+		now  time.Time
+		want error
+	}{
+		{"mid-stay", time.Date(2026, 7, 3, 23, 0, 0, 0, time.UTC), ErrCompleteBeforeCheckOut},
+		{"before check-in", time.Date(2026, 6, 20, 12, 0, 0, 0, time.UTC), ErrCompleteBeforeCheckOut},
+		{"check-out day", time.Date(2026, 7, 4, 0, 0, 0, 0, time.UTC), nil},
+		{"after check-out", time.Date(2026, 7, 10, 9, 0, 0, 0, time.UTC), nil},
+	}
+	for _, tt := range tests {
+		if got := b.CheckComplete(tt.now); !errors.Is(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// HostBookingFilter narrows a host's booking list. Empty fields don't
+// filter; From and To bound check-in as [From, To).
+type HostBookingFilter struct {
+	ListingID string
+	From      string // YYYY-MM-DD
+	To        string // YYYY-MM-DD
+	Status    string
+	Limit     int
+	Offset    int
+}
+
+// knownStatuses is every booking status, for filter validation.
+var knownStatuses = map[string]bool{
+	StatusPendingHostApproval: true, StatusPaymentPending: true, StatusConfirmed: true,
+	StatusCancelledByGuest: true, StatusCancelledByHost: true, StatusRejected: true,
+	StatusFailed: true, StatusCompleted: true, StatusExpired: true, StatusNoShow: true,
+}
+
+// Validate returns a caller-facing error for malformed dates, an empty
+// range or an unknown status.
+func (f HostBookingFilter) Validate() error {
+	var from, to time.Time
+	var err error
+	if f.From != "" {
+		if from, err = time.Parse("2006-01-02", f.From); err != nil {
+			return errors.New("from must be a YYYY-MM-DD date")
+		}
+	}
+	if f.To != "" {
+		if to, err = time.Parse("2006-01-02", f.To); err != nil {
+			return errors.New("to must be a YYYY-MM-DD date")
+		}
+	}
+	if f.From != "" && f.To != "" && !to.After(from) {
+		return errors.New("to must be after from")
+	}
+	if f.Status != "" && !knownStatuses[f.Status] {
+		return errors.New("unknown status: " + f.Status)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "testing"
+
+func TestHostBookingFilterValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		f       HostBookingFilter
+		wantErr bool
+	}{
+		{"empty", HostBookingFilter{}, false},
+		{"full", HostBookingFilter{ListingID: "l1", From: "2026-06-01", To: "2026-07-01", Status: StatusConfirmed}, false},
+		{"open-ended from", HostBookingFilter{From: "2026-06-01"}, false},
+		{"bad from", HostBookingFilter{From: "06/01/2026"}, true},
+		{"bad to", HostBookingFilter{To: "2026-13-01"}, true},
+		{"empty range", HostBookingFilter{From: "2026-06-01", To: "2026-06-01"}, true},
+		{"unknown status", HostBookingFilter{Status: "pending"}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.f.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/saidmashhud/zist/internal/money"
+	"github.com/shopspring/decimal"
+)
+
+// AmountLimits bounds a booking's total. Each bound is a decimal string;
+// empty means unbounded.
+type AmountLimits struct {
+	Min string
+	Max string
+}
+
+// Check returns a caller-facing error if total falls outside the limits,
+// comparing exact decimals and quoting amounts in currency's minor units.
+// Unparseable bounds are ignored rather than blocking bookings.
+func (l AmountLimits) Check(r Rounding, total, currency string) error {
+	t, err := money.Parse(total)
+	if err != nil {
+		return err
+	}
+	if lo, ok := parseBound(l.Min); ok && t.Cmp(lo) < 0 {
+		return fmt.Errorf("booking total %s %s is below the minimum of %s",
+			r.Format(t, currency), currency, r.Format(lo, currency))
+	}
+	if hi, ok := parseBound(l.Max); ok && t.Cmp(hi) > 0 {
+		return fmt.Errorf("booking total %s %s exceeds the maximum of %s",
+			r.Format(t, currency), currency, r.Format(hi, currency))
+	}
+	return nil
+}
+
+func parseBound(s string) (decimal.Decimal, bool) {
+	if strings.TrimSpace(s) == "" {
+		return decimal.Zero, false
+	}
+	d, err := money.Parse(s)
+	return d, err == nil
+}
+
+// CheckCurrency returns a caller-facing error if currency is not among the
+// tenant's supported codes. An empty list accepts every currency.
+func CheckCurrency(supported []string, currency string) error {
+	if len(supported) == 0 {
+		return nil
+	}
+	for _, c := range supported {
+		if strings.EqualFold(c, currency) {
+			return nil
+		}
+	}
+	return fmt.Errorf("currency %s is not supported by this tenant", strings.ToUpper(currency))
+}
+
+// CheckPhotos returns a caller-facing error if a listing with count photos
+// falls short of the tenant's minimum.
+func CheckPhotos(count, min int) error {
+	if count < min {
+		return fmt.Errorf("listing needs at least %d photos to be booked; it has %d", min, count)
+	}
+	return nil
+}
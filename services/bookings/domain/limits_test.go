@@ -0,0 +1,61 @@
+package domain
+
+import "testing"
+
+func TestAmountLimitsCheck(t *testing.T) {
+	limits := AmountLimits{Min: "1000.00", Max: "50000000.00"}
+	tests := []struct {
+		name    string
+		limits  AmountLimits
+		total   string
+		wantErr bool
+	}{
+		{"within range", limits, "250000.00", false},
+		{"below min", limits, "0.00", true},
+		{"above max", limits, "75000000.00", true},
+		{"at bounds", limits, "1000", false},
+		{"no limits", AmountLimits{}, "0", false},
+		{"max only", AmountLimits{Max: "100"}, "100.01", true},
+		{"malformed bound ignored", AmountLimits{Min: "abc"}, "0", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limits.Check(Rounding{}, tt.total, "UZS")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check(%s): want error=%v, got %v", tt.total, tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestAmountLimitsMessageMinorUnits(t *testing.T) {
+	r := Rounding{MinorUnits: map[string]int{"UZS": 0}}
+	err := AmountLimits{Min: "100000"}.Check(r, "99999", "UZS")
+	if err == nil || err.Error() != "booking total 99999 UZS is below the minimum of 100000" {
+		t.Errorf("got %v, want amounts in whole sum", err)
+	}
+}
+
+func TestCheckCurrency(t *testing.T) {
+	if err := CheckCurrency([]string{"UZS"}, "USD"); err == nil {
+		t.Error("UZS-only: want USD rejected")
+	}
+	if err := CheckCurrency([]string{"UZS", "USD"}, "usd"); err != nil {
+		t.Errorf("case-insensitive match: %v", err)
+	}
+	if err := CheckCurrency(nil, "EUR"); err != nil {
+		t.Errorf("empty list: want any currency, got %v", err)
+	}
+}
+
+func TestCheckPhotos(t *testing.T) {
+	if err := CheckPhotos(2, 3); err == nil {
+		t.Error("2 photos under a minimum of 3: want error")
+	}
+	if err := CheckPhotos(3, 3); err != nil {
+		t.Errorf("3 photos at a minimum of 3: %v", err)
+	}
+	if err := CheckPhotos(0, 0); err != nil {
+		t.Errorf("no minimum: %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// DefaultMaxMessageLength caps the guest's note to the host on a booking
+// request, in characters.
+const DefaultMaxMessageLength = 2000
+
+// CheckMessage returns a caller-facing error if msg is longer than max
+// characters. Length is counted in runes so Cyrillic and Uzbek text get the
+// same allowance as ASCII. A non-positive max disables the check.
+func CheckMessage(msg string, max int) error {
+	if max <= 0 {
+		return nil
+	}
+	if n := utf8.RuneCountInString(msg); n > max {
+		return fmt.Errorf("message is %d characters; the maximum is %d", n, max)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     string
+		max     int
+		wantErr bool
+	}{
+		{"empty", "", 10, false},
+		{"at limit", strings.Repeat("a", 10), 10, false},
+		{"oversize", strings.Repeat("a", 11), 10, true},
+		{"counts runes not bytes", strings.Repeat("ж", 10), 10, false},
+		{"disabled", strings.Repeat("a", 5000), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckMessage(tt.msg, tt.max)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckMessage(len %d, %d): want error=%v, got %v", len(tt.msg), tt.max, tt.wantErr, err)
+			}
+		})
+	}
+}
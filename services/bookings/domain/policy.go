@@ -2,57 +2,164 @@ package domain
 
 import (
 	"fmt"
-	"math"
-	"strconv"
-	"strings"
 	"time"
+
+	"github.com/saidmashhud/zist/internal/tenantconfig"
+	"github.com/shopspring/decimal"
 )
 
-// CalculateRefund returns the refund amount based on cancellation policy and time until check-in.
+// RefundTier refunds RefundPct percent of the stay to guests who cancel at
+// least HoursBefore hours before check-in. Tenant catalogs use the same tiers.
+type RefundTier = tenantconfig.RefundTier
+
+// CancellationPolicy is a named set of refund tiers. A cancellation gets the
+// best refund among the tiers it is early enough for, or nothing.
+type CancellationPolicy struct {
+	Name  string       `json:"name"`
+	Tiers []RefundTier `json:"tiers"`
+}
+
+// ResolvePolicy returns the named policy: a built-in, else one from the
+// tenant's catalog. Unknown names get a policy with no tiers (no refund).
+func ResolvePolicy(name string, catalog []CancellationPolicy) CancellationPolicy {
+	p, _ := LookupPolicy(name, catalog)
+	return p
+}
+
+// LookupPolicy is ResolvePolicy that also reports whether name was found,
+// so a booking isn't made under a policy that can't be read.
+func LookupPolicy(name string, catalog []CancellationPolicy) (CancellationPolicy, bool) {
+	if p, ok := tenantconfig.BuiltinPolicy(name); ok {
+		return CancellationPolicy(p), true
+	}
+	for _, p := range catalog {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return CancellationPolicy{Name: name}, false
+}
+
+// FreeCancellationUntil returns the last moment (unix seconds) a guest can
+// cancel for a full refund, or nil if the policy has no free window (strict).
+// checkInAt is the stay's check-in time; see CheckInTime.
+func FreeCancellationUntil(policy string, checkInAt time.Time) *int64 {
+	return ResolvePolicy(policy, nil).FreeCancellationUntil(checkInAt)
+}
+
+// FreeCancellationUntil returns the last moment (unix seconds) a guest can
+// cancel under p for a full refund, or nil if no tier refunds in full.
+func (p CancellationPolicy) FreeCancellationUntil(checkInAt time.Time) *int64 {
+	hours := -1
+	for _, t := range p.Tiers {
+		if t.RefundPct >= 100 && (hours < 0 || t.HoursBefore < hours) {
+			hours = t.HoursBefore
+		}
+	}
+	if hours < 0 {
+		return nil
+	}
+	until := checkInAt.Add(-time.Duration(hours) * time.Hour).Unix()
+	return &until
+}
+
+// refundPct is the refund percentage under p for cancelling at now.
+func (p CancellationPolicy) refundPct(checkInAt, now time.Time) int {
+	var pct int
+	for _, t := range p.Tiers {
+		deadline := checkInAt.Add(-time.Duration(t.HoursBefore) * time.Hour)
+		if !now.After(deadline) && t.RefundPct > pct {
+			pct = min(t.RefundPct, 100)
+		}
+	}
+	return pct
+}
+
+// CheckInTime is when a stay begins: checkInFrom (HH:MM, midnight if empty)
+// on the check-in date in the listing's timezone (UTC if empty or unknown).
+// Refund tiers, the free cancellation window and the guest cancellation
+// cutoff all count back from it.
+func CheckInTime(checkIn, checkInFrom, timezone string) (time.Time, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	day, err := time.ParseInLocation("2006-01-02", checkIn, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid check_in date: %w", err)
+	}
+	if t, err := time.Parse("15:04", checkInFrom); err == nil {
+		day = day.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute)
+	}
+	return day, nil
+}
+
+// CancellationCutoff returns the last moment a guest may cancel: before
+// ahead of CheckInTime.
+func CancellationCutoff(checkIn, checkInFrom, timezone string, before time.Duration) (time.Time, error) {
+	checkInAt, err := CheckInTime(checkIn, checkInFrom, timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return checkInAt.Add(-before), nil
+}
+
+// CalculateRefund returns the refund amount under a built-in cancellation
+// policy for cancelling now, rounded half up to two places. checkInAt is the
+// stay's check-in time; see CheckInTime.
 //
 // Policies:
 //
 //	flexible:  ≥ 24h before check-in → 100%  |  < 24h → 0%
 //	moderate:  ≥ 5 days → 100%  |  1–4 days (≥ 24h) → 50%  |  < 24h → 0%
 //	strict:    ≥ 14 days → 50%  |  < 14 days → 0%
-func CalculateRefund(policy, totalAmount, currency, checkIn string) (RefundResult, error) {
-	checkInDate, err := time.Parse("2006-01-02", checkIn)
-	if err != nil {
-		return RefundResult{}, fmt.Errorf("invalid check_in date: %w", err)
-	}
+//
+// A full refund is given exactly up to FreeCancellationUntil.
+// The refundable deposit is carved out of totalAmount before the policy is
+// applied and is always returned in full.
+func CalculateRefund(policy, totalAmount, deposit, currency string, checkInAt time.Time) (RefundResult, error) {
+	return calculateRefundAt(policy, totalAmount, deposit, currency, checkInAt, time.Now())
+}
+
+func calculateRefundAt(policy, totalAmount, deposit, currency string, checkInAt, now time.Time) (RefundResult, error) {
+	return ResolvePolicy(policy, nil).refundAt(Rounding{}, totalAmount, deposit, currency, checkInAt, now)
+}
 
-	hoursUntil := time.Until(checkInDate).Hours()
-	daysUntil := hoursUntil / 24.0
+// Refund returns the refund under p for cancelling now, rounded with r; see
+// CalculateRefund.
+func (p CancellationPolicy) Refund(r Rounding, totalAmount, deposit, currency string, checkInAt time.Time) (RefundResult, error) {
+	return p.refundAt(r, totalAmount, deposit, currency, checkInAt, time.Now())
+}
 
-	total, err := strconv.ParseFloat(strings.TrimSpace(totalAmount), 64)
+func (p CancellationPolicy) refundAt(r Rounding, totalAmount, deposit, currency string, checkInAt, now time.Time) (RefundResult, error) {
+	pct := p.refundPct(checkInAt, now)
+	refund, dep, err := r.Refund(totalAmount, deposit, currency, pct)
 	if err != nil {
-		return RefundResult{}, fmt.Errorf("invalid total_amount: %w", err)
+		return RefundResult{}, err
 	}
+	return RefundResult{
+		RefundAmount:  refund,
+		RefundPct:     pct,
+		DepositRefund: dep,
+		Currency:      currency,
+	}, nil
+}
 
-	var pct int
-	switch policy {
-	case "flexible":
-		if hoursUntil >= 24 {
-			pct = 100
-		}
-	case "moderate":
-		if daysUntil >= 5 {
-			pct = 100
-		} else if hoursUntil >= 24 {
-			pct = 50
-		}
-	case "strict":
-		if daysUntil >= 14 {
-			pct = 50
-		}
-	default:
-		pct = 0
+// FullRefund returns a 100% refund of totalAmount, with the deposit broken out.
+// Used for host cancellations.
+func FullRefund(r Rounding, totalAmount, deposit, currency string) RefundResult {
+	_, dep, _ := r.Refund(totalAmount, deposit, currency, 100)
+	return RefundResult{
+		RefundAmount:  totalAmount,
+		RefundPct:     100,
+		DepositRefund: dep,
+		Currency:      currency,
 	}
+}
 
-	refund := math.Round(total*float64(pct)) / 100.0
-	return RefundResult{
-		RefundAmount: fmt.Sprintf("%.2f", refund),
-		RefundPct:    pct,
-		Currency:     currency,
-	}, nil
+// NoRefund is the refund for a booking nothing was charged for, such as one
+// paid on arrival.
+func NoRefund(r Rounding, currency string) RefundResult {
+	zero := r.Format(decimal.Zero, currency)
+	return RefundResult{RefundAmount: zero, DepositRefund: zero, Currency: currency}
 }
@@ -0,0 +1,230 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculateRefund_DepositAlwaysRefunded(t *testing.T) {
+	soon := time.Now().Add(2 * time.Hour)
+	far := time.Now().AddDate(0, 0, 30)
+
+	tests := []struct {
+		name       string
+		policy     string
+		checkIn    time.Time
+		wantAmount string
+		wantPct    int
+	}{
+		// total 1150.00 = 1000.00 stay + 150.00 deposit
+		{"flexible early", "flexible", far, "1150.00", 100},
+		{"flexible late", "flexible", soon, "150.00", 0},
+		{"strict early", "strict", far, "650.00", 50},
+		{"strict late", "strict", soon, "150.00", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CalculateRefund(tt.policy, "1150.00", "150.00", "UZS", tt.checkIn)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.RefundAmount != tt.wantAmount {
+				t.Errorf("refundAmount: want %s, got %s", tt.wantAmount, got.RefundAmount)
+			}
+			if got.RefundPct != tt.wantPct {
+				t.Errorf("refundPct: want %d, got %d", tt.wantPct, got.RefundPct)
+			}
+			if got.DepositRefund != "150.00" {
+				t.Errorf("depositRefund: want 150.00, got %s", got.DepositRefund)
+			}
+		})
+	}
+}
+
+func TestCalculateRefund_NoDeposit(t *testing.T) {
+	far := time.Now().AddDate(0, 0, 30)
+	got, err := CalculateRefund("strict", "1000.00", "", "UZS", far)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.RefundAmount != "500.00" || got.DepositRefund != "0.00" {
+		t.Errorf("want 500.00/0.00, got %s/%s", got.RefundAmount, got.DepositRefund)
+	}
+}
+
+func TestFullRefund_BreaksOutDeposit(t *testing.T) {
+	got := FullRefund(Rounding{}, "1150.00", "150.00", "UZS")
+	if got.RefundAmount != "1150.00" || got.RefundPct != 100 || got.DepositRefund != "150.00" {
+		t.Errorf("unexpected full refund: %+v", got)
+	}
+}
+
+func TestRefund_MinorUnits(t *testing.T) {
+	// strict gives 50% a month out: 50% of 1001 sum is 500.5, which is 500
+	// with banker's rounding in whole sum. The deposit is formatted the same.
+	r := Rounding{Mode: RoundHalfEven, MinorUnits: map[string]int{"UZS": 0}}
+	far := time.Now().AddDate(0, 0, 30)
+	got, err := ResolvePolicy("strict", nil).Refund(r, "1201", "200", "UZS", far)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.RefundAmount != "700" || got.DepositRefund != "200" {
+		t.Errorf("want 700/200, got %s/%s", got.RefundAmount, got.DepositRefund)
+	}
+	if got := NoRefund(r, "UZS"); got.RefundAmount != "0" {
+		t.Errorf("NoRefund = %s, want 0", got.RefundAmount)
+	}
+}
+
+func TestFreeCancellationUntil(t *testing.T) {
+	checkIn := time.Date(2026, 7, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		policy string
+		want   *time.Time
+	}{
+		{"flexible", ptrTime(checkIn.Add(-24 * time.Hour))},
+		{"moderate", ptrTime(checkIn.AddDate(0, 0, -5))},
+		{"strict", nil},
+		{"unknown", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			got := FreeCancellationUntil(tt.policy, checkIn)
+			switch {
+			case tt.want == nil && got != nil:
+				t.Errorf("want no free window, got %d", *got)
+			case tt.want != nil && (got == nil || *got != tt.want.Unix()):
+				t.Errorf("want %d, got %v", tt.want.Unix(), got)
+			}
+		})
+	}
+}
+
+// The refund must be full exactly up to the advertised deadline, both
+// counted back from the listing's local check-in time.
+func TestCalculateRefund_AgreesWithFreeCancellationUntil(t *testing.T) {
+	checkInAt, err := CheckInTime("2026-07-10", "14:00", "Asia/Tashkent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cutoff, _ := CancellationCutoff("2026-07-10", "14:00", "Asia/Tashkent", 24*time.Hour)
+	for _, policy := range []string{"flexible", "moderate"} {
+		until := FreeCancellationUntil(policy, checkInAt)
+		deadline := time.Unix(*until, 0)
+		if policy == "flexible" && !deadline.Equal(cutoff) {
+			t.Errorf("flexible deadline %v: want the 24h cutoff %v", deadline.UTC(), cutoff.UTC())
+		}
+
+		at, _ := calculateRefundAt(policy, "1000.00", "", "UZS", checkInAt, deadline)
+		after, _ := calculateRefundAt(policy, "1000.00", "", "UZS", checkInAt, deadline.Add(time.Second))
+		if at.RefundPct != 100 {
+			t.Errorf("%s at deadline: want 100%%, got %d%%", policy, at.RefundPct)
+		}
+		if after.RefundPct == 100 {
+			t.Errorf("%s after deadline: want partial refund, got 100%%", policy)
+		}
+	}
+}
+
+func ptrTime(t time.Time) *time.Time { return &t }
+
+func TestCancellationCutoff(t *testing.T) {
+	tests := []struct {
+		name        string
+		checkInFrom string
+		timezone    string
+		before      time.Duration
+		want        string
+	}{
+		{"midnight utc", "", "", 0, "2026-07-01T00:00:00Z"},
+		{"check-in time in listing zone", "14:00", "Asia/Tashkent", 0, "2026-07-01T09:00:00Z"},
+		{"hours before check-in", "14:00", "Asia/Tashkent", 48 * time.Hour, "2026-06-29T09:00:00Z"},
+		{"unknown zone falls back to utc", "bad", "Mars/Olympus", 0, "2026-07-01T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		got, err := CancellationCutoff("2026-07-01", tt.checkInFrom, tt.timezone, tt.before)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if s := got.UTC().Format(time.RFC3339); s != tt.want {
+			t.Errorf("%s: want %s, got %s", tt.name, tt.want, s)
+		}
+	}
+	if _, err := CheckInTime("10/07/2026", "", ""); err == nil {
+		t.Error("want error for malformed check-in")
+	}
+}
+
+func TestCancellationCutoff_PastCheckIn(t *testing.T) {
+	// A guest cancelling the day after check-in is past the cutoff.
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	cutoff, err := CancellationCutoff(yesterday, "", "Asia/Tashkent", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !time.Now().After(cutoff) {
+		t.Errorf("cutoff %v should have passed", cutoff)
+	}
+}
+
+func TestCustomPolicyRefund(t *testing.T) {
+	catalog := []CancellationPolicy{{
+		Name:  "super_strict",
+		Tiers: []RefundTier{{HoursBefore: 30 * 24, RefundPct: 75}, {HoursBefore: 7 * 24, RefundPct: 25}},
+	}}
+	p := ResolvePolicy("super_strict", catalog)
+	checkIn := time.Date(2026, 7, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		wantPct int
+		want    string
+	}{
+		{"a month out", checkIn.AddDate(0, 0, -40), 75, "750.00"},
+		{"two weeks out", checkIn.AddDate(0, 0, -14), 25, "250.00"},
+		{"at the 7-day boundary", checkIn.AddDate(0, 0, -7), 25, "250.00"},
+		{"three days out", checkIn.AddDate(0, 0, -3), 0, "0.00"},
+	}
+	for _, tt := range tests {
+		got, err := p.refundAt(Rounding{}, "1000.00", "", "UZS", checkIn, tt.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.RefundPct != tt.wantPct || got.RefundAmount != tt.want {
+			t.Errorf("%s: got %d%% %s, want %d%% %s", tt.name, got.RefundPct, got.RefundAmount, tt.wantPct, tt.want)
+		}
+	}
+	if until := p.FreeCancellationUntil(checkIn); until != nil {
+		t.Errorf("no full-refund tier: want no free window, got %d", *until)
+	}
+
+	// Built-in names can't be shadowed by the catalog.
+	shadow := []CancellationPolicy{{Name: "flexible", Tiers: []RefundTier{{HoursBefore: 0, RefundPct: 100}}}}
+	if got := ResolvePolicy("flexible", shadow); got.Tiers[0].HoursBefore != 24 {
+		t.Errorf("flexible: want the built-in 24h tier, got %+v", got.Tiers)
+	}
+	if got := ResolvePolicy("gone", catalog); len(got.Tiers) != 0 {
+		t.Errorf("unknown policy: want no tiers, got %+v", got.Tiers)
+	}
+	if _, ok := LookupPolicy("gone", catalog); ok {
+		t.Error("LookupPolicy(gone): want not found")
+	}
+	if _, ok := LookupPolicy("super_strict", nil); ok {
+		t.Error("custom policy without its catalog: want not found")
+	}
+	if _, ok := LookupPolicy("strict", nil); !ok {
+		t.Error("built-in without a catalog: want found")
+	}
+}
+
+func TestBookingPolicy_UsesCapturedTiers(t *testing.T) {
+	b := Booking{CancellationPolicy: "super_strict", CancellationTiers: []RefundTier{{HoursBefore: 0, RefundPct: 10}}}
+	if got := b.Policy(); len(got.Tiers) != 1 || got.Tiers[0].RefundPct != 10 {
+		t.Errorf("captured tiers: got %+v", got)
+	}
+	legacy := Booking{CancellationPolicy: "strict"}
+	if got := legacy.Policy(); len(got.Tiers) != 1 || got.Tiers[0].HoursBefore != 14*24 {
+		t.Errorf("legacy booking: want built-in strict, got %+v", got)
+	}
+}
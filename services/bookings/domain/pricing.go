@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"github.com/saidmashhud/zist/internal/money"
+	"github.com/shopspring/decimal"
+)
+
+// Pricing is a booking's amounts in its currency's minor units.
+type Pricing = money.Pricing
+
+// Rounding controls how booking amounts are rounded; see money.Rounding. The
+// zero value rounds half up to two places for every currency.
+type Rounding = money.Rounding
+
+// Rounding modes for booking amounts.
+const (
+	RoundHalfUp   = money.RoundHalfUp
+	RoundHalfEven = money.RoundHalfEven
+)
+
+// DefaultMinorUnits is the number of decimal places for currencies absent
+// from Rounding.MinorUnits.
+const DefaultMinorUnits = money.DefaultMinorUnits
+
+// Price computes a booking's platform fee and total from the listings quote
+// in exact decimal arithmetic, rounding half up to two places. See
+// money.Rounding.Price.
+func Price(subtotal, cleaning, deposit string, feePct float64) (Pricing, error) {
+	return Rounding{}.Price(subtotal, cleaning, deposit, "", feePct)
+}
+
+func parseMoney(s string) (decimal.Decimal, error) {
+	return money.Parse(s)
+}
+
+// ValidRefundAmount reports whether s is a positive decimal amount, as a
+// settled refund must be.
+func ValidRefundAmount(s string) bool {
+	d, err := parseMoney(s)
+	return err == nil && d.IsPositive()
+}
@@ -0,0 +1,103 @@
+package domain
+
+import "testing"
+
+func TestPrice_Exact(t *testing.T) {
+	// 350000.00 × 3 nights = 1050000.00; +50000.00 cleaning = 1100000.00 fee
+	// base; 12% = 132000.00; +200000.00 deposit.
+	p, err := Price("1050000.00", "50000.00", "200000.00", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Pricing{Subtotal: "1050000.00", CleaningFee: "50000.00", Deposit: "200000.00", PlatformFee: "132000.00", Total: "1432000.00"}
+	if p != want {
+		t.Errorf("Price = %+v, want %+v", p, want)
+	}
+}
+
+func TestPrice_LargeAmountsStayExact(t *testing.T) {
+	// float64 cannot represent these sums exactly; decimals must.
+	p, err := Price("9007199254740993.10", "0.20", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Total != "9007199254740993.30" {
+		t.Errorf("Total = %s, want 9007199254740993.30", p.Total)
+	}
+}
+
+func TestPrice_FeeRounding(t *testing.T) {
+	tests := []struct {
+		subtotal string
+		pct      float64
+		fee      string
+		total    string
+	}{
+		{"0.10", 12.5, "0.01", "0.11"},             // 0.0125 → 0.01
+		{"0.20", 12.5, "0.03", "0.23"},             // 0.025 → 0.03 (half away from zero)
+		{"333333.33", 12, "40000.00", "373333.33"}, // 39999.9996 → 40000.00
+	}
+	for _, tt := range tests {
+		p, err := Price(tt.subtotal, "0", "0", tt.pct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.PlatformFee != tt.fee || p.Total != tt.total {
+			t.Errorf("Price(%s, %v%%) = fee %s total %s, want %s / %s", tt.subtotal, tt.pct, p.PlatformFee, p.Total, tt.fee, tt.total)
+		}
+	}
+}
+
+func TestPrice_InvalidAmount(t *testing.T) {
+	if _, err := Price("abc", "0", "0", 12); err == nil {
+		t.Error("want error for non-numeric subtotal")
+	}
+}
+
+func TestRounding_HalfUpVsBankers(t *testing.T) {
+	// 0.20 × 12.5% = 0.025 and 0.60 × 12.5% = 0.075: both sit on a .5 boundary.
+	tests := []struct {
+		mode, subtotal, fee string
+	}{
+		{RoundHalfUp, "0.20", "0.03"},
+		{RoundHalfEven, "0.20", "0.02"}, // to the even neighbour
+		{RoundHalfUp, "0.60", "0.08"},
+		{RoundHalfEven, "0.60", "0.08"},
+	}
+	for _, tt := range tests {
+		p, err := Rounding{Mode: tt.mode}.Price(tt.subtotal, "0", "0", "USD", 12.5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.PlatformFee != tt.fee {
+			t.Errorf("%s: fee on %s = %s, want %s", tt.mode, tt.subtotal, p.PlatformFee, tt.fee)
+		}
+	}
+}
+
+func TestRounding_MinorUnits(t *testing.T) {
+	r := Rounding{Mode: RoundHalfEven, MinorUnits: map[string]int{"UZS": 0}}
+	p, err := r.Price("1025", "0", "0", "uzs", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1025 × 10% = 102.5 → 102 (banker's), in whole sum.
+	if p.PlatformFee != "102" || p.Total != "1127" || p.Subtotal != "1025" {
+		t.Errorf("UZS = %+v, want fee 102 total 1127 with no decimals", p)
+	}
+	// Currencies without an entry keep two places.
+	if got := r.Places("USD"); got != DefaultMinorUnits {
+		t.Errorf("Places(USD) = %d, want %d", got, DefaultMinorUnits)
+	}
+}
+
+func TestValidRefundAmount(t *testing.T) {
+	for s, want := range map[string]bool{
+		"50.00": true, "0.01": true, "100000": true,
+		"0": false, "-5.00": false, "": false, "abc": false,
+	} {
+		if got := ValidRefundAmount(s); got != want {
+			t.Errorf("ValidRefundAmount(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package domain
+
+import "time"
+
+// Breakdown is a booking's priced line items, frozen at creation so later
+// listing price changes never alter what the guest was charged.
+type Breakdown struct {
+	Nights         int     `json:"nights"`
+	Subtotal       string  `json:"subtotal"` // nightly prices, overrides applied
+	CleaningFee    string  `json:"cleaningFee"`
+	PlatformFee    string  `json:"platformFee"`
+	PlatformFeePct float64 `json:"platformFeePct,omitempty"`
+	Deposit        string  `json:"deposit"` // refundable
+	Total          string  `json:"total"`
+	Currency       string  `json:"currency"`
+}
+
+// NewBreakdown records the amounts a booking is created with.
+func NewBreakdown(nights int, currency string, feePct float64, p Pricing) *Breakdown {
+	return &Breakdown{
+		Nights:         nights,
+		Subtotal:       p.Subtotal,
+		CleaningFee:    p.CleaningFee,
+		PlatformFee:    p.PlatformFee,
+		PlatformFeePct: feePct,
+		Deposit:        p.Deposit,
+		Total:          p.Total,
+		Currency:       currency,
+	}
+}
+
+// Receipt is what a guest or host sees for a booking's charges.
+type Receipt struct {
+	BookingID     string    `json:"bookingId"`
+	ListingID     string    `json:"listingId"`
+	CheckIn       string    `json:"checkIn"`
+	CheckOut      string    `json:"checkOut"`
+	Guests        int       `json:"guests"`
+	Status        string    `json:"status"`
+	PaymentStatus string    `json:"paymentStatus"`
+	Lines         Breakdown `json:"lines"`
+	CreatedAt     int64     `json:"createdAt"`
+}
+
+// NewReceipt builds b's receipt from its stored breakdown. Bookings created
+// before breakdowns were stored get one derived from their stored amounts:
+// the subtotal is what remains of the total after fees and deposit, and the
+// fee percentage is unknown.
+func NewReceipt(b Booking) Receipt {
+	lines := b.Breakdown
+	if lines == nil {
+		lines = derivedBreakdown(b)
+	}
+	return Receipt{
+		BookingID:     b.ID,
+		ListingID:     b.ListingID,
+		CheckIn:       b.CheckIn,
+		CheckOut:      b.CheckOut,
+		Guests:        b.Guests,
+		Status:        b.Status,
+		PaymentStatus: b.PaymentStatus,
+		Lines:         *lines,
+		CreatedAt:     b.CreatedAt,
+	}
+}
+
+func derivedBreakdown(b Booking) *Breakdown {
+	total, _ := parseMoney(b.TotalAmount)
+	fee, _ := parseMoney(b.PlatformFee)
+	cleaning, _ := parseMoney(b.CleaningFee)
+	deposit, _ := parseMoney(b.Deposit)
+	var nights int
+	ci, err1 := time.Parse("2006-01-02", b.CheckIn)
+	co, err2 := time.Parse("2006-01-02", b.CheckOut)
+	if err1 == nil && err2 == nil {
+		nights = int(co.Sub(ci).Hours() / 24)
+	}
+	return &Breakdown{
+		Nights:      nights,
+		Subtotal:    total.Sub(fee).Sub(cleaning).Sub(deposit).StringFixed(2),
+		CleaningFee: cleaning.StringFixed(2),
+		PlatformFee: fee.StringFixed(2),
+		Deposit:     deposit.StringFixed(2),
+		Total:       total.StringFixed(2),
+		Currency:    b.Currency,
+	}
+}
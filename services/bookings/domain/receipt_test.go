@@ -0,0 +1,34 @@
+package domain
+
+import "testing"
+
+func TestNewReceipt_UsesStoredBreakdown(t *testing.T) {
+	p, err := Price("1050000.00", "50000.00", "0", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := Booking{
+		ID: "b1", CheckIn: "2030-01-01", CheckOut: "2030-01-04", Currency: "UZS",
+		TotalAmount: p.Total, PlatformFee: p.PlatformFee, CleaningFee: p.CleaningFee, Deposit: p.Deposit,
+		Breakdown: NewBreakdown(3, "UZS", 12, p),
+	}
+	got := NewReceipt(b).Lines
+	want := Breakdown{
+		Nights: 3, Subtotal: "1050000.00", CleaningFee: "50000.00", PlatformFee: "132000.00",
+		PlatformFeePct: 12, Deposit: "0.00", Total: "1232000.00", Currency: "UZS",
+	}
+	if got != want {
+		t.Errorf("lines = %+v, want %+v", got, want)
+	}
+}
+
+func TestNewReceipt_DerivesLegacyBreakdown(t *testing.T) {
+	b := Booking{
+		ID: "b1", CheckIn: "2030-01-01", CheckOut: "2030-01-03", Currency: "UZS",
+		TotalAmount: "724000.00", PlatformFee: "24000.00", CleaningFee: "0.00", Deposit: "500000.00",
+	}
+	got := NewReceipt(b).Lines
+	if got.Nights != 2 || got.Subtotal != "200000.00" || got.Total != "724000.00" || got.PlatformFeePct != 0 {
+		t.Errorf("derived lines = %+v", got)
+	}
+}
@@ -0,0 +1,58 @@
+package domain
+
+// Reservation is a booking's hold on a listing's dates, as reported by the
+// listings service. BookingID is empty for rows that lost theirs.
+type Reservation struct {
+	ListingID string   `json:"listingId"`
+	BookingID string   `json:"bookingId"`
+	Dates     []string `json:"dates"`
+}
+
+// Kinds of availability drift found by Reconcile.
+const (
+	// DriftStale is a reservation held for a booking that released its
+	// dates (cancelled, rejected, failed, expired or no-show); it is safe to
+	// release.
+	DriftStale = "stale"
+	// DriftOrphan is a reservation for no booking on that listing. It may be
+	// a booking still being created, so it is only reported.
+	DriftOrphan = "orphan"
+)
+
+// releasesDates lists the statuses whose dates are freed on entry. A
+// pending_host_approval booking holds none either, but approval reserves
+// them before the status moves, so it is left alone.
+var releasesDates = map[string]bool{
+	StatusCancelledByGuest: true,
+	StatusCancelledByHost:  true,
+	StatusRejected:         true,
+	StatusFailed:           true,
+	StatusExpired:          true,
+	StatusNoShow:           true,
+}
+
+// Discrepancy is a reservation that disagrees with its booking. Status is
+// the booking's status, empty for orphans; Released is set once a fix has
+// freed the dates.
+type Discrepancy struct {
+	Reservation
+	Kind     string `json:"kind"`
+	Status   string `json:"status,omitempty"`
+	Released int    `json:"released"`
+}
+
+// Reconcile compares reservations with the bookings they were made for,
+// keyed by booking ID, and returns those that should no longer exist.
+func Reconcile(reservations []Reservation, bookings map[string]Booking) []Discrepancy {
+	out := []Discrepancy{}
+	for _, res := range reservations {
+		b, ok := bookings[res.BookingID]
+		switch {
+		case !ok || b.ListingID != res.ListingID:
+			out = append(out, Discrepancy{Reservation: res, Kind: DriftOrphan})
+		case releasesDates[b.Status]:
+			out = append(out, Discrepancy{Reservation: res, Kind: DriftStale, Status: b.Status})
+		}
+	}
+	return out
+}
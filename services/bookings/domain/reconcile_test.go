@@ -0,0 +1,52 @@
+package domain
+
+import "testing"
+
+func TestReconcile(t *testing.T) {
+	bookings := map[string]Booking{
+		"b-confirmed": {ListingID: "l1", Status: StatusConfirmed},
+		"b-pending":   {ListingID: "l1", Status: StatusPaymentPending},
+		"b-approval":  {ListingID: "l1", Status: StatusPendingHostApproval},
+		"b-cancelled": {ListingID: "l1", Status: StatusCancelledByGuest},
+		"b-expired":   {ListingID: "l2", Status: StatusExpired},
+		"b-moved":     {ListingID: "l2", Status: StatusConfirmed},
+	}
+	reservations := []Reservation{
+		{ListingID: "l1", BookingID: "b-confirmed", Dates: []string{"2030-01-01"}},
+		{ListingID: "l1", BookingID: "b-pending", Dates: []string{"2030-01-02"}},
+		{ListingID: "l1", BookingID: "b-approval", Dates: []string{"2030-01-03"}},
+		{ListingID: "l1", BookingID: "b-cancelled", Dates: []string{"2030-01-04", "2030-01-05"}},
+		{ListingID: "l2", BookingID: "b-expired", Dates: []string{"2030-01-06"}},
+		{ListingID: "l1", BookingID: "b-moved", Dates: []string{"2030-01-07"}},
+		{ListingID: "l1", BookingID: "b-missing", Dates: []string{"2030-01-08"}},
+		{ListingID: "l1", BookingID: "", Dates: []string{"2030-01-09"}},
+	}
+
+	got := Reconcile(reservations, bookings)
+	want := []struct{ booking, kind, status string }{
+		{"b-cancelled", DriftStale, StatusCancelledByGuest},
+		{"b-expired", DriftStale, StatusExpired},
+		{"b-moved", DriftOrphan, ""},
+		{"b-missing", DriftOrphan, ""},
+		{"", DriftOrphan, ""},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d discrepancies, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		d := got[i]
+		if d.BookingID != w.booking || d.Kind != w.kind || d.Status != w.status {
+			t.Errorf("discrepancy %d = {%q %q %q}, want {%q %q %q}", i, d.BookingID, d.Kind, d.Status, w.booking, w.kind, w.status)
+		}
+	}
+	if len(got[0].Dates) != 2 {
+		t.Errorf("stale dates = %v, want both nights", got[0].Dates)
+	}
+}
+
+func TestReconcile_NothingToReport(t *testing.T) {
+	got := Reconcile(nil, nil)
+	if got == nil || len(got) != 0 {
+		t.Errorf("Reconcile(nil, nil) = %#v, want an empty slice", got)
+	}
+}
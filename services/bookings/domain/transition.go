@@ -0,0 +1,80 @@
+package domain
+
+import "strings"
+
+// transitions is the booking lifecycle: for each status, the statuses it may
+// move to. Anything absent is terminal.
+var transitions = map[string][]string{
+	StatusPendingHostApproval: {StatusPaymentPending, StatusRejected, StatusCancelledByGuest, StatusCancelledByHost},
+	StatusPaymentPending:      {StatusConfirmed, StatusFailed, StatusExpired, StatusCancelledByGuest, StatusCancelledByHost},
+	StatusConfirmed:           {StatusCompleted, StatusNoShow, StatusCancelledByGuest, StatusCancelledByHost},
+}
+
+// CanTransition reports whether a booking in status from may move to to.
+// Handlers check it up front for a clear 409; the store's conditional
+// updates remain the race-safe backstop.
+func CanTransition(from, to string) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionSources lists the statuses from which a booking may move to to,
+// in lifecycle order.
+func TransitionSources(to string) []string {
+	var out []string
+	for _, from := range []string{StatusPendingHostApproval, StatusPaymentPending, StatusConfirmed} {
+		if CanTransition(from, to) {
+			out = append(out, from)
+		}
+	}
+	return out
+}
+
+// transitionVerb names the action that moves a booking into a status.
+var transitionVerb = map[string]string{
+	StatusPaymentPending:   "approve",
+	StatusRejected:         "reject",
+	StatusConfirmed:        "confirm",
+	StatusFailed:           "fail",
+	StatusExpired:          "expire",
+	StatusCompleted:        "complete",
+	StatusNoShow:           "mark no-show on",
+	StatusCancelledByGuest: "cancel",
+	StatusCancelledByHost:  "cancel",
+}
+
+// statusAdjective describes a booking in a status, for error messages.
+var statusAdjective = map[string]string{
+	StatusPendingHostApproval: "pending-approval",
+	StatusPaymentPending:      "payment-pending",
+	StatusConfirmed:           "confirmed",
+	StatusCancelledByGuest:    "cancelled",
+	StatusCancelledByHost:     "cancelled",
+	StatusRejected:            "rejected",
+	StatusFailed:              "failed",
+	StatusCompleted:           "completed",
+	StatusExpired:             "expired",
+	StatusNoShow:              "no-show",
+}
+
+// TransitionError describes a disallowed transition for the caller, e.g.
+// "cannot confirm a cancelled booking".
+func TransitionError(from, to string) string {
+	verb, ok := transitionVerb[to]
+	if !ok {
+		verb = "move to " + to
+	}
+	adj, ok := statusAdjective[from]
+	if !ok {
+		adj = from
+	}
+	article := "a"
+	if adj != "" && strings.ContainsRune("aeiou", rune(adj[0])) {
+		article = "an"
+	}
+	return "cannot " + verb + " " + article + " " + adj + " booking"
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCanTransition(t *testing.T) {
+	all := []string{
+		StatusPendingHostApproval, StatusPaymentPending, StatusConfirmed,
+		StatusCancelledByGuest, StatusCancelledByHost, StatusRejected,
+		StatusFailed, StatusCompleted, StatusExpired, StatusNoShow,
+	}
+	allowed := map[[2]string]bool{
+		{StatusPendingHostApproval, StatusPaymentPending}:   true,
+		{StatusPendingHostApproval, StatusRejected}:         true,
+		{StatusPendingHostApproval, StatusCancelledByGuest}: true,
+		{StatusPendingHostApproval, StatusCancelledByHost}:  true,
+		{StatusPaymentPending, StatusConfirmed}:             true,
+		{StatusPaymentPending, StatusFailed}:                true,
+		{StatusPaymentPending, StatusExpired}:               true,
+		{StatusPaymentPending, StatusCancelledByGuest}:      true,
+		{StatusPaymentPending, StatusCancelledByHost}:       true,
+		{StatusConfirmed, StatusCompleted}:                  true,
+		{StatusConfirmed, StatusNoShow}:                     true,
+		{StatusConfirmed, StatusCancelledByGuest}:           true,
+		{StatusConfirmed, StatusCancelledByHost}:            true,
+	}
+	for _, from := range all {
+		for _, to := range all {
+			want := allowed[[2]string{from, to}]
+			if got := CanTransition(from, to); got != want {
+				t.Errorf("CanTransition(%s, %s) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+	if CanTransition("unknown", StatusConfirmed) {
+		t.Error("unknown status should not transition")
+	}
+}
+
+func TestTransitionError(t *testing.T) {
+	tests := []struct{ from, to, want string }{
+		{StatusCancelledByGuest, StatusConfirmed, "cannot confirm a cancelled booking"},
+		{StatusExpired, StatusConfirmed, "cannot confirm an expired booking"},
+		{StatusConfirmed, StatusFailed, "cannot fail a confirmed booking"},
+		{StatusPaymentPending, StatusPaymentPending, "cannot approve a payment-pending booking"},
+		{StatusCompleted, StatusCancelledByHost, "cannot cancel a completed booking"},
+		{StatusPaymentPending, StatusNoShow, "cannot mark no-show on a payment-pending booking"},
+	}
+	for _, tt := range tests {
+		if got := TransitionError(tt.from, tt.to); got != tt.want {
+			t.Errorf("TransitionError(%s, %s) = %q, want %q", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestTransitionSources(t *testing.T) {
+	tests := []struct {
+		to   string
+		want []string
+	}{
+		{StatusConfirmed, []string{StatusPaymentPending}},
+		{StatusRejected, []string{StatusPendingHostApproval}},
+		{StatusCancelledByGuest, []string{StatusPendingHostApproval, StatusPaymentPending, StatusConfirmed}},
+		{StatusPendingHostApproval, nil},
+	}
+	for _, tt := range tests {
+		got := TransitionSources(tt.to)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("TransitionSources(%s) = %v, want %v", tt.to, got, tt.want)
+		}
+	}
+}
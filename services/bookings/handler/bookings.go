@@ -1,12 +1,12 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"math"
+	"log/slog"
 	"net/http"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -17,12 +17,6 @@ import (
 	"github.com/saidmashhud/zist/services/bookings/store"
 )
 
-// mustFloat parses a decimal string to float64; returns 0 on error.
-func mustFloat(s string) float64 {
-	f, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
-	return f
-}
-
 // ListBookings returns the authenticated guest's bookings.
 // GET /bookings/
 func (h *Handler) ListBookings(w http.ResponseWriter, r *http.Request) {
@@ -36,10 +30,44 @@ func (h *Handler) ListBookings(w http.ResponseWriter, r *http.Request) {
 		httputil.WriteError(w, http.StatusInternalServerError, "db query failed")
 		return
 	}
+	if r.URL.Query().Get("expand") == "listing" {
+		h.expandListings(r.Context(), principal.TenantID, bookings)
+	}
 	httputil.WriteJSON(w, http.StatusOK, map[string]any{"bookings": bookings})
 }
 
-// GetBooking returns a single booking. The caller must be the guest or host.
+// maxExpandedListings caps how many distinct listings one expanded list
+// fetches; bookings beyond the cap are returned without a listing.
+const maxExpandedListings = 50
+
+// expandListings embeds a listing summary in each booking using a single
+// batch call. If listings is unavailable the bookings are returned as-is.
+func (h *Handler) expandListings(ctx context.Context, tenantID string, bookings []domain.Booking) {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, b := range bookings {
+		if !seen[b.ListingID] && len(ids) < maxExpandedListings {
+			seen[b.ListingID] = true
+			ids = append(ids, b.ListingID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	summaries, err := h.Listings.GetListingSummaries(ctx, tenantID, ids)
+	if err != nil {
+		slog.Warn("listing expansion skipped", "err", err)
+		return
+	}
+	for i := range bookings {
+		if s, ok := summaries[bookings[i].ListingID]; ok {
+			bookings[i].Listing = &s
+		}
+	}
+}
+
+// GetBooking returns a single booking. The caller must be the guest or host,
+// or hold zist.admin; admin reads of other users' bookings are audited.
 // GET /bookings/{id}
 func (h *Handler) GetBooking(w http.ResponseWriter, r *http.Request) {
 	principal := zistauth.FromContext(r.Context())
@@ -59,11 +87,104 @@ func (h *Handler) GetBooking(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.authorizeRead(w, r, principal, b) {
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, b)
+}
+
+// GetReceipt returns a booking's charges as priced when it was created.
+// GET /bookings/{id}/receipt
+func (h *Handler) GetReceipt(w http.ResponseWriter, r *http.Request) {
+	principal := zistauth.FromContext(r.Context())
+	if principal == nil || principal.TenantID == "" {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	b, err := h.Store.Get(r.Context(), principal.TenantID, chi.URLParam(r, "id"))
+	if err == store.ErrNotFound {
+		httputil.WriteError(w, http.StatusNotFound, "booking not found")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if !h.authorizeRead(w, r, principal, b) {
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, domain.NewReceipt(b))
+}
+
+// GetBookingSummary returns what a post-stay screen needs in one call: the
+// booking, its listing (title and cover photo) and canReview, which is true
+// for the guest of a completed stay that has no review yet. Only the guest
+// and host may read it. If listings or reviews can't be reached the listing
+// is left out and canReview is false.
+// GET /bookings/{id}/summary
+func (h *Handler) GetBookingSummary(w http.ResponseWriter, r *http.Request) {
+	principal := zistauth.FromContext(r.Context())
+	if principal == nil || principal.TenantID == "" {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	b, err := h.Store.Get(r.Context(), principal.TenantID, chi.URLParam(r, "id"))
+	if err == store.ErrNotFound {
+		httputil.WriteError(w, http.StatusNotFound, "booking not found")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
 	if principal.UserID != b.GuestID && principal.UserID != b.HostID {
 		httputil.WriteError(w, http.StatusForbidden, "forbidden")
 		return
 	}
-	httputil.WriteJSON(w, http.StatusOK, b)
+
+	bookings := []domain.Booking{b}
+	h.expandListings(r.Context(), principal.TenantID, bookings)
+	summary := domain.Summary{Booking: bookings[0]}
+
+	if b.CanReview(principal.UserID) && h.Reviews != nil {
+		reviewed, err := h.Reviews.HasReview(r.Context(), principal.TenantID, b.ID)
+		if err != nil {
+			slog.Warn("review eligibility unavailable", "bookingId", b.ID, "err", err)
+		} else {
+			summary.CanReview = !reviewed
+		}
+	}
+	httputil.WriteJSON(w, http.StatusOK, summary)
+}
+
+// authorizeRead lets the booking's guest and host read it, and admins after
+// their access is audited. It writes the error response when it refuses.
+func (h *Handler) authorizeRead(w http.ResponseWriter, r *http.Request, principal *zistauth.Principal, b domain.Booking) bool {
+	if principal.UserID == b.GuestID || principal.UserID == b.HostID {
+		return true
+	}
+	if !principal.HasScope("zist.admin") {
+		httputil.WriteError(w, http.StatusForbidden, "forbidden")
+		return false
+	}
+	if err := h.auditAdminRead(r.Context(), principal, b.ID); err != nil {
+		slog.Error("admin booking read not audited", "bookingId", b.ID, "actor", principal.UserID, "err", err)
+		httputil.WriteError(w, http.StatusServiceUnavailable, "audit log unavailable")
+		return false
+	}
+	return true
+}
+
+// auditAdminRead records an admin viewing a booking they are not party to.
+// Without an audit client the access is only logged locally.
+func (h *Handler) auditAdminRead(ctx context.Context, p *zistauth.Principal, bookingID string) error {
+	if h.Audit == nil {
+		slog.Warn("admin read of booking (audit client not configured)", "bookingId", bookingID, "actor", p.UserID)
+		return nil
+	}
+	return h.Audit.Record(ctx, p.TenantID, p.UserID, "read_booking", "booking:"+bookingID, "")
 }
 
 // CreateBooking creates a new booking request.
@@ -92,7 +213,16 @@ func (h *Handler) CreateBooking(w http.ResponseWriter, r *http.Request) {
 		httputil.WriteError(w, http.StatusUnprocessableEntity, "listingId, checkIn, checkOut are required")
 		return
 	}
-
+	guests, err := domain.NormalizeGuests(req.Guests)
+	if err != nil {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	req.Guests = guests
+	if err := domain.CheckMessage(req.Message, h.MaxMessageLength); err != nil {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
 	ciDate, err1 := time.Parse("2006-01-02", req.CheckIn)
 	coDate, err2 := time.Parse("2006-01-02", req.CheckOut)
 	if err1 != nil || err2 != nil || !coDate.After(ciDate) {
@@ -110,7 +240,16 @@ func (h *Handler) CreateBooking(w http.ResponseWriter, r *http.Request) {
 		httputil.WriteError(w, http.StatusNotFound, "listing not found")
 		return
 	}
-	if listing.Status != "active" {
+	// Hosts may book their own paused listing (e.g. for an offline guest);
+	// everyone else needs it active. Drafts are never bookable.
+	switch listing.Status {
+	case "active":
+	case "paused":
+		if principal.UserID != listing.HostID {
+			httputil.WriteError(w, http.StatusUnprocessableEntity, "listing is not active")
+			return
+		}
+	default:
 		httputil.WriteError(w, http.StatusUnprocessableEntity, "listing is not active")
 		return
 	}
@@ -130,11 +269,91 @@ func (h *Handler) CreateBooking(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ppn := mustFloat(listing.PricePerNight)
-	cleaning := mustFloat(listing.CleaningFee)
-	subtotal := ppn * float64(nights)
-	platformFee := math.Round((subtotal+cleaning)*h.FeeGuestPct) / 100.0
-	total := subtotal + cleaning + platformFee
+	// Price from the listings quote so per-date overrides are charged.
+	quote, err := h.Listings.Quote(r.Context(), principal.TenantID, req.ListingID, req.CheckIn, req.CheckOut, req.Guests)
+	var rejected *QuoteRejectedError
+	if errors.As(err, &rejected) {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, rejected.Message)
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, "could not reach listings service")
+		return
+	}
+	if quote == nil {
+		httputil.WriteError(w, http.StatusNotFound, "listing not found")
+		return
+	}
+	pricing, err := h.Rounding.Price(quote.Subtotal, quote.CleaningFee, quote.Deposit, quote.Currency, h.FeeGuestPct)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, "invalid quote from listings service")
+		return
+	}
+
+	// Guard against mispriced listings and calendar hoarding: the tenant may
+	// require photos, restrict currencies, bound booking totals and cap a
+	// guest's unpaid bookings. If the limits can't be read the booking
+	// proceeds unguarded.
+	var graceMinutes int
+	var policies []domain.CancellationPolicy
+	if h.Tenants != nil {
+		limits, err := h.tenantLimits(r.Context(), principal.TenantID)
+		if err != nil {
+			slog.Warn("tenant booking limits unavailable", "tenantId", principal.TenantID, "err", err)
+		} else {
+			graceMinutes = limits.PaymentGraceMinutes
+			policies = limits.Policies
+			if limits.MinPhotos > 0 {
+				photos, err := h.Listings.PhotoCount(r.Context(), principal.TenantID, req.ListingID)
+				if err != nil {
+					httputil.WriteError(w, http.StatusBadGateway, "could not reach listings service")
+					return
+				}
+				if err := domain.CheckPhotos(photos, limits.MinPhotos); err != nil {
+					httputil.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+					return
+				}
+			}
+			if err := domain.CheckCurrency(limits.Currencies, quote.Currency); err != nil {
+				httputil.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+				return
+			}
+			if err := limits.Amount.Check(h.Rounding, pricing.Total, quote.Currency); err != nil {
+				httputil.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+				return
+			}
+			if limits.MaxPendingPerGuest > 0 {
+				pending, err := h.Store.CountPendingByGuest(r.Context(), principal.TenantID, principal.UserID)
+				if err != nil {
+					httputil.WriteError(w, http.StatusInternalServerError, "db error")
+					return
+				}
+				if pending >= limits.MaxPendingPerGuest {
+					httputil.WriteError(w, http.StatusTooManyRequests,
+						fmt.Sprintf("too many pending bookings: at most %d may await approval or payment at once", limits.MaxPendingPerGuest))
+					return
+				}
+			}
+		}
+	}
+
+	// A custom policy lives in the tenant's catalog. If the catalog can't be
+	// read, or no longer has the policy, the refund terms are unknown, so
+	// the booking waits rather than being made with none.
+	policy, ok := domain.LookupPolicy(listing.CancellationPolicy, policies)
+	if !ok {
+		slog.Warn("cancellation policy unavailable", "tenantId", principal.TenantID,
+			"listingId", req.ListingID, "policy", listing.CancellationPolicy)
+		httputil.WriteError(w, http.StatusServiceUnavailable, "cancellation policy unavailable; try again later")
+		return
+	}
+
+	// Count the message only once the request is known to be valid, so
+	// rejected attempts don't use up the guest's allowance.
+	if req.Message != "" && !h.allowMessage(principal.TenantID, principal.UserID, req.ListingID) {
+		httputil.WriteError(w, http.StatusTooManyRequests, "too many messages to this host; try again later")
+		return
+	}
 
 	var dates []string
 	for d := ciDate; d.Before(coDate); d = d.AddDate(0, 0, 1) {
@@ -143,8 +362,13 @@ func (h *Handler) CreateBooking(w http.ResponseWriter, r *http.Request) {
 
 	now := time.Now().Unix()
 	bookingID := uuid.NewString()
-
-	var initialStatus string
+	window := h.paymentWindow(listing.PaymentWindowMinutes)
+	var expiresAt *int64
+	totalAmount := pricing.Total
+	// A free instant booking has nothing to pay, and a pay-on-arrival one is
+	// paid in person, so both skip payment_pending.
+	free := h.AutoConfirmFree && domain.IsFreeStay(totalAmount)
+	initialStatus, paymentStatus := domain.InitialStatus(listing.InstantBook, listing.PayOnArrival, free)
 	if listing.InstantBook {
 		conflicts, err := h.Listings.MarkDatesBooked(r.Context(), principal.TenantID, req.ListingID, bookingID, dates)
 		if err != nil {
@@ -158,29 +382,43 @@ func (h *Handler) CreateBooking(w http.ResponseWriter, r *http.Request) {
 			})
 			return
 		}
-		initialStatus = domain.StatusPaymentPending
-	} else {
-		initialStatus = domain.StatusPendingHostApproval
+		if initialStatus == domain.StatusPaymentPending {
+			exp := now + int64(window)*60
+			expiresAt = &exp
+		}
 	}
 
 	b := domain.Booking{
-		ID:                 bookingID,
-		ListingID:          req.ListingID,
-		GuestID:            principal.UserID,
-		HostID:             listing.HostID,
-		CheckIn:            req.CheckIn,
-		CheckOut:           req.CheckOut,
-		Guests:             req.Guests,
-		TotalAmount:        fmt.Sprintf("%.2f", total),
-		PlatformFee:        fmt.Sprintf("%.2f", platformFee),
-		CleaningFee:        fmt.Sprintf("%.2f", cleaning),
-		Currency:           listing.Currency,
-		Status:             initialStatus,
-		CancellationPolicy: listing.CancellationPolicy,
-		Message:            req.Message,
-		CreatedAt:          now,
-		UpdatedAt:          now,
+		ID:                   bookingID,
+		ListingID:            req.ListingID,
+		GuestID:              principal.UserID,
+		GuestEmail:           principal.Email,
+		HostID:               listing.HostID,
+		CheckIn:              req.CheckIn,
+		CheckOut:             req.CheckOut,
+		Guests:               req.Guests,
+		TotalAmount:          totalAmount,
+		PlatformFee:          pricing.PlatformFee,
+		CleaningFee:          pricing.CleaningFee,
+		Deposit:              pricing.Deposit,
+		Currency:             quote.Currency,
+		Breakdown:            domain.NewBreakdown(nights, quote.Currency, h.FeeGuestPct, pricing),
+		Status:               initialStatus,
+		PaymentStatus:        paymentStatus,
+		CancellationPolicy:   listing.CancellationPolicy,
+		Message:              req.Message,
+		ExpiresAt:            expiresAt,
+		PaymentWindowMinutes: window,
+		PaymentGraceMinutes:  graceMinutes,
+		CreatedAt:            now,
+		UpdatedAt:            now,
 	}
+	b.SetHoldRemaining(now)
+	b.CancellationTiers = policy.Tiers
+	// Dates were validated above, so check-in cannot fail to parse. The
+	// window counts back from the listing's check-in time, as refunds do.
+	checkInAt, _ := domain.CheckInTime(b.CheckIn, listing.CheckInFrom, listing.Timezone)
+	b.FreeCancellationUntil = policy.FreeCancellationUntil(checkInAt)
 
 	if err := h.Store.Create(r.Context(), principal.TenantID, b); err != nil {
 		if listing.InstantBook {
@@ -190,5 +428,107 @@ func (h *Handler) CreateBooking(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if initialStatus == domain.StatusConfirmed {
+		if h.Notify != nil {
+			msg := "Your Zist booking is confirmed! Check-in: " + b.CheckIn + ", Check-out: " + b.CheckOut + "."
+			go h.Notify.NotifyUser(r.Context(), b.GuestID, "booking_confirmed", msg)
+		}
+		h.announceConfirmed(principal.TenantID, b, listing.Title)
+	}
 	httputil.WriteJSON(w, http.StatusCreated, b)
 }
+
+// UpdateBooking lets the guest change the guest count on a booking that is
+// still pending. Dates can't be changed here. Pricing is per night with no
+// per-guest component, so the total is unchanged.
+// PATCH /bookings/{id}
+func (h *Handler) UpdateBooking(w http.ResponseWriter, r *http.Request) {
+	principal := zistauth.FromContext(r.Context())
+	if principal == nil || principal.TenantID == "" {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req struct {
+		Guests *int `json:"guests"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Guests == nil {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, "guests is required")
+		return
+	}
+	if *req.Guests < 1 {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, "guests must be at least 1")
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	b, err := h.Store.Get(r.Context(), principal.TenantID, id)
+	if err == store.ErrNotFound {
+		httputil.WriteError(w, http.StatusNotFound, "booking not found")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if principal.UserID != b.GuestID {
+		httputil.WriteError(w, http.StatusForbidden, "only the guest can modify this booking")
+		return
+	}
+
+	switch b.Status {
+	case domain.StatusPendingHostApproval, domain.StatusPaymentPending:
+	case domain.StatusConfirmed:
+		httputil.WriteError(w, http.StatusConflict, "confirmed bookings can't be modified without host approval")
+		return
+	default:
+		httputil.WriteError(w, http.StatusConflict, fmt.Sprintf("cannot modify booking in %s status", b.Status))
+		return
+	}
+
+	listing, err := h.Listings.GetListing(r.Context(), principal.TenantID, b.ListingID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, "could not reach listings service")
+		return
+	}
+	if listing == nil {
+		httputil.WriteError(w, http.StatusNotFound, "listing not found")
+		return
+	}
+	if *req.Guests > listing.MaxGuests {
+		httputil.WriteError(w, http.StatusUnprocessableEntity,
+			fmt.Sprintf("listing capacity is %d guests", listing.MaxGuests))
+		return
+	}
+
+	ok, err := h.Store.UpdateGuests(r.Context(), principal.TenantID, id, *req.Guests)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "update failed")
+		return
+	}
+	if !ok {
+		httputil.WriteError(w, http.StatusConflict, "booking state changed concurrently")
+		return
+	}
+
+	b, err = h.Store.Get(r.Context(), principal.TenantID, id)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, b)
+}
+
+// writeTransitionConflict answers a disallowed status transition with 409,
+// naming the booking's current status and the statuses the action requires.
+func writeTransitionConflict(w http.ResponseWriter, from, to string) {
+	httputil.WriteJSON(w, http.StatusConflict, map[string]any{
+		"error":          domain.TransitionError(from, to),
+		"status":         from,
+		"requiredStatus": domain.TransitionSources(to),
+	})
+}
@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	zistauth "github.com/saidmashhud/zist/internal/auth"
@@ -11,7 +13,9 @@ import (
 )
 
 // CancelBooking handles cancellation by the guest or host.
-// Computes a policy-based refund. Host cancellations always yield 100% refund.
+// Computes a policy-based refund. Host cancellations always yield 100% refund,
+// and bookings paid on arrival refund nothing since nothing was charged.
+// The refundable deposit is always returned in full.
 // POST /bookings/{id}/cancel
 func (h *Handler) CancelBooking(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -42,37 +46,53 @@ func (h *Handler) CancelBooking(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	switch b.Status {
-	case domain.StatusPendingHostApproval, domain.StatusPaymentPending, domain.StatusConfirmed:
-		// allowed
-	default:
-		httputil.WriteError(w, http.StatusConflict, "booking cannot be cancelled in status: "+b.Status)
+	if !domain.CanTransition(b.Status, newStatus) {
+		writeTransitionConflict(w, b.Status, newStatus)
 		return
 	}
+	var checkInAt time.Time
+	if newStatus == domain.StatusCancelledByGuest {
+		checkInAt, err = h.checkInAt(r, principal.TenantID, b)
+		if err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, "cancellation cutoff failed")
+			return
+		}
+		if time.Now().After(checkInAt.Add(-h.GuestCancelCutoff)) {
+			httputil.WriteError(w, http.StatusConflict, "past cancellation cutoff")
+			return
+		}
+	}
 
 	var refund domain.RefundResult
-	if newStatus == domain.StatusCancelledByHost {
-		refund = domain.RefundResult{
-			RefundAmount: b.TotalAmount,
-			RefundPct:    100,
-			Currency:     b.Currency,
-		}
+	if b.PaymentStatus == domain.PaymentOnArrival {
+		refund = domain.NoRefund(h.Rounding, b.Currency)
+	} else if newStatus == domain.StatusCancelledByHost {
+		refund = domain.FullRefund(h.Rounding, b.TotalAmount, b.Deposit, b.Currency)
 	} else {
-		refund, err = domain.CalculateRefund(b.CancellationPolicy, b.TotalAmount, b.Currency, b.CheckIn)
+		// The same check-in as the cutoff above, so tiers count back from it.
+		refund, err = b.Policy().Refund(h.Rounding, b.TotalAmount, b.Deposit, b.Currency, checkInAt)
 		if err != nil {
 			httputil.WriteError(w, http.StatusInternalServerError, "refund calculation failed")
 			return
 		}
 	}
 
-	if err := h.Store.Cancel(r.Context(), principal.TenantID, id, newStatus); err != nil {
+	ok, err := h.Store.Cancel(r.Context(), principal.TenantID, id, b.Status, newStatus)
+	if err != nil {
 		httputil.WriteError(w, http.StatusInternalServerError, "update failed")
 		return
 	}
+	if !ok {
+		httputil.WriteError(w, http.StatusConflict, "booking state changed concurrently")
+		return
+	}
 
-	// Release reserved dates only if they were reserved.
-	if b.Status == domain.StatusPaymentPending || b.Status == domain.StatusConfirmed {
-		h.Listings.ReleaseDates(r.Context(), principal.TenantID, b.ListingID, b.ID) //nolint:errcheck
+	// Releasing is idempotent, so a booking whose dates were never reserved
+	// (pending approval) or were already freed just reports 0.
+	if released, err := h.Listings.ReleaseDates(r.Context(), principal.TenantID, b.ListingID, b.ID); err != nil {
+		slog.Error("failed to release dates for cancelled booking", "bookingId", b.ID, "err", err)
+	} else {
+		slog.Info("booking cancelled, dates released", "bookingId", b.ID, "listingId", b.ListingID, "released", released)
 	}
 
 	httputil.WriteJSON(w, http.StatusOK, map[string]any{
@@ -80,3 +100,15 @@ func (h *Handler) CancelBooking(w http.ResponseWriter, r *http.Request) {
 		"refund": refund,
 	})
 }
+
+// checkInAt is when b's stay begins: the listing's check-in time in its
+// timezone. If the listing can't be read, check-in is midnight UTC.
+func (h *Handler) checkInAt(r *http.Request, tenantID string, b domain.Booking) (time.Time, error) {
+	var tz, checkInFrom string
+	if l, err := h.Listings.GetListing(r.Context(), tenantID, b.ListingID); err != nil {
+		slog.Warn("listing unavailable for cancellation cutoff", "bookingId", b.ID, "err", err)
+	} else if l != nil {
+		tz, checkInFrom = l.Timezone, l.CheckInFrom
+	}
+	return domain.CheckInTime(b.CheckIn, checkInFrom, tz)
+}
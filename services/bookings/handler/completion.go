@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/saidmashhud/zist/services/bookings/store"
+)
+
+// EventReviewReminder asks a notification consumer to nudge the guest of a
+// completed stay to leave a review.
+const EventReviewReminder = "zist.review.reminder"
+
+// reviewReminderBatch bounds the reminders published per sweep.
+const reviewReminderBatch = 100
+
+// RunCompletionWorker periodically completes confirmed stays whose check-out
+// date has arrived and, when review reminders are enabled, queues and
+// publishes them. It blocks until ctx is cancelled.
+func (h *Handler) RunCompletionWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.completeDue(ctx)
+			h.sendReviewReminders(ctx)
+		}
+	}
+}
+
+func (h *Handler) completeDue(ctx context.Context) {
+	sweepCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	completed, err := h.Store.CompleteDue(sweepCtx, now.Format("2006-01-02"), now.Unix(), h.reviewReminderDue(now))
+	if err != nil {
+		slog.Error("completion sweep failed", "err", err)
+		return
+	}
+	for _, b := range completed {
+		slog.Info("booking completed", "bookingId", b.ID, "listingId", b.ListingID)
+	}
+}
+
+// reviewReminderDue is when a stay completed at now should be reminded
+// about, or nil when there is no events plane to publish reminders on.
+func (h *Handler) reviewReminderDue(now time.Time) *int64 {
+	if h.Events == nil {
+		return nil
+	}
+	due := now.Add(h.ReviewReminderDelay).Unix()
+	return &due
+}
+
+func (h *Handler) sendReviewReminders(ctx context.Context) {
+	if h.Events == nil {
+		return
+	}
+	sweepCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	due, err := h.Store.DueReviewReminders(sweepCtx, time.Now().Unix(), reviewReminderBatch)
+	if err != nil {
+		slog.Error("review reminder sweep failed", "err", err)
+		return
+	}
+	for _, r := range due {
+		if err := h.Events.Publish(sweepCtx, r.TenantID, EventReviewReminder, "review-reminder:"+r.ID, reviewReminderPayload(r)); err != nil {
+			// Left unsent; the next sweep retries it.
+			slog.Warn("review reminder publish failed", "bookingId", r.ID, "err", err)
+			continue
+		}
+		if err := h.Store.MarkReviewReminderSent(sweepCtx, r.ID, time.Now().Unix()); err != nil {
+			slog.Error("failed to mark review reminder sent", "bookingId", r.ID, "err", err)
+			continue
+		}
+		slog.Info("review reminder published", "bookingId", r.ID)
+	}
+}
+
+func reviewReminderPayload(r store.ReviewReminder) map[string]string {
+	return map[string]string{
+		"bookingId": r.ID,
+		"listingId": r.ListingID,
+		"guestId":   r.GuestID,
+		"hostId":    r.HostID,
+		"checkIn":   r.CheckIn,
+		"checkOut":  r.CheckOut,
+	}
+}
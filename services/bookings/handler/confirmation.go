@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/saidmashhud/zist/services/bookings/domain"
+)
+
+// EventBookingConfirmed asks a notification consumer to send the guest a
+// booking confirmation.
+const EventBookingConfirmed = "zist.booking.confirmed"
+
+// EventRefundRequired tells operations a payment was captured for a booking
+// that could no longer be confirmed and must be refunded by hand.
+const EventRefundRequired = "zist.booking.refund_required"
+
+// announceConfirmed publishes zist.booking.confirmed for b in the background.
+// It is best-effort: a failed publish is logged and never fails the
+// confirmation. The event ID is derived from the booking, so mgEvents
+// delivers it once even if a confirmation is replayed. An empty listingTitle
+// is looked up from the listings service.
+func (h *Handler) announceConfirmed(tenantID string, b domain.Booking, listingTitle string) {
+	if h.BookingEvents == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if listingTitle == "" && h.Listings != nil {
+			if l, err := h.Listings.GetListing(ctx, tenantID, b.ListingID); err == nil && l != nil {
+				listingTitle = l.Title
+			}
+		}
+		err := h.BookingEvents.Publish(ctx, tenantID, EventBookingConfirmed,
+			"booking-confirmed:"+b.ID, bookingConfirmedPayload(b, listingTitle))
+		if err != nil {
+			slog.Warn("booking confirmation publish failed", "bookingId", b.ID, "err", err)
+			return
+		}
+		slog.Info("booking confirmation published", "bookingId", b.ID)
+	}()
+}
+
+func bookingConfirmedPayload(b domain.Booking, listingTitle string) map[string]any {
+	return map[string]any{
+		"bookingId":    b.ID,
+		"listingId":    b.ListingID,
+		"listingTitle": listingTitle,
+		"guestId":      b.GuestID,
+		"guestEmail":   b.GuestEmail,
+		"checkIn":      b.CheckIn,
+		"checkOut":     b.CheckOut,
+		"guests":       b.Guests,
+		"totalAmount":  b.TotalAmount,
+		"currency":     b.Currency,
+	}
+}
+
+// alertRefundDue raises a late capture for manual refund: always as an
+// error log, and as zist.booking.refund_required when booking events are
+// configured. Like announceConfirmed it publishes in the background and
+// keys the event on the booking, so a replayed capture alerts once.
+func (h *Handler) alertRefundDue(tenantID string, b domain.Booking, paymentID string) {
+	slog.Error("payment captured after the booking lapsed; refund required",
+		"tenantId", tenantID, "bookingId", b.ID, "paymentId", paymentID, "status", b.Status,
+		"amount", b.TotalAmount, "currency", b.Currency)
+	if h.BookingEvents == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		err := h.BookingEvents.Publish(ctx, tenantID, EventRefundRequired, "refund-required:"+b.ID, map[string]any{
+			"bookingId":   b.ID,
+			"paymentId":   paymentID,
+			"guestId":     b.GuestID,
+			"totalAmount": b.TotalAmount,
+			"currency":    b.Currency,
+		})
+		if err != nil {
+			slog.Warn("refund alert publish failed", "bookingId", b.ID, "err", err)
+		}
+	}()
+}
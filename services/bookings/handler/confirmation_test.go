@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/saidmashhud/zist/services/bookings/domain"
+)
+
+func TestAnnounceConfirmed(t *testing.T) {
+	type published struct {
+		key  string
+		body map[string]any
+	}
+	got := make(chan published, 1)
+	events := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body) //nolint:errcheck
+		got <- published{key: r.Header.Get("Idempotency-Key"), body: body}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer events.Close()
+
+	h := (&Handler{}).WithBookingEvents(events.URL, "key")
+	h.announceConfirmed("t1", domain.Booking{
+		ID: "b1", ListingID: "l1", GuestID: "u1", GuestEmail: "guest@example.com",
+		CheckIn: "2026-07-01", CheckOut: "2026-07-04", Guests: 2,
+		TotalAmount: "336.00", Currency: "USD",
+	}, "Sea view flat")
+
+	select {
+	case p := <-got:
+		if p.key != "booking-confirmed:b1" || p.body["event_id"] != p.key {
+			t.Errorf("idempotency: got key %q, event_id %v", p.key, p.body["event_id"])
+		}
+		if p.body["event_type"] != EventBookingConfirmed || p.body["tenant_id"] != "t1" {
+			t.Errorf("envelope: got %v", p.body)
+		}
+		payload, _ := p.body["payload"].(map[string]any)
+		for k, want := range map[string]any{
+			"guestEmail": "guest@example.com", "listingTitle": "Sea view flat",
+			"checkIn": "2026-07-01", "checkOut": "2026-07-04",
+			"totalAmount": "336.00", "currency": "USD",
+		} {
+			if payload[k] != want {
+				t.Errorf("payload %s: got %v, want %v", k, payload[k], want)
+			}
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no zist.booking.confirmed event published")
+	}
+}
+
+func TestAnnounceConfirmedDisabled(t *testing.T) {
+	// Without MGEVENTS_URL nothing is published and nothing panics.
+	(&Handler{}).WithBookingEvents("", "").announceConfirmed("t1", domain.Booking{ID: "b1"}, "")
+}
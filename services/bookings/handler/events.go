@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// eventsClient publishes domain events to the Mashgate events plane
+// (mgEvents), which fans them out to subscribed endpoints.
+type eventsClient struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+func newEventsClient(baseURL, apiKey string) *eventsClient {
+	return &eventsClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		http:    &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Publish emits one event. eventID doubles as the idempotency key, so a
+// retried publish is delivered once.
+func (c *eventsClient) Publish(ctx context.Context, tenantID, eventType, eventID string, payload any) error {
+	body, err := json.Marshal(map[string]any{
+		"event_id":   eventID,
+		"event_type": eventType,
+		"tenant_id":  tenantID,
+		"payload":    payload,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/v1/events", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Idempotency-Key", eventID)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("events plane unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("events plane returned %d: %s", resp.StatusCode, b)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// RunExpiryWorker periodically expires payment_pending bookings whose hold
+// (expires_at) has lapsed and releases their dates, retrying releases that
+// failed on earlier sweeps. It blocks until ctx is cancelled.
+func (h *Handler) RunExpiryWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.expireDue(ctx)
+		}
+	}
+}
+
+// releaseBatch bounds the date releases attempted per sweep.
+const releaseBatch = 100
+
+func (h *Handler) expireDue(ctx context.Context) {
+	sweepCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	expired, err := h.Store.ExpireDue(sweepCtx, time.Now().Unix())
+	if err != nil {
+		slog.Error("expiry sweep failed", "err", err)
+		return
+	}
+	for _, b := range expired {
+		slog.Info("booking expired", "bookingId", b.ID, "listingId", b.ListingID)
+	}
+
+	// Expiring a booking queues its dates for release. This picks up the
+	// bookings just expired and any whose release failed on an earlier
+	// sweep; releasing is idempotent, so a retry is always safe.
+	pending, err := h.Store.PendingReleases(sweepCtx, releaseBatch)
+	if err != nil {
+		slog.Error("pending date releases query failed", "err", err)
+		return
+	}
+	for _, b := range pending {
+		released, err := h.Listings.ReleaseDates(sweepCtx, b.TenantID, b.ListingID, b.ID)
+		if err != nil {
+			slog.Warn("failed to release dates for expired booking; will retry", "bookingId", b.ID, "err", err)
+			continue
+		}
+		if err := h.Store.MarkReleased(sweepCtx, b.TenantID, b.ID); err != nil {
+			slog.Error("failed to mark dates released", "bookingId", b.ID, "err", err)
+			continue
+		}
+		slog.Info("expired booking's dates released", "bookingId", b.ID, "listingId", b.ListingID, "released", released)
+	}
+}
@@ -2,6 +2,11 @@
 package handler
 
 import (
+	"time"
+
+	"github.com/saidmashhud/zist/internal/ratelimit"
+	"github.com/saidmashhud/zist/internal/tenantconfig"
+	"github.com/saidmashhud/zist/services/bookings/domain"
 	"github.com/saidmashhud/zist/services/bookings/store"
 )
 
@@ -10,12 +15,122 @@ type Handler struct {
 	Store       *store.Store
 	Listings    *ListingsClient
 	Notify      *notifyClient
-	FeeGuestPct float64 // e.g. 12.0 → 12%
+	Audit       *auditClient         // nil unless ADMIN_URL is set
+	Tenants     *tenantconfig.Client // booking limits; nil unless ADMIN_URL is set
+	Reviews     *reviewsClient       // review eligibility; nil unless REVIEWS_URL is set
+	FeeGuestPct float64              // e.g. 12.0 → 12%
+	// Rounding rounds booking amounts; the zero value is half up to cents.
+	Rounding domain.Rounding
+	// PaymentWindowMinutes is the default time a guest has to pay once a
+	// booking is payment_pending; listings may override it.
+	PaymentWindowMinutes int
+	// Events publishes review reminders; nil disables them.
+	Events              *eventsClient
+	ReviewReminderDelay time.Duration
+	// BookingEvents publishes zist.booking.confirmed and
+	// zist.booking.refund_required; nil disables them.
+	BookingEvents *eventsClient
+	// AutoConfirmFree confirms instant-book bookings whose total is zero
+	// straight away instead of waiting for a payment that will never come.
+	AutoConfirmFree bool
+	// GuestCancelCutoff is how long before check-in guests stop being able
+	// to cancel; 0 allows it up to check-in. Hosts may cancel anytime.
+	GuestCancelCutoff time.Duration
+	// MaxMessageLength caps the message on a booking request, in characters.
+	MaxMessageLength int
+	// messages rate-limits booking-request messages per guest per listing.
+	messages *ratelimit.Limiter
 }
 
 // New returns a Handler with the given dependencies.
 func New(s *store.Store, lc *ListingsClient, feeGuestPct float64) *Handler {
-	return &Handler{Store: s, Listings: lc, FeeGuestPct: feeGuestPct}
+	return &Handler{Store: s, Listings: lc, FeeGuestPct: feeGuestPct, PaymentWindowMinutes: defaultPaymentWindowMinutes, AutoConfirmFree: true, MaxMessageLength: domain.DefaultMaxMessageLength}
+}
+
+// WithFreeAutoConfirm toggles confirming zero-total instant bookings without
+// payment (on by default).
+func (h *Handler) WithFreeAutoConfirm(enabled bool) *Handler {
+	h.AutoConfirmFree = enabled
+	return h
+}
+
+// WithGuestCancelCutoff stops guest cancellations hours before check-in
+// instead of at check-in. Negative values are ignored.
+func (h *Handler) WithGuestCancelCutoff(hours int) *Handler {
+	if hours >= 0 {
+		h.GuestCancelCutoff = time.Duration(hours) * time.Hour
+	}
+	return h
+}
+
+// WithRounding sets the rounding mode and per-currency minor units for
+// booking amounts and refunds.
+func (h *Handler) WithRounding(r domain.Rounding) *Handler {
+	h.Rounding = r
+	return h
+}
+
+// WithMessageLimits caps booking-request messages at maxLen characters and
+// perHour messages per guest per listing. Non-positive values disable the
+// respective limit.
+func (h *Handler) WithMessageLimits(maxLen, perHour int) *Handler {
+	h.MaxMessageLength = maxLen
+	h.messages = ratelimit.New(perHour, time.Hour)
+	return h
+}
+
+// allowMessage reports whether user may send another message about listing.
+func (h *Handler) allowMessage(tenantID, userID, listingID string) bool {
+	if h.messages == nil {
+		return true
+	}
+	return h.messages.Allow(tenantID + "|" + userID + "|" + listingID)
+}
+
+// defaultPaymentWindowMinutes gives guests 24 h to pay.
+const defaultPaymentWindowMinutes = 24 * 60
+
+// WithPaymentWindow sets the platform-default payment window in minutes.
+func (h *Handler) WithPaymentWindow(minutes int) *Handler {
+	if minutes > 0 {
+		h.PaymentWindowMinutes = minutes
+	}
+	return h
+}
+
+// paymentWindow resolves the effective window: the listing's override if set,
+// otherwise the platform default.
+func (h *Handler) paymentWindow(listingMinutes int) int {
+	if listingMinutes > 0 {
+		return listingMinutes
+	}
+	return h.PaymentWindowMinutes
+}
+
+// WithAudit records privileged reads in the admin service's audit log.
+func (h *Handler) WithAudit(adminURL, internalToken string) *Handler {
+	if adminURL != "" {
+		h.Audit = newAuditClient(adminURL, internalToken)
+	}
+	return h
+}
+
+// WithTenantLimits enforces each tenant's min/max booking amount, read from
+// the admin service's tenant config.
+func (h *Handler) WithTenantLimits(adminURL, internalToken string) *Handler {
+	if adminURL != "" {
+		h.Tenants = tenantconfig.New(adminURL, internalToken)
+	}
+	return h
+}
+
+// WithReviews lets booking summaries check whether a stay was already
+// reviewed. Without it canReview is always false.
+func (h *Handler) WithReviews(reviewsURL, internalToken string) *Handler {
+	if reviewsURL != "" {
+		h.Reviews = newReviewsClient(reviewsURL, internalToken)
+	}
+	return h
 }
 
 // WithNotify attaches an mgNotify client for SMS/email notifications.
@@ -25,3 +140,23 @@ func (h *Handler) WithNotify(notifyURL, apiKey string) *Handler {
 	}
 	return h
 }
+
+// WithBookingEvents publishes a zist.booking.confirmed event through mgEvents
+// whenever a booking is confirmed, and zist.booking.refund_required when a
+// payment arrives too late to confirm one.
+func (h *Handler) WithBookingEvents(eventsURL, apiKey string) *Handler {
+	if eventsURL != "" {
+		h.BookingEvents = newEventsClient(eventsURL, apiKey)
+	}
+	return h
+}
+
+// WithReviewReminders publishes a zist.review.reminder event through mgEvents
+// delay after each stay completes.
+func (h *Handler) WithReviewReminders(eventsURL, apiKey string, delay time.Duration) *Handler {
+	if eventsURL != "" {
+		h.Events = newEventsClient(eventsURL, apiKey)
+		h.ReviewReminderDelay = delay
+	}
+	return h
+}
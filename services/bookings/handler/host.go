@@ -1,7 +1,10 @@
 package handler
 
 import (
+	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -11,20 +14,109 @@ import (
 	"github.com/saidmashhud/zist/services/bookings/store"
 )
 
-// ListHostBookings returns all bookings on the authenticated host's listings.
-// GET /bookings/host
+// Host booking list page sizes.
+const (
+	defaultHostBookingsPage = 100
+	maxHostBookingsPage     = 500
+)
+
+// ListHostBookings returns bookings on the authenticated host's listings,
+// newest first, optionally filtered by listing, check-in range and status.
+// GET /bookings/host?listingId=&from=&to=&status=&limit=&offset=
 func (h *Handler) ListHostBookings(w http.ResponseWriter, r *http.Request) {
 	principal := zistauth.FromContext(r.Context())
 	if principal == nil || principal.TenantID == "" {
 		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
-	bookings, err := h.Store.ListByHost(r.Context(), principal.TenantID, principal.UserID)
+	q := r.URL.Query()
+	f := domain.HostBookingFilter{
+		ListingID: q.Get("listingId"),
+		From:      q.Get("from"),
+		To:        q.Get("to"),
+		Status:    q.Get("status"),
+		Limit:     defaultHostBookingsPage,
+	}
+	if n, err := strconv.Atoi(q.Get("limit")); err == nil && n > 0 {
+		f.Limit = min(n, maxHostBookingsPage)
+	}
+	if n, err := strconv.Atoi(q.Get("offset")); err == nil && n > 0 {
+		f.Offset = n
+	}
+	if err := f.Validate(); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	bookings, total, err := h.Store.ListByHost(r.Context(), principal.TenantID, principal.UserID, f)
 	if err != nil {
 		httputil.WriteError(w, http.StatusInternalServerError, "db query failed")
 		return
 	}
-	httputil.WriteJSON(w, http.StatusOK, map[string]any{"bookings": bookings})
+	if bookings == nil {
+		bookings = []domain.Booking{}
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{
+		"bookings": bookings,
+		"total":    total,
+		"limit":    f.Limit,
+		"offset":   f.Offset,
+	})
+}
+
+// maxAnalyticsNights caps the analytics window.
+const maxAnalyticsNights = 366
+
+// HostAnalytics returns the host's earnings and occupancy over [from, to),
+// overall and per listing. Defaults to the 30 nights ending today.
+// GET /bookings/host/analytics?from=YYYY-MM-DD&to=YYYY-MM-DD
+func (h *Handler) HostAnalytics(w http.ResponseWriter, r *http.Request) {
+	principal := zistauth.FromContext(r.Context())
+	if principal == nil || principal.TenantID == "" {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	q := r.URL.Query()
+	to := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	if v := q.Get("to"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			httputil.WriteError(w, http.StatusBadRequest, "to must be YYYY-MM-DD")
+			return
+		}
+		to = d
+	}
+	from := to.AddDate(0, 0, -30)
+	if v := q.Get("from"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			httputil.WriteError(w, http.StatusBadRequest, "from must be YYYY-MM-DD")
+			return
+		}
+		from = d
+	}
+	if !to.After(from) {
+		httputil.WriteError(w, http.StatusBadRequest, "from must be before to")
+		return
+	}
+	if to.Sub(from) > maxAnalyticsNights*24*time.Hour {
+		httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("window is limited to %d nights", maxAnalyticsNights))
+		return
+	}
+	fromStr, toStr := from.Format("2006-01-02"), to.Format("2006-01-02")
+
+	stays, err := h.Store.ListHostStays(r.Context(), principal.TenantID, principal.UserID, fromStr, toStr)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db query failed")
+		return
+	}
+	capacity, err := h.Listings.HostCapacity(r.Context(), principal.TenantID, principal.UserID, fromStr, toStr)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, "could not reach listings service")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, domain.ComputeHostAnalytics(h.Rounding, from, to, stays, capacity))
 }
 
 // ApproveBooking lets a host approve a pending-approval request.
@@ -51,8 +143,8 @@ func (h *Handler) ApproveBooking(w http.ResponseWriter, r *http.Request) {
 		httputil.WriteError(w, http.StatusForbidden, "not your listing")
 		return
 	}
-	if b.Status != domain.StatusPendingHostApproval {
-		httputil.WriteError(w, http.StatusConflict, "booking is not pending host approval")
+	if !domain.CanTransition(b.Status, domain.StatusPaymentPending) {
+		writeTransitionConflict(w, b.Status, domain.StatusPaymentPending)
 		return
 	}
 
@@ -77,8 +169,9 @@ func (h *Handler) ApproveBooking(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Guest has 24 h to pay.
-	expiresAt := time.Now().Unix() + 86400
+	// Guest has the payment window captured at booking creation to pay.
+	window := h.paymentWindow(b.PaymentWindowMinutes)
+	expiresAt := time.Now().Unix() + int64(window)*60
 	ok, err := h.Store.Approve(r.Context(), principal.TenantID, id, expiresAt)
 	if err != nil {
 		h.Listings.ReleaseDates(r.Context(), principal.TenantID, b.ListingID, b.ID) //nolint:errcheck
@@ -92,8 +185,10 @@ func (h *Handler) ApproveBooking(w http.ResponseWriter, r *http.Request) {
 	}
 
 	httputil.WriteJSON(w, http.StatusOK, map[string]any{
-		"status":    domain.StatusPaymentPending,
-		"expiresAt": expiresAt,
+		"status":               domain.StatusPaymentPending,
+		"expiresAt":            expiresAt,
+		"paymentWindowMinutes": window,
+		"holdRemainingSeconds": int64(window) * 60,
 	})
 }
 
@@ -120,14 +215,81 @@ func (h *Handler) RejectBooking(w http.ResponseWriter, r *http.Request) {
 		httputil.WriteError(w, http.StatusForbidden, "not your listing")
 		return
 	}
-	if b.Status != domain.StatusPendingHostApproval {
-		httputil.WriteError(w, http.StatusConflict, "booking is not pending host approval")
+	if !domain.CanTransition(b.Status, domain.StatusRejected) {
+		writeTransitionConflict(w, b.Status, domain.StatusRejected)
 		return
 	}
 
-	if err := h.Store.Reject(r.Context(), principal.TenantID, id); err != nil {
+	ok, err := h.Store.Reject(r.Context(), principal.TenantID, id)
+	if err != nil {
 		httputil.WriteError(w, http.StatusInternalServerError, "update failed")
 		return
 	}
+	if !ok {
+		httputil.WriteError(w, http.StatusConflict, "booking state changed concurrently")
+		return
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// MarkNoShow records that the guest of a confirmed booking never arrived.
+// Host-only, once check-in (in the listing's timezone) has passed. The
+// booking's dates are released so the remaining nights can be rebooked.
+// POST /bookings/{id}/no-show
+func (h *Handler) MarkNoShow(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	principal := zistauth.FromContext(r.Context())
+	if principal == nil || principal.TenantID == "" {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	b, err := h.Store.Get(r.Context(), principal.TenantID, id)
+	if err == store.ErrNotFound {
+		httputil.WriteError(w, http.StatusNotFound, "booking not found")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if b.HostID != principal.UserID {
+		httputil.WriteError(w, http.StatusForbidden, "not your listing")
+		return
+	}
+	if !domain.CanTransition(b.Status, domain.StatusNoShow) {
+		writeTransitionConflict(w, b.Status, domain.StatusNoShow)
+		return
+	}
+	checkInAt, err := h.checkInAt(r, principal.TenantID, b)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "check-in time unavailable")
+		return
+	}
+	if err := b.CheckNoShow(principal.UserID, checkInAt, time.Now()); err != nil {
+		httputil.WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	ok, err := h.Store.MarkNoShow(r.Context(), principal.TenantID, id)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "update failed")
+		return
+	}
+	if !ok {
+		httputil.WriteError(w, http.StatusConflict, "booking state changed concurrently")
+		return
+	}
+
+	if released, err := h.Listings.ReleaseDates(r.Context(), principal.TenantID, b.ListingID, b.ID); err != nil {
+		slog.Error("failed to release dates for no-show booking", "bookingId", b.ID, "err", err)
+	} else {
+		slog.Info("booking marked no-show, dates released", "bookingId", b.ID, "listingId", b.ListingID, "released", released)
+	}
+	if h.Audit != nil {
+		if err := h.Audit.Record(r.Context(), principal.TenantID, principal.UserID, "mark_no_show", "booking:"+b.ID, ""); err != nil {
+			slog.Warn("no-show not audited", "bookingId", b.ID, "err", err)
+		}
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"status": domain.StatusNoShow})
+}
@@ -2,16 +2,22 @@ package handler
 
 import (
 	"encoding/json"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/services/bookings/domain"
 	"github.com/saidmashhud/zist/services/bookings/store"
 )
 
 // ConfirmBooking transitions a booking from payment_pending → confirmed.
 // Called by the payments service after a successful payment.captured event.
+// A payment is accepted until expires_at plus the tenant's payment grace;
+// a later one is flagged refund_due and answered 200 {"status":"refund_due"}.
 // POST /bookings/{id}/confirm  (internal token required)
 func (h *Handler) ConfirmBooking(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -26,30 +32,62 @@ func (h *Handler) ConfirmBooking(w http.ResponseWriter, r *http.Request) {
 	}
 	json.NewDecoder(r.Body).Decode(&req) //nolint:errcheck — body is optional
 
+	b, err := h.Store.Get(r.Context(), tenantID, id)
+	if err == store.ErrNotFound {
+		httputil.WriteError(w, http.StatusNotFound, "booking not found")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	// The money was taken after the booking stopped waiting for it (expired,
+	// or lapsed between sweeps past the tenant's grace): it can't confirm the
+	// booking, so flag the payment for refund and raise the alarm. The capture
+	// is handled, so this answers 200 rather than an error the caller would
+	// retry; a replay finds the payment already flagged and doesn't re-alert.
+	if b.LateCapture(time.Now().Unix()) {
+		if _, err := h.Store.MarkRefundDue(r.Context(), tenantID, id, req.PaymentID); err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, "update failed")
+			return
+		}
+		if b.PaymentStatus != domain.PaymentRefundDue {
+			h.alertRefundDue(tenantID, b, req.PaymentID)
+		}
+		httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": domain.PaymentRefundDue})
+		return
+	}
+	if !domain.CanTransition(b.Status, domain.StatusConfirmed) {
+		writeTransitionConflict(w, b.Status, domain.StatusConfirmed)
+		return
+	}
+
 	ok, err := h.Store.Confirm(r.Context(), tenantID, id, req.PaymentID)
 	if err != nil {
 		httputil.WriteError(w, http.StatusInternalServerError, "update failed")
 		return
 	}
 	if !ok {
-		httputil.WriteError(w, http.StatusNotFound, "booking not found or not in payment_pending status")
+		httputil.WriteError(w, http.StatusConflict, "booking state changed concurrently")
 		return
 	}
 
 	// Fire-and-forget: notify guest of confirmation via mgNotify.
 	// The notify service accepts a user_id and resolves contact info via mgID.
 	if h.Notify != nil {
-		if b, err2 := h.Store.Get(r.Context(), tenantID, id); err2 == nil {
-			msg := "Your Zist booking is confirmed! Check-in: " + b.CheckIn + ", Check-out: " + b.CheckOut + "."
-			go h.Notify.NotifyUser(r.Context(), b.GuestID, "booking_confirmed", msg)
-		}
+		msg := "Your Zist booking is confirmed! Check-in: " + b.CheckIn + ", Check-out: " + b.CheckOut + "."
+		go h.Notify.NotifyUser(r.Context(), b.GuestID, "booking_confirmed", msg)
 	}
+	h.announceConfirmed(tenantID, b, "")
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// FailBooking transitions a booking from payment_pending → failed and releases dates.
-// Called by the payments service after a payment.failed event.
+// FailBooking transitions a booking from payment_pending → failed and releases
+// its reserved dates as part of the same operation: 204 is returned only once
+// both are done. If the release fails the booking stays failed and the caller
+// gets 502; retrying on an already-failed booking skips the transition and
+// just releases again, so the payments webhook can retry until dates are free.
 // POST /bookings/{id}/fail  (internal token required)
 func (h *Handler) FailBooking(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -59,20 +97,84 @@ func (h *Handler) FailBooking(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	b, err := h.Store.Fail(r.Context(), tenantID, id)
+	b, err := h.Store.Get(r.Context(), tenantID, id)
 	if err == store.ErrNotFound {
-		httputil.WriteError(w, http.StatusNotFound, "booking not found or not in payment_pending status")
+		httputil.WriteError(w, http.StatusNotFound, "booking not found")
 		return
 	}
 	if err != nil {
-		httputil.WriteError(w, http.StatusInternalServerError, "update failed")
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
 		return
 	}
+	if b.Status != domain.StatusFailed {
+		if !domain.CanTransition(b.Status, domain.StatusFailed) {
+			writeTransitionConflict(w, b.Status, domain.StatusFailed)
+			return
+		}
+		// Store.Fail re-checks the status; a miss here means we lost a race.
+		b, err = h.Store.Fail(r.Context(), tenantID, id)
+		if err == store.ErrNotFound {
+			httputil.WriteError(w, http.StatusConflict, "booking state changed concurrently")
+			return
+		}
+		if err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, "update failed")
+			return
+		}
+	}
 
-	h.Listings.ReleaseDates(r.Context(), tenantID, b.ListingID, b.ID) //nolint:errcheck
+	released, err := h.Listings.ReleaseDates(r.Context(), tenantID, b.ListingID, b.ID)
+	if err != nil {
+		slog.Error("failed to release dates for failed booking", "bookingId", b.ID, "err", err)
+		httputil.WriteError(w, http.StatusBadGateway, "booking failed but its dates could not be released; retry")
+		return
+	}
+	slog.Info("booking failed, dates released", "bookingId", b.ID, "listingId", b.ListingID, "released", released)
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// CompleteBooking closes out one confirmed stay whose check-out has passed,
+// the same transition the completion sweep makes; useful for backfills.
+// POST /bookings/{id}/complete  (internal token required)
+func (h *Handler) CompleteBooking(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	tenantID := strings.TrimSpace(r.Header.Get("X-Tenant-ID"))
+	if tenantID == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "tenant_id is required")
+		return
+	}
+
+	b, err := h.Store.Get(r.Context(), tenantID, id)
+	if err == store.ErrNotFound {
+		httputil.WriteError(w, http.StatusNotFound, "booking not found")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if !domain.CanTransition(b.Status, domain.StatusCompleted) {
+		writeTransitionConflict(w, b.Status, domain.StatusCompleted)
+		return
+	}
+	now := time.Now().UTC()
+	if err := b.CheckComplete(now); err != nil {
+		httputil.WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
+	ok, err := h.Store.Complete(r.Context(), tenantID, id, now.Format("2006-01-02"), now.Unix(), h.reviewReminderDue(now))
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "update failed")
+		return
+	}
+	if !ok {
+		httputil.WriteError(w, http.StatusConflict, "booking state changed concurrently")
+		return
+	}
+	slog.Info("booking completed", "bookingId", b.ID, "listingId", b.ListingID)
+	httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": domain.StatusCompleted})
+}
+
 // SetCheckoutID stores the Mashgate checkout session ID on the booking.
 // Called by the payments service after creating a checkout session.
 // PUT /bookings/{id}/checkout  (internal token required)
@@ -107,3 +209,237 @@ func (h *Handler) SetCheckoutID(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// SetListingHost reassigns a listing's bookings to its new host after an
+// ownership transfer in the listings service. Idempotent.
+// PUT /bookings/internal/listings/{listingId}/host  (internal token required)
+func (h *Handler) SetListingHost(w http.ResponseWriter, r *http.Request) {
+	tenantID := strings.TrimSpace(r.Header.Get("X-Tenant-ID"))
+	if tenantID == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "tenant_id is required")
+		return
+	}
+
+	var req struct {
+		HostID string `json:"hostId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.HostID = strings.TrimSpace(req.HostID)
+	if req.HostID == "" {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, "hostId is required")
+		return
+	}
+
+	n, err := h.Store.SetListingHost(r.Context(), tenantID, chi.URLParam(r, "listingId"), req.HostID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "update failed")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]int64{"updated": n})
+}
+
+// settablePaymentStatus lists the payment statuses callers may set; the
+// others are only reached through their own transitions.
+var settablePaymentStatus = map[string]bool{
+	domain.PaymentPending:  true,
+	domain.PaymentCaptured: true,
+	domain.PaymentFailed:   true,
+	domain.PaymentRefunded: true,
+}
+
+// SetPaymentStatus records the payment status reported by Mashgate webhooks.
+// Transitions only move forward; a stale update gets 409 and changes nothing.
+// PUT /bookings/{id}/payment-status  (internal token required)
+func (h *Handler) SetPaymentStatus(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	tenantID := strings.TrimSpace(r.Header.Get("X-Tenant-ID"))
+	if tenantID == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "tenant_id is required")
+		return
+	}
+
+	var req struct {
+		PaymentStatus string `json:"paymentStatus"`
+		// RefundedAmount, with paymentStatus refunded, is the amount of a
+		// settled refund; a partial one leaves the payment
+		// partially_refunded.
+		RefundedAmount string `json:"refundedAmount"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !settablePaymentStatus[req.PaymentStatus] {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, "paymentStatus must be one of pending, captured, failed, refunded")
+		return
+	}
+	refund := strings.TrimSpace(req.RefundedAmount)
+	if refund != "" {
+		if req.PaymentStatus != domain.PaymentRefunded {
+			httputil.WriteError(w, http.StatusUnprocessableEntity, "refundedAmount is only allowed with paymentStatus refunded")
+			return
+		}
+		if !domain.ValidRefundAmount(refund) {
+			httputil.WriteError(w, http.StatusUnprocessableEntity, "refundedAmount must be a positive decimal")
+			return
+		}
+	}
+
+	var ok bool
+	var err error
+	if refund != "" {
+		ok, err = h.Store.RecordRefund(r.Context(), tenantID, id, refund)
+	} else {
+		ok, err = h.Store.SetPaymentStatus(r.Context(), tenantID, id, req.PaymentStatus)
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "update failed")
+		return
+	}
+	if !ok {
+		b, err := h.Store.Get(r.Context(), tenantID, id)
+		if err == store.ErrNotFound {
+			httputil.WriteError(w, http.StatusNotFound, "booking not found")
+			return
+		}
+		if err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, "db error")
+			return
+		}
+		httputil.WriteJSON(w, http.StatusConflict, map[string]string{
+			"error":         "payment status transition not allowed",
+			"paymentStatus": b.PaymentStatus,
+		})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetBookingInternal returns a booking for service-to-service callers that
+// need its payment state (e.g. payments resuming a lapsed checkout).
+// GET /bookings/{id}/checkout  (internal token required)
+func (h *Handler) GetBookingInternal(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	tenantID := strings.TrimSpace(r.Header.Get("X-Tenant-ID"))
+	if tenantID == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "tenant_id is required")
+		return
+	}
+
+	b, err := h.Store.Get(r.Context(), tenantID, id)
+	if err == store.ErrNotFound {
+		httputil.WriteError(w, http.StatusNotFound, "booking not found")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, b)
+}
+
+// maxStaleCheckouts caps one page of the reconciliation feed.
+const maxStaleCheckouts = 500
+
+// ListStaleCheckouts lists payment_pending bookings across all tenants whose
+// checkout has seen no update for older_than_seconds, so the payments
+// service can reconcile them against Mashgate when a webhook was lost.
+// GET /bookings/internal/stale-checkouts?older_than_seconds=&limit=  (internal token required)
+func (h *Handler) ListStaleCheckouts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	olderThan, err := strconv.Atoi(q.Get("older_than_seconds"))
+	if err != nil || olderThan <= 0 {
+		httputil.WriteError(w, http.StatusBadRequest, "older_than_seconds must be a positive integer")
+		return
+	}
+	limit := 50
+	if v := q.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			httputil.WriteError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+	}
+	if limit > maxStaleCheckouts {
+		limit = maxStaleCheckouts
+	}
+
+	now := time.Now()
+	before := now.Add(-time.Duration(olderThan) * time.Second).Unix()
+	stale, err := h.Store.ListStaleCheckouts(r.Context(), before, now.Unix(), limit)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"bookings": stale})
+}
+
+// ReconcileAvailability compares the tenant's booked dates in the listings
+// service with the bookings they were reserved for, and reports reservations
+// left behind by a failed cross-service call: stale ones for bookings that
+// have released their dates, and orphans for no booking at all. With
+// ?fix=true stale reservations are released; orphans are only reported.
+// POST /bookings/internal/reconcile?fix=  (internal token required)
+func (h *Handler) ReconcileAvailability(w http.ResponseWriter, r *http.Request) {
+	tenantID := strings.TrimSpace(r.Header.Get("X-Tenant-ID"))
+	if tenantID == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "tenant_id is required")
+		return
+	}
+	fix := r.URL.Query().Get("fix") == "true"
+
+	reservations, err := h.Listings.ListReservations(r.Context(), tenantID)
+	if err != nil {
+		slog.Error("reconcile: listing reservations failed", "tenantId", tenantID, "err", err)
+		httputil.WriteError(w, http.StatusBadGateway, "listings service unavailable")
+		return
+	}
+	ids := make([]string, 0, len(reservations))
+	for _, res := range reservations {
+		if res.BookingID != "" {
+			ids = append(ids, res.BookingID)
+		}
+	}
+	bookings, err := h.Store.GetByIDs(r.Context(), tenantID, ids)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	drift := domain.Reconcile(reservations, bookings)
+	var stale, orphaned, released, failed int
+	for i := range drift {
+		d := &drift[i]
+		if d.Kind == domain.DriftOrphan {
+			orphaned++
+			slog.Warn("reconcile: orphan reservation", "tenantId", tenantID, "listingId", d.ListingID, "bookingId", d.BookingID, "dates", d.Dates)
+			continue
+		}
+		stale++
+		if !fix {
+			continue
+		}
+		n, err := h.Listings.ReleaseDates(r.Context(), tenantID, d.ListingID, d.BookingID)
+		if err != nil {
+			slog.Error("reconcile: failed to release stale dates", "bookingId", d.BookingID, "err", err)
+			failed++
+			continue
+		}
+		d.Released = n
+		released += n
+		slog.Info("reconcile: stale dates released", "bookingId", d.BookingID, "listingId", d.ListingID, "status", d.Status, "released", n)
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{
+		"checked":       len(reservations),
+		"stale":         stale,
+		"orphaned":      orphaned,
+		"fixed":         fix,
+		"released":      released,
+		"failed":        failed,
+		"discrepancies": drift,
+	})
+}
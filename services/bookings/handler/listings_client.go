@@ -7,6 +7,8 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -74,36 +76,204 @@ func (c *ListingsClient) GetListing(ctx context.Context, tenantID, id string) (*
 	}
 
 	var raw struct {
-		ID                 string `json:"id"`
-		HostID             string `json:"hostId"`
-		InstantBook        bool   `json:"instantBook"`
-		CancellationPolicy string `json:"cancellationPolicy"`
-		PricePerNight      string `json:"pricePerNight"`
-		CleaningFee        string `json:"cleaningFee"`
-		Currency           string `json:"currency"`
-		MinNights          int    `json:"minNights"`
-		MaxNights          int    `json:"maxNights"`
-		MaxGuests          int    `json:"maxGuests"`
-		Status             string `json:"status"`
+		ID                   string `json:"id"`
+		Title                string `json:"title"`
+		HostID               string `json:"hostId"`
+		InstantBook          bool   `json:"instantBook"`
+		CancellationPolicy   string `json:"cancellationPolicy"`
+		MinNights            int    `json:"minNights"`
+		MaxNights            int    `json:"maxNights"`
+		MaxGuests            int    `json:"maxGuests"`
+		Status               string `json:"status"`
+		PaymentWindowMinutes int    `json:"paymentWindowMinutes"`
+		PayOnArrival         bool   `json:"payOnArrival"`
+		Timezone             string `json:"timezone"`
+		Rules                struct {
+			CheckInFrom string `json:"checkInFrom"`
+		} `json:"rules"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
 		return nil, fmt.Errorf("decode listing: %w", err)
 	}
 	return &domain.ListingInfo{
-		ID:                 raw.ID,
-		HostID:             raw.HostID,
-		InstantBook:        raw.InstantBook,
-		CancellationPolicy: raw.CancellationPolicy,
-		PricePerNight:      raw.PricePerNight,
-		CleaningFee:        raw.CleaningFee,
-		Currency:           raw.Currency,
-		MinNights:          raw.MinNights,
-		MaxNights:          raw.MaxNights,
-		MaxGuests:          raw.MaxGuests,
-		Status:             raw.Status,
+		ID:                   raw.ID,
+		Title:                raw.Title,
+		HostID:               raw.HostID,
+		InstantBook:          raw.InstantBook,
+		CancellationPolicy:   raw.CancellationPolicy,
+		MinNights:            raw.MinNights,
+		MaxNights:            raw.MaxNights,
+		MaxGuests:            raw.MaxGuests,
+		Status:               raw.Status,
+		PaymentWindowMinutes: raw.PaymentWindowMinutes,
+		PayOnArrival:         raw.PayOnArrival,
+		Timezone:             raw.Timezone,
+		CheckInFrom:          raw.Rules.CheckInFrom,
 	}, nil
 }
 
+// PhotoCount returns how many photos a listing has. Unlike GetListing, a
+// failure to read the photos is an error rather than zero photos.
+func (c *ListingsClient) PhotoCount(ctx context.Context, tenantID, listingID string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/listings/%s/photos/count", c.baseURL, url.PathEscape(listingID)), nil)
+	if err != nil {
+		return 0, err
+	}
+	c.setAuth(req)
+	req.Header.Set("X-Tenant-ID", tenantID)
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("listings service unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("listings service returned %d: %s", resp.StatusCode, b)
+	}
+	var out struct {
+		Count int `json:"count"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return 0, fmt.Errorf("decode photo count: %w", err)
+	}
+	return out.Count, nil
+}
+
+// GetListingSummaries batch-fetches listings by ID in one call, keyed by ID.
+// IDs the listings service doesn't return are absent from the map.
+func (c *ListingsClient) GetListingSummaries(ctx context.Context, tenantID string, ids []string) (map[string]domain.ListingSummary, error) {
+	q := url.Values{}
+	q.Set("ids", strings.Join(ids, ","))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/listings/batch?%s", c.baseURL, q.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	c.setAuth(req)
+	req.Header.Set("X-Tenant-ID", tenantID)
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("listings service unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("listings service returned %d: %s", resp.StatusCode, b)
+	}
+
+	var raw struct {
+		Listings []struct {
+			ID     string `json:"id"`
+			Title  string `json:"title"`
+			City   string `json:"city"`
+			Photos []struct {
+				URL string `json:"url"`
+			} `json:"photos"`
+		} `json:"listings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("decode listings batch: %w", err)
+	}
+	out := make(map[string]domain.ListingSummary, len(raw.Listings))
+	for _, l := range raw.Listings {
+		s := domain.ListingSummary{ID: l.ID, Title: l.Title, City: l.City}
+		if len(l.Photos) > 0 {
+			s.CoverPhoto = l.Photos[0].URL
+		}
+		out[l.ID] = s
+	}
+	return out, nil
+}
+
+// HostCapacity lists the host's listings with the nights they blocked in
+// [from, to).
+func (c *ListingsClient) HostCapacity(ctx context.Context, tenantID, hostID, from, to string) ([]domain.ListingCapacity, error) {
+	q := url.Values{}
+	q.Set("host_id", hostID)
+	q.Set("from", from)
+	q.Set("to", to)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/listings/capacity?%s", c.baseURL, q.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	c.setAuth(req)
+	req.Header.Set("X-Tenant-ID", tenantID)
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("listings service unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("listings service returned %d: %s", resp.StatusCode, b)
+	}
+	var raw struct {
+		Listings []domain.ListingCapacity `json:"listings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("decode capacity: %w", err)
+	}
+	return raw.Listings, nil
+}
+
+// QuoteRejectedError is returned when listings refuses to price a stay
+// (e.g. too many guests or nights); Message is safe to show the caller.
+type QuoteRejectedError struct {
+	Message string
+}
+
+func (e *QuoteRejectedError) Error() string { return "quote rejected: " + e.Message }
+
+// Quote prices a stay via the listings service. Returns nil, nil if the
+// listing is not found.
+func (c *ListingsClient) Quote(ctx context.Context, tenantID, listingID, checkIn, checkOut string, guests int) (*domain.Quote, error) {
+	q := url.Values{}
+	q.Set("check_in", checkIn)
+	q.Set("check_out", checkOut)
+	if guests > 0 {
+		q.Set("guests", strconv.Itoa(guests))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/listings/%s/quote?%s", c.baseURL, listingID, q.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	c.setAuth(req)
+	req.Header.Set("X-Tenant-ID", tenantID)
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("listings service unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		var e struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&e) //nolint:errcheck
+		return nil, &QuoteRejectedError{Message: e.Error}
+	default:
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("listings service returned %d: %s", resp.StatusCode, b)
+	}
+
+	var quote domain.Quote
+	if err := json.NewDecoder(resp.Body).Decode(&quote); err != nil {
+		return nil, fmt.Errorf("decode quote: %w", err)
+	}
+	return &quote, nil
+}
+
 // MarkDatesBooked reserves dates on a listing for a booking.
 // Returns non-empty conflict slice on 409.
 func (c *ListingsClient) MarkDatesBooked(ctx context.Context, tenantID, listingID, bookingID string, dates []string) ([]string, error) {
@@ -141,14 +311,43 @@ func (c *ListingsClient) MarkDatesBooked(ctx context.Context, tenantID, listingI
 	return nil, nil
 }
 
-// ReleaseDates releases dates previously reserved for a booking.
-func (c *ListingsClient) ReleaseDates(ctx context.Context, tenantID, listingID, bookingID string) error {
+// ListReservations returns the tenant's booked dates from today on, grouped
+// by listing and booking.
+func (c *ListingsClient) ListReservations(ctx context.Context, tenantID string) ([]domain.Reservation, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/listings/reservations", nil)
+	if err != nil {
+		return nil, err
+	}
+	c.setAuth(req)
+	req.Header.Set("X-Tenant-ID", tenantID)
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("listings service unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("listings service returned %d", resp.StatusCode)
+	}
+	var out struct {
+		Reservations []domain.Reservation `json:"reservations"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode reservations response: %w", err)
+	}
+	return out.Reservations, nil
+}
+
+// ReleaseDates releases dates previously reserved for a booking and returns
+// how many were freed. It is safe in any booking state: dates that were never
+// reserved, or already released, count as 0.
+func (c *ListingsClient) ReleaseDates(ctx context.Context, tenantID, listingID, bookingID string) (int, error) {
 	body, _ := json.Marshal(map[string]string{"bookingId": bookingID})
 	req, err := http.NewRequestWithContext(ctx, http.MethodDelete,
 		fmt.Sprintf("%s/listings/%s/availability/book", c.baseURL, listingID),
 		strings.NewReader(string(body)))
 	if err != nil {
-		return err
+		return 0, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	c.setAuth(req)
@@ -156,8 +355,17 @@ func (c *ListingsClient) ReleaseDates(ctx context.Context, tenantID, listingID,
 
 	resp, err := c.hc.Do(req)
 	if err != nil {
-		return fmt.Errorf("listings service unavailable: %w", err)
+		return 0, fmt.Errorf("listings service unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("listings service returned %d", resp.StatusCode)
+	}
+	var out struct {
+		Released int `json:"released"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return 0, fmt.Errorf("decode release response: %w", err)
 	}
-	resp.Body.Close()
-	return nil
+	return out.Released, nil
 }
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// reviewsClient asks the reviews service about reviews left for bookings.
+type reviewsClient struct {
+	baseURL       string
+	internalToken string
+	http          *http.Client
+}
+
+func newReviewsClient(baseURL, internalToken string) *reviewsClient {
+	return &reviewsClient{
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		internalToken: internalToken,
+		http:          &http.Client{Timeout: 3 * time.Second},
+	}
+}
+
+// HasReview reports whether the booking has already been reviewed.
+func (c *reviewsClient) HasReview(ctx context.Context, tenantID, bookingID string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.baseURL+"/reviews/internal/booking/"+url.PathEscape(bookingID), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("X-Internal-Token", c.internalToken)
+	req.Header.Set("X-Tenant-ID", tenantID)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("reviews service unavailable: %w", err)
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("reviews service returned %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/saidmashhud/zist/services/bookings/domain"
+)
+
+// bookingLimits are the tenant's guards on new bookings.
+type bookingLimits struct {
+	Amount domain.AmountLimits
+	// MaxPendingPerGuest caps a guest's pending_host_approval and
+	// payment_pending bookings; 0 means unlimited.
+	MaxPendingPerGuest int
+	// Currencies the tenant accepts bookings in; empty means any.
+	Currencies []string
+	// PaymentGraceMinutes is how late a captured payment may still confirm.
+	PaymentGraceMinutes int
+	// MinPhotos is the fewest photos a listing needs to be bookable.
+	MinPhotos int
+	// Policies is the tenant's catalog of custom cancellation policies.
+	Policies []domain.CancellationPolicy
+}
+
+// tenantLimits returns the tenant's booking limits. Unconfigured tenants get
+// zero limits (no bounds) and the default photo minimum.
+func (h *Handler) tenantLimits(ctx context.Context, tenantID string) (bookingLimits, error) {
+	cfg, err := h.Tenants.Get(ctx, tenantID)
+	if err != nil {
+		return bookingLimits{}, err
+	}
+	limits := bookingLimits{
+		Currencies:          cfg.SupportedCurrencies,
+		PaymentGraceMinutes: cfg.PaymentGraceMinutes,
+		MinPhotos:           cfg.MinPhotosToBook,
+	}
+	if cfg.MinBookingAmount != nil {
+		limits.Amount.Min = *cfg.MinBookingAmount
+	}
+	if cfg.MaxBookingAmount != nil {
+		limits.Amount.Max = *cfg.MaxBookingAmount
+	}
+	if cfg.MaxPendingBookingsPerGuest != nil {
+		limits.MaxPendingPerGuest = *cfg.MaxPendingBookingsPerGuest
+	}
+	for _, p := range cfg.CancellationPolicies {
+		limits.Policies = append(limits.Policies, domain.CancellationPolicy(p))
+	}
+	return limits, nil
+}
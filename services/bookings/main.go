@@ -11,6 +11,7 @@ import (
 
 	_ "github.com/lib/pq"
 	zistauth "github.com/saidmashhud/zist/internal/auth"
+	"github.com/saidmashhud/zist/internal/money"
 	"github.com/saidmashhud/zist/services/bookings/handler"
 	"github.com/saidmashhud/zist/services/bookings/store"
 )
@@ -34,6 +35,12 @@ func main() {
 		os.Exit(1)
 	}
 
+	rounding, err := money.NewRounding(cfg.FeeRoundingMode, cfg.CurrencyMinorUnits)
+	if err != nil {
+		slog.Error("invalid FEE_ROUNDING_MODE or CURRENCY_MINOR_UNITS", "err", err)
+		os.Exit(1)
+	}
+
 	db, err := sql.Open("postgres", cfg.DatabaseURL)
 	if err != nil {
 		slog.Error("failed to open db", "err", err)
@@ -60,9 +67,28 @@ func main() {
 
 	lc := handler.NewListingsClient(cfg.ListingsURL, cfg.InternalToken, tokenClient)
 	h := handler.New(store.New(db), lc, cfg.FeeGuestPct).
-		WithNotify(cfg.NotifyURL, cfg.MashgateAPIKey)
+		WithNotify(cfg.NotifyURL, cfg.MashgateAPIKey).
+		WithPaymentWindow(cfg.PaymentWindowMinutes).
+		WithAudit(cfg.AdminURL, cfg.InternalToken).
+		WithTenantLimits(cfg.AdminURL, cfg.InternalToken).
+		WithReviews(cfg.ReviewsURL, cfg.InternalToken).
+		WithFreeAutoConfirm(cfg.AutoConfirmFree).
+		WithGuestCancelCutoff(cfg.GuestCancelCutoffHrs).
+		WithMessageLimits(cfg.MaxMessageLength, cfg.MessagesPerHour).
+		WithRounding(rounding).
+		WithBookingEvents(cfg.EventsURL, cfg.MashgateAPIKey)
+	if cfg.ReviewReminderEnabled {
+		h.WithReviewReminders(cfg.EventsURL, cfg.MashgateAPIKey, time.Duration(cfg.ReviewReminderDelayHours)*time.Hour)
+	}
 	srv := &server{cfg: cfg, h: h}
 
+	if cfg.ExpirySweepSeconds > 0 {
+		go h.RunExpiryWorker(context.Background(), time.Duration(cfg.ExpirySweepSeconds)*time.Second)
+	}
+	if cfg.CompletionSweepSeconds > 0 {
+		go h.RunCompletionWorker(context.Background(), time.Duration(cfg.CompletionSweepSeconds)*time.Second)
+	}
+
 	slog.Info("Bookings service starting", "port", cfg.Port)
 	server := &http.Server{
 		Addr:              ":" + cfg.Port,
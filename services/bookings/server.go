@@ -34,21 +34,32 @@ func (s *server) routes() http.Handler {
 	hostAuth := chi.Chain(zistauth.RequireAuth, zistauth.RequireScope("zist.listings.manage"))
 
 	r.Route("/bookings", func(r chi.Router) {
-		// Static route before /{id}.
+		// Static routes before /{id}.
 		r.With(hostAuth...).Get("/host", s.h.ListHostBookings)
+		r.With(hostAuth...).Get("/host/analytics", s.h.HostAnalytics)
+		r.With(internal...).Get("/internal/stale-checkouts", s.h.ListStaleCheckouts)
+		r.With(internal...).Put("/internal/listings/{listingId}/host", s.h.SetListingHost)
+		r.With(internal...).Post("/internal/reconcile", s.h.ReconcileAvailability)
 
 		r.With(readAuth...).Get("/", s.h.ListBookings)
 		r.With(guestAuth...).Post("/", s.h.CreateBooking)
 
 		r.With(readAuth...).Get("/{id}", s.h.GetBooking)
+		r.With(readAuth...).Get("/{id}/receipt", s.h.GetReceipt)
+		r.With(readAuth...).Get("/{id}/summary", s.h.GetBookingSummary)
+		r.With(guestAuth...).Patch("/{id}", s.h.UpdateBooking)
 		r.With(zistauth.RequireAuth).Post("/{id}/cancel", s.h.CancelBooking)
 
 		r.With(hostAuth...).Post("/{id}/approve", s.h.ApproveBooking)
 		r.With(hostAuth...).Post("/{id}/reject", s.h.RejectBooking)
+		r.With(hostAuth...).Post("/{id}/no-show", s.h.MarkNoShow)
 
 		r.With(internal...).Post("/{id}/confirm", s.h.ConfirmBooking)
 		r.With(internal...).Post("/{id}/fail", s.h.FailBooking)
+		r.With(internal...).Post("/{id}/complete", s.h.CompleteBooking)
+		r.With(internal...).Get("/{id}/checkout", s.h.GetBookingInternal)
 		r.With(internal...).Put("/{id}/checkout", s.h.SetCheckoutID)
+		r.With(internal...).Put("/{id}/payment-status", s.h.SetPaymentStatus)
 	})
 
 	return r
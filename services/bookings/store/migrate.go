@@ -36,12 +36,27 @@ func Migrate(db *sql.DB) error {
 		`ALTER TABLE bookings ADD COLUMN IF NOT EXISTS host_id TEXT NOT NULL DEFAULT ''`,
 		`ALTER TABLE bookings ADD COLUMN IF NOT EXISTS platform_fee TEXT NOT NULL DEFAULT '0'`,
 		`ALTER TABLE bookings ADD COLUMN IF NOT EXISTS cleaning_fee TEXT NOT NULL DEFAULT '0'`,
+		`ALTER TABLE bookings ADD COLUMN IF NOT EXISTS deposit TEXT NOT NULL DEFAULT '0'`,
 		`ALTER TABLE bookings ADD COLUMN IF NOT EXISTS cancellation_policy TEXT NOT NULL DEFAULT 'flexible'`,
 		`ALTER TABLE bookings ADD COLUMN IF NOT EXISTS message TEXT NOT NULL DEFAULT ''`,
 		`ALTER TABLE bookings ADD COLUMN IF NOT EXISTS checkout_id TEXT`,
 		`ALTER TABLE bookings ADD COLUMN IF NOT EXISTS approved_at BIGINT`,
 		`ALTER TABLE bookings ADD COLUMN IF NOT EXISTS expires_at BIGINT`,
+		`ALTER TABLE bookings ADD COLUMN IF NOT EXISTS payment_window_minutes INT NOT NULL DEFAULT 0`,
 		`ALTER TABLE bookings ADD COLUMN IF NOT EXISTS payment_id TEXT`,
+		`ALTER TABLE bookings ADD COLUMN IF NOT EXISTS payment_status TEXT NOT NULL DEFAULT 'none'`,
+		`ALTER TABLE bookings ADD COLUMN IF NOT EXISTS free_cancellation_until BIGINT`,
+		`ALTER TABLE bookings ADD COLUMN IF NOT EXISTS breakdown JSONB`,
+		`ALTER TABLE bookings ADD COLUMN IF NOT EXISTS guest_email TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE bookings ADD COLUMN IF NOT EXISTS payment_grace_minutes INT NOT NULL DEFAULT 0`,
+		`ALTER TABLE bookings ADD COLUMN IF NOT EXISTS cancellation_tiers JSONB`,
+		// When the payments reconciler last picked the booking up; it
+		// rotates through the backlog by this rather than updated_at.
+		`ALTER TABLE bookings ADD COLUMN IF NOT EXISTS reconcile_attempted_at BIGINT NOT NULL DEFAULT 0`,
+		`ALTER TABLE bookings ADD COLUMN IF NOT EXISTS refunded_amount TEXT NOT NULL DEFAULT '0'`,
+		// Set when a booking expires, cleared once the listings service has
+		// released its dates; the expiry sweep retries until then.
+		`ALTER TABLE bookings ADD COLUMN IF NOT EXISTS release_pending BOOLEAN NOT NULL DEFAULT false`,
 	}
 	for _, col := range cols {
 		if _, err := db.Exec(col); err != nil {
@@ -63,19 +78,57 @@ func Migrate(db *sql.DB) error {
 		return err
 	}
 
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_bookings_release_pending ON bookings(updated_at) WHERE release_pending`); err != nil {
+		return err
+	}
+
 	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_bookings_tenant_guest ON bookings(tenant_id, guest_id, created_at DESC)`); err != nil {
 		return err
 	}
 	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_bookings_tenant_host ON bookings(tenant_id, host_id, created_at DESC)`); err != nil {
 		return err
 	}
+	// Host booking list filtered by check-in range.
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_bookings_host_check_in ON bookings(tenant_id, host_id, check_in)`); err != nil {
+		return err
+	}
+	// Expiry worker sweep: payment_pending bookings by deadline.
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_bookings_pending_expiry ON bookings(expires_at) WHERE status = 'payment_pending'`); err != nil {
+		return err
+	}
+	// Payment reconciliation: stale payment_pending bookings with a checkout.
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_bookings_pending_checkout ON bookings(updated_at) WHERE status = 'payment_pending' AND checkout_id IS NOT NULL`); err != nil {
+		return err
+	}
+
+	// One review reminder per booking; sent_at is set once the event is out.
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS review_reminders (
+			booking_id  TEXT PRIMARY KEY,
+			tenant_id   TEXT   NOT NULL,
+			listing_id  TEXT   NOT NULL,
+			guest_id    TEXT   NOT NULL,
+			host_id     TEXT   NOT NULL DEFAULT '',
+			check_in    DATE   NOT NULL,
+			check_out   DATE   NOT NULL,
+			due_at      BIGINT NOT NULL,
+			sent_at     BIGINT,
+			created_at  BIGINT NOT NULL
+		)
+	`); err != nil {
+		return err
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_review_reminders_due ON review_reminders(due_at) WHERE sent_at IS NULL`); err != nil {
+		return err
+	}
 
 	_, _ = db.Exec(`ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_status_check`)
 	_, err = db.Exec(`
 		ALTER TABLE bookings ADD CONSTRAINT bookings_status_check
 		CHECK (status IN (
 			'pending_host_approval','payment_pending','confirmed',
-			'cancelled_by_guest','cancelled_by_host','rejected','failed','completed'
+			'cancelled_by_guest','cancelled_by_host','rejected','failed','completed',
+			'expired','no_show'
 		))
 	`)
 	return err
@@ -3,9 +3,11 @@ package store
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/saidmashhud/zist/services/bookings/domain"
 )
 
@@ -15,9 +17,11 @@ var ErrNotFound = errors.New("not found")
 // bookingColumns is the SELECT list used by all queries.
 const bookingColumns = `id, listing_id, guest_id, host_id,
 	check_in::text, check_out::text, guests,
-	total_amount, platform_fee, cleaning_fee, currency,
-	status, cancellation_policy, message,
-	checkout_id, approved_at, expires_at, payment_id, created_at, updated_at`
+	total_amount, platform_fee, cleaning_fee, deposit, currency,
+	status, payment_status, cancellation_policy, free_cancellation_until, message,
+	checkout_id, approved_at, expires_at, payment_window_minutes, payment_id,
+	created_at, updated_at, breakdown, guest_email, payment_grace_minutes,
+	cancellation_tiers, refunded_amount`
 
 // Store provides all SQL operations for the bookings service.
 type Store struct {
@@ -31,14 +35,23 @@ func New(db *sql.DB) *Store { return &Store{db: db} }
 
 func scanBooking(scan func(...any) error) (domain.Booking, error) {
 	var b domain.Booking
+	var breakdown, tiers []byte
 	err := scan(
 		&b.ID, &b.ListingID, &b.GuestID, &b.HostID,
 		&b.CheckIn, &b.CheckOut, &b.Guests,
-		&b.TotalAmount, &b.PlatformFee, &b.CleaningFee, &b.Currency,
-		&b.Status, &b.CancellationPolicy, &b.Message,
-		&b.CheckoutID, &b.ApprovedAt, &b.ExpiresAt, &b.PaymentID,
-		&b.CreatedAt, &b.UpdatedAt,
+		&b.TotalAmount, &b.PlatformFee, &b.CleaningFee, &b.Deposit, &b.Currency,
+		&b.Status, &b.PaymentStatus, &b.CancellationPolicy, &b.FreeCancellationUntil, &b.Message,
+		&b.CheckoutID, &b.ApprovedAt, &b.ExpiresAt, &b.PaymentWindowMinutes, &b.PaymentID,
+		&b.CreatedAt, &b.UpdatedAt, &breakdown, &b.GuestEmail, &b.PaymentGraceMinutes,
+		&tiers, &b.RefundedAmount,
 	)
+	if len(breakdown) > 0 {
+		json.Unmarshal(breakdown, &b.Breakdown) //nolint:errcheck
+	}
+	if len(tiers) > 0 {
+		json.Unmarshal(tiers, &b.CancellationTiers) //nolint:errcheck
+	}
+	b.SetHoldRemaining(time.Now().Unix())
 	return b, err
 }
 
@@ -63,11 +76,95 @@ func (s *Store) ListByGuest(ctx context.Context, tenantID, guestID string) ([]do
 		tenantID, guestID)
 }
 
-// ListByHost returns all bookings on a host's listings (newest first, limit 100).
-func (s *Store) ListByHost(ctx context.Context, tenantID, hostID string) ([]domain.Booking, error) {
-	return s.list(ctx,
-		`SELECT `+bookingColumns+` FROM bookings WHERE tenant_id = $1 AND host_id = $2 ORDER BY created_at DESC LIMIT 100`,
-		tenantID, hostID)
+// hostFilterWhere matches a host's bookings against a HostBookingFilter;
+// $1..$6 are tenant, host, listing, from, to and status.
+const hostFilterWhere = `WHERE tenant_id = $1 AND host_id = $2
+	AND ($3 = '' OR listing_id = $3)
+	AND ($4 = '' OR check_in >= $4::date)
+	AND ($5 = '' OR check_in < $5::date)
+	AND ($6 = '' OR status = $6)`
+
+// ListByHost returns a page of bookings on a host's listings matching f,
+// newest first, along with the total number of matches.
+func (s *Store) ListByHost(ctx context.Context, tenantID, hostID string, f domain.HostBookingFilter) ([]domain.Booking, int, error) {
+	args := []any{tenantID, hostID, f.ListingID, f.From, f.To, f.Status}
+	var total int
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM bookings `+hostFilterWhere, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+bookingColumns+` FROM bookings `+hostFilterWhere+`
+		 ORDER BY created_at DESC, id DESC LIMIT $7 OFFSET $8`,
+		append(args, f.Limit, f.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var out []domain.Booking
+	for rows.Next() {
+		b, err := scanBooking(rows.Scan)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, b)
+	}
+	return out, total, rows.Err()
+}
+
+// CountPendingByGuest counts a guest's bookings still awaiting host approval
+// or payment.
+func (s *Store) CountPendingByGuest(ctx context.Context, tenantID, guestID string) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM bookings WHERE tenant_id = $1 AND guest_id = $2 AND status IN ($3, $4)`,
+		tenantID, guestID, domain.StatusPendingHostApproval, domain.StatusPaymentPending).Scan(&n)
+	return n, err
+}
+
+// ListHostStays returns a host's confirmed, completed or no-show bookings
+// whose stay overlaps [from, to). A no-show was still paid for.
+func (s *Store) ListHostStays(ctx context.Context, tenantID, hostID, from, to string) ([]domain.Booking, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+bookingColumns+` FROM bookings
+		 WHERE tenant_id = $1 AND host_id = $2 AND status IN ($3, $4, $7)
+		   AND check_in < $6::date AND check_out > $5::date`,
+		tenantID, hostID, domain.StatusConfirmed, domain.StatusCompleted, from, to, domain.StatusNoShow)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []domain.Booking
+	for rows.Next() {
+		b, err := scanBooking(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// GetByIDs fetches the tenant's bookings with the given IDs, keyed by ID.
+// IDs with no booking are absent from the map.
+func (s *Store) GetByIDs(ctx context.Context, tenantID string, ids []string) (map[string]domain.Booking, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+bookingColumns+` FROM bookings WHERE tenant_id = $1 AND id = ANY($2)`,
+		tenantID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[string]domain.Booking, len(ids))
+	for rows.Next() {
+		b, err := scanBooking(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		out[b.ID] = b
+	}
+	return out, rows.Err()
 }
 
 func (s *Store) list(ctx context.Context, query, tenantID, userID string) ([]domain.Booking, error) {
@@ -95,15 +192,25 @@ func (s *Store) list(ctx context.Context, query, tenantID, userID string) ([]dom
 
 // Create inserts a new booking.
 func (s *Store) Create(ctx context.Context, tenantID string, b domain.Booking) error {
+	var breakdown []byte
+	if b.Breakdown != nil {
+		breakdown, _ = json.Marshal(b.Breakdown)
+	}
+	var tiers []byte
+	if b.CancellationTiers != nil {
+		tiers, _ = json.Marshal(b.CancellationTiers)
+	}
 	_, err := s.db.ExecContext(ctx, `
 		INSERT INTO bookings
 			(tenant_id, id, listing_id, guest_id, host_id, check_in, check_out, guests,
-			 total_amount, platform_fee, cleaning_fee, currency, status,
-			 cancellation_policy, message, created_at, updated_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17)`,
+			 total_amount, platform_fee, cleaning_fee, deposit, currency, status,
+			 cancellation_policy, free_cancellation_until, message, expires_at, payment_window_minutes, created_at, updated_at,
+			 breakdown, guest_email, payment_grace_minutes, cancellation_tiers)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24,$25)`,
 		tenantID, b.ID, b.ListingID, b.GuestID, b.HostID, b.CheckIn, b.CheckOut, b.Guests,
-		b.TotalAmount, b.PlatformFee, b.CleaningFee, b.Currency, b.Status,
-		b.CancellationPolicy, b.Message, b.CreatedAt, b.UpdatedAt)
+		b.TotalAmount, b.PlatformFee, b.CleaningFee, b.Deposit, b.Currency, b.Status,
+		b.CancellationPolicy, b.FreeCancellationUntil, b.Message, b.ExpiresAt, b.PaymentWindowMinutes, b.CreatedAt, b.UpdatedAt,
+		breakdown, b.GuestEmail, b.PaymentGraceMinutes, tiers)
 	return err
 }
 
@@ -122,22 +229,242 @@ func (s *Store) Approve(ctx context.Context, tenantID, id string, expiresAt int6
 	return n > 0, nil
 }
 
-// Reject transitions a booking from pending_host_approval → rejected.
-func (s *Store) Reject(ctx context.Context, tenantID, id string) error {
-	_, err := s.db.ExecContext(ctx,
-		`UPDATE bookings SET status = $1, updated_at = $2 WHERE tenant_id = $3 AND id = $4`,
-		domain.StatusRejected, time.Now().Unix(), tenantID, id)
-	return err
+// UpdateGuests changes the guest count on a booking that is still pending
+// (host approval or payment). Returns false if it has moved on.
+func (s *Store) UpdateGuests(ctx context.Context, tenantID, id string, guests int) (bool, error) {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE bookings SET guests = $1, updated_at = $2
+		 WHERE tenant_id = $3 AND id = $4 AND status IN ($5, $6)`,
+		guests, time.Now().Unix(), tenantID, id,
+		domain.StatusPendingHostApproval, domain.StatusPaymentPending)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// ExpiredBooking identifies a booking whose payment hold lapsed.
+type ExpiredBooking struct {
+	TenantID  string
+	ID        string
+	ListingID string
+}
+
+// ExpireDue moves every payment_pending booking whose expires_at, plus its
+// payment grace, has passed to expired, queues their dates for release (see
+// PendingReleases) and returns them.
+func (s *Store) ExpireDue(ctx context.Context, now int64) ([]ExpiredBooking, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`UPDATE bookings SET status = $1, updated_at = $2, release_pending = true
+		 WHERE status = $3 AND expires_at IS NOT NULL AND expires_at + payment_grace_minutes * 60 <= $2
+		 RETURNING tenant_id, id, listing_id`,
+		domain.StatusExpired, now, domain.StatusPaymentPending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ExpiredBooking
+	for rows.Next() {
+		var e ExpiredBooking
+		if err := rows.Scan(&e.TenantID, &e.ID, &e.ListingID); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// PendingReleases returns up to limit expired bookings whose dates the
+// listings service hasn't released yet, oldest first.
+func (s *Store) PendingReleases(ctx context.Context, limit int) ([]ExpiredBooking, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT tenant_id, id, listing_id FROM bookings
+		 WHERE release_pending ORDER BY updated_at LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ExpiredBooking
+	for rows.Next() {
+		var e ExpiredBooking
+		if err := rows.Scan(&e.TenantID, &e.ID, &e.ListingID); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
 }
 
-// Cancel transitions a booking to a cancelled status.
-func (s *Store) Cancel(ctx context.Context, tenantID, id, newStatus string) error {
+// MarkReleased records that an expired booking's dates have been released.
+func (s *Store) MarkReleased(ctx context.Context, tenantID, id string) error {
 	_, err := s.db.ExecContext(ctx,
-		`UPDATE bookings SET status = $1, updated_at = $2 WHERE tenant_id = $3 AND id = $4`,
-		newStatus, time.Now().Unix(), tenantID, id)
+		`UPDATE bookings SET release_pending = false WHERE tenant_id = $1 AND id = $2`, tenantID, id)
 	return err
 }
 
+// StaleCheckout is a payment_pending booking whose checkout has gone quiet.
+type StaleCheckout struct {
+	TenantID   string `json:"tenantId"`
+	ID         string `json:"id"`
+	CheckoutID string `json:"checkoutId"`
+}
+
+// ListStaleCheckouts returns up to limit payment_pending bookings, across all
+// tenants, that have a checkout ID and haven't been updated since before,
+// and stamps them as attempted at now. Bookings attempted least recently come
+// first, so a checkout that stays stuck doesn't crowd out the rest of the
+// backlog on every pass.
+func (s *Store) ListStaleCheckouts(ctx context.Context, before, now int64, limit int) ([]StaleCheckout, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`UPDATE bookings SET reconcile_attempted_at = $1
+		 WHERE id IN (
+			SELECT id FROM bookings
+			WHERE status = $2 AND checkout_id IS NOT NULL AND updated_at < $3
+			ORDER BY reconcile_attempted_at, updated_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED)
+		 RETURNING tenant_id, id, checkout_id`,
+		now, domain.StatusPaymentPending, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []StaleCheckout{}
+	for rows.Next() {
+		var c StaleCheckout
+		if err := rows.Scan(&c.TenantID, &c.ID, &c.CheckoutID); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// CompletedBooking is a stay the completion sweep just closed out.
+type CompletedBooking struct {
+	TenantID  string
+	ID        string
+	ListingID string
+	GuestID   string
+	HostID    string
+	CheckIn   string
+	CheckOut  string
+}
+
+// CompleteDue moves every confirmed booking whose check-out date is on or
+// before today (YYYY-MM-DD) to completed and returns them. With a non-nil
+// reminderDueAt, their review reminders are queued in the same statement,
+// so a completed stay never misses its reminder.
+func (s *Store) CompleteDue(ctx context.Context, today string, now int64, reminderDueAt *int64) ([]CompletedBooking, error) {
+	return s.complete(ctx, now, reminderDueAt,
+		`status = $4 AND check_out <= $5::date`, domain.StatusConfirmed, today)
+}
+
+// complete moves the bookings matching cond (placeholders from $4) to
+// completed and, unless reminderDueAt is nil, queues a review reminder for
+// each; bookings that already have one keep it.
+func (s *Store) complete(ctx context.Context, now int64, reminderDueAt *int64, cond string, args ...any) ([]CompletedBooking, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`WITH done AS (
+			UPDATE bookings SET status = $1, updated_at = $2
+			WHERE `+cond+`
+			RETURNING tenant_id, id, listing_id, guest_id, host_id, check_in, check_out
+		), reminders AS (
+			INSERT INTO review_reminders
+			  (booking_id, tenant_id, listing_id, guest_id, host_id, check_in, check_out, due_at, created_at)
+			SELECT id, tenant_id, listing_id, guest_id, host_id, check_in, check_out, $3::bigint, $2
+			FROM done WHERE $3::bigint IS NOT NULL
+			ON CONFLICT (booking_id) DO NOTHING
+		)
+		SELECT tenant_id, id, listing_id, guest_id, host_id, check_in::text, check_out::text FROM done`,
+		append([]any{domain.StatusCompleted, now, reminderDueAt}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []CompletedBooking
+	for rows.Next() {
+		var c CompletedBooking
+		if err := rows.Scan(&c.TenantID, &c.ID, &c.ListingID, &c.GuestID, &c.HostID, &c.CheckIn, &c.CheckOut); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// Complete transitions one booking from confirmed → completed, provided its
+// check-out date is on or before today (YYYY-MM-DD), queueing its review
+// reminder like CompleteDue. Returns false if the booking was no longer
+// confirmed or its stay isn't over.
+func (s *Store) Complete(ctx context.Context, tenantID, id, today string, now int64, reminderDueAt *int64) (bool, error) {
+	done, err := s.complete(ctx, now, reminderDueAt,
+		`tenant_id = $4 AND id = $5 AND status = $6 AND check_out <= $7::date`,
+		tenantID, id, domain.StatusConfirmed, today)
+	return len(done) > 0, err
+}
+
+// Reject transitions a booking from pending_host_approval → rejected.
+// Returns false if the booking was no longer pending approval.
+func (s *Store) Reject(ctx context.Context, tenantID, id string) (bool, error) {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE bookings SET status = $1, updated_at = $2 WHERE tenant_id = $3 AND id = $4 AND status = $5`,
+		domain.StatusRejected, time.Now().Unix(), tenantID, id, domain.StatusPendingHostApproval)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// MarkNoShow transitions a booking from confirmed → no_show.
+// Returns false if the booking was no longer confirmed.
+func (s *Store) MarkNoShow(ctx context.Context, tenantID, id string) (bool, error) {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE bookings SET status = $1, updated_at = $2 WHERE tenant_id = $3 AND id = $4 AND status = $5`,
+		domain.StatusNoShow, time.Now().Unix(), tenantID, id, domain.StatusConfirmed)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// Cancel transitions a booking from status from to a cancelled status.
+// Returns false if the booking had meanwhile left from.
+func (s *Store) Cancel(ctx context.Context, tenantID, id, from, newStatus string) (bool, error) {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE bookings SET status = $1, updated_at = $2 WHERE tenant_id = $3 AND id = $4 AND status = $5`,
+		newStatus, time.Now().Unix(), tenantID, id, from)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// MarkRefundDue records a payment captured after the booking stopped
+// waiting for it (see Booking.LateCapture): the booking keeps its status and
+// the payment is flagged refund_due. Returns false if the booking has since
+// moved on or its payment was already refunded.
+func (s *Store) MarkRefundDue(ctx context.Context, tenantID, id, paymentID string) (bool, error) {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE bookings SET payment_status = $1, payment_id = COALESCE(NULLIF($2, ''), payment_id), updated_at = $3
+		 WHERE tenant_id = $4 AND id = $5 AND status IN ($6, $7) AND payment_status <> $8`,
+		domain.PaymentRefundDue, paymentID, time.Now().Unix(), tenantID, id,
+		domain.StatusPaymentPending, domain.StatusExpired, domain.PaymentRefunded)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
 // Confirm transitions a booking from payment_pending → confirmed.
 // paymentID may be empty. Returns false if booking was not in payment_pending.
 func (s *Store) Confirm(ctx context.Context, tenantID, id, paymentID string) (bool, error) {
@@ -146,14 +473,14 @@ func (s *Store) Confirm(ctx context.Context, tenantID, id, paymentID string) (bo
 	var err error
 	if paymentID != "" {
 		result, err = s.db.ExecContext(ctx,
-			`UPDATE bookings SET status = $1, payment_id = $2, updated_at = $3
+			`UPDATE bookings SET status = $1, payment_id = $2, payment_status = $7, updated_at = $3
 			 WHERE tenant_id = $4 AND id = $5 AND status = $6`,
-			domain.StatusConfirmed, paymentID, now, tenantID, id, domain.StatusPaymentPending)
+			domain.StatusConfirmed, paymentID, now, tenantID, id, domain.StatusPaymentPending, domain.PaymentCaptured)
 	} else {
 		result, err = s.db.ExecContext(ctx,
-			`UPDATE bookings SET status = $1, updated_at = $2
+			`UPDATE bookings SET status = $1, payment_status = $6, updated_at = $2
 			 WHERE tenant_id = $3 AND id = $4 AND status = $5`,
-			domain.StatusConfirmed, now, tenantID, id, domain.StatusPaymentPending)
+			domain.StatusConfirmed, now, tenantID, id, domain.StatusPaymentPending, domain.PaymentCaptured)
 	}
 	if err != nil {
 		return false, err
@@ -176,11 +503,51 @@ func (s *Store) Fail(ctx context.Context, tenantID, id string) (domain.Booking,
 	}
 
 	_, err = s.db.ExecContext(ctx,
-		`UPDATE bookings SET status = $1, updated_at = $2 WHERE tenant_id = $3 AND id = $4`,
-		domain.StatusFailed, time.Now().Unix(), tenantID, id)
+		`UPDATE bookings SET status = $1, payment_status = $2, updated_at = $3 WHERE tenant_id = $4 AND id = $5`,
+		domain.StatusFailed, domain.PaymentFailed, time.Now().Unix(), tenantID, id)
 	return b, err
 }
 
+// SetPaymentStatus moves a booking's payment status forward. Returns false if
+// the booking doesn't exist or its current payment status can't reach status.
+func (s *Store) SetPaymentStatus(ctx context.Context, tenantID, id, status string) (bool, error) {
+	from := domain.PaymentStatusPredecessors(status)
+	if len(from) == 0 {
+		return false, nil
+	}
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE bookings SET payment_status = $1, updated_at = $2
+		 WHERE tenant_id = $3 AND id = $4 AND payment_status = ANY($5)`,
+		status, time.Now().Unix(), tenantID, id, pq.Array(from))
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// RecordRefund adds a settled refund of amount to the booking's refunded
+// total and sets its payment status from the result: refunded once the
+// total is covered, partially_refunded before. The payment must be in a
+// status refunds may follow. Returns false if it isn't (or the booking
+// doesn't exist).
+func (s *Store) RecordRefund(ctx context.Context, tenantID, id, amount string) (bool, error) {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE bookings SET
+			refunded_amount = (refunded_amount::numeric + $1::numeric)::text,
+			payment_status = CASE WHEN refunded_amount::numeric + $1::numeric >= total_amount::numeric
+			                      THEN $2 ELSE $3 END,
+			updated_at = $4
+		 WHERE tenant_id = $5 AND id = $6 AND payment_status = ANY($7)`,
+		amount, domain.PaymentRefunded, domain.PaymentPartiallyRefunded, time.Now().Unix(),
+		tenantID, id, pq.Array(domain.PaymentStatusPredecessors(domain.PaymentPartiallyRefunded)))
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
 // SetCheckoutID stores the Mashgate checkout session ID.
 // Returns false if the booking was not found.
 func (s *Store) SetCheckoutID(ctx context.Context, tenantID, id, checkoutID string) (bool, error) {
@@ -193,3 +560,71 @@ func (s *Store) SetCheckoutID(ctx context.Context, tenantID, id, checkoutID stri
 	n, _ := result.RowsAffected()
 	return n > 0, nil
 }
+
+// SetListingHost moves every booking of a listing, and its unsent review
+// reminders, to hostID after the listing changed hands. Returns the number of
+// bookings that changed; re-running with the same host changes none.
+func (s *Store) SetListingHost(ctx context.Context, tenantID, listingID, hostID string) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE bookings SET host_id = $1, updated_at = $2
+		 WHERE tenant_id = $3 AND listing_id = $4 AND host_id <> $1`,
+		hostID, time.Now().Unix(), tenantID, listingID)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := result.RowsAffected()
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE review_reminders SET host_id = $1
+		 WHERE tenant_id = $2 AND listing_id = $3 AND sent_at IS NULL`,
+		hostID, tenantID, listingID); err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// ─── review reminders ────────────────────────────────────────────────────────
+
+// ReviewReminder is a pending nudge for a guest to review a completed stay.
+type ReviewReminder struct {
+	CompletedBooking
+	DueAt int64
+}
+
+// DueReviewReminders returns up to limit unsent reminders due at or before now.
+func (s *Store) DueReviewReminders(ctx context.Context, now int64, limit int) ([]ReviewReminder, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT booking_id, tenant_id, listing_id, guest_id, host_id, check_in::text, check_out::text, due_at
+		 FROM review_reminders
+		 WHERE sent_at IS NULL AND due_at <= $1
+		 ORDER BY due_at
+		 LIMIT $2`,
+		now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ReviewReminder
+	for rows.Next() {
+		var r ReviewReminder
+		if err := rows.Scan(&r.ID, &r.TenantID, &r.ListingID, &r.GuestID, &r.HostID, &r.CheckIn, &r.CheckOut, &r.DueAt); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// MarkReviewReminderSent records that a booking's reminder went out.
+func (s *Store) MarkReviewReminderSent(ctx context.Context, bookingID string, now int64) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE review_reminders SET sent_at = $1 WHERE booking_id = $2 AND sent_at IS NULL`,
+		now, bookingID)
+	return err
+}
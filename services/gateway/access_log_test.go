@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	defer slog.SetDefault(prev)
+
+	var upstreamReqID string
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(accessLog)
+	// Stand-in for propagateAuth: it clones the request before setting headers.
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = r.Clone(r.Context())
+			r.Header.Set("X-User-ID", "user-1")
+			r.Header.Set("X-Tenant-ID", "tenant-1")
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.Use(capturePrincipal)
+	r.Get("/api/listings/{id}", func(w http.ResponseWriter, r *http.Request) {
+		upstreamReqID = r.Header.Get(requestIDHeader)
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/listings/42", nil)
+	req.Header.Set("X-Request-Id", "req-abc")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if upstreamReqID != "req-abc" {
+		t.Errorf("upstream X-Request-ID = %q, want req-abc", upstreamReqID)
+	}
+	if got := rec.Header().Get(requestIDHeader); got != "req-abc" {
+		t.Errorf("response X-Request-ID = %q, want req-abc", got)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("log line: %v (%s)", err, buf.String())
+	}
+	want := map[string]any{
+		"request_id": "req-abc",
+		"route":      "/api/listings/{id}",
+		"status":     float64(http.StatusTeapot),
+		"user_id":    "user-1",
+		"tenant_id":  "tenant-1",
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("%s = %v, want %v", k, entry[k], v)
+		}
+	}
+	if _, ok := entry["latency_ms"]; !ok {
+		t.Error("latency_ms missing")
+	}
+}
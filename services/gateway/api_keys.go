@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apiKeyPrefix marks Zist API keys. Only bearer tokens with this prefix are
+// treated as API keys; any other Authorization header is left untouched.
+const apiKeyPrefix = "zk_"
+
+// apiKeyPrincipal is the identity an API key authenticates as.
+type apiKeyPrincipal struct {
+	KeyID     string // non-secret identifier, safe to log
+	TenantID  string
+	Scopes    string // space-separated
+	ExpiresAt int64  // unix seconds; 0 = never
+}
+
+// apiKeyStore resolves a presented key. Lookup returns nil, nil for keys it
+// does not know (or that are revoked or expired).
+type apiKeyStore interface {
+	Lookup(ctx context.Context, key string) (*apiKeyPrincipal, error)
+}
+
+// hashAPIKey returns the hex SHA-256 of key; only hashes are ever compared
+// or stored.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// staticAPIKeys holds keys configured through the environment.
+type staticAPIKeys map[string]apiKeyPrincipal // key hash → principal
+
+// newStaticAPIKey configures a single gateway-wide key bound to tenantID.
+// It returns nil when no key (or no tenant) is configured.
+func newStaticAPIKey(key, tenantID, scopes string) staticAPIKeys {
+	if key == "" {
+		return nil
+	}
+	if !strings.HasPrefix(key, apiKeyPrefix) || tenantID == "" {
+		slog.Error("GATEWAY_API_KEY ignored: it must start with " + apiKeyPrefix + " and GATEWAY_API_KEY_TENANT must be set")
+		return nil
+	}
+	h := hashAPIKey(key)
+	return staticAPIKeys{h: {KeyID: "static-" + h[:8], TenantID: tenantID, Scopes: scopes}}
+}
+
+func (s staticAPIKeys) Lookup(_ context.Context, key string) (*apiKeyPrincipal, error) {
+	if p, ok := s[hashAPIKey(key)]; ok {
+		return &p, nil
+	}
+	return nil, nil
+}
+
+// apiKeyStores tries each store in order and returns the first match.
+type apiKeyStores []apiKeyStore
+
+func (s apiKeyStores) Lookup(ctx context.Context, key string) (*apiKeyPrincipal, error) {
+	for _, st := range s {
+		p, err := st.Lookup(ctx, key)
+		if p != nil || err != nil {
+			return p, err
+		}
+	}
+	return nil, nil
+}
+
+// adminAPIKeys resolves per-tenant keys managed in the admin service. Results,
+// including misses, are cached for ttl, so a revoked key keeps working for at
+// most ttl after revocation.
+type adminAPIKeys struct {
+	baseURL       string
+	internalToken string
+	ttl           time.Duration
+	hc            *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cachedAPIKey // key hash → lookup result
+}
+
+type cachedAPIKey struct {
+	p       *apiKeyPrincipal
+	expires time.Time
+}
+
+func newAdminAPIKeys(baseURL, internalToken string, ttl time.Duration) *adminAPIKeys {
+	return &adminAPIKeys{
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		internalToken: internalToken,
+		ttl:           ttl,
+		hc:            &http.Client{Timeout: 3 * time.Second},
+		cache:         make(map[string]cachedAPIKey),
+	}
+}
+
+func (a *adminAPIKeys) Lookup(ctx context.Context, key string) (*apiKeyPrincipal, error) {
+	h := hashAPIKey(key)
+	now := time.Now()
+
+	a.mu.Lock()
+	if c, ok := a.cache[h]; ok && now.Before(c.expires) {
+		a.mu.Unlock()
+		return c.p, nil
+	}
+	a.mu.Unlock()
+
+	p, err := a.fetch(ctx, h)
+	if err != nil {
+		return nil, err // not cached: the next request retries
+	}
+
+	a.mu.Lock()
+	for k, c := range a.cache { // drop stale entries so the map stays bounded
+		if !now.Before(c.expires) {
+			delete(a.cache, k)
+		}
+	}
+	a.cache[h] = cachedAPIKey{p: p, expires: now.Add(a.ttl)}
+	a.mu.Unlock()
+	return p, nil
+}
+
+func (a *adminAPIKeys) fetch(ctx context.Context, hash string) (*apiKeyPrincipal, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL+"/admin/internal/api-keys/"+hash, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Internal-Token", a.internalToken)
+	resp, err := a.hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("admin service unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("admin service returned %d", resp.StatusCode)
+	}
+	var raw struct {
+		KeyID     string   `json:"keyId"`
+		TenantID  string   `json:"tenantId"`
+		Scopes    []string `json:"scopes"`
+		ExpiresAt *int64   `json:"expiresAt"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("decode api key: %w", err)
+	}
+	p := &apiKeyPrincipal{KeyID: raw.KeyID, TenantID: raw.TenantID, Scopes: strings.Join(raw.Scopes, " ")}
+	if raw.ExpiresAt != nil {
+		p.ExpiresAt = *raw.ExpiresAt
+	}
+	return p, nil
+}
+
+// apiKeyAuth authenticates `Authorization: Bearer zk_...` requests for
+// headless integrations. A valid key becomes X-User-* headers exactly like a
+// session cookie would, and the key itself is not forwarded upstream. An
+// unknown key is rejected rather than downgraded to anonymous. Must run after
+// propagateAuth, which strips client-supplied identity headers.
+func apiKeyAuth(keys apiKeyStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || !strings.HasPrefix(token, apiKeyPrefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			p, err := keys.Lookup(r.Context(), token)
+			if err != nil {
+				slog.Error("api key lookup failed", "err", err)
+				writeJSONError(w, http.StatusServiceUnavailable, "API key validation unavailable")
+				return
+			}
+			// A cached principal may outlive its key's expiry.
+			if p == nil || (p.ExpiresAt > 0 && time.Now().Unix() >= p.ExpiresAt) {
+				writeJSONError(w, http.StatusUnauthorized, "invalid API key")
+				return
+			}
+
+			r = r.Clone(r.Context())
+			r.Header.Del("Authorization")
+			r.Header.Set("X-User-ID", "apikey:"+p.KeyID)
+			r.Header.Set("X-Tenant-ID", p.TenantID)
+			r.Header.Set("X-User-Email", "")
+			r.Header.Set("X-User-Scopes", p.Scopes)
+
+			// Usage trail for auditing headless access.
+			slog.Info("api key request",
+				"keyId", p.KeyID, "tenantId", p.TenantID,
+				"method", r.Method, "path", r.URL.Path)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	keys := newStaticAPIKey("zk_test_secret", "tenant-1", "zist.listings.read")
+
+	var got http.Header
+	h := apiKeyAuth(keys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+
+	do := func(auth string) int {
+		got = nil
+		req := httptest.NewRequest(http.MethodGet, "/api/listings", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := do("Bearer zk_test_secret"); code != http.StatusOK {
+		t.Fatalf("valid key: want 200, got %d", code)
+	}
+	if got.Get("X-Tenant-ID") != "tenant-1" || got.Get("X-User-Scopes") != "zist.listings.read" {
+		t.Errorf("valid key: unexpected identity headers %v", got)
+	}
+	if got.Get("X-User-ID") == "" || got.Get("Authorization") != "" {
+		t.Errorf("valid key: want X-User-ID set and Authorization stripped, got %v", got)
+	}
+
+	if code := do("Bearer zk_wrong"); code != http.StatusUnauthorized {
+		t.Errorf("unknown key: want 401, got %d", code)
+	}
+
+	// Non-key bearer tokens (e.g. JWTs) and anonymous requests pass through.
+	if code := do("Bearer eyJhbGciOiJFUzI1NiJ9.e30.sig"); code != http.StatusOK || got.Get("X-User-ID") != "" {
+		t.Errorf("JWT bearer: want untouched pass-through, got %d %v", code, got)
+	}
+	if code := do(""); code != http.StatusOK || got.Get("X-User-ID") != "" {
+		t.Errorf("anonymous: want pass-through, got %d", code)
+	}
+}
+
+func TestNewStaticAPIKeyRequiresPrefixAndTenant(t *testing.T) {
+	if newStaticAPIKey("plainsecret", "tenant-1", "") != nil {
+		t.Error("key without zk_ prefix should be ignored")
+	}
+	if newStaticAPIKey("zk_secret", "", "") != nil {
+		t.Error("key without tenant should be ignored")
+	}
+}
+
+func TestAdminAPIKeysCachesLookups(t *testing.T) {
+	var calls atomic.Int32
+	good := hashAPIKey("zk_good")
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Header.Get("X-Internal-Token") != "tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if strings.TrimPrefix(r.URL.Path, "/admin/internal/api-keys/") != good {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"keyId":"k1","tenantId":"tenant-1","scopes":["zist.listings.read","zist.bookings.read"]}`)) //nolint:errcheck
+	}))
+	defer admin.Close()
+
+	keys := newAdminAPIKeys(admin.URL, "tok", time.Minute)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		p, err := keys.Lookup(ctx, "zk_good")
+		if err != nil || p == nil {
+			t.Fatalf("lookup %d: got %v, %v", i, p, err)
+		}
+		if p.TenantID != "tenant-1" || p.Scopes != "zist.listings.read zist.bookings.read" {
+			t.Errorf("unexpected principal %+v", p)
+		}
+	}
+	if p, err := keys.Lookup(ctx, "zk_bad"); p != nil || err != nil {
+		t.Errorf("unknown key: want nil, nil; got %v, %v", p, err)
+	}
+	keys.Lookup(ctx, "zk_bad") //nolint:errcheck
+	if n := calls.Load(); n != 2 {
+		t.Errorf("want 2 admin calls (one hit, one miss), got %d", n)
+	}
+}
+
+func TestAPIKeyAuthRejectsExpiredPrincipal(t *testing.T) {
+	keys := staticAPIKeys{hashAPIKey("zk_old"): {KeyID: "k", TenantID: "t", ExpiresAt: time.Now().Add(-time.Second).Unix()}}
+	h := apiKeyAuth(keys)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	req := httptest.NewRequest(http.MethodGet, "/api/listings", nil)
+	req.Header.Set("Authorization", "Bearer zk_old")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expired key: want 401, got %d", rec.Code)
+	}
+}
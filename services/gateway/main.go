@@ -22,6 +22,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"log/slog"
@@ -71,9 +72,9 @@ func main() {
 	mashgateAPIKey := getenv("MASHGATE_API_KEY", "")
 
 	r := chi.NewRouter()
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
 	r.Use(middleware.RequestID)
+	r.Use(accessLog)
+	r.Use(middleware.Recoverer)
 	r.Use(otelhttp.NewMiddleware("zist-gateway"))
 
 	// Advertise HTTP/3 on every response so browsers upgrade automatically
@@ -89,23 +90,62 @@ func main() {
 	// Runs on all /api/* requests (strips injection, sets headers from mgID).
 	r.Use(propagateAuth(mgIDURL, clientID, sessionCookieName))
 
-	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
-		fmt.Fprint(w, "ok")
-	})
+	// Headless integrations: `Authorization: Bearer zk_...` API keys, either
+	// the single env-configured key or per-tenant keys issued by admin.
+	var keys apiKeyStores
+	if static := newStaticAPIKey(
+		getenv("GATEWAY_API_KEY", ""),
+		getenv("GATEWAY_API_KEY_TENANT", ""),
+		getenv("GATEWAY_API_KEY_SCOPES", "zist.listings.read zist.bookings.read"),
+	); static != nil {
+		keys = append(keys, static)
+	}
+	if internalToken := getenv("INTERNAL_TOKEN", ""); internalToken != "" {
+		ttl := time.Duration(getenvInt("GATEWAY_API_KEY_CACHE_SECONDS", 60)) * time.Second
+		keys = append(keys, newAdminAPIKeys(adminURL, internalToken, ttl))
+	}
+	if len(keys) > 0 {
+		r.Use(apiKeyAuth(keys))
+	}
+	r.Use(capturePrincipal)
+
+	// Service backends: each *_URL may list several comma-separated instances.
+	// Backends are probed on GATEWAY_HEALTH_PATH; the services only expose
+	// /healthz today, so that is the default rather than /readyz.
+	listings := newUpstreamPool("listings", listingsURL)
+	bookings := newUpstreamPool("bookings", bookingsURL)
+	payments := newUpstreamPool("payments", paymentsURL)
+	reviews := newUpstreamPool("reviews", reviewsURL)
+	admin := newUpstreamPool("admin", adminURL)
+	search := newUpstreamPool("search", searchURL)
+	if retries := getenvInt("GATEWAY_GET_RETRIES", 1); retries > 0 {
+		for _, p := range []*upstreamPool{listings, bookings, payments, reviews, admin, search} {
+			p.withRetries(retries)
+		}
+	}
+	checker := newHealthChecker(
+		getenv("GATEWAY_HEALTH_PATH", "/healthz"),
+		time.Duration(getenvInt("GATEWAY_HEALTH_INTERVAL_SECONDS", 10))*time.Second,
+		getenvInt("GATEWAY_UNHEALTHY_THRESHOLD", 3),
+		listings, bookings, payments, reviews, admin, search,
+	)
+	go checker.Run(context.Background())
+
+	r.Get("/healthz", healthz(checker))
 
 	// Mashgate SDK client — shared by auth routes and webhook admin.
 	mg := mashgate.New(mgIDURL, mashgateAPIKey).WithEvents(mashgate.EventsConfig{})
 
 	// Auth routes via Mashgate SDK (login, logout, refresh, me)
-	mountAuth(r, mg)
+	mountAuth(r, mg, mgIDURL, parseReturnToPrefixes(getenv("GATEWAY_RETURN_TO_PREFIXES", "/")))
 
 	// API routes — listings/bookings keep service prefixes; payments expects root paths.
-	mountAPI(r, "listings", proxyTo(listingsURL))
-	mountAPI(r, "bookings", proxyTo(bookingsURL))
-	mountPaymentsAPI(r, proxyTo(paymentsURL))
-	mountAPI(r, "reviews", proxyTo(reviewsURL))
-	mountAPI(r, "admin", proxyTo(adminURL))
-	mountAPI(r, "search", proxyTo(searchURL))
+	mountAPI(r, "listings", listings)
+	mountAPI(r, "bookings", bookings)
+	mountPaymentsAPI(r, payments)
+	mountAPI(r, "reviews", reviews)
+	mountAPI(r, "admin", admin)
+	mountAPI(r, "search", search)
 
 	// Chat WebSocket proxy → HookLine (optional; enabled when CHAT_URL is set).
 	if chatURL != "" {
@@ -202,6 +242,19 @@ func mountPaymentsAPI(r chi.Router, h http.Handler) {
 	r.Handle("/api/payments/*", stripped)
 }
 
+// healthz reports the gateway as up together with the health of every
+// service backend. It answers 200 even when backends are down: the gateway
+// itself is still serving.
+func healthz(c *healthChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{ //nolint:errcheck
+			"status":    "ok",
+			"upstreams": c.status(),
+		})
+	}
+}
+
 func proxyTo(target string) http.Handler {
 	u, err := url.Parse(target)
 	if err != nil {
@@ -209,7 +262,17 @@ func proxyTo(target string) http.Handler {
 	}
 	proxy := httputil.NewSingleHostReverseProxy(u)
 	proxy.Transport = otelhttp.NewTransport(http.DefaultTransport)
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		if resp.StatusCode == http.StatusBadGateway && retrySlot(resp.Request.Context()) != nil {
+			return errUpstreamBadGateway
+		}
+		return nil
+	}
 	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		if slot := retrySlot(r.Context()); slot != nil {
+			*slot = err // the upstream pool retries; nothing written yet
+			return
+		}
 		slog.Warn("proxy error", "target", target, "path", r.URL.Path, "err", err)
 		http.Error(w, "upstream unavailable", http.StatusBadGateway)
 	}
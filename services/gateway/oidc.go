@@ -2,8 +2,11 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	mashgate "github.com/saidmashhud/mashgate/packages/sdk-go"
@@ -17,19 +20,31 @@ const (
 
 // mountAuth registers credential-based auth routes using the Mashgate SDK.
 //
-//	POST /api/auth/login    – email+password → set session + refresh cookies
+//	POST /api/auth/login    – email+password → set session + refresh cookies;
+//	                          optional ?returnTo= is validated and echoed back
 //	POST /api/auth/logout   – invalidate refresh token, clear cookies
 //	POST /api/auth/refresh  – exchange refresh token for new token pair
-//	GET  /api/auth/me       – return user info from propagateAuth headers
-func mountAuth(r chi.Router, mgClient *mashgate.Client) {
-	r.Post("/api/auth/login", handleLogin(mgClient))
+//	GET  /api/auth/me       – mgID userinfo merged with the validated session
+func mountAuth(r chi.Router, mgClient *mashgate.Client, mgIDURL string, returnToPrefixes []string) {
+	r.Post("/api/auth/login", handleLogin(mgClient, returnToPrefixes))
 	r.Post("/api/auth/logout", handleLogout(mgClient))
 	r.Post("/api/auth/refresh", handleRefresh(mgClient))
-	r.Get("/api/auth/me", handleMe())
+	r.Get("/api/auth/me", handleMe(mgIDURL))
 }
 
-func handleLogin(mgClient *mashgate.Client) http.HandlerFunc {
+// handleLogin authenticates and, on success, tells the client where to go
+// next: the requested returnTo when it is allowlisted, "/" otherwise.
+func handleLogin(mgClient *mashgate.Client, returnToPrefixes []string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		returnTo := "/"
+		if v := r.URL.Query().Get("returnTo"); v != "" {
+			if !allowedReturnTo(v, returnToPrefixes) {
+				writeJSONError(w, http.StatusBadRequest, "returnTo not allowed")
+				return
+			}
+			returnTo = v
+		}
+
 		var req struct {
 			Email    string `json:"email"`
 			Password string `json:"password"`
@@ -50,8 +65,45 @@ func handleLogin(mgClient *mashgate.Client) http.HandlerFunc {
 		}
 
 		setSessionCookies(w, r, pair)
-		writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+		writeJSON(w, http.StatusOK, map[string]any{"success": true, "returnTo": returnTo})
+	}
+}
+
+// allowedReturnTo reports whether v is a same-origin path under one of the
+// allowlisted prefixes. Anything with a scheme or host, protocol-relative
+// ("//evil.example") or backslash tricks, is rejected as an open redirect.
+func allowedReturnTo(v string, prefixes []string) bool {
+	if !strings.HasPrefix(v, "/") || strings.HasPrefix(v, "//") || strings.ContainsAny(v, "\\\r\n") {
+		return false
+	}
+	u, err := url.Parse(v)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return false
+	}
+	p := u.Path
+	if p == "" || strings.HasPrefix(p, "//") || strings.Contains(p, "/../") || strings.HasSuffix(p, "/..") {
+		return false
+	}
+	for _, prefix := range prefixes {
+		base := strings.TrimSuffix(prefix, "/")
+		if base == "" || p == base || strings.HasPrefix(p, base+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseReturnToPrefixes splits a comma-separated allowlist, keeping only
+// absolute paths.
+func parseReturnToPrefixes(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if strings.HasPrefix(p, "/") {
+			out = append(out, p)
+		}
 	}
+	return out
 }
 
 func handleLogout(mgClient *mashgate.Client) http.HandlerFunc {
@@ -84,24 +136,64 @@ func handleRefresh(mgClient *mashgate.Client) http.HandlerFunc {
 	}
 }
 
-// handleMe reads X-User-* headers injected by the propagateAuth middleware.
-// Returns 401 if the session cookie is missing or invalid.
-func handleMe() http.HandlerFunc {
+// handleMe returns the mgID userinfo for the session merged with what the
+// gateway validated: user_id, tenant_id, email and scopes come from the
+// X-User-* headers injected by propagateAuth and override the userinfo
+// fields of the same name. When mgID is unreachable only the validated
+// fields are returned.
+func handleMe(mgIDURL string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID := r.Header.Get("X-User-ID")
 		if userID == "" {
 			writeJSONError(w, http.StatusUnauthorized, "not authenticated")
 			return
 		}
-		writeJSON(w, http.StatusOK, map[string]string{
-			"user_id":   userID,
-			"tenant_id": r.Header.Get("X-Tenant-ID"),
-			"email":     r.Header.Get("X-User-Email"),
-			"scopes":    r.Header.Get("X-User-Scopes"),
-		})
+		me := map[string]any{}
+		if cookie, err := r.Cookie(sessionCookieName); err == nil && cookie.Value != "" {
+			if info, err := fetchUserinfo(r, mgIDURL, cookie.Value); err == nil {
+				me = info
+			}
+		}
+		me["user_id"] = userID
+		me["tenant_id"] = r.Header.Get("X-Tenant-ID")
+		if email := r.Header.Get("X-User-Email"); email != "" || me["email"] == nil {
+			me["email"] = email
+		}
+		me["scopes"] = r.Header.Get("X-User-Scopes")
+		writeJSON(w, http.StatusOK, me)
+	}
+}
+
+// fetchUserinfo reads the session's userinfo from mgID.
+func fetchUserinfo(r *http.Request, mgIDURL, token string) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet,
+		strings.TrimRight(mgIDURL, "/")+"/v1/auth/userinfo", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := userinfoClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("userinfo: status %d", resp.StatusCode)
 	}
+	var info map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+	if info == nil {
+		info = map[string]any{}
+	}
+	return info, nil
 }
 
+// userinfoClient bounds the userinfo call so a slow mgID degrades /me to
+// the validated fields instead of hanging it.
+var userinfoClient = &http.Client{Timeout: 3 * time.Second}
+
 // setSessionCookies writes the access token + refresh token into httpOnly cookies.
 func setSessionCookies(w http.ResponseWriter, r *http.Request, pair *mashgate.TokenPair) {
 	secure := isSecureRequest(r)
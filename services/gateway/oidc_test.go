@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAllowedReturnTo(t *testing.T) {
+	prefixes := []string{"/bookings", "/host/"}
+	cases := []struct {
+		in   string
+		want bool
+	}{
+		{"/bookings", true},
+		{"/bookings/abc?tab=payment", true},
+		{"/host/listings", true},
+		{"/host", true},
+		{"/bookingsevil", false},
+		{"/listings/1", false},
+		{"", false},
+		{"bookings", false},
+		{"//evil.example/bookings", false},
+		{"/\\evil.example", false},
+		{"https://evil.example/bookings", false},
+		{"/%2F%2Fevil.example", false},
+		{"/bookings/../admin", false},
+		{"/bookings\r\nSet-Cookie: x=1", false},
+	}
+	for _, c := range cases {
+		if got := allowedReturnTo(c.in, prefixes); got != c.want {
+			t.Errorf("allowedReturnTo(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+
+	if !allowedReturnTo("/anything/at/all", []string{"/"}) {
+		t.Error("root prefix should allow any same-origin path")
+	}
+	if allowedReturnTo("/bookings", nil) {
+		t.Error("empty allowlist should allow nothing")
+	}
+}
+
+func TestParseReturnToPrefixes(t *testing.T) {
+	got := parseReturnToPrefixes(" /bookings, ,https://x, /host/ ")
+	if len(got) != 2 || got[0] != "/bookings" || got[1] != "/host/" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestHandleLoginRejectsDisallowedReturnTo(t *testing.T) {
+	// Rejection happens before any call to Mashgate, so no client is needed.
+	h := handleLogin(nil, []string{"/bookings"})
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login?returnTo=//evil.example",
+		strings.NewReader(`{"email":"a@b.c","password":"x"}`))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "returnTo not allowed") {
+		t.Errorf("body = %s", rec.Body.String())
+	}
+}
+
+func TestHandleMeEnrichesUserinfo(t *testing.T) {
+	mgID := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/auth/userinfo" || r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// A stale tenant_id in userinfo must not win over the validated one.
+		fmt.Fprint(w, `{"name":"Aziza","email":"a@b.c","tenant_id":"stale"}`)
+	}))
+	defer mgID.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/me", nil)
+	req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: "tok"})
+	req.Header.Set("X-User-ID", "u1")
+	req.Header.Set("X-Tenant-ID", "t1")
+	req.Header.Set("X-User-Scopes", "zist.bookings.read zist.listings.manage")
+	rec := httptest.NewRecorder()
+	handleMe(mgID.URL).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var got map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"user_id":   "u1",
+		"tenant_id": "t1",
+		"email":     "a@b.c",
+		"name":      "Aziza",
+		"scopes":    "zist.bookings.read zist.listings.manage",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestHandleMeUnauthenticated(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handleMe("http://127.0.0.1:1").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/auth/me", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", rec.Code)
+	}
+}
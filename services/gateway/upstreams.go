@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ─── Upstream pools ─────────────────────────────────────────────────────────
+
+// upstream is a single backend instance of a service.
+type upstream struct {
+	target  string
+	proxy   http.Handler
+	healthy atomic.Bool
+	fails   int // consecutive failed probes; only touched by the health checker
+}
+
+// upstreamPool round-robins requests over a service's backends, skipping the
+// ones the health checker has taken out of rotation.
+type upstreamPool struct {
+	name      string
+	upstreams []*upstream
+	next      atomic.Uint64
+	retries   int // extra attempts for GET/HEAD after a 502 or connection error
+}
+
+// newUpstreamPool builds a pool from a comma-separated list of backend URLs,
+// e.g. LISTINGS_URL="http://listings-1:8001,http://listings-2:8001".
+func newUpstreamPool(name, targets string) *upstreamPool {
+	p := &upstreamPool{name: name}
+	for _, t := range strings.Split(targets, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		u := &upstream{target: t, proxy: proxyTo(t)}
+		u.healthy.Store(true)
+		p.upstreams = append(p.upstreams, u)
+	}
+	if len(p.upstreams) == 0 {
+		panic(fmt.Sprintf("no proxy targets for %s", name))
+	}
+	return p
+}
+
+// pick returns the next healthy backend. When every backend is down the pool
+// fails open and rotates over all of them, so a broken probe path cannot take
+// the whole service offline.
+func (p *upstreamPool) pick() *upstream {
+	n := uint64(len(p.upstreams))
+	start := p.next.Add(1) - 1
+	for i := uint64(0); i < n; i++ {
+		u := p.upstreams[(start+i)%n]
+		if u.healthy.Load() {
+			return u
+		}
+	}
+	return p.upstreams[start%n]
+}
+
+// withRetries lets idempotent requests be retried up to n times, each on the
+// next backend in rotation.
+func (p *upstreamPool) withRetries(n int) *upstreamPool {
+	p.retries = n
+	return p
+}
+
+func (p *upstreamPool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		for i := 0; i < p.retries && r.Context().Err() == nil; i++ {
+			var failed error
+			u := p.pick()
+			u.proxy.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), retryErrKey{}, &failed)))
+			if failed == nil {
+				return
+			}
+			slog.Warn("retrying proxied request",
+				"service", p.name, "target", u.target, "path", r.URL.Path, "err", failed)
+		}
+	}
+	// Final (or only) attempt: failures are answered with 502 as usual.
+	p.pick().proxy.ServeHTTP(w, r)
+}
+
+// retryErrKey marks a proxied request as retryable. Its value is an *error
+// the proxy's ErrorHandler fills in instead of writing a 502.
+type retryErrKey struct{}
+
+var errUpstreamBadGateway = errors.New("upstream returned 502")
+
+func retrySlot(ctx context.Context) *error {
+	slot, _ := ctx.Value(retryErrKey{}).(*error)
+	return slot
+}
+
+// upstreamStatus is one backend's entry in the /healthz report.
+type upstreamStatus struct {
+	URL     string `json:"url"`
+	Healthy bool   `json:"healthy"`
+}
+
+func (p *upstreamPool) status() []upstreamStatus {
+	out := make([]upstreamStatus, len(p.upstreams))
+	for i, u := range p.upstreams {
+		out[i] = upstreamStatus{URL: u.target, Healthy: u.healthy.Load()}
+	}
+	return out
+}
+
+// ─── Health checks ──────────────────────────────────────────────────────────
+
+// healthChecker periodically probes every backend of its pools. A backend is
+// taken out of rotation after threshold consecutive failed probes and put
+// back on the first successful one.
+type healthChecker struct {
+	pools     []*upstreamPool
+	path      string
+	interval  time.Duration
+	threshold int
+	client    *http.Client
+}
+
+func newHealthChecker(path string, interval time.Duration, threshold int, pools ...*upstreamPool) *healthChecker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	timeout := interval
+	if timeout <= 0 || timeout > 5*time.Second {
+		timeout = 5 * time.Second
+	}
+	return &healthChecker{
+		pools:     pools,
+		path:      path,
+		interval:  interval,
+		threshold: threshold,
+		client:    &http.Client{Timeout: timeout},
+	}
+}
+
+// Run probes all backends every interval until ctx is cancelled.
+// A non-positive interval disables active checks; every backend stays in rotation.
+func (c *healthChecker) Run(ctx context.Context) {
+	if c.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		c.probeAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *healthChecker) probeAll(ctx context.Context) {
+	for _, p := range c.pools {
+		for _, u := range p.upstreams {
+			c.record(p.name, u, c.probe(ctx, u))
+		}
+	}
+}
+
+func (c *healthChecker) probe(ctx context.Context, u *upstream) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(u.target, "/")+c.path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (c *healthChecker) record(name string, u *upstream, err error) {
+	if err == nil {
+		u.fails = 0
+		if !u.healthy.Swap(true) {
+			slog.Info("upstream recovered", "service", name, "target", u.target)
+		}
+		return
+	}
+	u.fails++
+	if u.fails >= c.threshold && u.healthy.Swap(false) {
+		slog.Warn("upstream removed from rotation",
+			"service", name, "target", u.target, "failures", u.fails, "err", err)
+	}
+}
+
+// status reports every pool's backends, keyed by service name.
+func (c *healthChecker) status() map[string][]upstreamStatus {
+	out := make(map[string][]upstreamStatus, len(c.pools))
+	for _, p := range c.pools {
+		out[p.name] = p.status()
+	}
+	return out
+}
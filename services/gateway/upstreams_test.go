@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestUpstreamPoolHealthChecks(t *testing.T) {
+	var downHealthy atomic.Bool
+	backend := func(name string, healthy *atomic.Bool) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/readyz" && healthy != nil && !healthy.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(name)) //nolint:errcheck
+		}))
+	}
+	a := backend("a", nil)
+	defer a.Close()
+	b := backend("b", &downHealthy)
+	defer b.Close()
+
+	pool := newUpstreamPool("listings", a.URL+", "+b.URL)
+	checker := newHealthChecker("/readyz", 0, 2, pool)
+	ctx := context.Background()
+
+	hits := func(n int) map[string]int {
+		got := map[string]int{}
+		for i := 0; i < n; i++ {
+			w := httptest.NewRecorder()
+			pool.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/listings", nil))
+			got[w.Body.String()]++
+		}
+		return got
+	}
+
+	if got := hits(4); got["a"] != 2 || got["b"] != 2 {
+		t.Fatalf("round-robin: want 2/2, got %v", got)
+	}
+
+	checker.probeAll(ctx)
+	if got := hits(4); got["b"] != 2 {
+		t.Errorf("one failed probe is below the threshold: want b kept, got %v", got)
+	}
+	checker.probeAll(ctx)
+	if got := hits(4); got["a"] != 4 {
+		t.Errorf("after threshold: want all traffic on a, got %v", got)
+	}
+
+	w := httptest.NewRecorder()
+	healthz(checker)(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	var body struct {
+		Status    string                      `json:"status"`
+		Upstreams map[string][]upstreamStatus `json:"upstreams"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	want := []upstreamStatus{{URL: a.URL, Healthy: true}, {URL: b.URL, Healthy: false}}
+	if w.Code != http.StatusOK || body.Status != "ok" || len(body.Upstreams["listings"]) != 2 ||
+		body.Upstreams["listings"][0] != want[0] || body.Upstreams["listings"][1] != want[1] {
+		t.Errorf("healthz: got %d %+v", w.Code, body)
+	}
+
+	downHealthy.Store(true)
+	checker.probeAll(ctx)
+	if got := hits(4); got["a"] != 2 || got["b"] != 2 {
+		t.Errorf("after recovery: want 2/2, got %v", got)
+	}
+}
+
+func TestUpstreamPoolFailsOpen(t *testing.T) {
+	pool := newUpstreamPool("search", "http://127.0.0.1:1")
+	for _, u := range pool.upstreams {
+		u.healthy.Store(false)
+	}
+	if pool.pick() == nil {
+		t.Fatal("want a backend even when all are unhealthy")
+	}
+}
+
+func TestUpstreamPoolRetriesGET(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// Drop the connection without a response.
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		w.Write([]byte("ok")) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	pool := newUpstreamPool("listings", srv.URL).withRetries(1)
+
+	w := httptest.NewRecorder()
+	pool.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/listings", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "ok" {
+		t.Errorf("GET: want 200 ok after retry, got %d %q", w.Code, w.Body.String())
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("GET: want 2 upstream calls, got %d", n)
+	}
+
+	calls.Store(0)
+	w = httptest.NewRecorder()
+	pool.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/listings", nil))
+	if w.Code != http.StatusBadGateway || calls.Load() != 1 {
+		t.Errorf("POST: want a single attempt and 502, got %d after %d calls", w.Code, calls.Load())
+	}
+}
+
+func TestUpstreamPoolRetriesUpstream502(t *testing.T) {
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer bad.Close()
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok")) //nolint:errcheck
+	}))
+	defer good.Close()
+
+	pool := newUpstreamPool("search", bad.URL+","+good.URL).withRetries(1)
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		pool.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/search", nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("request %d: want 200 from the other backend, got %d", i+1, w.Code)
+		}
+	}
+}
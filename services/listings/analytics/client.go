@@ -56,11 +56,15 @@ func (c *Client) Track(ctx context.Context, event string, props map[string]any)
 }
 
 // TrackListingView records a listing_view event for host analytics.
-func (c *Client) TrackListingView(ctx context.Context, tenantID, listingID, hostID string) {
+// viewerHash is an opaque viewer identity; unique is false for repeat views
+// by the same viewer within the dedup window.
+func (c *Client) TrackListingView(ctx context.Context, tenantID, listingID, hostID, viewerHash string, unique bool) {
 	go c.Track(ctx, "listing_view", map[string]any{
-		"tenant_id":  tenantID,
-		"listing_id": listingID,
-		"host_id":    hostID,
+		"tenant_id":   tenantID,
+		"listing_id":  listingID,
+		"host_id":     hostID,
+		"viewer_hash": viewerHash,
+		"unique":      unique,
 	})
 }
 
@@ -1,6 +1,9 @@
 package main
 
-import httputil "github.com/saidmashhud/zist/internal/httputil"
+import (
+	httputil "github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/services/listings/media"
+)
 
 // Config holds all configuration for the listings service, loaded from environment variables.
 type Config struct {
@@ -8,9 +11,43 @@ type Config struct {
 	DatabaseURL         string
 	InternalToken       string
 	PlatformFeeGuestPct float64
+	FeeRoundingMode     string // half_up (default) or half_even, as in bookings
+	CurrencyMinorUnits  string // CODE:places pairs, e.g. "UZS:0", as in bookings
 	MgLogsURL           string // mgLogs analytics endpoint (optional)
 	MgFlagsURL          string // mgFlags feature flags endpoint (optional)
-	MashgateAPIKey      string // shared API key for mgLogs + mgFlags
+	MgEventsURL         string // mgEvents endpoint for zist.listing.* events (optional)
+	MashgateAPIKey      string // shared API key for mgLogs, mgFlags and mgEvents
+	SearchURL           string // search service base URL for projection updates (optional)
+	ReviewsURL          string // reviews service base URL for host ratings on detail (optional)
+	AdminURL            string // admin service base URL for tenant settings and audit (optional)
+	BookingsURL         string // bookings service base URL for listing transfers (optional)
+	MgIDURL             string // mgID base URL; listing transfers are refused without it
+	MgIDAdminToken      string // mgID admin token for user lookups
+	HostRatingCacheSecs int
+
+	// Local photo storage (dev); uploads are disabled when MediaDir is empty
+	MediaDir            string
+	MediaBaseURL        string // public URL the /listings/media routes are reachable at
+	MediaSigningKey     string // HMAC key for upload URLs; defaults to InternalToken
+	PhotoUploadsPerHour int    // upload URLs per host per hour (0 = unlimited)
+
+	// Optional HEAD check on photo URLs passed to AddPhoto
+	PhotoCheckEnabled   bool
+	PhotoMaxBytes       int64  // largest accepted Content-Length (0 = unlimited)
+	PhotoCheckOnFailure string // "skip" or "reject" when the HEAD request fails
+
+	EmbedsPerMinute int    // public embed fetches per client IP per minute (0 = unlimited)
+	TrustedProxies  string // comma-separated CIDRs whose X-Forwarded-For is believed
+
+	SnoozeSweepSeconds int // how often snoozed listings are checked for republishing
+
+	Geocoder string // "static" fills missing coordinates from the city; empty disables
+
+	// Publish quality gates (price and city are always required)
+	PublishMinPhotos          int
+	PublishRequireDescription bool
+	PublishRequireAddress     bool
+	PublishAllowFree          bool
 }
 
 // LoadConfig reads configuration from environment variables with sensible defaults.
@@ -20,8 +57,39 @@ func LoadConfig() *Config {
 		DatabaseURL:         httputil.Getenv("DATABASE_URL", "postgres://dev:dev@db:5432/zist?sslmode=disable"),
 		InternalToken:       httputil.Getenv("INTERNAL_TOKEN", ""),
 		PlatformFeeGuestPct: httputil.GetenvFloat("PLATFORM_FEE_GUEST_PCT", 12.0),
+		FeeRoundingMode:     httputil.Getenv("FEE_ROUNDING_MODE", "half_up"),
+		CurrencyMinorUnits:  httputil.Getenv("CURRENCY_MINOR_UNITS", ""),
 		MgLogsURL:           httputil.Getenv("MGLOGS_URL", ""),
 		MgFlagsURL:          httputil.Getenv("MGFLAGS_URL", ""),
+		MgEventsURL:         httputil.Getenv("MGEVENTS_URL", ""),
 		MashgateAPIKey:      httputil.Getenv("MASHGATE_API_KEY", ""),
+		SearchURL:           httputil.Getenv("SEARCH_URL", ""),
+		ReviewsURL:          httputil.Getenv("REVIEWS_URL", ""),
+		AdminURL:            httputil.Getenv("ADMIN_URL", ""),
+		BookingsURL:         httputil.Getenv("BOOKINGS_URL", ""),
+		MgIDURL:             httputil.Getenv("MGID_URL", ""),
+		MgIDAdminToken:      httputil.Getenv("MGID_ADMIN_TOKEN", ""),
+		HostRatingCacheSecs: httputil.GetenvInt("HOST_RATING_CACHE_SECONDS", 60),
+
+		MediaDir:            httputil.Getenv("MEDIA_DIR", ""),
+		MediaBaseURL:        httputil.Getenv("MEDIA_BASE_URL", "http://localhost:8000/api/listings/media"),
+		MediaSigningKey:     httputil.Getenv("MEDIA_SIGNING_KEY", ""),
+		PhotoUploadsPerHour: httputil.GetenvInt("PHOTO_UPLOADS_PER_HOUR", 30),
+
+		PhotoCheckEnabled:   httputil.Getenv("PHOTO_CHECK_ENABLED", "false") == "true",
+		PhotoMaxBytes:       int64(httputil.GetenvInt("PHOTO_MAX_BYTES", media.MaxUploadBytes)),
+		PhotoCheckOnFailure: httputil.Getenv("PHOTO_CHECK_ON_FAILURE", "skip"),
+
+		EmbedsPerMinute: httputil.GetenvInt("EMBED_REQUESTS_PER_MINUTE", 120),
+		TrustedProxies:  httputil.Getenv("TRUSTED_PROXIES", httputil.DefaultTrustedProxies),
+
+		SnoozeSweepSeconds: httputil.GetenvInt("SNOOZE_SWEEP_SECONDS", 300),
+
+		Geocoder: httputil.Getenv("GEOCODER", ""),
+
+		PublishMinPhotos:          httputil.GetenvInt("PUBLISH_MIN_PHOTOS", 1),
+		PublishRequireDescription: httputil.Getenv("PUBLISH_REQUIRE_DESCRIPTION", "false") == "true",
+		PublishRequireAddress:     httputil.Getenv("PUBLISH_REQUIRE_ADDRESS", "false") == "true",
+		PublishAllowFree:          httputil.Getenv("PUBLISH_ALLOW_FREE", "false") == "true",
 	}
 }
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// MaxRangeDays bounds the window GET /listings/{id}/availability/ranges
+// covers.
+const MaxRangeDays = 731
+
+// DateRange is a run of consecutive unavailable days sharing a status and
+// booking. To is exclusive, like a booking's check-out.
+type DateRange struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Status    string `json:"status"` // blocked|booked
+	BookingID string `json:"bookingId,omitempty"`
+}
+
+// MergeRanges collapses days, sorted by date, into ranges: consecutive days
+// with the same status and booking become one. Adjacent bookings stay
+// separate ranges.
+func MergeRanges(days []AvailabilityDay) ([]DateRange, error) {
+	ranges := []DateRange{}
+	var end time.Time // exclusive end of the last range
+	for _, d := range days {
+		day, err := time.Parse(dateLayout, d.Date)
+		if err != nil {
+			return nil, err
+		}
+		next := day.AddDate(0, 0, 1).Format(dateLayout)
+		if n := len(ranges); n > 0 && day.Equal(end) &&
+			ranges[n-1].Status == d.Status && ranges[n-1].BookingID == d.BookingID {
+			ranges[n-1].To = next
+		} else {
+			ranges = append(ranges, DateRange{From: d.Date, To: next, Status: d.Status, BookingID: d.BookingID})
+		}
+		end = day.AddDate(0, 0, 1)
+	}
+	return ranges, nil
+}
+
+// ErrRangeWindow is returned by ParseRangeWindow for an unusable window.
+var ErrRangeWindow = errors.New("from and to must be YYYY-MM-DD with from before to, at most 731 days apart")
+
+// ParseRangeWindow reads the [from, to) window for availability ranges.
+// A missing from is today (UTC) and a missing to is a year after from.
+func ParseRangeWindow(from, to string, now time.Time) (string, string, error) {
+	start := now.UTC().Truncate(24 * time.Hour)
+	if from != "" {
+		t, err := time.Parse(dateLayout, from)
+		if err != nil {
+			return "", "", ErrRangeWindow
+		}
+		start = t
+	}
+	end := start.AddDate(1, 0, 0)
+	if to != "" {
+		t, err := time.Parse(dateLayout, to)
+		if err != nil {
+			return "", "", ErrRangeWindow
+		}
+		end = t
+	}
+	if !start.Before(end) || end.Sub(start) > MaxRangeDays*24*time.Hour {
+		return "", "", ErrRangeWindow
+	}
+	return start.Format(dateLayout), end.Format(dateLayout), nil
+}
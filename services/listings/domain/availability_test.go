@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMergeRanges(t *testing.T) {
+	days := []AvailabilityDay{
+		{Date: "2026-05-01", Status: "booked", BookingID: "b1"},
+		{Date: "2026-05-02", Status: "booked", BookingID: "b1"},
+		{Date: "2026-05-03", Status: "booked", BookingID: "b1"},
+		{Date: "2026-05-04", Status: "booked", BookingID: "b2"}, // back-to-back booking
+		{Date: "2026-05-07", Status: "blocked"},
+		{Date: "2026-05-08", Status: "blocked"},
+	}
+	got, err := MergeRanges(days)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DateRange{
+		{From: "2026-05-01", To: "2026-05-04", Status: "booked", BookingID: "b1"},
+		{From: "2026-05-04", To: "2026-05-05", Status: "booked", BookingID: "b2"},
+		{From: "2026-05-07", To: "2026-05-09", Status: "blocked"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeRanges = %+v, want %+v", got, want)
+	}
+	if got, _ := MergeRanges(nil); got == nil || len(got) != 0 {
+		t.Errorf("no days: want empty ranges, got %v", got)
+	}
+}
+
+func TestParseRangeWindow(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	if from, to, err := ParseRangeWindow("", "", now); err != nil || from != "2026-03-10" || to != "2027-03-10" {
+		t.Errorf("defaults: got %s..%s, %v", from, to, err)
+	}
+	for _, c := range [][2]string{{"2026-05-01", "2026-05-01"}, {"2026-05-01", "2028-06-01"}, {"05/01/2026", ""}} {
+		if _, _, err := ParseRangeWindow(c[0], c[1], now); err == nil {
+			t.Errorf("ParseRangeWindow(%q, %q): want error", c[0], c[1])
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package domain
+
+// Embed is the public projection of a listing served to cross-origin
+// widgets. It carries only what a listing card shows; host-only fields such
+// as the address, deposit or booking settings never appear here.
+type Embed struct {
+	ID            string  `json:"id"`
+	Title         string  `json:"title"`
+	City          string  `json:"city"`
+	Country       string  `json:"country"`
+	PricePerNight string  `json:"pricePerNight"`
+	Currency      string  `json:"currency"`
+	CoverPhotoURL string  `json:"coverPhotoUrl,omitempty"`
+	AverageRating float64 `json:"averageRating"`
+	ReviewCount   int     `json:"reviewCount"`
+}
+
+// NewEmbed builds the widget projection of l; cover may be nil.
+func NewEmbed(l Listing, cover *Photo) Embed {
+	e := Embed{
+		ID:            l.ID,
+		Title:         l.Title,
+		City:          l.City,
+		Country:       l.Country,
+		PricePerNight: l.PricePerNight,
+		Currency:      l.Currency,
+		AverageRating: l.AverageRating,
+		ReviewCount:   l.ReviewCount,
+	}
+	if cover != nil {
+		e.CoverPhotoURL = cover.URL
+	}
+	return e
+}
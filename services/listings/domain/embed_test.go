@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewEmbed(t *testing.T) {
+	l := Listing{
+		ID: "l1", Title: "Old Town Loft", City: "Bukhara", Country: "UZ",
+		Address: "12 Secret St", PricePerNight: "150000.00", Currency: "UZS",
+		Deposit: "50000.00", HostID: "host-1", AverageRating: 4.5, ReviewCount: 8,
+	}
+	e := NewEmbed(l, &Photo{URL: "https://cdn.example/cover.jpg"})
+	if e.CoverPhotoURL != "https://cdn.example/cover.jpg" || e.PricePerNight != "150000.00" || e.ReviewCount != 8 {
+		t.Errorf("unexpected embed: %+v", e)
+	}
+
+	b, _ := json.Marshal(e)
+	for _, field := range []string{"address", "deposit", "hostId", "12 Secret St"} {
+		if strings.Contains(string(b), field) {
+			t.Errorf("embed leaks %q: %s", field, b)
+		}
+	}
+
+	if e := NewEmbed(l, nil); e.CoverPhotoURL != "" {
+		t.Errorf("want no cover, got %q", e.CoverPhotoURL)
+	}
+}
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// ETag is a weak validator for a response made of listings. It changes when
+// any listing is updated, re-rated or has its photos changed, which covers
+// everything the detail and search responses render.
+func ETag(listings ...Listing) string {
+	h := sha256.New()
+	for _, l := range listings {
+		fmt.Fprintf(h, "%s|%d|%s|%g|%d", l.ID, l.UpdatedAt, l.Status, l.AverageRating, l.ReviewCount)
+		for _, p := range l.Photos {
+			fmt.Fprintf(h, "|%s:%d:%s", p.ID, p.SortOrder, p.URL)
+		}
+		if s := l.HostSummary; s != nil {
+			fmt.Fprintf(h, "|host:%g:%d", s.AverageRating, s.ReviewCount)
+		}
+		h.Write([]byte{'\n'})
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
@@ -0,0 +1,34 @@
+package domain
+
+import "testing"
+
+func TestETag(t *testing.T) {
+	base := Listing{ID: "l1", UpdatedAt: 100, Status: StatusActive, Photos: []Photo{{ID: "p1", SortOrder: 0}}}
+	tag := ETag(base)
+	if tag != ETag(base) {
+		t.Fatal("ETag is not deterministic")
+	}
+	if tag[:3] != `W/"` {
+		t.Errorf("want weak ETag, got %s", tag)
+	}
+
+	changes := map[string]func(l *Listing){
+		"updatedAt": func(l *Listing) { l.UpdatedAt = 101 },
+		"rating":    func(l *Listing) { l.AverageRating = 4.5 },
+		"photos":    func(l *Listing) { l.Photos = append(l.Photos, Photo{ID: "p2", SortOrder: 1}) },
+		"reorder":   func(l *Listing) { l.Photos = []Photo{{ID: "p1", SortOrder: 3}} },
+		"host":      func(l *Listing) { l.HostSummary = &HostSummary{AverageRating: 4.9, ReviewCount: 3} },
+	}
+	for name, change := range changes {
+		l := base
+		l.Photos = append([]Photo(nil), base.Photos...)
+		change(&l)
+		if ETag(l) == tag {
+			t.Errorf("%s change kept the ETag", name)
+		}
+	}
+
+	if ETag(base, Listing{ID: "l2"}) == ETag(Listing{ID: "l2"}, base) {
+		t.Error("result order should affect the ETag")
+	}
+}
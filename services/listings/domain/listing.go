@@ -1,17 +1,62 @@
 // Package domain defines the core domain types for the listings service.
 package domain
 
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/saidmashhud/zist/internal/tenantconfig"
+)
+
+// Listing statuses. Archived listings are off the market for good: they stay
+// readable but must be unarchived (back to paused) before they can be
+// published again.
+const (
+	StatusDraft    = "draft"
+	StatusActive   = "active"
+	StatusPaused   = "paused"
+	StatusArchived = "archived"
+)
+
+// BulkStatusFrom returns the status a bulk status change to to applies to:
+// hosts can pause all their active listings or resume all their paused ones.
+// ok is false for any other target.
+func BulkStatusFrom(to string) (from string, ok bool) {
+	switch to {
+	case StatusPaused:
+		return StatusActive, true
+	case StatusActive:
+		return StatusPaused, true
+	}
+	return "", false
+}
+
+// Where a listing's coordinates came from.
+const (
+	LocationProvided = "provided" // sent by the host
+	LocationGeocoded = "geocoded" // filled in from the city
+)
+
 // Listing represents a rental property listing.
 type Listing struct {
 	ID          string `json:"id"`
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	// Location
-	City    string `json:"city"`
-	Country string `json:"country"`
-	Address string `json:"address"`
+	City    string   `json:"city"`
+	Country string   `json:"country"`
+	Address string   `json:"address"`
+	Lat     *float64 `json:"lat,omitempty"`
+	Lng     *float64 `json:"lng,omitempty"`
+	// LocationSource is provided or geocoded; empty when there are no
+	// coordinates.
+	LocationSource string `json:"locationSource,omitempty"`
 	// Property
-	Type      string `json:"type"` // apartment|house|guesthouse|room
+	Type      string `json:"type"` // one of the tenant's allowed types
 	Bedrooms  int    `json:"bedrooms"`
 	Beds      int    `json:"beds"`
 	Bathrooms int    `json:"bathrooms"`
@@ -28,10 +73,18 @@ type Listing struct {
 	MinNights int `json:"minNights"`
 	MaxNights int `json:"maxNights"`
 	// Booking settings
-	CancellationPolicy string `json:"cancellationPolicy"` // flexible|moderate|strict
-	InstantBook        bool   `json:"instantBook"`
+	CancellationPolicy   string `json:"cancellationPolicy"` // a built-in or one from the tenant's catalog
+	InstantBook          bool   `json:"instantBook"`
+	PaymentWindowMinutes int    `json:"paymentWindowMinutes"` // 0 = platform default
+	// PayOnArrival confirms instant bookings without online payment; the
+	// guest pays the host in person.
+	PayOnArrival bool `json:"payOnArrival"`
+	// Timezone is the IANA zone the listing's calendar dates are local to;
+	// empty means UTC.
+	Timezone string `json:"timezone"`
 	// Status & ratings
-	Status        string  `json:"status"` // draft|active|paused
+	Status        string  `json:"status"`                // draft|active|paused|archived
+	SnoozeUntil   string  `json:"snoozeUntil,omitempty"` // YYYY-MM-DD; republished on this date
 	AverageRating float64 `json:"averageRating"`
 	ReviewCount   int     `json:"reviewCount"`
 	// Meta
@@ -39,7 +92,74 @@ type Listing struct {
 	CreatedAt int64  `json:"createdAt"`
 	UpdatedAt int64  `json:"updatedAt"`
 	// Computed (loaded separately)
-	Photos []Photo `json:"photos,omitempty"`
+	Photos      []Photo      `json:"photos,omitempty"`
+	HostSummary *HostSummary `json:"hostSummary,omitempty"` // detail view only
+}
+
+// HostSummary is the host's rating across all their listings, as reported
+// by the reviews service.
+type HostSummary struct {
+	AverageRating float64 `json:"averageRating"`
+	ReviewCount   int     `json:"reviewCount"`
+}
+
+// ListingCapacity reports how many nights of a window a host blocked on a
+// listing; the rest of the window was bookable.
+type ListingCapacity struct {
+	ListingID     string `json:"listingId"`
+	Title         string `json:"title"`
+	BlockedNights int    `json:"blockedNights"`
+}
+
+// Reservation is a booking's hold on a listing: the dates marked booked
+// for it, in order. BookingID is empty for rows that lost theirs.
+type Reservation struct {
+	ListingID string   `json:"listingId"`
+	BookingID string   `json:"bookingId"`
+	Dates     []string `json:"dates"`
+}
+
+// OrderByIDs returns listings arranged in the order of ids, dropping ids
+// with no matching listing.
+func OrderByIDs(listings []Listing, ids []string) []Listing {
+	byID := make(map[string]Listing, len(listings))
+	for _, l := range listings {
+		byID[l.ID] = l
+	}
+	out := make([]Listing, 0, len(ids))
+	for _, id := range ids {
+		if l, ok := byID[id]; ok {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// SplitIDs parses a comma-separated id list, skipping blanks and repeats
+// while keeping the first-seen order.
+func SplitIDs(raw string) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, id := range strings.Split(raw, ",") {
+		if id = strings.TrimSpace(id); id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Comparable arranges listings for a side-by-side comparison in the order of
+// ids. Missing ids and archived listings are left out.
+func Comparable(listings []Listing, ids []string) []Listing {
+	ordered := OrderByIDs(listings, ids)
+	out := ordered[:0]
+	for _, l := range ordered {
+		if l.Status != StatusArchived {
+			out = append(out, l)
+		}
+	}
+	return out
 }
 
 // HouseRules describes behaviour rules for a listing.
@@ -78,57 +198,117 @@ type PricePreview struct {
 	Subtotal         string `json:"subtotal"`
 	CleaningFee      string `json:"cleaningFee"`
 	PlatformFeeGuest string `json:"platformFeeGuest"`
+	Deposit          string `json:"deposit"` // refundable; included in Total, not in the fee base
 	Total            string `json:"total"`
 	Currency         string `json:"currency"`
 }
 
+// ViewStats summarises listing views for the host. UniqueViews counts views
+// after per-viewer deduplication; UniqueViewers counts distinct viewers.
+type ViewStats struct {
+	Days          int `json:"days"`
+	Views         int `json:"views"`
+	UniqueViews   int `json:"uniqueViews"`
+	UniqueViewers int `json:"uniqueViewers"`
+}
+
+// SearchDocument is the projection of a listing pushed to the search service.
+type SearchDocument struct {
+	ID            string   `json:"id"`
+	TenantID      string   `json:"tenantId"`
+	HostID        string   `json:"hostId"`
+	Title         string   `json:"title"`
+	City          string   `json:"city"`
+	Country       string   `json:"country"`
+	Type          string   `json:"type"`
+	PricePerNight string   `json:"pricePerNight"`
+	Currency      string   `json:"currency"`
+	MaxGuests     int      `json:"maxGuests"`
+	InstantBook   bool     `json:"instantBook"`
+	AverageRating float64  `json:"averageRating"`
+	ReviewCount   int      `json:"reviewCount"`
+	Amenities     []string `json:"amenities"`
+	CoverPhoto    string   `json:"coverPhoto"`
+	Status        string   `json:"status"`
+	Lat           *float64 `json:"lat,omitempty"`
+	Lng           *float64 `json:"lng,omitempty"`
+	Timezone      string   `json:"timezone,omitempty"`
+	CreatedAt     int64    `json:"createdAt"`
+	UpdatedAt     int64    `json:"updatedAt"`
+}
+
 // CreateListingInput holds validated fields for a new listing.
 type CreateListingInput struct {
-	TenantID           string
-	HostID             string
-	Title              string
-	Description        string
-	City               string
-	Country            string
-	Address            string
-	Type               string
-	Bedrooms           int
-	Beds               int
-	Bathrooms          int
-	MaxGuests          int
-	Amenities          []string
-	Rules              HouseRules
-	PricePerNight      string
-	Currency           string
-	CleaningFee        string
-	Deposit            string
-	MinNights          int
-	MaxNights          int
-	CancellationPolicy string
-	InstantBook        bool
+	TenantID             string
+	HostID               string
+	Title                string
+	Description          string
+	City                 string
+	Country              string
+	Address              string
+	Lat                  *float64
+	Lng                  *float64
+	LocationSource       string
+	Type                 string
+	Bedrooms             int
+	Beds                 int
+	Bathrooms            int
+	MaxGuests            int
+	Amenities            []string
+	Rules                HouseRules
+	PricePerNight        string
+	Currency             string
+	CleaningFee          string
+	Deposit              string
+	MinNights            int
+	MaxNights            int
+	CancellationPolicy   string
+	InstantBook          bool
+	PaymentWindowMinutes int
+	PayOnArrival         bool
+	Timezone             string
+}
+
+// MissingFields returns the required fields left blank in in.
+func (in CreateListingInput) MissingFields(required []string) []string {
+	return MissingFields(Listing{
+		Title:         in.Title,
+		Description:   in.Description,
+		City:          in.City,
+		Country:       in.Country,
+		Address:       in.Address,
+		PricePerNight: in.PricePerNight,
+		Timezone:      in.Timezone,
+	}, required)
 }
 
 // UpdateListingInput holds optional fields for a partial update.
 type UpdateListingInput struct {
-	Title              *string
-	Description        *string
-	Address            *string
-	Type               *string
-	Bedrooms           *int
-	Beds               *int
-	Bathrooms          *int
-	MaxGuests          *int
-	Amenities          []string
-	Rules              *HouseRules
-	PricePerNight      *string
-	Currency           *string
-	CleaningFee        *string
-	Deposit            *string
-	MinNights          *int
-	MaxNights          *int
-	CancellationPolicy *string
-	InstantBook        *bool
-	Status             *string
+	Title                *string
+	Description          *string
+	Address              *string
+	Lat                  *float64 // set together with Lng
+	Lng                  *float64
+	LocationSource       *string
+	Type                 *string
+	Bedrooms             *int
+	Beds                 *int
+	Bathrooms            *int
+	MaxGuests            *int
+	Amenities            []string
+	Rules                *HouseRules
+	PricePerNight        *string
+	Currency             *string
+	CleaningFee          *string
+	Deposit              *string
+	MinNights            *int
+	MaxNights            *int
+	CancellationPolicy   *string
+	InstantBook          *bool
+	PaymentWindowMinutes *int
+	PayOnArrival         *bool
+	Timezone             *string
+	Status               *string
 }
 
 // SearchFilters holds all query parameters for listing search.
@@ -142,5 +322,106 @@ type SearchFilters struct {
 	MaxPrice        string
 	Amenities       []string
 	InstantBookOnly bool
-	Limit           int
+	// MinRating keeps listings rated at least this; unrated listings (0)
+	// drop out of any positive threshold.
+	MinRating float64
+	Limit     int
+}
+
+// ParseMinRating parses a minRating query value (0–5); empty means no
+// threshold.
+func ParseMinRating(v string) (float64, error) {
+	if v == "" {
+		return 0, nil
+	}
+	r, err := strconv.ParseFloat(v, 64)
+	if err != nil || r < 0 || r > 5 {
+		return 0, errors.New("minRating must be a number between 0 and 5")
+	}
+	return r, nil
+}
+
+// DefaultCancellationPolicy applies when a listing is created without one.
+const DefaultCancellationPolicy = "moderate"
+
+// CheckCancellationPolicy returns a caller-facing error unless name is a
+// built-in policy (available to every tenant) or one of the tenant's custom
+// policies.
+func CheckCancellationPolicy(name string, custom []string) error {
+	builtin := tenantconfig.BuiltinPolicyNames()
+	if slices.Contains(builtin, name) || slices.Contains(custom, name) {
+		return nil
+	}
+	return fmt.Errorf("unknown cancellationPolicy %q; choose one of %s",
+		name, strings.Join(append(builtin, custom...), ", "))
+}
+
+// DefaultListingType is given to listings created without a type.
+const DefaultListingType = "apartment"
+
+// ResolveListingType checks a listing's type against the tenant's allowed
+// types (tenantconfig.DefaultListingTypes if none). An empty type becomes
+// DefaultListingType, or the first allowed type if that isn't allowed.
+func ResolveListingType(t string, allowed []string) (string, error) {
+	if len(allowed) == 0 {
+		allowed = tenantconfig.DefaultListingTypes()
+	}
+	t = strings.ToLower(strings.TrimSpace(t))
+	switch {
+	case t == "" && slices.Contains(allowed, DefaultListingType):
+		return DefaultListingType, nil
+	case t == "":
+		return allowed[0], nil
+	case slices.Contains(allowed, t):
+		return t, nil
+	}
+	return "", fmt.Errorf("unknown type %q; choose one of %s", t, strings.Join(allowed, ", "))
+}
+
+// ValidateCoordinates checks an optional listing location: lat and lng are
+// given together (or not at all) and lie within WGS84 ranges.
+func ValidateCoordinates(lat, lng *float64) error {
+	if lat == nil && lng == nil {
+		return nil
+	}
+	if lat == nil || lng == nil {
+		return errors.New("lat and lng must be given together")
+	}
+	if *lat < -90 || *lat > 90 {
+		return errors.New("lat must be between -90 and 90")
+	}
+	if *lng < -180 || *lng > 180 {
+		return errors.New("lng must be between -180 and 180")
+	}
+	return nil
+}
+
+// ValidateTimezone checks a listing timezone: empty (UTC) or an IANA zone
+// name such as "Asia/Tashkent".
+func ValidateTimezone(tz string) error {
+	if tz == "" {
+		return nil
+	}
+	if tz == "Local" {
+		return errors.New("timezone must be an IANA zone name")
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return fmt.Errorf("unknown timezone %q", tz)
+	}
+	return nil
+}
+
+// CheckCurrency reports whether a listing may be priced in currency under a
+// tenant's supported currencies. An empty list allows any code.
+func CheckCurrency(supported []string, currency string) error {
+	if len(supported) == 0 {
+		return nil
+	}
+	for _, c := range supported {
+		if strings.EqualFold(c, currency) {
+			return nil
+		}
+	}
+	return fmt.Errorf("currency %s is not supported; use one of: %s",
+		strings.ToUpper(currency), strings.Join(supported, ", "))
 }
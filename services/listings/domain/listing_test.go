@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOrderByIDs(t *testing.T) {
+	listings := []Listing{{ID: "c"}, {ID: "a"}, {ID: "b"}}
+	got := OrderByIDs(listings, []string{"b", "missing", "c", "a"})
+	var ids []string
+	for _, l := range got {
+		ids = append(ids, l.ID)
+	}
+	if want := []string{"b", "c", "a"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("want %v, got %v", want, ids)
+	}
+}
+
+func TestComparable(t *testing.T) {
+	ids := SplitIDs(" b, a,,c ,b,gone")
+	if want := []string{"b", "a", "c", "gone"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("SplitIDs: want %v, got %v", want, ids)
+	}
+	listings := []Listing{
+		{ID: "a", AverageRating: 4.2},
+		{ID: "c", AverageRating: 3.9},
+		{ID: "b", AverageRating: 4.8},
+		{ID: "old", Status: StatusArchived},
+	}
+	got := Comparable(listings, append(ids, "old"))
+	var seen []string
+	for _, l := range got {
+		seen = append(seen, l.ID)
+	}
+	if want := []string{"b", "a", "c"}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("want %v, got %v", want, seen)
+	}
+	if got[0].AverageRating != 4.8 {
+		t.Errorf("rating not carried: got %v", got[0].AverageRating)
+	}
+}
+
+func TestValidateCoordinates(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name     string
+		lat, lng *float64
+		ok       bool
+	}{
+		{"none", nil, nil, true},
+		{"tashkent", f(41.2995), f(69.2401), true},
+		{"bounds", f(-90), f(180), true},
+		{"lat only", f(41.3), nil, false},
+		{"lng only", nil, f(69.2), false},
+		{"lat out of range", f(90.01), f(0), false},
+		{"lng out of range", f(0), f(-180.5), false},
+	}
+	for _, tt := range tests {
+		if err := ValidateCoordinates(tt.lat, tt.lng); (err == nil) != tt.ok {
+			t.Errorf("%s: ValidateCoordinates err = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestValidateTimezone(t *testing.T) {
+	for _, tz := range []string{"", "UTC", "Asia/Tashkent", "America/New_York"} {
+		if err := ValidateTimezone(tz); err != nil {
+			t.Errorf("%q: unexpected error %v", tz, err)
+		}
+	}
+	for _, tz := range []string{"Local", "Mars/Olympus", "+05:00"} {
+		if err := ValidateTimezone(tz); err == nil {
+			t.Errorf("%q: want error", tz)
+		}
+	}
+}
+
+func TestCheckCurrency(t *testing.T) {
+	uzsOnly := []string{"UZS"}
+	if err := CheckCurrency(uzsOnly, "USD"); err == nil {
+		t.Error("UZS-only tenant: want USD listing rejected")
+	}
+	if err := CheckCurrency(uzsOnly, "uzs"); err != nil {
+		t.Errorf("UZS-only tenant: lower-case uzs rejected: %v", err)
+	}
+	if err := CheckCurrency(nil, "EUR"); err != nil {
+		t.Errorf("no restriction: want any code allowed, got %v", err)
+	}
+}
+
+func TestBulkStatusFrom(t *testing.T) {
+	cases := []struct {
+		to, from string
+		ok       bool
+	}{
+		{StatusPaused, StatusActive, true},
+		{StatusActive, StatusPaused, true},
+		{StatusArchived, "", false},
+		{StatusDraft, "", false},
+	}
+	for _, c := range cases {
+		if from, ok := BulkStatusFrom(c.to); from != c.from || ok != c.ok {
+			t.Errorf("BulkStatusFrom(%q) = %q, %v; want %q, %v", c.to, from, ok, c.from, c.ok)
+		}
+	}
+}
+
+func TestParseMinRating(t *testing.T) {
+	if got, err := ParseMinRating("4"); err != nil || got != 4 {
+		t.Errorf("ParseMinRating(4) = %v, %v", got, err)
+	}
+	if got, err := ParseMinRating(""); err != nil || got != 0 {
+		t.Errorf("ParseMinRating(\"\") = %v, %v; want no threshold", got, err)
+	}
+	for _, v := range []string{"-0.5", "6", "x"} {
+		if _, err := ParseMinRating(v); err == nil {
+			t.Errorf("ParseMinRating(%q): want error", v)
+		}
+	}
+}
+
+func TestCheckCancellationPolicy(t *testing.T) {
+	if err := CheckCancellationPolicy("strict", nil); err != nil {
+		t.Errorf("built-in: %v", err)
+	}
+	if err := CheckCancellationPolicy("super_strict", []string{"super_strict"}); err != nil {
+		t.Errorf("custom: %v", err)
+	}
+	if err := CheckCancellationPolicy("super_strict", nil); err == nil {
+		t.Error("not in catalog: want error")
+	}
+}
+
+func TestResolveListingType(t *testing.T) {
+	tests := []struct {
+		in      string
+		allowed []string
+		want    string
+		wantErr bool
+	}{
+		{"", nil, "apartment", false},
+		{" House ", nil, "house", false},
+		{"yurt", nil, "", true},
+		{"yurt", []string{"yurt", "room"}, "yurt", false},
+		{"", []string{"yurt", "room"}, "yurt", false},
+		{"apartment", []string{"yurt", "room"}, "", true},
+	}
+	for _, tt := range tests {
+		got, err := ResolveListingType(tt.in, tt.allowed)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ResolveListingType(%q, %v) = %q, %v; want %q, error %v", tt.in, tt.allowed, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PublishCheck is what publish rules inspect: the listing plus facts that are
+// loaded separately from it.
+type PublishCheck struct {
+	Listing    Listing
+	PhotoCount int
+}
+
+// PublishRule is a single quality gate for publishing. It returns a short
+// description of the unmet requirement, or "" when the listing passes.
+type PublishRule func(c PublishCheck) string
+
+// MinPhotos requires at least n photos.
+func MinPhotos(n int) PublishRule {
+	return func(c PublishCheck) string {
+		if c.PhotoCount >= n {
+			return ""
+		}
+		if n == 1 {
+			return "at least one photo is required"
+		}
+		return fmt.Sprintf("at least %d photos are required", n)
+	}
+}
+
+// RequireDescription requires a non-blank description.
+func RequireDescription() PublishRule {
+	return func(c PublishCheck) string {
+		if strings.TrimSpace(c.Listing.Description) == "" {
+			return "description is required"
+		}
+		return ""
+	}
+}
+
+// RequirePositivePrice requires pricePerNight to parse as a number above zero.
+func RequirePositivePrice() PublishRule {
+	return func(c PublishCheck) string {
+		p, err := strconv.ParseFloat(strings.TrimSpace(c.Listing.PricePerNight), 64)
+		if err != nil || p <= 0 {
+			return "pricePerNight must be greater than 0"
+		}
+		return ""
+	}
+}
+
+// RequireNonNegativePrice requires pricePerNight to parse as a number of at
+// least zero, admitting free listings.
+func RequireNonNegativePrice() PublishRule {
+	return func(c PublishCheck) string {
+		p, err := strconv.ParseFloat(strings.TrimSpace(c.Listing.PricePerNight), 64)
+		if err != nil || p < 0 {
+			return "pricePerNight must be 0 or more"
+		}
+		return ""
+	}
+}
+
+// RequireLocation requires a city and, if requireAddress is set, a street address.
+func RequireLocation(requireAddress bool) PublishRule {
+	return func(c PublishCheck) string {
+		switch {
+		case strings.TrimSpace(c.Listing.City) == "":
+			return "city is required"
+		case requireAddress && strings.TrimSpace(c.Listing.Address) == "":
+			return "address is required"
+		}
+		return ""
+	}
+}
+
+// requirableFields reads each field a tenant can make mandatory; its keys
+// are tenantconfig.RequirableListingFields.
+var requirableFields = map[string]func(Listing) string{
+	"title":         func(l Listing) string { return l.Title },
+	"description":   func(l Listing) string { return l.Description },
+	"city":          func(l Listing) string { return l.City },
+	"country":       func(l Listing) string { return l.Country },
+	"address":       func(l Listing) string { return l.Address },
+	"pricePerNight": func(l Listing) string { return l.PricePerNight },
+	"timezone":      func(l Listing) string { return l.Timezone },
+}
+
+// MissingFields returns the required fields that are blank in l, in the
+// order given. Names it doesn't know are ignored.
+func MissingFields(l Listing, required []string) []string {
+	missing := []string{}
+	for _, name := range required {
+		get, ok := requirableFields[name]
+		if ok && strings.TrimSpace(get(l)) == "" && !slices.Contains(missing, name) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// RequireFields requires each named field to be filled in.
+func RequireFields(fields []string) PublishRule {
+	return func(c PublishCheck) string {
+		if missing := MissingFields(c.Listing, fields); len(missing) > 0 {
+			return "required fields missing: " + strings.Join(missing, ", ")
+		}
+		return ""
+	}
+}
+
+// PublishConfig selects the built-in publish rules.
+type PublishConfig struct {
+	MinPhotos          int
+	RequireDescription bool
+	RequireAddress     bool
+	AllowFree          bool // accept pricePerNight = 0
+}
+
+// Rules returns the rule set for c. Price and city are always checked.
+func (c PublishConfig) Rules() []PublishRule {
+	minPhotos := c.MinPhotos
+	if minPhotos < 1 {
+		minPhotos = 1
+	}
+	price := RequirePositivePrice()
+	if c.AllowFree {
+		price = RequireNonNegativePrice()
+	}
+	rules := []PublishRule{MinPhotos(minPhotos), price, RequireLocation(c.RequireAddress)}
+	if c.RequireDescription {
+		rules = append(rules, RequireDescription())
+	}
+	return rules
+}
+
+// CheckPublish runs every rule and returns all unmet requirements, so hosts
+// can fix everything in one pass. An empty result means the listing may be
+// published.
+func CheckPublish(c PublishCheck, rules []PublishRule) []string {
+	missing := []string{}
+	for _, rule := range rules {
+		if msg := rule(c); msg != "" {
+			missing = append(missing, msg)
+		}
+	}
+	return missing
+}
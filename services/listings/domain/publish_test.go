@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/saidmashhud/zist/internal/tenantconfig"
+)
+
+func TestCheckPublish_MultipleFailures(t *testing.T) {
+	rules := PublishConfig{MinPhotos: 3, RequireDescription: true, RequireAddress: true}.Rules()
+	c := PublishCheck{
+		Listing:    Listing{City: "Tashkent", PricePerNight: "0"},
+		PhotoCount: 1,
+	}
+	got := CheckPublish(c, rules)
+	want := []string{
+		"at least 3 photos are required",
+		"pricePerNight must be greater than 0",
+		"address is required",
+		"description is required",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckPublish = %q, want %q", got, want)
+	}
+}
+
+func TestCheckPublish_Passes(t *testing.T) {
+	rules := PublishConfig{MinPhotos: 1, RequireDescription: true}.Rules()
+	c := PublishCheck{
+		Listing: Listing{
+			City:          "Samarkand",
+			Description:   "Quiet courtyard house",
+			PricePerNight: "120000.00",
+		},
+		PhotoCount: 2,
+	}
+	if got := CheckPublish(c, rules); len(got) != 0 {
+		t.Errorf("CheckPublish = %q, want none", got)
+	}
+}
+
+func TestPublishConfig_DefaultsKeepPhotoCheck(t *testing.T) {
+	rules := PublishConfig{}.Rules()
+	c := PublishCheck{Listing: Listing{City: "Bukhara", PricePerNight: "50.00"}}
+	got := CheckPublish(c, rules)
+	if len(got) != 1 || got[0] != "at least one photo is required" {
+		t.Errorf("CheckPublish = %q, want only the photo requirement", got)
+	}
+}
+
+func TestPublishConfig_AllowFree(t *testing.T) {
+	free := PublishCheck{Listing: Listing{City: "Khiva", PricePerNight: "0"}, PhotoCount: 1}
+	if got := CheckPublish(free, PublishConfig{}.Rules()); len(got) != 1 {
+		t.Errorf("free listing without AllowFree: got %q, want price requirement", got)
+	}
+	if got := CheckPublish(free, PublishConfig{AllowFree: true}.Rules()); len(got) != 0 {
+		t.Errorf("free listing with AllowFree: got %q, want none", got)
+	}
+	negative := PublishCheck{Listing: Listing{City: "Khiva", PricePerNight: "-5"}, PhotoCount: 1}
+	if got := CheckPublish(negative, PublishConfig{AllowFree: true}.Rules()); len(got) != 1 || got[0] != "pricePerNight must be 0 or more" {
+		t.Errorf("negative price with AllowFree: got %q", got)
+	}
+}
+
+func TestMissingFields(t *testing.T) {
+	l := Listing{Title: "Loft", City: " ", PricePerNight: "90"}
+	got := MissingFields(l, []string{"title", "city", "pricePerNight", "address", "city", "unknown"})
+	if want := []string{"city", "address"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MissingFields = %q, want %q", got, want)
+	}
+}
+
+func TestRequirableFieldsMatchTenantConfig(t *testing.T) {
+	for _, name := range tenantconfig.RequirableListingFields {
+		if _, ok := requirableFields[name]; !ok {
+			t.Errorf("admin accepts %q but listings can't check it", name)
+		}
+	}
+	if len(requirableFields) != len(tenantconfig.RequirableListingFields) {
+		t.Errorf("listings checks %d fields, admin accepts %d", len(requirableFields), len(tenantconfig.RequirableListingFields))
+	}
+	for _, name := range tenantconfig.DefaultRequiredListingFields() {
+		if _, ok := requirableFields[name]; !ok {
+			t.Errorf("default required field %q can't be checked", name)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/saidmashhud/zist/internal/money"
+	"github.com/shopspring/decimal"
+)
+
+// MaxRateMultiplier bounds a rate plan's multiplier.
+const MaxRateMultiplier = 10
+
+// RatePlan scales a listing's base price by Multiplier for the nights in
+// [From, To), e.g. "1.5" for summer. Per-date price overrides still win.
+// Multiplier is a decimal string so nightly prices stay exact.
+type RatePlan struct {
+	ID         string `json:"id"`
+	ListingID  string `json:"listingId"`
+	Name       string `json:"name"`
+	From       string `json:"from"` // YYYY-MM-DD, first night
+	To         string `json:"to"`   // YYYY-MM-DD, exclusive
+	Multiplier string `json:"multiplier"`
+	CreatedAt  int64  `json:"createdAt"`
+}
+
+// Validate checks the plan's dates and multiplier.
+func (p RatePlan) Validate() error {
+	from, err1 := time.Parse(dateLayout, p.From)
+	to, err2 := time.Parse(dateLayout, p.To)
+	if err1 != nil || err2 != nil || !to.After(from) {
+		return errors.New("from and to must be YYYY-MM-DD with from before to")
+	}
+	m, err := money.Parse(p.Multiplier)
+	if err != nil || !m.IsPositive() || m.GreaterThan(decimal.NewFromInt(MaxRateMultiplier)) {
+		return fmt.Errorf("multiplier must be a decimal greater than 0 and at most %d", MaxRateMultiplier)
+	}
+	return nil
+}
+
+// Covers reports whether the night of date (YYYY-MM-DD) falls in the plan.
+func (p RatePlan) Covers(date string) bool {
+	return date >= p.From && date < p.To
+}
+
+// Overlaps reports whether the two plans share a night.
+func (p RatePlan) Overlaps(q RatePlan) bool {
+	return p.From < q.To && q.From < p.To
+}
+
+// NightlyPrices returns the price of each night in [checkIn, checkOut): the
+// night's override if it has one, else the base price times the multiplier
+// of the plan covering it, rounded to currency's minor units with r, else the
+// base price. An unparseable base price or multiplier is an error.
+func NightlyPrices(r money.Rounding, currency, base, checkIn, checkOut string, overrides map[string]string, plans []RatePlan) (map[string]string, error) {
+	start, err1 := time.Parse(dateLayout, checkIn)
+	end, err2 := time.Parse(dateLayout, checkOut)
+	if err1 != nil || err2 != nil {
+		return nil, errors.New("check_in and check_out must be YYYY-MM-DD")
+	}
+	basePrice, err := money.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("base price: %w", err)
+	}
+	prices := map[string]string{}
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		date := d.Format(dateLayout)
+		if p, ok := overrides[date]; ok {
+			prices[date] = p
+			continue
+		}
+		price := base
+		for _, plan := range plans {
+			if plan.Covers(date) {
+				m, err := money.Parse(plan.Multiplier)
+				if err != nil {
+					return nil, fmt.Errorf("rate plan %s multiplier: %w", plan.ID, err)
+				}
+				price = r.Format(basePrice.Mul(m), currency)
+				break
+			}
+		}
+		prices[date] = price
+	}
+	return prices, nil
+}
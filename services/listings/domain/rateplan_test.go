@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/saidmashhud/zist/internal/money"
+)
+
+func TestNightlyPrices_SummerPlan(t *testing.T) {
+	summer := []RatePlan{{Name: "Summer", From: "2026-06-01", To: "2026-09-01", Multiplier: "1.5"}}
+	sum := func(prices map[string]string) float64 {
+		var total float64
+		for _, p := range prices {
+			f, _ := strconv.ParseFloat(p, 64)
+			total += f
+		}
+		return total
+	}
+
+	// Two nights straddling the plan start: one base, one at 1.5×.
+	plain, err := NightlyPrices(money.Rounding{}, "USD", "100.00", "2026-05-31", "2026-06-02", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withPlan, _ := NightlyPrices(money.Rounding{}, "USD", "100.00", "2026-05-31", "2026-06-02", nil, summer)
+	if sum(plain) != 200 || sum(withPlan) != 250 {
+		t.Errorf("subtotal: want 200 without and 250 with the plan, got %v and %v", sum(plain), sum(withPlan))
+	}
+	if withPlan["2026-06-01"] != "150.00" || withPlan["2026-05-31"] != "100.00" {
+		t.Errorf("nightly prices: got %v", withPlan)
+	}
+
+	// A per-date override beats the plan.
+	overridden, _ := NightlyPrices(money.Rounding{}, "USD", "100.00", "2026-06-01", "2026-06-02",
+		map[string]string{"2026-06-01": "120.00"}, summer)
+	if overridden["2026-06-01"] != "120.00" {
+		t.Errorf("override: want 120.00, got %s", overridden["2026-06-01"])
+	}
+}
+
+func TestRatePlanValidate(t *testing.T) {
+	ok := RatePlan{From: "2026-06-01", To: "2026-09-01", Multiplier: "1.5"}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("valid plan: %v", err)
+	}
+	for _, p := range []RatePlan{
+		{From: "2026-09-01", To: "2026-06-01", Multiplier: "1.5"},
+		{From: "2026-06-01", To: "2026-09-01", Multiplier: "0"},
+		{From: "2026-06-01", To: "2026-09-01", Multiplier: "11"},
+		{From: "June", To: "2026-09-01", Multiplier: "1"},
+	} {
+		if p.Validate() == nil {
+			t.Errorf("%+v: want error", p)
+		}
+	}
+	if !ok.Overlaps(RatePlan{From: "2026-08-31", To: "2026-10-01"}) || ok.Overlaps(RatePlan{From: "2026-09-01", To: "2026-10-01"}) {
+		t.Error("Overlaps: plans sharing only a boundary must not overlap")
+	}
+}
+
+func TestNightlyPrices_RoundsInMinorUnits(t *testing.T) {
+	plan := []RatePlan{{From: "2026-06-01", To: "2026-09-01", Multiplier: "1.15"}}
+	uzs := money.Rounding{MinorUnits: map[string]int{"UZS": 0}}
+	prices, err := NightlyPrices(uzs, "UZS", "100005", "2026-06-01", "2026-06-02", nil, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 100005 × 1.15 = 115005.75 exactly; a float product would drift.
+	if prices["2026-06-01"] != "115006" {
+		t.Errorf("want 115006, got %s", prices["2026-06-01"])
+	}
+	if _, err := NightlyPrices(uzs, "UZS", "abc", "2026-06-01", "2026-06-02", nil, plan); err == nil {
+		t.Error("unparseable base price: want error")
+	}
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// snoozeLayout is the date format for snoozeUntil.
+const snoozeLayout = "2006-01-02"
+
+// maxSnoozeDays bounds how far ahead a listing can be snoozed.
+const maxSnoozeDays = 365
+
+// ErrInvalidSnooze is returned by ParseSnoozeUntil for a malformed or
+// out-of-range date.
+var ErrInvalidSnooze = errors.New("until must be a date (YYYY-MM-DD) after today and within a year")
+
+// ParseSnoozeUntil validates the date a snoozed listing goes back on the
+// market. It must fall after today (UTC) and at most a year ahead.
+func ParseSnoozeUntil(until string, now time.Time) (string, error) {
+	d, err := time.Parse(snoozeLayout, until)
+	if err != nil {
+		return "", ErrInvalidSnooze
+	}
+	today := SnoozeCutoff(now)
+	if d.Format(snoozeLayout) <= today || d.After(now.UTC().AddDate(0, 0, maxSnoozeDays)) {
+		return "", ErrInvalidSnooze
+	}
+	return d.Format(snoozeLayout), nil
+}
+
+// SnoozeCutoff is the date at now (UTC). Listings snoozed until this date or
+// earlier are due to be republished.
+func SnoozeCutoff(now time.Time) string {
+	return now.UTC().Format(snoozeLayout)
+}
+
+// SnoozeDue reports whether a listing snoozed until the given date should be
+// active again at now. An empty until means no snooze is scheduled.
+func SnoozeDue(until string, now time.Time) bool {
+	return until != "" && until <= SnoozeCutoff(now)
+}
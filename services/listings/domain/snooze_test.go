@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseSnoozeUntil(t *testing.T) {
+	now := time.Date(2030, 5, 10, 22, 0, 0, 0, time.UTC)
+	for until, ok := range map[string]bool{
+		"2030-05-11": true,
+		"2031-05-10": true,
+		"2030-05-10": false, // today
+		"2030-05-09": false,
+		"2031-05-11": false, // more than a year out
+		"11/05/2030": false,
+		"":           false,
+	} {
+		got, err := ParseSnoozeUntil(until, now)
+		if ok && (err != nil || got != until) {
+			t.Errorf("ParseSnoozeUntil(%q) = %q, %v; want accepted", until, got, err)
+		}
+		if !ok && !errors.Is(err, ErrInvalidSnooze) {
+			t.Errorf("ParseSnoozeUntil(%q) err = %v, want ErrInvalidSnooze", until, err)
+		}
+	}
+}
+
+func TestSnoozeDue(t *testing.T) {
+	until := "2030-05-11"
+	tests := []struct {
+		now  time.Time
+		want bool
+	}{
+		{time.Date(2030, 5, 10, 23, 59, 59, 0, time.UTC), false},
+		{time.Date(2030, 5, 11, 0, 0, 0, 0, time.UTC), true},
+		{time.Date(2030, 6, 1, 12, 0, 0, 0, time.UTC), true},
+		// 2030-05-11 03:00 in Tashkent is still the 10th in UTC.
+		{time.Date(2030, 5, 11, 3, 0, 0, 0, time.FixedZone("UZT", 5*3600)), false},
+	}
+	for _, tt := range tests {
+		if got := SnoozeDue(until, tt.now); got != tt.want {
+			t.Errorf("SnoozeDue(%q, %v) = %v, want %v", until, tt.now, got, tt.want)
+		}
+	}
+	if SnoozeDue("", time.Now()) {
+		t.Error("SnoozeDue with no snooze: want false")
+	}
+}
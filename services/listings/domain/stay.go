@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// StayCheck is a prospective booking to validate against its listing.
+type StayCheck struct {
+	ListingID string `json:"listingId"`
+	CheckIn   string `json:"checkIn"`
+	CheckOut  string `json:"checkOut"`
+	Guests    int    `json:"guests"`
+}
+
+// StayResult reports whether a StayCheck is bookable, and if not, why.
+type StayResult struct {
+	ListingID string   `json:"listingId"`
+	Valid     bool     `json:"valid"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// ErrInvalidStayDates is returned by StayNights for unparseable or
+// out-of-order dates.
+var ErrInvalidStayDates = errors.New("invalid dates: check_out must be after check_in")
+
+// StayNights returns the nights between checkIn and checkOut (YYYY-MM-DD).
+func StayNights(checkIn, checkOut string) (int, error) {
+	ci, err1 := time.Parse(dateLayout, checkIn)
+	co, err2 := time.Parse(dateLayout, checkOut)
+	if err1 != nil || err2 != nil || !co.After(ci) {
+		return 0, ErrInvalidStayDates
+	}
+	return int(co.Sub(ci).Hours() / 24), nil
+}
+
+// CheckGuests returns a caller-facing error if guests exceeds maxGuests.
+func CheckGuests(guests, maxGuests int) error {
+	if guests > maxGuests {
+		return fmt.Errorf("listing capacity is %d guests", maxGuests)
+	}
+	return nil
+}
+
+// CheckNights returns a caller-facing error if nights falls outside the
+// listing's minimum and maximum stay.
+func CheckNights(nights, minNights, maxNights int) error {
+	if nights < minNights {
+		return fmt.Errorf("minimum stay is %d nights", minNights)
+	}
+	if nights > maxNights {
+		return fmt.Errorf("maximum stay is %d nights", maxNights)
+	}
+	return nil
+}
+
+// ValidateStay checks s against l (nil when the listing doesn't exist): the
+// listing must be active, hold the guests (0 counts as 1) and allow the
+// number of nights. Every failed check is reported.
+func ValidateStay(l *Listing, s StayCheck) StayResult {
+	res := StayResult{ListingID: s.ListingID}
+	if l == nil {
+		res.Errors = []string{"listing not found"}
+		return res
+	}
+	if l.Status != StatusActive {
+		res.Errors = append(res.Errors, "listing is not active")
+	}
+	guests := s.Guests
+	if guests == 0 {
+		guests = 1
+	}
+	if guests < 0 {
+		res.Errors = append(res.Errors, "guests must be at least 1")
+	} else if err := CheckGuests(guests, l.MaxGuests); err != nil {
+		res.Errors = append(res.Errors, err.Error())
+	}
+	if nights, err := StayNights(s.CheckIn, s.CheckOut); err != nil {
+		res.Errors = append(res.Errors, err.Error())
+	} else if err := CheckNights(nights, l.MinNights, l.MaxNights); err != nil {
+		res.Errors = append(res.Errors, err.Error())
+	}
+	res.Valid = len(res.Errors) == 0
+	return res
+}
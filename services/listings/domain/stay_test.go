@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateStay(t *testing.T) {
+	active := &Listing{ID: "a", Status: StatusActive, MaxGuests: 2, MinNights: 2, MaxNights: 10}
+	paused := &Listing{ID: "p", Status: StatusPaused, MaxGuests: 2, MinNights: 1, MaxNights: 10}
+	stay := StayCheck{CheckIn: "2030-05-01", CheckOut: "2030-05-04", Guests: 2}
+
+	tests := []struct {
+		name    string
+		l       *Listing
+		change  func(*StayCheck)
+		wantErr []string
+	}{
+		{"valid", active, nil, nil},
+		{"guests default to one", active, func(s *StayCheck) { s.Guests = 0 }, nil},
+		{"inactive", paused, nil, []string{"listing is not active"}},
+		{"not found", nil, nil, []string{"listing not found"}},
+		{"over capacity", active, func(s *StayCheck) { s.Guests = 3 }, []string{"listing capacity is 2 guests"}},
+		{"too short", active, func(s *StayCheck) { s.CheckOut = "2030-05-02" }, []string{"minimum stay is 2 nights"}},
+		{"too long", active, func(s *StayCheck) { s.CheckOut = "2030-05-20" }, []string{"maximum stay is 10 nights"}},
+		{"bad dates", active, func(s *StayCheck) { s.CheckOut = s.CheckIn }, []string{ErrInvalidStayDates.Error()}},
+		{"every failure reported", paused, func(s *StayCheck) { s.Guests = 5 }, []string{"listing is not active", "listing capacity is 2 guests"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := stay
+			if tt.change != nil {
+				tt.change(&s)
+			}
+			res := ValidateStay(tt.l, s)
+			if res.Valid != (len(tt.wantErr) == 0) || strings.Join(res.Errors, "; ") != strings.Join(tt.wantErr, "; ") {
+				t.Errorf("ValidateStay = %+v, want errors %v", res, tt.wantErr)
+			}
+		})
+	}
+}
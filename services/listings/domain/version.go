@@ -0,0 +1,52 @@
+package domain
+
+// MaxListingVersions is how many versions are kept per listing; older ones
+// are pruned as new ones are recorded.
+const MaxListingVersions = 20
+
+// ListingVersion is a snapshot of a listing as saved by one create, update
+// or restore, with the user who made it.
+type ListingVersion struct {
+	ID        string  `json:"id"`
+	ListingID string  `json:"listingId"`
+	EditorID  string  `json:"editorId"`
+	Snapshot  Listing `json:"snapshot"`
+	CreatedAt int64   `json:"createdAt"`
+}
+
+// RestoreInput is the update that brings a listing back to v. Only host
+// editable content is restored: status, ratings, ownership and photos are
+// left as they are now.
+func (v ListingVersion) RestoreInput() UpdateListingInput {
+	l := v.Snapshot
+	in := UpdateListingInput{
+		Title:                &l.Title,
+		Description:          &l.Description,
+		Address:              &l.Address,
+		Type:                 &l.Type,
+		Bedrooms:             &l.Bedrooms,
+		Beds:                 &l.Beds,
+		Bathrooms:            &l.Bathrooms,
+		MaxGuests:            &l.MaxGuests,
+		Amenities:            l.Amenities,
+		Rules:                &l.Rules,
+		PricePerNight:        &l.PricePerNight,
+		Currency:             &l.Currency,
+		CleaningFee:          &l.CleaningFee,
+		Deposit:              &l.Deposit,
+		MinNights:            &l.MinNights,
+		MaxNights:            &l.MaxNights,
+		CancellationPolicy:   &l.CancellationPolicy,
+		InstantBook:          &l.InstantBook,
+		PaymentWindowMinutes: &l.PaymentWindowMinutes,
+		PayOnArrival:         &l.PayOnArrival,
+		Timezone:             &l.Timezone,
+	}
+	if l.Lat != nil && l.Lng != nil {
+		in.Lat, in.Lng, in.LocationSource = l.Lat, l.Lng, &l.LocationSource
+	}
+	if in.Amenities == nil {
+		in.Amenities = []string{}
+	}
+	return in
+}
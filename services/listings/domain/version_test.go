@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRestoreInput(t *testing.T) {
+	// A version round-trips through its stored JSON before being restored.
+	old := Listing{
+		ID: "l1", Title: "Old Town Loft", City: "Bukhara", PricePerNight: "150000.00",
+		Status: StatusPaused, AverageRating: 4.8, HostID: "h1",
+	}
+	raw, err := json.Marshal(ListingVersion{ID: "v1", ListingID: "l1", EditorID: "h1", Snapshot: old})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v ListingVersion
+	if err := json.Unmarshal(raw, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	in := v.RestoreInput()
+	if in.Title == nil || *in.Title != "Old Town Loft" {
+		t.Errorf("title: got %v, want the old title", in.Title)
+	}
+	if in.PricePerNight == nil || *in.PricePerNight != "150000.00" {
+		t.Errorf("price: got %v", in.PricePerNight)
+	}
+	if in.Status != nil {
+		t.Errorf("status must not be restored, got %q", *in.Status)
+	}
+	if in.Lat != nil || in.Lng != nil {
+		t.Error("coordinates absent from the version must be left alone")
+	}
+	if in.Amenities == nil {
+		t.Error("amenities should restore to an empty list")
+	}
+}
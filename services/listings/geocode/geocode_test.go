@@ -0,0 +1,23 @@
+package geocode
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStatic_Geocode(t *testing.T) {
+	g := NewStatic()
+	p, ok, err := g.Geocode(context.Background(), Query{City: "  Tashkent "})
+	if err != nil || !ok {
+		t.Fatalf("Tashkent: ok=%v err=%v, want found", ok, err)
+	}
+	if p != (Point{41.2995, 69.2401}) {
+		t.Errorf("Tashkent = %+v", p)
+	}
+	if _, ok, _ := g.Geocode(context.Background(), Query{City: "SAMARKAND"}); !ok {
+		t.Error("lookup should be case-insensitive")
+	}
+	if _, ok, err := g.Geocode(context.Background(), Query{City: "Atlantis"}); ok || err != nil {
+		t.Errorf("unknown city: ok=%v err=%v, want not found without error", ok, err)
+	}
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// auditClient records privileged listing changes in the admin service's
+// audit log.
+type auditClient struct {
+	baseURL       string
+	internalToken string
+	http          *http.Client
+}
+
+func newAuditClient(baseURL, internalToken string) *auditClient {
+	return &auditClient{
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		internalToken: internalToken,
+		http:          &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Record writes an audit entry. Callers run the audited action only once it
+// has succeeded.
+func (c *auditClient) Record(ctx context.Context, tenantID, actorID, action, resource, detail string) error {
+	body, _ := json.Marshal(map[string]string{
+		"actorId":  actorID,
+		"action":   action,
+		"resource": resource,
+		"detail":   detail,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		c.baseURL+"/admin/internal/audit", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Internal-Token", c.internalToken)
+	req.Header.Set("X-Tenant-ID", tenantID)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("admin service returned %d", resp.StatusCode)
+	}
+	return nil
+}
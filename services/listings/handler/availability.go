@@ -7,6 +7,7 @@ import (
 	"time"
 
 	httputil "github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/services/listings/domain"
 	"github.com/saidmashhud/zist/services/listings/store"
 )
 
@@ -25,6 +26,30 @@ func (h *Handler) GetCalendar(w http.ResponseWriter, r *http.Request) {
 	httputil.WriteJSON(w, http.StatusOK, map[string]any{"month": month, "days": calendar})
 }
 
+// GetAvailabilityRanges returns the listing's blocked and booked days as
+// ranges rather than one entry per day.
+// GET /listings/{id}/availability/ranges?from=&to=
+func (h *Handler) GetAvailabilityRanges(w http.ResponseWriter, r *http.Request) {
+	id := listingID(r)
+	q := r.URL.Query()
+	from, to, err := domain.ParseRangeWindow(q.Get("from"), q.Get("to"), time.Now())
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	days, err := h.Store.UnavailableDays(r.Context(), id, from, to)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	ranges, err := domain.MergeRanges(days)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"from": from, "to": to, "ranges": ranges})
+}
+
 func (h *Handler) BlockDates(w http.ResponseWriter, r *http.Request) {
 	id := listingID(r)
 	if h.requireOwner(w, r, id) == "" {
@@ -137,9 +162,8 @@ func (h *Handler) CheckAvailability(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) MarkDatesBooked(w http.ResponseWriter, r *http.Request) {
 	id := listingID(r)
-	tenantID := strings.TrimSpace(r.Header.Get("X-Tenant-ID"))
+	tenantID := serviceTenant(w, r)
 	if tenantID == "" {
-		httputil.WriteError(w, http.StatusBadRequest, "tenant_id is required")
 		return
 	}
 
@@ -175,11 +199,14 @@ func (h *Handler) MarkDatesBooked(w http.ResponseWriter, r *http.Request) {
 	httputil.WriteJSON(w, http.StatusOK, map[string]any{"marked": len(req.Dates)})
 }
 
+// UnmarkDatesBooked releases a booking's dates and reports how many were
+// freed. It is idempotent: a second call (cancel racing the expiry worker,
+// say) succeeds with released = 0.
+// DELETE /listings/{id}/availability/book  (internal)
 func (h *Handler) UnmarkDatesBooked(w http.ResponseWriter, r *http.Request) {
 	id := listingID(r)
-	tenantID := strings.TrimSpace(r.Header.Get("X-Tenant-ID"))
+	tenantID := serviceTenant(w, r)
 	if tenantID == "" {
-		httputil.WriteError(w, http.StatusBadRequest, "tenant_id is required")
 		return
 	}
 
@@ -190,8 +217,13 @@ func (h *Handler) UnmarkDatesBooked(w http.ResponseWriter, r *http.Request) {
 		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
+	if strings.TrimSpace(req.BookingID) == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "bookingId is required")
+		return
+	}
 
-	if err := h.Store.UnmarkDatesBooked(r.Context(), tenantID, id, req.BookingID); err != nil {
+	released, err := h.Store.UnmarkDatesBooked(r.Context(), tenantID, id, req.BookingID)
+	if err != nil {
 		if err == store.ErrNotFound {
 			httputil.WriteError(w, http.StatusNotFound, "listing not found")
 			return
@@ -199,5 +231,47 @@ func (h *Handler) UnmarkDatesBooked(w http.ResponseWriter, r *http.Request) {
 		httputil.WriteError(w, http.StatusInternalServerError, "unmark failed")
 		return
 	}
-	httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": "released"})
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"status": "released", "released": released})
+}
+
+// HostCapacity lists a host's listings with the nights they blocked in
+// [from, to), for the bookings service's occupancy analytics.
+// GET /listings/capacity?host_id=&from=&to=  (internal)
+func (h *Handler) HostCapacity(w http.ResponseWriter, r *http.Request) {
+	tenantID := serviceTenant(w, r)
+	if tenantID == "" {
+		return
+	}
+	q := r.URL.Query()
+	hostID, from, to := q.Get("host_id"), q.Get("from"), q.Get("to")
+	fromDate, err1 := time.Parse("2006-01-02", from)
+	toDate, err2 := time.Parse("2006-01-02", to)
+	if hostID == "" || err1 != nil || err2 != nil || !toDate.After(fromDate) {
+		httputil.WriteError(w, http.StatusBadRequest, "host_id, from and to (YYYY-MM-DD, from < to) are required")
+		return
+	}
+	listings, err := h.Store.HostCapacity(r.Context(), tenantID, hostID, from, to)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"listings": listings})
+}
+
+// ListReservations lists the tenant's booked dates from today on, grouped
+// by listing and booking, so the bookings service can find dates held for
+// bookings that no longer need them.
+// GET /listings/reservations  (internal)
+func (h *Handler) ListReservations(w http.ResponseWriter, r *http.Request) {
+	tenantID := serviceTenant(w, r)
+	if tenantID == "" {
+		return
+	}
+	today := time.Now().UTC().Format("2006-01-02")
+	reservations, err := h.Store.ListReservations(r.Context(), tenantID, today)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"reservations": reservations})
 }
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// bookingsClient calls the bookings service's internal endpoints.
+type bookingsClient struct {
+	baseURL       string
+	internalToken string
+	http          *http.Client
+}
+
+func newBookingsClient(baseURL, internalToken string) *bookingsClient {
+	return &bookingsClient{
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		internalToken: internalToken,
+		http:          &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// SetListingHost moves a listing's bookings to hostID and returns how many
+// changed.
+func (c *bookingsClient) SetListingHost(ctx context.Context, tenantID, listingID, hostID string) (int, error) {
+	body, _ := json.Marshal(map[string]string{"hostId": hostID})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut,
+		c.baseURL+"/bookings/internal/listings/"+url.PathEscape(listingID)+"/host", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Internal-Token", c.internalToken)
+	req.Header.Set("X-Tenant-ID", tenantID)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("bookings service returned %d", resp.StatusCode)
+	}
+	var out struct {
+		Updated int `json:"updated"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return 0, fmt.Errorf("decode bookings response: %w", err)
+	}
+	return out.Updated, nil
+}
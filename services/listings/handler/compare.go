@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	httputil "github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/services/listings/domain"
+)
+
+// maxCompareIDs caps how many listings CompareListings puts side by side.
+const maxCompareIDs = 4
+
+// CompareListings returns the full detail of a few listings in one call,
+// each with its cover photo and rating, so a guest comparing options needs
+// no per-listing requests. Results follow the order of ids; missing and
+// archived listings are omitted.
+// GET /listings/compare?ids=a,b,c
+func (h *Handler) CompareListings(w http.ResponseWriter, r *http.Request) {
+	ids := domain.SplitIDs(r.URL.Query().Get("ids"))
+	if len(ids) == 0 {
+		httputil.WriteError(w, http.StatusBadRequest, "ids is required")
+		return
+	}
+	if len(ids) > maxCompareIDs {
+		httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("at most %d listings can be compared", maxCompareIDs))
+		return
+	}
+
+	var (
+		listings []domain.Listing
+		err      error
+	)
+	if tenantID := tenantFromRequest(r); tenantID != "" {
+		listings, err = h.Store.GetManyForTenant(r.Context(), tenantID, ids)
+	} else {
+		listings, err = h.Store.GetMany(r.Context(), ids)
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	covers, err := h.Store.GetCoverPhotos(r.Context(), ids)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	listings = domain.Comparable(listings, ids)
+	for i := range listings {
+		if p, ok := covers[listings[i].ID]; ok {
+			listings[i].Photos = []domain.Photo{p}
+		}
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"listings": listings})
+}
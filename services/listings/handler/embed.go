@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	httputil "github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/internal/ratelimit"
+	"github.com/saidmashhud/zist/services/listings/domain"
+	"github.com/saidmashhud/zist/services/listings/store"
+)
+
+// defaultEmbedsPerMinute limits embed fetches per client IP.
+const defaultEmbedsPerMinute = 120
+
+// embedMaxAge is how long browsers and CDNs may cache an embed.
+const embedMaxAge = "300"
+
+// WithEmbedRateLimit allows perMinute embed fetches per client IP (0
+// disables the limit).
+func (h *Handler) WithEmbedRateLimit(perMinute int) *Handler {
+	h.embeds = ratelimit.New(perMinute, time.Minute)
+	return h
+}
+
+// WithTrustedProxies sets the peers whose X-Forwarded-For identifies the
+// client for embed limits and anonymous view counts. Without any, the direct
+// peer address is used.
+func (h *Handler) WithTrustedProxies(p httputil.TrustedProxies) *Handler {
+	h.Proxies = p
+	return h
+}
+
+// EmbedListing returns the public card of an active listing for widgets on
+// hosts' own sites. Unlike GetListing it is readable from any origin, is
+// cacheable and exposes only domain.Embed fields.
+// GET /listings/{id}/embed  (OPTIONS answers CORS preflight)
+func (h *Handler) EmbedListing(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// Keyed on the client IP alone: the Referer is the client's to choose, so
+	// a key that included it would give each forged value a fresh budget.
+	if !h.embeds.Allow(h.Proxies.ClientIP(r)) {
+		w.Header().Set("Retry-After", "60")
+		httputil.WriteError(w, http.StatusTooManyRequests, "too many embed requests, try again later")
+		return
+	}
+
+	id := listingID(r)
+	l, err := h.Store.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && l.Status != domain.StatusActive) {
+		httputil.WriteError(w, http.StatusNotFound, "listing not found")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age="+embedMaxAge)
+	httputil.WriteJSON(w, http.StatusOK, domain.NewEmbed(l, h.Store.GetCoverPhoto(r.Context(), id)))
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+)
+
+// notModified sets the ETag and Cache-Control headers for a cacheable read
+// and, if the client's If-None-Match already holds etag, answers 304 and
+// returns true. Clients must revalidate, so edits show up immediately.
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	for _, tag := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNotModified(t *testing.T) {
+	const etag = `W/"abc"`
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{"", false},
+		{`W/"abc"`, true},
+		{`"abc"`, true},
+		{`W/"old", W/"abc"`, true},
+		{`W/"old"`, false},
+		{"*", true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/listings/l1", nil)
+		if tt.ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", tt.ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		if got := notModified(w, r, etag); got != tt.want {
+			t.Errorf("If-None-Match %q: got %v, want %v", tt.ifNoneMatch, got, tt.want)
+		}
+		if w.Header().Get("ETag") != etag {
+			t.Errorf("If-None-Match %q: ETag header not set", tt.ifNoneMatch)
+		}
+		if tt.want && w.Code != http.StatusNotModified {
+			t.Errorf("If-None-Match %q: want 304, got %d", tt.ifNoneMatch, w.Code)
+		}
+	}
+}
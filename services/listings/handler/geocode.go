@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/saidmashhud/zist/services/listings/domain"
+	"github.com/saidmashhud/zist/services/listings/geocode"
+)
+
+// WithGeocoder fills in coordinates from the city for listings saved without
+// lat/lng. Nil (the default) leaves such listings out of geo search.
+func (h *Handler) WithGeocoder(g geocode.Geocoder) *Handler {
+	h.Geocoder = g
+	return h
+}
+
+// locate geocodes a listing without host-provided coordinates. It returns
+// nils when geocoding is off, the place is unknown or the lookup fails; a
+// failed lookup must not block saving the listing, so it is only logged.
+func (h *Handler) locate(ctx context.Context, city, country, address string) (lat, lng *float64) {
+	if h.Geocoder == nil {
+		return nil, nil
+	}
+	p, ok, err := h.Geocoder.Geocode(ctx, geocode.Query{City: city, Country: country, Address: address})
+	if err != nil {
+		slog.Warn("geocoding failed", "city", city, "err", err)
+		return nil, nil
+	}
+	if !ok {
+		return nil, nil
+	}
+	return &p.Lat, &p.Lng
+}
+
+// geocodeUpdate decides the location columns for a partial update that did
+// not send lat/lng: listings with host coordinates are left alone, others
+// are (re)geocoded when they have no point yet or their address changed.
+func (h *Handler) geocodeUpdate(ctx context.Context, cur domain.Listing, in *domain.UpdateListingInput) {
+	if h.Geocoder == nil || cur.LocationSource == domain.LocationProvided {
+		return
+	}
+	if cur.Lat != nil && in.Address == nil {
+		return
+	}
+	address := cur.Address
+	if in.Address != nil {
+		address = *in.Address
+	}
+	lat, lng := h.locate(ctx, cur.City, cur.Country, address)
+	if lat == nil {
+		return
+	}
+	source := domain.LocationGeocoded
+	in.Lat, in.Lng, in.LocationSource = lat, lng, &source
+}
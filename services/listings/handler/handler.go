@@ -6,11 +6,20 @@ import (
 	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	zistauth "github.com/saidmashhud/zist/internal/auth"
 	httputil "github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/internal/money"
+	"github.com/saidmashhud/zist/internal/ratelimit"
+	"github.com/saidmashhud/zist/internal/tenantconfig"
 	"github.com/saidmashhud/zist/services/listings/analytics"
+	"github.com/saidmashhud/zist/services/listings/domain"
+	"github.com/saidmashhud/zist/services/listings/geocode"
+	"github.com/saidmashhud/zist/services/listings/hostrating"
+	"github.com/saidmashhud/zist/services/listings/media"
+	"github.com/saidmashhud/zist/services/listings/searchindex"
 	"github.com/saidmashhud/zist/services/listings/store"
 )
 
@@ -18,12 +27,67 @@ import (
 type Handler struct {
 	Store       *store.Store
 	Analytics   *analytics.Client
-	FeeGuestPct float64 // e.g. 12.0 → 12%
+	Search      *searchindex.Client
+	Events      *searchindex.Events // listing events; preferred over Search when enabled
+	HostRatings *hostrating.Client
+	Geocoder    geocode.Geocoder     // nil disables geocoding
+	Tenants     *tenantconfig.Client // tenant settings; nil unless ADMIN_URL is set
+	Audit       *auditClient         // admin audit log; nil unless ADMIN_URL is set
+	Bookings    *bookingsClient      // nil unless BOOKINGS_URL is set
+	Users       *usersClient         // mgID user directory; nil unless MGID_URL is set
+	FeeGuestPct float64              // e.g. 12.0 → 12%
+	// Rounding prices quotes; the zero value is half up to cents.
+	Rounding money.Rounding
+	// PublishRules gate PublishListing; all failures are reported together.
+	PublishRules []domain.PublishRule
+	// Media issues photo upload URLs; nil disables PhotoUploadURL.
+	Media media.Storage
+	// PhotoCheck validates photo URLs with a HEAD request in AddPhoto; nil skips it.
+	PhotoCheck *media.Checker
+	// Proxies are the peers whose X-Forwarded-For is believed when
+	// identifying anonymous clients.
+	Proxies httputil.TrustedProxies
+	uploads *ratelimit.Limiter // upload URLs issued per host
+	embeds  *ratelimit.Limiter // public embed fetches per client IP
 }
 
+// defaultUploadsPerHour limits upload URLs issued to one host.
+const defaultUploadsPerHour = 30
+
 // New creates a Handler with the given store and platform fee percentage.
 func New(s *store.Store, feeGuestPct float64) *Handler {
-	return &Handler{Store: s, FeeGuestPct: feeGuestPct, Analytics: analytics.New("", "")}
+	return &Handler{
+		Store:        s,
+		FeeGuestPct:  feeGuestPct,
+		Analytics:    analytics.New("", ""),
+		Search:       searchindex.New("", ""),
+		Events:       searchindex.NewEvents("", ""),
+		HostRatings:  hostrating.New("", 0),
+		PublishRules: domain.PublishConfig{}.Rules(),
+		uploads:      ratelimit.New(defaultUploadsPerHour, time.Hour),
+		embeds:       ratelimit.New(defaultEmbedsPerMinute, time.Minute),
+	}
+}
+
+// WithMedia enables first-party photo uploads backed by storage, allowing
+// each host perHour upload URLs (0 disables the limit).
+func (h *Handler) WithMedia(storage media.Storage, perHour int) *Handler {
+	h.Media = storage
+	h.uploads = ratelimit.New(perHour, time.Hour)
+	return h
+}
+
+// WithPhotoCheck enables the HEAD request on photo URLs registered by AddPhoto.
+func (h *Handler) WithPhotoCheck(c *media.Checker) *Handler {
+	h.PhotoCheck = c
+	return h
+}
+
+// WithPublishRules replaces the publish quality gates. Platforms can mix the
+// built-in rules from domain.PublishConfig with their own.
+func (h *Handler) WithPublishRules(rules ...domain.PublishRule) *Handler {
+	h.PublishRules = rules
+	return h
 }
 
 // WithAnalytics attaches an mgLogs analytics client.
@@ -32,6 +96,59 @@ func (h *Handler) WithAnalytics(baseURL, apiKey string) *Handler {
 	return h
 }
 
+// WithSearchIndex attaches a client for the search service's projection.
+func (h *Handler) WithSearchIndex(baseURL, internalToken string) *Handler {
+	h.Search = searchindex.New(baseURL, internalToken)
+	return h
+}
+
+// WithListingEvents publishes zist.listing.* events to mgEvents. When set,
+// the search projection is fed from the events plane instead of direct pushes.
+func (h *Handler) WithListingEvents(baseURL, apiKey string) *Handler {
+	h.Events = searchindex.NewEvents(baseURL, apiKey)
+	return h
+}
+
+// WithHostRatings shows the host's aggregate rating on listing detail,
+// caching each host's summary for ttl.
+func (h *Handler) WithHostRatings(reviewsURL string, ttl time.Duration) *Handler {
+	h.HostRatings = hostrating.New(reviewsURL, ttl)
+	return h
+}
+
+// WithAudit records privileged listing changes in the admin audit log.
+func (h *Handler) WithAudit(adminURL, internalToken string) *Handler {
+	if adminURL != "" {
+		h.Audit = newAuditClient(adminURL, internalToken)
+	}
+	return h
+}
+
+// WithRounding prices quotes with the bookings service's rounding mode and
+// per-currency minor units.
+func (h *Handler) WithRounding(r money.Rounding) *Handler {
+	h.Rounding = r
+	return h
+}
+
+// WithBookings lets listing changes that bookings denormalise (the host)
+// cascade to the bookings service.
+func (h *Handler) WithBookings(bookingsURL, internalToken string) *Handler {
+	if bookingsURL != "" {
+		h.Bookings = newBookingsClient(bookingsURL, internalToken)
+	}
+	return h
+}
+
+// WithUsers checks listing transfers against mgID's user directory, so a
+// listing only moves to a user of its tenant.
+func (h *Handler) WithUsers(mgIDURL, adminToken string) *Handler {
+	if mgIDURL != "" {
+		h.Users = newUsersClient(mgIDURL, adminToken)
+	}
+	return h
+}
+
 // requireOwner verifies the authenticated user is the listing's host.
 // Returns the hostID on success; writes an error response and returns "" on failure.
 func (h *Handler) requireOwner(w http.ResponseWriter, r *http.Request, listingID string) string {
@@ -60,6 +177,17 @@ func (h *Handler) requireOwner(w http.ResponseWriter, r *http.Request, listingID
 // listingID extracts and returns the {id} URL parameter.
 func listingID(r *http.Request) string { return chi.URLParam(r, "id") }
 
+// serviceTenant returns the tenant an internal caller acts for, as vouched
+// for by service auth. It writes 400 and returns "" if there is none.
+func serviceTenant(w http.ResponseWriter, r *http.Request) string {
+	p := zistauth.RequestPrincipal(r)
+	if p == nil || p.TenantID == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "tenant_id is required")
+		return ""
+	}
+	return p.TenantID
+}
+
 func tenantFromRequest(r *http.Request) string {
 	if p := zistauth.FromContext(r.Context()); p != nil && strings.TrimSpace(p.TenantID) != "" {
 		return strings.TrimSpace(p.TenantID)
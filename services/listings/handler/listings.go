@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -11,6 +15,7 @@ import (
 	zistauth "github.com/saidmashhud/zist/internal/auth"
 	httputil "github.com/saidmashhud/zist/internal/httputil"
 	"github.com/saidmashhud/zist/services/listings/domain"
+	"github.com/saidmashhud/zist/services/listings/searchindex"
 	"github.com/saidmashhud/zist/services/listings/store"
 )
 
@@ -28,20 +33,67 @@ func (h *Handler) ListMyListings(w http.ResponseWriter, r *http.Request) {
 	httputil.WriteJSON(w, http.StatusOK, map[string]any{"listings": listings})
 }
 
+// SetMyListingsStatus pauses all of the host's active listings, or resumes
+// all their paused ones, in one go.
+// POST /listings/mine/status
+func (h *Handler) SetMyListingsStatus(w http.ResponseWriter, r *http.Request) {
+	p := zistauth.FromContext(r.Context())
+	if p == nil || p.TenantID == "" {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	from, ok := domain.BulkStatusFrom(req.Status)
+	if !ok {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, "status must be active or paused")
+		return
+	}
+	ids, err := h.Store.SetHostStatus(r.Context(), p.TenantID, p.UserID, from, req.Status)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "update failed")
+		return
+	}
+	event := searchindex.EventListingUnpublished
+	if req.Status == domain.StatusActive {
+		event = searchindex.EventListingPublished
+	}
+	for _, id := range ids {
+		h.reindex(r.Context(), id, event)
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"status": req.Status, "updated": len(ids)})
+}
+
+// ListListings returns a page of listings, newest first, with the total
+// number of matches.
+// GET /listings?city=&status=&limit=&offset=
 func (h *Handler) ListListings(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 	city := q.Get("city")
 	statusFilter := q.Get("status")
-	limit := 50
+	limit, offset := 50, 0
 	if n, err := strconv.Atoi(q.Get("limit")); err == nil && n > 0 && n <= 100 {
 		limit = n
 	}
-	listings, err := h.Store.List(r.Context(), statusFilter, city, limit)
+	if n, err := strconv.Atoi(q.Get("offset")); err == nil && n > 0 {
+		offset = n
+	}
+	listings, total, err := h.Store.List(r.Context(), statusFilter, city, limit, offset)
 	if err != nil {
 		httputil.WriteError(w, http.StatusInternalServerError, "db error")
 		return
 	}
-	httputil.WriteJSON(w, http.StatusOK, map[string]any{"listings": listings})
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{
+		"listings": listings,
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
+	})
 }
 
 func (h *Handler) GetListing(w http.ResponseWriter, r *http.Request) {
@@ -69,12 +121,127 @@ func (h *Handler) GetListing(w http.ResponseWriter, r *http.Request) {
 		l.Photos = photos
 	}
 
-	// Analytics: track listing view for host dashboard.
-	h.Analytics.TrackListingView(r.Context(), tenantID, id, l.HostID)
+	// Best-effort: the listing is still served if reviews is unreachable.
+	// The host's rating is the one in the listing's own tenant.
+	if h.HostRatings.Enabled() {
+		ratingTenant := tenantID
+		if ratingTenant == "" {
+			ratingTenant, err = h.Store.GetTenantID(r.Context(), id)
+		}
+		if err != nil {
+			slog.Warn("listing tenant lookup failed", "listingId", id, "err", err)
+		} else if hs, err := h.HostRatings.Summary(r.Context(), ratingTenant, l.HostID); err != nil {
+			slog.Warn("host rating unavailable", "hostId", l.HostID, "err", err)
+		} else {
+			l.HostSummary = hs
+		}
+	}
+
+	// Analytics: track listing view for host dashboard. A revalidated
+	// (304) load is still a view.
+	h.trackView(r, tenantID, l)
 
+	if notModified(w, r, domain.ETag(l)) {
+		return
+	}
 	httputil.WriteJSON(w, http.StatusOK, l)
 }
 
+// maxBatchIDs caps the ids accepted by BatchListings.
+const maxBatchIDs = 100
+
+// BatchListings returns several listings of the caller's tenant in one call,
+// each with its cover photo, so other services avoid per-listing lookups.
+// Results follow the order of ids; unknown ids are omitted.
+// GET /listings/batch?ids=a,b,c  (internal)
+func (h *Handler) BatchListings(w http.ResponseWriter, r *http.Request) {
+	tenantID := serviceTenant(w, r)
+	if tenantID == "" {
+		return
+	}
+	ids := domain.SplitIDs(r.URL.Query().Get("ids"))
+	if len(ids) == 0 {
+		httputil.WriteError(w, http.StatusBadRequest, "ids is required")
+		return
+	}
+	if len(ids) > maxBatchIDs {
+		httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("at most %d ids per request", maxBatchIDs))
+		return
+	}
+
+	listings, err := h.Store.GetManyForTenant(r.Context(), tenantID, ids)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	covers, err := h.Store.GetCoverPhotos(r.Context(), ids)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	listings = domain.OrderByIDs(listings, ids)
+	for i := range listings {
+		if p, ok := covers[listings[i].ID]; ok {
+			listings[i].Photos = []domain.Photo{p}
+		}
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"listings": listings})
+}
+
+// ValidateStays handles POST /listings/validate (internal). Bulk importers
+// send up to maxBatchIDs prospective bookings and get each one's validity
+// back, in request order, from the same checks as a single booking.
+func (h *Handler) ValidateStays(w http.ResponseWriter, r *http.Request) {
+	tenantID := serviceTenant(w, r)
+	if tenantID == "" {
+		return
+	}
+	var req struct {
+		Items []domain.StayCheck `json:"items"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(req.Items) == 0 {
+		httputil.WriteError(w, http.StatusBadRequest, "items is required")
+		return
+	}
+	if len(req.Items) > maxBatchIDs {
+		httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("at most %d items per request", maxBatchIDs))
+		return
+	}
+
+	ids := make([]string, 0, len(req.Items))
+	for _, it := range req.Items {
+		ids = append(ids, it.ListingID)
+	}
+	listings, err := h.Store.GetManyForTenant(r.Context(), tenantID, ids)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	byID := make(map[string]*domain.Listing, len(listings))
+	for i := range listings {
+		byID[listings[i].ID] = &listings[i]
+	}
+
+	results := make([]domain.StayResult, 0, len(req.Items))
+	valid := 0
+	for _, it := range req.Items {
+		res := domain.ValidateStay(byID[it.ListingID], it)
+		if res.Valid {
+			valid++
+		}
+		results = append(results, res)
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{
+		"results": results,
+		"valid":   valid,
+		"invalid": len(results) - valid,
+	})
+}
+
 func (h *Handler) CreateListing(w http.ResponseWriter, r *http.Request) {
 	p := zistauth.FromContext(r.Context())
 	if p == nil || p.TenantID == "" {
@@ -83,69 +250,121 @@ func (h *Handler) CreateListing(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Title              string            `json:"title"`
-		Description        string            `json:"description"`
-		City               string            `json:"city"`
-		Country            string            `json:"country"`
-		Address            string            `json:"address"`
-		Type               string            `json:"type"`
-		Bedrooms           int               `json:"bedrooms"`
-		Beds               int               `json:"beds"`
-		Bathrooms          int               `json:"bathrooms"`
-		MaxGuests          int               `json:"maxGuests"`
-		Amenities          []string          `json:"amenities"`
-		Rules              domain.HouseRules `json:"rules"`
-		PricePerNight      string            `json:"pricePerNight"`
-		Currency           string            `json:"currency"`
-		CleaningFee        string            `json:"cleaningFee"`
-		Deposit            string            `json:"deposit"`
-		MinNights          int               `json:"minNights"`
-		MaxNights          int               `json:"maxNights"`
-		CancellationPolicy string            `json:"cancellationPolicy"`
-		InstantBook        bool              `json:"instantBook"`
+		Title                string            `json:"title"`
+		Description          string            `json:"description"`
+		City                 string            `json:"city"`
+		Country              string            `json:"country"`
+		Address              string            `json:"address"`
+		Lat                  *float64          `json:"lat"`
+		Lng                  *float64          `json:"lng"`
+		Type                 string            `json:"type"`
+		Bedrooms             int               `json:"bedrooms"`
+		Beds                 int               `json:"beds"`
+		Bathrooms            int               `json:"bathrooms"`
+		MaxGuests            int               `json:"maxGuests"`
+		Amenities            []string          `json:"amenities"`
+		Rules                domain.HouseRules `json:"rules"`
+		PricePerNight        string            `json:"pricePerNight"`
+		Currency             string            `json:"currency"`
+		CleaningFee          string            `json:"cleaningFee"`
+		Deposit              string            `json:"deposit"`
+		MinNights            int               `json:"minNights"`
+		MaxNights            int               `json:"maxNights"`
+		CancellationPolicy   string            `json:"cancellationPolicy"`
+		InstantBook          bool              `json:"instantBook"`
+		PaymentWindowMinutes int               `json:"paymentWindowMinutes"`
+		PayOnArrival         bool              `json:"payOnArrival"`
+		Timezone             string            `json:"timezone"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
-	if strings.TrimSpace(req.Title) == "" || strings.TrimSpace(req.City) == "" || req.PricePerNight == "" {
-		httputil.WriteError(w, http.StatusUnprocessableEntity, "title, city, and pricePerNight are required")
+	if !validPaymentWindow(req.PaymentWindowMinutes) {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, paymentWindowError)
+		return
+	}
+	if err := domain.ValidateCoordinates(req.Lat, req.Lng); err != nil {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	req.Timezone = strings.TrimSpace(req.Timezone)
+	if err := domain.ValidateTimezone(req.Timezone); err != nil {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	req.Currency = httputil.OrDefault(req.Currency, "USD")
+	if err := h.checkCurrency(r.Context(), p.TenantID, req.Currency); err != nil {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	listingType, err := domain.ResolveListingType(req.Type, h.listingTypes(r.Context(), p.TenantID))
+	if err != nil {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	req.CancellationPolicy = httputil.OrDefault(req.CancellationPolicy, domain.DefaultCancellationPolicy)
+	if err := h.checkPolicy(r.Context(), p.TenantID, req.CancellationPolicy); err != nil {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, err.Error())
 		return
 	}
 
 	if req.Amenities == nil {
 		req.Amenities = []string{}
 	}
+	var locationSource string
+	if req.Lat != nil {
+		locationSource = domain.LocationProvided
+	} else {
+		req.Lat, req.Lng = h.locate(r.Context(), req.City, req.Country, req.Address)
+		if req.Lat != nil {
+			locationSource = domain.LocationGeocoded
+		}
+	}
 
 	in := domain.CreateListingInput{
-		TenantID:           p.TenantID,
-		HostID:             p.UserID,
-		Title:              req.Title,
-		Description:        req.Description,
-		City:               req.City,
-		Country:            httputil.OrDefault(req.Country, ""),
-		Address:            req.Address,
-		Type:               httputil.OrDefault(req.Type, "apartment"),
-		Bedrooms:           atLeast1(req.Bedrooms),
-		Beds:               atLeast1(req.Beds),
-		Bathrooms:          atLeast1(req.Bathrooms),
-		MaxGuests:          atLeast1(req.MaxGuests),
-		Amenities:          req.Amenities,
-		Rules:              req.Rules,
-		PricePerNight:      req.PricePerNight,
-		Currency:           httputil.OrDefault(req.Currency, "USD"),
-		CleaningFee:        httputil.OrDefault(req.CleaningFee, "0"),
-		Deposit:            httputil.OrDefault(req.Deposit, "0"),
-		MinNights:          atLeast1(req.MinNights),
-		MaxNights:          positiveOrDefault(req.MaxNights, 365),
-		CancellationPolicy: httputil.OrDefault(req.CancellationPolicy, "moderate"),
-		InstantBook:        req.InstantBook,
+		TenantID:             p.TenantID,
+		HostID:               p.UserID,
+		Title:                req.Title,
+		Description:          req.Description,
+		City:                 req.City,
+		Country:              httputil.OrDefault(req.Country, ""),
+		Address:              req.Address,
+		Lat:                  req.Lat,
+		Lng:                  req.Lng,
+		LocationSource:       locationSource,
+		Type:                 listingType,
+		Bedrooms:             atLeast1(req.Bedrooms),
+		Beds:                 atLeast1(req.Beds),
+		Bathrooms:            atLeast1(req.Bathrooms),
+		MaxGuests:            atLeast1(req.MaxGuests),
+		Amenities:            req.Amenities,
+		Rules:                req.Rules,
+		PricePerNight:        req.PricePerNight,
+		Currency:             req.Currency,
+		CleaningFee:          httputil.OrDefault(req.CleaningFee, "0"),
+		Deposit:              httputil.OrDefault(req.Deposit, "0"),
+		MinNights:            atLeast1(req.MinNights),
+		MaxNights:            positiveOrDefault(req.MaxNights, 365),
+		CancellationPolicy:   req.CancellationPolicy,
+		InstantBook:          req.InstantBook,
+		PaymentWindowMinutes: req.PaymentWindowMinutes,
+		PayOnArrival:         req.PayOnArrival,
+		Timezone:             req.Timezone,
+	}
+	if missing := in.MissingFields(h.requiredFields(r.Context(), p.TenantID)); len(missing) > 0 {
+		httputil.WriteJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"error":   "listing missing required fields",
+			"missing": missing,
+		})
+		return
 	}
 	l, err := h.Store.Create(r.Context(), in)
 	if err != nil {
 		httputil.WriteError(w, http.StatusInternalServerError, "create failed")
 		return
 	}
+	h.recordVersion(r, l)
 	httputil.WriteJSON(w, http.StatusCreated, l)
 }
 
@@ -171,6 +390,8 @@ func (h *Handler) UpdateListing(w http.ResponseWriter, r *http.Request) {
 	decode("title", &req.Title)
 	decode("description", &req.Description)
 	decode("address", &req.Address)
+	decode("lat", &req.Lat)
+	decode("lng", &req.Lng)
 	decode("type", &req.Type)
 	decode("bedrooms", &req.Bedrooms)
 	decode("beds", &req.Beds)
@@ -186,8 +407,41 @@ func (h *Handler) UpdateListing(w http.ResponseWriter, r *http.Request) {
 	decode("maxNights", &req.MaxNights)
 	decode("cancellationPolicy", &req.CancellationPolicy)
 	decode("instantBook", &req.InstantBook)
+	decode("paymentWindowMinutes", &req.PaymentWindowMinutes)
+	decode("payOnArrival", &req.PayOnArrival)
+	decode("timezone", &req.Timezone)
 	decode("status", &req.Status)
 
+	if req.Status != nil {
+		if *req.Status == domain.StatusArchived {
+			httputil.WriteError(w, http.StatusUnprocessableEntity, "use POST /listings/{id}/archive to archive a listing")
+			return
+		}
+		if cur, err := h.Store.Get(r.Context(), id); err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, "db error")
+			return
+		} else if cur.Status == domain.StatusArchived {
+			httputil.WriteError(w, http.StatusConflict, "listing is archived; unarchive it first")
+			return
+		}
+	}
+
+	if err := h.validateUpdate(r.Context(), tenantFromRequest(r), &req); err != nil {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if req.Lat != nil {
+		provided := domain.LocationProvided
+		req.LocationSource = &provided
+	} else if h.Geocoder != nil {
+		cur, err := h.Store.Get(r.Context(), id)
+		if err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, "db error")
+			return
+		}
+		h.geocodeUpdate(r.Context(), cur, &req)
+	}
+
 	l, err := h.Store.Update(r.Context(), id, req)
 	if errors.Is(err, store.ErrNotFound) {
 		httputil.WriteError(w, http.StatusNotFound, "listing not found")
@@ -197,9 +451,49 @@ func (h *Handler) UpdateListing(w http.ResponseWriter, r *http.Request) {
 		httputil.WriteError(w, http.StatusInternalServerError, "update failed")
 		return
 	}
+	h.recordVersion(r, l)
+	h.reindex(r.Context(), id, searchindex.EventListingUpdated)
 	httputil.WriteJSON(w, http.StatusOK, l)
 }
 
+// validateUpdate checks the fields set in an update against the same rules and
+// tenant config as a create, normalising the timezone and type in place. Used
+// by both UpdateListing and RestoreVersion, so an old version can't bring back
+// a value the listing could no longer be saved with.
+func (h *Handler) validateUpdate(ctx context.Context, tenantID string, req *domain.UpdateListingInput) error {
+	if req.PaymentWindowMinutes != nil && !validPaymentWindow(*req.PaymentWindowMinutes) {
+		return errors.New(paymentWindowError)
+	}
+	if req.Timezone != nil {
+		tz := strings.TrimSpace(*req.Timezone)
+		if err := domain.ValidateTimezone(tz); err != nil {
+			return err
+		}
+		req.Timezone = &tz
+	}
+	if err := domain.ValidateCoordinates(req.Lat, req.Lng); err != nil {
+		return err
+	}
+	if req.Currency != nil {
+		if err := h.checkCurrency(ctx, tenantID, *req.Currency); err != nil {
+			return err
+		}
+	}
+	if req.Type != nil {
+		t, err := domain.ResolveListingType(*req.Type, h.listingTypes(ctx, tenantID))
+		if err != nil {
+			return err
+		}
+		req.Type = &t
+	}
+	if req.CancellationPolicy != nil {
+		if err := h.checkPolicy(ctx, tenantID, *req.CancellationPolicy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (h *Handler) DeleteListing(w http.ResponseWriter, r *http.Request) {
 	id := listingID(r)
 	if h.requireOwner(w, r, id) == "" {
@@ -212,6 +506,7 @@ func (h *Handler) DeleteListing(w http.ResponseWriter, r *http.Request) {
 		httputil.WriteError(w, http.StatusInternalServerError, "delete failed")
 		return
 	}
+	h.unindex(r.Context(), tenantFromRequest(r), id)
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -220,16 +515,30 @@ func (h *Handler) PublishListing(w http.ResponseWriter, r *http.Request) {
 	if h.requireOwner(w, r, id) == "" {
 		return
 	}
+	l, err := h.Store.Get(r.Context(), id)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if l.Status == domain.StatusArchived {
+		httputil.WriteError(w, http.StatusConflict, "listing is archived; unarchive it first")
+		return
+	}
 	count, _ := h.Store.PhotoCount(r.Context(), id)
-	if count == 0 {
-		httputil.WriteError(w, http.StatusUnprocessableEntity, "at least one photo is required to publish")
+	rules := append(slices.Clip(h.PublishRules), domain.RequireFields(h.requiredFields(r.Context(), tenantFromRequest(r))))
+	if missing := domain.CheckPublish(domain.PublishCheck{Listing: l, PhotoCount: count}, rules); len(missing) > 0 {
+		httputil.WriteJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"error":   "listing does not meet publish requirements",
+			"missing": missing,
+		})
 		return
 	}
-	if err := h.Store.SetStatus(r.Context(), id, "active"); err != nil {
+	if err := h.Store.SetStatus(r.Context(), id, domain.StatusActive); err != nil {
 		httputil.WriteError(w, http.StatusInternalServerError, "publish failed")
 		return
 	}
-	httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": "active"})
+	h.reindex(r.Context(), id, searchindex.EventListingPublished)
+	httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": domain.StatusActive})
 }
 
 func (h *Handler) UnpublishListing(w http.ResponseWriter, r *http.Request) {
@@ -237,11 +546,63 @@ func (h *Handler) UnpublishListing(w http.ResponseWriter, r *http.Request) {
 	if h.requireOwner(w, r, id) == "" {
 		return
 	}
-	if err := h.Store.SetStatus(r.Context(), id, "paused"); err != nil {
+	l, err := h.Store.Get(r.Context(), id)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if l.Status == domain.StatusArchived {
+		httputil.WriteError(w, http.StatusConflict, "listing is archived; unarchive it first")
+		return
+	}
+	if err := h.Store.SetStatus(r.Context(), id, domain.StatusPaused); err != nil {
 		httputil.WriteError(w, http.StatusInternalServerError, "unpublish failed")
 		return
 	}
-	httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": "paused"})
+	h.reindex(r.Context(), id, searchindex.EventListingUnpublished)
+	httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": domain.StatusPaused})
+}
+
+// ArchiveListing takes a listing off the market permanently. It drops out of
+// search and public lists but stays readable, so existing bookings and
+// reviews keep resolving it.
+// POST /listings/{id}/archive
+func (h *Handler) ArchiveListing(w http.ResponseWriter, r *http.Request) {
+	id := listingID(r)
+	if h.requireOwner(w, r, id) == "" {
+		return
+	}
+	if err := h.Store.SetStatus(r.Context(), id, domain.StatusArchived); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "archive failed")
+		return
+	}
+	h.reindex(r.Context(), id, searchindex.EventListingUpdated)
+	httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": domain.StatusArchived})
+}
+
+// UnarchiveListing returns an archived listing to paused; the host then has
+// to publish it again.
+// POST /listings/{id}/unarchive
+func (h *Handler) UnarchiveListing(w http.ResponseWriter, r *http.Request) {
+	id := listingID(r)
+	if h.requireOwner(w, r, id) == "" {
+		return
+	}
+	l, err := h.Store.Get(r.Context(), id)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if l.Status != domain.StatusArchived {
+		httputil.WriteError(w, http.StatusConflict, "listing is not archived")
+		return
+	}
+	if err := h.Store.SetStatus(r.Context(), id, domain.StatusPaused); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "unarchive failed")
+		return
+	}
+	h.reindex(r.Context(), id, searchindex.EventListingUpdated)
+	httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": domain.StatusPaused})
 }
 
 // ─── helpers ─────────────────────────────────────────────────────────────────
@@ -253,6 +614,15 @@ func atLeast1(n int) int {
 	return n
 }
 
+// maxPaymentWindowMinutes caps how long a host can hold dates awaiting payment.
+const maxPaymentWindowMinutes = 7 * 24 * 60
+
+const paymentWindowError = "paymentWindowMinutes must be between 0 (platform default) and 10080"
+
+func validPaymentWindow(n int) bool {
+	return n >= 0 && n <= maxPaymentWindowMinutes
+}
+
 func positiveOrDefault(n, def int) int {
 	if n <= 0 {
 		return def
@@ -3,10 +3,15 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	httputil "github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/internal/tenantconfig"
+	"github.com/saidmashhud/zist/services/listings/searchindex"
 	"github.com/saidmashhud/zist/services/listings/store"
 )
 
@@ -18,6 +23,33 @@ func (h *Handler) ListPhotos(w http.ResponseWriter, r *http.Request) {
 	httputil.WriteJSON(w, http.StatusOK, map[string]any{"photos": photos})
 }
 
+// PhotoCount returns how many photos a listing has, for other services (e.g.
+// bookings enforcing a tenant's photo minimum). Unlike the public listing it
+// fails when the photos can't be read rather than reporting none.
+// GET /listings/{id}/photos/count
+func (h *Handler) PhotoCount(w http.ResponseWriter, r *http.Request) {
+	id := listingID(r)
+	tenantID := serviceTenant(w, r)
+	if tenantID == "" {
+		return
+	}
+	if _, err := h.Store.GetForTenant(r.Context(), tenantID, id); errors.Is(err, store.ErrNotFound) {
+		httputil.WriteError(w, http.StatusNotFound, "listing not found")
+		return
+	} else if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	count, err := h.Store.PhotoCount(r.Context(), id)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]int{"count": count})
+}
+
+// AddPhoto registers a photo URL on the listing.
+// POST /listings/{id}/photos
 func (h *Handler) AddPhoto(w http.ResponseWriter, r *http.Request) {
 	id := listingID(r)
 	if h.requireOwner(w, r, id) == "" {
@@ -38,19 +70,82 @@ func (h *Handler) AddPhoto(w http.ResponseWriter, r *http.Request) {
 	}
 
 	count, _ := h.Store.PhotoCount(r.Context(), id)
-	if count >= 20 {
-		httputil.WriteError(w, http.StatusUnprocessableEntity, "photo limit exceeded (max 20)")
+	if count >= tenantconfig.MaxListingPhotos {
+		httputil.WriteError(w, http.StatusUnprocessableEntity,
+			fmt.Sprintf("photo limit exceeded (max %d)", tenantconfig.MaxListingPhotos))
 		return
 	}
+	if h.PhotoCheck != nil {
+		if err := h.PhotoCheck.Check(r.Context(), req.URL); err != nil {
+			httputil.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+	}
 
 	photo, err := h.Store.AddPhoto(r.Context(), id, req.URL, req.Caption, count)
 	if err != nil {
 		httputil.WriteError(w, http.StatusInternalServerError, "insert photo failed")
 		return
 	}
+	h.reindex(r.Context(), id, searchindex.EventListingUpdated)
 	httputil.WriteJSON(w, http.StatusCreated, photo)
 }
 
+// uploadURLTTL is how long a photo upload URL stays valid.
+const uploadURLTTL = 15 * time.Minute
+
+// photoExtensions lists the accepted image types and their file extensions.
+var photoExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// PhotoUploadURL issues a signed URL the host PUTs an image to, plus the
+// public URL to register with AddPhoto afterwards.
+// POST /listings/{id}/photos/upload-url
+func (h *Handler) PhotoUploadURL(w http.ResponseWriter, r *http.Request) {
+	id := listingID(r)
+	hostID := h.requireOwner(w, r, id)
+	if hostID == "" {
+		return
+	}
+	if h.Media == nil {
+		httputil.WriteError(w, http.StatusServiceUnavailable, "photo uploads are not configured")
+		return
+	}
+
+	var req struct {
+		ContentType string `json:"contentType"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	ext, ok := photoExtensions[req.ContentType]
+	if !ok {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, "contentType must be image/jpeg, image/png or image/webp")
+		return
+	}
+	if !h.uploads.Allow(hostID) {
+		httputil.WriteError(w, http.StatusTooManyRequests, "too many upload requests, try again later")
+		return
+	}
+
+	up, err := h.Media.PresignUpload(r.Context(), id+"/"+uuid.NewString()+ext, req.ContentType, uploadURLTTL)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "could not create upload URL")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{
+		"uploadUrl":   up.UploadURL,
+		"method":      http.MethodPut,
+		"contentType": req.ContentType,
+		"publicUrl":   up.PublicURL,
+		"expiresAt":   up.ExpiresAt,
+	})
+}
+
 func (h *Handler) ReorderPhotos(w http.ResponseWriter, r *http.Request) {
 	id := listingID(r)
 	if h.requireOwner(w, r, id) == "" {
@@ -81,6 +176,7 @@ func (h *Handler) ReorderPhotos(w http.ResponseWriter, r *http.Request) {
 		httputil.WriteError(w, http.StatusInternalServerError, "reorder failed")
 		return
 	}
+	h.reindex(r.Context(), id, searchindex.EventListingUpdated)
 	httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
@@ -97,5 +193,6 @@ func (h *Handler) DeletePhoto(w http.ResponseWriter, r *http.Request) {
 		httputil.WriteError(w, http.StatusInternalServerError, "delete failed")
 		return
 	}
+	h.reindex(r.Context(), id, searchindex.EventListingUpdated)
 	w.WriteHeader(http.StatusNoContent)
 }
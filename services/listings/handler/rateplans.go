@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	zistauth "github.com/saidmashhud/zist/internal/auth"
+	httputil "github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/services/listings/domain"
+	"github.com/saidmashhud/zist/services/listings/store"
+)
+
+// ListRatePlans returns the listing's seasonal rate plans.
+// GET /listings/{id}/rate-plans  (owner only)
+func (h *Handler) ListRatePlans(w http.ResponseWriter, r *http.Request) {
+	id := listingID(r)
+	if h.requireOwner(w, r, id) == "" {
+		return
+	}
+	plans, err := h.Store.ListRatePlans(r.Context(), id)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"ratePlans": plans})
+}
+
+// CreateRatePlan adds a base-price multiplier for a date range.
+// POST /listings/{id}/rate-plans  (owner only)
+func (h *Handler) CreateRatePlan(w http.ResponseWriter, r *http.Request) {
+	id := listingID(r)
+	if h.requireOwner(w, r, id) == "" {
+		return
+	}
+	var req struct {
+		Name       string `json:"name"`
+		From       string `json:"from"`
+		To         string `json:"to"`
+		Multiplier string `json:"multiplier"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	plan := domain.RatePlan{
+		ListingID:  id,
+		Name:       strings.TrimSpace(req.Name),
+		From:       req.From,
+		To:         req.To,
+		Multiplier: strings.TrimSpace(req.Multiplier),
+	}
+	if err := plan.Validate(); err != nil {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	plan, err := h.Store.CreateRatePlan(r.Context(), zistauth.FromContext(r.Context()).TenantID, plan)
+	if errors.Is(err, store.ErrPlanOverlap) {
+		httputil.WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "create failed")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusCreated, plan)
+}
+
+// DeleteRatePlan removes a rate plan.
+// DELETE /listings/{id}/rate-plans/{planId}  (owner only)
+func (h *Handler) DeleteRatePlan(w http.ResponseWriter, r *http.Request) {
+	id := listingID(r)
+	if h.requireOwner(w, r, id) == "" {
+		return
+	}
+	err := h.Store.DeleteRatePlan(r.Context(), id, chi.URLParam(r, "planId"))
+	if errors.Is(err, store.ErrNotFound) {
+		httputil.WriteError(w, http.StatusNotFound, "rate plan not found")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "delete failed")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
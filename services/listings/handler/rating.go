@@ -6,6 +6,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/services/listings/searchindex"
 )
 
 // UpdateRating handles PUT /listings/{id}/rating (internal).
@@ -26,6 +27,7 @@ func (h *Handler) UpdateRating(w http.ResponseWriter, r *http.Request) {
 		httputil.WriteError(w, http.StatusInternalServerError, "failed to update rating")
 		return
 	}
+	h.reindex(r.Context(), id, searchindex.EventListingUpdated)
 
 	httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
@@ -1,16 +1,17 @@
 package handler
 
 import (
-	"fmt"
-	"math"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	httputil "github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/internal/money"
 	"github.com/saidmashhud/zist/services/listings/domain"
 	"github.com/saidmashhud/zist/services/listings/store"
+	"github.com/shopspring/decimal"
 )
 
 func (h *Handler) SearchListings(w http.ResponseWriter, r *http.Request) {
@@ -35,6 +36,12 @@ func (h *Handler) SearchListings(w http.ResponseWriter, r *http.Request) {
 	if amenities := q.Get("amenities"); amenities != "" {
 		f.Amenities = strings.Split(amenities, ",")
 	}
+	minRating, err := domain.ParseMinRating(q.Get("minRating"))
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	f.MinRating = minRating
 
 	// Validate date pair if provided.
 	if f.CheckIn != "" && f.CheckOut != "" {
@@ -59,6 +66,9 @@ func (h *Handler) SearchListings(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if notModified(w, r, domain.ETag(listings...)) {
+		return
+	}
 	httputil.WriteJSON(w, http.StatusOK, map[string]any{
 		"listings": listings,
 		"total":    len(listings),
@@ -67,77 +77,127 @@ func (h *Handler) SearchListings(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) PricePreview(w http.ResponseWriter, r *http.Request) {
 	id := listingID(r)
+	preview, ok := h.priceQuote(w, r, id)
+	if !ok {
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, preview)
+}
+
+// Quote is the authoritative price for a stay, used by bookings so that the
+// amount charged matches the preview (per-date overrides included).
+// GET /listings/{id}/quote?check_in=&check_out=&guests=  (internal)
+func (h *Handler) Quote(w http.ResponseWriter, r *http.Request) {
+	id := listingID(r)
+	tenantID := serviceTenant(w, r)
+	if tenantID == "" {
+		return
+	}
+	l, err := h.Store.GetForTenant(r.Context(), tenantID, id)
+	if errors.Is(err, store.ErrNotFound) {
+		httputil.WriteError(w, http.StatusNotFound, "listing not found")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if g := r.URL.Query().Get("guests"); g != "" {
+		guests, err := strconv.Atoi(g)
+		if err != nil || guests < 1 {
+			httputil.WriteError(w, http.StatusBadRequest, "guests must be a positive integer")
+			return
+		}
+		if err := domain.CheckGuests(guests, l.MaxGuests); err != nil {
+			httputil.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+	}
+	quote, ok := h.priceQuote(w, r, id)
+	if !ok {
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, quote)
+}
+
+// priceQuote prices the check_in/check_out stay for listing id, writing the
+// error response and returning false when the request can't be priced.
+func (h *Handler) priceQuote(w http.ResponseWriter, r *http.Request, id string) (domain.PricePreview, bool) {
 	checkIn := r.URL.Query().Get("check_in")
 	checkOut := r.URL.Query().Get("check_out")
 
 	if checkIn == "" || checkOut == "" {
 		httputil.WriteError(w, http.StatusBadRequest, "check_in and check_out are required")
-		return
+		return domain.PricePreview{}, false
 	}
 
 	ciDate, err1 := time.Parse("2006-01-02", checkIn)
 	coDate, err2 := time.Parse("2006-01-02", checkOut)
 	if err1 != nil || err2 != nil || !coDate.After(ciDate) {
 		httputil.WriteError(w, http.StatusBadRequest, "invalid dates: check_out must be after check_in")
-		return
+		return domain.PricePreview{}, false
 	}
 
 	nights := int(coDate.Sub(ciDate).Hours() / 24)
 	if nights <= 0 {
 		httputil.WriteError(w, http.StatusBadRequest, "minimum stay is 1 night")
-		return
+		return domain.PricePreview{}, false
 	}
 
-	ppn, cleaningFee, currency, minNights, maxNights, err := h.Store.GetPricingInfo(r.Context(), id)
+	ppn, cleaningFee, depositAmt, currency, minNights, maxNights, err := h.Store.GetPricingInfo(r.Context(), id)
 	if err != nil {
 		if err == store.ErrNotFound {
 			httputil.WriteError(w, http.StatusNotFound, "listing not found")
 		} else {
 			httputil.WriteError(w, http.StatusInternalServerError, "db error")
 		}
-		return
+		return domain.PricePreview{}, false
 	}
-	if nights < minNights {
-		httputil.WriteError(w, http.StatusUnprocessableEntity, fmt.Sprintf("minimum stay is %d nights", minNights))
-		return
-	}
-	if nights > maxNights {
-		httputil.WriteError(w, http.StatusUnprocessableEntity, fmt.Sprintf("maximum stay is %d nights", maxNights))
-		return
+	if err := domain.CheckNights(nights, minNights, maxNights); err != nil {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+		return domain.PricePreview{}, false
 	}
 
-	// Per-day prices from the store (uses price_override if set).
-	pricesByDate, _ := h.Store.GetPricesByDate(r.Context(), id, ppn, checkIn, checkOut)
-
-	basePPN := parseFloat(ppn)
-	var subtotal float64
-	effectivePPN := basePPN
-
-	if len(pricesByDate) > 0 {
-		for _, p := range pricesByDate {
-			subtotal += parseFloat(p)
+	// Every night at its price_override, else the base price scaled by any
+	// rate plan, else the base price.
+	overrides, plans, err := h.Store.GetPriceRules(r.Context(), id, checkIn, checkOut)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return domain.PricePreview{}, false
+	}
+	nightly, err := domain.NightlyPrices(h.Rounding, currency, ppn, checkIn, checkOut, overrides, plans)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "invalid listing price")
+		return domain.PricePreview{}, false
+	}
+	subtotal := decimal.Zero
+	for _, p := range nightly {
+		d, err := money.Parse(p)
+		if err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, "invalid listing price")
+			return domain.PricePreview{}, false
 		}
-		effectivePPN = subtotal / float64(len(pricesByDate))
-	} else {
-		subtotal = basePPN * float64(nights)
+		subtotal = subtotal.Add(d)
 	}
 
-	cleaning := parseFloat(cleaningFee)
-	platformFee := math.Round((subtotal+cleaning)*h.FeeGuestPct) / 100.0
-	total := subtotal + cleaning + platformFee
+	// Rounded as the bookings service rounds, so a booking for the same
+	// dates is charged this total. The deposit is held and returned, so it
+	// never attracts the platform fee.
+	pricing, err := h.Rounding.Price(subtotal.String(), cleaningFee, depositAmt, currency, h.FeeGuestPct)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "invalid listing price")
+		return domain.PricePreview{}, false
+	}
+	perNight := subtotal.Div(decimal.NewFromInt(int64(len(nightly))))
 
-	httputil.WriteJSON(w, http.StatusOK, domain.PricePreview{
+	return domain.PricePreview{
 		Nights:           nights,
-		PricePerNight:    fmt.Sprintf("%.2f", effectivePPN),
-		Subtotal:         fmt.Sprintf("%.2f", subtotal),
-		CleaningFee:      fmt.Sprintf("%.2f", cleaning),
-		PlatformFeeGuest: fmt.Sprintf("%.2f", platformFee),
-		Total:            fmt.Sprintf("%.2f", total),
+		PricePerNight:    h.Rounding.Format(perNight, currency),
+		Subtotal:         pricing.Subtotal,
+		CleaningFee:      pricing.CleaningFee,
+		PlatformFeeGuest: pricing.PlatformFee,
+		Deposit:          pricing.Deposit,
+		Total:            pricing.Total,
 		Currency:         currency,
-	})
-}
-
-func parseFloat(s string) float64 {
-	f, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
-	return f
+	}, true
 }
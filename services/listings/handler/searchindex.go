@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	zistauth "github.com/saidmashhud/zist/internal/auth"
+	httputil "github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/services/listings/searchindex"
+)
+
+// reindex propagates the current state of a listing to the search
+// projection: as eventType on the events plane when one is configured,
+// otherwise by pushing straight to the search service. Failures are logged,
+// not surfaced: the listing write has already succeeded and a later change
+// or a full reindex will repair the projection.
+func (h *Handler) reindex(ctx context.Context, id, eventType string) {
+	if !h.Events.Enabled() && !h.Search.Enabled() {
+		return
+	}
+	doc, err := h.Store.SearchDocument(ctx, id)
+	if err != nil {
+		slog.Warn("search index: load listing failed", "listing_id", id, "err", err)
+		return
+	}
+	if h.Events.Enabled() {
+		if err := h.Events.PublishDocument(ctx, eventType, doc); err != nil {
+			slog.Warn("listing event: publish failed", "listing_id", id, "event", eventType, "err", err)
+		}
+		return
+	}
+	if err := h.Search.Upsert(ctx, doc); err != nil {
+		slog.Warn("search index: upsert failed", "listing_id", id, "err", err)
+	}
+}
+
+// unindex removes a deleted listing from the search projection.
+func (h *Handler) unindex(ctx context.Context, tenantID, id string) {
+	deletedAt := time.Now().Unix()
+	if h.Events.Enabled() {
+		if err := h.Events.PublishDeleted(ctx, tenantID, id, deletedAt); err != nil {
+			slog.Warn("listing event: publish failed", "listing_id", id, "event", searchindex.EventListingDeleted, "err", err)
+		}
+		return
+	}
+	if err := h.Search.Remove(ctx, id, deletedAt); err != nil {
+		slog.Warn("search index: remove failed", "listing_id", id, "err", err)
+	}
+}
+
+// ReindexListings pushes every listing in the caller's tenant to the search
+// projection. Intended for backfills after the projection is (re)created.
+// POST /listings/reindex (zist.admin)
+func (h *Handler) ReindexListings(w http.ResponseWriter, r *http.Request) {
+	p := zistauth.FromContext(r.Context())
+	if p == nil || p.TenantID == "" {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !h.Search.Enabled() {
+		httputil.WriteError(w, http.StatusServiceUnavailable, "search indexing is not configured")
+		return
+	}
+
+	ids, err := h.Store.ListIDsForTenant(r.Context(), p.TenantID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	var indexed, failed int
+	for _, id := range ids {
+		doc, err := h.Store.SearchDocument(r.Context(), id)
+		if err == nil {
+			err = h.Search.Upsert(r.Context(), doc)
+		}
+		if err != nil {
+			slog.Warn("search reindex failed", "listing_id", id, "err", err)
+			failed++
+			continue
+		}
+		indexed++
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]int{"indexed": indexed, "failed": failed})
+}
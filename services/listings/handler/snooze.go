@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	httputil "github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/services/listings/domain"
+	"github.com/saidmashhud/zist/services/listings/searchindex"
+)
+
+// SnoozeListing pauses a published listing until a date, after which the
+// snooze worker republishes it. Snoozing again moves the date.
+// POST /listings/{id}/snooze?until=YYYY-MM-DD
+func (h *Handler) SnoozeListing(w http.ResponseWriter, r *http.Request) {
+	id := listingID(r)
+	if h.requireOwner(w, r, id) == "" {
+		return
+	}
+	until, err := domain.ParseSnoozeUntil(r.URL.Query().Get("until"), time.Now())
+	if err != nil {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	l, err := h.Store.Get(r.Context(), id)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if l.Status != domain.StatusActive && l.Status != domain.StatusPaused {
+		httputil.WriteError(w, http.StatusConflict, "only published listings can be snoozed")
+		return
+	}
+	if err := h.Store.Snooze(r.Context(), id, until); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "snooze failed")
+		return
+	}
+	h.reindex(r.Context(), id, searchindex.EventListingUnpublished)
+	httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": domain.StatusPaused, "snoozeUntil": until})
+}
+
+// UnsnoozeListing cancels a scheduled republish. The listing stays paused
+// until the host publishes it.
+// POST /listings/{id}/unsnooze
+func (h *Handler) UnsnoozeListing(w http.ResponseWriter, r *http.Request) {
+	id := listingID(r)
+	if h.requireOwner(w, r, id) == "" {
+		return
+	}
+	ok, err := h.Store.Unsnooze(r.Context(), id)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "unsnooze failed")
+		return
+	}
+	if !ok {
+		httputil.WriteError(w, http.StatusConflict, "listing is not snoozed")
+		return
+	}
+	h.reindex(r.Context(), id, searchindex.EventListingUpdated)
+	httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": domain.StatusPaused})
+}
+
+// RunSnoozeWorker periodically republishes listings whose snooze date has
+// arrived. It blocks until ctx is cancelled.
+func (h *Handler) RunSnoozeWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.wakeSnoozed(ctx)
+		}
+	}
+}
+
+func (h *Handler) wakeSnoozed(ctx context.Context) {
+	sweepCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	ids, err := h.Store.WakeSnoozed(sweepCtx, domain.SnoozeCutoff(time.Now()))
+	if err != nil {
+		slog.Error("snooze sweep failed", "err", err)
+		return
+	}
+	for _, id := range ids {
+		h.reindex(sweepCtx, id, searchindex.EventListingPublished)
+		slog.Info("snoozed listing republished", "listing_id", id)
+	}
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/internal/tenantconfig"
+	"github.com/saidmashhud/zist/services/listings/domain"
+)
+
+// WithTenantConfig enforces per-tenant settings read from the admin service.
+// Without it every currency is accepted.
+func (h *Handler) WithTenantConfig(adminURL, internalToken string) *Handler {
+	if adminURL != "" {
+		h.Tenants = tenantconfig.New(adminURL, internalToken)
+	}
+	return h
+}
+
+// checkCurrency rejects a listing currency the tenant doesn't support. If the
+// tenant config can't be read the currency is allowed.
+func (h *Handler) checkCurrency(ctx context.Context, tenantID, currency string) error {
+	if h.Tenants == nil {
+		return nil
+	}
+	cfg, err := h.Tenants.Get(ctx, tenantID)
+	if err != nil {
+		slog.Warn("tenant config unavailable", "tenantId", tenantID, "err", err)
+		return nil
+	}
+	return domain.CheckCurrency(cfg.SupportedCurrencies, currency)
+}
+
+// requiredFields returns the listing fields the tenant requires: the
+// defaults plus any its config adds. If the tenant config can't be read only
+// the defaults apply.
+func (h *Handler) requiredFields(ctx context.Context, tenantID string) []string {
+	required := tenantconfig.DefaultRequiredListingFields()
+	if h.Tenants == nil {
+		return required
+	}
+	cfg, err := h.Tenants.Get(ctx, tenantID)
+	if err != nil {
+		slog.Warn("tenant config unavailable", "tenantId", tenantID, "err", err)
+		return required
+	}
+	return append(required, cfg.RequiredListingFields...)
+}
+
+// checkPolicy rejects a cancellation policy that is neither built in nor in
+// the tenant's catalog. If the tenant config can't be read only the
+// built-ins are accepted.
+func (h *Handler) checkPolicy(ctx context.Context, tenantID, policy string) error {
+	var custom []string
+	if h.Tenants != nil {
+		cfg, err := h.Tenants.Get(ctx, tenantID)
+		if err != nil {
+			slog.Warn("tenant config unavailable", "tenantId", tenantID, "err", err)
+		}
+		custom = cfg.PolicyNames()
+	}
+	return domain.CheckCancellationPolicy(policy, custom)
+}
+
+// PolicyUsage counts the tenant's listings by cancellation policy, so admin
+// can refuse to delete a custom policy listings still use.
+// GET /listings/policy-usage  (internal)
+func (h *Handler) PolicyUsage(w http.ResponseWriter, r *http.Request) {
+	tenantID := serviceTenant(w, r)
+	if tenantID == "" {
+		return
+	}
+	usage, err := h.Store.PolicyUsage(r.Context(), tenantID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"policies": usage})
+}
+
+// listingTypes returns the types the tenant allows listings to have. If the
+// tenant config can't be read the defaults apply.
+func (h *Handler) listingTypes(ctx context.Context, tenantID string) []string {
+	if h.Tenants == nil {
+		return tenantconfig.DefaultListingTypes()
+	}
+	cfg, err := h.Tenants.Get(ctx, tenantID)
+	if err != nil {
+		slog.Warn("tenant config unavailable", "tenantId", tenantID, "err", err)
+		return tenantconfig.DefaultListingTypes()
+	}
+	return cfg.ListingTypes()
+}
+
+// ListingTypes lists the types the tenant allows, for listing forms.
+// GET /listings/types
+func (h *Handler) ListingTypes(w http.ResponseWriter, r *http.Request) {
+	types := h.listingTypes(r.Context(), tenantFromRequest(r))
+	def, _ := domain.ResolveListingType("", types)
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"types": types, "default": def})
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	zistauth "github.com/saidmashhud/zist/internal/auth"
+	"github.com/saidmashhud/zist/services/listings/domain"
+)
+
+func TestCreateListingRequiredFields(t *testing.T) {
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"tenantId":"t1","requiredListingFields":["title","city","pricePerNight","address"]}`)
+	}))
+	defer admin.Close()
+
+	// The check runs before the listing is stored, so no store is needed.
+	h := New(nil, 0).WithTenantConfig(admin.URL, "tok")
+	req := httptest.NewRequest(http.MethodPost, "/listings",
+		strings.NewReader(`{"title":"Courtyard room","city":"Bukhara","pricePerNight":"300000","currency":"UZS"}`))
+	req.Header.Set("X-User-ID", "host1")
+	req.Header.Set("X-Tenant-ID", "t1")
+	rec := httptest.NewRecorder()
+	zistauth.Middleware(http.HandlerFunc(h.CreateListing)).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", rec.Code)
+	}
+	var body struct {
+		Error   string   `json:"error"`
+		Missing []string `json:"missing"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Error != "listing missing required fields" || !slices.Equal(body.Missing, []string{"address"}) {
+		t.Errorf("body = %+v, want missing [address]", body)
+	}
+}
+
+func TestCreateListingCancellationPolicy(t *testing.T) {
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"tenantId":"t1","cancellationPolicies":[{"name":"super_strict","tiers":[{"hoursBefore":720,"refundPct":50}]}]}`)
+	}))
+	defer admin.Close()
+
+	// An unknown policy is rejected before the listing is stored, so no store
+	// is needed.
+	h := New(nil, 0).WithTenantConfig(admin.URL, "tok")
+	req := httptest.NewRequest(http.MethodPost, "/listings",
+		strings.NewReader(`{"title":"Courtyard room","city":"Bukhara","pricePerNight":"300000","currency":"UZS","cancellationPolicy":"ultra_strict"}`))
+	req.Header.Set("X-User-ID", "host1")
+	req.Header.Set("X-Tenant-ID", "t1")
+	rec := httptest.NewRecorder()
+	zistauth.Middleware(http.HandlerFunc(h.CreateListing)).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "super_strict") {
+		t.Errorf("body = %s, want the tenant's policies listed", rec.Body)
+	}
+}
+
+func TestCreateListingType(t *testing.T) {
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"tenantId":"t1","allowedListingTypes":["yurt","room"]}`)
+	}))
+	defer admin.Close()
+
+	h := New(nil, 0).WithTenantConfig(admin.URL, "tok")
+	req := httptest.NewRequest(http.MethodPost, "/listings",
+		strings.NewReader(`{"title":"Steppe flat","city":"Nukus","pricePerNight":"300000","currency":"UZS","type":"apartment"}`))
+	req.Header.Set("X-User-ID", "host1")
+	req.Header.Set("X-Tenant-ID", "t1")
+	rec := httptest.NewRecorder()
+	zistauth.Middleware(http.HandlerFunc(h.CreateListing)).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "yurt, room") {
+		t.Errorf("body = %s, want the tenant's types listed", rec.Body)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/listings/types", nil)
+	req.Header.Set("X-Tenant-ID", "t1")
+	rec = httptest.NewRecorder()
+	h.ListingTypes(rec, req)
+	var body struct {
+		Types   []string `json:"types"`
+		Default string   `json:"default"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(body.Types, []string{"yurt", "room"}) || body.Default != "yurt" {
+		t.Errorf("types = %+v, want [yurt room] defaulting to yurt", body)
+	}
+}
+
+func TestValidateUpdateRestoredVersion(t *testing.T) {
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"tenantId":"t1","allowedListingTypes":["room"]}`)
+	}))
+	defer admin.Close()
+
+	h := New(nil, 0).WithTenantConfig(admin.URL, "tok")
+	v := domain.ListingVersion{Snapshot: domain.Listing{
+		Type: "room", Currency: "UZS", CancellationPolicy: "flexible", Timezone: "Asia/Tashkent",
+	}}
+	in := v.RestoreInput()
+	if err := h.validateUpdate(context.Background(), "t1", &in); err != nil {
+		t.Fatalf("valid version rejected: %v", err)
+	}
+
+	// A version saved before the tenant dropped its type can't bring it back.
+	v.Snapshot.Type = "apartment"
+	in = v.RestoreInput()
+	if err := h.validateUpdate(context.Background(), "t1", &in); err == nil {
+		t.Error("restoring a type the tenant no longer allows was accepted")
+	}
+
+	v.Snapshot.Type = "room"
+	v.Snapshot.PaymentWindowMinutes = -1
+	in = v.RestoreInput()
+	if err := h.validateUpdate(context.Background(), "t1", &in); err == nil {
+		t.Error("restoring an invalid payment window was accepted")
+	}
+}
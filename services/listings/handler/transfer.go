@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	zistauth "github.com/saidmashhud/zist/internal/auth"
+	httputil "github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/services/listings/searchindex"
+	"github.com/saidmashhud/zist/services/listings/store"
+)
+
+// TransferListing moves a listing to another host in the same tenant, e.g.
+// when the property's host account changes. The listing's bookings follow
+// via the bookings service. The new host must be a user of the tenant in
+// mgID, whether or not they host anything yet. Re-running a transfer is safe,
+// so a failed booking cascade can be retried.
+// POST /listings/{id}/transfer (zist.admin)
+func (h *Handler) TransferListing(w http.ResponseWriter, r *http.Request) {
+	p := zistauth.FromContext(r.Context())
+	if p == nil || p.TenantID == "" {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if h.Bookings == nil {
+		httputil.WriteError(w, http.StatusServiceUnavailable, "bookings service is not configured")
+		return
+	}
+	if h.Users == nil {
+		httputil.WriteError(w, http.StatusServiceUnavailable, "user directory is not configured")
+		return
+	}
+
+	var req struct {
+		HostID string `json:"hostId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.HostID = strings.TrimSpace(req.HostID)
+	if req.HostID == "" {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, "hostId is required")
+		return
+	}
+
+	tenantID, err := h.Users.TenantOf(r.Context(), req.HostID)
+	if errors.Is(err, errUnknownUser) {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, "hostId is not a known user")
+		return
+	}
+	if err != nil {
+		slog.Error("listing transfer: user lookup failed", "host_id", req.HostID, "err", err)
+		httputil.WriteError(w, http.StatusBadGateway, "user directory unavailable")
+		return
+	}
+	if tenantID != p.TenantID {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, "hostId belongs to a different tenant")
+		return
+	}
+
+	id := listingID(r)
+	prevHost, err := h.Store.GetHostIDForTenant(r.Context(), p.TenantID, id)
+	if errors.Is(err, store.ErrNotFound) {
+		httputil.WriteError(w, http.StatusNotFound, "listing not found")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	if h.Audit == nil {
+		slog.Warn("listing transfer (audit client not configured)", "listing_id", id, "actor", p.UserID, "to", req.HostID)
+	} else if err := h.Audit.Record(r.Context(), p.TenantID, p.UserID, "transfer_listing",
+		"listing:"+id, "from "+prevHost+" to "+req.HostID); err != nil {
+		slog.Error("listing transfer not audited", "listing_id", id, "err", err)
+		httputil.WriteError(w, http.StatusServiceUnavailable, "audit log unavailable")
+		return
+	}
+
+	if err := h.Store.SetHost(r.Context(), p.TenantID, id, req.HostID); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	h.reindex(r.Context(), id, searchindex.EventListingUpdated)
+
+	updated, err := h.Bookings.SetListingHost(r.Context(), p.TenantID, id, req.HostID)
+	if err != nil {
+		slog.Error("listing transfer: bookings not updated", "listing_id", id, "err", err)
+		httputil.WriteError(w, http.StatusBadGateway, "listing transferred but bookings were not updated; retry the transfer")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{
+		"listingId":       id,
+		"hostId":          req.HostID,
+		"previousHostId":  prevHost,
+		"bookingsUpdated": updated,
+	})
+}
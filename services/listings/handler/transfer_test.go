@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	zistauth "github.com/saidmashhud/zist/internal/auth"
+)
+
+func TestTransferListingHostTenant(t *testing.T) {
+	mgid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/iam/users/host-t2":
+			fmt.Fprint(w, `{"user_id":"host-t2","tenant_id":"t2"}`)
+		case "/v1/iam/users/down":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mgid.Close()
+
+	// Membership is checked before the listing is read, so no store is needed.
+	h := New(nil, 0).WithBookings("http://bookings.invalid", "tok").WithUsers(mgid.URL, "admin")
+	for _, tc := range []struct {
+		hostID string
+		want   int
+	}{
+		{"host-t2", http.StatusUnprocessableEntity},
+		{"nobody", http.StatusUnprocessableEntity},
+		{"down", http.StatusBadGateway},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/listings/l1/transfer",
+			strings.NewReader(`{"hostId":"`+tc.hostID+`"}`))
+		req.Header.Set("X-User-ID", "admin1")
+		req.Header.Set("X-Tenant-ID", "t1")
+		rec := httptest.NewRecorder()
+		zistauth.Middleware(http.HandlerFunc(h.TransferListing)).ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("hostId %s: status = %d, want %d (%s)", tc.hostID, rec.Code, tc.want, rec.Body)
+		}
+	}
+}
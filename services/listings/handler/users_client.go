@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// errUnknownUser is returned by usersClient.TenantOf when mgID has no such user.
+var errUnknownUser = errors.New("unknown user")
+
+// usersClient looks users up in mgID's IAM directory, the source of truth for
+// which tenant a user belongs to.
+type usersClient struct {
+	baseURL    string
+	adminToken string
+	http       *http.Client
+}
+
+func newUsersClient(baseURL, adminToken string) *usersClient {
+	return &usersClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		adminToken: adminToken,
+		http:       &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// TenantOf returns the tenant userID belongs to, or errUnknownUser.
+func (c *usersClient) TenantOf(ctx context.Context, userID string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.baseURL+"/v1/iam/users/"+url.PathEscape(userID), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.adminToken)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", errUnknownUser
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("mgID returned %d", resp.StatusCode)
+	}
+	var out struct {
+		TenantID string `json:"tenant_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("decode mgID user: %w", err)
+	}
+	return out.TenantID, nil
+}
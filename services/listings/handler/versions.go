@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	zistauth "github.com/saidmashhud/zist/internal/auth"
+	httputil "github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/services/listings/domain"
+	"github.com/saidmashhud/zist/services/listings/searchindex"
+	"github.com/saidmashhud/zist/services/listings/store"
+)
+
+// recordVersion saves l to its edit history. Failures are logged and never
+// fail the edit itself.
+func (h *Handler) recordVersion(r *http.Request, l domain.Listing) {
+	var editorID string
+	if p := zistauth.FromContext(r.Context()); p != nil {
+		editorID = p.UserID
+	}
+	if err := h.Store.RecordVersion(r.Context(), editorID, l, domain.MaxListingVersions); err != nil {
+		slog.Warn("listing version not recorded", "listingId", l.ID, "err", err)
+	}
+}
+
+// ListVersions returns the listing's saved versions, newest first.
+// GET /listings/{id}/versions  (owner only)
+func (h *Handler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	id := listingID(r)
+	if h.requireOwner(w, r, id) == "" {
+		return
+	}
+	versions, err := h.Store.ListVersions(r.Context(), id)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"versions": versions})
+}
+
+// RestoreVersion rolls the listing's content back to a saved version. The
+// restore is itself recorded as a new version, so it can be undone.
+// POST /listings/{id}/versions/{vid}/restore  (owner only)
+func (h *Handler) RestoreVersion(w http.ResponseWriter, r *http.Request) {
+	id := listingID(r)
+	if h.requireOwner(w, r, id) == "" {
+		return
+	}
+	v, err := h.Store.GetVersion(r.Context(), id, chi.URLParam(r, "vid"))
+	if errors.Is(err, store.ErrNotFound) {
+		httputil.WriteError(w, http.StatusNotFound, "version not found")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	in := v.RestoreInput()
+	if err := h.validateUpdate(r.Context(), tenantFromRequest(r), &in); err != nil {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	l, err := h.Store.Update(r.Context(), id, in)
+	if errors.Is(err, store.ErrNotFound) {
+		httputil.WriteError(w, http.StatusNotFound, "listing not found")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "update failed")
+		return
+	}
+	h.recordVersion(r, l)
+	h.reindex(r.Context(), id, searchindex.EventListingUpdated)
+	httputil.WriteJSON(w, http.StatusOK, l)
+}
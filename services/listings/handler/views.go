@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	zistauth "github.com/saidmashhud/zist/internal/auth"
+	httputil "github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/services/listings/domain"
+)
+
+// viewDedupWindow is how long repeat views by one viewer count only once.
+const viewDedupWindow = 30 * time.Minute
+
+// trackView records a listing view, deduplicated per viewer, and forwards it
+// to analytics. Failures never affect the page being served.
+func (h *Handler) trackView(r *http.Request, tenantID string, l domain.Listing) {
+	viewer := h.viewerHash(r)
+	now := time.Now()
+	unique, err := h.Store.RecordView(r.Context(), tenantID, l.ID, viewer, now.Unix(), now.Add(-viewDedupWindow).Unix())
+	if err != nil {
+		slog.Warn("record listing view failed", "listingId", l.ID, "err", err)
+		unique = true
+	}
+	h.Analytics.TrackListingView(r.Context(), tenantID, l.ID, l.HostID, viewer, unique)
+}
+
+// viewerHash identifies a viewer without storing who they are: the principal
+// ID when authenticated, otherwise the client IP and user agent.
+func (h *Handler) viewerHash(r *http.Request) string {
+	var id string
+	if p := zistauth.FromContext(r.Context()); p != nil && p.UserID != "" {
+		id = "user:" + p.UserID
+	} else {
+		id = "anon:" + h.Proxies.ClientIP(r) + "|" + r.UserAgent()
+	}
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+// ListingViews returns view counts for the owner's listing over the last
+// `days` days (default 30, max 365).
+// GET /listings/{id}/views
+func (h *Handler) ListingViews(w http.ResponseWriter, r *http.Request) {
+	id := listingID(r)
+	if h.requireOwner(w, r, id) == "" {
+		return
+	}
+	days := 30
+	if n, err := strconv.Atoi(r.URL.Query().Get("days")); err == nil && n > 0 && n <= 365 {
+		days = n
+	}
+	since := time.Now().AddDate(0, 0, -days).Unix()
+	stats, err := h.Store.ViewStats(r.Context(), id, since)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	stats.Days = days
+	httputil.WriteJSON(w, http.StatusOK, stats)
+}
@@ -0,0 +1,90 @@
+// Package hostrating fetches a host's aggregate rating from the reviews
+// service for display on listing detail.
+package hostrating
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/saidmashhud/zist/services/listings/domain"
+)
+
+// Client calls GET /reviews/host/{hostId}/summary and caches results per
+// tenant and host for ttl.
+type Client struct {
+	baseURL string
+	ttl     time.Duration
+	http    *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cached
+}
+
+type cached struct {
+	summary domain.HostSummary
+	expires time.Time
+}
+
+// New creates a Client. Returns a no-op client if baseURL is empty.
+func New(baseURL string, ttl time.Duration) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		ttl:     ttl,
+		http:    &http.Client{Timeout: 2 * time.Second},
+		cache:   make(map[string]cached),
+	}
+}
+
+// Enabled reports whether a reviews service URL is configured.
+func (c *Client) Enabled() bool { return c.baseURL != "" }
+
+// Summary returns the host's rating summary within the tenant, or nil if the
+// client is disabled.
+func (c *Client) Summary(ctx context.Context, tenantID, hostID string) (*domain.HostSummary, error) {
+	if !c.Enabled() || hostID == "" {
+		return nil, nil
+	}
+	key := tenantID + "/" + hostID
+	now := time.Now()
+	c.mu.Lock()
+	if e, ok := c.cache[key]; ok && now.Before(e.expires) {
+		c.mu.Unlock()
+		return &e.summary, nil
+	}
+	c.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.baseURL+"/reviews/host/"+url.PathEscape(hostID)+"/summary", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Tenant-ID", tenantID)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("reviews service unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reviews service returned %d", resp.StatusCode)
+	}
+	var s domain.HostSummary
+	if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+		return nil, fmt.Errorf("decode host summary: %w", err)
+	}
+
+	c.mu.Lock()
+	for k, e := range c.cache {
+		if !now.Before(e.expires) {
+			delete(c.cache, k)
+		}
+	}
+	c.cache[key] = cached{summary: s, expires: now.Add(c.ttl)}
+	c.mu.Unlock()
+	return &s, nil
+}
@@ -7,9 +7,15 @@ import (
 	"net/http"
 	"os"
 	"time"
+	_ "time/tzdata" // the alpine image has no zoneinfo for listing timezones
 
 	_ "github.com/lib/pq"
+	httputil "github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/internal/money"
+	"github.com/saidmashhud/zist/services/listings/domain"
+	"github.com/saidmashhud/zist/services/listings/geocode"
 	"github.com/saidmashhud/zist/services/listings/handler"
+	"github.com/saidmashhud/zist/services/listings/media"
 	"github.com/saidmashhud/zist/services/listings/store"
 )
 
@@ -31,6 +37,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	proxies, err := httputil.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		slog.Error("invalid TRUSTED_PROXIES", "err", err)
+		os.Exit(1)
+	}
+
+	rounding, err := money.NewRounding(cfg.FeeRoundingMode, cfg.CurrencyMinorUnits)
+	if err != nil {
+		slog.Error("invalid FEE_ROUNDING_MODE or CURRENCY_MINOR_UNITS", "err", err)
+		os.Exit(1)
+	}
+
 	db, err := sql.Open("postgres", cfg.DatabaseURL)
 	if err != nil {
 		slog.Error("failed to open db", "err", err)
@@ -48,10 +66,48 @@ func main() {
 		os.Exit(1)
 	}
 
-	s := &server{
-		cfg: cfg,
-		h: handler.New(store.New(db), cfg.PlatformFeeGuestPct).
-			WithAnalytics(cfg.MgLogsURL, cfg.MashgateAPIKey),
+	h := handler.New(store.New(db), cfg.PlatformFeeGuestPct).
+		WithAnalytics(cfg.MgLogsURL, cfg.MashgateAPIKey).
+		WithSearchIndex(cfg.SearchURL, cfg.InternalToken).
+		WithListingEvents(cfg.MgEventsURL, cfg.MashgateAPIKey).
+		WithHostRatings(cfg.ReviewsURL, time.Duration(cfg.HostRatingCacheSecs)*time.Second).
+		WithTenantConfig(cfg.AdminURL, cfg.InternalToken).
+		WithAudit(cfg.AdminURL, cfg.InternalToken).
+		WithBookings(cfg.BookingsURL, cfg.InternalToken).
+		WithUsers(cfg.MgIDURL, cfg.MgIDAdminToken).
+		WithEmbedRateLimit(cfg.EmbedsPerMinute).
+		WithTrustedProxies(proxies).
+		WithRounding(rounding).
+		WithPublishRules(domain.PublishConfig{
+			MinPhotos:          cfg.PublishMinPhotos,
+			RequireDescription: cfg.PublishRequireDescription,
+			RequireAddress:     cfg.PublishRequireAddress,
+			AllowFree:          cfg.PublishAllowFree,
+		}.Rules()...)
+	if cfg.MediaDir != "" {
+		key := cfg.MediaSigningKey
+		if key == "" {
+			key = cfg.InternalToken
+		}
+		h.WithMedia(media.NewLocal(cfg.MediaDir, cfg.MediaBaseURL, key), cfg.PhotoUploadsPerHour)
+		slog.Info("local photo storage enabled", "dir", cfg.MediaDir)
+	}
+	if cfg.PhotoCheckEnabled {
+		h.WithPhotoCheck(media.NewChecker(cfg.PhotoMaxBytes, cfg.PhotoCheckOnFailure == "reject", 5*time.Second))
+		slog.Info("photo URL check enabled", "maxBytes", cfg.PhotoMaxBytes, "onFailure", cfg.PhotoCheckOnFailure)
+	}
+	switch cfg.Geocoder {
+	case "":
+	case "static":
+		h.WithGeocoder(geocode.NewStatic())
+		slog.Info("static geocoding enabled")
+	default:
+		slog.Warn("unknown GEOCODER, geocoding disabled", "geocoder", cfg.Geocoder)
+	}
+	s := &server{cfg: cfg, h: h}
+
+	if cfg.SnoozeSweepSeconds > 0 {
+		go h.RunSnoozeWorker(context.Background(), time.Duration(cfg.SnoozeSweepSeconds)*time.Second)
 	}
 
 	slog.Info("listings service starting", "port", cfg.Port)
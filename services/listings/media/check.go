@@ -0,0 +1,135 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrTooLarge means the remote photo's Content-Length exceeds the limit.
+	ErrTooLarge = errors.New("photo exceeds the maximum size")
+	// ErrNotImage means the remote photo is not served with an image/* type.
+	ErrNotImage = errors.New("photo is not an image")
+	// ErrUnreachable means the HEAD request failed and the checker rejects on failure.
+	ErrUnreachable = errors.New("photo URL could not be checked")
+	// ErrForbiddenURL means the photo URL is not http(s), or it or a redirect
+	// resolves to a private, loopback or link-local address. It is always
+	// rejected, whatever RejectOnError says.
+	ErrForbiddenURL = errors.New("photo URL must be a public http or https address")
+)
+
+// maxRedirects matches net/http's default redirect limit.
+const maxRedirects = 10
+
+// Checker validates a photo URL with a HEAD request before it is registered:
+// the response must declare an image/* Content-Type and, when it declares a
+// length, stay within MaxBytes.
+type Checker struct {
+	MaxBytes int64 // 0 disables the size limit
+	// RejectOnError rejects photos whose HEAD request fails (network error or
+	// non-2xx status); otherwise the check is skipped for them.
+	RejectOnError bool
+	client        *http.Client
+	// allowIP decides which addresses HEAD requests may connect to; tests
+	// widen it to reach httptest servers on loopback.
+	allowIP func(net.IP) bool
+}
+
+// NewChecker creates a Checker whose HEAD requests give up after timeout.
+// Requests only go to public addresses: the dialer checks every address
+// after DNS resolution, so neither a hostname nor a redirect can reach an
+// internal service.
+func NewChecker(maxBytes int64, rejectOnError bool, timeout time.Duration) *Checker {
+	c := &Checker{MaxBytes: maxBytes, RejectOnError: rejectOnError, allowIP: publicIP}
+	dialer := &net.Dialer{Timeout: timeout, Control: c.control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // a proxy would dial on our behalf, unchecked
+	transport.DialContext = dialer.DialContext
+	c.client = &http.Client{Timeout: timeout, Transport: transport, CheckRedirect: checkRedirect}
+	return c
+}
+
+// Check issues the HEAD request for photoURL. It returns nil when the photo is
+// acceptable or could not be checked and RejectOnError is off.
+func (c *Checker) Check(ctx context.Context, photoURL string) error {
+	u, err := url.Parse(photoURL)
+	if err != nil || !httpScheme(u) {
+		return ErrForbiddenURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u.String(), nil)
+	if err != nil {
+		return c.failed(err)
+	}
+	resp, err := c.client.Do(req)
+	if errors.Is(err, ErrForbiddenURL) {
+		return ErrForbiddenURL
+	}
+	if err != nil {
+		return c.failed(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return c.failed(fmt.Errorf("HEAD returned %d", resp.StatusCode))
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mediaType, "image/") {
+		return ErrNotImage
+	}
+	if c.MaxBytes > 0 && resp.ContentLength > c.MaxBytes {
+		return ErrTooLarge
+	}
+	return nil
+}
+
+// control runs after DNS resolution, just before each connection, so it
+// sees the address actually dialled, including for redirects.
+func (c *Checker) control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !c.allowIP(ip) {
+		return ErrForbiddenURL
+	}
+	return nil
+}
+
+// checkRedirect refuses redirects to anything but http(s); where they
+// resolve to is checked when they are dialled.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if !httpScheme(req.URL) {
+		return ErrForbiddenURL
+	}
+	return nil
+}
+
+func httpScheme(u *url.URL) bool {
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// publicIP reports whether ip is routable on the internet, i.e. not
+// private, loopback, link-local, multicast or unspecified.
+func publicIP(ip net.IP) bool {
+	return !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+func (c *Checker) failed(err error) error {
+	if c.RejectOnError {
+		return fmt.Errorf("%w: %v", ErrUnreachable, err)
+	}
+	return nil
+}
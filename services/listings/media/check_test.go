@@ -0,0 +1,119 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheckerHead(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("want HEAD, got %s", r.Method)
+		}
+		switch r.URL.Path {
+		case "/big.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Header().Set("Content-Length", "52428800") // 50 MB
+		case "/ok.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Header().Set("Content-Length", "204800")
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	c := NewChecker(10<<20, false, time.Second)
+	c.allowIP = func(net.IP) bool { return true }
+	if err := c.Check(ctx, srv.URL+"/big.jpg"); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("oversized: want ErrTooLarge, got %v", err)
+	}
+	if err := c.Check(ctx, srv.URL+"/ok.jpg"); err != nil {
+		t.Errorf("ok: want nil, got %v", err)
+	}
+	if err := c.Check(ctx, srv.URL+"/page.html"); !errors.Is(err, ErrNotImage) {
+		t.Errorf("html: want ErrNotImage, got %v", err)
+	}
+	if err := c.Check(ctx, srv.URL+"/missing.jpg"); err != nil {
+		t.Errorf("failure skipped: want nil, got %v", err)
+	}
+
+	strict := NewChecker(10<<20, true, time.Second)
+	strict.allowIP = func(net.IP) bool { return true }
+	if err := strict.Check(ctx, srv.URL+"/missing.jpg"); !errors.Is(err, ErrUnreachable) {
+		t.Errorf("failure rejected: want ErrUnreachable, got %v", err)
+	}
+}
+
+func TestCheckerRejectsInternalTargets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/to-file":
+			http.Redirect(w, r, "file:///etc/passwd", http.StatusFound)
+		case "/to-internal":
+			http.Redirect(w, r, "http://127.0.0.2:1/photo.jpg", http.StatusFound)
+		default:
+			w.Header().Set("Content-Type", "image/jpeg")
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	// Even with failures skipped, internal targets are rejected.
+	c := NewChecker(10<<20, false, time.Second)
+	for _, u := range []string{
+		srv.URL + "/ok.jpg",             // loopback
+		"http://169.254.169.254/latest", // link-local metadata endpoint
+		"http://10.0.0.1/photo.jpg",
+		"ftp://example.com/photo.jpg",
+		"file:///etc/passwd",
+		"/relative.jpg",
+	} {
+		if err := c.Check(ctx, u); !errors.Is(err, ErrForbiddenURL) {
+			t.Errorf("%s: want ErrForbiddenURL, got %v", u, err)
+		}
+	}
+
+	// Redirects are re-checked: the scheme before following, the address
+	// when it is dialled.
+	c.allowIP = func(ip net.IP) bool { return ip.Equal(net.IPv4(127, 0, 0, 1)) }
+	if err := c.Check(ctx, srv.URL+"/ok.jpg"); err != nil {
+		t.Errorf("allowed address: want nil, got %v", err)
+	}
+	for _, path := range []string{"/to-file", "/to-internal"} {
+		if err := c.Check(ctx, srv.URL+path); !errors.Is(err, ErrForbiddenURL) {
+			t.Errorf("redirect %s: want ErrForbiddenURL, got %v", path, err)
+		}
+	}
+}
+
+func TestPublicIP(t *testing.T) {
+	for _, tc := range []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+	} {
+		if got := publicIP(net.ParseIP(tc.ip)); got != tc.want {
+			t.Errorf("publicIP(%s) = %v, want %v", tc.ip, got, tc.want)
+		}
+	}
+}
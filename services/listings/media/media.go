@@ -0,0 +1,172 @@
+// Package media abstracts listing photo storage behind signed upload URLs.
+// Hosts obtain an upload URL, PUT the image there, and then register the
+// returned public URL as a photo. Production deployments plug in an object
+// store; Local serves development from the listings service itself.
+package media
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Upload is a pre-signed upload target.
+type Upload struct {
+	UploadURL string // PUT the object body here before ExpiresAt
+	PublicURL string // register this with AddPhoto once uploaded
+	ExpiresAt int64
+}
+
+// Storage issues pre-signed upload URLs for object keys.
+type Storage interface {
+	PresignUpload(ctx context.Context, key, contentType string, ttl time.Duration) (Upload, error)
+}
+
+// MaxUploadBytes caps a single uploaded object.
+const MaxUploadBytes = 10 << 20
+
+var (
+	// ErrInvalidSignature means an upload URL was tampered with or forged.
+	ErrInvalidSignature = errors.New("invalid upload signature")
+	// ErrExpired means an upload URL was used after its expiry.
+	ErrExpired = errors.New("upload URL expired")
+	// ErrInvalidKey means a key would escape the storage root.
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// Local stores objects under a directory and serves them over HTTP. Upload
+// URLs point back at its own handler, signed with HMAC-SHA256.
+type Local struct {
+	dir     string
+	baseURL string // public URL the handler is mounted at
+	secret  []byte
+}
+
+// NewLocal creates a Local storage rooted at dir, reachable at baseURL.
+func NewLocal(dir, baseURL, secret string) *Local {
+	return &Local{dir: dir, baseURL: strings.TrimRight(baseURL, "/"), secret: []byte(secret)}
+}
+
+// PresignUpload implements Storage.
+func (l *Local) PresignUpload(_ context.Context, key, contentType string, ttl time.Duration) (Upload, error) {
+	if _, err := l.path(key); err != nil {
+		return Upload{}, err
+	}
+	expires := time.Now().Add(ttl).Unix()
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(expires, 10))
+	q.Set("sig", l.sign(key, contentType, expires))
+	public := l.baseURL + "/" + key
+	return Upload{UploadURL: public + "?" + q.Encode(), PublicURL: public, ExpiresAt: expires}, nil
+}
+
+// Verify checks an upload URL's signature and expiry.
+func (l *Local) Verify(key, contentType string, expires int64, sig string) error {
+	want := l.sign(key, contentType, expires)
+	if !hmac.Equal([]byte(want), []byte(sig)) {
+		return ErrInvalidSignature
+	}
+	if time.Now().Unix() > expires {
+		return ErrExpired
+	}
+	return nil
+}
+
+// Save writes the object for key, replacing any existing one.
+func (l *Local) Save(key string, body io.Reader) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		os.Remove(p)
+		return err
+	}
+	return f.Close()
+}
+
+// ServeHTTP serves stored objects on GET and accepts signed uploads on PUT.
+// Mount it with the base URL's path stripped.
+func (l *Local) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		http.FileServer(filesOnly{http.Dir(l.dir)}).ServeHTTP(w, r)
+	case http.MethodPut:
+		expires, _ := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+		err := l.Verify(key, r.Header.Get("Content-Type"), expires, r.URL.Query().Get("sig"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if err := l.Save(key, http.MaxBytesReader(w, r.Body, MaxUploadBytes)); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "upload too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "upload failed", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// filesOnly hides directories from a FileSystem, so the file server answers
+// 404 instead of listing what has been uploaded.
+type filesOnly struct{ fs http.FileSystem }
+
+func (f filesOnly) Open(name string) (http.File, error) {
+	file, err := f.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, os.ErrNotExist
+	}
+	return file, nil
+}
+
+// sign binds the key, content type and expiry so none can be altered.
+func (l *Local) sign(key, contentType string, expires int64) string {
+	mac := hmac.New(sha256.New, l.secret)
+	fmt.Fprintf(mac, "%s\n%s\n%d", key, contentType, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path maps key to a file under dir, rejecting keys that escape it.
+func (l *Local) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if key == "" || clean != "/"+key {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(l.dir, clean), nil
+}
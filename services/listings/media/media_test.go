@@ -0,0 +1,71 @@
+package media
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalPresignAndVerify(t *testing.T) {
+	l := NewLocal(t.TempDir(), "http://media.test/listings/media/", "secret")
+	up, err := l.PresignUpload(context.Background(), "lst/photo.jpg", "image/jpeg", time.Minute)
+	if err != nil {
+		t.Fatalf("presign: %v", err)
+	}
+	if up.PublicURL != "http://media.test/listings/media/lst/photo.jpg" {
+		t.Errorf("public URL: got %s", up.PublicURL)
+	}
+	u, err := url.Parse(up.UploadURL)
+	if err != nil {
+		t.Fatalf("parse upload URL: %v", err)
+	}
+	expires, _ := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	sig := u.Query().Get("sig")
+
+	if err := l.Verify("lst/photo.jpg", "image/jpeg", expires, sig); err != nil {
+		t.Errorf("valid signature rejected: %v", err)
+	}
+	if err := l.Verify("lst/other.jpg", "image/jpeg", expires, sig); err != ErrInvalidSignature {
+		t.Errorf("different key: want ErrInvalidSignature, got %v", err)
+	}
+	if err := l.Verify("lst/photo.jpg", "image/png", expires, sig); err != ErrInvalidSignature {
+		t.Errorf("different content type: want ErrInvalidSignature, got %v", err)
+	}
+	past := time.Now().Add(-time.Minute).Unix()
+	if err := l.Verify("lst/photo.jpg", "image/jpeg", past, l.sign("lst/photo.jpg", "image/jpeg", past)); err != ErrExpired {
+		t.Errorf("expired URL: want ErrExpired, got %v", err)
+	}
+}
+
+func TestLocalRejectsEscapingKeys(t *testing.T) {
+	l := NewLocal(t.TempDir(), "http://media.test", "secret")
+	for _, key := range []string{"", "../etc/passwd", "a/../../b", "/abs"} {
+		if _, err := l.path(key); err != ErrInvalidKey {
+			t.Errorf("key %q: want ErrInvalidKey, got %v", key, err)
+		}
+	}
+}
+
+func TestLocalServesFilesNotDirectories(t *testing.T) {
+	l := NewLocal(t.TempDir(), "http://media.test", "secret")
+	if err := l.Save("lst/photo.jpg", strings.NewReader("jpeg")); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	for path, want := range map[string]int{
+		"/lst/photo.jpg": http.StatusOK,
+		"/lst/":          http.StatusNotFound,
+		"/lst":           http.StatusNotFound,
+		"/":              http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		l.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("GET %s: want %d, got %d", path, want, rec.Code)
+		}
+	}
+}
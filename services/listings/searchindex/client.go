@@ -0,0 +1,74 @@
+// Package searchindex pushes listing documents to the search service's
+// search_listings projection.
+package searchindex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/saidmashhud/zist/services/listings/domain"
+)
+
+// Client calls the search service's internal index endpoints.
+type Client struct {
+	baseURL       string
+	internalToken string
+	http          *http.Client
+}
+
+// New creates a Client. Returns a no-op client if baseURL is empty.
+func New(baseURL, internalToken string) *Client {
+	return &Client{
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		internalToken: internalToken,
+		http:          &http.Client{Timeout: 3 * time.Second},
+	}
+}
+
+// Enabled reports whether a search service URL is configured.
+func (c *Client) Enabled() bool { return c.baseURL != "" }
+
+// Upsert sends doc to POST /internal/search/index.
+func (c *Client) Upsert(ctx context.Context, doc domain.SearchDocument) error {
+	if !c.Enabled() {
+		return nil
+	}
+	body, _ := json.Marshal(doc)
+	return c.do(ctx, http.MethodPost, "/internal/search/index", body)
+}
+
+// Remove sends DELETE /internal/search/index/{id}, versioned by deletedAt
+// (unix seconds).
+func (c *Client) Remove(ctx context.Context, listingID string, deletedAt int64) error {
+	if !c.Enabled() {
+		return nil
+	}
+	return c.do(ctx, http.MethodDelete,
+		"/internal/search/index/"+url.PathEscape(listingID)+"?deletedAt="+strconv.FormatInt(deletedAt, 10), nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Internal-Token", c.internalToken)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("search service unavailable: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("search service returned %d", resp.StatusCode)
+	}
+	return nil
+}
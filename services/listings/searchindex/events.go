@@ -0,0 +1,94 @@
+package searchindex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saidmashhud/zist/services/listings/domain"
+)
+
+// Listing lifecycle events published to the Mashgate events plane. The
+// search service subscribes to them to maintain its projection.
+const (
+	EventListingUpdated     = "zist.listing.updated"
+	EventListingPublished   = "zist.listing.published"
+	EventListingUnpublished = "zist.listing.unpublished"
+	EventListingDeleted     = "zist.listing.deleted"
+)
+
+// Events publishes listing events to mgEvents, which fans them out to
+// subscribers such as the search indexer.
+type Events struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+// NewEvents creates an Events publisher. Returns a no-op publisher if
+// baseURL is empty.
+func NewEvents(baseURL, apiKey string) *Events {
+	return &Events{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		http:    &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Enabled reports whether an events plane URL is configured.
+func (e *Events) Enabled() bool { return e.baseURL != "" }
+
+// PublishDocument emits eventType carrying the full indexable document, so
+// subscribers never need to read the listings database.
+func (e *Events) PublishDocument(ctx context.Context, eventType string, doc domain.SearchDocument) error {
+	return e.publish(ctx, doc.TenantID, eventType, doc)
+}
+
+// PublishDeleted emits zist.listing.deleted for a listing. deletedAt (unix
+// seconds) lets the indexer drop documents from before the deletion that
+// arrive after it.
+func (e *Events) PublishDeleted(ctx context.Context, tenantID, listingID string, deletedAt int64) error {
+	return e.publish(ctx, tenantID, EventListingDeleted, map[string]any{
+		"id": listingID, "tenantId": tenantID, "deletedAt": deletedAt,
+	})
+}
+
+func (e *Events) publish(ctx context.Context, tenantID, eventType string, payload any) error {
+	if !e.Enabled() {
+		return nil
+	}
+	eventID := uuid.NewString()
+	body, err := json.Marshal(map[string]any{
+		"event_id":   eventID,
+		"event_type": eventType,
+		"tenant_id":  tenantID,
+		"payload":    payload,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/v1/events", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	req.Header.Set("Idempotency-Key", eventID)
+
+	resp, err := e.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("events plane unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("events plane returned %d: %s", resp.StatusCode, b)
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	zistauth "github.com/saidmashhud/zist/internal/auth"
 	"github.com/saidmashhud/zist/services/listings/handler"
+	"github.com/saidmashhud/zist/services/listings/media"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
@@ -32,36 +33,69 @@ func (s *server) routes() http.Handler {
 
 	hostWrite := chi.Chain(zistauth.RequireAuth, zistauth.RequireScope("zist.listings.manage"))
 	internal := chi.Chain(zistauth.RequireServiceAuth(s.cfg.InternalToken, nil))
+	admin := chi.Chain(zistauth.RequireAuth, zistauth.RequireScope("zist.admin"))
 
 	r.Route("/listings", func(r chi.Router) {
 		// Public
 		r.Get("/search", s.h.SearchListings)
 		r.With(zistauth.RequireAuth).Get("/mine", s.h.ListMyListings)
 		r.Get("/", s.h.ListListings)
+		r.Get("/compare", s.h.CompareListings)
+		r.Get("/types", s.h.ListingTypes)
 		r.Get("/{id}", s.h.GetListing)
 		r.Get("/{id}/calendar", s.h.GetCalendar)
 		r.Get("/{id}/price-preview", s.h.PricePreview)
 		r.Get("/{id}/photos", s.h.ListPhotos)
+		r.Get("/{id}/embed", s.h.EmbedListing)
+		r.Options("/{id}/embed", s.h.EmbedListing)
+		// Dev photo storage: signed PUT uploads and public GETs.
+		if local, ok := s.h.Media.(*media.Local); ok {
+			r.Handle("/media/*", http.StripPrefix("/listings/media", local))
+		}
 		r.Get("/{id}/availability/check", s.h.CheckAvailability)
+		r.Get("/{id}/availability/ranges", s.h.GetAvailabilityRanges)
 
 		// Host-only
 		r.With(hostWrite...).Post("/", s.h.CreateListing)
+		r.With(hostWrite...).Post("/mine/status", s.h.SetMyListingsStatus)
 		r.With(hostWrite...).Put("/{id}", s.h.UpdateListing)
 		r.With(hostWrite...).Patch("/{id}", s.h.UpdateListing)
 		r.With(hostWrite...).Delete("/{id}", s.h.DeleteListing)
 		r.With(hostWrite...).Post("/{id}/publish", s.h.PublishListing)
 		r.With(hostWrite...).Post("/{id}/unpublish", s.h.UnpublishListing)
+		r.With(hostWrite...).Post("/{id}/archive", s.h.ArchiveListing)
+		r.With(hostWrite...).Post("/{id}/unarchive", s.h.UnarchiveListing)
+		r.With(hostWrite...).Post("/{id}/snooze", s.h.SnoozeListing)
+		r.With(hostWrite...).Post("/{id}/unsnooze", s.h.UnsnoozeListing)
+		r.With(hostWrite...).Get("/{id}/versions", s.h.ListVersions)
+		r.With(hostWrite...).Post("/{id}/versions/{vid}/restore", s.h.RestoreVersion)
+		r.With(zistauth.RequireAuth).Get("/{id}/views", s.h.ListingViews)
 		r.With(hostWrite...).Post("/{id}/photos", s.h.AddPhoto)
+		r.With(hostWrite...).Post("/{id}/photos/upload-url", s.h.PhotoUploadURL)
 		r.With(hostWrite...).Patch("/{id}/photos/reorder", s.h.ReorderPhotos)
 		r.With(hostWrite...).Delete("/{id}/photos/{photoId}", s.h.DeletePhoto)
 		r.With(hostWrite...).Post("/{id}/availability/block", s.h.BlockDates)
 		r.With(hostWrite...).Delete("/{id}/availability/block", s.h.UnblockDates)
 		r.With(hostWrite...).Patch("/{id}/availability/price", s.h.SetPriceOverride)
+		r.With(hostWrite...).Get("/{id}/rate-plans", s.h.ListRatePlans)
+		r.With(hostWrite...).Post("/{id}/rate-plans", s.h.CreateRatePlan)
+		r.With(hostWrite...).Delete("/{id}/rate-plans/{planId}", s.h.DeleteRatePlan)
 
 		// Internal (called by bookings service)
+		r.With(internal...).Get("/batch", s.h.BatchListings)
+		r.With(internal...).Get("/capacity", s.h.HostCapacity)
+		r.With(internal...).Post("/validate", s.h.ValidateStays)
+		r.With(internal...).Get("/reservations", s.h.ListReservations)
+		r.With(internal...).Get("/policy-usage", s.h.PolicyUsage)
+		r.With(internal...).Get("/{id}/quote", s.h.Quote)
+		r.With(internal...).Get("/{id}/photos/count", s.h.PhotoCount)
 		r.With(internal...).Post("/{id}/availability/book", s.h.MarkDatesBooked)
 		r.With(internal...).Delete("/{id}/availability/book", s.h.UnmarkDatesBooked)
 
+		// Admin
+		r.With(admin...).Post("/reindex", s.h.ReindexListings)
+		r.With(admin...).Post("/{id}/transfer", s.h.TransferListing)
+
 		// Internal (called by reviews service)
 		r.With(internal...).Put("/{id}/rating", s.h.UpdateRating)
 	})
@@ -37,9 +37,16 @@ func Migrate(db *sql.DB) error {
 		`ALTER TABLE listings ADD COLUMN IF NOT EXISTS max_nights         INT     NOT NULL DEFAULT 365`,
 		`ALTER TABLE listings ADD COLUMN IF NOT EXISTS cancellation_policy TEXT   NOT NULL DEFAULT 'moderate'`,
 		`ALTER TABLE listings ADD COLUMN IF NOT EXISTS instant_book       BOOLEAN NOT NULL DEFAULT false`,
+		`ALTER TABLE listings ADD COLUMN IF NOT EXISTS payment_window_minutes INT NOT NULL DEFAULT 0`,
 		`ALTER TABLE listings ADD COLUMN IF NOT EXISTS status             TEXT    NOT NULL DEFAULT 'active'`,
 		`ALTER TABLE listings ADD COLUMN IF NOT EXISTS average_rating     NUMERIC(3,2) NOT NULL DEFAULT 0`,
 		`ALTER TABLE listings ADD COLUMN IF NOT EXISTS review_count       INT     NOT NULL DEFAULT 0`,
+		`ALTER TABLE listings ADD COLUMN IF NOT EXISTS snooze_until       DATE`,
+		`ALTER TABLE listings ADD COLUMN IF NOT EXISTS lat                DOUBLE PRECISION`,
+		`ALTER TABLE listings ADD COLUMN IF NOT EXISTS lng                DOUBLE PRECISION`,
+		`ALTER TABLE listings ADD COLUMN IF NOT EXISTS location_source    TEXT    NOT NULL DEFAULT ''`,
+		`ALTER TABLE listings ADD COLUMN IF NOT EXISTS pay_on_arrival     BOOLEAN NOT NULL DEFAULT false`,
+		`ALTER TABLE listings ADD COLUMN IF NOT EXISTS timezone           TEXT    NOT NULL DEFAULT ''`,
 	}
 	for _, stmt := range newCols {
 		if _, err := db.Exec(stmt); err != nil {
@@ -47,9 +54,27 @@ func Migrate(db *sql.DB) error {
 		}
 	}
 
+	// Before the search projection, the search service kept points in a
+	// listings.location column (PUT /search/locations). Carry them into
+	// lat/lng so search documents, including reindexes, keep them.
+	if _, err := db.Exec(`DO $$
+		BEGIN
+			IF EXISTS (SELECT 1 FROM information_schema.columns
+			           WHERE table_name = 'listings' AND column_name = 'location') THEN
+				EXECUTE 'UPDATE listings SET lat = ST_Y(location), lng = ST_X(location),
+				                location_source = ''provided''
+				         WHERE lat IS NULL AND location IS NOT NULL';
+			END IF;
+		END $$`); err != nil {
+		return err
+	}
+
 	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_listings_tenant_status_city ON listings(tenant_id, status, city, created_at DESC)`); err != nil {
 		return err
 	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_listings_snooze_until ON listings(snooze_until) WHERE snooze_until IS NOT NULL`); err != nil {
+		return err
+	}
 
 	if _, err := db.Exec(`
 		CREATE TABLE IF NOT EXISTS listing_photos (
@@ -82,5 +107,58 @@ func Migrate(db *sql.DB) error {
 		return err
 	}
 
+	// Seasonal multipliers on the base price; nights are [start_date, end_date).
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS listing_rate_plans (
+			id         TEXT    PRIMARY KEY,
+			tenant_id  TEXT    NOT NULL,
+			listing_id TEXT    NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+			name       TEXT    NOT NULL DEFAULT '',
+			start_date DATE    NOT NULL,
+			end_date   DATE    NOT NULL,
+			multiplier NUMERIC NOT NULL,
+			created_at BIGINT  NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_listing_rate_plans_listing
+			ON listing_rate_plans(listing_id, start_date);
+		-- Multipliers were floats; nightly prices are now computed in decimal.
+		ALTER TABLE listing_rate_plans
+			ALTER COLUMN multiplier TYPE NUMERIC USING multiplier::numeric;
+	`); err != nil {
+		return err
+	}
+
+	// Bounded edit history; created_at is unix nanoseconds so versions saved
+	// within the same second still sort correctly.
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS listing_versions (
+			id         TEXT   PRIMARY KEY,
+			listing_id TEXT   NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+			editor_id  TEXT   NOT NULL DEFAULT '',
+			snapshot   JSONB  NOT NULL,
+			created_at BIGINT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_listing_versions_listing
+			ON listing_versions(listing_id, created_at DESC);
+	`); err != nil {
+		return err
+	}
+
+	// Raw view events; viewer_hash never holds a raw user ID or IP.
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS listing_views (
+			id          BIGSERIAL PRIMARY KEY,
+			tenant_id   TEXT    NOT NULL DEFAULT '',
+			listing_id  TEXT    NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+			viewer_hash TEXT    NOT NULL,
+			is_unique   BOOLEAN NOT NULL,
+			viewed_at   BIGINT  NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_listing_views_viewer
+			ON listing_views(listing_id, viewer_hash, viewed_at DESC);
+	`); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/saidmashhud/zist/services/listings/domain"
 )
 
@@ -28,26 +29,28 @@ func New(db *sql.DB) *Store { return &Store{db: db} }
 // ─── SELECT helper ────────────────────────────────────────────────────────────
 
 const listingColumns = `
-	id, title, description, city, country, address,
+	id, title, description, city, country, address, lat, lng, location_source,
 	type, bedrooms, beds, bathrooms, max_guests,
 	amenities, rules,
 	price_per_night, currency, cleaning_fee, deposit,
 	min_nights, max_nights,
-	cancellation_policy, instant_book,
+	cancellation_policy, instant_book, payment_window_minutes, pay_on_arrival, timezone,
 	status, average_rating, review_count,
+	COALESCE(TO_CHAR(snooze_until, 'YYYY-MM-DD'), ''),
 	host_id, created_at, updated_at`
 
 func scanListing(scan func(dest ...any) error) (domain.Listing, error) {
 	var l domain.Listing
 	var amenitiesRaw, rulesRaw []byte
 	err := scan(
-		&l.ID, &l.Title, &l.Description, &l.City, &l.Country, &l.Address,
+		&l.ID, &l.Title, &l.Description, &l.City, &l.Country, &l.Address, &l.Lat, &l.Lng, &l.LocationSource,
 		&l.Type, &l.Bedrooms, &l.Beds, &l.Bathrooms, &l.MaxGuests,
 		&amenitiesRaw, &rulesRaw,
 		&l.PricePerNight, &l.Currency, &l.CleaningFee, &l.Deposit,
 		&l.MinNights, &l.MaxNights,
-		&l.CancellationPolicy, &l.InstantBook,
+		&l.CancellationPolicy, &l.InstantBook, &l.PaymentWindowMinutes, &l.PayOnArrival, &l.Timezone,
 		&l.Status, &l.AverageRating, &l.ReviewCount,
+		&l.SnoozeUntil,
 		&l.HostID, &l.CreatedAt, &l.UpdatedAt,
 	)
 	if err != nil {
@@ -87,26 +90,65 @@ func (s *Store) GetForTenant(ctx context.Context, tenantID, id string) (domain.L
 	return l, err
 }
 
-// List returns active listings with optional city/status filter.
-func (s *Store) List(ctx context.Context, statusFilter, city string, limit int) ([]domain.Listing, error) {
+// GetMany returns the listings among ids in one query, across tenants.
+// Missing ids are skipped; order is unspecified.
+func (s *Store) GetMany(ctx context.Context, ids []string) ([]domain.Listing, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+listingColumns+` FROM listings WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return collectListings(rows)
+}
+
+// GetManyForTenant returns the tenant's listings among ids in one query.
+// Missing ids are skipped; order is unspecified.
+func (s *Store) GetManyForTenant(ctx context.Context, tenantID string, ids []string) ([]domain.Listing, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+listingColumns+` FROM listings WHERE tenant_id = $1 AND id = ANY($2)`,
+		tenantID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return collectListings(rows)
+}
+
+// listFilterWhere is shared by List's count and page queries.
+const listFilterWhere = `
+	WHERE ($1 = '' OR status = $1)
+	  AND status <> 'archived'
+	  AND ($2 = '' OR LOWER(city) = LOWER($2))`
+
+// List returns a page of active listings with optional city/status filter,
+// newest first, along with the total number of matches. Archived listings
+// are never listed, even when asked for by status.
+func (s *Store) List(ctx context.Context, statusFilter, city string, limit, offset int) ([]domain.Listing, int, error) {
 	if statusFilter == "" {
 		statusFilter = "active"
 	}
 	if limit <= 0 || limit > 100 {
 		limit = 50
 	}
+	if offset < 0 {
+		offset = 0
+	}
+	var total int
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM listings `+listFilterWhere, statusFilter, city).Scan(&total); err != nil {
+		return nil, 0, err
+	}
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT `+listingColumns+`
-		 FROM listings
-		 WHERE ($1 = '' OR status = $1)
-		   AND ($2 = '' OR LOWER(city) = LOWER($2))
-		 ORDER BY created_at DESC LIMIT $3`,
-		statusFilter, city, limit)
+		`SELECT `+listingColumns+` FROM listings `+listFilterWhere+`
+		 ORDER BY created_at DESC, id DESC LIMIT $3 OFFSET $4`,
+		statusFilter, city, limit, offset)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
-	return collectListings(rows)
+	listings, err := collectListings(rows)
+	return listings, total, err
 }
 
 // ListByHost returns all listings owned by hostID within tenant scope.
@@ -150,6 +192,9 @@ func (s *Store) Search(ctx context.Context, f domain.SearchFilters) ([]domain.Li
 	if f.InstantBookOnly {
 		conditions = append(conditions, "l.instant_book = true")
 	}
+	if f.MinRating > 0 {
+		conditions = append(conditions, "l.average_rating >= "+argN(f.MinRating))
+	}
 	for _, amenity := range f.Amenities {
 		amenity = strings.TrimSpace(amenity)
 		if amenity != "" {
@@ -203,24 +248,24 @@ func (s *Store) Create(ctx context.Context, in domain.CreateListingInput) (domai
 			amenities, rules,
 			price_per_night, currency, cleaning_fee, deposit,
 			min_nights, max_nights,
-			cancellation_policy, instant_book,
-			status, host_id, created_at, updated_at
+			cancellation_policy, instant_book, payment_window_minutes,
+			status, host_id, created_at, updated_at, lat, lng, location_source, timezone, pay_on_arrival
 		) VALUES (
 			$1,$2,$3,$4,$5,$6,$7,
 			$8,$9,$10,$11,$12,
 			$13,$14,
 			$15,$16,$17,$18,
 			$19,$20,
-			$21,$22,
-			'draft',$23,$24,$25
+			$21,$22,$23,
+			'draft',$24,$25,$26,$27,$28,$29,$30,$31
 		)`,
 		in.TenantID, id, in.Title, in.Description, in.City, in.Country, in.Address,
 		in.Type, in.Bedrooms, in.Beds, in.Bathrooms, in.MaxGuests,
 		amenitiesJSON, rulesJSON,
 		in.PricePerNight, in.Currency, in.CleaningFee, in.Deposit,
 		in.MinNights, in.MaxNights,
-		in.CancellationPolicy, in.InstantBook,
-		in.HostID, now, now,
+		in.CancellationPolicy, in.InstantBook, in.PaymentWindowMinutes,
+		in.HostID, now, now, in.Lat, in.Lng, in.LocationSource, in.Timezone, in.PayOnArrival,
 	)
 	if err != nil {
 		return domain.Listing{}, err
@@ -246,6 +291,13 @@ func (s *Store) Update(ctx context.Context, id string, in domain.UpdateListingIn
 	if in.Address != nil {
 		add("address", *in.Address)
 	}
+	if in.Lat != nil && in.Lng != nil {
+		add("lat", *in.Lat)
+		add("lng", *in.Lng)
+	}
+	if in.LocationSource != nil {
+		add("location_source", *in.LocationSource)
+	}
 	if in.Type != nil {
 		add("type", *in.Type)
 	}
@@ -293,8 +345,18 @@ func (s *Store) Update(ctx context.Context, id string, in domain.UpdateListingIn
 	if in.InstantBook != nil {
 		add("instant_book", *in.InstantBook)
 	}
+	if in.PaymentWindowMinutes != nil {
+		add("payment_window_minutes", *in.PaymentWindowMinutes)
+	}
+	if in.PayOnArrival != nil {
+		add("pay_on_arrival", *in.PayOnArrival)
+	}
+	if in.Timezone != nil {
+		add("timezone", *in.Timezone)
+	}
 	if in.Status != nil {
 		add("status", *in.Status)
+		setClauses = append(setClauses, "snooze_until = NULL") // see SetStatus
 	}
 
 	args = append(args, id)
@@ -310,14 +372,84 @@ func (s *Store) Update(ctx context.Context, id string, in domain.UpdateListingIn
 	return s.Get(ctx, id)
 }
 
-// SetStatus updates only the listing status (publish/unpublish/pause).
+// SetStatus updates only the listing status (publish/unpublish/pause). A
+// manual status change supersedes any pending snooze, so it is cleared.
 func (s *Store) SetStatus(ctx context.Context, id, status string) error {
 	_, err := s.db.ExecContext(ctx,
-		`UPDATE listings SET status = $1, updated_at = $2 WHERE id = $3`,
+		`UPDATE listings SET status = $1, snooze_until = NULL, updated_at = $2 WHERE id = $3`,
 		status, time.Now().Unix(), id)
 	return err
 }
 
+// SetHostStatus moves every listing of the host in from to to, all in one
+// statement, and returns the IDs it changed. Like SetStatus it clears any
+// pending snooze.
+func (s *Store) SetHostStatus(ctx context.Context, tenantID, hostID, from, to string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`UPDATE listings SET status = $1, snooze_until = NULL, updated_at = $2
+		 WHERE tenant_id = $3 AND host_id = $4 AND status = $5
+		 RETURNING id`,
+		to, time.Now().Unix(), tenantID, hostID, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Snooze pauses a listing until the given date (YYYY-MM-DD).
+func (s *Store) Snooze(ctx context.Context, id, until string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE listings SET status = 'paused', snooze_until = $1, updated_at = $2 WHERE id = $3`,
+		until, time.Now().Unix(), id)
+	return err
+}
+
+// Unsnooze cancels a pending snooze; the listing stays paused. Returns false
+// if the listing had no snooze scheduled.
+func (s *Store) Unsnooze(ctx context.Context, id string) (bool, error) {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE listings SET snooze_until = NULL, updated_at = $1
+		 WHERE id = $2 AND snooze_until IS NOT NULL`,
+		time.Now().Unix(), id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// WakeSnoozed republishes paused listings snoozed until cutoff (YYYY-MM-DD)
+// or earlier, clearing their snooze, and returns their IDs.
+func (s *Store) WakeSnoozed(ctx context.Context, cutoff string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`UPDATE listings SET status = 'active', snooze_until = NULL, updated_at = $1
+		 WHERE snooze_until <= $2::date AND status = 'paused'
+		 RETURNING id`,
+		time.Now().Unix(), cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 // Delete removes a listing. Returns ErrNotFound if it doesn't exist.
 func (s *Store) Delete(ctx context.Context, id string) error {
 	result, err := s.db.ExecContext(ctx, `DELETE FROM listings WHERE id = $1`, id)
@@ -350,6 +482,31 @@ func (s *Store) GetHostIDForTenant(ctx context.Context, tenantID, id string) (st
 	return hostID, err
 }
 
+// GetTenantID returns the tenant listing id belongs to.
+func (s *Store) GetTenantID(ctx context.Context, id string) (string, error) {
+	var tenantID string
+	err := s.db.QueryRowContext(ctx, `SELECT tenant_id FROM listings WHERE id = $1`, id).Scan(&tenantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	return tenantID, err
+}
+
+// SetHost hands a listing in tenant to hostID. Returns ErrNotFound if the
+// listing doesn't exist there.
+func (s *Store) SetHost(ctx context.Context, tenantID, id, hostID string) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE listings SET host_id = $1, updated_at = $2 WHERE tenant_id = $3 AND id = $4`,
+		hostID, time.Now().Unix(), tenantID, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 // UpdateRating sets average_rating and review_count for a listing.
 // Called by the reviews service after a new review is submitted.
 func (s *Store) UpdateRating(ctx context.Context, listingID string, avg float64, count int) error {
@@ -359,12 +516,65 @@ func (s *Store) UpdateRating(ctx context.Context, listingID string, avg float64,
 	return err
 }
 
+// SearchDocument builds the search projection document for a listing,
+// including its tenant and cover photo URL.
+func (s *Store) SearchDocument(ctx context.Context, id string) (domain.SearchDocument, error) {
+	var d domain.SearchDocument
+	var amenitiesRaw []byte
+	err := s.db.QueryRowContext(ctx, `
+		SELECT l.id, l.tenant_id, l.host_id, l.title, l.city, l.country, l.type,
+		       l.price_per_night, l.currency, l.max_guests, l.instant_book,
+		       l.average_rating, l.review_count, l.amenities, l.status,
+		       l.lat, l.lng, l.timezone, l.created_at, l.updated_at,
+		       COALESCE((SELECT p.url FROM listing_photos p WHERE p.listing_id = l.id
+		                 ORDER BY p.sort_order ASC LIMIT 1), '')
+		FROM listings l WHERE l.id = $1`, id).Scan(
+		&d.ID, &d.TenantID, &d.HostID, &d.Title, &d.City, &d.Country, &d.Type,
+		&d.PricePerNight, &d.Currency, &d.MaxGuests, &d.InstantBook,
+		&d.AverageRating, &d.ReviewCount, &amenitiesRaw, &d.Status,
+		&d.Lat, &d.Lng, &d.Timezone, &d.CreatedAt, &d.UpdatedAt, &d.CoverPhoto,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return d, ErrNotFound
+	}
+	if err != nil {
+		return d, err
+	}
+	if len(amenitiesRaw) > 0 {
+		json.Unmarshal(amenitiesRaw, &d.Amenities) //nolint:errcheck
+	}
+	if d.Amenities == nil {
+		d.Amenities = []string{}
+	}
+	return d, nil
+}
+
+// ListIDsForTenant returns the ids of every listing in tenant, in any status.
+func (s *Store) ListIDsForTenant(ctx context.Context, tenantID string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id FROM listings WHERE tenant_id = $1 ORDER BY created_at`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 // GetPricingInfo returns price-relevant fields for price preview calculation.
-func (s *Store) GetPricingInfo(ctx context.Context, id string) (pricePerNight, cleaningFee, currency string, minNights, maxNights int, err error) {
+func (s *Store) GetPricingInfo(ctx context.Context, id string) (pricePerNight, cleaningFee, deposit, currency string, minNights, maxNights int, err error) {
 	err = s.db.QueryRowContext(ctx,
-		`SELECT price_per_night, cleaning_fee, currency, min_nights, max_nights
+		`SELECT price_per_night, cleaning_fee, deposit, currency, min_nights, max_nights
 		 FROM listings WHERE id = $1`, id).
-		Scan(&pricePerNight, &cleaningFee, &currency, &minNights, &maxNights)
+		Scan(&pricePerNight, &cleaningFee, &deposit, &currency, &minNights, &maxNights)
 	if errors.Is(err, sql.ErrNoRows) {
 		err = ErrNotFound
 	}
@@ -413,6 +623,28 @@ func (s *Store) GetCoverPhoto(ctx context.Context, listingID string) *domain.Pho
 	return &p
 }
 
+// GetCoverPhotos returns the first photo of each listing in ids, keyed by
+// listing ID. Listings without photos are absent from the map.
+func (s *Store) GetCoverPhotos(ctx context.Context, ids []string) (map[string]domain.Photo, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT DISTINCT ON (listing_id) id, listing_id, url, caption, sort_order, created_at
+		 FROM listing_photos WHERE listing_id = ANY($1)
+		 ORDER BY listing_id, sort_order ASC`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	covers := make(map[string]domain.Photo)
+	for rows.Next() {
+		var p domain.Photo
+		if err := rows.Scan(&p.ID, &p.ListingID, &p.URL, &p.Caption, &p.SortOrder, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		covers[p.ListingID] = p
+	}
+	return covers, rows.Err()
+}
+
 // AddPhoto inserts a new photo and returns it.
 func (s *Store) AddPhoto(ctx context.Context, listingID, url, caption string, sortOrder int) (domain.Photo, error) {
 	id := uuid.NewString()
@@ -460,8 +692,85 @@ func (s *Store) DeletePhoto(ctx context.Context, listingID, photoID string) erro
 	return nil
 }
 
+// PolicyUsage counts the tenant's listings by cancellation policy.
+func (s *Store) PolicyUsage(ctx context.Context, tenantID string) (map[string]int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT cancellation_policy, COUNT(*)
+		FROM listings
+		WHERE tenant_id = $1
+		GROUP BY cancellation_policy`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	usage := map[string]int{}
+	for rows.Next() {
+		var name string
+		var n int
+		if err := rows.Scan(&name, &n); err != nil {
+			return nil, err
+		}
+		usage[name] = n
+	}
+	return usage, rows.Err()
+}
+
 // ─── Availability ─────────────────────────────────────────────────────────────
 
+// HostCapacity returns, for each of a host's listings, the number of dates in
+// [from, to) the host has blocked. Used by bookings to derive occupancy.
+func (s *Store) HostCapacity(ctx context.Context, tenantID, hostID, from, to string) ([]domain.ListingCapacity, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT l.id, l.title, COUNT(av.id)
+		FROM listings l
+		LEFT JOIN listing_availability av
+		  ON av.listing_id = l.id AND av.status = 'blocked'
+		 AND av.date >= $3::date AND av.date < $4::date
+		WHERE l.tenant_id = $1 AND l.host_id = $2
+		GROUP BY l.id, l.title
+		ORDER BY l.title, l.id`,
+		tenantID, hostID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []domain.ListingCapacity{}
+	for rows.Next() {
+		var c domain.ListingCapacity
+		if err := rows.Scan(&c.ListingID, &c.Title, &c.BlockedNights); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// ListReservations returns the tenant's booked dates on or after from,
+// grouped by listing and booking, for reconciling them against bookings.
+func (s *Store) ListReservations(ctx context.Context, tenantID, from string) ([]domain.Reservation, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT av.listing_id, COALESCE(av.booking_id, ''), array_agg(av.date::text ORDER BY av.date)
+		FROM listing_availability av
+		JOIN listings l ON l.id = av.listing_id
+		WHERE l.tenant_id = $1 AND av.status = 'booked' AND av.date >= $2::date
+		GROUP BY av.listing_id, COALESCE(av.booking_id, '')
+		ORDER BY av.listing_id, 2`,
+		tenantID, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []domain.Reservation{}
+	for rows.Next() {
+		var res domain.Reservation
+		if err := rows.Scan(&res.ListingID, &res.BookingID, pq.Array(&res.Dates)); err != nil {
+			return nil, err
+		}
+		out = append(out, res)
+	}
+	return out, rows.Err()
+}
+
 // GetCalendar returns all availability days in the given month YYYY-MM,
 // filling missing days with {status: "available"}.
 func (s *Store) GetCalendar(ctx context.Context, listingID, month string) ([]domain.AvailabilityDay, error) {
@@ -523,6 +832,31 @@ func (s *Store) CheckAvailability(ctx context.Context, listingID, checkIn, check
 	return conflicts, nil
 }
 
+// UnavailableDays returns the listing's blocked and booked days in
+// [from, to), in date order.
+func (s *Store) UnavailableDays(ctx context.Context, listingID, from, to string) ([]domain.AvailabilityDay, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT date::text, status, COALESCE(booking_id,'')
+		 FROM listing_availability
+		 WHERE listing_id = $1 AND date >= $2::date AND date < $3::date
+		   AND status IN ('blocked', 'booked')
+		 ORDER BY date`,
+		listingID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var days []domain.AvailabilityDay
+	for rows.Next() {
+		var d domain.AvailabilityDay
+		if err := rows.Scan(&d.Date, &d.Status, &d.BookingID); err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
+
 // BlockDates marks the given dates as 'blocked'.
 func (s *Store) BlockDates(ctx context.Context, listingID string, dates []string) error {
 	tx, err := s.db.BeginTx(ctx, nil)
@@ -583,6 +917,9 @@ func (s *Store) SetPriceOverride(ctx context.Context, listingID string, entries
 
 // MarkDatesBooked reserves dates for bookingID.
 // Returns a non-empty conflict slice if any dates are already blocked/booked.
+// Reservations on the same listing are serialized with a transaction-scoped
+// advisory lock, so the conflict check and the insert can't interleave with a
+// concurrent reservation under READ COMMITTED.
 func (s *Store) MarkDatesBooked(ctx context.Context, tenantID, listingID, bookingID string, dates []string) ([]string, error) {
 	var exists bool
 	if err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM listings WHERE tenant_id = $1 AND id = $2)`, tenantID, listingID).Scan(&exists); err != nil {
@@ -598,6 +935,10 @@ func (s *Store) MarkDatesBooked(ctx context.Context, tenantID, listingID, bookin
 	}
 	defer tx.Rollback() //nolint:errcheck
 
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "listing_availability:"+listingID); err != nil {
+		return nil, err
+	}
+
 	conflictRows, err := tx.QueryContext(ctx,
 		`SELECT date::text FROM listing_availability
 		 WHERE listing_id = $1 AND date = ANY($2::date[]) AND status IN ('blocked','booked')`,
@@ -631,44 +972,237 @@ func (s *Store) MarkDatesBooked(ctx context.Context, tenantID, listingID, bookin
 	return nil, tx.Commit()
 }
 
-// UnmarkDatesBooked releases dates that were booked for bookingID.
-func (s *Store) UnmarkDatesBooked(ctx context.Context, tenantID, listingID, bookingID string) error {
+// UnmarkDatesBooked releases dates that were booked for bookingID and
+// returns how many it freed; repeating the call releases nothing.
+func (s *Store) UnmarkDatesBooked(ctx context.Context, tenantID, listingID, bookingID string) (int, error) {
 	var exists bool
 	if err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM listings WHERE tenant_id = $1 AND id = $2)`, tenantID, listingID).Scan(&exists); err != nil {
-		return err
+		return 0, err
 	}
 	if !exists {
-		return ErrNotFound
+		return 0, ErrNotFound
 	}
 
-	_, err := s.db.ExecContext(ctx,
+	result, err := s.db.ExecContext(ctx,
 		`DELETE FROM listing_availability WHERE listing_id = $1 AND booking_id = $2 AND status = 'booked'`,
 		listingID, bookingID)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	n, _ := result.RowsAffected()
+	return int(n), nil
 }
 
-// GetPricesByDate returns per-day effective prices (using price_override where set) for [checkIn, checkOut).
-func (s *Store) GetPricesByDate(ctx context.Context, listingID, basePrice, checkIn, checkOut string) (map[string]string, error) {
+// GetPriceRules returns what prices the nights in [checkIn, checkOut): the
+// per-date price overrides and the rate plans covering any of the nights.
+// domain.NightlyPrices combines them with the base price.
+func (s *Store) GetPriceRules(ctx context.Context, listingID, checkIn, checkOut string) (map[string]string, []domain.RatePlan, error) {
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT date::text, COALESCE(price_override, $1) AS effective_price
-		 FROM (
-		   SELECT generate_series($2::date, $3::date - interval '1 day', '1 day') AS date
-		 ) dates
-		 LEFT JOIN listing_availability av
-		   ON av.listing_id = $4 AND av.date = dates.date`,
-		basePrice, checkIn, checkOut, listingID)
+		`SELECT date::text, price_override FROM listing_availability
+		 WHERE listing_id = $1 AND date >= $2::date AND date < $3::date
+		   AND price_override IS NOT NULL`,
+		listingID, checkIn, checkOut)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
-	prices := map[string]string{}
+	overrides := map[string]string{}
 	for rows.Next() {
 		var dateStr, priceStr string
 		if rows.Scan(&dateStr, &priceStr) == nil {
-			prices[dateStr] = priceStr
+			overrides[dateStr] = priceStr
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	plans, err := s.listRatePlans(ctx,
+		`WHERE listing_id = $1 AND start_date < $3::date AND end_date > $2::date`,
+		listingID, checkIn, checkOut)
+	if err != nil {
+		return nil, nil, err
+	}
+	return overrides, plans, nil
+}
+
+// ─── Rate Plans ───────────────────────────────────────────────────────────────
+
+// ErrPlanOverlap is returned by CreateRatePlan when the plan shares a night
+// with an existing one.
+var ErrPlanOverlap = errors.New("rate plan overlaps an existing plan")
+
+// ListRatePlans returns the listing's rate plans by start date.
+func (s *Store) ListRatePlans(ctx context.Context, listingID string) ([]domain.RatePlan, error) {
+	return s.listRatePlans(ctx, `WHERE listing_id = $1`, listingID)
+}
+
+func (s *Store) listRatePlans(ctx context.Context, where string, args ...any) ([]domain.RatePlan, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, listing_id, name, start_date::text, end_date::text, multiplier, created_at
+		 FROM listing_rate_plans `+where+` ORDER BY start_date`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	plans := []domain.RatePlan{}
+	for rows.Next() {
+		var p domain.RatePlan
+		if err := rows.Scan(&p.ID, &p.ListingID, &p.Name, &p.From, &p.To, &p.Multiplier, &p.CreatedAt); err != nil {
+			return nil, err
 		}
+		plans = append(plans, p)
+	}
+	return plans, rows.Err()
+}
+
+// CreateRatePlan stores p for the listing, rejecting it with ErrPlanOverlap
+// if it shares a night with another of the listing's plans.
+func (s *Store) CreateRatePlan(ctx context.Context, tenantID string, p domain.RatePlan) (domain.RatePlan, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.RatePlan{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "listing_rate_plans:"+p.ListingID); err != nil {
+		return domain.RatePlan{}, err
+	}
+	var overlaps bool
+	if err := tx.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM listing_rate_plans
+		 WHERE listing_id = $1 AND start_date < $3::date AND end_date > $2::date)`,
+		p.ListingID, p.From, p.To).Scan(&overlaps); err != nil {
+		return domain.RatePlan{}, err
+	}
+	if overlaps {
+		return domain.RatePlan{}, ErrPlanOverlap
+	}
+	p.ID = uuid.NewString()
+	p.CreatedAt = time.Now().Unix()
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO listing_rate_plans (id, tenant_id, listing_id, name, start_date, end_date, multiplier, created_at)
+		 VALUES ($1, $2, $3, $4, $5::date, $6::date, $7, $8)`,
+		p.ID, tenantID, p.ListingID, p.Name, p.From, p.To, p.Multiplier, p.CreatedAt); err != nil {
+		return domain.RatePlan{}, err
+	}
+	return p, tx.Commit()
+}
+
+// DeleteRatePlan removes one of the listing's rate plans.
+func (s *Store) DeleteRatePlan(ctx context.Context, listingID, planID string) error {
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM listing_rate_plans WHERE listing_id = $1 AND id = $2`, listingID, planID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ─── Views ────────────────────────────────────────────────────────────────────
+
+// RecordView stores a view event and reports whether it is unique, i.e. the
+// same viewer had not viewed the listing since dedupSince.
+func (s *Store) RecordView(ctx context.Context, tenantID, listingID, viewerHash string, now, dedupSince int64) (bool, error) {
+	var unique bool
+	err := s.db.QueryRowContext(ctx,
+		`INSERT INTO listing_views (tenant_id, listing_id, viewer_hash, is_unique, viewed_at)
+		 VALUES ($1, $2, $3, NOT EXISTS (
+		   SELECT 1 FROM listing_views
+		   WHERE listing_id = $2 AND viewer_hash = $3 AND viewed_at > $5
+		 ), $4)
+		 RETURNING is_unique`,
+		tenantID, listingID, viewerHash, now, dedupSince).Scan(&unique)
+	return unique, err
+}
+
+// ViewStats returns view counts for a listing since the given time.
+func (s *Store) ViewStats(ctx context.Context, listingID string, since int64) (domain.ViewStats, error) {
+	var st domain.ViewStats
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*), COUNT(*) FILTER (WHERE is_unique), COUNT(DISTINCT viewer_hash)
+		 FROM listing_views WHERE listing_id = $1 AND viewed_at >= $2`,
+		listingID, since).Scan(&st.Views, &st.UniqueViews, &st.UniqueViewers)
+	return st, err
+}
+
+// ─── Versions ─────────────────────────────────────────────────────────────────
+
+// RecordVersion saves l as the listing's newest version and prunes all but
+// the newest keep versions.
+func (s *Store) RecordVersion(ctx context.Context, editorID string, l domain.Listing, keep int) error {
+	l.Photos, l.HostSummary = nil, nil
+	snapshot, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO listing_versions (id, listing_id, editor_id, snapshot, created_at)
+		 VALUES ($1, $2, $3, $4, $5)`,
+		uuid.NewString(), l.ID, editorID, snapshot, time.Now().UnixNano()); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM listing_versions WHERE listing_id = $1 AND id NOT IN (
+		   SELECT id FROM listing_versions WHERE listing_id = $1
+		   ORDER BY created_at DESC LIMIT $2
+		 )`, l.ID, keep); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListVersions returns a listing's versions, newest first.
+func (s *Store) ListVersions(ctx context.Context, listingID string) ([]domain.ListingVersion, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, listing_id, editor_id, snapshot, created_at
+		 FROM listing_versions WHERE listing_id = $1 ORDER BY created_at DESC`, listingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	versions := []domain.ListingVersion{}
+	for rows.Next() {
+		v, err := scanVersion(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// GetVersion returns one version of a listing. Returns ErrNotFound if the
+// listing has no such version.
+func (s *Store) GetVersion(ctx context.Context, listingID, versionID string) (domain.ListingVersion, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT id, listing_id, editor_id, snapshot, created_at
+		 FROM listing_versions WHERE listing_id = $1 AND id = $2`, listingID, versionID)
+	v, err := scanVersion(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return v, ErrNotFound
+	}
+	return v, err
+}
+
+func scanVersion(scan func(dest ...any) error) (domain.ListingVersion, error) {
+	var v domain.ListingVersion
+	var snapshot []byte
+	if err := scan(&v.ID, &v.ListingID, &v.EditorID, &snapshot, &v.CreatedAt); err != nil {
+		return v, err
+	}
+	if err := json.Unmarshal(snapshot, &v.Snapshot); err != nil {
+		return v, err
 	}
-	return prices, nil
+	v.CreatedAt /= int64(time.Second) // stored in ns so rapid edits keep their order
+	return v, nil
 }
 
 // ─── helpers ──────────────────────────────────────────────────────────────────
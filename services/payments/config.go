@@ -10,29 +10,45 @@ type Config struct {
 	MashgateURL   string
 	MashgateKey   string
 	WebhookSecret string
-	BookingsURL   string
-	InternalToken string
-	DatabaseURL   string
+	// Rotation overlap: the previous secret is accepted until
+	// WebhookSecretPreviousUntil (RFC3339), however often the service
+	// restarts in between.
+	WebhookSecretPrevious      string
+	WebhookSecretPreviousUntil string
+	BookingsURL                string
+	InternalToken              string
+	DatabaseURL                string
 
 	// Service JWT auth (optional; if set, JWT is preferred over InternalToken)
-	AuthServiceURL  string
-	AuthServiceKey  string
-	ServiceName     string
+	AuthServiceURL string
+	AuthServiceKey string
+	ServiceName    string
+
+	// Reconciliation of stale payment_pending bookings (0 interval disables).
+	ReconcileIntervalSeconds int
+	ReconcileStaleMinutes    int
+	ReconcileBatchSize       int
 }
 
 // LoadConfig reads configuration from environment variables.
 func LoadConfig() *Config {
 	return &Config{
-		Port:          httputil.Getenv("PAYMENTS_PORT", "8003"),
-		MashgateURL:   httputil.Getenv("MASHGATE_URL", "http://localhost:9661"),
-		MashgateKey:   httputil.Getenv("MASHGATE_API_KEY", ""),
-		WebhookSecret: httputil.Getenv("MASHGATE_WEBHOOK_SECRET", ""),
-		BookingsURL:   httputil.Getenv("BOOKINGS_URL", "http://bookings:8002"),
-		InternalToken: httputil.Getenv("INTERNAL_TOKEN", ""),
-		DatabaseURL:   httputil.Getenv("DATABASE_URL", ""),
+		Port:                       httputil.Getenv("PAYMENTS_PORT", "8003"),
+		MashgateURL:                httputil.Getenv("MASHGATE_URL", "http://localhost:9661"),
+		MashgateKey:                httputil.Getenv("MASHGATE_API_KEY", ""),
+		WebhookSecret:              httputil.Getenv("MASHGATE_WEBHOOK_SECRET", ""),
+		WebhookSecretPrevious:      httputil.Getenv("MASHGATE_WEBHOOK_SECRET_PREVIOUS", ""),
+		WebhookSecretPreviousUntil: httputil.Getenv("MASHGATE_WEBHOOK_SECRET_PREVIOUS_UNTIL", ""),
+		BookingsURL:                httputil.Getenv("BOOKINGS_URL", "http://bookings:8002"),
+		InternalToken:              httputil.Getenv("INTERNAL_TOKEN", ""),
+		DatabaseURL:                httputil.Getenv("DATABASE_URL", ""),
 
 		AuthServiceURL: httputil.Getenv("AUTH_SERVICE_URL", ""),
 		AuthServiceKey: httputil.Getenv("AUTH_SERVICE_KEY", ""),
 		ServiceName:    httputil.Getenv("SERVICE_NAME", "zist-payments"),
+
+		ReconcileIntervalSeconds: httputil.GetenvInt("RECONCILE_INTERVAL_SECONDS", 300),
+		ReconcileStaleMinutes:    httputil.GetenvInt("RECONCILE_STALE_MINUTES", 15),
+		ReconcileBatchSize:       httputil.GetenvInt("RECONCILE_BATCH_SIZE", 50),
 	}
 }
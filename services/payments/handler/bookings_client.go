@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -51,10 +53,26 @@ func (c *BookingsClient) setAuth(req *http.Request) {
 	req.Header.Set("X-Internal-Token", c.internalToken)
 }
 
+// ErrRefundDue is returned by ConfirmBooking when the payment arrived after
+// the booking stopped waiting for it: the bookings service flagged it for
+// refund instead of confirming. The capture has been handled.
+var ErrRefundDue = errors.New("payment captured late; marked for refund")
+
 // ConfirmBooking calls the bookings service to mark a booking as confirmed.
 func (c *BookingsClient) ConfirmBooking(ctx context.Context, tenantID, bookingID, paymentID string) error {
 	body, _ := json.Marshal(map[string]string{"paymentId": paymentID})
-	return c.post(ctx, tenantID, "/bookings/"+bookingID+"/confirm", body)
+	status, err := c.send(ctx, tenantID, "/bookings/"+bookingID+"/confirm", body)
+	if err != nil {
+		return err
+	}
+	switch status {
+	case http.StatusNoContent:
+		return nil
+	case http.StatusOK:
+		return ErrRefundDue
+	default:
+		return fmt.Errorf("bookings service returned %d", status)
+	}
 }
 
 // FailBooking calls the bookings service to mark a booking as failed.
@@ -62,6 +80,50 @@ func (c *BookingsClient) FailBooking(ctx context.Context, tenantID, bookingID st
 	return c.post(ctx, tenantID, "/bookings/"+bookingID+"/fail", nil)
 }
 
+// BookingInfo is the subset of a booking the payments service needs to
+// (re)start a checkout.
+type BookingInfo struct {
+	ID          string  `json:"id"`
+	ListingID   string  `json:"listingId"`
+	GuestID     string  `json:"guestId"`
+	TotalAmount string  `json:"totalAmount"`
+	Currency    string  `json:"currency"`
+	Status      string  `json:"status"`
+	CheckoutID  *string `json:"checkoutId"`
+	ExpiresAt   *int64  `json:"expiresAt"`
+}
+
+// GetBooking fetches a booking via the internal endpoint.
+// Returns nil, nil if the booking doesn't exist.
+func (c *BookingsClient) GetBooking(ctx context.Context, tenantID, bookingID string) (*BookingInfo, error) {
+	if strings.TrimSpace(tenantID) == "" {
+		return nil, errors.New("tenant id is required")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.baseURL+"/bookings/"+bookingID+"/checkout", nil)
+	if err != nil {
+		return nil, err
+	}
+	c.setAuth(req)
+	req.Header.Set("X-Tenant-ID", tenantID)
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bookings service returned %d", resp.StatusCode)
+	}
+	var b BookingInfo
+	if err := json.NewDecoder(resp.Body).Decode(&b); err != nil {
+		return nil, fmt.Errorf("decode booking: %w", err)
+	}
+	return &b, nil
+}
+
 // SetCheckoutID persists the Mashgate checkout session ID on the booking.
 func (c *BookingsClient) SetCheckoutID(ctx context.Context, tenantID, bookingID, checkoutID string) error {
 	if strings.TrimSpace(tenantID) == "" {
@@ -88,10 +150,106 @@ func (c *BookingsClient) SetCheckoutID(ctx context.Context, tenantID, bookingID,
 	return nil
 }
 
-func (c *BookingsClient) post(ctx context.Context, tenantID, path string, body []byte) error {
+// StaleCheckout identifies a payment_pending booking awaiting reconciliation.
+type StaleCheckout struct {
+	TenantID   string `json:"tenantId"`
+	ID         string `json:"id"`
+	CheckoutID string `json:"checkoutId"`
+}
+
+// ListStaleCheckouts fetches up to limit payment_pending bookings, across all
+// tenants, whose checkout has been idle for at least olderThan.
+func (c *BookingsClient) ListStaleCheckouts(ctx context.Context, olderThan time.Duration, limit int) ([]StaleCheckout, error) {
+	q := url.Values{}
+	q.Set("older_than_seconds", strconv.Itoa(int(olderThan.Seconds())))
+	q.Set("limit", strconv.Itoa(limit))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.baseURL+"/bookings/internal/stale-checkouts?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	c.setAuth(req)
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bookings service returned %d", resp.StatusCode)
+	}
+	var out struct {
+		Bookings []StaleCheckout `json:"bookings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode stale checkouts: %w", err)
+	}
+	return out.Bookings, nil
+}
+
+// ErrStalePaymentStatus is returned by SetPaymentStatus when the booking has
+// already moved past the requested payment status.
+var ErrStalePaymentStatus = errors.New("payment status transition not allowed")
+
+// SetPaymentStatus records a payment status (pending|captured|failed|refunded)
+// on the booking.
+func (c *BookingsClient) SetPaymentStatus(ctx context.Context, tenantID, bookingID, status string) error {
+	return c.putPaymentStatus(ctx, tenantID, bookingID, map[string]string{"paymentStatus": status})
+}
+
+// RecordRefund records a settled refund of amount on the booking, which
+// becomes partially_refunded or refunded depending on how much of its total
+// has been refunded. An empty amount refunds it in full.
+func (c *BookingsClient) RecordRefund(ctx context.Context, tenantID, bookingID, amount string) error {
+	return c.putPaymentStatus(ctx, tenantID, bookingID, map[string]string{
+		"paymentStatus": "refunded", "refundedAmount": amount,
+	})
+}
+
+func (c *BookingsClient) putPaymentStatus(ctx context.Context, tenantID, bookingID string, fields map[string]string) error {
 	if strings.TrimSpace(tenantID) == "" {
 		return errors.New("tenant id is required")
 	}
+	body, _ := json.Marshal(fields)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut,
+		c.baseURL+"/bookings/"+bookingID+"/payment-status",
+		bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.setAuth(req)
+	req.Header.Set("X-Tenant-ID", tenantID)
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	case http.StatusConflict:
+		return ErrStalePaymentStatus
+	default:
+		return fmt.Errorf("bookings service returned %d", resp.StatusCode)
+	}
+}
+
+func (c *BookingsClient) post(ctx context.Context, tenantID, path string, body []byte) error {
+	status, err := c.send(ctx, tenantID, path, body)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusNoContent {
+		return fmt.Errorf("bookings service returned %d", status)
+	}
+	return nil
+}
+
+// send POSTs body to path and returns the response status.
+func (c *BookingsClient) send(ctx context.Context, tenantID, path string, body []byte) (int, error) {
+	if strings.TrimSpace(tenantID) == "" {
+		return 0, errors.New("tenant id is required")
+	}
 	var reqBody *bytes.Reader
 	if body != nil {
 		reqBody = bytes.NewReader(body)
@@ -100,7 +258,7 @@ func (c *BookingsClient) post(ctx context.Context, tenantID, path string, body [
 	}
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, reqBody)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
@@ -109,11 +267,8 @@ func (c *BookingsClient) post(ctx context.Context, tenantID, path string, body [
 	req.Header.Set("X-Tenant-ID", tenantID)
 	resp, err := c.hc.Do(req)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("bookings service returned %d", resp.StatusCode)
-	}
-	return nil
+	resp.Body.Close()
+	return resp.StatusCode, nil
 }
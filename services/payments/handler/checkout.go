@@ -1,17 +1,27 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	mashgate "github.com/saidmashhud/mashgate/packages/sdk-go"
 	zistauth "github.com/saidmashhud/zist/internal/auth"
 	"github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/services/payments/store"
 )
 
-// CreateCheckout creates a Mashgate checkout session and returns the hosted checkout URL.
+// CreateCheckout creates a Mashgate checkout session for the caller's
+// payment_pending booking, charging the booking's total, and returns the
+// hosted checkout URL.
+// Later calls for the same booking get the session already opened for it,
+// with 200, while it is still valid, whatever Idempotency-Key they send.
 // POST /checkout
 func (h *Handler) CreateCheckout(w http.ResponseWriter, r *http.Request) {
 	principal := zistauth.FromContext(r.Context())
@@ -20,10 +30,9 @@ func (h *Handler) CreateCheckout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The amount and listing come from the booking; the client can't set them.
 	var req struct {
-		ListingID     string `json:"listingId"`
 		BookingID     string `json:"bookingId"`
-		Amount        string `json:"amount"`
 		Currency      string `json:"currency"`
 		SuccessURL    string `json:"successUrl"`
 		CancelURL     string `json:"cancelUrl"`
@@ -33,43 +42,210 @@ func (h *Handler) CreateCheckout(w http.ResponseWriter, r *http.Request) {
 		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
-	if req.Amount == "" || req.Currency == "" {
-		httputil.WriteError(w, http.StatusUnprocessableEntity, "amount and currency are required")
+	if req.BookingID == "" || req.Currency == "" {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, "bookingId and currency are required")
 		return
 	}
+	b, err := h.Bookings.GetBooking(r.Context(), principal.TenantID, req.BookingID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, "could not reach bookings service")
+		return
+	}
+	if b == nil {
+		httputil.WriteError(w, http.StatusNotFound, "booking not found")
+		return
+	}
+	if b.GuestID != principal.UserID {
+		httputil.WriteError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+	if b.Status != "payment_pending" {
+		httputil.WriteError(w, http.StatusConflict, "booking is not awaiting payment")
+		return
+	}
+	// The booking's currency comes from its listing; never charge in another.
+	if !strings.EqualFold(strings.TrimSpace(req.Currency), b.Currency) {
+		httputil.WriteError(w, http.StatusUnprocessableEntity,
+			fmt.Sprintf("currency %s does not match booking currency %s", req.Currency, b.Currency))
+		return
+	}
+
+	// Sessions are remembered per booking, so any retry for the booking gets
+	// the open session whatever Idempotency-Key it sends. Mashgate sees the
+	// key scoped to the booking, so one key can't collide across bookings.
+	key := b.ID
+	if k := strings.TrimSpace(r.Header.Get("Idempotency-Key")); k != "" {
+		key = b.ID + ":" + k
+	}
+	cs, err := h.Sessions.GetCheckoutSession(r.Context(), principal.TenantID, b.ID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		slog.Warn("checkout session lookup failed", "bookingId", b.ID, "err", err)
+	}
+	if err == nil && reusableSession(cs, b, time.Now()) {
+		httputil.WriteJSON(w, http.StatusOK, map[string]string{
+			"sessionId":   cs.SessionID,
+			"checkoutUrl": cs.CheckoutURL,
+		})
+		return
+	}
+
+	sessionID, checkoutURL, err := h.startCheckout(r.Context(), principal.TenantID, checkoutParams{
+		ListingID:      b.ListingID,
+		BookingID:      b.ID,
+		Amount:         b.TotalAmount,
+		Currency:       b.Currency,
+		SuccessURL:     req.SuccessURL,
+		CancelURL:      req.CancelURL,
+		CustomerEmail:  req.CustomerEmail,
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, "payment gateway error")
+		return
+	}
+	h.rememberSession(r.Context(), principal.TenantID, b.ID, sessionID, checkoutURL, b)
+
+	httputil.WriteJSON(w, http.StatusCreated, map[string]string{
+		"sessionId":   sessionID,
+		"checkoutUrl": checkoutURL,
+	})
+}
+
+// ResumeCheckout issues a fresh checkout session for a booking that is still
+// payment_pending, e.g. after the guest let the previous session lapse.
+// POST /checkout/{bookingId}/resume
+func (h *Handler) ResumeCheckout(w http.ResponseWriter, r *http.Request) {
+	principal := zistauth.FromContext(r.Context())
+	if principal == nil || principal.TenantID == "" {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// Body is optional; redirect URLs and email may be supplied again.
+	var req struct {
+		SuccessURL    string `json:"successUrl"`
+		CancelURL     string `json:"cancelUrl"`
+		CustomerEmail string `json:"customerEmail"`
+	}
+	json.NewDecoder(r.Body).Decode(&req) //nolint:errcheck
 
-	session, err := h.MG.CreateCheckout(r.Context(), mashgate.CreateCheckoutRequest{
-		TotalAmount: mashgate.Money{Amount: req.Amount, Currency: req.Currency},
+	bookingID := chi.URLParam(r, "bookingId")
+	b, err := h.Bookings.GetBooking(r.Context(), principal.TenantID, bookingID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, "could not reach bookings service")
+		return
+	}
+	if b == nil {
+		httputil.WriteError(w, http.StatusNotFound, "booking not found")
+		return
+	}
+	if b.GuestID != principal.UserID {
+		httputil.WriteError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+	if b.Status != "payment_pending" {
+		httputil.WriteError(w, http.StatusConflict, "booking is not awaiting payment")
+		return
+	}
+	if b.ExpiresAt != nil && time.Now().Unix() >= *b.ExpiresAt {
+		httputil.WriteError(w, http.StatusConflict, "booking payment window has expired")
+		return
+	}
+
+	// Key on the lapsed session so retries of the same resume are idempotent
+	// while still yielding a session distinct from the original.
+	key := b.ID + ":resume"
+	if b.CheckoutID != nil {
+		key += ":" + *b.CheckoutID
+	}
+	sessionID, checkoutURL, err := h.startCheckout(r.Context(), principal.TenantID, checkoutParams{
+		ListingID:      b.ListingID,
+		BookingID:      b.ID,
+		Amount:         b.TotalAmount,
+		Currency:       b.Currency,
+		SuccessURL:     req.SuccessURL,
+		CancelURL:      req.CancelURL,
+		CustomerEmail:  req.CustomerEmail,
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, "payment gateway error")
+		return
+	}
+	// A later POST /checkout for this booking should get the resumed session.
+	h.rememberSession(r.Context(), principal.TenantID, b.ID, sessionID, checkoutURL, b)
+
+	resp := map[string]any{
+		"sessionId":   sessionID,
+		"checkoutUrl": checkoutURL,
+	}
+	if b.ExpiresAt != nil {
+		resp["expiresAt"] = *b.ExpiresAt
+	}
+	httputil.WriteJSON(w, http.StatusCreated, resp)
+}
+
+// checkoutParams describes a checkout session for a single booking.
+type checkoutParams struct {
+	ListingID      string
+	BookingID      string
+	Amount         string
+	Currency       string
+	SuccessURL     string
+	CancelURL      string
+	CustomerEmail  string
+	IdempotencyKey string
+}
+
+// rememberSession records a freshly opened session for bookingID so retries
+// can reuse it. Failure only costs a duplicate session later, so it is logged.
+func (h *Handler) rememberSession(ctx context.Context, tenantID, bookingID, sessionID, checkoutURL string, b *BookingInfo) {
+	now := time.Now()
+	err := h.Sessions.PutCheckoutSession(ctx, store.CheckoutSession{
+		TenantID:    tenantID,
+		Key:         bookingID,
+		BookingID:   bookingID,
+		SessionID:   sessionID,
+		CheckoutURL: checkoutURL,
+		ExpiresAt:   sessionExpiry(b, now),
+		CreatedAt:   now.Unix(),
+	})
+	if err != nil {
+		slog.Warn("failed to store checkout session", "bookingId", bookingID, "err", err)
+	}
+}
+
+// startCheckout creates a Mashgate checkout session and records its id on the
+// booking. Failing to record the id is logged but not fatal: the webhook
+// carries bookingId in metadata.
+func (h *Handler) startCheckout(ctx context.Context, tenantID string, p checkoutParams) (sessionID, checkoutURL string, err error) {
+	session, err := h.MG.CreateCheckout(ctx, mashgate.CreateCheckoutRequest{
+		TotalAmount: mashgate.Money{Amount: p.Amount, Currency: p.Currency},
 		Items: []mashgate.LineItem{
 			{
-				Name:      fmt.Sprintf("Zist booking %s", req.BookingID),
+				Name:      fmt.Sprintf("Zist booking %s", p.BookingID),
 				Quantity:  1,
-				UnitPrice: mashgate.Money{Amount: req.Amount, Currency: req.Currency},
+				UnitPrice: mashgate.Money{Amount: p.Amount, Currency: p.Currency},
 			},
 		},
-		CustomerEmail:  req.CustomerEmail,
-		SuccessURL:     req.SuccessURL,
-		CancelURL:      req.CancelURL,
-		IdempotencyKey: req.BookingID,
+		CustomerEmail:  p.CustomerEmail,
+		SuccessURL:     p.SuccessURL,
+		CancelURL:      p.CancelURL,
+		IdempotencyKey: p.IdempotencyKey,
 		Metadata: map[string]string{
-			"bookingId": req.BookingID,
-			"listingId": req.ListingID,
+			"bookingId": p.BookingID,
+			"listingId": p.ListingID,
 		},
 	})
 	if err != nil {
 		slog.Error("Mashgate CreateCheckout failed", "err", err)
-		httputil.WriteError(w, http.StatusBadGateway, "payment gateway error")
-		return
+		return "", "", err
 	}
 
-	if req.BookingID != "" {
-		if err := h.Bookings.SetCheckoutID(r.Context(), principal.TenantID, req.BookingID, session.SessionID); err != nil {
-			slog.Warn("failed to store checkout_id on booking", "bookingId", req.BookingID, "err", err)
+	if p.BookingID != "" {
+		if err := h.Bookings.SetCheckoutID(ctx, tenantID, p.BookingID, session.SessionID); err != nil {
+			slog.Warn("failed to store checkout_id on booking", "bookingId", p.BookingID, "err", err)
 		}
 	}
-
-	httputil.WriteJSON(w, http.StatusCreated, map[string]string{
-		"sessionId":   session.SessionID,
-		"checkoutUrl": session.CheckoutURL,
-	})
+	return session.SessionID, session.CheckoutURL, nil
 }
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	mashgate "github.com/saidmashhud/mashgate/packages/sdk-go"
+	"github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/services/payments/store"
+)
+
+// sensitiveHeaders are never persisted with a dead letter.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":    true,
+	"Cookie":           true,
+	"X-Internal-Token": true,
+}
+
+// deadLetter persists an event that could not be processed. Best-effort:
+// a storage failure is logged and the webhook response is unaffected.
+func (h *Handler) deadLetter(r *http.Request, body []byte, event *mashgate.WebhookEvent, reason string) {
+	if h.DeadLetters == nil {
+		slog.Warn("webhook event dropped (no dead-letter store)", "reason", reason)
+		return
+	}
+
+	headers := make(map[string]string, len(r.Header))
+	for k, v := range r.Header {
+		if len(v) > 0 && !sensitiveHeaders[k] {
+			headers[k] = v[0]
+		}
+	}
+	d := store.DeadLetter{Body: string(body), Headers: headers, Reason: reason}
+	if event != nil {
+		d.EventID, d.EventType, d.TenantID = event.EventID, event.EventType, event.TenantID
+	}
+
+	// Detach from the request so a client disconnect doesn't lose the record.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), 5*time.Second)
+	defer cancel()
+	id, err := h.DeadLetters.AddDeadLetter(ctx, d)
+	if err != nil {
+		slog.Error("failed to store dead letter", "eventId", d.EventID, "err", err)
+		return
+	}
+	slog.Warn("webhook event dead-lettered", "id", id, "eventId", d.EventID, "reason", reason)
+}
+
+// ListDeadLetters handles GET /admin/webhooks/dead-letters.
+// Query: status (pending|reprocessed), limit (default 50, max 200).
+func (h *Handler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if h.DeadLetters == nil {
+		httputil.WriteError(w, http.StatusServiceUnavailable, "dead-letter store not configured")
+		return
+	}
+	limit := 50
+	if n, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && n > 0 && n <= 200 {
+		limit = n
+	}
+	items, err := h.DeadLetters.ListDeadLetters(r.Context(), strings.TrimSpace(r.URL.Query().Get("status")), limit)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"deadLetters": items})
+}
+
+// ReprocessDeadLetter re-parses and re-applies a stored event, bypassing dedup.
+// POST /admin/webhooks/dead-letters/{id}/reprocess
+func (h *Handler) ReprocessDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if h.DeadLetters == nil {
+		httputil.WriteError(w, http.StatusServiceUnavailable, "dead-letter store not configured")
+		return
+	}
+	id := chi.URLParam(r, "id")
+	d, err := h.DeadLetters.GetDeadLetter(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		httputil.WriteError(w, http.StatusNotFound, "dead letter not found")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if d.Status == store.DeadLetterReprocessed {
+		httputil.WriteError(w, http.StatusConflict, "dead letter already reprocessed")
+		return
+	}
+
+	procErr := h.reprocess(r.Context(), []byte(d.Body))
+	if err := h.DeadLetters.RecordAttempt(r.Context(), id, procErr); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if procErr != nil {
+		httputil.WriteJSON(w, http.StatusBadGateway, map[string]string{
+			"error":  "reprocess failed",
+			"reason": procErr.Error(),
+		})
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": store.DeadLetterReprocessed})
+}
+
+// reprocess parses and dispatches a raw event body. The signature was checked
+// when the event first arrived, so it is not re-verified here.
+func (h *Handler) reprocess(ctx context.Context, body []byte) error {
+	event, err := mashgate.ParseEvent(body)
+	if err != nil {
+		return err
+	}
+	if event.TenantID == "" {
+		return errMissingTenant
+	}
+	return h.dispatch(ctx, *event)
+}
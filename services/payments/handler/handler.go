@@ -2,7 +2,10 @@
 package handler
 
 import (
+	"time"
+
 	mashgate "github.com/saidmashhud/mashgate/packages/sdk-go"
+	"github.com/saidmashhud/zist/services/payments/store"
 )
 
 // DedupChecker abstracts the dedup store (in-memory or PostgreSQL-backed).
@@ -14,8 +17,14 @@ type DedupChecker interface {
 type Handler struct {
 	MG            *mashgate.Client
 	WebhookSecret string
-	Bookings      *BookingsClient
-	Dedup         DedupChecker
+	// PreviousWebhookSecret is still accepted until PreviousSecretUntil so
+	// events signed mid-rotation verify; empty disables it.
+	PreviousWebhookSecret string
+	PreviousSecretUntil   time.Time
+	Bookings              *BookingsClient
+	Dedup                 DedupChecker
+	DeadLetters           *store.Store // nil when DATABASE_URL is unset
+	Sessions              CheckoutSessions
 }
 
 // New returns a Handler with the given dependencies.
@@ -25,5 +34,27 @@ func New(mg *mashgate.Client, webhookSecret string, bc *BookingsClient, dc Dedup
 		WebhookSecret: webhookSecret,
 		Bookings:      bc,
 		Dedup:         dc,
+		Sessions:      newMemorySessions(),
 	}
 }
+
+// WithPreviousWebhookSecret also accepts webhooks signed with the secret
+// being rotated out, until until.
+func (h *Handler) WithPreviousWebhookSecret(secret string, until time.Time) *Handler {
+	h.PreviousWebhookSecret = secret
+	h.PreviousSecretUntil = until
+	return h
+}
+
+// WithDeadLetters enables persisting unprocessable webhook events.
+func (h *Handler) WithDeadLetters(s *store.Store) *Handler {
+	h.DeadLetters = s
+	return h
+}
+
+// WithCheckoutSessions persists reusable checkout sessions in s instead of
+// process memory.
+func (h *Handler) WithCheckoutSessions(s CheckoutSessions) *Handler {
+	h.Sessions = s
+	return h
+}
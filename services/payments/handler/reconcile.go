@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// ReconcileConfig bounds a reconciliation pass.
+type ReconcileConfig struct {
+	Interval   time.Duration // how often to sweep
+	StaleAfter time.Duration // how long a checkout may sit idle before it is checked
+	BatchSize  int           // max bookings checked per sweep
+}
+
+// RunReconciler periodically asks Mashgate for the state of checkouts tied to
+// stale payment_pending bookings and confirms or fails them, covering
+// webhooks that were lost or dead-lettered. It blocks until ctx is cancelled.
+func (h *Handler) RunReconciler(ctx context.Context, cfg ReconcileConfig) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.reconcile(ctx, cfg)
+		}
+	}
+}
+
+func (h *Handler) reconcile(ctx context.Context, cfg ReconcileConfig) {
+	sweepCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	stale, err := h.Bookings.ListStaleCheckouts(sweepCtx, cfg.StaleAfter, cfg.BatchSize)
+	if err != nil {
+		slog.Error("reconcile: failed to list stale checkouts", "err", err)
+		return
+	}
+	for _, b := range stale {
+		h.reconcileOne(sweepCtx, b)
+	}
+}
+
+func (h *Handler) reconcileOne(ctx context.Context, b StaleCheckout) {
+	session, err := h.MG.GetCheckout(ctx, b.CheckoutID)
+	if err != nil {
+		slog.Warn("reconcile: checkout lookup failed", "bookingId", b.ID, "checkoutId", b.CheckoutID, "err", err)
+		return
+	}
+
+	switch checkoutOutcome(session.Status) {
+	case outcomePaid:
+		err := h.Bookings.ConfirmBooking(ctx, b.TenantID, b.ID, "")
+		if errors.Is(err, ErrRefundDue) {
+			slog.Warn("reconcile: late payment marked for refund", "bookingId", b.ID, "checkoutId", b.CheckoutID)
+			return
+		}
+		if err != nil {
+			slog.Error("reconcile: failed to confirm booking", "bookingId", b.ID, "err", err)
+			return
+		}
+		slog.Info("reconcile: booking confirmed", "bookingId", b.ID, "checkoutId", b.CheckoutID)
+	case outcomeFailed:
+		if err := h.Bookings.FailBooking(ctx, b.TenantID, b.ID); err != nil {
+			slog.Error("reconcile: failed to mark booking as failed", "bookingId", b.ID, "err", err)
+			return
+		}
+		slog.Info("reconcile: booking failed", "bookingId", b.ID, "checkoutId", b.CheckoutID, "checkoutStatus", session.Status)
+	default:
+		slog.Debug("reconcile: checkout still open", "bookingId", b.ID, "checkoutStatus", session.Status)
+	}
+}
+
+type outcome int
+
+const (
+	outcomePending outcome = iota
+	outcomePaid
+	outcomeFailed
+)
+
+// checkoutOutcome maps a Mashgate checkout status onto what it means for the
+// booking. Unknown statuses are left alone for the next sweep.
+func checkoutOutcome(status string) outcome {
+	switch strings.ToLower(status) {
+	case "completed", "paid", "succeeded":
+		return outcomePaid
+	case "expired", "failed", "cancelled", "canceled":
+		return outcomeFailed
+	default:
+		return outcomePending
+	}
+}
@@ -23,8 +23,10 @@ func (h *Handler) CreateRefund(w http.ResponseWriter, r *http.Request) {
 		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
-	if req.PaymentID == "" || req.Amount == "" || req.Currency == "" {
-		httputil.WriteError(w, http.StatusUnprocessableEntity, "paymentId, amount, and currency are required")
+	// The booking ID keys the refund, and the refund.settled webhook is
+	// matched back to the booking by it.
+	if req.PaymentID == "" || req.Amount == "" || req.Currency == "" || req.BookingID == "" {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, "paymentId, amount, currency, and bookingId are required")
 		return
 	}
 
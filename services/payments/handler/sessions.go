@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/saidmashhud/zist/services/payments/store"
+)
+
+// checkoutSessionTTL bounds how long a session is reused when the booking
+// carries no payment window of its own.
+const checkoutSessionTTL = 30 * time.Minute
+
+// CheckoutSessions remembers the checkout session opened for each booking
+// (PostgreSQL or in-memory), keyed by booking id.
+type CheckoutSessions interface {
+	GetCheckoutSession(ctx context.Context, tenantID, key string) (store.CheckoutSession, error)
+	PutCheckoutSession(ctx context.Context, cs store.CheckoutSession) error
+}
+
+// memorySessions is the CheckoutSessions fallback used without a database.
+type memorySessions struct {
+	mu       sync.Mutex
+	sessions map[string]store.CheckoutSession
+}
+
+func newMemorySessions() *memorySessions {
+	return &memorySessions{sessions: make(map[string]store.CheckoutSession)}
+}
+
+func (m *memorySessions) GetCheckoutSession(_ context.Context, tenantID, key string) (store.CheckoutSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cs, ok := m.sessions[tenantID+"\x00"+key]
+	if !ok {
+		return cs, store.ErrNotFound
+	}
+	return cs, nil
+}
+
+func (m *memorySessions) PutCheckoutSession(_ context.Context, cs store.CheckoutSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now().Unix()
+	for k, old := range m.sessions {
+		if old.ExpiresAt <= now {
+			delete(m.sessions, k)
+		}
+	}
+	m.sessions[cs.TenantID+"\x00"+cs.Key] = cs
+	return nil
+}
+
+// sessionExpiry is when a session opened now for b stops being reusable:
+// the end of the booking's payment window, or checkoutSessionTTL without one.
+func sessionExpiry(b *BookingInfo, now time.Time) int64 {
+	if b != nil && b.ExpiresAt != nil {
+		return *b.ExpiresAt
+	}
+	return now.Add(checkoutSessionTTL).Unix()
+}
+
+// reusableSession reports whether cs can be handed back instead of opening a
+// new session. The booking, when known, must still await payment through
+// that very session; a resume replaces the booking's checkout id.
+func reusableSession(cs store.CheckoutSession, b *BookingInfo, now time.Time) bool {
+	if cs.SessionID == "" || now.Unix() >= cs.ExpiresAt {
+		return false
+	}
+	if b == nil {
+		return true
+	}
+	if b.Status != "payment_pending" {
+		return false
+	}
+	if b.ExpiresAt != nil && now.Unix() >= *b.ExpiresAt {
+		return false
+	}
+	return b.CheckoutID == nil || *b.CheckoutID == cs.SessionID
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	zistauth "github.com/saidmashhud/zist/internal/auth"
+	"github.com/saidmashhud/zist/services/payments/store"
+)
+
+func TestReusableSession(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	later := now.Add(10 * time.Minute).Unix()
+	earlier := now.Add(-time.Minute).Unix()
+	sid := "cs_1"
+	other := "cs_2"
+	cs := store.CheckoutSession{SessionID: sid, ExpiresAt: later}
+
+	tests := []struct {
+		name string
+		cs   store.CheckoutSession
+		b    *BookingInfo
+		want bool
+	}{
+		{"no booking", cs, nil, true},
+		{"session expired", store.CheckoutSession{SessionID: sid, ExpiresAt: earlier}, nil, false},
+		{"pending", cs, &BookingInfo{Status: "payment_pending", CheckoutID: &sid}, true},
+		{"checkout id not yet stored", cs, &BookingInfo{Status: "payment_pending"}, true},
+		{"resumed elsewhere", cs, &BookingInfo{Status: "payment_pending", CheckoutID: &other}, false},
+		{"confirmed", cs, &BookingInfo{Status: "confirmed", CheckoutID: &sid}, false},
+		{"window lapsed", cs, &BookingInfo{Status: "payment_pending", ExpiresAt: &earlier}, false},
+	}
+	for _, tt := range tests {
+		if got := reusableSession(tt.cs, tt.b, now); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCreateCheckoutReusesSession(t *testing.T) {
+	expires := time.Now().Add(15 * time.Minute).Unix()
+	booking := BookingInfo{
+		ID: "b1", ListingID: "l1", GuestID: "u1",
+		TotalAmount: "100.00", Currency: "USD", Status: "payment_pending", ExpiresAt: &expires,
+	}
+	bookings := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(booking) //nolint:errcheck
+	}))
+	defer bookings.Close()
+
+	// No Mashgate client: opening a new session instead of reusing would panic.
+	h := New(nil, "secret", NewBookingsClient(bookings.URL, "token", nil), nil)
+	h.rememberSession(context.Background(), "t1", "b1", "cs_1", "https://pay.example/cs_1", &booking)
+
+	checkout := func(idempotencyKey string) (int, map[string]string) {
+		r := httptest.NewRequest(http.MethodPost, "/checkout",
+			strings.NewReader(`{"bookingId":"b1","listingId":"l1","amount":"100.00","currency":"USD"}`))
+		r.Header.Set("X-User-ID", "u1")
+		r.Header.Set("X-Tenant-ID", "t1")
+		if idempotencyKey != "" {
+			r.Header.Set("Idempotency-Key", idempotencyKey)
+		}
+		w := httptest.NewRecorder()
+		zistauth.Middleware(http.HandlerFunc(h.CreateCheckout)).ServeHTTP(w, r)
+		var body map[string]string
+		json.NewDecoder(w.Body).Decode(&body) //nolint:errcheck
+		return w.Code, body
+	}
+
+	// The session belongs to the booking: retries with no key, a key, or a
+	// different key all get it back.
+	for i, key := range []string{"", "", "retry-42", "retry-43"} {
+		status, body := checkout(key)
+		if status != http.StatusOK {
+			t.Fatalf("call %d: want 200, got %d", i+1, status)
+		}
+		if body["sessionId"] != "cs_1" || body["checkoutUrl"] != "https://pay.example/cs_1" {
+			t.Errorf("call %d (key %q): want the stored session, got %v", i+1, key, body)
+		}
+	}
+}
+
+func TestCreateCheckoutChecksBooking(t *testing.T) {
+	booking := BookingInfo{ID: "b1", ListingID: "l1", GuestID: "u1", TotalAmount: "100.00", Currency: "USD", Status: "payment_pending"}
+	bookings := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(booking) //nolint:errcheck
+	}))
+	defer bookings.Close()
+
+	// Every case is refused before Mashgate is called, so no client is needed.
+	h := New(nil, "secret", NewBookingsClient(bookings.URL, "token", nil), nil)
+	checkout := func(user, body string) int {
+		r := httptest.NewRequest(http.MethodPost, "/checkout", strings.NewReader(body))
+		r.Header.Set("X-User-ID", user)
+		r.Header.Set("X-Tenant-ID", "t1")
+		w := httptest.NewRecorder()
+		zistauth.Middleware(http.HandlerFunc(h.CreateCheckout)).ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if got := checkout("u1", `{"amount":"1.00","currency":"USD"}`); got != http.StatusUnprocessableEntity {
+		t.Errorf("no bookingId: got %d, want 422", got)
+	}
+	if got := checkout("u2", `{"bookingId":"b1","currency":"USD"}`); got != http.StatusForbidden {
+		t.Errorf("another guest's booking: got %d, want 403", got)
+	}
+	booking.Status = "confirmed"
+	if got := checkout("u1", `{"bookingId":"b1","currency":"USD"}`); got != http.StatusConflict {
+		t.Errorf("confirmed booking: got %d, want 409", got)
+	}
+}
@@ -1,10 +1,14 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"time"
 
 	mashgate "github.com/saidmashhud/mashgate/packages/sdk-go"
 	"github.com/saidmashhud/zist/internal/httputil"
@@ -12,6 +16,9 @@ import (
 
 // HandleWebhook receives Mashgate webhook events, verifies the signature,
 // deduplicates, and dispatches to the appropriate handler.
+// Authenticated events that can't be parsed or applied are dead-lettered for
+// operator reprocessing; once parsed, the response stays 200 so Mashgate
+// doesn't retry.
 // POST /webhooks/mashgate
 func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
@@ -32,16 +39,19 @@ func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if h.WebhookSecret != "" {
-		if err := mashgate.VerifySignature(h.WebhookSecret, timestamp, string(body), signature); err != nil {
+		which, err := h.verifySignature(timestamp, string(body), signature, time.Now())
+		if err != nil {
 			slog.Warn("webhook signature verification failed", "err", err)
 			httputil.WriteError(w, http.StatusUnauthorized, "invalid webhook signature")
 			return
 		}
+		slog.Debug("webhook signature verified", "secret", which)
 	}
 
 	event, err := mashgate.ParseEvent(body)
 	if err != nil {
 		slog.Error("failed to parse webhook event", "err", err)
+		h.deadLetter(r, body, nil, "parse: "+err.Error())
 		httputil.WriteError(w, http.StatusBadRequest, "invalid event payload")
 		return
 	}
@@ -58,55 +68,141 @@ func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if event.TenantID == "" {
+		h.deadLetter(r, body, event, errMissingTenant.Error())
 		httputil.WriteError(w, http.StatusBadRequest, "missing tenant_id in webhook event")
 		return
 	}
 
+	if err := h.dispatch(r.Context(), *event); err != nil {
+		h.deadLetter(r, body, event, err.Error())
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// verifySignature checks a webhook against the current secret and, while a
+// rotation overlap lasts, the previous one. It returns which secret matched:
+// "current" or "previous".
+func (h *Handler) verifySignature(timestamp, body, signature string, now time.Time) (string, error) {
+	err := mashgate.VerifySignature(h.WebhookSecret, timestamp, body, signature)
+	if err == nil {
+		return "current", nil
+	}
+	if h.PreviousWebhookSecret != "" && now.Before(h.PreviousSecretUntil) {
+		if mashgate.VerifySignature(h.PreviousWebhookSecret, timestamp, body, signature) == nil {
+			slog.Info("webhook verified with previous secret", "until", h.PreviousSecretUntil.Format(time.RFC3339))
+			return "previous", nil
+		}
+	}
+	return "", err
+}
+
+// errMissingTenant is recorded for events that carry no tenant_id.
+var errMissingTenant = errors.New("missing tenant_id in webhook event")
+
+// errMissingBookingID is recorded for refund events that name no booking:
+// the money moved, so the event must not be dropped silently.
+var errMissingBookingID = errors.New("missing bookingId in refund event metadata")
+
+// dispatch applies a parsed event. A non-nil error means a downstream update
+// failed and the event should be dead-lettered.
+func (h *Handler) dispatch(ctx context.Context, event mashgate.WebhookEvent) error {
 	switch event.EventType {
 	case mashgate.EventPaymentCaptured:
-		h.onPaymentCaptured(r, *event)
+		return h.onPaymentCaptured(ctx, event)
 	case mashgate.EventPaymentFailed, mashgate.EventPaymentCaptureFailed:
-		h.onPaymentFailed(r, *event)
+		return h.onPaymentFailed(ctx, event)
 	case mashgate.EventRefundSettled:
-		slog.Info("refund settled", "paymentId", event.AggregateID)
+		return h.onRefundSettled(ctx, event)
 	case mashgate.EventRefundFailed:
 		slog.Warn("refund failed", "paymentId", event.AggregateID)
 	case mashgate.EventCheckoutCompleted:
+		// The guest has paid; capture is still in flight.
 		slog.Info("checkout completed", "sessionId", event.AggregateID)
+		return h.setPaymentStatus(ctx, event, "pending")
 	case mashgate.EventCheckoutExpired:
 		slog.Warn("checkout expired", "sessionId", event.AggregateID)
 
 	default:
 		slog.Debug("unhandled event type", "eventType", event.EventType)
 	}
-
-	httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	return nil
 }
 
-func (h *Handler) onPaymentCaptured(r *http.Request, event mashgate.WebhookEvent) {
+func (h *Handler) onPaymentCaptured(ctx context.Context, event mashgate.WebhookEvent) error {
 	slog.Info("payment captured", "paymentId", event.AggregateID)
 	bookingID := extractBookingID(event)
 	if bookingID == "" {
-		return
+		return nil
+	}
+	err := h.Bookings.ConfirmBooking(ctx, event.TenantID, bookingID, event.AggregateID)
+	if errors.Is(err, ErrRefundDue) {
+		slog.Warn("late capture marked for refund", "bookingId", bookingID, "paymentId", event.AggregateID)
+		return nil
 	}
-	if err := h.Bookings.ConfirmBooking(r.Context(), event.TenantID, bookingID, event.AggregateID); err != nil {
+	if err != nil {
 		slog.Error("failed to confirm booking", "bookingId", bookingID, "err", err)
-	} else {
-		slog.Info("booking confirmed", "bookingId", bookingID)
+		return fmt.Errorf("confirm booking %s: %w", bookingID, err)
 	}
+	slog.Info("booking confirmed", "bookingId", bookingID)
+	return nil
 }
 
-func (h *Handler) onPaymentFailed(r *http.Request, event mashgate.WebhookEvent) {
+func (h *Handler) onPaymentFailed(ctx context.Context, event mashgate.WebhookEvent) error {
 	slog.Warn("payment failed", "paymentId", event.AggregateID)
 	bookingID := extractBookingID(event)
 	if bookingID == "" {
-		return
+		return nil
 	}
-	if err := h.Bookings.FailBooking(r.Context(), event.TenantID, bookingID); err != nil {
+	if err := h.Bookings.FailBooking(ctx, event.TenantID, bookingID); err != nil {
 		slog.Error("failed to mark booking as failed", "bookingId", bookingID, "err", err)
-	} else {
-		slog.Info("booking marked as failed", "bookingId", bookingID)
+		return fmt.Errorf("fail booking %s: %w", bookingID, err)
 	}
+	slog.Info("booking marked as failed", "bookingId", bookingID)
+	return nil
+}
+
+// onRefundSettled records a settled refund on its booking, which works out
+// from the refunded amount whether the payment is now partially or fully
+// refunded. An event without an amount is taken as a full refund.
+func (h *Handler) onRefundSettled(ctx context.Context, event mashgate.WebhookEvent) error {
+	slog.Info("refund settled", "paymentId", event.AggregateID)
+	bookingID := extractBookingID(event)
+	if bookingID == "" {
+		slog.Error("refund settled without a booking", "paymentId", event.AggregateID)
+		return errMissingBookingID
+	}
+	amount := extractRefundAmount(event)
+	err := h.Bookings.RecordRefund(ctx, event.TenantID, bookingID, amount)
+	if errors.Is(err, ErrStalePaymentStatus) {
+		slog.Info("stale refund ignored", "bookingId", bookingID)
+		return nil
+	}
+	if err != nil {
+		slog.Error("failed to record refund", "bookingId", bookingID, "amount", amount, "err", err)
+		return fmt.Errorf("record refund on %s: %w", bookingID, err)
+	}
+	return nil
+}
+
+// setPaymentStatus mirrors a payment-only event onto the booking. Captured and
+// failed are set by the confirm/fail transitions themselves. Late events that
+// would move the payment backwards are ignored.
+func (h *Handler) setPaymentStatus(ctx context.Context, event mashgate.WebhookEvent, status string) error {
+	bookingID := extractBookingID(event)
+	if bookingID == "" {
+		return nil
+	}
+	err := h.Bookings.SetPaymentStatus(ctx, event.TenantID, bookingID, status)
+	if errors.Is(err, ErrStalePaymentStatus) {
+		slog.Info("stale payment status ignored", "bookingId", bookingID, "paymentStatus", status)
+		return nil
+	}
+	if err != nil {
+		slog.Error("failed to set payment status", "bookingId", bookingID, "paymentStatus", status, "err", err)
+		return fmt.Errorf("set payment status %s on %s: %w", status, bookingID, err)
+	}
+	return nil
 }
 
 func extractBookingID(event mashgate.WebhookEvent) string {
@@ -118,3 +214,15 @@ func extractBookingID(event mashgate.WebhookEvent) string {
 	}
 	return payload.Metadata["bookingId"]
 }
+
+// extractRefundAmount returns the refunded amount of a refund event, or ""
+// when the event doesn't carry one.
+func extractRefundAmount(event mashgate.WebhookEvent) string {
+	var payload struct {
+		Amount mashgate.Money `json:"amount"`
+	}
+	if err := json.Unmarshal(event.Data, &payload); err != nil {
+		return ""
+	}
+	return payload.Amount.Amount
+}
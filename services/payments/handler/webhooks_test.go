@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	mashgate "github.com/saidmashhud/mashgate/packages/sdk-go"
+)
+
+// sign produces Mashgate's v1 webhook signature for body.
+func sign(secret, body string) (timestamp, signature string) {
+	timestamp = strconv.FormatInt(time.Now().UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + body))
+	return timestamp, "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignatureRotation(t *testing.T) {
+	now := time.Now()
+	h := New(nil, "new-secret", nil, nil).WithPreviousWebhookSecret("old-secret", now.Add(time.Hour))
+	body := `{"event_id":"evt_1","event_type":"payment.captured"}`
+
+	ts, sig := sign("new-secret", body)
+	if which, err := h.verifySignature(ts, body, sig, now); err != nil || which != "current" {
+		t.Errorf("current secret: got %q, %v", which, err)
+	}
+
+	ts, sig = sign("old-secret", body)
+	if which, err := h.verifySignature(ts, body, sig, now); err != nil || which != "previous" {
+		t.Errorf("previous secret during overlap: got %q, %v", which, err)
+	}
+	if _, err := h.verifySignature(ts, body, sig, now.Add(2*time.Hour)); err == nil {
+		t.Error("previous secret accepted after the overlap window")
+	}
+
+	ts, sig = sign("unknown", body)
+	if _, err := h.verifySignature(ts, body, sig, now); err == nil {
+		t.Error("unknown secret accepted")
+	}
+}
+
+func TestDispatchLateCapture(t *testing.T) {
+	status := http.StatusOK
+	bookings := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer bookings.Close()
+	h := New(nil, "secret", NewBookingsClient(bookings.URL, "token", nil), nil)
+	event := mashgate.WebhookEvent{
+		EventType:   mashgate.EventPaymentCaptured,
+		TenantID:    "t1",
+		AggregateID: "pay_1",
+		Data:        json.RawMessage(`{"metadata":{"bookingId":"b1"}}`),
+	}
+
+	// Bookings flagged the late capture for refund: handled, not dead-lettered.
+	if err := h.dispatch(context.Background(), event); err != nil {
+		t.Errorf("late capture: want handled, got %v", err)
+	}
+	status = http.StatusConflict
+	if err := h.dispatch(context.Background(), event); err == nil {
+		t.Error("409: want an error to dead-letter")
+	}
+}
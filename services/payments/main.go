@@ -14,6 +14,7 @@ import (
 	zistauth "github.com/saidmashhud/zist/internal/auth"
 	"github.com/saidmashhud/zist/internal/dedup"
 	"github.com/saidmashhud/zist/services/payments/handler"
+	"github.com/saidmashhud/zist/services/payments/store"
 )
 
 func main() {
@@ -40,7 +41,9 @@ func main() {
 	}
 
 	// Dedup store: PostgreSQL if DATABASE_URL is set, else in-memory.
+	// The dead-letter store is only available with a database.
 	var dedupStore handler.DedupChecker
+	var paymentsStore *store.Store
 	if cfg.DatabaseURL != "" {
 		db, err := sql.Open("postgres", cfg.DatabaseURL)
 		if err != nil {
@@ -55,6 +58,12 @@ func main() {
 		}
 		dedupStore = pgDedup
 		slog.Info("using PostgreSQL-backed dedup store")
+
+		if err := store.Migrate(db); err != nil {
+			slog.Error("migration failed", "err", err)
+			os.Exit(1)
+		}
+		paymentsStore = store.New(db)
 	} else {
 		dedupStore = dedup.New(24 * time.Hour)
 		slog.Warn("DATABASE_URL not set — using in-memory dedup (not crash-safe)")
@@ -71,8 +80,29 @@ func main() {
 
 	bc := handler.NewBookingsClient(cfg.BookingsURL, cfg.InternalToken, tokenClient)
 	h := handler.New(mg, cfg.WebhookSecret, bc, dedupStore)
+	if cfg.WebhookSecretPrevious != "" {
+		until, err := time.Parse(time.RFC3339, cfg.WebhookSecretPreviousUntil)
+		if err != nil {
+			slog.Error("MASHGATE_WEBHOOK_SECRET_PREVIOUS_UNTIL must be an RFC3339 time when MASHGATE_WEBHOOK_SECRET_PREVIOUS is set",
+				"value", cfg.WebhookSecretPreviousUntil)
+			os.Exit(1)
+		}
+		h.WithPreviousWebhookSecret(cfg.WebhookSecretPrevious, until)
+		slog.Info("accepting previous webhook secret during rotation", "until", until.Format(time.RFC3339))
+	}
+	if paymentsStore != nil {
+		h.WithDeadLetters(paymentsStore).WithCheckoutSessions(paymentsStore)
+	}
 	srv := &server{cfg: cfg, h: h}
 
+	if cfg.ReconcileIntervalSeconds > 0 {
+		go h.RunReconciler(context.Background(), handler.ReconcileConfig{
+			Interval:   time.Duration(cfg.ReconcileIntervalSeconds) * time.Second,
+			StaleAfter: time.Duration(cfg.ReconcileStaleMinutes) * time.Minute,
+			BatchSize:  cfg.ReconcileBatchSize,
+		})
+	}
+
 	slog.Info("Payments service starting",
 		"port", cfg.Port,
 		"mashgate", cfg.MashgateURL,
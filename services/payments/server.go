@@ -29,10 +29,15 @@ func (s *server) routes() http.Handler {
 	})
 
 	internal := zistauth.RequireServiceAuth(s.cfg.InternalToken, nil)
+	admin := chi.Chain(zistauth.RequireAuth, zistauth.RequireScope("zist.admin"))
 
 	r.With(zistauth.RequireScope("zist.payments.create")).Post("/checkout", s.h.CreateCheckout)
+	r.With(zistauth.RequireScope("zist.payments.create")).Post("/checkout/{bookingId}/resume", s.h.ResumeCheckout)
 	r.With(internal).Post("/refund", s.h.CreateRefund)
 	r.Post("/webhooks/mashgate", s.h.HandleWebhook)
 
+	r.With(admin...).Get("/admin/webhooks/dead-letters", s.h.ListDeadLetters)
+	r.With(admin...).Post("/admin/webhooks/dead-letters/{id}/reprocess", s.h.ReprocessDeadLetter)
+
 	return r
 }
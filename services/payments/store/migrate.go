@@ -0,0 +1,39 @@
+package store
+
+import "database/sql"
+
+// Migrate runs idempotent DDL for the payments service tables.
+func Migrate(db *sql.DB) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS webhook_dead_letters (
+			id          TEXT PRIMARY KEY,
+			event_id    TEXT   NOT NULL DEFAULT '',
+			event_type  TEXT   NOT NULL DEFAULT '',
+			tenant_id   TEXT   NOT NULL DEFAULT '',
+			body        TEXT   NOT NULL,
+			headers     JSONB  NOT NULL DEFAULT '{}',
+			reason      TEXT   NOT NULL DEFAULT '',
+			status      TEXT   NOT NULL DEFAULT 'pending',
+			attempts    INT    NOT NULL DEFAULT 0,
+			created_at  BIGINT NOT NULL,
+			updated_at  BIGINT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_dead_letters_status ON webhook_dead_letters(status, created_at DESC)`,
+		`CREATE TABLE IF NOT EXISTS checkout_sessions (
+			tenant_id    TEXT   NOT NULL,
+			key          TEXT   NOT NULL,
+			booking_id   TEXT   NOT NULL DEFAULT '',
+			session_id   TEXT   NOT NULL,
+			checkout_url TEXT   NOT NULL DEFAULT '',
+			expires_at   BIGINT NOT NULL,
+			created_at   BIGINT NOT NULL,
+			PRIMARY KEY (tenant_id, key)
+		)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
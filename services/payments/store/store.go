@@ -0,0 +1,168 @@
+// Package store implements PostgreSQL persistence for the payments service.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned when a requested resource does not exist.
+var ErrNotFound = errors.New("not found")
+
+// Dead-letter statuses.
+const (
+	DeadLetterPending     = "pending"
+	DeadLetterReprocessed = "reprocessed"
+)
+
+// DeadLetter is a webhook event that could not be processed.
+type DeadLetter struct {
+	ID        string            `json:"id"`
+	EventID   string            `json:"eventId,omitempty"`
+	EventType string            `json:"eventType,omitempty"`
+	TenantID  string            `json:"tenantId,omitempty"`
+	Body      string            `json:"body"`
+	Headers   map[string]string `json:"headers"`
+	Reason    string            `json:"reason"`
+	Status    string            `json:"status"` // pending|reprocessed
+	Attempts  int               `json:"attempts"`
+	CreatedAt int64             `json:"createdAt"`
+	UpdatedAt int64             `json:"updatedAt"`
+}
+
+// Store wraps a PostgreSQL connection.
+type Store struct {
+	db *sql.DB
+}
+
+// New creates a Store backed by db.
+func New(db *sql.DB) *Store { return &Store{db: db} }
+
+// ─── Dead letters ─────────────────────────────────────────────────────────────
+
+const deadLetterColumns = `id, event_id, event_type, tenant_id, body, headers,
+	reason, status, attempts, created_at, updated_at`
+
+func scanDeadLetter(scan func(...any) error) (DeadLetter, error) {
+	var d DeadLetter
+	var headersRaw []byte
+	err := scan(&d.ID, &d.EventID, &d.EventType, &d.TenantID, &d.Body, &headersRaw,
+		&d.Reason, &d.Status, &d.Attempts, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return d, err
+	}
+	json.Unmarshal(headersRaw, &d.Headers) //nolint:errcheck
+	if d.Headers == nil {
+		d.Headers = map[string]string{}
+	}
+	return d, nil
+}
+
+// AddDeadLetter stores a failed webhook event and returns its id.
+func (s *Store) AddDeadLetter(ctx context.Context, d DeadLetter) (string, error) {
+	id := uuid.NewString()
+	now := time.Now().Unix()
+	headers, _ := json.Marshal(d.Headers)
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO webhook_dead_letters
+			(id, event_id, event_type, tenant_id, body, headers, reason, status, attempts, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,0,$9,$9)`,
+		id, d.EventID, d.EventType, d.TenantID, d.Body, string(headers), d.Reason, DeadLetterPending, now)
+	return id, err
+}
+
+// ListDeadLetters returns dead letters newest first, optionally filtered by status.
+func (s *Store) ListDeadLetters(ctx context.Context, status string, limit int) ([]DeadLetter, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+deadLetterColumns+` FROM webhook_dead_letters
+		 WHERE ($1 = '' OR status = $1)
+		 ORDER BY created_at DESC LIMIT $2`, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []DeadLetter{}
+	for rows.Next() {
+		d, err := scanDeadLetter(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// GetDeadLetter returns a single dead letter. Returns ErrNotFound if absent.
+func (s *Store) GetDeadLetter(ctx context.Context, id string) (DeadLetter, error) {
+	d, err := scanDeadLetter(s.db.QueryRowContext(ctx,
+		`SELECT `+deadLetterColumns+` FROM webhook_dead_letters WHERE id = $1`, id).Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return d, ErrNotFound
+	}
+	return d, err
+}
+
+// RecordAttempt bumps the attempt counter after a reprocess. A nil procErr
+// marks the dead letter reprocessed; otherwise the reason is updated.
+func (s *Store) RecordAttempt(ctx context.Context, id string, procErr error) error {
+	status, reason := DeadLetterReprocessed, ""
+	if procErr != nil {
+		status, reason = DeadLetterPending, procErr.Error()
+	}
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_dead_letters
+		SET status = $1, reason = CASE WHEN $2 = '' THEN reason ELSE $2 END,
+		    attempts = attempts + 1, updated_at = $3
+		WHERE id = $4`,
+		status, reason, time.Now().Unix(), id)
+	return err
+}
+
+// ─── Checkout sessions ────────────────────────────────────────────────────────
+
+// CheckoutSession is a Mashgate checkout session remembered under its
+// booking so a retried checkout can hand back the same session.
+type CheckoutSession struct {
+	TenantID    string
+	Key         string // the booking id
+	BookingID   string
+	SessionID   string
+	CheckoutURL string
+	ExpiresAt   int64
+	CreatedAt   int64
+}
+
+// GetCheckoutSession returns the session stored under key.
+// Returns ErrNotFound if absent.
+func (s *Store) GetCheckoutSession(ctx context.Context, tenantID, key string) (CheckoutSession, error) {
+	cs := CheckoutSession{TenantID: tenantID, Key: key}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT booking_id, session_id, checkout_url, expires_at, created_at
+		FROM checkout_sessions WHERE tenant_id = $1 AND key = $2`,
+		tenantID, key,
+	).Scan(&cs.BookingID, &cs.SessionID, &cs.CheckoutURL, &cs.ExpiresAt, &cs.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return cs, ErrNotFound
+	}
+	return cs, err
+}
+
+// PutCheckoutSession stores cs under its key, replacing any earlier session.
+func (s *Store) PutCheckoutSession(ctx context.Context, cs CheckoutSession) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO checkout_sessions
+			(tenant_id, key, booking_id, session_id, checkout_url, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (tenant_id, key) DO UPDATE
+		SET booking_id = EXCLUDED.booking_id, session_id = EXCLUDED.session_id,
+		    checkout_url = EXCLUDED.checkout_url, expires_at = EXCLUDED.expires_at,
+		    created_at = EXCLUDED.created_at`,
+		cs.TenantID, cs.Key, cs.BookingID, cs.SessionID, cs.CheckoutURL, cs.ExpiresAt, cs.CreatedAt)
+	return err
+}
@@ -8,6 +8,7 @@ type Config struct {
 	DatabaseURL   string
 	ListingsURL   string
 	InternalToken string
+	AdminURL      string // admin service for tenant review settings (optional)
 
 	// Service JWT auth (optional; if set, JWT is preferred over InternalToken)
 	AuthServiceURL string
@@ -22,6 +23,7 @@ func LoadConfig() *Config {
 		DatabaseURL:   httputil.Getenv("DATABASE_URL", "postgres://dev:dev@db:5432/zist?sslmode=disable"),
 		ListingsURL:   httputil.Getenv("LISTINGS_SERVICE_URL", "http://listings:8001"),
 		InternalToken: httputil.Getenv("INTERNAL_TOKEN", ""),
+		AdminURL:      httputil.Getenv("ADMIN_URL", ""),
 
 		AuthServiceURL: httputil.Getenv("AUTH_SERVICE_URL", ""),
 		AuthServiceKey: httputil.Getenv("AUTH_SERVICE_KEY", ""),
@@ -1,6 +1,15 @@
 // Package domain defines the Review entity and related types.
 package domain
 
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
 // Review represents a guest's review of a completed stay.
 type Review struct {
 	ID        string  `json:"id"`
@@ -9,11 +18,46 @@ type Review struct {
 	GuestID   string  `json:"guestId"`
 	HostID    string  `json:"hostId"`
 	TenantID  string  `json:"tenantId"`
-	Rating    int     `json:"rating"`   // 1–5
+	Rating    int     `json:"rating"` // 1–5
 	Comment   string  `json:"comment"`
 	Reply     string  `json:"reply,omitempty"` // host reply
-	CreatedAt int64   `json:"createdAt"`
-	UpdatedAt int64   `json:"updatedAt"`
+	Photos    []Photo `json:"photos"`
+	// HelpfulCount is how many users marked the review helpful.
+	HelpfulCount int   `json:"helpfulCount"`
+	CreatedAt    int64 `json:"createdAt"`
+	UpdatedAt    int64 `json:"updatedAt"`
+	// Listing is embedded in the host's received-reviews view.
+	Listing *ListingRef `json:"listing,omitempty"`
+}
+
+// ListingRef identifies the listing a review belongs to.
+type ListingRef struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// HostSummary aggregates the ratings a host has received across all their
+// listings. Breakdown counts reviews per star rating (1–5).
+type HostSummary struct {
+	HostID        string      `json:"hostId"`
+	AverageRating float64     `json:"averageRating"`
+	ReviewCount   int         `json:"reviewCount"`
+	Breakdown     map[int]int `json:"breakdown"`
+}
+
+// NewHostSummary builds a summary from per-rating review counts.
+func NewHostSummary(hostID string, countsByRating map[int]int) HostSummary {
+	s := HostSummary{HostID: hostID, Breakdown: map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}}
+	sum := 0
+	for rating, n := range countsByRating {
+		s.Breakdown[rating] = n
+		s.ReviewCount += n
+		sum += rating * n
+	}
+	if s.ReviewCount > 0 {
+		s.AverageRating = math.Round(float64(sum)/float64(s.ReviewCount)*100) / 100
+	}
+	return s
 }
 
 // CreateReviewInput holds the fields required to create a review.
@@ -25,4 +69,48 @@ type CreateReviewInput struct {
 	TenantID  string
 	Rating    int
 	Comment   string
+	Photos    []Photo
+}
+
+// Validate checks a new review's rating, comment and photos. The comment,
+// whitespace trimmed, must have at least minCommentLength characters; 0
+// allows an empty comment.
+func (in CreateReviewInput) Validate(minCommentLength int) error {
+	if in.Rating < 1 || in.Rating > 5 {
+		return errors.New("rating must be between 1 and 5")
+	}
+	if n := utf8.RuneCountInString(strings.TrimSpace(in.Comment)); n < minCommentLength {
+		return fmt.Errorf("comment must be at least %d characters", minCommentLength)
+	}
+	return ValidatePhotos(in.Photos)
+}
+
+// Photo is an image a guest attached to their review, in upload order.
+type Photo struct {
+	URL     string `json:"url"`
+	Caption string `json:"caption"`
+}
+
+// Review photo limits.
+const (
+	MaxReviewPhotos = 10
+	MaxPhotoCaption = 200
+)
+
+// ValidatePhotos checks photos attached to a new review: at most
+// MaxReviewPhotos, each an absolute http(s) URL with a short caption.
+func ValidatePhotos(photos []Photo) error {
+	if len(photos) > MaxReviewPhotos {
+		return fmt.Errorf("at most %d photos per review", MaxReviewPhotos)
+	}
+	for i, p := range photos {
+		u, err := url.Parse(p.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("photos[%d].url must be an absolute http(s) URL", i)
+		}
+		if len(p.Caption) > MaxPhotoCaption {
+			return fmt.Errorf("photos[%d].caption must be at most %d characters", i, MaxPhotoCaption)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,76 @@
+package domain
+
+import "testing"
+
+func TestNewHostSummary(t *testing.T) {
+	s := NewHostSummary("host-1", map[int]int{5: 2, 4: 1})
+	if s.ReviewCount != 3 {
+		t.Errorf("reviewCount: want 3, got %d", s.ReviewCount)
+	}
+	if s.AverageRating != 4.67 {
+		t.Errorf("averageRating: want 4.67, got %v", s.AverageRating)
+	}
+	if len(s.Breakdown) != 5 || s.Breakdown[1] != 0 || s.Breakdown[5] != 2 {
+		t.Errorf("breakdown: want all five ratings with 5★=2, got %v", s.Breakdown)
+	}
+
+	empty := NewHostSummary("host-2", nil)
+	if empty.ReviewCount != 0 || empty.AverageRating != 0 || len(empty.Breakdown) != 5 {
+		t.Errorf("no reviews: want zero summary with empty breakdown, got %+v", empty)
+	}
+}
+
+func TestValidatePhotos(t *testing.T) {
+	two := []Photo{
+		{URL: "https://cdn.example.com/a.jpg", Caption: "view"},
+		{URL: "http://cdn.example.com/b.jpg"},
+	}
+	if err := ValidatePhotos(two); err != nil {
+		t.Errorf("two valid photos: %v", err)
+	}
+	if err := ValidatePhotos(nil); err != nil {
+		t.Errorf("no photos: %v", err)
+	}
+
+	tooMany := make([]Photo, MaxReviewPhotos+1)
+	for i := range tooMany {
+		tooMany[i] = Photo{URL: "https://cdn.example.com/x.jpg"}
+	}
+	bad := map[string][]Photo{
+		"too many":         tooMany,
+		"relative url":     {{URL: "/uploads/a.jpg"}},
+		"javascript url":   {{URL: "javascript:alert(1)"}},
+		"empty url":        {{URL: ""}},
+		"caption too long": {{URL: "https://cdn.example.com/a.jpg", Caption: string(make([]byte, MaxPhotoCaption+1))}},
+	}
+	for name, photos := range bad {
+		if err := ValidatePhotos(photos); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
+
+func TestCreateReviewInputValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		comment string
+		minLen  int
+		ok      bool
+	}{
+		{"too short", "ok", 10, false},
+		{"padding doesn't count", "   ok      ", 10, false},
+		{"long enough", "Lovely flat, great host", 10, true},
+		{"multibyte counted as characters", "Отлично", 10, false},
+		{"empty allowed at zero", "", 0, true},
+		{"empty rejected otherwise", "", 1, false},
+	}
+	for _, tt := range tests {
+		in := CreateReviewInput{Rating: 5, Comment: tt.comment}
+		if err := in.Validate(tt.minLen); (err == nil) != tt.ok {
+			t.Errorf("%s: Validate err = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+	if err := (CreateReviewInput{Rating: 6, Comment: "Lovely flat, great host"}).Validate(0); err == nil {
+		t.Error("rating 6 should be rejected")
+	}
+}
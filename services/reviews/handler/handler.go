@@ -3,13 +3,17 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strings"
 
 	zistauth "github.com/saidmashhud/zist/internal/auth"
 	"github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/internal/tenantconfig"
 	"github.com/saidmashhud/zist/services/reviews/store"
 )
 
@@ -19,11 +23,28 @@ type Handler struct {
 	ListingsURL   string
 	InternalToken string
 	TokenClient   *zistauth.ServiceTokenClient
+	Tenants       *tenantconfig.Client // review visibility; nil unless ADMIN_URL is set
+	summaries     *summaryCache
 }
 
 // New creates a Handler.
 func New(s *store.Store, listingsURL, internalToken string, tokenClient *zistauth.ServiceTokenClient) *Handler {
-	return &Handler{Store: s, ListingsURL: listingsURL, InternalToken: internalToken, TokenClient: tokenClient}
+	return &Handler{
+		Store:         s,
+		ListingsURL:   listingsURL,
+		InternalToken: internalToken,
+		TokenClient:   tokenClient,
+		summaries:     newSummaryCache(hostSummaryTTL),
+	}
+}
+
+// WithTenantConfig reads per-tenant review settings (publicReviews) from the
+// admin service. Without it listing reviews are always public.
+func (h *Handler) WithTenantConfig(adminURL, internalToken string) *Handler {
+	if adminURL != "" {
+		h.Tenants = tenantconfig.New(adminURL, internalToken)
+	}
+	return h
 }
 
 // setAuth sets the appropriate auth header on the request.
@@ -60,6 +81,60 @@ func (h *Handler) updateListingStats(listingID string, avg float64, count int) {
 	resp.Body.Close()
 }
 
+// listingInfo is what the reviews service needs to know about a listing.
+type listingInfo struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	HostID string `json:"hostId"`
+}
+
+// listingsByID batch-fetches the tenant's listings from the listings
+// service, keyed by ID. IDs it doesn't return are absent from the map.
+func (h *Handler) listingsByID(ctx context.Context, tenantID string, ids []string) (map[string]listingInfo, error) {
+	q := url.Values{}
+	q.Set("ids", strings.Join(ids, ","))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/listings/batch?%s", h.ListingsURL, q.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	h.setAuth(req)
+	req.Header.Set("X-Tenant-ID", tenantID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("listings service unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("listings service returned %d", resp.StatusCode)
+	}
+
+	var raw struct {
+		Listings []listingInfo `json:"listings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("decode listings batch: %w", err)
+	}
+	listings := make(map[string]listingInfo, len(raw.Listings))
+	for _, l := range raw.Listings {
+		listings[l.ID] = l
+	}
+	return listings, nil
+}
+
+// listingTitles batch-fetches titles for listing IDs, keyed by ID.
+func (h *Handler) listingTitles(ctx context.Context, tenantID string, ids []string) (map[string]string, error) {
+	listings, err := h.listingsByID(ctx, tenantID, ids)
+	if err != nil {
+		return nil, err
+	}
+	titles := make(map[string]string, len(listings))
+	for id, l := range listings {
+		titles[id] = l.Title
+	}
+	return titles, nil
+}
+
 // tenantFromRequest extracts tenant_id from the request context.
 func tenantFromRequest(r *http.Request) string {
 	if p := zistauth.FromContext(r.Context()); p != nil && p.TenantID != "" {
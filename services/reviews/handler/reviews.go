@@ -1,12 +1,16 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	zistauth "github.com/saidmashhud/zist/internal/auth"
 	"github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/internal/tenantconfig"
 	"github.com/saidmashhud/zist/services/reviews/domain"
 	"github.com/saidmashhud/zist/services/reviews/store"
 )
@@ -20,11 +24,11 @@ func (h *Handler) CreateReview(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		BookingID string `json:"bookingId"`
-		ListingID string `json:"listingId"`
-		HostID    string `json:"hostId"`
-		Rating    int    `json:"rating"`
-		Comment   string `json:"comment"`
+		BookingID string         `json:"bookingId"`
+		ListingID string         `json:"listingId"`
+		Rating    int            `json:"rating"`
+		Comment   string         `json:"comment"`
+		Photos    []domain.Photo `json:"photos"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
@@ -34,20 +38,35 @@ func (h *Handler) CreateReview(w http.ResponseWriter, r *http.Request) {
 		httputil.WriteError(w, http.StatusUnprocessableEntity, "bookingId and listingId are required")
 		return
 	}
-	if req.Rating < 1 || req.Rating > 5 {
-		httputil.WriteError(w, http.StatusUnprocessableEntity, "rating must be between 1 and 5")
-		return
-	}
-
-	rev, err := h.Store.Create(r.Context(), domain.CreateReviewInput{
+	in := domain.CreateReviewInput{
 		BookingID: req.BookingID,
 		ListingID: req.ListingID,
 		GuestID:   p.UserID,
-		HostID:    req.HostID,
 		TenantID:  p.TenantID,
 		Rating:    req.Rating,
 		Comment:   req.Comment,
-	})
+		Photos:    req.Photos,
+	}
+	if err := in.Validate(h.minReviewLength(r.Context(), p.TenantID)); err != nil {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	// The host is the listing's, never the client's say: host summaries and
+	// received reviews aggregate on it.
+	listings, err := h.listingsByID(r.Context(), p.TenantID, []string{req.ListingID})
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, "could not reach listings service")
+		return
+	}
+	listing, ok := listings[req.ListingID]
+	if !ok || listing.HostID == "" {
+		httputil.WriteError(w, http.StatusUnprocessableEntity, "listing not found")
+		return
+	}
+	in.HostID = listing.HostID
+
+	rev, err := h.Store.Create(r.Context(), in)
 	if err == store.ErrAlreadyReviewed {
 		httputil.WriteError(w, http.StatusConflict, "booking already reviewed")
 		return
@@ -57,6 +76,8 @@ func (h *Handler) CreateReview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.summaries.evict(rev.TenantID, rev.HostID)
+
 	// Fire-and-forget: update listing's aggregate rating
 	avg, count, _ := h.Store.RatingSummary(r.Context(), req.ListingID)
 	go h.updateListingStats(req.ListingID, avg, count)
@@ -64,7 +85,9 @@ func (h *Handler) CreateReview(w http.ResponseWriter, r *http.Request) {
 	httputil.WriteJSON(w, http.StatusCreated, rev)
 }
 
-// ListReviewsByListing handles GET /reviews/listing/{id}.
+// ListReviewsByListing handles GET /reviews/listing/{id}. ?sort=helpful
+// orders by helpful votes instead of recency. Tenants with publicReviews off
+// require a signed-in caller.
 func (h *Handler) ListReviewsByListing(w http.ResponseWriter, r *http.Request) {
 	listingID := chi.URLParam(r, "id")
 	limit := 50
@@ -73,12 +96,24 @@ func (h *Handler) ListReviewsByListing(w http.ResponseWriter, r *http.Request) {
 			limit = n
 		}
 	}
+	sort := r.URL.Query().Get("sort")
+	if sort != "" && sort != store.SortRecent && sort != store.SortHelpful {
+		httputil.WriteError(w, http.StatusBadRequest, "sort must be recent or helpful")
+		return
+	}
 
-	reviews, err := h.Store.ListByListing(r.Context(), listingID, limit)
+	reviews, err := h.Store.ListByListing(r.Context(), listingID, limit, sort)
 	if err != nil {
 		httputil.WriteError(w, http.StatusInternalServerError, "db query failed")
 		return
 	}
+	// The tenant comes from the reviews themselves, so anonymous callers
+	// can't pick a more permissive one.
+	if len(reviews) > 0 && !h.publicReviews(r.Context(), reviews[0].TenantID) {
+		if requireAuth(w, r) == nil {
+			return
+		}
+	}
 	httputil.WriteJSON(w, http.StatusOK, map[string]any{"reviews": reviews})
 }
 
@@ -97,6 +132,62 @@ func (h *Handler) ListMyReviews(w http.ResponseWriter, r *http.Request) {
 	httputil.WriteJSON(w, http.StatusOK, map[string]any{"reviews": reviews})
 }
 
+// ListHostReviews handles GET /reviews/host — reviews received by the
+// authenticated host across all their listings, newest first. Each review
+// carries its listing's id and title. Paged with ?limit= (default 20, max
+// 100) and ?offset=.
+func (h *Handler) ListHostReviews(w http.ResponseWriter, r *http.Request) {
+	p := requireAuth(w, r)
+	if p == nil {
+		return
+	}
+	q := r.URL.Query()
+	if hostID := q.Get("host_id"); hostID != "" && hostID != p.UserID {
+		httputil.WriteError(w, http.StatusForbidden, "hosts can only read their own received reviews")
+		return
+	}
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	reviews, total, err := h.Store.ListByHost(r.Context(), p.TenantID, p.UserID, limit, offset)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db query failed")
+		return
+	}
+
+	if len(reviews) > 0 {
+		seen := map[string]bool{}
+		var ids []string
+		for _, rev := range reviews {
+			if !seen[rev.ListingID] {
+				seen[rev.ListingID] = true
+				ids = append(ids, rev.ListingID)
+			}
+		}
+		// Titles are decoration: serve the reviews without them if listings is down.
+		titles, err := h.listingTitles(r.Context(), p.TenantID, ids)
+		if err != nil {
+			slog.Warn("listing titles unavailable for host reviews", "err", err)
+		}
+		for i := range reviews {
+			reviews[i].Listing = &domain.ListingRef{ID: reviews[i].ListingID, Title: titles[reviews[i].ListingID]}
+		}
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{
+		"reviews": reviews,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
 // ReplyToReview handles POST /reviews/{id}/reply — host replies to a review.
 func (h *Handler) ReplyToReview(w http.ResponseWriter, r *http.Request) {
 	p := requireAuth(w, r)
@@ -124,3 +215,52 @@ func (h *Handler) ReplyToReview(w http.ResponseWriter, r *http.Request) {
 	}
 	httputil.WriteJSON(w, http.StatusOK, rev)
 }
+
+// publicReviews reports whether anonymous callers may read the tenant's
+// reviews. If the setting can't be read, reviews stay public.
+func (h *Handler) publicReviews(ctx context.Context, tenantID string) bool {
+	if h.Tenants == nil {
+		return true
+	}
+	cfg, err := h.Tenants.Get(ctx, tenantID)
+	if err != nil {
+		slog.Warn("tenant config unavailable; serving reviews publicly", "tenantId", tenantID, "err", err)
+		return true
+	}
+	return cfg.PublicReviews
+}
+
+// minReviewLength returns the tenant's minimum comment length, or the
+// default if it can't be read.
+func (h *Handler) minReviewLength(ctx context.Context, tenantID string) int {
+	if h.Tenants == nil {
+		return tenantconfig.DefaultMinReviewLength
+	}
+	cfg, err := h.Tenants.Get(ctx, tenantID)
+	if err != nil {
+		slog.Warn("tenant config unavailable; using default review length", "tenantId", tenantID, "err", err)
+		return tenantconfig.DefaultMinReviewLength
+	}
+	return cfg.MinReviewLength
+}
+
+// GetReviewByBooking handles GET /reviews/internal/booking/{bookingId} for
+// other services, e.g. bookings deciding whether a guest can still review.
+// The tenant comes from X-Tenant-ID, trusted after service auth.
+func (h *Handler) GetReviewByBooking(w http.ResponseWriter, r *http.Request) {
+	p := zistauth.RequestPrincipal(r)
+	if p == nil || p.TenantID == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "tenant_id is required")
+		return
+	}
+	rev, err := h.Store.GetByBooking(r.Context(), p.TenantID, chi.URLParam(r, "bookingId"))
+	if err == store.ErrNotFound {
+		httputil.WriteError(w, http.StatusNotFound, "review not found")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, rev)
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	zistauth "github.com/saidmashhud/zist/internal/auth"
+	"github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/services/reviews/domain"
+)
+
+// hostSummaryTTL bounds how stale a cached host summary can be. New reviews
+// evict their host's entry, so this mostly matters across replicas.
+const hostSummaryTTL = time.Minute
+
+// summaryCache memoises host summaries; listing pages read them constantly.
+// Entries are keyed by tenant and host, since a host's reviews in one tenant
+// say nothing about another.
+type summaryCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cachedSummary
+}
+
+type cachedSummary struct {
+	summary domain.HostSummary
+	expires time.Time
+}
+
+func newSummaryCache(ttl time.Duration) *summaryCache {
+	return &summaryCache{ttl: ttl, entries: make(map[string]cachedSummary)}
+}
+
+func summaryKey(tenantID, hostID string) string { return tenantID + "/" + hostID }
+
+func (c *summaryCache) get(tenantID, hostID string) (domain.HostSummary, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[summaryKey(tenantID, hostID)]
+	if !ok || time.Now().After(e.expires) {
+		return domain.HostSummary{}, false
+	}
+	return e.summary, true
+}
+
+func (c *summaryCache) put(tenantID string, s domain.HostSummary) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for key, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, key)
+		}
+	}
+	c.entries[summaryKey(tenantID, s.HostID)] = cachedSummary{summary: s, expires: now.Add(c.ttl)}
+}
+
+func (c *summaryCache) evict(tenantID, hostID string) {
+	c.mu.Lock()
+	delete(c.entries, summaryKey(tenantID, hostID))
+	c.mu.Unlock()
+}
+
+// HostSummary handles GET /reviews/host/{hostId}/summary — the host's average
+// rating, review count and per-star breakdown across all their listings.
+// Public, like per-listing reviews. The tenant is the caller's; for anonymous
+// reads such as listing detail it comes from the host's reviews themselves,
+// since the gateway strips X-Tenant-ID from unauthenticated requests.
+func (h *Handler) HostSummary(w http.ResponseWriter, r *http.Request) {
+	hostID := chi.URLParam(r, "hostId")
+	tenantID := ""
+	if p := zistauth.RequestPrincipal(r); p != nil {
+		tenantID = strings.TrimSpace(p.TenantID)
+	} else {
+		var err error
+		if tenantID, err = h.Store.HostTenant(r.Context(), hostID); err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, "db query failed")
+			return
+		}
+		if tenantID == "" {
+			httputil.WriteJSON(w, http.StatusOK, domain.NewHostSummary(hostID, nil))
+			return
+		}
+	}
+	if tenantID == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "tenant_id is required")
+		return
+	}
+	if s, ok := h.summaries.get(tenantID, hostID); ok {
+		httputil.WriteJSON(w, http.StatusOK, s)
+		return
+	}
+	counts, err := h.Store.HostRatingCounts(r.Context(), tenantID, hostID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db query failed")
+		return
+	}
+	s := domain.NewHostSummary(hostID, counts)
+	h.summaries.put(tenantID, s)
+	httputil.WriteJSON(w, http.StatusOK, s)
+}
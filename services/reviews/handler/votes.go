@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/services/reviews/store"
+)
+
+// MarkHelpful handles POST /reviews/{id}/helpful — the caller marks a review
+// helpful. Repeating the vote changes nothing; authors can't vote on their
+// own reviews, and only reviews in the caller's tenant can be voted on.
+func (h *Handler) MarkHelpful(w http.ResponseWriter, r *http.Request) {
+	h.vote(w, r, true)
+}
+
+// UnmarkHelpful handles DELETE /reviews/{id}/helpful — the caller withdraws
+// their helpful vote, if they had one.
+func (h *Handler) UnmarkHelpful(w http.ResponseWriter, r *http.Request) {
+	h.vote(w, r, false)
+}
+
+func (h *Handler) vote(w http.ResponseWriter, r *http.Request, helpful bool) {
+	p := requireAuth(w, r)
+	if p == nil {
+		return
+	}
+	reviewID := chi.URLParam(r, "id")
+
+	rev, err := h.Store.GetForTenant(r.Context(), p.TenantID, reviewID)
+	if errors.Is(err, store.ErrNotFound) {
+		httputil.WriteError(w, http.StatusNotFound, "review not found")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if rev.GuestID == p.UserID {
+		httputil.WriteError(w, http.StatusForbidden, "you can't vote on your own review")
+		return
+	}
+
+	var count int
+	if helpful {
+		count, err = h.Store.Vote(r.Context(), reviewID, p.UserID, p.TenantID)
+	} else {
+		count, err = h.Store.Unvote(r.Context(), reviewID, p.UserID)
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		httputil.WriteError(w, http.StatusNotFound, "review not found")
+		return
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to record vote")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{
+		"reviewId":     reviewID,
+		"helpfulCount": count,
+		"helpful":      helpful,
+	})
+}
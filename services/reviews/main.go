@@ -57,7 +57,8 @@ func main() {
 		slog.Info("service JWT auth enabled", "authService", cfg.AuthServiceURL)
 	}
 
-	h := handler.New(store.New(db), cfg.ListingsURL, cfg.InternalToken, tokenClient)
+	h := handler.New(store.New(db), cfg.ListingsURL, cfg.InternalToken, tokenClient).
+		WithTenantConfig(cfg.AdminURL, cfg.InternalToken)
 	srv := &server{cfg: cfg, h: h}
 
 	slog.Info("reviews service starting", "port", cfg.Port)
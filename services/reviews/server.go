@@ -29,15 +29,23 @@ func (s *server) routes() http.Handler {
 	})
 
 	authMW := chi.Chain(zistauth.RequireAuth)
+	internal := chi.Chain(zistauth.RequireServiceAuth(s.cfg.InternalToken, nil))
 
 	r.Route("/reviews", func(r chi.Router) {
-		// Public: list reviews for a listing
+		// Public: list reviews for a listing, host rating summary
 		r.Get("/listing/{id}", s.h.ListReviewsByListing)
+		r.Get("/host/{hostId}/summary", s.h.HostSummary)
 
-		// Authenticated: create review, view own reviews, reply
+		// Authenticated: create review, view own and received reviews, reply, vote
 		r.With(authMW...).Post("/", s.h.CreateReview)
 		r.With(authMW...).Get("/my", s.h.ListMyReviews)
+		r.With(authMW...).Get("/host", s.h.ListHostReviews)
 		r.With(authMW...).Post("/{id}/reply", s.h.ReplyToReview)
+		r.With(authMW...).Post("/{id}/helpful", s.h.MarkHelpful)
+		r.With(authMW...).Delete("/{id}/helpful", s.h.UnmarkHelpful)
+
+		// Internal (called by bookings service)
+		r.With(internal...).Get("/internal/booking/{bookingId}", s.h.GetReviewByBooking)
 	})
 
 	return r
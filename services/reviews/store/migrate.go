@@ -26,6 +26,7 @@ func Migrate(db *sql.DB) error {
 
 	addCols := []string{
 		`ALTER TABLE reviews ADD COLUMN IF NOT EXISTS reply TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE reviews ADD COLUMN IF NOT EXISTS helpful_count INT NOT NULL DEFAULT 0`,
 	}
 	for _, col := range addCols {
 		if _, err := db.Exec(col); err != nil {
@@ -38,5 +39,40 @@ func Migrate(db *sql.DB) error {
 		return err
 	}
 	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_reviews_guest ON reviews (tenant_id, guest_id, created_at DESC)`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_reviews_host ON reviews (tenant_id, host_id, created_at DESC)`)
+	if err != nil {
+		return err
+	}
+
+	// Photos guests attach to a review; they go wherever the review goes.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS review_photos (
+			id         TEXT PRIMARY KEY,
+			review_id  TEXT NOT NULL REFERENCES reviews(id) ON DELETE CASCADE,
+			position   INT  NOT NULL,
+			url        TEXT NOT NULL,
+			caption    TEXT NOT NULL DEFAULT '',
+			created_at BIGINT NOT NULL,
+			UNIQUE (review_id, position)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// One helpful vote per user per review; reviews.helpful_count mirrors
+	// the row count so listings can sort by it cheaply.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS review_votes (
+			review_id  TEXT NOT NULL REFERENCES reviews(id) ON DELETE CASCADE,
+			voter_id   TEXT NOT NULL,
+			tenant_id  TEXT NOT NULL DEFAULT '',
+			created_at BIGINT NOT NULL,
+			PRIMARY KEY (review_id, voter_id)
+		)
+	`)
 	return err
 }
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/saidmashhud/zist/services/reviews/domain"
 )
 
@@ -17,6 +18,12 @@ var ErrNotFound = errors.New("not found")
 // ErrAlreadyReviewed is returned when a booking already has a review.
 var ErrAlreadyReviewed = errors.New("booking already reviewed")
 
+// Listing review orders for ListByListing.
+const (
+	SortRecent  = "recent"
+	SortHelpful = "helpful"
+)
+
 // Store wraps a PostgreSQL connection and provides typed review queries.
 type Store struct {
 	db *sql.DB
@@ -30,17 +37,24 @@ func scanReview(scan func(dest ...any) error) (domain.Review, error) {
 	return r, scan(
 		&r.ID, &r.BookingID, &r.ListingID,
 		&r.GuestID, &r.HostID, &r.TenantID,
-		&r.Rating, &r.Comment, &r.Reply,
+		&r.Rating, &r.Comment, &r.Reply, &r.HelpfulCount,
 		&r.CreatedAt, &r.UpdatedAt,
 	)
 }
 
-// Create inserts a new review. Returns ErrAlreadyReviewed if the booking already has one.
+// Create inserts a new review and its photos in one transaction. Returns
+// ErrAlreadyReviewed if the booking already has one.
 func (s *Store) Create(ctx context.Context, in domain.CreateReviewInput) (domain.Review, error) {
 	id := uuid.NewString()
 	now := time.Now().Unix()
 
-	_, err := s.db.ExecContext(ctx, `
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Review{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	_, err = tx.ExecContext(ctx, `
 		INSERT INTO reviews
 			(id, booking_id, listing_id, guest_id, host_id, tenant_id, rating, comment, created_at, updated_at)
 		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)`,
@@ -54,48 +68,119 @@ func (s *Store) Create(ctx context.Context, in domain.CreateReviewInput) (domain
 		}
 		return domain.Review{}, err
 	}
+	for i, p := range in.Photos {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO review_photos (id, review_id, position, url, caption, created_at)
+			VALUES ($1,$2,$3,$4,$5,$6)`,
+			uuid.NewString(), id, i, p.URL, p.Caption, now,
+		); err != nil {
+			return domain.Review{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return domain.Review{}, err
+	}
 	return s.GetByID(ctx, id)
 }
 
 // GetByID returns a review by its ID.
 func (s *Store) GetByID(ctx context.Context, id string) (domain.Review, error) {
-	row := s.db.QueryRowContext(ctx,
-		`SELECT id,booking_id,listing_id,guest_id,host_id,tenant_id,rating,comment,reply,created_at,updated_at
-		 FROM reviews WHERE id=$1`, id)
+	return s.get(ctx, s.db.QueryRowContext(ctx,
+		`SELECT id,booking_id,listing_id,guest_id,host_id,tenant_id,rating,comment,reply,helpful_count,created_at,updated_at
+		 FROM reviews WHERE id=$1`, id))
+}
+
+// GetForTenant returns a review by its ID within a tenant; reviews of other
+// tenants are ErrNotFound.
+func (s *Store) GetForTenant(ctx context.Context, tenantID, id string) (domain.Review, error) {
+	return s.get(ctx, s.db.QueryRowContext(ctx,
+		`SELECT id,booking_id,listing_id,guest_id,host_id,tenant_id,rating,comment,reply,helpful_count,created_at,updated_at
+		 FROM reviews WHERE tenant_id=$1 AND id=$2`, tenantID, id))
+}
+
+// get scans a single review row and loads its photos.
+func (s *Store) get(ctx context.Context, row *sql.Row) (domain.Review, error) {
 	r, err := scanReview(row.Scan)
 	if errors.Is(err, sql.ErrNoRows) {
 		return r, ErrNotFound
 	}
-	return r, err
+	if err != nil {
+		return r, err
+	}
+	reviews := []domain.Review{r}
+	if err := s.attachPhotos(ctx, reviews); err != nil {
+		return r, err
+	}
+	return reviews[0], nil
+}
+
+// GetByBooking returns the review left for a booking within a tenant.
+func (s *Store) GetByBooking(ctx context.Context, tenantID, bookingID string) (domain.Review, error) {
+	var id string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id FROM reviews WHERE tenant_id=$1 AND booking_id=$2`, tenantID, bookingID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Review{}, ErrNotFound
+	}
+	if err != nil {
+		return domain.Review{}, err
+	}
+	return s.GetByID(ctx, id)
 }
 
-// ListByListing returns all reviews for a listing, newest first.
-func (s *Store) ListByListing(ctx context.Context, listingID string, limit int) ([]domain.Review, error) {
+// ListByListing returns reviews for a listing, newest first, or with
+// SortHelpful the most helpful first (ties newest first).
+func (s *Store) ListByListing(ctx context.Context, listingID string, limit int, sort string) ([]domain.Review, error) {
 	if limit <= 0 || limit > 100 {
 		limit = 50
 	}
+	order := "created_at DESC"
+	if sort == SortHelpful {
+		order = "helpful_count DESC, created_at DESC"
+	}
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT id,booking_id,listing_id,guest_id,host_id,tenant_id,rating,comment,reply,created_at,updated_at
-		 FROM reviews WHERE listing_id=$1 ORDER BY created_at DESC LIMIT $2`,
+		`SELECT id,booking_id,listing_id,guest_id,host_id,tenant_id,rating,comment,reply,helpful_count,created_at,updated_at
+		 FROM reviews WHERE listing_id=$1 ORDER BY `+order+` LIMIT $2`,
 		listingID, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	return collectReviews(rows)
+	return s.collectWithPhotos(ctx, rows)
 }
 
 // ListByGuest returns reviews written by a guest within a tenant.
 func (s *Store) ListByGuest(ctx context.Context, tenantID, guestID string) ([]domain.Review, error) {
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT id,booking_id,listing_id,guest_id,host_id,tenant_id,rating,comment,reply,created_at,updated_at
+		`SELECT id,booking_id,listing_id,guest_id,host_id,tenant_id,rating,comment,reply,helpful_count,created_at,updated_at
 		 FROM reviews WHERE tenant_id=$1 AND guest_id=$2 ORDER BY created_at DESC LIMIT 100`,
 		tenantID, guestID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	return collectReviews(rows)
+	return s.collectWithPhotos(ctx, rows)
+}
+
+// ListByHost returns a page of reviews received by a host across all their
+// listings, newest first, together with the host's total review count.
+func (s *Store) ListByHost(ctx context.Context, tenantID, hostID string, limit, offset int) ([]domain.Review, int, error) {
+	var total int
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM reviews WHERE tenant_id=$1 AND host_id=$2`,
+		tenantID, hostID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id,booking_id,listing_id,guest_id,host_id,tenant_id,rating,comment,reply,helpful_count,created_at,updated_at
+		 FROM reviews WHERE tenant_id=$1 AND host_id=$2 ORDER BY created_at DESC, id LIMIT $3 OFFSET $4`,
+		tenantID, hostID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	reviews, err := s.collectWithPhotos(ctx, rows)
+	return reviews, total, err
 }
 
 // SetReply allows a host to reply to a review.
@@ -113,6 +198,55 @@ func (s *Store) SetReply(ctx context.Context, reviewID, hostID, reply string) (d
 	return s.GetByID(ctx, reviewID)
 }
 
+// Vote records voterID finding a review helpful and returns the review's
+// helpful count. Voting twice is a no-op: (review, voter) is unique.
+func (s *Store) Vote(ctx context.Context, reviewID, voterID, tenantID string) (int, error) {
+	return s.changeVote(ctx, reviewID, 1,
+		`INSERT INTO review_votes (review_id, voter_id, tenant_id, created_at)
+		 VALUES ($1, $2, $3, $4) ON CONFLICT (review_id, voter_id) DO NOTHING`,
+		reviewID, voterID, tenantID, time.Now().Unix())
+}
+
+// Unvote withdraws voterID's helpful vote, if any, and returns the review's
+// helpful count.
+func (s *Store) Unvote(ctx context.Context, reviewID, voterID string) (int, error) {
+	return s.changeVote(ctx, reviewID, -1,
+		`DELETE FROM review_votes WHERE review_id=$1 AND voter_id=$2`,
+		reviewID, voterID)
+}
+
+// changeVote runs a vote insert or delete and, if it changed a row, moves the
+// review's helpful_count by delta in the same transaction.
+func (s *Store) changeVote(ctx context.Context, reviewID string, delta int, query string, args ...any) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var count int
+	err = tx.QueryRowContext(ctx,
+		`SELECT helpful_count FROM reviews WHERE id=$1 FOR UPDATE`, reviewID).Scan(&count)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		if err := tx.QueryRowContext(ctx,
+			`UPDATE reviews SET helpful_count = helpful_count + $1 WHERE id=$2 RETURNING helpful_count`,
+			delta, reviewID).Scan(&count); err != nil {
+			return 0, err
+		}
+	}
+	return count, tx.Commit()
+}
+
 // RatingSummary returns average rating and count for a listing.
 func (s *Store) RatingSummary(ctx context.Context, listingID string) (avg float64, count int, err error) {
 	err = s.db.QueryRowContext(ctx,
@@ -121,6 +255,39 @@ func (s *Store) RatingSummary(ctx context.Context, listingID string) (avg float6
 	return
 }
 
+// HostRatingCounts returns the number of reviews per star rating across all
+// of a host's listings in the tenant.
+func (s *Store) HostRatingCounts(ctx context.Context, tenantID, hostID string) (map[int]int, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT rating, COUNT(*) FROM reviews WHERE tenant_id=$1 AND host_id=$2 GROUP BY rating`,
+		tenantID, hostID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := map[int]int{}
+	for rows.Next() {
+		var rating, n int
+		if err := rows.Scan(&rating, &n); err != nil {
+			return nil, err
+		}
+		counts[rating] = n
+	}
+	return counts, rows.Err()
+}
+
+// HostTenant returns the tenant of the host's reviews, or "" if the host has
+// none. A host belongs to one tenant, so any of their reviews will do.
+func (s *Store) HostTenant(ctx context.Context, hostID string) (string, error) {
+	var tenantID string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT tenant_id FROM reviews WHERE host_id=$1 LIMIT 1`, hostID).Scan(&tenantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return tenantID, err
+}
+
 // ─── helpers ──────────────────────────────────────────────────────────────────
 
 func collectReviews(rows *sql.Rows) ([]domain.Review, error) {
@@ -138,6 +305,50 @@ func collectReviews(rows *sql.Rows) ([]domain.Review, error) {
 	return reviews, nil
 }
 
+// collectWithPhotos reads every review from rows, then loads their photos.
+func (s *Store) collectWithPhotos(ctx context.Context, rows *sql.Rows) ([]domain.Review, error) {
+	reviews, err := collectReviews(rows)
+	if err != nil {
+		return nil, err
+	}
+	rows.Close()
+	return reviews, s.attachPhotos(ctx, reviews)
+}
+
+// attachPhotos fills Photos on each review with a single query. Only the
+// reviews passed in are looked up, so a review that isn't returned never
+// exposes its photos.
+func (s *Store) attachPhotos(ctx context.Context, reviews []domain.Review) error {
+	if len(reviews) == 0 {
+		return nil
+	}
+	ids := make([]string, len(reviews))
+	byID := make(map[string]*domain.Review, len(reviews))
+	for i := range reviews {
+		reviews[i].Photos = []domain.Photo{}
+		ids[i] = reviews[i].ID
+		byID[reviews[i].ID] = &reviews[i]
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT review_id, url, caption FROM review_photos
+		 WHERE review_id = ANY($1) ORDER BY review_id, position`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var reviewID string
+		var p domain.Photo
+		if err := rows.Scan(&reviewID, &p.URL, &p.Caption); err != nil {
+			return err
+		}
+		if r := byID[reviewID]; r != nil {
+			r.Photos = append(r.Photos, p)
+		}
+	}
+	return rows.Err()
+}
+
 func isUniqueViolation(err error) bool {
 	if err == nil {
 		return false
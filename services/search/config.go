@@ -1,19 +1,66 @@
 package main
 
-import httputil "github.com/saidmashhud/zist/internal/httputil"
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	httputil "github.com/saidmashhud/zist/internal/httputil"
+)
 
 // Config holds configuration for the search service.
 type Config struct {
-	Port          string
-	DatabaseURL   string
-	InternalToken string
+	Port            string
+	DatabaseURL     string
+	InternalToken   string
+	AdminURL        string    // admin service base URL for tenant search settings (optional)
+	Marketplace     bool      // search across all tenants instead of the caller's
+	DefaultRadiusKM float64   // geo radius when lat/lng come without one
+	MaxRadiusKM     float64   // larger requested radii are clamped to this
+	RadiusLadderKM  []float64 // radii a minResults search widens through
+
+	// Default-sort ranking: rating × weight, plus a boost for new listings
+	RatingWeight float64
+	FreshBoost   float64
+	FreshDays    int // 0 disables the new listing boost
+
+	// Search query log for demand analytics
+	QueryLogEnabled       bool
+	QueryLogRetentionDays int
+	QueryLogMaxRows       int // 0 = no row cap
 }
 
 // LoadConfig reads configuration from environment variables.
 func LoadConfig() *Config {
 	return &Config{
-		Port:          httputil.Getenv("SEARCH_PORT", "8006"),
-		DatabaseURL:   httputil.Getenv("DATABASE_URL", "postgres://dev:dev@db:5432/zist?sslmode=disable"),
-		InternalToken: httputil.Getenv("INTERNAL_TOKEN", ""),
+		Port:            httputil.Getenv("SEARCH_PORT", "8006"),
+		DatabaseURL:     httputil.Getenv("DATABASE_URL", "postgres://dev:dev@db:5432/zist?sslmode=disable"),
+		InternalToken:   httputil.Getenv("INTERNAL_TOKEN", ""),
+		AdminURL:        httputil.Getenv("ADMIN_URL", ""),
+		Marketplace:     httputil.Getenv("SEARCH_MARKETPLACE", "false") == "true",
+		DefaultRadiusKM: httputil.GetenvFloat("SEARCH_DEFAULT_RADIUS_KM", 25),
+		MaxRadiusKM:     httputil.GetenvFloat("SEARCH_MAX_RADIUS_KM", 100),
+		RadiusLadderKM:  parseLadder(httputil.Getenv("SEARCH_RADIUS_LADDER_KM", "5,10,25")),
+
+		RatingWeight: httputil.GetenvFloat("SEARCH_RATING_WEIGHT", 1),
+		FreshBoost:   httputil.GetenvFloat("SEARCH_NEW_LISTING_BOOST", 3),
+		FreshDays:    httputil.GetenvInt("SEARCH_NEW_LISTING_DAYS", 14),
+
+		QueryLogEnabled:       httputil.Getenv("SEARCH_QUERY_LOG_ENABLED", "true") == "true",
+		QueryLogRetentionDays: httputil.GetenvInt("SEARCH_QUERY_LOG_RETENTION_DAYS", 30),
+		QueryLogMaxRows:       httputil.GetenvInt("SEARCH_QUERY_LOG_MAX_ROWS", 1000000),
+	}
+}
+
+// parseLadder reads a comma-separated list of radii in km, sorted ascending.
+// Entries that aren't positive numbers are skipped.
+func parseLadder(s string) []float64 {
+	var kms []float64
+	for _, part := range strings.Split(s, ",") {
+		if km, err := strconv.ParseFloat(strings.TrimSpace(part), 64); err == nil && km > 0 {
+			kms = append(kms, km)
+		}
 	}
+	sort.Float64s(kms)
+	return kms
 }
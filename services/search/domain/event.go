@@ -0,0 +1,38 @@
+package domain
+
+import "encoding/json"
+
+// Listing events emitted by the listings service through mgEvents.
+const (
+	EventListingUpdated     = "zist.listing.updated"
+	EventListingPublished   = "zist.listing.published"
+	EventListingUnpublished = "zist.listing.unpublished"
+	EventListingDeleted     = "zist.listing.deleted"
+)
+
+// ListingEvent is the mgEvents delivery envelope. Payload is an
+// IndexDocument for every event type; deletions only carry id and tenantId.
+type ListingEvent struct {
+	EventID   string          `json:"event_id"`
+	EventType string          `json:"event_type"`
+	TenantID  string          `json:"tenant_id"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// Index actions a listing event maps to.
+const (
+	ActionUpsert = "upsert"
+	ActionRemove = "remove"
+)
+
+// Action returns how the projection applies e, or "" for event types the
+// indexer does not handle.
+func (e ListingEvent) Action() string {
+	switch e.EventType {
+	case EventListingUpdated, EventListingPublished, EventListingUnpublished:
+		return ActionUpsert
+	case EventListingDeleted:
+		return ActionRemove
+	}
+	return ""
+}
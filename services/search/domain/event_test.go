@@ -0,0 +1,19 @@
+package domain
+
+import "testing"
+
+func TestListingEvent_Action(t *testing.T) {
+	cases := map[string]string{
+		EventListingUpdated:     ActionUpsert,
+		EventListingPublished:   ActionUpsert,
+		EventListingUnpublished: ActionUpsert,
+		EventListingDeleted:     ActionRemove,
+		"zist.booking.created":  "",
+		"":                      "",
+	}
+	for eventType, want := range cases {
+		if got := (ListingEvent{EventType: eventType}).Action(); got != want {
+			t.Errorf("Action(%q) = %q, want %q", eventType, got, want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// resultFields are the SearchResult JSON names a caller may project.
+var resultFields = map[string]bool{
+	"id": true, "title": true, "city": true, "country": true, "type": true,
+	"pricePerNight": true, "currency": true, "maxGuests": true,
+	"instantBook": true, "averageRating": true, "reviewCount": true,
+	"coverPhoto": true, "amenities": true, "distanceKm": true, "distance": true,
+	"moreFromHost": true,
+}
+
+// fieldAliases are short names accepted in fields= for common card fields.
+var fieldAliases = map[string]string{
+	"price":  "pricePerNight",
+	"cover":  "coverPhoto",
+	"rating": "averageRating",
+}
+
+// ParseFields parses a comma-separated fields= value into SearchResult JSON
+// names. An empty value means the full object and returns nil. Aliases are
+// resolved, duplicates dropped, and id is always included so clients can
+// link results.
+func ParseFields(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	fields := []string{"id"}
+	seen := map[string]bool{"id": true}
+	for _, f := range strings.Split(raw, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if canonical, ok := fieldAliases[f]; ok {
+			f = canonical
+		}
+		if !resultFields[f] {
+			return nil, fmt.Errorf("unknown field %q", f)
+		}
+		if !seen[f] {
+			seen[f] = true
+			fields = append(fields, f)
+		}
+	}
+	return fields, nil
+}
+
+// Project trims each result down to fields. Fields a result omits (an empty
+// coverPhoto, distance on a non-geo search) stay omitted.
+func Project(results []SearchResult, fields []string) ([]map[string]any, error) {
+	out := make([]map[string]any, 0, len(results))
+	for _, r := range results {
+		b, err := json.Marshal(r)
+		if err != nil {
+			return nil, err
+		}
+		var full map[string]json.RawMessage
+		if err := json.Unmarshal(b, &full); err != nil {
+			return nil, err
+		}
+		m := make(map[string]any, len(fields))
+		for _, f := range fields {
+			if v, ok := full[f]; ok {
+				m[f] = v
+			}
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// ProjectedResponse is a SearchResponse whose listings were trimmed by
+// fields=; its Listings shadows the embedded one in JSON.
+type ProjectedResponse struct {
+	SearchResponse
+	Listings    []map[string]any      `json:"listings"`
+	Suggestions *ProjectedSuggestions `json:"suggestions,omitempty"`
+}
+
+// ProjectedSuggestions are Suggestions trimmed by fields=.
+type ProjectedSuggestions struct {
+	Suggestions
+	Listings []map[string]any `json:"listings"`
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseFields(t *testing.T) {
+	got, err := ParseFields(" title, price ,cover,title")
+	if err != nil {
+		t.Fatalf("ParseFields: %v", err)
+	}
+	want := []string{"id", "title", "pricePerNight", "coverPhoto"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseFields = %v, want %v", got, want)
+	}
+	if got, err := ParseFields(""); err != nil || got != nil {
+		t.Errorf("empty: want nil, nil; got %v, %v", got, err)
+	}
+	if _, err := ParseFields("id,hostEmail"); err == nil {
+		t.Error("unknown field: want error")
+	}
+}
+
+func TestProjectAndResponse(t *testing.T) {
+	results := []SearchResult{{
+		ID: "l1", Title: "Loft", City: "Tashkent", PricePerNight: "350000.00",
+		Currency: "UZS", Amenities: []string{"wifi"},
+	}}
+	fields, _ := ParseFields("id,title,price,cover")
+	projected, err := Project(results, fields)
+	if err != nil {
+		t.Fatalf("Project: %v", err)
+	}
+
+	b, err := json.Marshal(ProjectedResponse{
+		SearchResponse: SearchResponse{Total: 1, Unit: UnitKM, Limit: 20},
+		Listings:       projected,
+	})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var resp struct {
+		Listings []map[string]any `json:"listings"`
+		Total    int              `json:"total"`
+	}
+	if err := json.Unmarshal(b, &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if resp.Total != 1 || len(resp.Listings) != 1 {
+		t.Fatalf("response = %s", b)
+	}
+	want := map[string]any{"id": "l1", "title": "Loft", "pricePerNight": "350000.00"}
+	if !reflect.DeepEqual(resp.Listings[0], want) {
+		t.Errorf("projected listing = %v, want %v (empty coverPhoto stays omitted)", resp.Listings[0], want)
+	}
+}
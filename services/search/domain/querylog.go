@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// QueryRecord is one logged search, kept for demand analytics. It carries the
+// filters and result count only: no user, IP or session, and coordinates are
+// rounded to one decimal (about 11 km) so a search can't pinpoint a guest.
+type QueryRecord struct {
+	TenantID    string
+	City        string // trimmed and lower-cased so counts group across spellings
+	Lat         *float64
+	Lng         *float64
+	RadiusKM    float64
+	CheckIn     string
+	CheckOut    string
+	Guests      int
+	Type        string
+	MinPrice    string
+	MaxPrice    string
+	Amenities   []string // sorted
+	InstantBook bool
+	ResultCount int
+	CreatedAt   int64
+}
+
+// NewQueryRecord builds the record logged for a search with f that matched
+// total listings.
+func NewQueryRecord(f SearchFilters, total int, now int64) QueryRecord {
+	rec := QueryRecord{
+		TenantID:    f.TenantID,
+		City:        strings.ToLower(strings.TrimSpace(f.City)),
+		CheckIn:     f.CheckIn,
+		CheckOut:    f.CheckOut,
+		Guests:      f.Guests,
+		Type:        f.Type,
+		MinPrice:    f.MinPrice,
+		MaxPrice:    f.MaxPrice,
+		Amenities:   append([]string{}, f.Amenities...),
+		InstantBook: f.InstantBookOnly,
+		ResultCount: total,
+		CreatedAt:   now,
+	}
+	sort.Strings(rec.Amenities)
+	if f.Lat != 0 && f.Lng != 0 {
+		lat, lng := math.Round(f.Lat*10)/10, math.Round(f.Lng*10)/10
+		rec.Lat, rec.Lng, rec.RadiusKM = &lat, &lng, f.RadiusKM
+	}
+	return rec
+}
+
+// CityDemand is how often a city was searched and how many of those searches
+// found nothing.
+type CityDemand struct {
+	City        string `json:"city"`
+	Searches    int    `json:"searches"`
+	ZeroResults int    `json:"zeroResults"`
+}
+
+// ZeroResultQuery groups searches that found no listings by what was asked.
+type ZeroResultQuery struct {
+	City     string `json:"city"`
+	Type     string `json:"type,omitempty"`
+	Guests   int    `json:"guests,omitempty"`
+	Searches int    `json:"searches"`
+}
+
+// QueryInsights is the admin view of logged searches.
+type QueryInsights struct {
+	Since             int64             `json:"since"`
+	TopCities         []CityDemand      `json:"topCities"`
+	ZeroResultQueries []ZeroResultQuery `json:"zeroResultQueries"`
+}
@@ -0,0 +1,27 @@
+package domain
+
+import "testing"
+
+func TestNewQueryRecord(t *testing.T) {
+	f := SearchFilters{
+		TenantID: "t1", City: "  Tashkent ", Lat: 41.311081, Lng: 69.240562, RadiusKM: 10,
+		Guests: 2, Amenities: []string{"wifi", "ac"}, InstantBookOnly: true,
+	}
+	rec := NewQueryRecord(f, 0, 100)
+	if rec.City != "tashkent" {
+		t.Errorf("city: want normalised, got %q", rec.City)
+	}
+	if rec.Lat == nil || *rec.Lat != 41.3 || *rec.Lng != 69.2 {
+		t.Errorf("coordinates: want rounded to 0.1, got %v,%v", rec.Lat, rec.Lng)
+	}
+	if rec.Amenities[0] != "ac" || f.Amenities[0] != "wifi" {
+		t.Errorf("amenities: want a sorted copy, got %v (filters %v)", rec.Amenities, f.Amenities)
+	}
+	if rec.ResultCount != 0 || !rec.InstantBook || rec.TenantID != "t1" {
+		t.Errorf("unexpected record %+v", rec)
+	}
+
+	if rec := NewQueryRecord(SearchFilters{City: "Samarkand"}, 3, 100); rec.Lat != nil || rec.RadiusKM != 0 {
+		t.Errorf("non-geo search: want no coordinates, got %+v", rec)
+	}
+}
@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+// Ranking orders results under the default sort (no sort_by). Each listing
+// scores RatingWeight per rating star, plus FreshBoost if it was created
+// within the last FreshDays, so new listings without reviews can surface
+// next to established ones. Explicit sort_by modes ignore it.
+type Ranking struct {
+	RatingWeight float64
+	FreshBoost   float64
+	FreshDays    int
+}
+
+// DefaultRanking treats a listing's first two weeks like a 3-star rating.
+var DefaultRanking = Ranking{RatingWeight: 1, FreshBoost: 3, FreshDays: 14}
+
+// FreshSince is the creation time (unix seconds) from which a listing still
+// counts as new at now.
+func (r Ranking) FreshSince(now time.Time) int64 {
+	return now.AddDate(0, 0, -r.FreshDays).Unix()
+}
+
+// Score is a listing's rank under the default sort; higher comes first.
+func (r Ranking) Score(averageRating float64, createdAt int64, now time.Time) float64 {
+	s := r.RatingWeight * averageRating
+	if r.FreshDays > 0 && createdAt >= r.FreshSince(now) {
+		s += r.FreshBoost
+	}
+	return s
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRanking_NewListingOutranksOldLowRated(t *testing.T) {
+	now := time.Date(2030, 3, 1, 12, 0, 0, 0, time.UTC)
+	brandNew := DefaultRanking.Score(0, now.Add(-time.Hour).Unix(), now)
+	oldLowRated := DefaultRanking.Score(2.5, now.AddDate(-1, 0, 0).Unix(), now)
+	if brandNew <= oldLowRated {
+		t.Errorf("new zero-review score %v should beat old 2.5-star score %v", brandNew, oldLowRated)
+	}
+	oldHighRated := DefaultRanking.Score(4.8, now.AddDate(-1, 0, 0).Unix(), now)
+	if brandNew >= oldHighRated {
+		t.Errorf("new zero-review score %v should not beat old 4.8-star score %v", brandNew, oldHighRated)
+	}
+}
+
+func TestRanking_BoostExpires(t *testing.T) {
+	now := time.Date(2030, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := Ranking{RatingWeight: 1, FreshBoost: 3, FreshDays: 14}
+	if got := r.Score(4, now.AddDate(0, 0, -14).Unix(), now); got != 7 {
+		t.Errorf("day 14: score = %v, want 7 (boosted)", got)
+	}
+	if got := r.Score(4, now.AddDate(0, 0, -15).Unix(), now); got != 4 {
+		t.Errorf("day 15: score = %v, want 4", got)
+	}
+	if got := (Ranking{RatingWeight: 1, FreshBoost: 3}).Score(4, now.Unix(), now); got != 4 {
+		t.Errorf("FreshDays 0 disables the boost: score = %v, want 4", got)
+	}
+}
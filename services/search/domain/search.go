@@ -1,11 +1,63 @@
 package domain
 
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/saidmashhud/zist/internal/tenantconfig"
+)
+
+// Distance units accepted by the search endpoint.
+const (
+	UnitKM = "km"
+	UnitMI = "mi"
+)
+
+// kmPerMile is the exact international mile in kilometres.
+const kmPerMile = 1.609344
+
+// ValidUnit reports whether u is a supported distance unit.
+func ValidUnit(u string) bool { return u == UnitKM || u == UnitMI }
+
+// ToKM converts a distance in unit to kilometres.
+func ToKM(v float64, unit string) float64 {
+	if unit == UnitMI {
+		return v * kmPerMile
+	}
+	return v
+}
+
+// FromKM converts a distance in kilometres to unit.
+func FromKM(km float64, unit string) float64 {
+	if unit == UnitMI {
+		return km / kmPerMile
+	}
+	return km
+}
+
+// EffectiveRadiusKM returns the radius a geo search actually uses: def when
+// none was requested, capped at max.
+func EffectiveRadiusKM(requested, def, max float64) float64 {
+	if requested <= 0 {
+		requested = def
+	}
+	if max > 0 && requested > max {
+		return max
+	}
+	return requested
+}
+
 // SearchFilters are the parameters accepted by the search endpoint.
 type SearchFilters struct {
+	TenantID        string // empty only in marketplace mode (all tenants)
 	City            string
+	FuzzyCity       bool // trigram match on City instead of exact
 	Lat             float64
 	Lng             float64
 	RadiusKM        float64
+	Unit            string // km (default) or mi; controls SearchResult.Distance
 	CheckIn         string // YYYY-MM-DD
 	CheckOut        string // YYYY-MM-DD
 	Guests          int
@@ -14,9 +66,54 @@ type SearchFilters struct {
 	MaxPrice        string
 	Amenities       []string
 	InstantBookOnly bool
-	SortBy          string // rating, price, distance (default: rating)
-	Limit           int
-	Offset          int
+	// MinRating keeps listings rated at least this; unrated listings (0)
+	// drop out of any positive threshold.
+	MinRating float64
+	// AvailableNow keeps listings free tonight, where tonight is the
+	// current date in each listing's own timezone.
+	AvailableNow bool
+	// CollapseByHost returns one listing per host, its cheapest match.
+	CollapseByHost bool
+	SortBy         string // one of tenantconfig.SearchSorts; empty ranks by Ranking
+	Limit          int
+	Offset         int
+	// MinResults widens a geo search's radius step by step until at least
+	// this many listings match; 0 searches the radius as given.
+	MinResults int
+}
+
+// Search page sizes: DefaultLimit when a search gives none, MaxLimit at most.
+const (
+	DefaultLimit = 50
+	MaxLimit     = tenantconfig.MaxSearchLimit
+)
+
+// EffectiveLimit is the page size for a requested limit under a tenant's
+// cap (0 = MaxLimit): missing limits get DefaultLimit, larger ones the cap.
+func EffectiveLimit(requested, tenantMax int) int {
+	limit := MaxLimit
+	if tenantMax > 0 && tenantMax < limit {
+		limit = tenantMax
+	}
+	if requested <= 0 {
+		return min(DefaultLimit, limit)
+	}
+	return min(requested, limit)
+}
+
+// MaxRating is the top of the review scale.
+const MaxRating = 5
+
+// ParseMinRating parses a minRating query value; empty means no threshold.
+func ParseMinRating(v string) (float64, error) {
+	if v == "" {
+		return 0, nil
+	}
+	r, err := strconv.ParseFloat(v, 64)
+	if err != nil || r < 0 || r > MaxRating {
+		return 0, errors.New("minRating must be a number between 0 and 5")
+	}
+	return r, nil
 }
 
 // SearchResult is a single listing returned from a search query.
@@ -35,12 +132,169 @@ type SearchResult struct {
 	CoverPhoto    string   `json:"coverPhoto,omitempty"`
 	Amenities     []string `json:"amenities"`
 	DistanceKM    *float64 `json:"distanceKm,omitempty"`
+	Distance      *float64 `json:"distance,omitempty"` // in SearchResponse.Unit
+	// MoreFromHost counts the host's other matches hidden by collapseByHost.
+	MoreFromHost int `json:"moreFromHost,omitempty"`
 }
 
 // SearchResponse wraps search results with pagination metadata.
 type SearchResponse struct {
 	Listings []SearchResult `json:"listings"`
 	Total    int            `json:"total"`
+	Unit     string         `json:"unit"`
 	Limit    int            `json:"limit"`
 	Offset   int            `json:"offset"`
+	// Effective radius of a geo search (after defaulting and clamping).
+	RadiusKM *float64 `json:"radiusKm,omitempty"`
+	Radius   *float64 `json:"radius,omitempty"` // in Unit
+	// RadiusExpanded is set when minResults widened the radius.
+	RadiusExpanded bool `json:"radiusExpanded,omitempty"`
+	// Suggestions is set only when nothing matched and a relaxed search did.
+	Suggestions *Suggestions `json:"suggestions,omitempty"`
+}
+
+// FacetCount is a single facet value with the number of matching listings.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// PriceBucket is a price-per-night range with the number of matching listings.
+type PriceBucket struct {
+	Min   string `json:"min"`
+	Max   string `json:"max"`
+	Count int    `json:"count"`
+}
+
+// FacetsResponse holds per-dimension counts for building filter sidebars.
+type FacetsResponse struct {
+	Total        int           `json:"total"`
+	Cities       []FacetCount  `json:"cities"`
+	Types        []FacetCount  `json:"types"`
+	PriceBuckets []PriceBucket `json:"priceBuckets"`
+	Amenities    []FacetCount  `json:"amenities"`
+}
+
+// IndexDocument is the subset of a listing stored in the search projection.
+// It is pushed by the listings service on every change that affects search.
+type IndexDocument struct {
+	ID            string   `json:"id"`
+	TenantID      string   `json:"tenantId"`
+	HostID        string   `json:"hostId"`
+	Title         string   `json:"title"`
+	City          string   `json:"city"`
+	Country       string   `json:"country"`
+	Type          string   `json:"type"`
+	PricePerNight string   `json:"pricePerNight"`
+	Currency      string   `json:"currency"`
+	MaxGuests     int      `json:"maxGuests"`
+	InstantBook   bool     `json:"instantBook"`
+	AverageRating float64  `json:"averageRating"`
+	ReviewCount   int      `json:"reviewCount"`
+	Amenities     []string `json:"amenities"`
+	CoverPhoto    string   `json:"coverPhoto"`
+	Status        string   `json:"status"`
+	// Lat/Lng set the geo point when present; absent keeps the indexed one
+	// (which may have come from PUT /search/locations/{id}).
+	Lat       *float64 `json:"lat,omitempty"`
+	Lng       *float64 `json:"lng,omitempty"`
+	Timezone  string   `json:"timezone,omitempty"` // IANA; empty is UTC
+	CreatedAt int64    `json:"createdAt"`
+	UpdatedAt int64    `json:"updatedAt"`
+	// DeletedAt is set on zist.listing.deleted payloads only; it versions
+	// the tombstone.
+	DeletedAt int64 `json:"deletedAt,omitempty"`
+}
+
+// Relaxation is a loosened copy of a search that matched nothing.
+type Relaxation struct {
+	Relaxed []string // what has been loosened: price, radius, amenities
+	Filters SearchFilters
+}
+
+// Relax lists progressively looser versions of f to suggest results from when
+// f matches nothing: first without the price range, then also with the geo
+// radius doubled (capped at maxRadiusKM), then also without amenities. Steps
+// that would not change f are skipped, so an unconstrained search gets none.
+func Relax(f SearchFilters, maxRadiusKM float64) []Relaxation {
+	var out []Relaxation
+	var relaxed []string
+	step := func(name string) {
+		relaxed = append(relaxed, name)
+		out = append(out, Relaxation{Relaxed: append([]string(nil), relaxed...), Filters: f})
+	}
+	if f.MinPrice != "" || f.MaxPrice != "" {
+		f.MinPrice, f.MaxPrice = "", ""
+		step("price")
+	}
+	if f.Lat != 0 && f.Lng != 0 && f.RadiusKM > 0 && (maxRadiusKM <= 0 || f.RadiusKM < maxRadiusKM) {
+		f.RadiusKM = EffectiveRadiusKM(f.RadiusKM*2, f.RadiusKM*2, maxRadiusKM)
+		step("radius")
+	}
+	if len(f.Amenities) > 0 {
+		f.Amenities = nil
+		step("amenities")
+	}
+	return out
+}
+
+// DefaultRadiusLadder is the sequence of radii (km) a minResults search
+// widens through.
+var DefaultRadiusLadder = []float64{5, 10, 25}
+
+// ExpandRadius widens a geo search that matched fewer than f.MinResults
+// listings through the ladder steps beyond its radius, capped at maxKM,
+// until one matches enough or the cap is searched. total is the count at
+// f's radius and search runs the search at a wider one. It returns the
+// filters and count of the last search run; f is returned unchanged when
+// no expansion was needed.
+func ExpandRadius(f SearchFilters, total int, ladder []float64, maxKM float64,
+	search func(SearchFilters) (int, error)) (SearchFilters, int, error) {
+	if f.MinResults <= 0 || f.Lat == 0 || f.Lng == 0 || f.RadiusKM <= 0 {
+		return f, total, nil
+	}
+	for _, km := range ladder {
+		if total >= f.MinResults || (maxKM > 0 && f.RadiusKM >= maxKM) {
+			break
+		}
+		if km <= f.RadiusKM {
+			continue
+		}
+		if maxKM > 0 && km > maxKM {
+			km = maxKM
+		}
+		wider := f
+		wider.RadiusKM = km
+		n, err := search(wider)
+		if err != nil {
+			return f, total, err
+		}
+		f, total = wider, n
+	}
+	return f, total, nil
+}
+
+// RelaxNote explains to a guest which of their filters a suggestion ignores.
+func RelaxNote(r Relaxation) string {
+	var parts []string
+	for _, name := range r.Relaxed {
+		switch name {
+		case "price":
+			parts = append(parts, "outside your price range")
+		case "radius":
+			parts = append(parts, fmt.Sprintf("up to %.3g %s away", FromKM(r.Filters.RadiusKM, r.Filters.Unit), r.Filters.Unit))
+		case "amenities":
+			parts = append(parts, "without all the amenities you asked for")
+		}
+	}
+	return "No exact matches. Showing listings " + strings.Join(parts, ", ") + "."
+}
+
+// Suggestions are results of a relaxed search, returned alongside an empty
+// result set so the guest has something to act on.
+type Suggestions struct {
+	Relaxed  []string       `json:"relaxed"`
+	Note     string         `json:"note"`
+	Listings []SearchResult `json:"listings"`
+	Total    int            `json:"total"`
 }
@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestUnitConversionRoundTrip(t *testing.T) {
+	if got := ToKM(10, UnitMI); math.Abs(got-16.09344) > 1e-9 {
+		t.Errorf("10mi: want 16.09344km, got %v", got)
+	}
+	if got := FromKM(16.09344, UnitMI); math.Abs(got-10) > 1e-9 {
+		t.Errorf("16.09344km: want 10mi, got %v", got)
+	}
+	if got := ToKM(5, UnitKM); got != 5 {
+		t.Errorf("km passthrough: want 5, got %v", got)
+	}
+	if ValidUnit("ft") {
+		t.Error("ft should not be a valid unit")
+	}
+}
+
+func TestEffectiveRadiusKM(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested float64
+		want      float64
+	}{
+		{"default when absent", 0, 25},
+		{"within limit", 10, 10},
+		{"clamped to max", 20000, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EffectiveRadiusKM(tt.requested, 25, 100); got != tt.want {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRelax(t *testing.T) {
+	f := SearchFilters{
+		City: "Tashkent", Lat: 41.3, Lng: 69.2, RadiusKM: 30, Unit: UnitKM,
+		MaxPrice: "50000", Amenities: []string{"pool"}, Guests: 4,
+	}
+	steps := Relax(f, 50)
+	if len(steps) != 3 {
+		t.Fatalf("want 3 relaxations, got %+v", steps)
+	}
+	if got := steps[0]; got.Filters.MaxPrice != "" || len(got.Filters.Amenities) != 1 || got.Filters.RadiusKM != 30 {
+		t.Errorf("price step: got %+v", got.Filters)
+	}
+	if got := steps[1]; got.Filters.RadiusKM != 50 || got.Filters.MaxPrice != "" || strings.Join(got.Relaxed, ",") != "price,radius" {
+		t.Errorf("radius step: want capped at 50 and cumulative, got %+v", got)
+	}
+	last := steps[2]
+	if last.Filters.Amenities != nil || last.Filters.Guests != 4 || last.Filters.City != "Tashkent" {
+		t.Errorf("amenities step: got %+v", last.Filters)
+	}
+	if f.MaxPrice != "50000" || len(f.Amenities) != 1 {
+		t.Error("Relax must not modify the original filters")
+	}
+	if note := RelaxNote(steps[1]); note != "No exact matches. Showing listings outside your price range, up to 50 km away." {
+		t.Errorf("note: got %q", note)
+	}
+
+	if steps := Relax(SearchFilters{City: "Tashkent", Guests: 2}, 50); len(steps) != 0 {
+		t.Errorf("nothing to relax: got %+v", steps)
+	}
+	if steps := Relax(SearchFilters{Lat: 41.3, Lng: 69.2, RadiusKM: 50}, 50); len(steps) != 0 {
+		t.Errorf("radius already at max: got %+v", steps)
+	}
+}
+
+func TestExpandRadius(t *testing.T) {
+	// A sparse area: 1 listing within 5 km, 3 within 10 km.
+	counts := map[float64]int{5: 1, 10: 3, 25: 8}
+	var searched []float64
+	search := func(f SearchFilters) (int, error) {
+		searched = append(searched, f.RadiusKM)
+		return counts[f.RadiusKM], nil
+	}
+	ladder := []float64{5, 10, 25}
+
+	f := SearchFilters{Lat: 41.3, Lng: 69.2, RadiusKM: 5, MinResults: 3}
+	got, total, err := ExpandRadius(f, counts[5], ladder, 100, search)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.RadiusKM != 10 || total != 3 || len(searched) != 1 {
+		t.Errorf("want one step to 10 km with 3 results, got %v km, %d results, searched %v", got.RadiusKM, total, searched)
+	}
+
+	searched = nil
+	f.MinResults = 50
+	got, total, _ = ExpandRadius(f, counts[5], ladder, 20, search)
+	if got.RadiusKM != 20 || len(searched) != 2 {
+		t.Errorf("capped: want to stop at 20 km after 2 steps, got %v km, searched %v", got.RadiusKM, searched)
+	}
+
+	searched = nil
+	f.MinResults = 0
+	if got, total, _ = ExpandRadius(f, 1, ladder, 100, search); got.RadiusKM != 5 || total != 1 || len(searched) != 0 {
+		t.Errorf("no minResults: want unchanged, got %v km, searched %v", got.RadiusKM, searched)
+	}
+}
+
+func TestEffectiveLimit(t *testing.T) {
+	cases := []struct{ requested, tenantMax, want int }{
+		{0, 0, DefaultLimit},
+		{30, 0, 30},
+		{500, 0, MaxLimit},
+		{50, 20, 20},
+		{0, 20, 20},
+		{500, 300, MaxLimit}, // a tenant can't raise the platform cap
+	}
+	for _, c := range cases {
+		if got := EffectiveLimit(c.requested, c.tenantMax); got != c.want {
+			t.Errorf("EffectiveLimit(%d, %d) = %d, want %d", c.requested, c.tenantMax, got, c.want)
+		}
+	}
+}
+
+func TestParseMinRating(t *testing.T) {
+	for v, want := range map[string]float64{"": 0, "4": 4, "3.5": 3.5, "0": 0, "5": 5} {
+		if got, err := ParseMinRating(v); err != nil || got != want {
+			t.Errorf("ParseMinRating(%q) = %v, %v; want %v", v, got, err, want)
+		}
+	}
+	for _, v := range []string{"-1", "5.5", "four"} {
+		if _, err := ParseMinRating(v); err == nil {
+			t.Errorf("ParseMinRating(%q): want error", v)
+		}
+	}
+}
@@ -1,13 +1,20 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	zistauth "github.com/saidmashhud/zist/internal/auth"
 	httputil "github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/internal/tenantconfig"
 	"github.com/saidmashhud/zist/services/search/domain"
 	"github.com/saidmashhud/zist/services/search/store"
 )
@@ -15,32 +22,217 @@ import (
 // Handler serves HTTP search endpoints.
 type Handler struct {
 	Store *store.Store
+	// Marketplace disables tenant scoping so every tenant's active listings
+	// are searchable together. Off by default.
+	Marketplace bool
+	// DefaultRadiusKM applies when lat/lng are given without a radius;
+	// MaxRadiusKM caps any requested radius.
+	DefaultRadiusKM float64
+	MaxRadiusKM     float64
+	// RadiusLadder is the radii (km) a minResults search widens through.
+	RadiusLadder []float64
+	// Ranking orders results when no sort_by is given.
+	Ranking domain.Ranking
+	// Tenants supplies each tenant's default sort; nil unless ADMIN_URL is set.
+	Tenants *tenantconfig.Client
+
+	// Query log; nil queries disables it (see WithQueryLog).
+	queries        chan domain.QueryRecord
+	queryRetention time.Duration
+	queryMaxRows   int
 }
 
+const (
+	defaultRadiusKM = 25.0
+	maxRadiusKM     = 100.0
+)
+
 // New creates a Handler.
-func New(s *store.Store) *Handler { return &Handler{Store: s} }
+func New(s *store.Store) *Handler {
+	return &Handler{
+		Store:           s,
+		DefaultRadiusKM: defaultRadiusKM,
+		MaxRadiusKM:     maxRadiusKM,
+		RadiusLadder:    domain.DefaultRadiusLadder,
+		Ranking:         domain.DefaultRanking,
+	}
+}
 
-// Search handles GET /search with query params.
-func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+// WithRadiusLadder replaces the radii a minResults search widens through;
+// an empty ladder keeps the default.
+func (h *Handler) WithRadiusLadder(kms []float64) *Handler {
+	if len(kms) > 0 {
+		h.RadiusLadder = kms
+	}
+	return h
+}
+
+// WithRanking sets the default-sort weights. FreshDays 0 turns the new
+// listing boost off.
+func (h *Handler) WithRanking(r domain.Ranking) *Handler {
+	h.Ranking = r
+	return h
+}
+
+// WithTenantConfig applies each tenant's defaultSort, read from the admin
+// service, to searches that give no sort_by.
+func (h *Handler) WithTenantConfig(adminURL, internalToken string) *Handler {
+	if adminURL != "" {
+		h.Tenants = tenantconfig.New(adminURL, internalToken)
+	}
+	return h
+}
+
+// tenantSettings reads the search settings of the tenant searching: f's
+// tenant, or in marketplace mode the caller's. ok is false when there is no
+// tenant or its config can't be read.
+func (h *Handler) tenantSettings(r *http.Request, f *domain.SearchFilters) (tenantconfig.Config, bool) {
+	if h.Tenants == nil {
+		return tenantconfig.Config{}, false
+	}
+	tenantID := f.TenantID
+	if p := zistauth.FromContext(r.Context()); tenantID == "" && p != nil {
+		tenantID = strings.TrimSpace(p.TenantID) // marketplace: the caller's tenant
+	}
+	if tenantID == "" {
+		return tenantconfig.Config{}, false
+	}
+	cfg, err := h.Tenants.Get(r.Context(), tenantID)
+	if err != nil {
+		slog.Warn("tenant search settings unavailable", "tenantId", tenantID, "err", err)
+		return tenantconfig.Config{}, false
+	}
+	return cfg, true
+}
+
+// applyDefaultSort fills f.SortBy from the caller's tenant config when the
+// search gave none. If the config can't be read the ranking applies.
+func (h *Handler) applyDefaultSort(r *http.Request, f *domain.SearchFilters) {
+	if f.SortBy != "" {
+		return
+	}
+	if cfg, ok := h.tenantSettings(r, f); ok && tenantconfig.ValidSort(cfg.DefaultSort) {
+		f.SortBy = cfg.DefaultSort
+	}
+}
+
+// applyLimit sets f.Limit to the page size actually served: the requested
+// limit within domain.MaxLimit and the tenant's maxSearchLimit.
+func (h *Handler) applyLimit(r *http.Request, f *domain.SearchFilters) {
+	var tenantMax int
+	if cfg, ok := h.tenantSettings(r, f); ok {
+		tenantMax = cfg.MaxSearchLimit
+	}
+	f.Limit = domain.EffectiveLimit(f.Limit, tenantMax)
+}
+
+// WithRadiusLimits overrides the default and maximum geo search radius (km).
+// Non-positive values keep the built-in defaults.
+func (h *Handler) WithRadiusLimits(defKM, maxKM float64) *Handler {
+	if defKM > 0 {
+		h.DefaultRadiusKM = defKM
+	}
+	if maxKM > 0 {
+		h.MaxRadiusKM = maxKM
+	}
+	return h
+}
+
+// clampRadius applies the radius default and cap to geo searches. A
+// minResults search without a radius starts at the ladder's first step.
+func (h *Handler) clampRadius(f *domain.SearchFilters) {
+	if f.Lat == 0 || f.Lng == 0 {
+		return
+	}
+	if f.MinResults > 0 && f.RadiusKM <= 0 && len(h.RadiusLadder) > 0 {
+		f.RadiusKM = h.RadiusLadder[0]
+	}
+	f.RadiusKM = domain.EffectiveRadiusKM(f.RadiusKM, h.DefaultRadiusKM, h.MaxRadiusKM)
+}
+
+// WithMarketplace enables cross-tenant (marketplace) search.
+func (h *Handler) WithMarketplace(enabled bool) *Handler {
+	h.Marketplace = enabled
+	return h
+}
+
+// errTenantRequired is returned when a search can't be scoped to a tenant.
+var errTenantRequired = errors.New("tenant_id is required")
+
+// scopeToTenant restricts f to the caller's tenant: the authenticated
+// principal's, or the X-Tenant-ID header for internal callers (the gateway
+// strips it from inbound requests). Marketplace mode searches all tenants.
+func (h *Handler) scopeToTenant(r *http.Request, f *domain.SearchFilters) error {
+	if h.Marketplace {
+		return nil
+	}
+	tenantID := strings.TrimSpace(r.Header.Get("X-Tenant-ID"))
+	if p := zistauth.FromContext(r.Context()); p != nil && strings.TrimSpace(p.TenantID) != "" {
+		tenantID = strings.TrimSpace(p.TenantID)
+	}
+	if tenantID == "" {
+		return errTenantRequired
+	}
+	f.TenantID = tenantID
+	return nil
+}
+
+// filtersFromQuery parses the search query params shared by Search and Facets.
+// `radius` is interpreted in `unit` (km by default); the legacy `radius_km`
+// is always kilometres and is used when `radius` is absent.
+func filtersFromQuery(r *http.Request) (domain.SearchFilters, error) {
 	q := r.URL.Query()
 
+	unit := q.Get("unit")
+	if unit == "" {
+		unit = domain.UnitKM
+	}
+	if !domain.ValidUnit(unit) {
+		return domain.SearchFilters{}, errors.New("unit must be km or mi")
+	}
+
 	lat, _ := strconv.ParseFloat(q.Get("lat"), 64)
 	lng, _ := strconv.ParseFloat(q.Get("lng"), 64)
 	radiusKM, _ := strconv.ParseFloat(q.Get("radius_km"), 64)
+	if v := q.Get("radius"); v != "" {
+		radius, _ := strconv.ParseFloat(v, 64)
+		radiusKM = domain.ToKM(radius, unit)
+	}
 	guests, _ := strconv.Atoi(q.Get("guests"))
 	limit, _ := strconv.Atoi(q.Get("limit"))
 	offset, _ := strconv.Atoi(q.Get("offset"))
+	var minResults int
+	if v := q.Get("minResults"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return domain.SearchFilters{}, errors.New("minResults must be a non-negative integer")
+		}
+		minResults = n
+	}
+	minRating, err := domain.ParseMinRating(q.Get("minRating"))
+	if err != nil {
+		return domain.SearchFilters{}, err
+	}
+
+	// availableNow=true is "bookable tonight": instant book, free for one
+	// night from today in the listing's timezone.
+	availableNow := q.Get("availableNow") == "true"
+	if availableNow && (q.Get("check_in") != "" || q.Get("check_out") != "") {
+		return domain.SearchFilters{}, errors.New("availableNow cannot be combined with check_in/check_out")
+	}
 
 	var amenities []string
 	if a := q.Get("amenities"); a != "" {
 		amenities = strings.Split(a, ",")
 	}
 
-	filters := domain.SearchFilters{
+	return domain.SearchFilters{
 		City:            q.Get("city"),
+		FuzzyCity:       q.Get("fuzzy") == "true",
 		Lat:             lat,
 		Lng:             lng,
 		RadiusKM:        radiusKM,
+		Unit:            unit,
 		CheckIn:         q.Get("check_in"),
 		CheckOut:        q.Get("check_out"),
 		Guests:          guests,
@@ -48,24 +240,139 @@ func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 		MinPrice:        q.Get("min_price"),
 		MaxPrice:        q.Get("max_price"),
 		Amenities:       amenities,
-		InstantBookOnly: q.Get("instant_book") == "true",
+		InstantBookOnly: availableNow || q.Get("instant_book") == "true",
+		MinRating:       minRating,
+		AvailableNow:    availableNow,
+		CollapseByHost:  q.Get("collapseByHost") == "true",
 		SortBy:          q.Get("sort_by"),
 		Limit:           limit,
 		Offset:          offset,
+		MinResults:      minResults,
+	}, nil
+}
+
+// Search handles GET /search with query params.
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	filters, err := filtersFromQuery(r)
+	if err == nil {
+		err = h.scopeToTenant(r, &filters)
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.clampRadius(&filters)
+	h.applyDefaultSort(r, &filters)
+	h.applyLimit(r, &filters)
+	fields, err := domain.ParseFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	results, total, err := h.Store.Search(r.Context(), filters)
+	results, total, err := h.Store.Search(r.Context(), filters, h.Ranking)
 	if err != nil {
 		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	requestedKM := filters.RadiusKM
+	filters, total, err = domain.ExpandRadius(filters, total, h.RadiusLadder, h.MaxRadiusKM,
+		func(f domain.SearchFilters) (int, error) {
+			wider, n, err := h.Store.Search(r.Context(), f, h.Ranking)
+			if err == nil {
+				results = wider
+			}
+			return n, err
+		})
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.logQuery(filters, total)
+	var suggestions *domain.Suggestions
+	if total == 0 && filters.Offset == 0 {
+		suggestions = h.suggest(r.Context(), filters)
+	}
 
-	httputil.WriteJSON(w, http.StatusOK, domain.SearchResponse{
+	resp := domain.SearchResponse{
 		Listings: results,
 		Total:    total,
+		Unit:     filters.Unit,
 		Limit:    filters.Limit,
 		Offset:   filters.Offset,
-	})
+
+		RadiusExpanded: filters.RadiusKM != requestedKM,
+		Suggestions:    suggestions,
+	}
+	if filters.RadiusKM > 0 {
+		km := filters.RadiusKM
+		radius := domain.FromKM(km, filters.Unit)
+		resp.RadiusKM, resp.Radius = &km, &radius
+	}
+	if fields != nil {
+		listings, err := domain.Project(results, fields)
+		if err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		projected := domain.ProjectedResponse{SearchResponse: resp, Listings: listings}
+		if suggestions != nil {
+			suggested, err := domain.Project(suggestions.Listings, fields)
+			if err != nil {
+				httputil.WriteError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			projected.Suggestions = &domain.ProjectedSuggestions{Suggestions: *suggestions, Listings: suggested}
+		}
+		httputil.WriteJSON(w, http.StatusOK, projected)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+// suggest runs progressively relaxed versions of a search that matched
+// nothing and returns the first that does, or nil. Failures only cost the
+// suggestions.
+func (h *Handler) suggest(ctx context.Context, f domain.SearchFilters) *domain.Suggestions {
+	for _, relaxed := range domain.Relax(f, h.MaxRadiusKM) {
+		results, total, err := h.Store.Search(ctx, relaxed.Filters, h.Ranking)
+		if err != nil {
+			slog.Warn("relaxed search failed", "relaxed", relaxed.Relaxed, "err", err)
+			return nil
+		}
+		if total > 0 {
+			return &domain.Suggestions{
+				Relaxed:  relaxed.Relaxed,
+				Note:     domain.RelaxNote(relaxed),
+				Listings: results,
+				Total:    total,
+			}
+		}
+	}
+	return nil
+}
+
+// maxFacetValues caps the number of values returned per facet.
+const maxFacetValues = 20
+
+// Facets handles GET /search/facets. It accepts the same filters as Search
+// and returns counts per city, type, price bucket and amenity.
+func (h *Handler) Facets(w http.ResponseWriter, r *http.Request) {
+	filters, err := filtersFromQuery(r)
+	if err == nil {
+		err = h.scopeToTenant(r, &filters)
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.clampRadius(&filters)
+	facets, err := h.Store.Facets(r.Context(), filters, maxFacetValues)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, facets)
 }
 
 // UpdateLocation handles PUT /search/locations/{id} (internal).
@@ -95,3 +402,77 @@ func (h *Handler) UpdateLocation(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// IndexListing handles POST /internal/search/index (internal).
+// Accepts either a bare listing document or a zist.listing.* event delivered
+// by mgEvents. Applying the same event twice is harmless: writes are keyed on
+// listing id and a document older than the indexed one is ignored.
+func (h *Handler) IndexListing(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	var ev domain.ListingEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	action := domain.ActionUpsert
+	if ev.EventType != "" {
+		action = ev.Action()
+		if action == "" {
+			// Not ours; acknowledge so the events plane does not retry.
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		body = ev.Payload
+	}
+	var doc domain.IndexDocument
+	if err := json.Unmarshal(body, &doc); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if strings.TrimSpace(doc.ID) == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+	if action == domain.ActionRemove {
+		err = h.Store.Remove(r.Context(), doc.ID, deletionTime(doc.DeletedAt))
+	} else {
+		err = h.Store.Upsert(r.Context(), doc)
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveListing handles DELETE /internal/search/index/{id} (internal).
+// ?deletedAt= (unix seconds) versions the tombstone; it defaults to now.
+func (h *Handler) RemoveListing(w http.ResponseWriter, r *http.Request) {
+	var deletedAt int64
+	if raw := r.URL.Query().Get("deletedAt"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n <= 0 {
+			httputil.WriteError(w, http.StatusBadRequest, "deletedAt must be a unix timestamp")
+			return
+		}
+		deletedAt = n
+	}
+	if err := h.Store.Remove(r.Context(), chi.URLParam(r, "id"), deletionTime(deletedAt)); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deletionTime is the tombstone version for a deletion: the time the
+// listings service deleted it, or now for senders that don't say.
+func deletionTime(deletedAt int64) int64 {
+	if deletedAt > 0 {
+		return deletedAt
+	}
+	return time.Now().Unix()
+}
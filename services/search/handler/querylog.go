@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	zistauth "github.com/saidmashhud/zist/internal/auth"
+	httputil "github.com/saidmashhud/zist/internal/httputil"
+	"github.com/saidmashhud/zist/services/search/domain"
+)
+
+// queryLogBuffer is how many searches may await writing before new ones are
+// dropped; logging never slows a search down.
+const queryLogBuffer = 1024
+
+// queryLogPruneEvery is how often old and excess query rows are deleted.
+const queryLogPruneEvery = time.Hour
+
+// WithQueryLog records every search in search_queries. Rows older than
+// retention (0 = kept indefinitely) are deleted, and at most maxRows are
+// kept (0 = no cap).
+// RunQueryLog must be running for anything to be written.
+func (h *Handler) WithQueryLog(retention time.Duration, maxRows int) *Handler {
+	h.queries = make(chan domain.QueryRecord, queryLogBuffer)
+	h.queryRetention = retention
+	h.queryMaxRows = maxRows
+	return h
+}
+
+// logQuery queues a search for the query log without blocking.
+func (h *Handler) logQuery(f domain.SearchFilters, total int) {
+	if h.queries == nil {
+		return
+	}
+	select {
+	case h.queries <- domain.NewQueryRecord(f, total, time.Now().Unix()):
+	default:
+		slog.Debug("search query log full, dropping query")
+	}
+}
+
+// RunQueryLog writes queued searches and prunes the log hourly. It blocks
+// until ctx is cancelled.
+func (h *Handler) RunQueryLog(ctx context.Context) {
+	if h.queries == nil {
+		return
+	}
+	h.pruneQueries(ctx)
+	ticker := time.NewTicker(queryLogPruneEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case q := <-h.queries:
+			writeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			if err := h.Store.LogQuery(writeCtx, q); err != nil {
+				slog.Warn("search query log write failed", "err", err)
+			}
+			cancel()
+		case <-ticker.C:
+			h.pruneQueries(ctx)
+		}
+	}
+}
+
+func (h *Handler) pruneQueries(ctx context.Context) {
+	pruneCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	var before int64 // no age limit without a retention
+	if h.queryRetention > 0 {
+		before = time.Now().Add(-h.queryRetention).Unix()
+	}
+	n, err := h.Store.PruneQueries(pruneCtx, before, h.queryMaxRows)
+	if err != nil {
+		slog.Error("search query log prune failed", "err", err)
+		return
+	}
+	if n > 0 {
+		slog.Info("search query log pruned", "rows", n)
+	}
+}
+
+// Insights reports the most searched cities and the most common searches that
+// found nothing, over the last `days` (default 30) with up to `limit` entries
+// (default 20, max 100) each. Admin only; scoped to the admin's tenant unless
+// search runs in marketplace mode.
+// GET /search/insights
+func (h *Handler) Insights(w http.ResponseWriter, r *http.Request) {
+	p := zistauth.FromContext(r.Context())
+	if p == nil || !p.HasScope("zist.admin") {
+		httputil.WriteError(w, http.StatusForbidden, "admin scope required")
+		return
+	}
+	tenantID := p.TenantID
+	if h.Marketplace {
+		tenantID = ""
+	}
+
+	q := r.URL.Query()
+	days, limit := 30, 20
+	if v := q.Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			httputil.WriteError(w, http.StatusBadRequest, "days must be a positive integer")
+			return
+		}
+		days = n
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 100 {
+			httputil.WriteError(w, http.StatusBadRequest, "limit must be between 1 and 100")
+			return
+		}
+		limit = n
+	}
+
+	since := time.Now().AddDate(0, 0, -days).Unix()
+	insights, err := h.Store.QueryInsights(r.Context(), tenantID, since, limit)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, insights)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/saidmashhud/zist/services/search/domain"
+)
+
+func TestApplyDefaultSort(t *testing.T) {
+	var lookups atomic.Int32
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups.Add(1)
+		if r.Header.Get("X-Internal-Token") != "tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/admin/internal/tenants/budget":
+			fmt.Fprint(w, `{"tenantId":"budget","defaultSort":"price"}`)
+		case "/admin/internal/tenants/bogus":
+			fmt.Fprint(w, `{"tenantId":"bogus","defaultSort":"newest"}`)
+		default:
+			fmt.Fprint(w, `{"tenantId":"other","defaultSort":""}`)
+		}
+	}))
+	defer admin.Close()
+
+	h := New(nil).WithTenantConfig(admin.URL, "tok")
+	req := httptest.NewRequest(http.MethodGet, "/search", nil)
+	cases := []struct {
+		name           string
+		tenant, sortBy string
+		want           string
+	}{
+		{"tenant default", "budget", "", "price"},
+		{"explicit sort wins", "budget", "rating", "rating"},
+		{"no tenant default keeps ranking", "other", "", ""},
+		{"invalid stored sort ignored", "bogus", "", ""},
+	}
+	for _, tc := range cases {
+		f := domain.SearchFilters{TenantID: tc.tenant, SortBy: tc.sortBy}
+		h.applyDefaultSort(req, &f)
+		if f.SortBy != tc.want {
+			t.Errorf("%s: SortBy = %q, want %q", tc.name, f.SortBy, tc.want)
+		}
+	}
+
+	// The budget tenant was looked up once; the second search hit the cache.
+	before := lookups.Load()
+	f := domain.SearchFilters{TenantID: "budget"}
+	h.applyDefaultSort(req, &f)
+	if f.SortBy != "price" || lookups.Load() != before {
+		t.Errorf("cached lookup: SortBy = %q, admin calls %d -> %d", f.SortBy, before, lookups.Load())
+	}
+}
+
+func TestApplyDefaultSortAdminDown(t *testing.T) {
+	// An unreachable admin service falls back to the ranking.
+	h := New(nil).WithTenantConfig("http://127.0.0.1:1", "tok")
+	f := domain.SearchFilters{TenantID: "budget"}
+	h.applyDefaultSort(httptest.NewRequest(http.MethodGet, "/search", nil), &f)
+	if f.SortBy != "" {
+		t.Errorf("SortBy = %q, want ranking", f.SortBy)
+	}
+}
+
+func TestApplyLimit(t *testing.T) {
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/admin/internal/tenants/capped":
+			fmt.Fprint(w, `{"tenantId":"capped","maxSearchLimit":20}`)
+		default:
+			fmt.Fprint(w, `{"tenantId":"other","maxSearchLimit":100}`)
+		}
+	}))
+	defer admin.Close()
+
+	h := New(nil).WithTenantConfig(admin.URL, "tok")
+	req := httptest.NewRequest(http.MethodGet, "/search", nil)
+	cases := []struct {
+		tenant      string
+		limit, want int
+	}{
+		{"capped", 50, 20},
+		{"capped", 0, 20},
+		{"capped", 10, 10},
+		{"other", 500, 100},
+		{"other", 0, 50},
+	}
+	for _, tc := range cases {
+		f := domain.SearchFilters{TenantID: tc.tenant, Limit: tc.limit}
+		h.applyLimit(req, &f)
+		if f.Limit != tc.want {
+			t.Errorf("%s limit=%d: got %d, want %d", tc.tenant, tc.limit, f.Limit, tc.want)
+		}
+	}
+}
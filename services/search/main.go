@@ -9,6 +9,7 @@ import (
 	"time"
 
 	_ "github.com/lib/pq"
+	"github.com/saidmashhud/zist/services/search/domain"
 	"github.com/saidmashhud/zist/services/search/handler"
 	"github.com/saidmashhud/zist/services/search/store"
 )
@@ -44,10 +45,21 @@ func main() {
 		os.Exit(1)
 	}
 
-	s := &server{
-		cfg: cfg,
-		h:   handler.New(store.New(db)),
+	h := handler.New(store.New(db)).
+		WithMarketplace(cfg.Marketplace).
+		WithRadiusLimits(cfg.DefaultRadiusKM, cfg.MaxRadiusKM).
+		WithRadiusLadder(cfg.RadiusLadderKM).
+		WithTenantConfig(cfg.AdminURL, cfg.InternalToken).
+		WithRanking(domain.Ranking{
+			RatingWeight: cfg.RatingWeight,
+			FreshBoost:   cfg.FreshBoost,
+			FreshDays:    cfg.FreshDays,
+		})
+	if cfg.QueryLogEnabled {
+		h.WithQueryLog(time.Duration(cfg.QueryLogRetentionDays)*24*time.Hour, cfg.QueryLogMaxRows)
+		go h.RunQueryLog(context.Background())
 	}
+	s := &server{cfg: cfg, h: h}
 
 	slog.Info("search service starting", "port", cfg.Port)
 	srv := &http.Server{
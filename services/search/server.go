@@ -32,10 +32,19 @@ func (s *server) routes() http.Handler {
 
 	r.Route("/search", func(r chi.Router) {
 		r.Get("/", s.h.Search)
+		r.Get("/facets", s.h.Facets)
+		r.With(zistauth.RequireAuth).Get("/insights", s.h.Insights)
 
 		// Internal: update listing location (called by listings service on create/update)
 		r.With(internal...).Put("/locations/{id}", s.h.UpdateLocation)
 	})
 
+	// Internal: projection maintenance (called by listings service)
+	r.Route("/internal/search", func(r chi.Router) {
+		r.Use(internal...)
+		r.Post("/index", s.h.IndexListing)
+		r.Delete("/index/{id}", s.h.RemoveListing)
+	})
+
 	return r
 }
@@ -2,15 +2,85 @@ package store
 
 import "database/sql"
 
-// Migrate ensures PostGIS extension and geographic column exist.
-// The search service reads the listings table owned by the listings service,
-// so it only adds the geographic column — it never creates the table.
+// Migrate creates the search_listings projection, the search_queries log
+// and their indexes.
+// The projection is owned by the search service and populated by the
+// listings service via POST /internal/search/index, so search never depends
+// on the listings table schema. Availability is still read from
+// listing_availability at query time because it changes with every booking.
 func Migrate(db *sql.DB) error {
 	stmts := []string{
 		`CREATE EXTENSION IF NOT EXISTS postgis`,
-		`ALTER TABLE listings ADD COLUMN IF NOT EXISTS location GEOMETRY(POINT, 4326)`,
-		`CREATE INDEX IF NOT EXISTS idx_listings_location ON listings USING GIST(location) WHERE location IS NOT NULL`,
-		`CREATE INDEX IF NOT EXISTS idx_listings_search ON listings(status, city, max_guests, instant_book, average_rating DESC)`,
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		`CREATE TABLE IF NOT EXISTS search_listings (
+			id              TEXT PRIMARY KEY,
+			tenant_id       TEXT    NOT NULL DEFAULT '',
+			host_id         TEXT    NOT NULL DEFAULT '',
+			title           TEXT    NOT NULL DEFAULT '',
+			city            TEXT    NOT NULL DEFAULT '',
+			country         TEXT    NOT NULL DEFAULT '',
+			type            TEXT    NOT NULL DEFAULT 'apartment',
+			price_per_night TEXT    NOT NULL DEFAULT '0',
+			currency        TEXT    NOT NULL DEFAULT 'USD',
+			max_guests      INT     NOT NULL DEFAULT 1,
+			instant_book    BOOLEAN NOT NULL DEFAULT false,
+			average_rating  FLOAT8  NOT NULL DEFAULT 0,
+			review_count    INT     NOT NULL DEFAULT 0,
+			amenities       JSONB   NOT NULL DEFAULT '[]',
+			cover_photo     TEXT    NOT NULL DEFAULT '',
+			status          TEXT    NOT NULL DEFAULT 'draft',
+			location        GEOMETRY(POINT, 4326),
+			created_at      BIGINT  NOT NULL DEFAULT 0,
+			updated_at      BIGINT  NOT NULL DEFAULT 0,
+			indexed_at      BIGINT  NOT NULL DEFAULT 0
+		)`,
+		`ALTER TABLE search_listings ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT ''`,
+		// Tombstones for deleted listings: a document that arrives after its
+		// deletion, and is not newer than it, must not bring the row back.
+		`CREATE TABLE IF NOT EXISTS search_listing_tombstones (
+			id         TEXT PRIMARY KEY,
+			deleted_at BIGINT NOT NULL
+		)`,
+		// Points set before the projection existed live in the legacy
+		// listings.location column; seed them so geo search keeps working
+		// until the listings are reindexed. Upsert keeps a seeded point when
+		// a document has no lat/lng.
+		`DO $$
+		BEGIN
+			IF EXISTS (SELECT 1 FROM information_schema.columns
+			           WHERE table_name = 'listings' AND column_name = 'location') THEN
+				EXECUTE 'INSERT INTO search_listings (id, tenant_id, location)
+				         SELECT id, tenant_id, location FROM listings WHERE location IS NOT NULL
+				         ON CONFLICT (id) DO UPDATE
+				             SET location = COALESCE(search_listings.location, EXCLUDED.location)';
+			END IF;
+		END $$`,
+		`CREATE INDEX IF NOT EXISTS idx_search_listings_location ON search_listings USING GIST(location) WHERE location IS NOT NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_search_listings_filters ON search_listings(status, city, max_guests, instant_book, average_rating DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_search_listings_tenant ON search_listings(tenant_id, status, city)`,
+		// Fuzzy city matching (fuzzy=true) uses the trigram % operator.
+		`CREATE INDEX IF NOT EXISTS idx_search_listings_city_trgm ON search_listings USING GIN (LOWER(city) gin_trgm_ops)`,
+		// Logged searches for demand analytics; no user or IP is recorded.
+		`CREATE TABLE IF NOT EXISTS search_queries (
+			id           BIGSERIAL PRIMARY KEY,
+			tenant_id    TEXT    NOT NULL DEFAULT '',
+			city         TEXT    NOT NULL DEFAULT '',
+			lat          FLOAT8,
+			lng          FLOAT8,
+			radius_km    FLOAT8  NOT NULL DEFAULT 0,
+			check_in     TEXT    NOT NULL DEFAULT '',
+			check_out    TEXT    NOT NULL DEFAULT '',
+			guests       INT     NOT NULL DEFAULT 0,
+			type         TEXT    NOT NULL DEFAULT '',
+			min_price    TEXT    NOT NULL DEFAULT '',
+			max_price    TEXT    NOT NULL DEFAULT '',
+			amenities    JSONB   NOT NULL DEFAULT '[]',
+			instant_book BOOLEAN NOT NULL DEFAULT false,
+			result_count INT     NOT NULL DEFAULT 0,
+			created_at   BIGINT  NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_search_queries_tenant_time ON search_queries(tenant_id, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_search_queries_created ON search_queries(created_at)`,
 	}
 	for _, stmt := range stmts {
 		if _, err := db.Exec(stmt); err != nil {
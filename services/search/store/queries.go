@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/saidmashhud/zist/services/search/domain"
+)
+
+// ─── search_queries ──────────────────────────────────────────────────────────
+
+// LogQuery stores one search for demand analytics.
+func (s *Store) LogQuery(ctx context.Context, q domain.QueryRecord) error {
+	amenities, _ := json.Marshal(q.Amenities)
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO search_queries
+			(tenant_id, city, lat, lng, radius_km, check_in, check_out, guests, type,
+			 min_price, max_price, amenities, instant_book, result_count, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15)`,
+		q.TenantID, q.City, q.Lat, q.Lng, q.RadiusKM, q.CheckIn, q.CheckOut, q.Guests, q.Type,
+		q.MinPrice, q.MaxPrice, string(amenities), q.InstantBook, q.ResultCount, q.CreatedAt)
+	return err
+}
+
+// PruneQueries deletes logged searches older than before and, beyond that,
+// all but the newest maxRows (0 = no row cap). It returns the rows deleted.
+func (s *Store) PruneQueries(ctx context.Context, before int64, maxRows int) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM search_queries WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	if maxRows <= 0 {
+		return n, nil
+	}
+	res, err = s.db.ExecContext(ctx, `
+		DELETE FROM search_queries
+		WHERE id <= (SELECT id FROM search_queries ORDER BY id DESC OFFSET $1 LIMIT 1)`, maxRows)
+	if err != nil {
+		return n, err
+	}
+	m, _ := res.RowsAffected()
+	return n + m, nil
+}
+
+// QueryInsights summarises searches logged since `since`: the most searched
+// cities and the most frequent zero-result searches, limit of each. An empty
+// tenantID covers every tenant.
+func (s *Store) QueryInsights(ctx context.Context, tenantID string, since int64, limit int) (domain.QueryInsights, error) {
+	out := domain.QueryInsights{
+		Since:             since,
+		TopCities:         []domain.CityDemand{},
+		ZeroResultQueries: []domain.ZeroResultQuery{},
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT city, COUNT(*), COUNT(*) FILTER (WHERE result_count = 0)
+		FROM search_queries
+		WHERE ($1 = '' OR tenant_id = $1) AND created_at >= $2 AND city <> ''
+		GROUP BY city
+		ORDER BY 2 DESC, city
+		LIMIT $3`, tenantID, since, limit)
+	if err != nil {
+		return out, err
+	}
+	for rows.Next() {
+		var c domain.CityDemand
+		if err := rows.Scan(&c.City, &c.Searches, &c.ZeroResults); err != nil {
+			rows.Close()
+			return out, err
+		}
+		out.TopCities = append(out.TopCities, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return out, err
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT city, type, guests, COUNT(*)
+		FROM search_queries
+		WHERE ($1 = '' OR tenant_id = $1) AND created_at >= $2 AND result_count = 0
+		GROUP BY city, type, guests
+		ORDER BY 4 DESC, city, type, guests
+		LIMIT $3`, tenantID, since, limit)
+	if err != nil {
+		return out, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var z domain.ZeroResultQuery
+		if err := rows.Scan(&z.City, &z.Type, &z.Guests, &z.Searches); err != nil {
+			return out, err
+		}
+		out.ZeroResultQueries = append(out.ZeroResultQueries, z)
+	}
+	return out, rows.Err()
+}
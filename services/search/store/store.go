@@ -4,29 +4,41 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/saidmashhud/zist/services/search/domain"
 )
 
-// Store provides read-only access to listings for search queries.
+// Store provides access to the search_listings projection.
 type Store struct{ db *sql.DB }
 
 // New creates a new Store backed by the given database connection.
 func New(db *sql.DB) *Store { return &Store{db: db} }
 
-// Search executes a filtered, sorted search over active listings.
-func (s *Store) Search(ctx context.Context, f domain.SearchFilters) ([]domain.SearchResult, int, error) {
-	var (
-		where []string
-		args  []any
-		idx   = 1
-	)
+// buildWhere translates filters into WHERE conditions and positional args.
+// It is shared by Search and Facets so that facet counts always match the
+// result set. The returned idx is the next free placeholder number.
+func buildWhere(f domain.SearchFilters) (where []string, args []any, idx int) {
+	idx = 1
 
 	where = append(where, "l.status = 'active'")
 
-	if f.City != "" {
+	if f.TenantID != "" {
+		where = append(where, fmt.Sprintf("l.tenant_id = $%d", idx))
+		args = append(args, f.TenantID)
+		idx++
+	}
+
+	if f.City != "" && f.FuzzyCity {
+		// pg_trgm similarity (default threshold 0.3) tolerates typos and
+		// extra words, e.g. "tashkend" or "Tashkent City" for Tashkent.
+		where = append(where, fmt.Sprintf("(LOWER(l.city) = LOWER($%d) OR LOWER(l.city) %% LOWER($%d))", idx, idx))
+		args = append(args, f.City)
+		idx++
+	} else if f.City != "" {
 		where = append(where, fmt.Sprintf("LOWER(l.city) = LOWER($%d)", idx))
 		args = append(args, f.City)
 		idx++
@@ -62,6 +74,11 @@ func (s *Store) Search(ctx context.Context, f domain.SearchFilters) ([]domain.Se
 	if f.InstantBookOnly {
 		where = append(where, "l.instant_book = true")
 	}
+	if f.MinRating > 0 {
+		where = append(where, fmt.Sprintf("l.average_rating >= $%d", idx))
+		args = append(args, f.MinRating)
+		idx++
+	}
 	if len(f.Amenities) > 0 {
 		where = append(where, fmt.Sprintf("l.amenities @> $%d::jsonb", idx))
 		b, _ := json.Marshal(f.Amenities)
@@ -80,6 +97,22 @@ func (s *Store) Search(ctx context.Context, f domain.SearchFilters) ([]domain.Se
 		args = append(args, f.CheckIn, f.CheckOut)
 		idx += 2
 	}
+	// Tonight is a one-night stay starting on the listing's local date.
+	if f.AvailableNow {
+		where = append(where, `NOT EXISTS (
+			SELECT 1 FROM listing_availability a
+			WHERE a.listing_id = l.id
+			  AND a.date = (now() AT TIME ZONE COALESCE(NULLIF(l.timezone, ''), 'UTC'))::date
+			  AND a.status IN ('blocked','booked')
+		)`)
+	}
+	return where, args, idx
+}
+
+// Search executes a filtered, sorted search over active listings. rank
+// orders results when f.SortBy is empty.
+func (s *Store) Search(ctx context.Context, f domain.SearchFilters, rank domain.Ranking) ([]domain.SearchResult, int, error) {
+	where, args, idx := buildWhere(f)
 
 	// Distance select expression
 	distExpr := "NULL::float8"
@@ -93,7 +126,20 @@ func (s *Store) Search(ctx context.Context, f domain.SearchFilters) ([]domain.Se
 	}
 
 	orderBy := "l.average_rating DESC, l.created_at DESC"
+	var rankArgs []any
 	switch f.SortBy {
+	case "":
+		// Same formula as domain.Ranking.Score. The weights are bound after
+		// the count query, which doesn't use them.
+		boost := rank.FreshBoost
+		if rank.FreshDays <= 0 {
+			boost = 0
+		}
+		orderBy = fmt.Sprintf(
+			"($%d::float8 * l.average_rating + CASE WHEN l.created_at >= $%d THEN $%d::float8 ELSE 0 END) DESC, l.created_at DESC",
+			idx, idx+1, idx+2)
+		rankArgs = []any{rank.RatingWeight, rank.FreshSince(time.Now()), boost}
+		idx += 3
 	case "price":
 		orderBy = "l.price_per_night::numeric ASC"
 	case "distance":
@@ -102,16 +148,28 @@ func (s *Store) Search(ctx context.Context, f domain.SearchFilters) ([]domain.Se
 		}
 	}
 
-	limit := f.Limit
-	if limit <= 0 || limit > 100 {
-		limit = 50
-	}
+	limit := domain.EffectiveLimit(f.Limit, 0)
 	offset := f.Offset
 	if offset < 0 {
 		offset = 0
 	}
 
-	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM listings l WHERE %s`, strings.Join(where, " AND "))
+	from, cond, moreExpr := "search_listings l", strings.Join(where, " AND "), "0"
+	if f.CollapseByHost {
+		// Keep each host's cheapest match and count the rest. Listings
+		// without a host are never grouped together.
+		const hostKey = "CASE WHEN l.host_id = '' THEN l.id ELSE l.host_id END"
+		from = fmt.Sprintf(`(
+			SELECT l.*,
+			       ROW_NUMBER() OVER (PARTITION BY %[1]s ORDER BY l.price_per_night::numeric ASC, l.id) AS host_rank,
+			       COUNT(*) OVER (PARTITION BY %[1]s) - 1 AS more_from_host
+			FROM search_listings l
+			WHERE %[2]s
+		) l`, hostKey, cond)
+		cond, moreExpr = "l.host_rank = 1", "l.more_from_host"
+	}
+
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE %s`, from, cond)
 	// Count uses the same args minus the distance-select args (last 2 if geo), but we reuse args here.
 	// Build separate arg lists for count (without the final distance args).
 	countArgs := args[:len(args)]
@@ -124,17 +182,17 @@ func (s *Store) Search(ctx context.Context, f domain.SearchFilters) ([]domain.Se
 		return nil, 0, fmt.Errorf("count: %w", err)
 	}
 
+	args = append(args, rankArgs...)
 	query := fmt.Sprintf(`
 		SELECT l.id, l.title, l.city, l.country, l.type,
 		       l.price_per_night, l.currency, l.max_guests, l.instant_book,
 		       l.average_rating, l.review_count, l.amenities,
-		       %s AS distance_km,
-		       (SELECT p.url FROM listing_photos p WHERE p.listing_id = l.id ORDER BY p.sort_order LIMIT 1) AS cover_photo
-		FROM listings l
+		       %s AS distance_km, l.cover_photo, %s
+		FROM %s
 		WHERE %s
 		ORDER BY %s
 		LIMIT %d OFFSET %d
-	`, distExpr, strings.Join(where, " AND "), orderBy, limit, offset)
+	`, distExpr, moreExpr, from, cond, orderBy, limit, offset)
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -147,12 +205,11 @@ func (s *Store) Search(ctx context.Context, f domain.SearchFilters) ([]domain.Se
 		var r domain.SearchResult
 		var amenitiesJSON string
 		var distKM sql.NullFloat64
-		var coverPhoto sql.NullString
 		if err := rows.Scan(
 			&r.ID, &r.Title, &r.City, &r.Country, &r.Type,
 			&r.PricePerNight, &r.Currency, &r.MaxGuests, &r.InstantBook,
 			&r.AverageRating, &r.ReviewCount, &amenitiesJSON,
-			&distKM, &coverPhoto,
+			&distKM, &r.CoverPhoto, &r.MoreFromHost,
 		); err != nil {
 			return nil, 0, fmt.Errorf("scan: %w", err)
 		}
@@ -163,9 +220,8 @@ func (s *Store) Search(ctx context.Context, f domain.SearchFilters) ([]domain.Se
 		if distKM.Valid {
 			d := distKM.Float64
 			r.DistanceKM = &d
-		}
-		if coverPhoto.Valid {
-			r.CoverPhoto = coverPhoto.String
+			du := domain.FromKM(d, f.Unit)
+			r.Distance = &du
 		}
 		results = append(results, r)
 	}
@@ -175,11 +231,215 @@ func (s *Store) Search(ctx context.Context, f domain.SearchFilters) ([]domain.Se
 	return results, total, rows.Err()
 }
 
+// Facets returns value counts for the sidebar filters over the same set of
+// listings Search would match. Each facet is capped at maxValues entries.
+func (s *Store) Facets(ctx context.Context, f domain.SearchFilters, maxValues int) (domain.FacetsResponse, error) {
+	where, args, _ := buildWhere(f)
+	cond := strings.Join(where, " AND ")
+
+	resp := domain.FacetsResponse{}
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM search_listings l WHERE `+cond, args...).Scan(&resp.Total); err != nil {
+		return resp, fmt.Errorf("facets count: %w", err)
+	}
+
+	var err error
+	if resp.Cities, err = s.facetCounts(ctx, fmt.Sprintf(`
+		SELECT l.city, COUNT(*) FROM search_listings l WHERE %s
+		GROUP BY l.city ORDER BY COUNT(*) DESC, l.city LIMIT %d`, cond, maxValues), args); err != nil {
+		return resp, fmt.Errorf("city facet: %w", err)
+	}
+	if resp.Types, err = s.facetCounts(ctx, fmt.Sprintf(`
+		SELECT l.type, COUNT(*) FROM search_listings l WHERE %s
+		GROUP BY l.type ORDER BY COUNT(*) DESC, l.type LIMIT %d`, cond, maxValues), args); err != nil {
+		return resp, fmt.Errorf("type facet: %w", err)
+	}
+	if resp.Amenities, err = s.facetCounts(ctx, fmt.Sprintf(`
+		SELECT a.amenity, COUNT(*) FROM search_listings l
+		CROSS JOIN LATERAL jsonb_array_elements_text(l.amenities) AS a(amenity)
+		WHERE %s
+		GROUP BY a.amenity ORDER BY COUNT(*) DESC, a.amenity LIMIT %d`, cond, maxValues), args); err != nil {
+		return resp, fmt.Errorf("amenity facet: %w", err)
+	}
+	if resp.PriceBuckets, err = s.priceBuckets(ctx, cond, args, resp.Total); err != nil {
+		return resp, fmt.Errorf("price facet: %w", err)
+	}
+	return resp, nil
+}
+
+func (s *Store) facetCounts(ctx context.Context, query string, args []any) ([]domain.FacetCount, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []domain.FacetCount{}
+	for rows.Next() {
+		var fc domain.FacetCount
+		if err := rows.Scan(&fc.Value, &fc.Count); err != nil {
+			return nil, err
+		}
+		out = append(out, fc)
+	}
+	return out, rows.Err()
+}
+
+// priceBuckets splits the matching price range into equal-width buckets.
+// Bounds are derived from the filtered set, so they work for any currency.
+func (s *Store) priceBuckets(ctx context.Context, cond string, args []any, total int) ([]domain.PriceBucket, error) {
+	var lo, hi sql.NullFloat64
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT MIN(l.price_per_night::numeric), MAX(l.price_per_night::numeric)
+		 FROM search_listings l WHERE `+cond, args...).Scan(&lo, &hi); err != nil {
+		return nil, err
+	}
+	out := []domain.PriceBucket{}
+	if !lo.Valid || !hi.Valid {
+		return out, nil
+	}
+	if hi.Float64 <= lo.Float64 {
+		// All matches share one price — a single bucket.
+		return append(out, domain.PriceBucket{
+			Min:   fmt.Sprintf("%.2f", lo.Float64),
+			Max:   fmt.Sprintf("%.2f", hi.Float64),
+			Count: total,
+		}), nil
+	}
+
+	width := (hi.Float64 - lo.Float64) / priceBucketCount
+	n := len(args)
+	// width_bucket puts the max value in bucket count+1; LEAST folds it back.
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT LEAST(width_bucket(l.price_per_night::numeric, $%d, $%d, %d), %d) AS bucket, COUNT(*)
+		FROM search_listings l WHERE %s
+		GROUP BY bucket ORDER BY bucket`,
+		n+1, n+2, priceBucketCount, priceBucketCount, cond),
+		append(args, lo.Float64, hi.Float64)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bucket, count int
+		if err := rows.Scan(&bucket, &count); err != nil {
+			return nil, err
+		}
+		start := lo.Float64 + width*float64(bucket-1)
+		out = append(out, domain.PriceBucket{
+			Min:   fmt.Sprintf("%.2f", start),
+			Max:   fmt.Sprintf("%.2f", start+width),
+			Count: count,
+		})
+	}
+	return out, rows.Err()
+}
+
+// priceBucketCount is the number of equal-width price ranges in the facet.
+const priceBucketCount = 5
+
 // UpdateLocation sets the PostGIS point for a listing (called via internal API).
+// A placeholder row is created if the listing has not been indexed yet; it
+// stays invisible to search until an index call sets its status.
 func (s *Store) UpdateLocation(ctx context.Context, listingID string, lat, lng float64) error {
 	_, err := s.db.ExecContext(ctx,
-		`UPDATE listings SET location = ST_SetSRID(ST_MakePoint($1, $2), 4326) WHERE id = $3`,
+		`INSERT INTO search_listings (id, location) VALUES ($3, ST_SetSRID(ST_MakePoint($1, $2), 4326))
+		 ON CONFLICT (id) DO UPDATE SET location = EXCLUDED.location`,
 		lng, lat, listingID,
 	)
 	return err
 }
+
+// Upsert writes a listing document into the projection. It is idempotent on
+// listing id. Location is only written when the document carries lat/lng;
+// otherwise the point set by UpdateLocation is kept. A document no newer than
+// the listing's deletion tombstone is dropped.
+func (s *Store) Upsert(ctx context.Context, d domain.IndexDocument) error {
+	amenities := d.Amenities
+	if amenities == nil {
+		amenities = []string{}
+	}
+	amenitiesJSON, _ := json.Marshal(amenities)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if err := lockListing(ctx, tx, d.ID); err != nil {
+		return err
+	}
+	var deletedAt int64
+	err = tx.QueryRowContext(ctx,
+		`SELECT deleted_at FROM search_listing_tombstones WHERE id = $1`, d.ID).Scan(&deletedAt)
+	switch {
+	case err == nil && d.UpdatedAt <= deletedAt:
+		return nil // stale: the listing was deleted after this version
+	case err != nil && !errors.Is(err, sql.ErrNoRows):
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO search_listings
+			(id, tenant_id, host_id, title, city, country, type,
+			 price_per_night, currency, max_guests, instant_book,
+			 average_rating, review_count, amenities, cover_photo, status,
+			 created_at, updated_at, indexed_at, location, timezone)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,
+			CASE WHEN $20::float8 IS NULL OR $21::float8 IS NULL THEN NULL
+			     ELSE ST_SetSRID(ST_MakePoint($21::float8, $20::float8), 4326) END, $22)
+		ON CONFLICT (id) DO UPDATE SET
+			tenant_id = EXCLUDED.tenant_id, host_id = EXCLUDED.host_id,
+			title = EXCLUDED.title, city = EXCLUDED.city, country = EXCLUDED.country,
+			type = EXCLUDED.type, price_per_night = EXCLUDED.price_per_night,
+			currency = EXCLUDED.currency, max_guests = EXCLUDED.max_guests,
+			instant_book = EXCLUDED.instant_book, average_rating = EXCLUDED.average_rating,
+			review_count = EXCLUDED.review_count, amenities = EXCLUDED.amenities,
+			cover_photo = EXCLUDED.cover_photo, status = EXCLUDED.status,
+			created_at = EXCLUDED.created_at, updated_at = EXCLUDED.updated_at,
+			indexed_at = EXCLUDED.indexed_at, timezone = EXCLUDED.timezone,
+			location = COALESCE(EXCLUDED.location, search_listings.location)
+		WHERE search_listings.updated_at <= EXCLUDED.updated_at`,
+		d.ID, d.TenantID, d.HostID, d.Title, d.City, d.Country, d.Type,
+		d.PricePerNight, d.Currency, d.MaxGuests, d.InstantBook,
+		d.AverageRating, d.ReviewCount, string(amenitiesJSON), d.CoverPhoto, d.Status,
+		d.CreatedAt, d.UpdatedAt, time.Now().Unix(), d.Lat, d.Lng, d.Timezone,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Remove deletes a listing from the projection and records a tombstone at
+// deletedAt, so Upsert ignores any document not newer than the deletion.
+// Removing an absent id is not an error.
+func (s *Store) Remove(ctx context.Context, listingID string, deletedAt int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if err := lockListing(ctx, tx, listingID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO search_listing_tombstones (id, deleted_at) VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE
+			SET deleted_at = GREATEST(search_listing_tombstones.deleted_at, EXCLUDED.deleted_at)`,
+		listingID, deletedAt); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM search_listings WHERE id = $1`, listingID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// lockListing serialises Upsert and Remove for one listing id, so a
+// document can't be written between a tombstone check and a deletion.
+func lockListing(ctx context.Context, tx *sql.Tx, listingID string) error {
+	_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "search_listings:"+listingID)
+	return err
+}
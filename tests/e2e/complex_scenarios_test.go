@@ -8,11 +8,18 @@
 package e2e
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // ---------------------------------------------------------------------------
@@ -184,7 +191,6 @@ func TestFullBookingLifecycleWithApproval(t *testing.T) {
 	review := map[string]any{
 		"bookingId": bookingID,
 		"listingId": listingID,
-		"hostId":    hostUser.UserID,
 		"rating":    5,
 		"comment":   "Absolutely wonderful stay! The mountains were breathtaking.",
 	}
@@ -992,7 +998,6 @@ func TestReviewConstraints(t *testing.T) {
 	review := map[string]any{
 		"bookingId": bookingID,
 		"listingId": listingID,
-		"hostId":    hostUser.UserID,
 		"rating":    4,
 		"comment":   "Great place!",
 	}
@@ -1180,19 +1185,19 @@ func TestSearchWithFilters(t *testing.T) {
 	}
 
 	// Search by city
-	status, _ := get(t, searchURL()+"/search?city=Tashkent", nil)
+	status, _ := get(t, searchURL()+"/search?city=Tashkent", authHeaders(defaultUser))
 	if status != http.StatusOK {
 		t.Fatalf("search by city: want 200, got %d", status)
 	}
 
 	// Search by price range
-	status, _ = get(t, searchURL()+"/search?min_price=100000&max_price=200000", nil)
+	status, _ = get(t, searchURL()+"/search?min_price=100000&max_price=200000", authHeaders(defaultUser))
 	if status != http.StatusOK {
 		t.Fatalf("search by price: want 200, got %d", status)
 	}
 
 	// Search instant book only
-	status, _ = get(t, searchURL()+"/search?instant_book=true", nil)
+	status, _ = get(t, searchURL()+"/search?instant_book=true", authHeaders(defaultUser))
 	if status != http.StatusOK {
 		t.Fatalf("search instant: want 200, got %d", status)
 	}
@@ -1237,31 +1242,44 @@ func TestInvalidStateTransitions(t *testing.T) {
 
 	// payment_pending → cannot approve (not pending_host_approval)
 	status, _ := post(t, bookingsURL()+"/bookings/"+bookingID+"/approve", nil, authHeaders(hostUser))
-	if status != http.StatusConflict && status != http.StatusNotFound {
-		t.Errorf("approve payment_pending: want 409 or 404, got %d", status)
+	if status != http.StatusConflict {
+		t.Errorf("approve payment_pending: want 409, got %d", status)
 	}
 
 	// payment_pending → cannot reject
 	status, _ = post(t, bookingsURL()+"/bookings/"+bookingID+"/reject", nil, authHeaders(hostUser))
-	if status != http.StatusConflict && status != http.StatusNotFound {
-		t.Errorf("reject payment_pending: want 409 or 404, got %d", status)
+	if status != http.StatusConflict {
+		t.Errorf("reject payment_pending: want 409, got %d", status)
 	}
 
 	// Confirm → confirmed
 	post(t, bookingsURL()+"/bookings/"+bookingID+"/confirm",
 		map[string]any{"paymentId": "pay_sm"}, internalHeaders())
 
-	// confirmed → cannot confirm again
-	status, _ = post(t, bookingsURL()+"/bookings/"+bookingID+"/confirm",
+	// confirmed → cannot confirm again; 409 names current and required states
+	status, resp = post(t, bookingsURL()+"/bookings/"+bookingID+"/confirm",
 		map[string]any{"paymentId": "pay_sm2"}, internalHeaders())
-	if status == http.StatusNoContent {
-		t.Error("should not be able to confirm an already confirmed booking")
+	if status != http.StatusConflict {
+		t.Errorf("confirm confirmed: want 409, got %d: %s", status, resp)
+	}
+	if got := jsonField(t, resp, "status"); got != "confirmed" {
+		t.Errorf("confirm confirmed: want status=confirmed, got %q", got)
+	}
+	if req := jsonArray(t, resp, "requiredStatus"); len(req) != 1 || req[0] != "payment_pending" {
+		t.Errorf("confirm confirmed: want requiredStatus=[payment_pending], got %v", req)
 	}
 
 	// confirmed → cannot fail
 	status, _ = post(t, bookingsURL()+"/bookings/"+bookingID+"/fail", nil, internalHeaders())
-	if status == http.StatusNoContent {
-		t.Error("should not be able to fail an already confirmed booking")
+	if status != http.StatusConflict {
+		t.Errorf("fail confirmed: want 409, got %d", status)
+	}
+
+	// unknown booking → 404, not 409
+	status, _ = post(t, bookingsURL()+"/bookings/00000000-0000-0000-0000-000000000000/confirm",
+		map[string]any{"paymentId": "pay_none"}, internalHeaders())
+	if status != http.StatusNotFound {
+		t.Errorf("confirm unknown: want 404, got %d", status)
 	}
 
 	del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
@@ -1389,3 +1407,3616 @@ func TestMultiListingHostDashboard(t *testing.T) {
 func marshalJSON(v any) ([]byte, error) {
 	return json.Marshal(v)
 }
+
+// ===========================================================================
+// Scenario 21: Refundable Deposit
+//
+// Listing with a deposit → preview shows deposit line outside the fee base →
+// booking records it → guest cancels inside the no-refund window and still
+// gets the deposit back.
+// ===========================================================================
+
+func TestRefundableDeposit(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":              "Deposit Loft",
+		"city":               "Tashkent",
+		"country":            "UZ",
+		"pricePerNight":      "100000.00",
+		"deposit":            "50000.00",
+		"currency":           "UZS",
+		"maxGuests":          2,
+		"instantBook":        true,
+		"cancellationPolicy": "strict",
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/deposit.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+
+	checkIn := time.Now().AddDate(0, 0, 3).Format("2006-01-02")
+	checkOut := time.Now().AddDate(0, 0, 5).Format("2006-01-02")
+
+	status, resp := get(t, listingsURL()+"/listings/"+listingID+
+		"/price-preview?check_in="+checkIn+"&check_out="+checkOut, nil)
+	if status != http.StatusOK {
+		t.Fatalf("price preview: want 200, got %d: %s", status, resp)
+	}
+	if got := jsonField(t, resp, "deposit"); got != "50000.00" {
+		t.Errorf("preview deposit: want 50000.00, got %s", got)
+	}
+	fee := jsonField(t, resp, "platformFeeGuest")
+	previewTotal := jsonField(t, resp, "total")
+
+	status, resp = post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID,
+		"checkIn":   checkIn,
+		"checkOut":  checkOut,
+		"guests":    1,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("create booking: want 201, got %d: %s", status, resp)
+	}
+	bookingID := jsonField(t, resp, "id")
+	if got := jsonField(t, resp, "deposit"); got != "50000.00" {
+		t.Errorf("booking deposit: want 50000.00, got %s", got)
+	}
+	if got := jsonField(t, resp, "platformFee"); got != fee {
+		t.Errorf("platform fee: want %s (preview), got %s", fee, got)
+	}
+	if got := jsonField(t, resp, "totalAmount"); got != previewTotal {
+		t.Errorf("total: want %s (preview), got %s", previewTotal, got)
+	}
+
+	post(t, bookingsURL()+"/bookings/"+bookingID+"/confirm",
+		map[string]any{"paymentId": "pay_deposit"}, internalHeaders())
+
+	// Strict policy < 14 days out → 0% of the stay, but the deposit comes back.
+	status, resp = post(t, bookingsURL()+"/bookings/"+bookingID+"/cancel", nil, authHeaders(defaultUser))
+	if status != http.StatusOK {
+		t.Fatalf("cancel: want 200, got %d: %s", status, resp)
+	}
+	var out struct {
+		Refund struct {
+			RefundAmount  string `json:"refundAmount"`
+			RefundPct     int    `json:"refundPct"`
+			DepositRefund string `json:"depositRefund"`
+		} `json:"refund"`
+	}
+	if err := json.Unmarshal(resp, &out); err != nil {
+		t.Fatalf("decode cancel response: %v", err)
+	}
+	if out.Refund.RefundPct != 0 || out.Refund.DepositRefund != "50000.00" || out.Refund.RefundAmount != "50000.00" {
+		t.Errorf("want deposit-only refund of 50000.00, got %+v", out.Refund)
+	}
+}
+
+// ===========================================================================
+// Scenario 22: Host Books Own Paused Listing
+//
+// Paused listing → host can book it for an offline guest, other guests can't.
+// Draft listing → nobody can book it, not even the host.
+// ===========================================================================
+
+func TestHostBooksPausedListing(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Paused Cottage",
+		"city":          "Bukhara",
+		"country":       "UZ",
+		"pricePerNight": "150000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+
+	booking := map[string]any{
+		"listingId": listingID,
+		"checkIn":   "2028-06-01",
+		"checkOut":  "2028-06-03",
+		"guests":    1,
+	}
+
+	// Draft: unbookable by anyone.
+	if status, _ := post(t, bookingsURL()+"/bookings", booking, authHeaders(hostUser)); status != http.StatusUnprocessableEntity {
+		t.Errorf("host books draft: want 422, got %d", status)
+	}
+
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/paused.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/unpublish", nil, authHeaders(hostUser))
+
+	if status, resp := post(t, bookingsURL()+"/bookings", booking, authHeaders(defaultUser)); status != http.StatusUnprocessableEntity {
+		t.Errorf("guest books paused: want 422, got %d: %s", status, resp)
+	}
+
+	status, resp := post(t, bookingsURL()+"/bookings", booking, authHeaders(hostUser))
+	if status != http.StatusCreated {
+		t.Fatalf("host books paused: want 201, got %d: %s", status, resp)
+	}
+	if got := jsonField(t, resp, "guestId"); got != hostUser.UserID {
+		t.Errorf("guestId: want %s, got %s", hostUser.UserID, got)
+	}
+}
+
+// ===========================================================================
+// Scenario 23: Parallel Overlapping Reservations
+//
+// Fire two overlapping reservations at the listings internal endpoint at the
+// same time, many rounds over. Exactly one must win each round.
+// ===========================================================================
+
+func TestParallelReservationsSerialize(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Race Condition Loft",
+		"city":          "Tashkent",
+		"country":       "UZ",
+		"pricePerNight": "100000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+
+	reserve := func(bookingID string, dates []string) (int, error) {
+		body, _ := json.Marshal(map[string]any{"bookingId": bookingID, "dates": dates})
+		req, err := http.NewRequest(http.MethodPost,
+			listingsURL()+"/listings/"+listingID+"/availability/book", bytes.NewReader(body))
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range internalHeaders() {
+			req.Header.Set(k, v)
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	start := time.Date(2029, 1, 1, 0, 0, 0, 0, time.UTC)
+	for round := 0; round < 10; round++ {
+		base := start.AddDate(0, 0, round*10)
+		day := func(n int) string { return base.AddDate(0, 0, n).Format("2006-01-02") }
+		// Overlap on day 2 only, so a naive check-then-insert can let both through.
+		attempts := [][]string{
+			{day(0), day(1), day(2)},
+			{day(2), day(3), day(4)},
+		}
+
+		var wg sync.WaitGroup
+		statuses := make([]int, len(attempts))
+		errs := make([]error, len(attempts))
+		for i, dates := range attempts {
+			wg.Add(1)
+			go func(i int, dates []string) {
+				defer wg.Done()
+				statuses[i], errs[i] = reserve(fmt.Sprintf("race-%d-%d", round, i), dates)
+			}(i, dates)
+		}
+		wg.Wait()
+
+		var ok, conflict int
+		for i, st := range statuses {
+			if errs[i] != nil {
+				t.Fatalf("round %d: reservation %d: %v", round, i, errs[i])
+			}
+			switch st {
+			case http.StatusOK:
+				ok++
+			case http.StatusConflict:
+				conflict++
+			default:
+				t.Fatalf("round %d: unexpected status %d", round, st)
+			}
+		}
+		if ok != 1 || conflict != 1 {
+			t.Errorf("round %d: want exactly one success and one conflict, got %d/%d", round, ok, conflict)
+		}
+	}
+}
+
+// ===========================================================================
+// Scenario 24: Webhook Dead Letters
+//
+// A signed payment.captured event for a booking that doesn't exist is
+// acknowledged with 200 but lands in the dead-letter table. An admin can
+// list it and reprocess it; the retry fails again and stays pending.
+// ===========================================================================
+
+func TestWebhookDeadLetters(t *testing.T) {
+	bookingID := fmt.Sprintf("bk_missing_%d", time.Now().UnixNano())
+	eventID := "evt_dead_" + bookingID
+	webhookPayload := map[string]any{
+		"event_id":     eventID,
+		"event_type":   "payment.captured",
+		"aggregate_id": "pay_dead_letter",
+		"tenant_id":    defaultUser.TenantID,
+		"data": map[string]any{
+			"metadata": map[string]any{"bookingId": bookingID},
+		},
+	}
+	payloadJSON, _ := marshalJSON(webhookPayload)
+
+	status, resp := post(t, paymentsURL()+"/webhooks/mashgate", webhookPayload, webhookHeaders(payloadJSON))
+	if status != http.StatusOK {
+		t.Fatalf("webhook: want 200, got %d: %s", status, resp)
+	}
+
+	// Non-admins can't see dead letters.
+	status, _ = get(t, paymentsURL()+"/admin/webhooks/dead-letters", authHeaders(defaultUser))
+	if status != http.StatusForbidden {
+		t.Errorf("non-admin list: want 403, got %d", status)
+	}
+
+	status, resp = get(t, paymentsURL()+"/admin/webhooks/dead-letters?status=pending&limit=200", authHeaders(adminUser))
+	if status == http.StatusServiceUnavailable {
+		t.Skip("payments running without DATABASE_URL; dead letters disabled")
+	}
+	if status != http.StatusOK {
+		t.Fatalf("list dead letters: want 200, got %d: %s", status, resp)
+	}
+	var list struct {
+		DeadLetters []struct {
+			ID       string            `json:"id"`
+			EventID  string            `json:"eventId"`
+			Reason   string            `json:"reason"`
+			Status   string            `json:"status"`
+			Attempts int               `json:"attempts"`
+			Headers  map[string]string `json:"headers"`
+		} `json:"deadLetters"`
+	}
+	if err := json.Unmarshal(resp, &list); err != nil {
+		t.Fatalf("decode dead letters: %v", err)
+	}
+	var deadID string
+	for _, d := range list.DeadLetters {
+		if d.EventID != eventID {
+			continue
+		}
+		deadID = d.ID
+		if d.Reason == "" {
+			t.Error("dead letter should record a reason")
+		}
+		if d.Attempts != 0 {
+			t.Errorf("fresh dead letter: want 0 attempts, got %d", d.Attempts)
+		}
+		if _, ok := d.Headers["Authorization"]; ok {
+			t.Error("Authorization header must not be persisted")
+		}
+	}
+	if deadID == "" {
+		t.Fatalf("event %s not found among pending dead letters", eventID)
+	}
+
+	// The booking still doesn't exist, so reprocessing fails and stays pending.
+	status, resp = post(t, paymentsURL()+"/admin/webhooks/dead-letters/"+deadID+"/reprocess", nil, authHeaders(adminUser))
+	if status != http.StatusBadGateway {
+		t.Fatalf("reprocess: want 502, got %d: %s", status, resp)
+	}
+
+	status, resp = get(t, paymentsURL()+"/admin/webhooks/dead-letters?status=pending&limit=200", authHeaders(adminUser))
+	if status != http.StatusOK {
+		t.Fatalf("relist: want 200, got %d", status)
+	}
+	if err := json.Unmarshal(resp, &list); err != nil {
+		t.Fatalf("decode dead letters: %v", err)
+	}
+	for _, d := range list.DeadLetters {
+		if d.ID == deadID && d.Attempts != 1 {
+			t.Errorf("after reprocess: want 1 attempt, got %d", d.Attempts)
+		}
+	}
+
+	status, _ = post(t, paymentsURL()+"/admin/webhooks/dead-letters/does-not-exist/reprocess", nil, authHeaders(adminUser))
+	if status != http.StatusNotFound {
+		t.Errorf("reprocess unknown: want 404, got %d", status)
+	}
+}
+
+// ===========================================================================
+// Scenario 25: Per-Listing Payment Window
+//
+// A listing with a 3-day payment window hands that window to both
+// instant-book bookings (expiresAt at creation) and approved requests
+// (expiresAt at approval). Out-of-range windows are rejected.
+// ===========================================================================
+
+func TestPaymentWindowPerListing(t *testing.T) {
+	const window = 3 * 24 * 60
+
+	status, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":                "Long Window Villa",
+		"city":                 "Samarkand",
+		"pricePerNight":        "900000.00",
+		"currency":             "UZS",
+		"maxGuests":            4,
+		"instantBook":          true,
+		"paymentWindowMinutes": 99999,
+	}, authHeaders(hostUser))
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("out-of-range window: want 422, got %d: %s", status, resp)
+	}
+
+	_, resp = post(t, listingsURL()+"/listings", map[string]any{
+		"title":                "Long Window Villa",
+		"city":                 "Samarkand",
+		"pricePerNight":        "900000.00",
+		"currency":             "UZS",
+		"maxGuests":            4,
+		"instantBook":          true,
+		"paymentWindowMinutes": window,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	if got := jsonField(t, resp, "paymentWindowMinutes"); got != fmt.Sprint(window) {
+		t.Fatalf("listing paymentWindowMinutes: want %d, got %s", window, got)
+	}
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/window.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	// Instant book: expiresAt is set at creation from the listing window.
+	before := time.Now().Unix()
+	status, resp = post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID,
+		"checkIn":   "2029-03-01",
+		"checkOut":  "2029-03-03",
+		"guests":    2,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("instant book: want 201, got %d: %s", status, resp)
+	}
+	var instant struct {
+		ExpiresAt            int64 `json:"expiresAt"`
+		PaymentWindowMinutes int   `json:"paymentWindowMinutes"`
+	}
+	if err := json.Unmarshal(resp, &instant); err != nil {
+		t.Fatalf("decode booking: %v", err)
+	}
+	if instant.PaymentWindowMinutes != window {
+		t.Errorf("instant booking window: want %d, got %d", window, instant.PaymentWindowMinutes)
+	}
+	if want := before + window*60; instant.ExpiresAt < want || instant.ExpiresAt > want+60 {
+		t.Errorf("instant booking expiresAt: want ~%d, got %d", want, instant.ExpiresAt)
+	}
+
+	// Request-to-book: the window applies when the host approves.
+	put(t, listingsURL()+"/listings/"+listingID, map[string]any{"instantBook": false}, authHeaders(hostUser))
+	status, resp = post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID,
+		"checkIn":   "2029-04-01",
+		"checkOut":  "2029-04-03",
+		"guests":    2,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("request booking: want 201, got %d: %s", status, resp)
+	}
+	bookingID := jsonField(t, resp, "id")
+
+	before = time.Now().Unix()
+	status, resp = post(t, bookingsURL()+"/bookings/"+bookingID+"/approve", nil, authHeaders(hostUser))
+	if status != http.StatusOK {
+		t.Fatalf("approve: want 200, got %d: %s", status, resp)
+	}
+	var approved struct {
+		ExpiresAt            int64 `json:"expiresAt"`
+		PaymentWindowMinutes int   `json:"paymentWindowMinutes"`
+	}
+	if err := json.Unmarshal(resp, &approved); err != nil {
+		t.Fatalf("decode approve: %v", err)
+	}
+	if approved.PaymentWindowMinutes != window {
+		t.Errorf("approve window: want %d, got %d", window, approved.PaymentWindowMinutes)
+	}
+	if want := before + window*60; approved.ExpiresAt < want || approved.ExpiresAt > want+60 {
+		t.Errorf("approve expiresAt: want ~%d, got %d", want, approved.ExpiresAt)
+	}
+}
+
+// ===========================================================================
+// Scenario 26: Resume a Lapsed Checkout
+//
+// A payment_pending booking can get a fresh checkout session; only its
+// guest may ask, and bookings in any other state are rejected.
+// ===========================================================================
+
+func TestResumeCheckout(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Resume Checkout Flat",
+		"city":          "Bukhara",
+		"pricePerNight": "300000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+		"instantBook":   true,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/resume.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID,
+		"checkIn":   "2029-05-10",
+		"checkOut":  "2029-05-12",
+		"guests":    1,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("create booking: want 201, got %d: %s", status, resp)
+	}
+	bookingID := jsonField(t, resp, "id")
+	resumeURL := paymentsURL() + "/checkout/" + bookingID + "/resume"
+
+	status, _ = post(t, resumeURL, nil, authHeaders(guestUser2))
+	if status != http.StatusForbidden {
+		t.Errorf("resume by other guest: want 403, got %d", status)
+	}
+
+	status, resp = post(t, resumeURL, map[string]any{
+		"successUrl": "http://localhost:3000/success",
+		"cancelUrl":  "http://localhost:3000/cancel",
+	}, authHeaders(defaultUser))
+	// 201 if Mashgate is running, 502 if unavailable — both are valid.
+	if status != http.StatusCreated && status != http.StatusBadGateway {
+		t.Fatalf("resume: want 201 or 502, got %d: %s", status, resp)
+	}
+	if status == http.StatusCreated {
+		if jsonField(t, resp, "checkoutUrl") == "" {
+			t.Error("resume response missing checkoutUrl")
+		}
+		_, resp = get(t, bookingsURL()+"/bookings/"+bookingID, authHeaders(defaultUser))
+		if jsonField(t, resp, "checkoutId") == "" {
+			t.Error("resumed checkout id should be stored on the booking")
+		}
+	}
+
+	// Once cancelled, the booking is no longer awaiting payment.
+	post(t, bookingsURL()+"/bookings/"+bookingID+"/cancel", nil, authHeaders(defaultUser))
+	status, _ = post(t, resumeURL, nil, authHeaders(defaultUser))
+	if status != http.StatusConflict {
+		t.Errorf("resume cancelled booking: want 409, got %d", status)
+	}
+
+	status, _ = post(t, paymentsURL()+"/checkout/does-not-exist/resume", nil, authHeaders(defaultUser))
+	if status != http.StatusNotFound {
+		t.Errorf("resume unknown booking: want 404, got %d", status)
+	}
+}
+
+// ===========================================================================
+// Scenario 27: Payment Status Tracking
+//
+// paymentStatus follows Mashgate events independently of booking status:
+// none → pending (checkout.completed) → captured → partially_refunded →
+// refunded. A late checkout.completed must not move a captured payment
+// backwards.
+// ===========================================================================
+
+func TestPaymentStatusTracking(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Payment Status Studio",
+		"city":          "Tashkent",
+		"pricePerNight": "200000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+		"instantBook":   true,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/paystatus.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID,
+		"checkIn":   "2029-06-01",
+		"checkOut":  "2029-06-03",
+		"guests":    1,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("create booking: want 201, got %d: %s", status, resp)
+	}
+	bookingID := jsonField(t, resp, "id")
+	if got := jsonField(t, resp, "paymentStatus"); got != "none" {
+		t.Errorf("new booking paymentStatus: want none, got %q", got)
+	}
+
+	send := func(eventType string, extra ...map[string]any) {
+		t.Helper()
+		data := map[string]any{
+			"metadata": map[string]any{"bookingId": bookingID},
+		}
+		for _, e := range extra {
+			for k, v := range e {
+				data[k] = v
+			}
+		}
+		payload := map[string]any{
+			"event_id":     fmt.Sprintf("evt_paystatus_%s_%s_%d", eventType, bookingID, time.Now().UnixNano()),
+			"event_type":   eventType,
+			"aggregate_id": "pay_paystatus_" + bookingID,
+			"tenant_id":    defaultUser.TenantID,
+			"data":         data,
+		}
+		raw, _ := marshalJSON(payload)
+		status, resp := post(t, paymentsURL()+"/webhooks/mashgate", payload, webhookHeaders(raw))
+		if status != http.StatusOK {
+			t.Fatalf("%s webhook: want 200, got %d: %s", eventType, status, resp)
+		}
+	}
+	expect := func(wantStatus, wantPayment string) {
+		t.Helper()
+		_, resp := get(t, bookingsURL()+"/bookings/"+bookingID, authHeaders(defaultUser))
+		if got := jsonField(t, resp, "status"); got != wantStatus {
+			t.Errorf("status: want %s, got %s", wantStatus, got)
+		}
+		if got := jsonField(t, resp, "paymentStatus"); got != wantPayment {
+			t.Errorf("paymentStatus: want %s, got %s", wantPayment, got)
+		}
+	}
+
+	send("checkout.completed")
+	expect("payment_pending", "pending")
+
+	send("payment.captured")
+	expect("confirmed", "captured")
+
+	// Out-of-order delivery: a late checkout.completed is ignored.
+	send("checkout.completed")
+	expect("confirmed", "captured")
+
+	// A partial refund leaves the payment partially refunded; the rest
+	// (an event without an amount refunds in full) completes it.
+	send("refund.settled", map[string]any{"amount": map[string]any{"amount": "1000.00", "currency": "UZS"}})
+	expect("confirmed", "partially_refunded")
+	_, resp = get(t, bookingsURL()+"/bookings/"+bookingID, authHeaders(defaultUser))
+	if got := jsonField(t, resp, "refundedAmount"); got != "1000" && got != "1000.00" {
+		t.Errorf("refundedAmount: want 1000.00, got %q", got)
+	}
+
+	send("refund.settled")
+	expect("confirmed", "refunded")
+}
+
+// ===========================================================================
+// Scenario 28: Admin Read of Any Booking
+//
+// Support staff with zist.admin can read a booking they are not party to,
+// and the access lands in the admin audit log. Other users still get 403.
+// ===========================================================================
+
+func TestAdminReadsAnyBooking(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Support Lookup Loft",
+		"city":          "Tashkent",
+		"pricePerNight": "150000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/support.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID,
+		"checkIn":   "2029-07-01",
+		"checkOut":  "2029-07-03",
+		"guests":    1,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("create booking: want 201, got %d: %s", status, resp)
+	}
+	bookingID := jsonField(t, resp, "id")
+
+	status, _ = get(t, bookingsURL()+"/bookings/"+bookingID, authHeaders(guestUser2))
+	if status != http.StatusForbidden {
+		t.Errorf("non-admin read: want 403, got %d", status)
+	}
+
+	status, resp = get(t, bookingsURL()+"/bookings/"+bookingID, authHeaders(adminUser))
+	if status != http.StatusOK {
+		t.Fatalf("admin read: want 200, got %d: %s", status, resp)
+	}
+	if jsonField(t, resp, "id") != bookingID {
+		t.Errorf("admin read returned wrong booking")
+	}
+
+	status, resp = get(t, adminURL()+"/admin/audit?actor_id="+adminUser.UserID+"&limit=500", authHeaders(adminUser))
+	if status != http.StatusOK {
+		t.Fatalf("audit log: want 200, got %d", status)
+	}
+	found := false
+	for _, e := range jsonArray(t, resp, "entries") {
+		entry, _ := e.(map[string]any)
+		if entry["action"] == "read_booking" && entry["resource"] == "booking:"+bookingID {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected read_booking audit entry for booking:%s", bookingID)
+	}
+}
+
+// ===========================================================================
+// Scenario 29: Change Guest Count
+//
+// A guest can change the party size on a pending booking within the
+// listing's capacity. Over-capacity is 422; confirmed bookings are 409.
+// ===========================================================================
+
+func TestChangeGuestCount(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Guest Count Cottage",
+		"city":          "Fergana",
+		"pricePerNight": "250000.00",
+		"currency":      "UZS",
+		"maxGuests":     3,
+		"instantBook":   true,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/guests.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID,
+		"checkIn":   "2029-08-01",
+		"checkOut":  "2029-08-04",
+		"guests":    1,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("create booking: want 201, got %d: %s", status, resp)
+	}
+	bookingID := jsonField(t, resp, "id")
+	total := jsonField(t, resp, "totalAmount")
+	bookingURL := bookingsURL() + "/bookings/" + bookingID
+
+	// Within capacity.
+	status, resp = patch(t, bookingURL, map[string]any{"guests": 3}, authHeaders(defaultUser))
+	if status != http.StatusOK {
+		t.Fatalf("patch within capacity: want 200, got %d: %s", status, resp)
+	}
+	if got := jsonField(t, resp, "guests"); got != "3" {
+		t.Errorf("guests: want 3, got %s", got)
+	}
+	if got := jsonField(t, resp, "totalAmount"); got != total {
+		t.Errorf("totalAmount changed: want %s, got %s", total, got)
+	}
+
+	// Over capacity.
+	status, _ = patch(t, bookingURL, map[string]any{"guests": 4}, authHeaders(defaultUser))
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("patch over capacity: want 422, got %d", status)
+	}
+
+	// Only the guest may change it.
+	status, _ = patch(t, bookingURL, map[string]any{"guests": 2}, authHeaders(guestUser2))
+	if status != http.StatusForbidden {
+		t.Errorf("patch by other user: want 403, got %d", status)
+	}
+
+	// Confirm via webhook, then changes need host approval.
+	payload := map[string]any{
+		"event_id":     "evt_guests_" + bookingID,
+		"event_type":   "payment.captured",
+		"aggregate_id": "pay_guests_" + bookingID,
+		"tenant_id":    defaultUser.TenantID,
+		"data": map[string]any{
+			"metadata": map[string]any{"bookingId": bookingID},
+		},
+	}
+	raw, _ := marshalJSON(payload)
+	post(t, paymentsURL()+"/webhooks/mashgate", payload, webhookHeaders(raw))
+
+	status, _ = patch(t, bookingURL, map[string]any{"guests": 2}, authHeaders(defaultUser))
+	if status != http.StatusConflict {
+		t.Errorf("patch confirmed booking: want 409, got %d", status)
+	}
+}
+
+// ===========================================================================
+// Scenario 30: Publish Preconditions
+//
+// A listing failing several quality gates at once gets a single 422 listing
+// every unmet requirement; fixing them all lets it publish.
+// ===========================================================================
+
+func TestPublishPreconditions(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Not Ready Yet",
+		"city":          "Nukus",
+		"pricePerNight": "0",
+		"currency":      "UZS",
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	publishURL := listingsURL() + "/listings/" + listingID + "/publish"
+
+	status, resp := post(t, publishURL, nil, authHeaders(hostUser))
+	if status != http.StatusUnprocessableEntity {
+		t.Fatalf("publish: want 422, got %d: %s", status, resp)
+	}
+	missing := jsonArray(t, resp, "missing")
+	if len(missing) < 2 {
+		t.Fatalf("want at least 2 missing requirements (photo, price), got %v", missing)
+	}
+	var hasPhoto, hasPrice bool
+	for _, m := range missing {
+		s, _ := m.(string)
+		hasPhoto = hasPhoto || strings.Contains(s, "photo")
+		hasPrice = hasPrice || strings.Contains(s, "pricePerNight")
+	}
+	if !hasPhoto || !hasPrice {
+		t.Errorf("missing requirements should mention photo and price, got %v", missing)
+	}
+
+	put(t, listingsURL()+"/listings/"+listingID, map[string]any{
+		"pricePerNight": "180000.00",
+		"description":   "Bright flat near the museum",
+		"address":       "12 Karakalpakstan St",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/ready.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+
+	status, resp = post(t, publishURL, nil, authHeaders(hostUser))
+	if status != http.StatusOK {
+		t.Errorf("publish after fixes: want 200, got %d: %s", status, resp)
+	}
+}
+
+// ===========================================================================
+// Scenario 31: Reservation Hold Countdown
+//
+// A payment_pending booking reports how long its dates stay held
+// (holdRemainingSeconds, bounded by the payment window); once the booking
+// leaves payment_pending the field disappears.
+// ===========================================================================
+
+func TestReservationHoldRemaining(t *testing.T) {
+	const window = 30
+
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":                "Short Hold Loft",
+		"city":                 "Bukhara",
+		"pricePerNight":        "400000.00",
+		"currency":             "UZS",
+		"maxGuests":            2,
+		"instantBook":          true,
+		"paymentWindowMinutes": window,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/hold.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID,
+		"checkIn":   "2029-05-10",
+		"checkOut":  "2029-05-12",
+		"guests":    1,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("instant book: want 201, got %d: %s", status, resp)
+	}
+	bookingID := jsonField(t, resp, "id")
+
+	status, resp = get(t, bookingsURL()+"/bookings/"+bookingID, authHeaders(defaultUser))
+	if status != http.StatusOK {
+		t.Fatalf("get booking: want 200, got %d: %s", status, resp)
+	}
+	var held struct {
+		HoldRemainingSeconds *int64 `json:"holdRemainingSeconds"`
+	}
+	if err := json.Unmarshal(resp, &held); err != nil {
+		t.Fatalf("decode booking: %v", err)
+	}
+	if held.HoldRemainingSeconds == nil {
+		t.Fatalf("payment_pending booking: holdRemainingSeconds missing: %s", resp)
+	}
+	if got := *held.HoldRemainingSeconds; got <= 0 || got > window*60 {
+		t.Errorf("holdRemainingSeconds: want in (0, %d], got %d", window*60, got)
+	}
+
+	post(t, bookingsURL()+"/bookings/"+bookingID+"/cancel", nil, authHeaders(defaultUser))
+	_, resp = get(t, bookingsURL()+"/bookings/"+bookingID, authHeaders(defaultUser))
+	held.HoldRemainingSeconds = nil
+	if err := json.Unmarshal(resp, &held); err != nil {
+		t.Fatalf("decode booking: %v", err)
+	}
+	if held.HoldRemainingSeconds != nil {
+		t.Errorf("cancelled booking: want no holdRemainingSeconds, got %d", *held.HoldRemainingSeconds)
+	}
+}
+
+// ===========================================================================
+// Scenario 32: Listing Quote Drives Booking Price
+//
+// The internal quote endpoint sums per-night prices, and a booking over an
+// override date is charged the override rather than the base price.
+// ===========================================================================
+
+func TestBookingUsesListingQuote(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Festival Flat",
+		"city":          "Khiva",
+		"pricePerNight": "200000.00",
+		"currency":      "UZS",
+		"maxGuests":     3,
+		"instantBook":   true,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/festival.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	status, _ := patch(t, listingsURL()+"/listings/"+listingID+"/availability/price", map[string]any{
+		"entries": []map[string]any{{"date": "2029-06-01", "price": "500000.00"}},
+	}, authHeaders(hostUser))
+	if status != http.StatusOK {
+		t.Fatalf("set price override: want 200, got %d", status)
+	}
+
+	quoteURL := listingsURL() + "/listings/" + listingID + "/quote?check_in=2029-06-01&check_out=2029-06-03"
+	status, _ = get(t, quoteURL, authHeaders(defaultUser))
+	if status != http.StatusUnauthorized && status != http.StatusForbidden {
+		t.Errorf("quote without service auth: want 401/403, got %d", status)
+	}
+	status, resp = get(t, quoteURL+"&guests=9", internalHeaders())
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("quote over capacity: want 422, got %d: %s", status, resp)
+	}
+	status, resp = get(t, quoteURL+"&guests=2", internalHeaders())
+	if status != http.StatusOK {
+		t.Fatalf("quote: want 200, got %d: %s", status, resp)
+	}
+	// 500000 override + 200000 base
+	if got := jsonField(t, resp, "subtotal"); got != "700000.00" {
+		t.Errorf("quote subtotal: want 700000.00, got %s", got)
+	}
+
+	status, resp = post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID,
+		"checkIn":   "2029-06-01",
+		"checkOut":  "2029-06-03",
+		"guests":    2,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("create booking: want 201, got %d: %s", status, resp)
+	}
+	bookingID := jsonField(t, resp, "id")
+	defer post(t, bookingsURL()+"/bookings/"+bookingID+"/cancel", nil, authHeaders(defaultUser))
+
+	var b struct {
+		TotalAmount string `json:"totalAmount"`
+		PlatformFee string `json:"platformFee"`
+		CleaningFee string `json:"cleaningFee"`
+		Deposit     string `json:"deposit"`
+	}
+	if err := json.Unmarshal(resp, &b); err != nil {
+		t.Fatalf("decode booking: %v", err)
+	}
+	stay := parseAmount(t, b.TotalAmount) - parseAmount(t, b.PlatformFee) -
+		parseAmount(t, b.CleaningFee) - parseAmount(t, b.Deposit)
+	if stay != 700000 {
+		t.Errorf("booking stay amount: want 700000 (override applied), got %.2f", stay)
+	}
+}
+
+// ===========================================================================
+// Scenario 33: Booking Total Matches Price Preview
+//
+// Over a range mixing weekend overrides and base nights, the amount a guest
+// is charged equals the total the public price preview showed them.
+// ===========================================================================
+
+func TestBookingTotalMatchesPreview(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Weekend Premium Suite",
+		"city":          "Tashkent",
+		"pricePerNight": "300000.00",
+		"cleaningFee":   "75000.00",
+		"deposit":       "100000.00",
+		"currency":      "UZS",
+		"maxGuests":     4,
+		"instantBook":   true,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/suite.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	status, _ := patch(t, listingsURL()+"/listings/"+listingID+"/availability/price", map[string]any{
+		"entries": []map[string]any{
+			{"date": "2029-07-06", "price": "450000.00"},
+			{"date": "2029-07-07", "price": "450000.00"},
+		},
+	}, authHeaders(hostUser))
+	if status != http.StatusOK {
+		t.Fatalf("set price override: want 200, got %d", status)
+	}
+
+	status, resp = get(t,
+		listingsURL()+"/listings/"+listingID+"/price-preview?check_in=2029-07-05&check_out=2029-07-09", nil)
+	if status != http.StatusOK {
+		t.Fatalf("price preview: want 200, got %d: %s", status, resp)
+	}
+	previewTotal := jsonField(t, resp, "total")
+	previewFee := jsonField(t, resp, "platformFeeGuest")
+
+	status, resp = post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID,
+		"checkIn":   "2029-07-05",
+		"checkOut":  "2029-07-09",
+		"guests":    2,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("create booking: want 201, got %d: %s", status, resp)
+	}
+	bookingID := jsonField(t, resp, "id")
+	defer post(t, bookingsURL()+"/bookings/"+bookingID+"/cancel", nil, authHeaders(defaultUser))
+
+	if got := jsonField(t, resp, "totalAmount"); got != previewTotal {
+		t.Errorf("booking totalAmount: want preview total %s, got %s", previewTotal, got)
+	}
+	if got := jsonField(t, resp, "platformFee"); got != previewFee {
+		t.Errorf("booking platformFee: want preview fee %s, got %s", previewFee, got)
+	}
+}
+
+// ===========================================================================
+// Scenario 34: Guest Dashboard Listing Expansion
+//
+// GET /bookings?expand=listing embeds each booking's listing title and cover
+// photo; without the flag bookings are returned bare.
+// ===========================================================================
+
+func TestBookingsExpandListing(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Dashboard Expansion Studio",
+		"city":          "Nukus",
+		"pricePerNight": "180000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+		"instantBook":   true,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/dashboard.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID,
+		"checkIn":   "2029-08-01",
+		"checkOut":  "2029-08-03",
+		"guests":    1,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("create booking: want 201, got %d: %s", status, resp)
+	}
+	bookingID := jsonField(t, resp, "id")
+	defer post(t, bookingsURL()+"/bookings/"+bookingID+"/cancel", nil, authHeaders(defaultUser))
+
+	type listed struct {
+		Bookings []struct {
+			ID      string `json:"id"`
+			Listing *struct {
+				Title      string `json:"title"`
+				CoverPhoto string `json:"coverPhoto"`
+			} `json:"listing"`
+		} `json:"bookings"`
+	}
+	find := func(query string) (found bool, title, cover string, expanded bool) {
+		t.Helper()
+		status, resp := get(t, bookingsURL()+"/bookings"+query, authHeaders(defaultUser))
+		if status != http.StatusOK {
+			t.Fatalf("list bookings%s: want 200, got %d: %s", query, status, resp)
+		}
+		var out listed
+		if err := json.Unmarshal(resp, &out); err != nil {
+			t.Fatalf("decode bookings: %v", err)
+		}
+		for _, b := range out.Bookings {
+			if b.ID == bookingID {
+				if b.Listing == nil {
+					return true, "", "", false
+				}
+				return true, b.Listing.Title, b.Listing.CoverPhoto, true
+			}
+		}
+		return false, "", "", false
+	}
+
+	found, title, cover, expanded := find("?expand=listing")
+	if !found {
+		t.Fatal("booking missing from expanded list")
+	}
+	if !expanded || title != "Dashboard Expansion Studio" || cover != "https://example.com/dashboard.jpg" {
+		t.Errorf("expanded listing: want title and cover photo, got expanded=%v title=%q cover=%q", expanded, title, cover)
+	}
+	if _, _, _, expanded = find(""); expanded {
+		t.Error("listing embedded without expand=listing")
+	}
+}
+
+// ===========================================================================
+// Scenario 35: Internal Batch Listing Lookup
+//
+// /listings/batch returns listings in the requested order, omits unknown
+// ids, and rejects oversized id lists.
+// ===========================================================================
+
+func TestListingsBatchLookup(t *testing.T) {
+	var ids []string
+	for _, title := range []string{"Batch One", "Batch Two"} {
+		_, resp := post(t, listingsURL()+"/listings", map[string]any{
+			"title":         title,
+			"city":          "Termez",
+			"pricePerNight": "100000.00",
+			"currency":      "UZS",
+		}, authHeaders(hostUser))
+		id := jsonField(t, resp, "id")
+		defer del(t, listingsURL()+"/listings/"+id, authHeaders(hostUser))
+		ids = append(ids, id)
+	}
+
+	batchURL := listingsURL() + "/listings/batch?ids="
+	status, _ := get(t, batchURL+ids[0], authHeaders(hostUser))
+	if status != http.StatusUnauthorized && status != http.StatusForbidden {
+		t.Errorf("batch without service auth: want 401/403, got %d", status)
+	}
+
+	status, resp := get(t, batchURL+ids[1]+",does-not-exist,"+ids[0], internalHeaders())
+	if status != http.StatusOK {
+		t.Fatalf("batch: want 200, got %d: %s", status, resp)
+	}
+	got := jsonArray(t, resp, "listings")
+	if len(got) != 2 {
+		t.Fatalf("batch: want 2 listings, got %d: %s", len(got), resp)
+	}
+	if got[0].(map[string]any)["id"] != ids[1] || got[1].(map[string]any)["id"] != ids[0] {
+		t.Errorf("batch order: want [%s %s], got %s", ids[1], ids[0], resp)
+	}
+
+	many := make([]string, 101)
+	for i := range many {
+		many[i] = fmt.Sprintf("id-%d", i)
+	}
+	status, _ = get(t, batchURL+strings.Join(many, ","), internalHeaders())
+	if status != http.StatusBadRequest {
+		t.Errorf("oversized batch: want 400, got %d", status)
+	}
+}
+
+// ===========================================================================
+// Scenario 36: Signed Photo Upload
+//
+// The owner gets a signed upload URL, PUTs an image to it, and registers the
+// public URL as a photo. Non-owners and unsupported types are refused, and a
+// tampered signature is rejected.
+// ===========================================================================
+
+func TestPhotoUploadURL(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Upload Test Flat",
+		"city":          "Andijan",
+		"pricePerNight": "120000.00",
+		"currency":      "UZS",
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	uploadURL := listingsURL() + "/listings/" + listingID + "/photos/upload-url"
+
+	status, _ := post(t, uploadURL, map[string]any{"contentType": "image/jpeg"}, authHeaders(defaultUser))
+	if status != http.StatusForbidden {
+		t.Errorf("non-owner upload URL: want 403, got %d", status)
+	}
+	status, _ = post(t, uploadURL, map[string]any{"contentType": "application/pdf"}, authHeaders(hostUser))
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("unsupported type: want 422, got %d", status)
+	}
+
+	status, resp = post(t, uploadURL, map[string]any{"contentType": "image/jpeg"}, authHeaders(hostUser))
+	if status == http.StatusServiceUnavailable {
+		t.Skip("photo storage not configured (MEDIA_DIR)")
+	}
+	if status != http.StatusOK {
+		t.Fatalf("upload URL: want 200, got %d: %s", status, resp)
+	}
+	signed := jsonField(t, resp, "uploadUrl")
+	publicURL := jsonField(t, resp, "publicUrl")
+	if !strings.HasPrefix(signed, publicURL+"?") {
+		t.Fatalf("upload URL %q should extend public URL %q", signed, publicURL)
+	}
+
+	// The URLs are gateway-facing; exercise the listings service directly.
+	direct := listingsURL() + "/listings/media/" + strings.SplitN(signed, "/listings/media/", 2)[1]
+	image := []byte("\xff\xd8\xff\xe0fake-jpeg")
+	putRaw := func(u string) int {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPut, u, bytes.NewReader(image))
+		req.Header.Set("Content-Type", "image/jpeg")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("PUT %s: %v", u, err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+	if got := putRaw(strings.Replace(direct, "sig=", "sig=00", 1)); got != http.StatusForbidden {
+		t.Errorf("tampered signature: want 403, got %d", got)
+	}
+	if got := putRaw(direct); got != http.StatusCreated {
+		t.Fatalf("signed upload: want 201, got %d", got)
+	}
+
+	status, resp = post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": publicURL, "caption": "uploaded",
+	}, authHeaders(hostUser))
+	if status != http.StatusCreated {
+		t.Errorf("register uploaded photo: want 201, got %d: %s", status, resp)
+	}
+}
+
+// ===========================================================================
+// Scenario 37: Listing View Deduplication
+//
+// Repeated loads by the same viewer within the dedup window count once
+// toward unique views, while every load is still recorded.
+// ===========================================================================
+
+func TestListingViewDedup(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "View Counter Loft",
+		"city":          "Fergana",
+		"pricePerNight": "110000.00",
+		"currency":      "UZS",
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+
+	for i := 0; i < 3; i++ {
+		get(t, listingsURL()+"/listings/"+listingID, authHeaders(defaultUser))
+	}
+	get(t, listingsURL()+"/listings/"+listingID, authHeaders(guestUser2))
+
+	viewsURL := listingsURL() + "/listings/" + listingID + "/views"
+	status, _ := get(t, viewsURL, authHeaders(defaultUser))
+	if status != http.StatusForbidden {
+		t.Errorf("non-owner views: want 403, got %d", status)
+	}
+	status, resp = get(t, viewsURL, authHeaders(hostUser))
+	if status != http.StatusOK {
+		t.Fatalf("views: want 200, got %d: %s", status, resp)
+	}
+	if got := jsonField(t, resp, "views"); got != "4" {
+		t.Errorf("views: want 4, got %s", got)
+	}
+	if got := jsonField(t, resp, "uniqueViews"); got != "2" {
+		t.Errorf("uniqueViews: want 2, got %s", got)
+	}
+	if got := jsonField(t, resp, "uniqueViewers"); got != "2" {
+		t.Errorf("uniqueViewers: want 2, got %s", got)
+	}
+}
+
+// ===========================================================================
+// Scenario 38: Tenant API Key Lifecycle
+//
+// Admin issues a key (plaintext shown once), lists it without the secret,
+// and revokes it; the internal lookup stops resolving it after revocation.
+// ===========================================================================
+
+func TestTenantAPIKeyLifecycle(t *testing.T) {
+	base := adminURL() + "/admin/tenants/" + hostUser.TenantID + "/api-keys"
+
+	status, _ := post(t, base, map[string]any{"name": "e2e", "scopes": []string{"zist.listings.read"}}, authHeaders(defaultUser))
+	if status != http.StatusForbidden {
+		t.Errorf("non-admin create: want 403, got %d", status)
+	}
+	status, _ = post(t, base, map[string]any{"name": "e2e", "scopes": []string{"zist.admin"}}, authHeaders(adminUser))
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("zist.admin key: want 422, got %d", status)
+	}
+
+	status, resp := post(t, base, map[string]any{
+		"name":   "e2e-integration",
+		"scopes": []string{"zist.listings.read", "zist.bookings.read"},
+	}, authHeaders(adminUser))
+	if status != http.StatusCreated {
+		t.Fatalf("create key: want 201, got %d: %s", status, resp)
+	}
+	key := jsonField(t, resp, "key")
+	if !strings.HasPrefix(key, "zk_") {
+		t.Fatalf("want zk_ key, got %q", key)
+	}
+	var created struct {
+		APIKey struct {
+			ID string `json:"id"`
+		} `json:"apiKey"`
+	}
+	json.Unmarshal(resp, &created) //nolint:errcheck
+	keyID := created.APIKey.ID
+
+	status, resp = get(t, base, authHeaders(adminUser))
+	if status != http.StatusOK {
+		t.Fatalf("list keys: want 200, got %d", status)
+	}
+	if strings.Contains(string(resp), key) {
+		t.Error("list must not expose the plaintext key")
+	}
+	if !strings.Contains(string(resp), keyID) {
+		t.Errorf("created key %s missing from list", keyID)
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	lookupURL := adminURL() + "/admin/internal/api-keys/" + hex.EncodeToString(sum[:])
+	status, resp = get(t, lookupURL, internalHeaders())
+	if status != http.StatusOK || jsonField(t, resp, "tenantId") != hostUser.TenantID {
+		t.Fatalf("lookup: want 200 for tenant %s, got %d: %s", hostUser.TenantID, status, resp)
+	}
+
+	status, _ = del(t, base+"/"+keyID, authHeaders(adminUser))
+	if status != http.StatusNoContent {
+		t.Fatalf("revoke: want 204, got %d", status)
+	}
+	status, _ = del(t, base+"/"+keyID, authHeaders(adminUser))
+	if status != http.StatusNotFound {
+		t.Errorf("second revoke: want 404, got %d", status)
+	}
+	status, _ = get(t, lookupURL, internalHeaders())
+	if status != http.StatusNotFound {
+		t.Errorf("lookup after revoke: want 404, got %d", status)
+	}
+
+	status, resp = get(t, adminURL()+"/admin/audit?limit=50", authHeaders(adminUser))
+	if status != http.StatusOK {
+		t.Fatalf("audit: want 200, got %d", status)
+	}
+	for _, action := range []string{"create_api_key", "revoke_api_key"} {
+		if !strings.Contains(string(resp), action) {
+			t.Errorf("audit log missing %s", action)
+		}
+	}
+}
+
+// ===========================================================================
+// Scenario 39: Host Received Reviews
+//
+// Reviews across two of a host's listings show up in one paged list with
+// listing titles; other users cannot read them.
+// ===========================================================================
+
+func TestHostReceivedReviews(t *testing.T) {
+	var listingIDs []string
+	for _, title := range []string{"Received Reviews Flat A", "Received Reviews Flat B"} {
+		_, resp := post(t, listingsURL()+"/listings", map[string]any{
+			"title":         title,
+			"city":          "Nukus",
+			"pricePerNight": "90000.00",
+			"currency":      "UZS",
+		}, authHeaders(hostUser))
+		id := jsonField(t, resp, "id")
+		listingIDs = append(listingIDs, id)
+		defer del(t, listingsURL()+"/listings/"+id, authHeaders(hostUser))
+	}
+
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+	for i, listingID := range listingIDs {
+		status, resp := post(t, reviewsURL()+"/reviews", map[string]any{
+			"bookingId": fmt.Sprintf("host-reviews-%d-%s", i, suffix),
+			"listingId": listingID,
+			"rating":    5 - i,
+			"comment":   "Lovely stay",
+		}, authHeaders(defaultUser))
+		if status != http.StatusCreated {
+			t.Fatalf("create review %d: want 201, got %d: %s", i, status, resp)
+		}
+	}
+
+	status, resp := get(t, reviewsURL()+"/reviews/host?limit=1", authHeaders(hostUser))
+	if status != http.StatusOK {
+		t.Fatalf("host reviews: want 200, got %d: %s", status, resp)
+	}
+	if n := len(jsonArray(t, resp, "reviews")); n != 1 {
+		t.Errorf("limit=1: want 1 review, got %d", n)
+	}
+	var page struct {
+		Reviews []struct {
+			ListingID string `json:"listingId"`
+			Listing   struct {
+				Title string `json:"title"`
+			} `json:"listing"`
+		} `json:"reviews"`
+		Total int `json:"total"`
+	}
+	if err := json.Unmarshal(resp, &page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if page.Total < 2 {
+		t.Errorf("total: want at least 2, got %d", page.Total)
+	}
+	// Newest first: the second listing's review was written last.
+	if len(page.Reviews) == 1 && (page.Reviews[0].ListingID != listingIDs[1] || page.Reviews[0].Listing.Title != "Received Reviews Flat B") {
+		t.Errorf("want newest review on Flat B with title, got %+v", page.Reviews[0])
+	}
+
+	status, _ = get(t, reviewsURL()+"/reviews/host?host_id="+hostUser.UserID, authHeaders(defaultUser))
+	if status != http.StatusForbidden {
+		t.Errorf("other user's host_id: want 403, got %d", status)
+	}
+}
+
+// ===========================================================================
+// Scenario 40: Host Rating Summary
+//
+// A new review updates the host's aggregate (cache evicted on write), and
+// listing detail surfaces it. The aggregate is per tenant.
+// ===========================================================================
+
+func TestHostRatingSummary(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Host Summary Cottage",
+		"city":          "Termez",
+		"pricePerNight": "70000.00",
+		"currency":      "UZS",
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+
+	// Anonymous, as listing detail and the public route ask.
+	summaryURL := reviewsURL() + "/reviews/host/" + hostUser.UserID + "/summary"
+	status, resp := get(t, summaryURL, nil)
+	if status != http.StatusOK {
+		t.Fatalf("summary: want 200, got %d: %s", status, resp)
+	}
+	var before struct {
+		ReviewCount int            `json:"reviewCount"`
+		Breakdown   map[string]int `json:"breakdown"`
+	}
+	json.Unmarshal(resp, &before) //nolint:errcheck
+
+	status, resp = post(t, reviewsURL()+"/reviews", map[string]any{
+		"bookingId": fmt.Sprintf("host-summary-%d", time.Now().UnixNano()),
+		"listingId": listingID,
+		"rating":    3,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("create review: want 201, got %d: %s", status, resp)
+	}
+
+	_, resp = get(t, summaryURL, nil)
+	var after struct {
+		ReviewCount int            `json:"reviewCount"`
+		Breakdown   map[string]int `json:"breakdown"`
+	}
+	json.Unmarshal(resp, &after) //nolint:errcheck
+	if after.ReviewCount != before.ReviewCount+1 || after.Breakdown["3"] != before.Breakdown["3"]+1 {
+		t.Errorf("summary not refreshed: before %+v, after %+v", before, after)
+	}
+
+	// Another tenant doesn't see this tenant's reviews of the host.
+	outsider := testUser{UserID: "e2e-summary-outsider", TenantID: "e2e-tenant-other", Email: "outsider@zist.test", Scopes: defaultUser.Scopes}
+	_, resp = get(t, summaryURL, authHeaders(outsider))
+	if n := jsonField(t, resp, "reviewCount"); n != "0" {
+		t.Errorf("other tenant: want reviewCount 0, got %s", resp)
+	}
+
+	_, resp = get(t, listingsURL()+"/listings/"+listingID, nil)
+	var detail struct {
+		HostSummary *struct {
+			ReviewCount int `json:"reviewCount"`
+		} `json:"hostSummary"`
+	}
+	json.Unmarshal(resp, &detail) //nolint:errcheck
+	if detail.HostSummary == nil || detail.HostSummary.ReviewCount < 1 {
+		t.Errorf("listing detail: want hostSummary with reviews, got %s", resp)
+	}
+}
+
+// ===========================================================================
+// Scenario 41: Host Analytics
+//
+// A confirmed stay and a blocked date on a fresh listing show up in the
+// host's earnings and occupancy for the window.
+// ===========================================================================
+
+func TestHostAnalytics(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Analytics Guesthouse",
+		"city":          "Khiva",
+		"pricePerNight": "100000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+		"instantBook":   true,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/analytics.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/availability/block",
+		map[string]any{"dates": []string{"2033-02-09"}}, authHeaders(hostUser))
+
+	status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID,
+		"checkIn":   "2033-02-02",
+		"checkOut":  "2033-02-05",
+		"guests":    1,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("create booking: want 201, got %d: %s", status, resp)
+	}
+	bookingID := jsonField(t, resp, "id")
+	post(t, bookingsURL()+"/bookings/"+bookingID+"/confirm",
+		map[string]any{"paymentId": "pay_analytics"}, internalHeaders())
+
+	status, resp = get(t, bookingsURL()+"/bookings/host/analytics?from=2033-02-01&to=2033-02-11", authHeaders(hostUser))
+	if status != http.StatusOK {
+		t.Fatalf("analytics: want 200, got %d: %s", status, resp)
+	}
+	var a struct {
+		Listings []struct {
+			ListingID       string            `json:"listingId"`
+			Revenue         map[string]string `json:"revenue"`
+			NightsBooked    int               `json:"nightsBooked"`
+			AvailableNights int               `json:"availableNights"`
+			OccupancyRate   float64           `json:"occupancyRate"`
+		} `json:"listings"`
+	}
+	if err := json.Unmarshal(resp, &a); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	found := false
+	for _, l := range a.Listings {
+		if l.ListingID != listingID {
+			continue
+		}
+		found = true
+		if l.NightsBooked != 3 || l.AvailableNights != 9 {
+			t.Errorf("want 3 booked / 9 available, got %d / %d", l.NightsBooked, l.AvailableNights)
+		}
+		if l.OccupancyRate != 0.3333 {
+			t.Errorf("occupancy: want 0.3333, got %v", l.OccupancyRate)
+		}
+		if parseAmount(t, l.Revenue["UZS"]) != 300000 {
+			t.Errorf("revenue: want 300000.00 (3 nights, fee excluded), got %v", l.Revenue)
+		}
+	}
+	if !found {
+		t.Errorf("listing %s missing from analytics: %s", listingID, resp)
+	}
+
+	status, _ = get(t, bookingsURL()+"/bookings/host/analytics?from=2033-02-11&to=2033-02-01", authHeaders(hostUser))
+	if status != http.StatusBadRequest {
+		t.Errorf("reversed window: want 400, got %d", status)
+	}
+}
+
+// ===========================================================================
+// Scenario 42: Tenant Booking Amount Limits
+//
+// A dedicated tenant gets min/max booking amounts; totals outside the range
+// are rejected with 422. A separate tenant keeps the cached limits from
+// leaking into other scenarios.
+// ===========================================================================
+
+func TestTenantBookingAmountLimits(t *testing.T) {
+	host := testUser{UserID: "e2e-limits-host", TenantID: "e2e-tenant-limits", Email: "limits-host@zist.test", Scopes: hostUser.Scopes}
+	guest := testUser{UserID: "e2e-limits-guest", TenantID: "e2e-tenant-limits", Email: "limits-guest@zist.test", Scopes: defaultUser.Scopes}
+
+	status, resp := put(t, adminURL()+"/admin/tenants/"+host.TenantID, map[string]any{
+		"platformFeePct":   12.0,
+		"maxListings":      50,
+		"minBookingAmount": "500.00",
+		"maxBookingAmount": "100.00",
+	}, authHeaders(adminUser))
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("min > max: want 422, got %d: %s", status, resp)
+	}
+	status, resp = put(t, adminURL()+"/admin/tenants/"+host.TenantID, map[string]any{
+		"platformFeePct":   12.0,
+		"maxListings":      50,
+		"minBookingAmount": "200000.00",
+		"maxBookingAmount": "1000000.00",
+	}, authHeaders(adminUser))
+	if status != http.StatusOK {
+		t.Fatalf("set limits: want 200, got %d: %s", status, resp)
+	}
+
+	newListing := func(price string) string {
+		_, resp := post(t, listingsURL()+"/listings", map[string]any{
+			"title":         "Limits " + price,
+			"city":          "Andijan",
+			"pricePerNight": price,
+			"currency":      "UZS",
+			"maxGuests":     2,
+		}, authHeaders(host))
+		id := jsonField(t, resp, "id")
+		post(t, listingsURL()+"/listings/"+id+"/photos", map[string]any{
+			"url": "https://example.com/limits.jpg", "caption": "cover",
+		}, authHeaders(host))
+		post(t, listingsURL()+"/listings/"+id+"/publish", nil, authHeaders(host))
+		return id
+	}
+	book := func(listingID string) (int, []byte) {
+		return post(t, bookingsURL()+"/bookings", map[string]any{
+			"listingId": listingID,
+			"checkIn":   "2033-03-01",
+			"checkOut":  "2033-03-03",
+			"guests":    1,
+		}, authHeaders(guest))
+	}
+
+	cheap := newListing("1.00")
+	defer del(t, listingsURL()+"/listings/"+cheap, authHeaders(host))
+	if status, resp := book(cheap); status != http.StatusUnprocessableEntity {
+		t.Errorf("below min: want 422, got %d: %s", status, resp)
+	}
+
+	pricey := newListing("9000000.00")
+	defer del(t, listingsURL()+"/listings/"+pricey, authHeaders(host))
+	if status, resp := book(pricey); status != http.StatusUnprocessableEntity {
+		t.Errorf("above max: want 422, got %d: %s", status, resp)
+	}
+
+	normal := newListing("150000.00")
+	defer del(t, listingsURL()+"/listings/"+normal, authHeaders(host))
+	if status, resp := book(normal); status != http.StatusCreated {
+		t.Errorf("within limits: want 201, got %d: %s", status, resp)
+	}
+}
+
+// ===========================================================================
+// Scenario 43: Listing Archival
+//
+// An archived listing leaves the public list and can't be booked or
+// republished, but still resolves by id; unarchive returns it to paused.
+// ===========================================================================
+
+func TestListingArchival(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Archival Cottage",
+		"city":          "Termez",
+		"pricePerNight": "90000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+		"instantBook":   true,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/archival.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	status, resp := post(t, listingsURL()+"/listings/"+listingID+"/archive", nil, authHeaders(hostUser))
+	if status != http.StatusOK || jsonField(t, resp, "status") != "archived" {
+		t.Fatalf("archive: want 200 archived, got %d: %s", status, resp)
+	}
+
+	for _, q := range []string{"?city=Termez", "?city=Termez&status=archived"} {
+		_, resp = get(t, listingsURL()+"/listings"+q, nil)
+		if strings.Contains(string(resp), listingID) {
+			t.Errorf("archived listing returned by GET /listings%s", q)
+		}
+	}
+	status, resp = get(t, listingsURL()+"/listings/"+listingID, nil)
+	if status != http.StatusOK || jsonField(t, resp, "status") != "archived" {
+		t.Errorf("get archived: want 200 archived, got %d: %s", status, resp)
+	}
+
+	status, _ = post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID,
+		"checkIn":   "2033-04-01",
+		"checkOut":  "2033-04-03",
+		"guests":    1,
+	}, authHeaders(defaultUser))
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("book archived: want 422, got %d", status)
+	}
+	if status, _ = post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser)); status != http.StatusConflict {
+		t.Errorf("publish archived: want 409, got %d", status)
+	}
+
+	status, resp = post(t, listingsURL()+"/listings/"+listingID+"/unarchive", nil, authHeaders(hostUser))
+	if status != http.StatusOK || jsonField(t, resp, "status") != "paused" {
+		t.Fatalf("unarchive: want 200 paused, got %d: %s", status, resp)
+	}
+	if status, _ = post(t, listingsURL()+"/listings/"+listingID+"/unarchive", nil, authHeaders(hostUser)); status != http.StatusConflict {
+		t.Errorf("unarchive twice: want 409, got %d", status)
+	}
+	if status, _ = post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser)); status != http.StatusOK {
+		t.Errorf("republish after unarchive: want 200, got %d", status)
+	}
+}
+
+// ===========================================================================
+// Scenario 44: Audit Log Paging
+//
+// Five entries for a fresh actor are walked two at a time; pages don't
+// overlap, follow newest-first order and report the same total.
+// ===========================================================================
+
+func TestAuditLogPaging(t *testing.T) {
+	actor := fmt.Sprintf("e2e-audit-pager-%d", time.Now().UnixNano())
+	for i := 0; i < 5; i++ {
+		status, resp := post(t, adminURL()+"/admin/internal/audit", map[string]any{
+			"actorId":  actor,
+			"action":   "e2e.page",
+			"resource": fmt.Sprintf("item:%d", i),
+		}, internalHeaders())
+		if status != http.StatusNoContent {
+			t.Fatalf("record audit %d: want 204, got %d: %s", i, status, resp)
+		}
+	}
+
+	type page struct {
+		Entries []struct {
+			ID        string `json:"id"`
+			CreatedAt int64  `json:"createdAt"`
+		} `json:"entries"`
+		Total  int `json:"total"`
+		Offset int `json:"offset"`
+	}
+	seen := map[string]bool{}
+	var lastCreated int64 = 1 << 62
+	pages := 0
+	for offset := 0; ; offset += 2 {
+		status, resp := get(t, fmt.Sprintf("%s/admin/audit?actor_id=%s&limit=2&offset=%d", adminURL(), actor, offset), authHeaders(adminUser))
+		if status != http.StatusOK {
+			t.Fatalf("page at %d: want 200, got %d: %s", offset, status, resp)
+		}
+		var p page
+		if err := json.Unmarshal(resp, &p); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if p.Total != 5 || p.Offset != offset {
+			t.Fatalf("page at %d: want total 5, got total %d offset %d", offset, p.Total, p.Offset)
+		}
+		if len(p.Entries) == 0 {
+			break
+		}
+		pages++
+		for _, e := range p.Entries {
+			if seen[e.ID] {
+				t.Errorf("entry %s returned on more than one page", e.ID)
+			}
+			seen[e.ID] = true
+			if e.CreatedAt > lastCreated {
+				t.Errorf("entries out of order: %d after %d", e.CreatedAt, lastCreated)
+			}
+			lastCreated = e.CreatedAt
+		}
+	}
+	if pages != 3 || len(seen) != 5 {
+		t.Errorf("want 5 entries over 3 pages, got %d over %d", len(seen), pages)
+	}
+}
+
+// ===========================================================================
+// Scenario 45: Tenant Config Dry Run
+//
+// A dry-run fee change reports the diff but leaves the stored config and the
+// audit log untouched.
+// ===========================================================================
+
+func TestTenantConfigDryRun(t *testing.T) {
+	tenant := fmt.Sprintf("e2e-tenant-dryrun-%d", time.Now().UnixNano())
+	base := adminURL() + "/admin/tenants/" + tenant
+	status, resp := put(t, base, map[string]any{"platformFeePct": 10.0, "maxListings": 20}, authHeaders(adminUser))
+	if status != http.StatusOK {
+		t.Fatalf("seed config: want 200, got %d: %s", status, resp)
+	}
+	auditCount := func() int {
+		_, resp := get(t, adminURL()+"/admin/audit?actor_id="+adminUser.UserID+"&limit=1", authHeaders(adminUser))
+		var a struct {
+			Total int `json:"total"`
+		}
+		json.Unmarshal(resp, &a) //nolint:errcheck
+		return a.Total
+	}
+	before := auditCount()
+
+	status, resp = put(t, base+"?dryRun=true", map[string]any{"platformFeePct": 25.0, "maxListings": 20}, authHeaders(adminUser))
+	if status != http.StatusOK {
+		t.Fatalf("dry run: want 200, got %d: %s", status, resp)
+	}
+	var dry struct {
+		DryRun bool `json:"dryRun"`
+		Config struct {
+			PlatformFeePct float64 `json:"platformFeePct"`
+		} `json:"config"`
+		Diff map[string]struct {
+			From float64 `json:"from"`
+			To   float64 `json:"to"`
+		} `json:"diff"`
+	}
+	if err := json.Unmarshal(resp, &dry); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !dry.DryRun || dry.Config.PlatformFeePct != 25 {
+		t.Errorf("want dryRun with fee 25, got %s", resp)
+	}
+	if c, ok := dry.Diff["platformFeePct"]; !ok || c.From != 10 || c.To != 25 || len(dry.Diff) != 1 {
+		t.Errorf("diff: want only platformFeePct 10 → 25, got %s", resp)
+	}
+
+	_, resp = get(t, base, authHeaders(adminUser))
+	if fee := jsonField(t, resp, "platformFeePct"); fee != "10" {
+		t.Errorf("stored fee changed by dry run: %s", resp)
+	}
+	if after := auditCount(); after != before {
+		t.Errorf("dry run wrote audit entries: %d → %d", before, after)
+	}
+
+	status, _ = put(t, base+"?dryRun=true", map[string]any{"minBookingAmount": "9.00", "maxBookingAmount": "1.00"}, authHeaders(adminUser))
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("invalid dry run: want 422, got %d", status)
+	}
+}
+
+// ===========================================================================
+// Scenario 46: Listing Embed
+//
+// An active listing's embed card is public and omits host-only fields;
+// paused listings aren't embeddable.
+// ===========================================================================
+
+func TestListingEmbed(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Embeddable Yurt",
+		"city":          "Nukus",
+		"address":       "7 Private Lane",
+		"pricePerNight": "80000.00",
+		"currency":      "UZS",
+		"deposit":       "20000.00",
+		"maxGuests":     2,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/yurt.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+
+	if status, _ := get(t, listingsURL()+"/listings/"+listingID+"/embed", nil); status != http.StatusNotFound {
+		t.Errorf("embed draft: want 404, got %d", status)
+	}
+
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+	status, resp := get(t, listingsURL()+"/listings/"+listingID+"/embed", nil)
+	if status != http.StatusOK {
+		t.Fatalf("embed: want 200, got %d: %s", status, resp)
+	}
+	if jsonField(t, resp, "title") != "Embeddable Yurt" || jsonField(t, resp, "coverPhotoUrl") != "https://example.com/yurt.jpg" {
+		t.Errorf("embed: unexpected card %s", resp)
+	}
+	for _, field := range []string{"address", "deposit", "hostId"} {
+		if jsonField(t, resp, field) != "" {
+			t.Errorf("embed exposes %s: %s", field, resp)
+		}
+	}
+
+	post(t, listingsURL()+"/listings/"+listingID+"/unpublish", nil, authHeaders(hostUser))
+	if status, _ := get(t, listingsURL()+"/listings/"+listingID+"/embed", nil); status != http.StatusNotFound {
+		t.Errorf("embed paused: want 404, got %d", status)
+	}
+}
+
+// ===========================================================================
+// Scenario 47: Listing Conditional GET
+//
+// Replaying a listing's ETag yields 304 until the listing changes.
+// ===========================================================================
+
+func TestListingConditionalGet(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "ETag Townhouse",
+		"city":          "Namangan",
+		"pricePerNight": "110000.00",
+		"currency":      "UZS",
+		"maxGuests":     3,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+
+	fetch := func(ifNoneMatch string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, listingsURL()+"/listings/"+listingID, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		res, err := httpClient.Do(req)
+		if err != nil {
+			t.Fatalf("get listing: %v", err)
+		}
+		res.Body.Close()
+		return res
+	}
+
+	first := fetch("")
+	etag := first.Header.Get("ETag")
+	if first.StatusCode != http.StatusOK || etag == "" {
+		t.Fatalf("first get: want 200 with ETag, got %d %q", first.StatusCode, etag)
+	}
+	if res := fetch(etag); res.StatusCode != http.StatusNotModified {
+		t.Errorf("repeat get: want 304, got %d", res.StatusCode)
+	}
+
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/etag.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	if res := fetch(etag); res.StatusCode != http.StatusOK || res.Header.Get("ETag") == etag {
+		t.Errorf("after photo added: want 200 with new ETag, got %d %q", res.StatusCode, res.Header.Get("ETag"))
+	}
+}
+
+// ===========================================================================
+// Scenario 48: Idempotent Date Release
+//
+// Releasing a booking's dates reports how many were freed; a repeat release
+// (cancel racing the expiry worker) frees nothing and still succeeds.
+// ===========================================================================
+
+func TestDateReleaseIdempotent(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Release Count Flat",
+		"city":          "Andijan",
+		"pricePerNight": "70000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+		"instantBook":   true,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/release.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID,
+		"checkIn":   "2033-05-10",
+		"checkOut":  "2033-05-13",
+		"guests":    1,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("create booking: want 201, got %d: %s", status, resp)
+	}
+	bookingID := jsonField(t, resp, "id")
+
+	release := func() string {
+		t.Helper()
+		status, resp := doRequest(t, http.MethodDelete, listingsURL()+"/listings/"+listingID+"/availability/book",
+			map[string]any{"bookingId": bookingID}, internalHeaders())
+		if status != http.StatusOK {
+			t.Fatalf("release: want 200, got %d: %s", status, resp)
+		}
+		return jsonField(t, resp, "released")
+	}
+	if got := release(); got != "3" {
+		t.Errorf("first release: want 3 dates, got %s", got)
+	}
+	if got := release(); got != "0" {
+		t.Errorf("second release: want 0 dates, got %s", got)
+	}
+
+	// Cancelling afterwards releases again without error.
+	status, resp = post(t, bookingsURL()+"/bookings/"+bookingID+"/cancel", nil, authHeaders(defaultUser))
+	if status != http.StatusOK {
+		t.Errorf("cancel after release: want 200, got %d: %s", status, resp)
+	}
+}
+
+// ===========================================================================
+// Scenario 49: Host Booking Filters
+//
+// The host's booking list narrows by listing, check-in range and status and
+// pages with a stable total.
+// ===========================================================================
+
+func TestHostBookingFilters(t *testing.T) {
+	var listingIDs []string
+	for _, title := range []string{"Filter Flat A", "Filter Flat B"} {
+		_, resp := post(t, listingsURL()+"/listings", map[string]any{
+			"title":         title,
+			"city":          "Jizzakh",
+			"pricePerNight": "60000.00",
+			"currency":      "UZS",
+			"maxGuests":     2,
+			"instantBook":   true,
+		}, authHeaders(hostUser))
+		id := jsonField(t, resp, "id")
+		defer del(t, listingsURL()+"/listings/"+id, authHeaders(hostUser))
+		post(t, listingsURL()+"/listings/"+id+"/photos", map[string]any{
+			"url": "https://example.com/filter.jpg", "caption": "cover",
+		}, authHeaders(hostUser))
+		post(t, listingsURL()+"/listings/"+id+"/publish", nil, authHeaders(hostUser))
+		listingIDs = append(listingIDs, id)
+	}
+
+	stays := []struct{ listing, checkIn, checkOut string }{
+		{listingIDs[0], "2034-01-05", "2034-01-07"},
+		{listingIDs[0], "2034-02-05", "2034-02-07"},
+		{listingIDs[1], "2034-01-10", "2034-01-12"},
+	}
+	for _, s := range stays {
+		status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+			"listingId": s.listing, "checkIn": s.checkIn, "checkOut": s.checkOut, "guests": 1,
+		}, authHeaders(defaultUser))
+		if status != http.StatusCreated {
+			t.Fatalf("create booking: want 201, got %d: %s", status, resp)
+		}
+	}
+
+	count := func(query string) (int, string) {
+		t.Helper()
+		status, resp := get(t, bookingsURL()+"/bookings/host?"+query, authHeaders(hostUser))
+		if status != http.StatusOK {
+			t.Fatalf("GET /bookings/host?%s: want 200, got %d: %s", query, status, resp)
+		}
+		return len(jsonArray(t, resp, "bookings")), jsonField(t, resp, "total")
+	}
+
+	if n, total := count("listingId=" + listingIDs[0]); n != 2 || total != "2" {
+		t.Errorf("by listing: want 2 of 2, got %d of %s", n, total)
+	}
+	if n, _ := count("listingId=" + listingIDs[0] + "&from=2034-01-01&to=2034-02-01"); n != 1 {
+		t.Errorf("by listing and range: want 1, got %d", n)
+	}
+	if n, _ := count("from=2034-01-01&to=2034-03-01&status=payment_pending&listingId=" + listingIDs[1]); n != 1 {
+		t.Errorf("by status: want 1, got %d", n)
+	}
+	if n, total := count("listingId=" + listingIDs[0] + "&limit=1&offset=1"); n != 1 || total != "2" {
+		t.Errorf("paged: want 1 of 2, got %d of %s", n, total)
+	}
+	if status, _ := get(t, bookingsURL()+"/bookings/host?from=2034-02-01&to=2034-01-01", authHeaders(hostUser)); status != http.StatusBadRequest {
+		t.Errorf("inverted range: want 400, got %d", status)
+	}
+}
+
+// ===========================================================================
+// Scenario 50: Free Instant Booking
+//
+// An instant-book stay that costs nothing is confirmed on creation, with no
+// payment window.
+// ===========================================================================
+
+func TestFreeInstantBookingConfirms(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Community Guest Room",
+		"city":          "Navoi",
+		"pricePerNight": "40000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+		"instantBook":   true,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/free.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+	// Publishing needs a positive price by default; drop it once live.
+	if status, resp := patch(t, listingsURL()+"/listings/"+listingID, map[string]any{"pricePerNight": "0.00"}, authHeaders(hostUser)); status != http.StatusOK {
+		t.Fatalf("set free price: want 200, got %d: %s", status, resp)
+	}
+
+	status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID,
+		"checkIn":   "2034-03-01",
+		"checkOut":  "2034-03-03",
+		"guests":    1,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("create booking: want 201, got %d: %s", status, resp)
+	}
+	if got := jsonField(t, resp, "status"); got != "confirmed" {
+		t.Errorf("free booking: want status confirmed, got %q", got)
+	}
+	if got := jsonField(t, resp, "totalAmount"); got != "0.00" {
+		t.Errorf("free booking: want totalAmount 0.00, got %q", got)
+	}
+	if got := jsonField(t, resp, "expiresAt"); got != "" && got != "null" {
+		t.Errorf("free booking: want no expiresAt, got %s", got)
+	}
+
+	// The dates are taken.
+	status, _ = post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID,
+		"checkIn":   "2034-03-02",
+		"checkOut":  "2034-03-04",
+		"guests":    1,
+	}, authHeaders(guestUser2))
+	if status != http.StatusConflict {
+		t.Errorf("overlapping booking: want 409, got %d", status)
+	}
+}
+
+// ===========================================================================
+// Scenario 51: Pending Bookings Cap
+//
+// With maxPendingBookingsPerGuest = 2 a guest's third unpaid booking is
+// refused with 429; cancelling one frees a slot. A dedicated tenant keeps the
+// cap out of other scenarios.
+// ===========================================================================
+
+func TestPendingBookingsCap(t *testing.T) {
+	host := testUser{UserID: "e2e-cap-host", TenantID: "e2e-tenant-cap", Email: "cap-host@zist.test", Scopes: hostUser.Scopes}
+	guest := testUser{UserID: "e2e-cap-guest", TenantID: "e2e-tenant-cap", Email: "cap-guest@zist.test", Scopes: defaultUser.Scopes}
+
+	if status, resp := put(t, adminURL()+"/admin/tenants/"+host.TenantID, map[string]any{
+		"platformFeePct": 12.0, "maxListings": 50, "maxPendingBookingsPerGuest": 0,
+	}, authHeaders(adminUser)); status != http.StatusUnprocessableEntity {
+		t.Errorf("cap 0: want 422, got %d: %s", status, resp)
+	}
+	if status, resp := put(t, adminURL()+"/admin/tenants/"+host.TenantID, map[string]any{
+		"platformFeePct": 12.0, "maxListings": 50, "maxPendingBookingsPerGuest": 2,
+	}, authHeaders(adminUser)); status != http.StatusOK {
+		t.Fatalf("set cap: want 200, got %d: %s", status, resp)
+	}
+
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Cap Cottage",
+		"city":          "Gulistan",
+		"pricePerNight": "50000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+		"instantBook":   true,
+	}, authHeaders(host))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(host))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/cap.jpg", "caption": "cover",
+	}, authHeaders(host))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(host))
+
+	book := func(checkIn, checkOut string) (int, []byte) {
+		return post(t, bookingsURL()+"/bookings", map[string]any{
+			"listingId": listingID, "checkIn": checkIn, "checkOut": checkOut, "guests": 1,
+		}, authHeaders(guest))
+	}
+	status, resp := book("2034-04-01", "2034-04-02")
+	if status != http.StatusCreated {
+		t.Fatalf("first booking: want 201, got %d: %s", status, resp)
+	}
+	firstID := jsonField(t, resp, "id")
+	if status, resp := book("2034-04-03", "2034-04-04"); status != http.StatusCreated {
+		t.Fatalf("second booking: want 201, got %d: %s", status, resp)
+	}
+	if status, resp := book("2034-04-05", "2034-04-06"); status != http.StatusTooManyRequests {
+		t.Errorf("third booking: want 429, got %d: %s", status, resp)
+	}
+
+	post(t, bookingsURL()+"/bookings/"+firstID+"/cancel", nil, authHeaders(guest))
+	if status, resp := book("2034-04-05", "2034-04-06"); status != http.StatusCreated {
+		t.Errorf("after cancel: want 201, got %d: %s", status, resp)
+	}
+}
+
+// ===========================================================================
+// Scenario 52: Guest Count Floor
+//
+// A booking without guests is stored with 1 guest; a negative count is
+// rejected with 422 before anything is reserved.
+// ===========================================================================
+
+func TestBookingGuestsDefault(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Guest Floor Flat",
+		"city":          "Nukus",
+		"pricePerNight": "60000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/floor.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID, "checkIn": "2034-06-01", "checkOut": "2034-06-03", "guests": -1,
+	}, authHeaders(defaultUser))
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("guests=-1: want 422, got %d: %s", status, resp)
+	}
+
+	status, resp = post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID, "checkIn": "2034-06-01", "checkOut": "2034-06-03",
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("guests omitted: want 201, got %d: %s", status, resp)
+	}
+	defer post(t, bookingsURL()+"/bookings/"+jsonField(t, resp, "id")+"/cancel", nil, authHeaders(defaultUser))
+	if got := jsonField(t, resp, "guests"); got != "1" {
+		t.Errorf("guests omitted: want 1, got %s", got)
+	}
+}
+
+// ===========================================================================
+// Scenario 53: Listing Snooze
+//
+// Snoozing pauses a listing and records snoozeUntil; unsnooze clears the
+// date but leaves it paused, and publishing over a snooze clears it too.
+// The timed republish itself is left to the sweep.
+// ===========================================================================
+
+func TestListingSnooze(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Snooze Cabin",
+		"city":          "Termez",
+		"pricePerNight": "70000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	base := listingsURL() + "/listings/" + listingID
+
+	if status, resp := post(t, base+"/snooze?until=2099-01-01", nil, authHeaders(hostUser)); status != http.StatusUnprocessableEntity {
+		t.Errorf("snooze past a year: want 422, got %d: %s", status, resp)
+	}
+	until := time.Now().UTC().AddDate(0, 0, 7).Format("2006-01-02")
+	if status, resp := post(t, base+"/snooze?until="+until, nil, authHeaders(hostUser)); status != http.StatusConflict {
+		t.Errorf("snooze draft: want 409, got %d: %s", status, resp)
+	}
+
+	post(t, base+"/photos", map[string]any{"url": "https://example.com/snooze.jpg", "caption": "cover"}, authHeaders(hostUser))
+	post(t, base+"/publish", nil, authHeaders(hostUser))
+
+	status, resp := post(t, base+"/snooze?until="+until, nil, authHeaders(hostUser))
+	if status != http.StatusOK {
+		t.Fatalf("snooze: want 200, got %d: %s", status, resp)
+	}
+	_, resp = get(t, base, authHeaders(hostUser))
+	if got := jsonField(t, resp, "status"); got != "paused" {
+		t.Errorf("snoozed status: want paused, got %s", got)
+	}
+	if got := jsonField(t, resp, "snoozeUntil"); got != until {
+		t.Errorf("snoozeUntil: want %s, got %s", until, got)
+	}
+
+	if status, resp := post(t, base+"/unsnooze", nil, authHeaders(hostUser)); status != http.StatusOK {
+		t.Fatalf("unsnooze: want 200, got %d: %s", status, resp)
+	}
+	if status, resp := post(t, base+"/unsnooze", nil, authHeaders(hostUser)); status != http.StatusConflict {
+		t.Errorf("second unsnooze: want 409, got %d: %s", status, resp)
+	}
+	_, resp = get(t, base, authHeaders(hostUser))
+	if strings.Contains(string(resp), `"snoozeUntil"`) || jsonField(t, resp, "status") != "paused" {
+		t.Errorf("after unsnooze: want paused without snoozeUntil, got %s", resp)
+	}
+
+	post(t, base+"/snooze?until="+until, nil, authHeaders(hostUser))
+	post(t, base+"/publish", nil, authHeaders(hostUser))
+	_, resp = get(t, base, authHeaders(hostUser))
+	if strings.Contains(string(resp), `"snoozeUntil"`) {
+		t.Errorf("publish should clear the snooze, got %s", resp)
+	}
+}
+
+// ===========================================================================
+// Scenario 54: Checkout Currency Must Match the Booking
+//
+// A checkout for a UZS booking requested in USD is refused with 422 before
+// any payment session is opened; an unknown booking is 404.
+// ===========================================================================
+
+func TestCheckoutCurrencyMismatch(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Currency Check Flat",
+		"city":          "Navoi",
+		"pricePerNight": "90000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+		"instantBook":   true,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/currency.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID, "checkIn": "2034-07-01", "checkOut": "2034-07-03", "guests": 1,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("create booking: want 201, got %d: %s", status, resp)
+	}
+	bookingID := jsonField(t, resp, "id")
+	defer post(t, bookingsURL()+"/bookings/"+bookingID+"/cancel", nil, authHeaders(defaultUser))
+
+	checkout := func(bookingID, currency string) (int, []byte) {
+		return post(t, paymentsURL()+"/checkout", map[string]any{
+			"bookingId": bookingID, "listingId": listingID,
+			"amount": jsonField(t, resp, "totalAmount"), "currency": currency,
+		}, authHeaders(defaultUser))
+	}
+	status, body := checkout(bookingID, "USD")
+	if status != http.StatusUnprocessableEntity {
+		t.Fatalf("USD checkout for UZS booking: want 422, got %d: %s", status, body)
+	}
+	if !strings.Contains(jsonField(t, body, "error"), "UZS") {
+		t.Errorf("error should name the booking currency, got %s", body)
+	}
+	if status, body := checkout("00000000-0000-4000-8000-000000000000", "UZS"); status != http.StatusNotFound {
+		t.Errorf("unknown booking: want 404, got %d: %s", status, body)
+	}
+}
+
+// ===========================================================================
+// Scenario 55: Receipt Survives Listing Price Changes
+//
+// The receipt's line items are frozen at booking time: repricing the listing
+// afterwards leaves the stored breakdown untouched.
+// ===========================================================================
+
+func TestBookingReceiptFrozen(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Receipt Loft",
+		"city":          "Karshi",
+		"pricePerNight": "350000.00",
+		"cleaningFee":   "50000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+		"instantBook":   true,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/receipt.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID, "checkIn": "2034-08-01", "checkOut": "2034-08-04", "guests": 1,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("create booking: want 201, got %d: %s", status, resp)
+	}
+	bookingID := jsonField(t, resp, "id")
+	defer post(t, bookingsURL()+"/bookings/"+bookingID+"/cancel", nil, authHeaders(defaultUser))
+
+	lines := func() map[string]any {
+		t.Helper()
+		status, resp := get(t, bookingsURL()+"/bookings/"+bookingID+"/receipt", authHeaders(defaultUser))
+		if status != http.StatusOK {
+			t.Fatalf("receipt: want 200, got %d: %s", status, resp)
+		}
+		var out struct {
+			Lines map[string]any `json:"lines"`
+		}
+		if err := json.Unmarshal(resp, &out); err != nil {
+			t.Fatalf("decode receipt: %v", err)
+		}
+		return out.Lines
+	}
+	before := lines()
+	if before["subtotal"] != "1050000.00" || before["cleaningFee"] != "50000.00" || before["nights"] != float64(3) {
+		t.Fatalf("receipt lines at booking time: %v", before)
+	}
+
+	put(t, listingsURL()+"/listings/"+listingID, map[string]any{
+		"pricePerNight": "900000.00", "cleaningFee": "0",
+	}, authHeaders(hostUser))
+
+	after := lines()
+	for _, k := range []string{"subtotal", "cleaningFee", "platformFee", "total"} {
+		if after[k] != before[k] {
+			t.Errorf("%s changed after repricing: %v → %v", k, before[k], after[k])
+		}
+	}
+}
+
+// ===========================================================================
+// Scenario 56: Tenant Supported Currencies
+//
+// A UZS-only tenant rejects listings priced in USD, on create and on update.
+// The tenant is dedicated so the cached restriction can't affect other
+// scenarios.
+// ===========================================================================
+
+func TestTenantSupportedCurrencies(t *testing.T) {
+	host := testUser{UserID: "e2e-fx-host", TenantID: "e2e-tenant-fx", Email: "fx-host@zist.test", Scopes: hostUser.Scopes}
+
+	status, resp := put(t, adminURL()+"/admin/tenants/"+host.TenantID, map[string]any{
+		"platformFeePct":      12.0,
+		"maxListings":         50,
+		"supportedCurrencies": []string{"usd1"},
+	}, authHeaders(adminUser))
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("malformed code: want 422, got %d: %s", status, resp)
+	}
+	status, resp = put(t, adminURL()+"/admin/tenants/"+host.TenantID, map[string]any{
+		"platformFeePct":      12.0,
+		"maxListings":         50,
+		"supportedCurrencies": []string{"uzs"},
+	}, authHeaders(adminUser))
+	if status != http.StatusOK {
+		t.Fatalf("set currencies: want 200, got %d: %s", status, resp)
+	}
+	if got := jsonArray(t, resp, "supportedCurrencies"); len(got) != 1 || got[0] != "UZS" {
+		t.Errorf("supportedCurrencies: want [UZS], got %v", got)
+	}
+
+	listing := func(currency string) map[string]any {
+		return map[string]any{
+			"title":         "FX " + currency,
+			"city":          "Namangan",
+			"pricePerNight": "300000.00",
+			"currency":      currency,
+		}
+	}
+	status, resp = post(t, listingsURL()+"/listings", listing("USD"), authHeaders(host))
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("USD listing on UZS-only tenant: want 422, got %d: %s", status, resp)
+	}
+
+	status, resp = post(t, listingsURL()+"/listings", listing("UZS"), authHeaders(host))
+	if status != http.StatusCreated {
+		t.Fatalf("UZS listing: want 201, got %d: %s", status, resp)
+	}
+	id := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+id, authHeaders(host))
+
+	status, resp = put(t, listingsURL()+"/listings/"+id, map[string]any{"currency": "USD"}, authHeaders(host))
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("switch to USD: want 422, got %d: %s", status, resp)
+	}
+}
+
+// ===========================================================================
+// Scenario 57: Failing a Booking Frees Its Dates
+//
+// /fail releases the reserved calendar itself, and a repeat call on the
+// already-failed booking succeeds so webhook retries are harmless.
+// ===========================================================================
+
+func TestFailBookingReleasesDates(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Fail Release Flat",
+		"city":          "Termez",
+		"pricePerNight": "200000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+		"instantBook":   true,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/fail.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID, "checkIn": "2034-09-10", "checkOut": "2034-09-12", "guests": 1,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("create booking: want 201, got %d: %s", status, resp)
+	}
+	bookingID := jsonField(t, resp, "id")
+
+	available := func() string {
+		t.Helper()
+		_, resp := get(t, listingsURL()+"/listings/"+listingID+"/availability/check?check_in=2034-09-10&check_out=2034-09-12", nil)
+		return jsonField(t, resp, "available")
+	}
+	if got := available(); got != "false" {
+		t.Fatalf("dates should be reserved after instant booking, available=%s", got)
+	}
+
+	for i := 1; i <= 2; i++ {
+		status, resp = post(t, bookingsURL()+"/bookings/"+bookingID+"/fail", nil, internalHeaders())
+		if status != http.StatusNoContent {
+			t.Fatalf("fail call %d: want 204, got %d: %s", i, status, resp)
+		}
+		if got := available(); got != "true" {
+			t.Errorf("after fail call %d: dates should be free, available=%s", i, got)
+		}
+	}
+}
+
+// ===========================================================================
+// Scenario 58: Review With Photos
+//
+// A review carries two photos in order, both on create and in the listing's
+// review feed; a non-http URL is rejected.
+// ===========================================================================
+
+func TestReviewWithPhotos(t *testing.T) {
+	listingID := createListing(t, hostUser, "Review Photos Flat")
+	review := map[string]any{
+		"bookingId": listingID + "-booking",
+		"listingId": listingID,
+		"rating":    5,
+		"comment":   "Lovely balcony",
+		"photos": []map[string]any{
+			{"url": "https://cdn.example.com/balcony.jpg", "caption": "Balcony"},
+			{"url": "https://cdn.example.com/kitchen.jpg", "caption": "Kitchen"},
+		},
+	}
+
+	bad := map[string]any{
+		"bookingId": listingID + "-bad", "listingId": listingID, "rating": 4,
+		"photos": []map[string]any{{"url": "javascript:alert(1)"}},
+	}
+	if status, resp := post(t, reviewsURL()+"/reviews", bad, authHeaders(defaultUser)); status != http.StatusUnprocessableEntity {
+		t.Errorf("invalid photo url: want 422, got %d: %s", status, resp)
+	}
+
+	status, resp := post(t, reviewsURL()+"/reviews", review, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("create review: want 201, got %d: %s", status, resp)
+	}
+	checkPhotos := func(where string, photos []any) {
+		t.Helper()
+		if len(photos) != 2 {
+			t.Fatalf("%s: want 2 photos, got %v", where, photos)
+		}
+		first, _ := photos[0].(map[string]any)
+		second, _ := photos[1].(map[string]any)
+		if first["caption"] != "Balcony" || second["url"] != "https://cdn.example.com/kitchen.jpg" {
+			t.Errorf("%s: photos out of order or altered: %v", where, photos)
+		}
+	}
+	checkPhotos("create", jsonArray(t, resp, "photos"))
+
+	status, resp = get(t, reviewsURL()+"/reviews/listing/"+listingID, nil)
+	if status != http.StatusOK {
+		t.Fatalf("list reviews: want 200, got %d: %s", status, resp)
+	}
+	reviews := jsonArray(t, resp, "reviews")
+	if len(reviews) != 1 {
+		t.Fatalf("want 1 review for listing, got %d", len(reviews))
+	}
+	rev, _ := reviews[0].(map[string]any)
+	photos, _ := rev["photos"].([]any)
+	checkPhotos("listing feed", photos)
+}
+
+// ===========================================================================
+// Scenario 59: Helpful Review Votes
+//
+// Votes are one per user, authors can't vote on their own review, other
+// tenants can't see it to vote, and sort=helpful puts the most-voted review
+// first.
+// ===========================================================================
+
+func TestReviewHelpfulVotes(t *testing.T) {
+	listingID := createListing(t, hostUser, "Helpful Votes Flat")
+	create := func(suffix, comment string) string {
+		t.Helper()
+		status, resp := post(t, reviewsURL()+"/reviews", map[string]any{
+			"bookingId": listingID + "-" + suffix,
+			"listingId": listingID,
+			"rating":    4,
+			"comment":   comment,
+		}, authHeaders(defaultUser))
+		if status != http.StatusCreated {
+			t.Fatalf("create review %s: want 201, got %d: %s", suffix, status, resp)
+		}
+		return jsonField(t, resp, "id")
+	}
+	useful := create("a", "Detailed notes on transport and noise")
+	create("b", "Nice")
+
+	helpfulURL := reviewsURL() + "/reviews/" + useful + "/helpful"
+	if status, _ := post(t, helpfulURL, nil, authHeaders(defaultUser)); status != http.StatusForbidden {
+		t.Errorf("author voting own review: want 403, got %d", status)
+	}
+	outsider := testUser{UserID: "e2e-helpful-outsider", TenantID: "e2e-tenant-other", Email: "outsider@zist.test", Scopes: defaultUser.Scopes}
+	if status, _ := post(t, helpfulURL, nil, authHeaders(outsider)); status != http.StatusNotFound {
+		t.Errorf("voting from another tenant: want 404, got %d", status)
+	}
+
+	for i := 0; i < 2; i++ {
+		status, resp := post(t, helpfulURL, nil, authHeaders(guestUser2))
+		if status != http.StatusOK {
+			t.Fatalf("vote %d: want 200, got %d: %s", i+1, status, resp)
+		}
+		if got := jsonField(t, resp, "helpfulCount"); got != "1" {
+			t.Errorf("vote %d: helpfulCount want 1, got %s", i+1, got)
+		}
+	}
+	status, resp := post(t, helpfulURL, nil, authHeaders(hostUser))
+	if status != http.StatusOK || jsonField(t, resp, "helpfulCount") != "2" {
+		t.Errorf("second voter: want 200 with count 2, got %d: %s", status, resp)
+	}
+
+	status, resp = get(t, reviewsURL()+"/reviews/listing/"+listingID+"?sort=helpful", nil)
+	if status != http.StatusOK {
+		t.Fatalf("list helpful: want 200, got %d: %s", status, resp)
+	}
+	reviews := jsonArray(t, resp, "reviews")
+	if len(reviews) != 2 {
+		t.Fatalf("want 2 reviews, got %d", len(reviews))
+	}
+	if first, _ := reviews[0].(map[string]any); first["id"] != useful {
+		t.Errorf("sort=helpful: want %s first, got %v", useful, first["id"])
+	}
+
+	status, resp = del(t, helpfulURL, authHeaders(hostUser))
+	if status != http.StatusOK || jsonField(t, resp, "helpfulCount") != "1" {
+		t.Errorf("withdraw vote: want 200 with count 1, got %d: %s", status, resp)
+	}
+
+	if status, _ := get(t, reviewsURL()+"/reviews/listing/"+listingID+"?sort=stars", nil); status != http.StatusBadRequest {
+		t.Errorf("unknown sort: want 400, got %d", status)
+	}
+}
+
+// ===========================================================================
+// Scenario 60: Tenant Review Visibility
+//
+// Reviews are public by default; a tenant with publicReviews off hides them
+// from anonymous callers but still serves signed-in users.
+// ===========================================================================
+
+func TestTenantPublicReviews(t *testing.T) {
+	review := func(guest testUser, listingID string) {
+		t.Helper()
+		status, resp := post(t, reviewsURL()+"/reviews", map[string]any{
+			"bookingId": listingID + "-booking",
+			"listingId": listingID,
+			"rating":    5,
+			"comment":   "Visibility check",
+		}, authHeaders(guest))
+		if status != http.StatusCreated {
+			t.Fatalf("create review: want 201, got %d: %s", status, resp)
+		}
+	}
+
+	// Default tenant: anonymous reads work.
+	publicListing := createListing(t, hostUser, "Public Reviews Flat")
+	review(defaultUser, publicListing)
+	if status, resp := get(t, reviewsURL()+"/reviews/listing/"+publicListing, nil); status != http.StatusOK {
+		t.Errorf("public tenant, anonymous: want 200, got %d: %s", status, resp)
+	}
+
+	// Private tenant: a dedicated one so the cached setting stays contained.
+	guest := testUser{UserID: "e2e-private-guest", TenantID: "e2e-tenant-private-reviews", Email: "private-guest@zist.test", Scopes: defaultUser.Scopes}
+	status, resp := put(t, adminURL()+"/admin/tenants/"+guest.TenantID, map[string]any{
+		"platformFeePct": 12.0,
+		"maxListings":    50,
+		"publicReviews":  false,
+	}, authHeaders(adminUser))
+	if status != http.StatusOK {
+		t.Fatalf("disable public reviews: want 200, got %d: %s", status, resp)
+	}
+	privateHost := testUser{UserID: "e2e-private-host", TenantID: guest.TenantID, Email: "private-host@zist.test", Scopes: hostUser.Scopes}
+	privateListing := createListing(t, privateHost, "Private Reviews Flat")
+	review(guest, privateListing)
+
+	if status, _ := get(t, reviewsURL()+"/reviews/listing/"+privateListing, nil); status != http.StatusUnauthorized {
+		t.Errorf("private tenant, anonymous: want 401, got %d", status)
+	}
+	status, resp = get(t, reviewsURL()+"/reviews/listing/"+privateListing, authHeaders(guest))
+	if status != http.StatusOK {
+		t.Fatalf("private tenant, signed in: want 200, got %d: %s", status, resp)
+	}
+	if n := len(jsonArray(t, resp, "reviews")); n != 1 {
+		t.Errorf("private tenant, signed in: want 1 review, got %d", n)
+	}
+}
+
+// ===========================================================================
+// Scenario 61: Booking Summary
+//
+// The summary embeds the listing and reports canReview; a confirmed stay
+// that hasn't completed can't be reviewed yet, and outsiders get 403.
+// ===========================================================================
+
+func TestBookingSummary(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Summary Cottage",
+		"city":          "Khiva",
+		"pricePerNight": "180000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+		"instantBook":   true,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/summary.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID, "checkIn": "2034-10-01", "checkOut": "2034-10-03", "guests": 1,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("create booking: want 201, got %d: %s", status, resp)
+	}
+	bookingID := jsonField(t, resp, "id")
+	defer post(t, bookingsURL()+"/bookings/"+bookingID+"/cancel", nil, authHeaders(defaultUser))
+	post(t, bookingsURL()+"/bookings/"+bookingID+"/confirm",
+		map[string]any{"paymentId": "pay_summary"}, internalHeaders())
+
+	summaryURL := bookingsURL() + "/bookings/" + bookingID + "/summary"
+	for _, u := range []testUser{defaultUser, hostUser} {
+		status, resp = get(t, summaryURL, authHeaders(u))
+		if status != http.StatusOK {
+			t.Fatalf("summary as %s: want 200, got %d: %s", u.UserID, status, resp)
+		}
+		var out struct {
+			Booking struct {
+				ID      string `json:"id"`
+				Status  string `json:"status"`
+				Listing *struct {
+					Title      string `json:"title"`
+					CoverPhoto string `json:"coverPhoto"`
+				} `json:"listing"`
+			} `json:"booking"`
+			CanReview bool `json:"canReview"`
+		}
+		if err := json.Unmarshal(resp, &out); err != nil {
+			t.Fatalf("decode summary: %v", err)
+		}
+		if out.Booking.ID != bookingID || out.Booking.Status != "confirmed" {
+			t.Errorf("summary booking: got %s", resp)
+		}
+		if out.Booking.Listing == nil || out.Booking.Listing.Title != "Summary Cottage" ||
+			out.Booking.Listing.CoverPhoto != "https://example.com/summary.jpg" {
+			t.Errorf("summary listing: got %s", resp)
+		}
+		if out.CanReview {
+			t.Errorf("as %s: stay not completed, canReview should be false", u.UserID)
+		}
+	}
+
+	if status, _ := get(t, summaryURL, authHeaders(guestUser2)); status != http.StatusForbidden {
+		t.Errorf("outsider: want 403, got %d", status)
+	}
+}
+
+// ===========================================================================
+// Scenario 62: Checkout Retries Reuse the Session
+//
+// Two checkout calls for one booking hand back the same Mashgate session;
+// the retry answers 200 instead of opening a duplicate.
+// ===========================================================================
+
+func TestCheckoutIdempotent(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Idempotent Checkout Flat",
+		"city":          "Termez",
+		"pricePerNight": "150000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+		"instantBook":   true,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/idempotent.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID, "checkIn": "2034-11-01", "checkOut": "2034-11-03", "guests": 1,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("create booking: want 201, got %d: %s", status, resp)
+	}
+	bookingID := jsonField(t, resp, "id")
+	defer post(t, bookingsURL()+"/bookings/"+bookingID+"/cancel", nil, authHeaders(defaultUser))
+
+	body := map[string]any{
+		"bookingId": bookingID, "listingId": listingID,
+		"amount": jsonField(t, resp, "totalAmount"), "currency": "UZS",
+	}
+	status, first := post(t, paymentsURL()+"/checkout", body, authHeaders(defaultUser))
+	// 201 if Mashgate is running, 502 if unavailable — both are valid.
+	if status == http.StatusBadGateway {
+		t.Skip("Mashgate unavailable — cannot open a checkout session")
+	}
+	if status != http.StatusCreated {
+		t.Fatalf("first checkout: want 201, got %d: %s", status, first)
+	}
+
+	status, second := post(t, paymentsURL()+"/checkout", body, authHeaders(defaultUser))
+	if status != http.StatusOK {
+		t.Fatalf("retried checkout: want 200, got %d: %s", status, second)
+	}
+	if a, b := jsonField(t, first, "sessionId"), jsonField(t, second, "sessionId"); a != b {
+		t.Errorf("retry opened a new session: %s then %s", a, b)
+	}
+}
+
+// ===========================================================================
+// Scenario 63: Listing Transfer Moves Its Bookings
+//
+// An admin hands a listing to another host in the tenant; the existing
+// booking now shows up for the new host. A host from another tenant is
+// refused, as is a non-admin caller. Membership comes from mgID, so the
+// transfer itself is skipped when mgID doesn't know the test's host.
+// ===========================================================================
+
+func TestListingTransfer(t *testing.T) {
+	newHost := testUser{
+		UserID:   fmt.Sprintf("e2e-host-transfer-%d", time.Now().UnixNano()%1e9),
+		TenantID: hostUser.TenantID,
+		Email:    "transfer@zist.test",
+		Scopes:   hostUser.Scopes,
+	}
+
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Transferred Flat",
+		"city":          "Nukus",
+		"pricePerNight": "110000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+		"instantBook":   true,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(newHost))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/transfer.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID, "checkIn": "2034-12-01", "checkOut": "2034-12-03", "guests": 1,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("create booking: want 201, got %d: %s", status, resp)
+	}
+	bookingID := jsonField(t, resp, "id")
+	defer post(t, bookingsURL()+"/bookings/"+bookingID+"/cancel", nil, authHeaders(defaultUser))
+
+	transferURL := listingsURL() + "/listings/" + listingID + "/transfer"
+	if status, _ := post(t, transferURL, map[string]any{"hostId": newHost.UserID}, authHeaders(hostUser)); status != http.StatusForbidden {
+		t.Errorf("transfer by host: want 403, got %d", status)
+	}
+
+	// A tenant 2 host cannot receive a tenant 1 listing.
+	_, resp = post(t, listingsURL()+"/listings", map[string]any{
+		"title": "Tenant Two Flat", "city": "Samarkand", "pricePerNight": "90000.00", "currency": "UZS",
+	}, authHeaders(tenant2Host))
+	t2Listing := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+t2Listing, authHeaders(tenant2Host))
+	if status, body := post(t, transferURL, map[string]any{"hostId": tenant2Host.UserID}, authHeaders(adminUser)); status != http.StatusUnprocessableEntity {
+		t.Errorf("transfer to other tenant's host: want 422, got %d: %s", status, body)
+	}
+
+	status, resp = post(t, transferURL, map[string]any{"hostId": newHost.UserID}, authHeaders(adminUser))
+	if status == http.StatusServiceUnavailable || status == http.StatusBadGateway ||
+		(status == http.StatusUnprocessableEntity && strings.Contains(string(resp), "not a known user")) {
+		t.Skipf("mgID can't confirm the new host's tenant: %d %s", status, resp)
+	}
+	if status != http.StatusOK {
+		t.Fatalf("transfer: want 200, got %d: %s", status, resp)
+	}
+	if jsonField(t, resp, "bookingsUpdated") != "1" {
+		t.Errorf("bookingsUpdated: want 1, got %s", resp)
+	}
+
+	_, resp = get(t, listingsURL()+"/listings/"+listingID, nil)
+	if jsonField(t, resp, "hostId") != newHost.UserID {
+		t.Errorf("listing hostId: want %s, got %s", newHost.UserID, jsonField(t, resp, "hostId"))
+	}
+	_, resp = get(t, bookingsURL()+"/bookings/"+bookingID, authHeaders(defaultUser))
+	if jsonField(t, resp, "hostId") != newHost.UserID {
+		t.Errorf("booking hostId: want %s, got %s", newHost.UserID, jsonField(t, resp, "hostId"))
+	}
+	status, resp = get(t, bookingsURL()+"/bookings/host?listingId="+listingID, authHeaders(newHost))
+	if status != http.StatusOK || len(jsonArray(t, resp, "bookings")) != 1 {
+		t.Errorf("new host's bookings: want the transferred booking, got %d: %s", status, resp)
+	}
+	_, resp = get(t, bookingsURL()+"/bookings/host?listingId="+listingID, authHeaders(hostUser))
+	if len(jsonArray(t, resp, "bookings")) != 0 {
+		t.Errorf("previous host should no longer see the booking: %s", resp)
+	}
+}
+
+// ===========================================================================
+// Scenario 64: Pay on Arrival
+//
+// An instant-book listing that takes payment on arrival confirms bookings
+// straight away, skipping payment_pending; without the flag they wait for
+// payment as usual.
+// ===========================================================================
+
+func TestPayOnArrivalSkipsPaymentPending(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Cash Guesthouse",
+		"city":          "Kokand",
+		"pricePerNight": "80000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+		"instantBook":   true,
+		"payOnArrival":  true,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	if jsonField(t, resp, "payOnArrival") != "true" {
+		t.Fatalf("listing should be pay-on-arrival: %s", resp)
+	}
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/cash.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	book := func(checkIn, checkOut string) []byte {
+		status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+			"listingId": listingID, "checkIn": checkIn, "checkOut": checkOut, "guests": 1,
+		}, authHeaders(defaultUser))
+		if status != http.StatusCreated {
+			t.Fatalf("create booking: want 201, got %d: %s", status, resp)
+		}
+		return resp
+	}
+
+	resp = book("2035-01-10", "2035-01-12")
+	onArrivalID := jsonField(t, resp, "id")
+	if got := jsonField(t, resp, "status"); got != "confirmed" {
+		t.Errorf("pay-on-arrival booking: want confirmed, got %q", got)
+	}
+	if got := jsonField(t, resp, "paymentStatus"); got != "on_arrival" {
+		t.Errorf("pay-on-arrival booking: want paymentStatus on_arrival, got %q", got)
+	}
+	if got := jsonField(t, resp, "expiresAt"); got != "" && got != "null" {
+		t.Errorf("pay-on-arrival booking: want no expiresAt, got %s", got)
+	}
+
+	// Nothing was charged, so cancelling refunds nothing.
+	status, resp := post(t, bookingsURL()+"/bookings/"+onArrivalID+"/cancel", nil, authHeaders(defaultUser))
+	if status != http.StatusOK {
+		t.Fatalf("cancel pay-on-arrival booking: want 200, got %d: %s", status, resp)
+	}
+	var cancelled struct {
+		Refund struct {
+			RefundAmount string `json:"refundAmount"`
+		} `json:"refund"`
+	}
+	if err := json.Unmarshal(resp, &cancelled); err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.Refund.RefundAmount != "0.00" {
+		t.Errorf("cancel pay-on-arrival booking: want refundAmount 0.00, got %s", cancelled.Refund.RefundAmount)
+	}
+
+	// Opting out restores the normal payment flow.
+	if status, body := patch(t, listingsURL()+"/listings/"+listingID, map[string]any{"payOnArrival": false}, authHeaders(hostUser)); status != http.StatusOK {
+		t.Fatalf("disable payOnArrival: want 200, got %d: %s", status, body)
+	}
+	resp = book("2035-02-10", "2035-02-12")
+	defer post(t, bookingsURL()+"/bookings/"+jsonField(t, resp, "id")+"/cancel", nil, authHeaders(defaultUser))
+	if got := jsonField(t, resp, "status"); got != "payment_pending" {
+		t.Errorf("after opting out: want payment_pending, got %q", got)
+	}
+}
+
+// ===========================================================================
+// Scenario 65: Pause All of a Host's Listings
+//
+// A host going on leave pauses every active listing in one call; only their
+// own active listings change, and resuming brings them back.
+// ===========================================================================
+
+func TestBulkPauseMyListings(t *testing.T) {
+	// A dedicated host, so other scenarios' listings are left alone.
+	host := testUser{
+		UserID:   "e2e-bulk-host-001",
+		TenantID: hostUser.TenantID,
+		Email:    "bulk-host@zist.test",
+		Scopes:   hostUser.Scopes,
+	}
+	var ids []string
+	for i := 1; i <= 3; i++ {
+		_, resp := post(t, listingsURL()+"/listings", map[string]any{
+			"title":         fmt.Sprintf("Leave Flat %d", i),
+			"city":          "Nukus",
+			"pricePerNight": "60000.00",
+			"currency":      "UZS",
+			"maxGuests":     2,
+		}, authHeaders(host))
+		id := jsonField(t, resp, "id")
+		defer del(t, listingsURL()+"/listings/"+id, authHeaders(host))
+		post(t, listingsURL()+"/listings/"+id+"/photos", map[string]any{"url": "https://example.com/leave.jpg"}, authHeaders(host))
+		if status, resp := post(t, listingsURL()+"/listings/"+id+"/publish", nil, authHeaders(host)); status != http.StatusOK {
+			t.Fatalf("publish: want 200, got %d: %s", status, resp)
+		}
+		ids = append(ids, id)
+	}
+
+	if status, resp := post(t, listingsURL()+"/listings/mine/status", map[string]any{"status": "archived"}, authHeaders(host)); status != http.StatusUnprocessableEntity {
+		t.Errorf("archive all: want 422, got %d: %s", status, resp)
+	}
+
+	status, resp := post(t, listingsURL()+"/listings/mine/status", map[string]any{"status": "paused"}, authHeaders(host))
+	if status != http.StatusOK {
+		t.Fatalf("pause all: want 200, got %d: %s", status, resp)
+	}
+	if got := jsonField(t, resp, "updated"); got != "3" {
+		t.Errorf("updated: want 3, got %s", got)
+	}
+	for _, id := range ids {
+		_, resp := get(t, listingsURL()+"/listings/"+id, authHeaders(host))
+		if got := jsonField(t, resp, "status"); got != "paused" {
+			t.Errorf("listing %s: want paused, got %s", id, got)
+		}
+	}
+
+	_, resp = post(t, listingsURL()+"/listings/mine/status", map[string]any{"status": "paused"}, authHeaders(host))
+	if got := jsonField(t, resp, "updated"); got != "0" {
+		t.Errorf("second pause: want 0 updated, got %s", got)
+	}
+	_, resp = post(t, listingsURL()+"/listings/mine/status", map[string]any{"status": "active"}, authHeaders(host))
+	if got := jsonField(t, resp, "updated"); got != "3" {
+		t.Errorf("resume all: want 3 updated, got %s", got)
+	}
+}
+
+// ===========================================================================
+// Scenario 66: Seasonal Rate Plans
+//
+// A 1.5× summer plan raises the price preview for summer nights; a per-date
+// override still wins, overlapping plans are refused, and deleting the plan
+// restores the base price.
+// ===========================================================================
+
+func TestRatePlanRaisesPreview(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Seasonal Dacha",
+		"city":          "Chimgan",
+		"pricePerNight": "100000.00",
+		"currency":      "UZS",
+		"maxGuests":     4,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	base := listingsURL() + "/listings/" + listingID
+	preview := base + "/price-preview?check_in=2027-07-10&check_out=2027-07-12"
+
+	_, resp = get(t, preview, nil)
+	if got := jsonField(t, resp, "subtotal"); got != "200000.00" {
+		t.Fatalf("base subtotal: want 200000.00, got %s", got)
+	}
+	baseTotal := parseAmount(t, jsonField(t, resp, "total"))
+
+	if status, resp := post(t, base+"/rate-plans", map[string]any{
+		"name": "Summer", "from": "2027-06-01", "to": "2027-06-01", "multiplier": "1.5",
+	}, authHeaders(hostUser)); status != http.StatusUnprocessableEntity {
+		t.Errorf("empty range: want 422, got %d: %s", status, resp)
+	}
+	if status, resp := post(t, base+"/rate-plans", map[string]any{
+		"name": "Summer", "from": "2027-06-01", "to": "2027-09-01", "multiplier": "1.5",
+	}, authHeaders(defaultUser)); status != http.StatusForbidden {
+		t.Errorf("non-owner: want 403, got %d: %s", status, resp)
+	}
+	status, resp := post(t, base+"/rate-plans", map[string]any{
+		"name": "Summer", "from": "2027-06-01", "to": "2027-09-01", "multiplier": "1.5",
+	}, authHeaders(hostUser))
+	if status != http.StatusCreated {
+		t.Fatalf("create plan: want 201, got %d: %s", status, resp)
+	}
+	planID := jsonField(t, resp, "id")
+	if status, resp := post(t, base+"/rate-plans", map[string]any{
+		"name": "Peak", "from": "2027-08-15", "to": "2027-09-15", "multiplier": "2",
+	}, authHeaders(hostUser)); status != http.StatusConflict {
+		t.Errorf("overlapping plan: want 409, got %d: %s", status, resp)
+	}
+
+	_, resp = get(t, preview, nil)
+	if got := jsonField(t, resp, "subtotal"); got != "300000.00" {
+		t.Errorf("summer subtotal: want 300000.00, got %s", got)
+	}
+	if total := parseAmount(t, jsonField(t, resp, "total")); total <= baseTotal {
+		t.Errorf("summer total %v should exceed base total %v", total, baseTotal)
+	}
+
+	patch(t, base+"/availability/price", map[string]any{
+		"entries": []map[string]any{{"date": "2027-07-10", "price": "120000.00"}},
+	}, authHeaders(hostUser))
+	_, resp = get(t, preview, nil)
+	if got := jsonField(t, resp, "subtotal"); got != "270000.00" {
+		t.Errorf("override + plan subtotal: want 270000.00, got %s", got)
+	}
+
+	if status, _ := del(t, base+"/rate-plans/"+planID, authHeaders(hostUser)); status != http.StatusNoContent {
+		t.Errorf("delete plan: want 204, got %d", status)
+	}
+	_, resp = get(t, base+"/rate-plans", authHeaders(hostUser))
+	if plans := jsonArray(t, resp, "ratePlans"); len(plans) != 0 {
+		t.Errorf("after delete: want no plans, got %v", plans)
+	}
+}
+
+// ===========================================================================
+// Scenario 67: Completing a Booking Early Is Refused
+//
+// The internal complete endpoint only closes out confirmed stays whose
+// check-out has passed; a future stay stays confirmed.
+// ===========================================================================
+
+func TestCompleteBookingBeforeCheckOut(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Early Checkout Flat",
+		"city":          "Andijan",
+		"pricePerNight": "90000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+		"instantBook":   true,
+		"payOnArrival":  true,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/early.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID, "checkIn": "2035-03-10", "checkOut": "2035-03-12", "guests": 1,
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated || jsonField(t, resp, "status") != "confirmed" {
+		t.Fatalf("create booking: want confirmed 201, got %d: %s", status, resp)
+	}
+	bookingID := jsonField(t, resp, "id")
+	defer post(t, bookingsURL()+"/bookings/"+bookingID+"/cancel", nil, authHeaders(defaultUser))
+
+	if status, resp := post(t, bookingsURL()+"/bookings/"+bookingID+"/complete", nil, nil); status != http.StatusUnauthorized && status != http.StatusForbidden {
+		t.Errorf("without internal token: want 401/403, got %d: %s", status, resp)
+	}
+	status, resp = post(t, bookingsURL()+"/bookings/"+bookingID+"/complete", nil, internalHeaders())
+	if status != http.StatusConflict {
+		t.Fatalf("premature complete: want 409, got %d: %s", status, resp)
+	}
+	if !strings.Contains(string(resp), "check-out") {
+		t.Errorf("premature complete: want a check-out error, got %s", resp)
+	}
+	_, resp = get(t, bookingsURL()+"/bookings/"+bookingID, authHeaders(defaultUser))
+	if got := jsonField(t, resp, "status"); got != "confirmed" {
+		t.Errorf("after refused complete: want confirmed, got %s", got)
+	}
+}
+
+// ===========================================================================
+// Scenario 68: Oversize Booking Message Is Rejected
+//
+// A booking request whose message exceeds the configured length (2000
+// characters by default) is refused with 422 and reserves nothing.
+// ===========================================================================
+
+func TestBookingMessageTooLong(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Chatty Guest Flat",
+		"city":          "Namangan",
+		"pricePerNight": "70000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+		"instantBook":   true,
+		"payOnArrival":  true,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/chatty.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID, "checkIn": "2035-04-10", "checkOut": "2035-04-12", "guests": 1,
+		"message": strings.Repeat("x", 2001),
+	}, authHeaders(defaultUser))
+	if status != http.StatusUnprocessableEntity {
+		t.Fatalf("oversize message: want 422, got %d: %s", status, resp)
+	}
+	if !strings.Contains(string(resp), "message") {
+		t.Errorf("oversize message: want a message error, got %s", resp)
+	}
+
+	status, resp = post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID, "checkIn": "2035-04-10", "checkOut": "2035-04-12", "guests": 1,
+		"message": "See you soon",
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("short message: want 201 (dates still free), got %d: %s", status, resp)
+	}
+	defer post(t, bookingsURL()+"/bookings/"+jsonField(t, resp, "id")+"/cancel", nil, authHeaders(defaultUser))
+}
+
+// ===========================================================================
+// Scenario 69: Batch Stay Validation
+//
+// Bulk importers pre-validate a mix of stays: an active listing passes, a
+// draft is reported inactive, and an over-capacity stay names the capacity.
+// ===========================================================================
+
+func TestValidateStaysBatch(t *testing.T) {
+	create := func(title string) string {
+		_, resp := post(t, listingsURL()+"/listings", map[string]any{
+			"title":         title,
+			"city":          "Fergana",
+			"pricePerNight": "80000.00",
+			"currency":      "UZS",
+			"maxGuests":     2,
+		}, authHeaders(hostUser))
+		id := jsonField(t, resp, "id")
+		t.Cleanup(func() { del(t, listingsURL()+"/listings/"+id, authHeaders(hostUser)) })
+		return id
+	}
+	activeID := create("Validate Active Flat")
+	post(t, listingsURL()+"/listings/"+activeID+"/photos", map[string]any{
+		"url": "https://example.com/validate.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	if status, resp := post(t, listingsURL()+"/listings/"+activeID+"/publish", nil, authHeaders(hostUser)); status != http.StatusOK {
+		t.Fatalf("publish: want 200, got %d: %s", status, resp)
+	}
+	draftID := create("Validate Draft Flat")
+
+	body := map[string]any{"items": []map[string]any{
+		{"listingId": activeID, "checkIn": "2035-06-01", "checkOut": "2035-06-03", "guests": 2},
+		{"listingId": draftID, "checkIn": "2035-06-01", "checkOut": "2035-06-03", "guests": 1},
+		{"listingId": activeID, "checkIn": "2035-06-01", "checkOut": "2035-06-03", "guests": 5},
+	}}
+	if status, resp := post(t, listingsURL()+"/listings/validate", body, nil); status != http.StatusUnauthorized && status != http.StatusForbidden {
+		t.Errorf("without internal token: want 401/403, got %d: %s", status, resp)
+	}
+	status, resp := post(t, listingsURL()+"/listings/validate", body, internalHeaders())
+	if status != http.StatusOK {
+		t.Fatalf("validate: want 200, got %d: %s", status, resp)
+	}
+	var out struct {
+		Results []struct {
+			ListingID string   `json:"listingId"`
+			Valid     bool     `json:"valid"`
+			Errors    []string `json:"errors"`
+		} `json:"results"`
+		Valid   int `json:"valid"`
+		Invalid int `json:"invalid"`
+	}
+	if err := json.Unmarshal(resp, &out); err != nil || len(out.Results) != 3 {
+		t.Fatalf("decode: want 3 results, got %s", resp)
+	}
+	if !out.Results[0].Valid {
+		t.Errorf("active listing: want valid, got %+v", out.Results[0])
+	}
+	if r := out.Results[1]; r.Valid || r.ListingID != draftID || len(r.Errors) != 1 || r.Errors[0] != "listing is not active" {
+		t.Errorf("draft listing: want not active, got %+v", r)
+	}
+	if r := out.Results[2]; r.Valid || len(r.Errors) != 1 || !strings.Contains(r.Errors[0], "capacity") {
+		t.Errorf("over capacity: want capacity error, got %+v", r)
+	}
+	if out.Valid != 1 || out.Invalid != 2 {
+		t.Errorf("summary: want 1 valid / 2 invalid, got %d / %d", out.Valid, out.Invalid)
+	}
+}
+
+// ===========================================================================
+// Scenario 70: Photo Minimum Before Booking
+//
+// With minPhotosToBook = 3 a published listing with one photo can't be
+// booked (422); once it has three photos the same booking goes through. A
+// dedicated tenant keeps the threshold out of other scenarios.
+// ===========================================================================
+
+func TestMinPhotosToBook(t *testing.T) {
+	host := testUser{UserID: "e2e-photos-host", TenantID: "e2e-tenant-photos", Email: "photos-host@zist.test", Scopes: hostUser.Scopes}
+	guest := testUser{UserID: "e2e-photos-guest", TenantID: "e2e-tenant-photos", Email: "photos-guest@zist.test", Scopes: defaultUser.Scopes}
+
+	if status, resp := put(t, adminURL()+"/admin/tenants/"+host.TenantID, map[string]any{
+		"platformFeePct": 12.0, "maxListings": 50, "minPhotosToBook": 21,
+	}, authHeaders(adminUser)); status != http.StatusUnprocessableEntity {
+		t.Errorf("minPhotosToBook 21: want 422, got %d: %s", status, resp)
+	}
+	if status, resp := put(t, adminURL()+"/admin/tenants/"+host.TenantID, map[string]any{
+		"platformFeePct": 12.0, "maxListings": 50, "minPhotosToBook": 3,
+	}, authHeaders(adminUser)); status != http.StatusOK {
+		t.Fatalf("set minPhotosToBook: want 200, got %d: %s", status, resp)
+	}
+
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Sparse Photo Flat",
+		"city":          "Karshi",
+		"pricePerNight": "60000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+		"instantBook":   true,
+		"payOnArrival":  true,
+	}, authHeaders(host))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(host))
+	addPhoto := func(n int) {
+		post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+			"url": fmt.Sprintf("https://example.com/sparse-%d.jpg", n), "caption": "photo",
+		}, authHeaders(host))
+	}
+	addPhoto(1)
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(host))
+
+	booking := map[string]any{
+		"listingId": listingID, "checkIn": "2035-07-01", "checkOut": "2035-07-03", "guests": 1,
+	}
+	status, resp := post(t, bookingsURL()+"/bookings", booking, authHeaders(guest))
+	if status != http.StatusUnprocessableEntity {
+		t.Fatalf("one photo: want 422, got %d: %s", status, resp)
+	}
+	if !strings.Contains(string(resp), "at least 3 photos") {
+		t.Errorf("one photo: want a photo minimum error, got %s", resp)
+	}
+
+	addPhoto(2)
+	addPhoto(3)
+	status, resp = post(t, bookingsURL()+"/bookings", booking, authHeaders(guest))
+	if status != http.StatusCreated {
+		t.Fatalf("three photos: want 201, got %d: %s", status, resp)
+	}
+	defer post(t, bookingsURL()+"/bookings/"+jsonField(t, resp, "id")+"/cancel", nil, authHeaders(guest))
+}
+
+// ===========================================================================
+// Scenario 71: Custom Cancellation Policy
+//
+// A tenant defines super_strict (75% a month out, 25% a week out). Listings
+// may use it while unknown names are refused, and a guest cancelling ten
+// days out gets the 25% tier.
+// ===========================================================================
+
+func TestCustomCancellationPolicy(t *testing.T) {
+	host := testUser{UserID: "e2e-policy-host", TenantID: "e2e-tenant-policy", Email: "policy-host@zist.test", Scopes: hostUser.Scopes}
+	guest := testUser{UserID: "e2e-policy-guest", TenantID: "e2e-tenant-policy", Email: "policy-guest@zist.test", Scopes: defaultUser.Scopes}
+
+	if status, resp := put(t, adminURL()+"/admin/tenants/"+host.TenantID, map[string]any{
+		"platformFeePct": 12.0, "maxListings": 50,
+		"cancellationPolicies": []map[string]any{{"name": "strict", "tiers": []map[string]any{{"hoursBefore": 0, "refundPct": 100}}}},
+	}, authHeaders(adminUser)); status != http.StatusUnprocessableEntity {
+		t.Errorf("redefining a built-in: want 422, got %d: %s", status, resp)
+	}
+	if status, resp := put(t, adminURL()+"/admin/tenants/"+host.TenantID, map[string]any{
+		"platformFeePct": 12.0, "maxListings": 50,
+		"cancellationPolicies": []map[string]any{{"name": "super_strict", "tiers": []map[string]any{
+			{"hoursBefore": 30 * 24, "refundPct": 75},
+			{"hoursBefore": 7 * 24, "refundPct": 25},
+		}}},
+	}, authHeaders(adminUser)); status != http.StatusOK {
+		t.Fatalf("set policies: want 200, got %d: %s", status, resp)
+	}
+
+	listing := map[string]any{
+		"title":              "Super Strict Flat",
+		"city":               "Termez",
+		"pricePerNight":      "100000.00",
+		"currency":           "UZS",
+		"maxGuests":          2,
+		"instantBook":        true,
+		"payOnArrival":       true,
+		"cancellationPolicy": "ultra_strict",
+	}
+	if status, resp := post(t, listingsURL()+"/listings", listing, authHeaders(host)); status != http.StatusUnprocessableEntity {
+		t.Errorf("unknown policy: want 422, got %d: %s", status, resp)
+	}
+	listing["cancellationPolicy"] = "super_strict"
+	status, resp := post(t, listingsURL()+"/listings", listing, authHeaders(host))
+	if status != http.StatusCreated {
+		t.Fatalf("create with custom policy: want 201, got %d: %s", status, resp)
+	}
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(host))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/strict.jpg", "caption": "cover",
+	}, authHeaders(host))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(host))
+
+	checkIn := time.Now().AddDate(0, 0, 10).Format("2006-01-02")
+	checkOut := time.Now().AddDate(0, 0, 12).Format("2006-01-02")
+	status, resp = post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID, "checkIn": checkIn, "checkOut": checkOut, "guests": 1,
+	}, authHeaders(guest))
+	if status != http.StatusCreated {
+		t.Fatalf("create booking: want 201, got %d: %s", status, resp)
+	}
+	bookingID := jsonField(t, resp, "id")
+	if got := jsonField(t, resp, "cancellationPolicy"); got != "super_strict" {
+		t.Errorf("booking policy: want super_strict, got %s", got)
+	}
+
+	status, resp = post(t, bookingsURL()+"/bookings/"+bookingID+"/cancel", nil, authHeaders(guest))
+	if status != http.StatusOK {
+		t.Fatalf("cancel: want 200, got %d: %s", status, resp)
+	}
+	var body struct {
+		Refund struct {
+			RefundPct int `json:"refundPct"`
+		} `json:"refund"`
+	}
+	if err := json.Unmarshal(resp, &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Refund.RefundPct != 25 {
+		t.Errorf("cancel ten days out: want the 25%% tier, got %d%%: %s", body.Refund.RefundPct, resp)
+	}
+}
+
+// ===========================================================================
+// Scenario 72: Availability Reconciliation
+//
+// A cancelled booking's dates are reserved again (a lost release) and dates
+// are reserved for a booking that never existed. Reconciliation reports
+// both, releases only the stale ones when asked to fix, and keeps flagging
+// the orphan.
+// ===========================================================================
+
+func TestReconcileAvailability(t *testing.T) {
+	host := testUser{UserID: "e2e-reconcile-host", TenantID: "e2e-tenant-reconcile", Email: "reconcile-host@zist.test", Scopes: hostUser.Scopes}
+	guest := testUser{UserID: "e2e-reconcile-guest", TenantID: "e2e-tenant-reconcile", Email: "reconcile-guest@zist.test", Scopes: defaultUser.Scopes}
+	internal := map[string]string{"X-Internal-Token": internalToken(), "X-Tenant-ID": host.TenantID}
+
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Drifting Flat",
+		"city":          "Nukus",
+		"pricePerNight": "80000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+		"instantBook":   true,
+		"payOnArrival":  true,
+	}, authHeaders(host))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(host))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/drift.jpg", "caption": "cover",
+	}, authHeaders(host))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(host))
+
+	day := func(n int) string { return time.Now().AddDate(0, 0, n).Format("2006-01-02") }
+	status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID, "checkIn": day(40), "checkOut": day(42), "guests": 1,
+	}, authHeaders(guest))
+	if status != http.StatusCreated {
+		t.Fatalf("create booking: want 201, got %d: %s", status, resp)
+	}
+	bookingID := jsonField(t, resp, "id")
+	if status, resp := post(t, bookingsURL()+"/bookings/"+bookingID+"/cancel", nil, authHeaders(guest)); status != http.StatusOK {
+		t.Fatalf("cancel: want 200, got %d: %s", status, resp)
+	}
+
+	reserve := func(bookingID string, dates ...string) {
+		t.Helper()
+		status, resp := post(t, listingsURL()+"/listings/"+listingID+"/availability/book",
+			map[string]any{"bookingId": bookingID, "dates": dates}, internal)
+		if status != http.StatusOK {
+			t.Fatalf("reserve for %s: want 200, got %d: %s", bookingID, status, resp)
+		}
+	}
+	reserve(bookingID, day(40), day(41))
+	reserve("e2e-orphan-booking", day(50))
+	defer doRequest(t, http.MethodDelete, listingsURL()+"/listings/"+listingID+"/availability/book",
+		map[string]any{"bookingId": "e2e-orphan-booking"}, internal)
+
+	type summary struct {
+		Checked       int `json:"checked"`
+		Stale         int `json:"stale"`
+		Orphaned      int `json:"orphaned"`
+		Released      int `json:"released"`
+		Discrepancies []struct {
+			BookingID string `json:"bookingId"`
+			Kind      string `json:"kind"`
+			Status    string `json:"status"`
+		} `json:"discrepancies"`
+	}
+	reconcile := func(query string) summary {
+		t.Helper()
+		status, resp := post(t, bookingsURL()+"/bookings/internal/reconcile"+query, nil, internal)
+		if status != http.StatusOK {
+			t.Fatalf("reconcile%s: want 200, got %d: %s", query, status, resp)
+		}
+		var s summary
+		if err := json.Unmarshal(resp, &s); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	got := reconcile("")
+	if got.Checked != 2 || got.Stale != 1 || got.Orphaned != 1 || got.Released != 0 {
+		t.Errorf("report: want 2 checked, 1 stale, 1 orphan, nothing released; got %+v", got)
+	}
+	for _, d := range got.Discrepancies {
+		switch d.BookingID {
+		case bookingID:
+			if d.Kind != "stale" || d.Status != "cancelled_by_guest" {
+				t.Errorf("cancelled booking: want stale/cancelled_by_guest, got %s/%s", d.Kind, d.Status)
+			}
+		case "e2e-orphan-booking":
+			if d.Kind != "orphan" {
+				t.Errorf("unknown booking: want orphan, got %s", d.Kind)
+			}
+		}
+	}
+
+	if got := reconcile("?fix=true"); got.Stale != 1 || got.Released != 2 {
+		t.Errorf("fix: want the stale reservation's 2 dates released, got %+v", got)
+	}
+	if got := reconcile(""); got.Checked != 1 || got.Stale != 0 || got.Orphaned != 1 {
+		t.Errorf("after fix: want only the orphan left, got %+v", got)
+	}
+}
+
+// ===========================================================================
+// Scenario 73: Paging Through the Listings List
+//
+// 55 listings in one city are paged through 20 at a time. Every listing is
+// seen exactly once, the total stays the same on each page, and omitting
+// limit still gives the first 50.
+// ===========================================================================
+
+func TestListListingsPaging(t *testing.T) {
+	host := testUser{UserID: "e2e-paging-host", TenantID: "e2e-tenant-paging", Email: "paging-host@zist.test", Scopes: hostUser.Scopes}
+	if status, resp := put(t, adminURL()+"/admin/tenants/"+host.TenantID, map[string]any{
+		"platformFeePct": 12.0, "maxListings": 100,
+	}, authHeaders(adminUser)); status != http.StatusOK {
+		t.Fatalf("raise maxListings: want 200, got %d: %s", status, resp)
+	}
+
+	const n = 55
+	city := fmt.Sprintf("Pagetown %d", time.Now().UnixNano())
+	created := map[string]bool{}
+	for i := 0; i < n; i++ {
+		status, resp := post(t, listingsURL()+"/listings", map[string]any{
+			"title":         fmt.Sprintf("Paged Flat %d", i),
+			"city":          city,
+			"pricePerNight": "50000.00",
+			"currency":      "UZS",
+		}, authHeaders(host))
+		if status != http.StatusCreated {
+			t.Fatalf("create listing %d: want 201, got %d: %s", i, status, resp)
+		}
+		id := jsonField(t, resp, "id")
+		created[id] = true
+		defer del(t, listingsURL()+"/listings/"+id, authHeaders(host))
+	}
+
+	base := listingsURL() + "/listings?status=draft&city=" + url.QueryEscape(city)
+	seen := map[string]bool{}
+	for offset := 0; offset < n; offset += 20 {
+		status, resp := get(t, fmt.Sprintf("%s&limit=20&offset=%d", base, offset), nil)
+		if status != http.StatusOK {
+			t.Fatalf("offset %d: want 200, got %d: %s", offset, status, resp)
+		}
+		if got := jsonField(t, resp, "total"); got != strconv.Itoa(n) {
+			t.Errorf("offset %d: want total %d, got %s", offset, n, got)
+		}
+		page := jsonArray(t, resp, "listings")
+		if want := min(20, n-offset); len(page) != want {
+			t.Errorf("offset %d: want %d listings, got %d", offset, want, len(page))
+		}
+		for _, item := range page {
+			id, _ := item.(map[string]any)["id"].(string)
+			if !created[id] || seen[id] {
+				t.Errorf("offset %d: unexpected or repeated listing %s", offset, id)
+			}
+			seen[id] = true
+		}
+	}
+	if len(seen) != n {
+		t.Errorf("paging saw %d of %d listings", len(seen), n)
+	}
+
+	_, resp := get(t, base, nil)
+	if got := len(jsonArray(t, resp, "listings")); got != 50 {
+		t.Errorf("no limit: want the default 50, got %d", got)
+	}
+}
+
+// ===========================================================================
+// Scenario 74: Search Tombstones
+//
+// A deleted listing stays out of the search projection when an update from
+// before the deletion is delivered late; a newer document may index it again.
+// ===========================================================================
+
+func TestSearchTombstone(t *testing.T) {
+	searcher := testUser{UserID: "e2e-tombstone-guest", TenantID: "e2e-tenant-tombstone", Email: "tombstone@e2e.test"}
+	id := fmt.Sprintf("e2e-tombstone-%d", time.Now().UnixNano())
+	city := "Tombstone " + id
+	now := time.Now().Unix()
+	doc := func(updatedAt int64) map[string]any {
+		return map[string]any{
+			"id": id, "tenantId": searcher.TenantID, "title": "Tombstoned Flat", "city": city,
+			"pricePerNight": "50.00", "currency": "USD", "maxGuests": 2, "status": "active",
+			"createdAt": now - 100, "updatedAt": updatedAt,
+		}
+	}
+	searchCity := func() int {
+		_, resp := get(t, searchURL()+"/search?city="+url.QueryEscape(city), authHeaders(searcher))
+		return len(jsonArray(t, resp, "listings"))
+	}
+
+	if status, resp := post(t, searchURL()+"/internal/search/index", doc(now-10), internalHeaders()); status != http.StatusNoContent {
+		t.Fatalf("index: want 204, got %d: %s", status, resp)
+	}
+	if n := searchCity(); n != 1 {
+		t.Fatalf("after index: want 1 result, got %d", n)
+	}
+
+	indexURL := searchURL() + "/internal/search/index/" + id + "?deletedAt=" + strconv.FormatInt(now, 10)
+	if status, resp := del(t, indexURL, internalHeaders()); status != http.StatusNoContent {
+		t.Fatalf("remove: want 204, got %d: %s", status, resp)
+	}
+
+	// An update made before the deletion, delivered after it.
+	post(t, searchURL()+"/internal/search/index", doc(now-5), internalHeaders())
+	if n := searchCity(); n != 0 {
+		t.Errorf("stale update: want the listing to stay deleted, got %d results", n)
+	}
+
+	post(t, searchURL()+"/internal/search/index", doc(now+5), internalHeaders())
+	if n := searchCity(); n != 1 {
+		t.Errorf("newer document: want 1 result, got %d", n)
+	}
+	del(t, searchURL()+"/internal/search/index/"+id, internalHeaders())
+}
+
+// ===========================================================================
+// Scenario 75: Rejected Booking Requests Don't Use Up the Message Allowance
+//
+// A guest whose requests are refused (here: too many guests) has not reached
+// the host, so those messages don't count toward BOOKING_MESSAGES_PER_HOUR
+// (10 by default) and a valid request with a message still goes through.
+// ===========================================================================
+
+func TestRejectedRequestsKeepMessageAllowance(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Patient Guest Flat",
+		"city":          "Navoi",
+		"pricePerNight": "65000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+		"instantBook":   true,
+		"payOnArrival":  true,
+	}, authHeaders(hostUser))
+	listingID := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+listingID, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/photos", map[string]any{
+		"url": "https://example.com/patient.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
+
+	for i := 0; i < 10; i++ {
+		status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+			"listingId": listingID, "checkIn": "2035-05-10", "checkOut": "2035-05-12", "guests": 5,
+			"message": "Can we squeeze in?",
+		}, authHeaders(defaultUser))
+		if status != http.StatusUnprocessableEntity {
+			t.Fatalf("over capacity #%d: want 422, got %d: %s", i+1, status, resp)
+		}
+	}
+
+	status, resp := post(t, bookingsURL()+"/bookings", map[string]any{
+		"listingId": listingID, "checkIn": "2035-05-10", "checkOut": "2035-05-12", "guests": 2,
+		"message": "Just the two of us then",
+	}, authHeaders(defaultUser))
+	if status != http.StatusCreated {
+		t.Fatalf("valid request after rejections: want 201, got %d: %s", status, resp)
+	}
+	defer post(t, bookingsURL()+"/bookings/"+jsonField(t, resp, "id")+"/cancel", nil, authHeaders(defaultUser))
+}
@@ -246,7 +246,6 @@ func TestCrossProductFullGuestJourney(t *testing.T) {
 	review := map[string]any{
 		"bookingId": bookingID,
 		"listingId": listingID,
-		"hostId":    hostUser.UserID,
 		"rating":    5,
 		"comment":   "Incredible guesthouse! The courtyard was magical at sunset.",
 	}
@@ -375,7 +374,7 @@ func TestCrossProductSearchBookCancelRebook(t *testing.T) {
 	post(t, listingsURL()+"/listings/"+listingID+"/publish", nil, authHeaders(hostUser))
 
 	// Search via search service
-	status, _ := get(t, searchURL()+"/search?city=Khiva", nil)
+	status, _ := get(t, searchURL()+"/search?city=Khiva", authHeaders(defaultUser))
 	if status != http.StatusOK {
 		t.Logf("search service returned %d (may need time to index)", status)
 	}
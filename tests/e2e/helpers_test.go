@@ -102,6 +102,25 @@ func del(t *testing.T, url string, headers map[string]string) (int, []byte) {
 	return doRequest(t, http.MethodDelete, url, nil, headers)
 }
 
+// createListing creates a listing owned by host and deletes it when the test
+// ends. Reviews need a real listing: the reviews service takes the host from it.
+func createListing(t *testing.T, host testUser, title string) string {
+	t.Helper()
+	status, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         title,
+		"city":          "Tashkent",
+		"pricePerNight": "100000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+	}, authHeaders(host))
+	if status != http.StatusCreated {
+		t.Fatalf("create listing %q: want 201, got %d: %s", title, status, resp)
+	}
+	id := jsonField(t, resp, "id")
+	t.Cleanup(func() { del(t, listingsURL()+"/listings/"+id, authHeaders(host)) })
+	return id
+}
+
 // ---------------------------------------------------------------------------
 // Auth simulation helpers
 //
@@ -214,3 +233,13 @@ func jsonArray(t *testing.T, data []byte, field string) []any {
 	}
 	return arr
 }
+
+// parseAmount parses a decimal money string from a response.
+func parseAmount(t *testing.T, s string) float64 {
+	t.Helper()
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		t.Fatalf("parse amount %q: %v", s, err)
+	}
+	return f
+}
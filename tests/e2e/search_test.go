@@ -0,0 +1,835 @@
+// Package e2e — search service scenarios.
+//
+// These tests exercise the search service (:8006) against listings created
+// through the listings service. They require the full docker compose stack.
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ===========================================================================
+// Scenario 1: Facet Counts Match Search Results
+//
+// Listings tagged with a unique amenity → facets filtered by that amenity
+// report per-city counts consistent with /search.
+// ===========================================================================
+
+func TestSearchFacets(t *testing.T) {
+	const marker = "e2e_facet_marker"
+	fixtures := []struct {
+		city, price string
+	}{
+		{"Tashkent", "100000.00"},
+		{"Tashkent", "200000.00"},
+		{"Samarkand", "300000.00"},
+	}
+	for _, f := range fixtures {
+		_, resp := post(t, listingsURL()+"/listings", map[string]any{
+			"title":         "Facet " + f.city,
+			"city":          f.city,
+			"country":       "UZ",
+			"pricePerNight": f.price,
+			"currency":      "UZS",
+			"maxGuests":     2,
+			"amenities":     []string{"wifi", marker},
+		}, authHeaders(hostUser))
+		id := jsonField(t, resp, "id")
+		post(t, listingsURL()+"/listings/"+id+"/photos", map[string]any{
+			"url": "https://example.com/facet-" + id + ".jpg", "caption": "cover",
+		}, authHeaders(hostUser))
+		post(t, listingsURL()+"/listings/"+id+"/publish", nil, authHeaders(hostUser))
+		defer del(t, listingsURL()+"/listings/"+id, authHeaders(hostUser))
+	}
+
+	status, resp := get(t, searchURL()+"/search/facets?amenities="+marker, authHeaders(defaultUser))
+	if status != http.StatusOK {
+		t.Fatalf("facets: want 200, got %d: %s", status, resp)
+	}
+	var facets struct {
+		Total  int `json:"total"`
+		Cities []struct {
+			Value string `json:"value"`
+			Count int    `json:"count"`
+		} `json:"cities"`
+		PriceBuckets []struct {
+			Count int `json:"count"`
+		} `json:"priceBuckets"`
+		Amenities []struct {
+			Value string `json:"value"`
+			Count int    `json:"count"`
+		} `json:"amenities"`
+	}
+	if err := json.Unmarshal(resp, &facets); err != nil {
+		t.Fatalf("decode facets: %v", err)
+	}
+	if facets.Total != len(fixtures) {
+		t.Errorf("total: want %d, got %d", len(fixtures), facets.Total)
+	}
+
+	cities := map[string]int{}
+	for _, c := range facets.Cities {
+		cities[c.Value] = c.Count
+	}
+	if cities["Tashkent"] != 2 || cities["Samarkand"] != 1 {
+		t.Errorf("city facet: want Tashkent=2 Samarkand=1, got %v", cities)
+	}
+
+	var bucketed int
+	for _, b := range facets.PriceBuckets {
+		bucketed += b.Count
+	}
+	if bucketed != facets.Total {
+		t.Errorf("price buckets sum to %d, want %d", bucketed, facets.Total)
+	}
+
+	// Same filters on /search must agree with the facet total.
+	_, resp = get(t, searchURL()+"/search?amenities="+marker, authHeaders(defaultUser))
+	if got := jsonField(t, resp, "total"); got != "3" {
+		t.Errorf("search total: want 3, got %s", got)
+	}
+}
+
+// ===========================================================================
+// Scenario 2: Distance Units
+//
+// The same geo query in km and mi returns proportional distances, and a
+// radius given in miles is interpreted as miles.
+// ===========================================================================
+
+func TestSearchDistanceUnits(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Units Test Flat",
+		"city":          "Tashkent",
+		"country":       "UZ",
+		"pricePerNight": "100000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+	}, authHeaders(hostUser))
+	id := jsonField(t, resp, "id")
+	post(t, listingsURL()+"/listings/"+id+"/photos", map[string]any{
+		"url": "https://example.com/units.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+id+"/publish", nil, authHeaders(hostUser))
+	defer del(t, listingsURL()+"/listings/"+id, authHeaders(hostUser))
+
+	// Chorsu bazaar; the query point below is Amir Timur square (~4 km away).
+	status, _ := put(t, searchURL()+"/search/locations/"+id,
+		map[string]any{"lat": 41.3265, "lng": 69.2350}, internalHeaders())
+	if status != http.StatusNoContent {
+		t.Fatalf("update location: want 204, got %d", status)
+	}
+
+	distance := func(query string) float64 {
+		t.Helper()
+		status, resp := get(t, searchURL()+"/search?lat=41.3111&lng=69.2797&limit=100&"+query, authHeaders(defaultUser))
+		if status != http.StatusOK {
+			t.Fatalf("search %s: want 200, got %d: %s", query, status, resp)
+		}
+		var out struct {
+			Listings []struct {
+				ID       string   `json:"id"`
+				Distance *float64 `json:"distance"`
+			} `json:"listings"`
+		}
+		if err := json.Unmarshal(resp, &out); err != nil {
+			t.Fatalf("decode search: %v", err)
+		}
+		for _, l := range out.Listings {
+			if l.ID == id && l.Distance != nil {
+				return *l.Distance
+			}
+		}
+		t.Fatalf("listing %s not in results for %s", id, query)
+		return 0
+	}
+
+	km := distance("unit=km&radius=10")
+	mi := distance("unit=mi&radius=10")
+	if ratio := km / mi; math.Abs(ratio-1.609344) > 1e-6 {
+		t.Errorf("km/mi ratio: want 1.609344, got %v (km=%v mi=%v)", ratio, km, mi)
+	}
+
+	// A 2 km radius must not reach the listing.
+	_, resp = get(t, searchURL()+"/search?lat=41.3111&lng=69.2797&unit=km&radius=2&limit=100", authHeaders(defaultUser))
+	for _, l := range jsonArray(t, resp, "listings") {
+		if l.(map[string]any)["id"] == id {
+			t.Error("listing ~4km away should be outside a 2km radius")
+		}
+	}
+
+	status, _ = get(t, searchURL()+"/search?unit=furlong", authHeaders(defaultUser))
+	if status != http.StatusBadRequest {
+		t.Errorf("invalid unit: want 400, got %d", status)
+	}
+}
+
+// ===========================================================================
+// Scenario 3: Search Projection Freshness
+//
+// Publish → listing appears in /search; unpublish → it disappears; an admin
+// reindex backfills the tenant's listings into the projection.
+// ===========================================================================
+
+func TestSearchProjectionLifecycle(t *testing.T) {
+	const city = "E2EProjectionCity"
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Projection Test",
+		"city":          city,
+		"country":       "UZ",
+		"pricePerNight": "90000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+	}, authHeaders(hostUser))
+	id := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+id, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+id+"/photos", map[string]any{
+		"url": "https://example.com/projection.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+
+	total := func() string {
+		t.Helper()
+		_, resp := get(t, searchURL()+"/search?city="+city, authHeaders(defaultUser))
+		return jsonField(t, resp, "total")
+	}
+
+	if got := total(); got != "0" {
+		t.Errorf("draft listing: want 0 results, got %s", got)
+	}
+
+	post(t, listingsURL()+"/listings/"+id+"/publish", nil, authHeaders(hostUser))
+	if got := total(); got != "1" {
+		t.Errorf("after publish: want 1 result, got %s", got)
+	}
+
+	// The projection carries the cover photo.
+	_, resp = get(t, searchURL()+"/search?city="+city, authHeaders(defaultUser))
+	listings := jsonArray(t, resp, "listings")
+	if len(listings) == 1 {
+		if cover := listings[0].(map[string]any)["coverPhoto"]; cover != "https://example.com/projection.jpg" {
+			t.Errorf("coverPhoto: want projection.jpg, got %v", cover)
+		}
+	}
+
+	post(t, listingsURL()+"/listings/"+id+"/unpublish", nil, authHeaders(hostUser))
+	if got := total(); got != "0" {
+		t.Errorf("after unpublish: want 0 results, got %s", got)
+	}
+
+	// Reindex requires zist.admin.
+	status, _ := post(t, listingsURL()+"/listings/reindex", nil, authHeaders(hostUser))
+	if status != http.StatusForbidden {
+		t.Errorf("reindex as host: want 403, got %d", status)
+	}
+	status, resp = post(t, listingsURL()+"/listings/reindex", nil, authHeaders(adminUser))
+	if status != http.StatusOK {
+		t.Fatalf("reindex: want 200, got %d: %s", status, resp)
+	}
+	if jsonField(t, resp, "indexed") == "0" {
+		t.Error("reindex: want at least one listing indexed")
+	}
+}
+
+// TestSearchTenantIsolation checks that search is scoped to the caller's
+// tenant: a listing published in tenant 2 is invisible to tenant 1.
+func TestSearchTenantIsolation(t *testing.T) {
+	const city = "E2ETenantIsolationCity"
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Tenant Two Only",
+		"city":          city,
+		"country":       "UZ",
+		"pricePerNight": "80000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+	}, authHeaders(tenant2Host))
+	id := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+id, authHeaders(tenant2Host))
+	post(t, listingsURL()+"/listings/"+id+"/photos", map[string]any{
+		"url": "https://example.com/tenant2.jpg", "caption": "cover",
+	}, authHeaders(tenant2Host))
+	post(t, listingsURL()+"/listings/"+id+"/publish", nil, authHeaders(tenant2Host))
+
+	_, resp = get(t, searchURL()+"/search?city="+city, authHeaders(tenant2Guest))
+	if got := jsonField(t, resp, "total"); got != "1" {
+		t.Errorf("tenant 2 search: want 1 result, got %s", got)
+	}
+
+	_, resp = get(t, searchURL()+"/search?city="+city, authHeaders(defaultUser))
+	if got := jsonField(t, resp, "total"); got != "0" {
+		t.Errorf("tenant 1 search leaked tenant 2 listing: got %s results", got)
+	}
+	_, resp = get(t, searchURL()+"/search/facets?city="+city, authHeaders(defaultUser))
+	if got := jsonField(t, resp, "total"); got != "0" {
+		t.Errorf("tenant 1 facets leaked tenant 2 listing: got %s", got)
+	}
+
+	// Without a tenant the search can't be scoped (unless marketplace mode is on).
+	status, _ := get(t, searchURL()+"/search?city="+city, nil)
+	if status != http.StatusBadRequest && status != http.StatusOK {
+		t.Errorf("anonymous search: want 400 (or 200 in marketplace mode), got %d", status)
+	}
+}
+
+// TestSearchRadiusClamped checks that an oversized geo radius is clamped to
+// the configured maximum and that a missing radius gets the default; the
+// applied radius is echoed in the response.
+func TestSearchRadiusClamped(t *testing.T) {
+	radius := func(query string) (km, inUnit float64) {
+		t.Helper()
+		status, resp := get(t, searchURL()+"/search?lat=41.3111&lng=69.2797&"+query, authHeaders(defaultUser))
+		if status != http.StatusOK {
+			t.Fatalf("search %s: want 200, got %d: %s", query, status, resp)
+		}
+		var out struct {
+			RadiusKM *float64 `json:"radiusKm"`
+			Radius   *float64 `json:"radius"`
+		}
+		if err := json.Unmarshal(resp, &out); err != nil {
+			t.Fatalf("decode search: %v", err)
+		}
+		if out.RadiusKM == nil || out.Radius == nil {
+			t.Fatalf("search %s: effective radius missing: %s", query, resp)
+		}
+		return *out.RadiusKM, *out.Radius
+	}
+
+	if km, _ := radius("radius_km=20000"); km != 100 {
+		t.Errorf("oversized radius: want clamped to 100km, got %v", km)
+	}
+	if km, mi := radius("unit=mi&radius=5000"); km != 100 || math.Abs(mi-100/1.609344) > 1e-6 {
+		t.Errorf("oversized mi radius: want 100km (%.4fmi), got %vkm / %vmi", 100/1.609344, km, mi)
+	}
+	if km, _ := radius(""); km != 25 {
+		t.Errorf("missing radius: want default 25km, got %v", km)
+	}
+	if km, _ := radius("radius_km=10"); km != 10 {
+		t.Errorf("in-range radius: want 10km, got %v", km)
+	}
+
+	_, resp := get(t, searchURL()+"/search?city=Tashkent", authHeaders(defaultUser))
+	if strings.Contains(string(resp), `"radiusKm"`) {
+		t.Errorf("non-geo search: want no radiusKm, got %s", resp)
+	}
+}
+
+// TestSearchFuzzyCity checks that a misspelt city finds the listing only
+// when fuzzy matching is requested; exact matching stays the default.
+func TestSearchFuzzyCity(t *testing.T) {
+	_, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Fuzzy Match Cottage",
+		"city":          "Fuzzytown",
+		"country":       "UZ",
+		"pricePerNight": "150000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+	}, authHeaders(hostUser))
+	id := jsonField(t, resp, "id")
+	post(t, listingsURL()+"/listings/"+id+"/photos", map[string]any{
+		"url": "https://example.com/fuzzy.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+id+"/publish", nil, authHeaders(hostUser))
+	defer del(t, listingsURL()+"/listings/"+id, authHeaders(hostUser))
+
+	found := func(query string) bool {
+		t.Helper()
+		status, resp := get(t, searchURL()+"/search?limit=100&"+query, authHeaders(defaultUser))
+		if status != http.StatusOK {
+			t.Fatalf("search %s: want 200, got %d: %s", query, status, resp)
+		}
+		for _, l := range jsonArray(t, resp, "listings") {
+			if l.(map[string]any)["id"] == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	if found("city=fuzzytwon") {
+		t.Error("exact mode: misspelt city should not match")
+	}
+	if !found("city=fuzzytwon&fuzzy=true") {
+		t.Error("fuzzy mode: misspelt city should match Fuzzytown")
+	}
+	if !found("city=Fuzzytown%20City&fuzzy=true") {
+		t.Error("fuzzy mode: city with extra words should match Fuzzytown")
+	}
+	if !found("city=FUZZYTOWN") {
+		t.Error("exact mode: case-insensitive match should still work")
+	}
+}
+
+// TestSearchListingEvents checks the events-plane subscription: a
+// zist.listing.published delivery indexes the document, a redelivered or
+// older update does not roll it back, and zist.listing.deleted removes it.
+func TestSearchListingEvents(t *testing.T) {
+	const city = "E2EListingEventsCity"
+	id := fmt.Sprintf("00000000-0000-4000-8000-%012d", time.Now().UnixNano()%1e12)
+	doc := func(title string, updatedAt int64) map[string]any {
+		return map[string]any{
+			"id": id, "tenantId": defaultUser.TenantID, "hostId": hostUser.UserID,
+			"title": title, "city": city, "country": "UZ", "type": "apartment",
+			"pricePerNight": "80000.00", "currency": "UZS", "maxGuests": 2,
+			"status": "active", "createdAt": updatedAt, "updatedAt": updatedAt,
+		}
+	}
+	deliver := func(eventType string, payload map[string]any) {
+		t.Helper()
+		status, resp := post(t, searchURL()+"/internal/search/index", map[string]any{
+			"event_id":   fmt.Sprintf("%s-%d", eventType, time.Now().UnixNano()),
+			"event_type": eventType,
+			"tenant_id":  defaultUser.TenantID,
+			"payload":    payload,
+		}, internalHeaders())
+		if status != http.StatusNoContent {
+			t.Fatalf("%s: want 204, got %d: %s", eventType, status, resp)
+		}
+	}
+	titles := func() []string {
+		t.Helper()
+		_, resp := get(t, searchURL()+"/search?city="+city, authHeaders(defaultUser))
+		var out []string
+		for _, l := range jsonArray(t, resp, "listings") {
+			out = append(out, l.(map[string]any)["title"].(string))
+		}
+		return out
+	}
+
+	deliver("zist.listing.published", doc("Evented Flat", 2000))
+	deliver("zist.listing.published", doc("Evented Flat", 2000))
+	if got := titles(); len(got) != 1 || got[0] != "Evented Flat" {
+		t.Fatalf("after publish: want [Evented Flat], got %v", got)
+	}
+
+	deliver("zist.listing.updated", doc("Stale Title", 1000))
+	if got := titles(); len(got) != 1 || got[0] != "Evented Flat" {
+		t.Errorf("stale update: want [Evented Flat], got %v", got)
+	}
+
+	deliver("zist.listing.reviewed", doc("Ignored", 3000))
+	deliver("zist.listing.deleted", map[string]any{"id": id, "tenantId": defaultUser.TenantID})
+	if got := titles(); len(got) != 0 {
+		t.Errorf("after delete: want no results, got %v", got)
+	}
+}
+
+// TestSearchListingCoordinates checks that coordinates given on listing
+// create reach the projection: once published the listing is found by a
+// radius search around them, with no separate location call.
+func TestSearchListingCoordinates(t *testing.T) {
+	status, resp := post(t, listingsURL()+"/listings", map[string]any{
+		"title": "Half a Point", "city": "Fergana", "pricePerNight": "50000.00", "lat": 40.38,
+	}, authHeaders(hostUser))
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("lat without lng: want 422, got %d: %s", status, resp)
+	}
+
+	_, resp = post(t, listingsURL()+"/listings", map[string]any{
+		"title":         "Coordinates Test Flat",
+		"city":          "Fergana",
+		"country":       "UZ",
+		"pricePerNight": "85000.00",
+		"currency":      "UZS",
+		"maxGuests":     2,
+		"lat":           40.3864,
+		"lng":           71.7864,
+	}, authHeaders(hostUser))
+	id := jsonField(t, resp, "id")
+	defer del(t, listingsURL()+"/listings/"+id, authHeaders(hostUser))
+	if got := jsonField(t, resp, "lat"); got != "40.3864" {
+		t.Errorf("created lat: want 40.3864, got %s", got)
+	}
+	post(t, listingsURL()+"/listings/"+id+"/photos", map[string]any{
+		"url": "https://example.com/coords.jpg", "caption": "cover",
+	}, authHeaders(hostUser))
+	post(t, listingsURL()+"/listings/"+id+"/publish", nil, authHeaders(hostUser))
+
+	found := func(query string) bool {
+		t.Helper()
+		status, resp := get(t, searchURL()+"/search?limit=100&"+query, authHeaders(defaultUser))
+		if status != http.StatusOK {
+			t.Fatalf("search %s: want 200, got %d: %s", query, status, resp)
+		}
+		for _, l := range jsonArray(t, resp, "listings") {
+			if l.(map[string]any)["id"] == id {
+				return true
+			}
+		}
+		return false
+	}
+	if !found("lat=40.39&lng=71.79&radius=2") {
+		t.Error("radius search around the listing's coordinates should find it")
+	}
+	if found("lat=41.3111&lng=69.2797&radius=10") {
+		t.Error("radius search around Tashkent should not find a Fergana listing")
+	}
+}
+
+// TestSearchNewListingBoost checks the default ranking: a brand-new listing
+// with no reviews outranks a year-old 2-star one, while sort_by=rating
+// still orders by rating alone.
+func TestSearchNewListingBoost(t *testing.T) {
+	const city = "E2EFreshnessCity"
+	now := time.Now().Unix()
+	index := func(id, title string, rating float64, reviews int, createdAt int64) {
+		t.Helper()
+		status, resp := post(t, searchURL()+"/internal/search/index", map[string]any{
+			"id": id, "tenantId": defaultUser.TenantID, "hostId": hostUser.UserID,
+			"title": title, "city": city, "country": "UZ", "type": "apartment",
+			"pricePerNight": "70000.00", "currency": "UZS", "maxGuests": 2,
+			"averageRating": rating, "reviewCount": reviews,
+			"status": "active", "createdAt": createdAt, "updatedAt": now,
+		}, internalHeaders())
+		if status != http.StatusNoContent {
+			t.Fatalf("index %s: want 204, got %d: %s", title, status, resp)
+		}
+	}
+	suffix := now % 1e12
+	oldID := fmt.Sprintf("00000000-0000-4000-8001-%012d", suffix)
+	newID := fmt.Sprintf("00000000-0000-4000-8002-%012d", suffix)
+	index(oldID, "Old Low Rated", 2.0, 4, now-365*24*3600)
+	index(newID, "Brand New", 0, 0, now)
+	defer del(t, searchURL()+"/internal/search/index/"+oldID, internalHeaders())
+	defer del(t, searchURL()+"/internal/search/index/"+newID, internalHeaders())
+
+	first := func(query string) string {
+		t.Helper()
+		_, resp := get(t, searchURL()+"/search?city="+city+query, authHeaders(defaultUser))
+		listings := jsonArray(t, resp, "listings")
+		if len(listings) != 2 {
+			t.Fatalf("search%s: want 2 listings, got %s", query, resp)
+		}
+		return listings[0].(map[string]any)["title"].(string)
+	}
+	if got := first(""); got != "Brand New" {
+		t.Errorf("default ranking: want Brand New first, got %s", got)
+	}
+	if got := first("&sort_by=rating"); got != "Old Low Rated" {
+		t.Errorf("sort_by=rating: want Old Low Rated first, got %s", got)
+	}
+}
+
+// TestSearchFieldProjection checks fields=: each listing carries only the
+// requested keys (plus id), aliases resolve, and unknown names are rejected.
+func TestSearchFieldProjection(t *testing.T) {
+	const city = "E2EProjectionCity"
+	now := time.Now().Unix()
+	id := fmt.Sprintf("00000000-0000-4000-8003-%012d", now%1e12)
+	status, resp := post(t, searchURL()+"/internal/search/index", map[string]any{
+		"id": id, "tenantId": defaultUser.TenantID, "hostId": hostUser.UserID,
+		"title": "Projected Flat", "city": city, "country": "UZ", "type": "apartment",
+		"pricePerNight": "90000.00", "currency": "UZS", "maxGuests": 2,
+		"coverPhoto": "https://example.com/projected.jpg", "amenities": []string{"wifi"},
+		"status": "active", "createdAt": now, "updatedAt": now,
+	}, internalHeaders())
+	if status != http.StatusNoContent {
+		t.Fatalf("index: want 204, got %d: %s", status, resp)
+	}
+	defer del(t, searchURL()+"/internal/search/index/"+id, internalHeaders())
+
+	status, resp = get(t, searchURL()+"/search?city="+city+"&fields=title,price,cover", authHeaders(defaultUser))
+	if status != http.StatusOK {
+		t.Fatalf("projected search: want 200, got %d: %s", status, resp)
+	}
+	if jsonField(t, resp, "total") != "1" {
+		t.Errorf("total: want 1, got %s", jsonField(t, resp, "total"))
+	}
+	listings := jsonArray(t, resp, "listings")
+	if len(listings) != 1 {
+		t.Fatalf("want 1 listing, got %s", resp)
+	}
+	got := listings[0].(map[string]any)
+	want := map[string]any{
+		"id":            id,
+		"title":         "Projected Flat",
+		"pricePerNight": "90000.00",
+		"coverPhoto":    "https://example.com/projected.jpg",
+	}
+	if len(got) != len(want) {
+		t.Errorf("projected keys: want %v, got %v", want, got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: want %v, got %v", k, v, got[k])
+		}
+	}
+
+	if status, _ := get(t, searchURL()+"/search?city="+city+"&fields=title,hostEmail", authHeaders(defaultUser)); status != http.StatusBadRequest {
+		t.Errorf("unknown field: want 400, got %d", status)
+	}
+}
+
+// TestSearchAvailableNow checks availableNow=true: only instant-bookable
+// listings free tonight (in their own timezone) are returned.
+func TestSearchAvailableNow(t *testing.T) {
+	city := fmt.Sprintf("E2ETonightCity%d", time.Now().UnixNano()%1e9)
+	const tz = "Asia/Tashkent"
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		t.Skipf("no zoneinfo for %s: %v", tz, err)
+	}
+	tonight := time.Now().In(loc).Format("2006-01-02")
+
+	create := func(title string, instant bool) string {
+		status, resp := post(t, listingsURL()+"/listings", map[string]any{
+			"title": title, "city": city, "pricePerNight": "120000.00", "currency": "UZS",
+			"maxGuests": 2, "instantBook": instant, "timezone": tz,
+		}, authHeaders(hostUser))
+		if status != http.StatusCreated {
+			t.Fatalf("create %s: want 201, got %d: %s", title, status, resp)
+		}
+		id := jsonField(t, resp, "id")
+		t.Cleanup(func() { del(t, listingsURL()+"/listings/"+id, authHeaders(hostUser)) })
+		post(t, listingsURL()+"/listings/"+id+"/photos", map[string]any{
+			"url": "https://example.com/tonight.jpg", "caption": "cover",
+		}, authHeaders(hostUser))
+		post(t, listingsURL()+"/listings/"+id+"/publish", nil, authHeaders(hostUser))
+		return id
+	}
+	free := create("Free Tonight", true)
+	blocked := create("Blocked Tonight", true)
+	create("Request Only", false)
+
+	status, _ := post(t, listingsURL()+"/listings/"+blocked+"/availability/block", map[string]any{
+		"dates": []string{tonight},
+	}, authHeaders(hostUser))
+	if status != http.StatusOK {
+		t.Fatalf("block tonight: want 200, got %d", status)
+	}
+
+	status, resp := get(t, searchURL()+"/search?city="+city+"&availableNow=true", authHeaders(defaultUser))
+	if status != http.StatusOK {
+		t.Fatalf("availableNow search: want 200, got %d: %s", status, resp)
+	}
+	listings := jsonArray(t, resp, "listings")
+	if len(listings) != 1 || listings[0].(map[string]any)["id"] != free {
+		t.Errorf("availableNow: want only %s, got %s", free, resp)
+	}
+
+	status, _ = get(t, searchURL()+"/search?city="+city+"&availableNow=true&check_in="+tonight, authHeaders(defaultUser))
+	if status != http.StatusBadRequest {
+		t.Errorf("availableNow with check_in: want 400, got %d", status)
+	}
+}
+
+// TestSearchCollapseByHost checks collapseByHost=true: a host's several
+// matches collapse to the cheapest one with a moreFromHost count, while
+// other hosts' listings are untouched.
+func TestSearchCollapseByHost(t *testing.T) {
+	city := fmt.Sprintf("E2ECollapseCity%d", time.Now().UnixNano()%1e9)
+	now := time.Now().Unix()
+	index := func(n int, hostID, price string) string {
+		id := fmt.Sprintf("00000000-0000-4000-8004-%012d", (now*10+int64(n))%1e12)
+		status, resp := post(t, searchURL()+"/internal/search/index", map[string]any{
+			"id": id, "tenantId": defaultUser.TenantID, "hostId": hostID,
+			"title": fmt.Sprintf("Room %d", n), "city": city, "country": "UZ", "type": "room",
+			"pricePerNight": price, "currency": "UZS", "maxGuests": 2,
+			"status": "active", "createdAt": now, "updatedAt": now,
+		}, internalHeaders())
+		if status != http.StatusNoContent {
+			t.Fatalf("index %d: want 204, got %d: %s", n, status, resp)
+		}
+		t.Cleanup(func() { del(t, searchURL()+"/internal/search/index/"+id, internalHeaders()) })
+		return id
+	}
+	index(1, hostUser.UserID, "300000.00")
+	cheapest := index(2, hostUser.UserID, "100000.00")
+	index(3, hostUser.UserID, "200000.00")
+	other := index(4, "e2e-other-host", "500000.00")
+
+	status, resp := get(t, searchURL()+"/search?city="+city, authHeaders(defaultUser))
+	if status != http.StatusOK || jsonField(t, resp, "total") != "4" {
+		t.Fatalf("uncollapsed: want 4 listings, got %d: %s", status, resp)
+	}
+
+	status, resp = get(t, searchURL()+"/search?city="+city+"&collapseByHost=true&sort_by=price", authHeaders(defaultUser))
+	if status != http.StatusOK {
+		t.Fatalf("collapsed search: want 200, got %d: %s", status, resp)
+	}
+	if jsonField(t, resp, "total") != "2" {
+		t.Errorf("collapsed total: want 2 hosts, got %s", jsonField(t, resp, "total"))
+	}
+	listings := jsonArray(t, resp, "listings")
+	if len(listings) != 2 {
+		t.Fatalf("collapsed: want 2 listings, got %s", resp)
+	}
+	first, second := listings[0].(map[string]any), listings[1].(map[string]any)
+	if first["id"] != cheapest || first["moreFromHost"] != float64(2) {
+		t.Errorf("want cheapest %s with moreFromHost=2, got %v", cheapest, first)
+	}
+	if second["id"] != other || second["moreFromHost"] != nil {
+		t.Errorf("want %s without moreFromHost, got %v", other, second)
+	}
+}
+
+// TestSearchQueryLog checks that a search is logged once and surfaces in the
+// admin insights as a zero-result city.
+func TestSearchQueryLog(t *testing.T) {
+	city := fmt.Sprintf("E2EQueryLogCity%d", time.Now().UnixNano()%1e9)
+	status, resp := get(t, searchURL()+"/search?city="+city+"&guests=3", authHeaders(defaultUser))
+	if status != http.StatusOK || jsonField(t, resp, "total") != "0" {
+		t.Fatalf("search: want 200 with no results, got %d: %s", status, resp)
+	}
+
+	status, _ = get(t, searchURL()+"/search/insights", authHeaders(defaultUser))
+	if status != http.StatusForbidden {
+		t.Errorf("insights as guest: want 403, got %d", status)
+	}
+
+	// The log is written asynchronously.
+	want := strings.ToLower(city)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, resp = get(t, searchURL()+"/search/insights?limit=100", authHeaders(adminUser))
+		if status != http.StatusOK {
+			t.Fatalf("insights: want 200, got %d: %s", status, resp)
+		}
+		var found map[string]any
+		for _, c := range jsonArray(t, resp, "topCities") {
+			if m := c.(map[string]any); m["city"] == want {
+				found = m
+			}
+		}
+		if found != nil {
+			if found["searches"] != float64(1) || found["zeroResults"] != float64(1) {
+				t.Errorf("want one zero-result search for %s, got %v", want, found)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("search for %s not logged: %s", want, resp)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	var zero bool
+	for _, z := range jsonArray(t, resp, "zeroResultQueries") {
+		if m := z.(map[string]any); m["city"] == want && m["guests"] == float64(3) {
+			zero = true
+		}
+	}
+	if !zero {
+		t.Errorf("want %s among zero-result queries, got %s", want, resp)
+	}
+}
+
+// TestSearchZeroResultSuggestions checks that an over-constrained search stays
+// empty but suggests what a relaxed search finds.
+func TestSearchZeroResultSuggestions(t *testing.T) {
+	city := fmt.Sprintf("E2ESuggestCity%d", time.Now().UnixNano()%1e9)
+	now := time.Now().Unix()
+	id := fmt.Sprintf("00000000-0000-4000-8005-%012d", now%1e12)
+	status, resp := post(t, searchURL()+"/internal/search/index", map[string]any{
+		"id": id, "tenantId": defaultUser.TenantID, "hostId": hostUser.UserID,
+		"title": "Suggested flat", "city": city, "country": "UZ", "type": "apartment",
+		"pricePerNight": "300000.00", "currency": "UZS", "maxGuests": 4,
+		"amenities": []string{"wifi"},
+		"status":    "active", "createdAt": now, "updatedAt": now,
+	}, internalHeaders())
+	if status != http.StatusNoContent {
+		t.Fatalf("index: want 204, got %d: %s", status, resp)
+	}
+	t.Cleanup(func() { del(t, searchURL()+"/internal/search/index/"+id, internalHeaders()) })
+
+	status, resp = get(t, searchURL()+"/search?city="+city+"&max_price=100000&amenities=pool", authHeaders(defaultUser))
+	if status != http.StatusOK {
+		t.Fatalf("search: want 200, got %d: %s", status, resp)
+	}
+	if jsonField(t, resp, "total") != "0" || len(jsonArray(t, resp, "listings")) != 0 {
+		t.Errorf("primary results: want empty, got %s", resp)
+	}
+	var body struct {
+		Suggestions *struct {
+			Relaxed  []string         `json:"relaxed"`
+			Note     string           `json:"note"`
+			Total    int              `json:"total"`
+			Listings []map[string]any `json:"listings"`
+		} `json:"suggestions"`
+	}
+	if err := json.Unmarshal(resp, &body); err != nil || body.Suggestions == nil {
+		t.Fatalf("want suggestions, got %s", resp)
+	}
+	s := body.Suggestions
+	if strings.Join(s.Relaxed, ",") != "price,amenities" || s.Note == "" {
+		t.Errorf("relaxed: want price,amenities with a note, got %v %q", s.Relaxed, s.Note)
+	}
+	if s.Total != 1 || len(s.Listings) != 1 || s.Listings[0]["id"] != id {
+		t.Errorf("want the indexed listing suggested, got %s", resp)
+	}
+
+	status, resp = get(t, searchURL()+"/search?city="+city+"&max_price=100000&guests=9", authHeaders(defaultUser))
+	if status != http.StatusOK || strings.Contains(string(resp), `"suggestions"`) {
+		t.Errorf("guests are never relaxed: want no suggestions, got %d: %s", status, resp)
+	}
+}
+
+// TestSearchMinRating checks that minRating=4 keeps 4-star-and-up listings and
+// drops both a 3-star and an unrated one, in the search service and in
+// listings /search.
+func TestSearchMinRating(t *testing.T) {
+	city := fmt.Sprintf("E2ERatingCity%d", time.Now().UnixNano()%1e9)
+	now := time.Now().Unix()
+	ratings := []float64{4.5, 3, 0}
+	ids := make([]string, len(ratings))
+	for i, rating := range ratings {
+		ids[i] = fmt.Sprintf("00000000-0000-4000-8006-%012d", (now+int64(i))%1e12)
+		status, resp := post(t, searchURL()+"/internal/search/index", map[string]any{
+			"id": ids[i], "tenantId": defaultUser.TenantID, "hostId": hostUser.UserID,
+			"title": "Rated flat", "city": city, "country": "UZ", "type": "apartment",
+			"pricePerNight": "200000.00", "currency": "UZS", "maxGuests": 2,
+			"averageRating": rating, "reviewCount": 3,
+			"status": "active", "createdAt": now, "updatedAt": now,
+		}, internalHeaders())
+		if status != http.StatusNoContent {
+			t.Fatalf("index: want 204, got %d: %s", status, resp)
+		}
+		id := ids[i]
+		t.Cleanup(func() { del(t, searchURL()+"/internal/search/index/"+id, internalHeaders()) })
+	}
+
+	status, resp := get(t, searchURL()+"/search?city="+city+"&minRating=4", authHeaders(defaultUser))
+	if status != http.StatusOK {
+		t.Fatalf("search: want 200, got %d: %s", status, resp)
+	}
+	listings := jsonArray(t, resp, "listings")
+	if len(listings) != 1 || listings[0].(map[string]any)["id"] != ids[0] {
+		t.Errorf("search minRating=4: want only the 4.5-star listing, got %s", resp)
+	}
+	if status, resp := get(t, searchURL()+"/search?city="+city+"&minRating=6", authHeaders(defaultUser)); status != http.StatusBadRequest {
+		t.Errorf("minRating=6: want 400, got %d: %s", status, resp)
+	}
+
+	listingIDs := map[float64]string{}
+	for _, rating := range []float64{4.5, 3} {
+		_, resp := post(t, listingsURL()+"/listings", map[string]any{
+			"title": "Rated listing", "city": city, "pricePerNight": "200000.00",
+			"currency": "UZS", "maxGuests": 2,
+		}, authHeaders(hostUser))
+		id := jsonField(t, resp, "id")
+		t.Cleanup(func() { del(t, listingsURL()+"/listings/"+id, authHeaders(hostUser)) })
+		post(t, listingsURL()+"/listings/"+id+"/photos", map[string]any{
+			"url": "https://example.com/rated.jpg", "caption": "cover",
+		}, authHeaders(hostUser))
+		post(t, listingsURL()+"/listings/"+id+"/publish", nil, authHeaders(hostUser))
+		if status, resp := put(t, listingsURL()+"/listings/"+id+"/rating", map[string]any{
+			"averageRating": rating, "reviewCount": 3,
+		}, internalHeaders()); status != http.StatusOK {
+			t.Fatalf("set rating: want 200, got %d: %s", status, resp)
+		}
+		listingIDs[rating] = id
+	}
+
+	status, resp = get(t, listingsURL()+"/listings/search?city="+city+"&minRating=4", nil)
+	if status != http.StatusOK {
+		t.Fatalf("listings search: want 200, got %d: %s", status, resp)
+	}
+	listings = jsonArray(t, resp, "listings")
+	if len(listings) != 1 || listings[0].(map[string]any)["id"] != listingIDs[4.5] {
+		t.Errorf("listings search minRating=4: want only the 4.5-star listing, got %s", resp)
+	}
+}